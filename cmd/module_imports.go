@@ -5,7 +5,9 @@ package cmd
 import (
 	_ "github.com/praetorian-inc/nebula/pkg/modules/aws/analyze"
 	_ "github.com/praetorian-inc/nebula/pkg/modules/aws/recon"
+	_ "github.com/praetorian-inc/nebula/pkg/modules/azure/analyze"
 	_ "github.com/praetorian-inc/nebula/pkg/modules/azure/recon"
 	_ "github.com/praetorian-inc/nebula/pkg/modules/gcp/recon"
+	_ "github.com/praetorian-inc/nebula/pkg/modules/multi/analyze"
 	_ "github.com/praetorian-inc/nebula/pkg/modules/saas/recon"
 )
@@ -9,12 +9,24 @@ import (
 var versionCmd = &cobra.Command{
 	Use:   "version",
 	Short: "Print the version number of Nebula",
-	Long:  `All software has versions. This is Nebula's`,
+	Long:  `Print the Nebula version, commit, build time, and Go version of this binary`,
 	Run: func(cmd *cobra.Command, args []string) {
+		info := version.Info()
 		message.Info(version.FullVersion())
+		message.Info("Version:    %s", info.Version)
+		message.Info("Commit:     %s", info.Commit)
+		message.Info("Built:      %s", info.BuildTime)
+		message.Info("Go version: %s", info.GoVersion)
+		if info.Modified {
+			message.Warning("Built from a working tree with uncommitted changes")
+		}
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(versionCmd)
+
+	// --version prints the same build string as the version command
+	rootCmd.Version = version.AbbreviatedVersion()
+	rootCmd.SetVersionTemplate(version.FullVersion() + "\n")
 }
@@ -0,0 +1,90 @@
+package helpers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"time"
+)
+
+// imdsTokenURL is the Azure Instance Metadata Service token endpoint, served
+// on every Azure VM. App Service and Functions expose their own endpoint in
+// IDENTITY_ENDPOINT instead.
+const imdsTokenURL = "http://169.254.169.254/metadata/identity/oauth2/token"
+
+// managedIdentityResponse is a token from IMDS or the App Service identity
+// endpoint, which return expires_in as a string
+type managedIdentityResponse struct {
+	AccessToken string      `json:"access_token"`
+	TokenType   string      `json:"token_type"`
+	ExpiresIn   json.Number `json:"expires_in"`
+	Resource    string      `json:"resource"`
+}
+
+// GetManagedIdentityToken gets an access token for resource from the managed
+// identity of the Azure host Nebula runs on. clientID selects a user-assigned
+// identity; when empty the system-assigned identity is used. The request goes
+// to a link-local endpoint, so it never uses a proxy.
+func GetManagedIdentityToken(resource, clientID string) (*TokenResponse, error) {
+	query := url.Values{"resource": {resource}}
+	if clientID != "" {
+		query.Set("client_id", clientID)
+	}
+
+	endpoint, header, headerValue := imdsTokenURL, "Metadata", "true"
+	query.Set("api-version", "2018-02-01")
+	if identityEndpoint := os.Getenv("IDENTITY_ENDPOINT"); identityEndpoint != "" {
+		endpoint, header, headerValue = identityEndpoint, "X-IDENTITY-HEADER", os.Getenv("IDENTITY_HEADER")
+		query.Set("api-version", "2019-08-01")
+	}
+
+	req, err := http.NewRequest("GET", endpoint+"?"+query.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %v", err)
+	}
+	req.Header.Set(header, headerValue)
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("managed identity token request failed (is Nebula running on an Azure host with a managed identity?): %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var buf bytes.Buffer
+		buf.ReadFrom(resp.Body)
+		return nil, fmt.Errorf("managed identity token request failed with status %d: %s", resp.StatusCode, buf.String())
+	}
+
+	var miResp managedIdentityResponse
+	if err := json.NewDecoder(resp.Body).Decode(&miResp); err != nil {
+		return nil, fmt.Errorf("failed to decode managed identity token response: %v", err)
+	}
+	expiresIn, _ := strconv.Atoi(miResp.ExpiresIn.String())
+	return &TokenResponse{
+		AccessToken: miResp.AccessToken,
+		TokenType:   miResp.TokenType,
+		ExpiresIn:   expiresIn,
+		Scope:       miResp.Resource,
+	}, nil
+}
+
+// GetManagedIdentityGraphAPIToken gets a Graph API access token from the managed identity
+func GetManagedIdentityGraphAPIToken(clientID string) (*TokenResponse, error) {
+	return GetManagedIdentityToken("https://graph.microsoft.com", clientID)
+}
+
+// GetManagedIdentityPIMToken gets a PIM API access token from the managed identity
+func GetManagedIdentityPIMToken(clientID string) (*TokenResponse, error) {
+	return GetManagedIdentityToken(AzurePIMAudience, clientID)
+}
+
+// GetManagedIdentityAzureRMToken gets an Azure Resource Manager access token from the managed identity
+func GetManagedIdentityAzureRMToken(clientID string) (*TokenResponse, error) {
+	return GetManagedIdentityToken("https://management.core.windows.net/", clientID)
+}
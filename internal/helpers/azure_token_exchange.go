@@ -20,17 +20,34 @@ type TokenResponse struct {
 	Scope        string `json:"scope,omitempty"`
 }
 
+// Client IDs and audiences the refresh token exchanges use
+const (
+	AzureGraphClientID  = "74658136-14ec-4630-ad9b-26e160ff0fc6" // Microsoft Graph API client ID
+	AzurePortalClientID = "c44b4083-3bb0-49c1-b47d-974e53cbdf3c" // Azure Portal broker
+	AzurePIMAudience    = "01fc33a7-78ba-4d2f-a4b7-768e336e890e" // PIM API audience
+)
+
+// azurePublicLoginEndpoint is the Entra ID authority of the public cloud
+const azurePublicLoginEndpoint = "https://login.microsoftonline.com"
+
 // ExchangeRefreshToken exchanges a refresh token for an access token
 func ExchangeRefreshToken(refreshToken, clientID, tenantID, scope, proxyURL string) (*TokenResponse, error) {
+	return ExchangeRefreshTokenAt(azurePublicLoginEndpoint, refreshToken, clientID, tenantID, scope, proxyURL)
+}
+
+// ExchangeRefreshTokenAt exchanges a refresh token for an access token at the
+// Entra ID authority loginEndpoint, such as https://login.microsoftonline.us
+// for Azure Government
+func ExchangeRefreshTokenAt(loginEndpoint, refreshToken, clientID, tenantID, scope, proxyURL string) (*TokenResponse, error) {
 	// Detect which format to use based on client_id
-	useBrokerFormat := (clientID == "c44b4083-3bb0-49c1-b47d-974e53cbdf3c") // Azure Portal broker
+	useBrokerFormat := (clientID == AzurePortalClientID)
 
 	var tokenURL string
 	var formData url.Values
 
 	if useBrokerFormat {
 		// Use simple format for Azure Portal broker (no query params, no redirect_uri)
-		tokenURL = fmt.Sprintf("https://login.microsoftonline.com/%s/oauth2/v2.0/token", tenantID)
+		tokenURL = fmt.Sprintf("%s/%s/oauth2/v2.0/token", loginEndpoint, tenantID)
 		formData = url.Values{
 			"client_id":                    {clientID},
 			"scope":                        {scope},
@@ -45,10 +62,10 @@ func ExchangeRefreshToken(refreshToken, clientID, tenantID, scope, proxyURL stri
 		}
 	} else {
 		// Use broker format for other clients (with query params and redirect_uri)
-		brkClientID := "c44b4083-3bb0-49c1-b47d-974e53cbdf3c"
+		brkClientID := AzurePortalClientID
 		brkRedirectURI := "https://portal.azure.com/"
-		tokenURL = fmt.Sprintf("https://login.microsoftonline.com/%s/oauth2/v2.0/token?brk_client_id=%s&brk_redirect_uri=%s",
-			tenantID, brkClientID, url.QueryEscape(brkRedirectURI))
+		tokenURL = fmt.Sprintf("%s/%s/oauth2/v2.0/token?brk_client_id=%s&brk_redirect_uri=%s",
+			loginEndpoint, tenantID, brkClientID, url.QueryEscape(brkRedirectURI))
 
 		encodedRedirectURI := fmt.Sprintf("brk-%s://portal.azure.com", brkClientID)
 		formData = url.Values{
@@ -124,22 +141,18 @@ func ExchangeRefreshToken(refreshToken, clientID, tenantID, scope, proxyURL stri
 
 // GetGraphAPIToken gets a Graph API access token
 func GetGraphAPIToken(refreshToken, tenantID, proxyURL string) (*TokenResponse, error) {
-	clientID := "74658136-14ec-4630-ad9b-26e160ff0fc6" // Microsoft Graph API client ID
 	scope := "https://graph.microsoft.com/.default"
-	return ExchangeRefreshToken(refreshToken, clientID, tenantID, scope, proxyURL)
+	return ExchangeRefreshToken(refreshToken, AzureGraphClientID, tenantID, scope, proxyURL)
 }
 
 // GetPIMToken gets a PIM API access token
 func GetPIMToken(refreshToken, tenantID, proxyURL string) (*TokenResponse, error) {
-	clientID := "74658136-14ec-4630-ad9b-26e160ff0fc6" // Microsoft Graph API client ID for PIM
-	pimAudience := "01fc33a7-78ba-4d2f-a4b7-768e336e890e"     // PIM API audience
-	scope := pimAudience + "/.default"                        // PIM API scope format
-	return ExchangeRefreshToken(refreshToken, clientID, tenantID, scope, proxyURL)
+	scope := AzurePIMAudience + "/.default" // PIM API scope format
+	return ExchangeRefreshToken(refreshToken, AzureGraphClientID, tenantID, scope, proxyURL)
 }
 
 // GetAzureRMToken gets an Azure Resource Manager access token
 func GetAzureRMToken(refreshToken, tenantID, proxyURL string) (*TokenResponse, error) {
-	clientID := "c44b4083-3bb0-49c1-b47d-974e53cbdf3c" // Azure Management API client ID
 	scope := "https://management.core.windows.net//.default"
-	return ExchangeRefreshToken(refreshToken, clientID, tenantID, scope, proxyURL)
+	return ExchangeRefreshToken(refreshToken, AzurePortalClientID, tenantID, scope, proxyURL)
 }
\ No newline at end of file
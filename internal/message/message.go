@@ -5,7 +5,9 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"strings"
 	"sync"
+	"text/tabwriter"
 
 	"github.com/fatih/color"
 	"github.com/praetorian-inc/nebula/version"
@@ -134,6 +136,26 @@ func Section(format string, args ...any) {
 	printf(sectionColor, "\n-=[", "%s]=-\n", msg)
 }
 
+// Table prints rows under a header with aligned columns unless quiet/silent
+// mode is enabled
+func Table(headers []string, rows [][]string) {
+	if quiet || silent {
+		return
+	}
+
+	var b strings.Builder
+	w := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, strings.Join(headers, "\t"))
+	for _, row := range rows {
+		fmt.Fprintln(w, strings.Join(row, "\t"))
+	}
+	w.Flush()
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	fmt.Fprint(outWriter, b.String())
+}
+
 // Prints the banner
 func Banner(modules int) {
 	if quiet || silent {
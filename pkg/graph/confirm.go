@@ -0,0 +1,53 @@
+package graph
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/mattn/go-isatty"
+)
+
+// CountNodesQuery counts every node in the database
+const CountNodesQuery = "MATCH (n) RETURN count(n) AS count"
+
+// ErrWriteNotConfirmed is returned when the operator declines a write to a
+// non-empty database
+var ErrWriteNotConfirmed = errors.New("aborted: write to a non-empty Neo4j database was not confirmed")
+
+// ConfirmWrite asks the operator on the terminal before a command writes to,
+// or clears, a database that already holds nodes. action completes the
+// sentence "this command will ...". An empty database or assumeYes (--yes)
+// needs no confirmation. Without a terminal on stdin the write is refused, so
+// a scheduled job cannot silently wipe or pollute a shared graph.
+func ConfirmWrite(target string, existingNodes int64, action string, assumeYes bool) error {
+	stdin := os.Stdin.Fd()
+	interactive := isatty.IsTerminal(stdin) || isatty.IsCygwinTerminal(stdin)
+	return confirmWrite(os.Stdin, os.Stderr, interactive, target, existingNodes, action, assumeYes)
+}
+
+func confirmWrite(in io.Reader, out io.Writer, interactive bool, target string, existingNodes int64, action string, assumeYes bool) error {
+	if assumeYes || existingNodes == 0 {
+		return nil
+	}
+
+	warning := fmt.Sprintf("Neo4j database %s already holds %d nodes and this command will %s", target, existingNodes, action)
+	if !interactive {
+		return fmt.Errorf("%s; pass --yes to proceed without confirmation", warning)
+	}
+
+	fmt.Fprintf(out, "%s. Continue? [y/N]: ", warning)
+	answer, err := bufio.NewReader(in).ReadString('\n')
+	if err != nil && answer == "" {
+		return ErrWriteNotConfirmed
+	}
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return nil
+	default:
+		return ErrWriteNotConfirmed
+	}
+}
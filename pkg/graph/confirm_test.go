@@ -0,0 +1,42 @@
+package graph
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfirmWrite(t *testing.T) {
+	const target = "bolt://localhost:7687"
+
+	t.Run("empty database needs no confirmation", func(t *testing.T) {
+		var out bytes.Buffer
+		assert.NoError(t, confirmWrite(strings.NewReader(""), &out, false, target, 0, "delete all of them", false))
+		assert.Empty(t, out.String())
+	})
+
+	t.Run("--yes skips the prompt", func(t *testing.T) {
+		assert.NoError(t, confirmWrite(strings.NewReader(""), &bytes.Buffer{}, false, target, 42, "delete all of them", true))
+	})
+
+	t.Run("non-interactive write is refused", func(t *testing.T) {
+		err := confirmWrite(strings.NewReader("y\n"), &bytes.Buffer{}, false, target, 42, "delete all of them", false)
+		assert.ErrorContains(t, err, "already holds 42 nodes")
+		assert.ErrorContains(t, err, "--yes")
+	})
+
+	t.Run("operator answers", func(t *testing.T) {
+		for answer, confirmed := range map[string]bool{"y\n": true, " YES \n": true, "n\n": false, "\n": false, "": false} {
+			var out bytes.Buffer
+			err := confirmWrite(strings.NewReader(answer), &out, true, target, 42, "delete all of them", false)
+			assert.Contains(t, out.String(), "this command will delete all of them. Continue? [y/N]")
+			if confirmed {
+				assert.NoError(t, err, "answer %q", answer)
+			} else {
+				assert.ErrorIs(t, err, ErrWriteNotConfirmed, "answer %q", answer)
+			}
+		}
+	})
+}
@@ -0,0 +1,63 @@
+package graph
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Edge is the flat form of a Relationship written to edge files, for loading
+// the graph into tools other than Neo4j. Source and Target are the identity
+// values of the start and end nodes, joined with "|" for composite keys.
+type Edge struct {
+	Source           string         `json:"source"`
+	SourceLabels     []string       `json:"source_labels"`
+	SourceProperties map[string]any `json:"source_properties,omitempty"`
+	Target           string         `json:"target"`
+	TargetLabels     []string       `json:"target_labels"`
+	TargetProperties map[string]any `json:"target_properties,omitempty"`
+	Type             string         `json:"type"`
+	Properties       map[string]any `json:"properties"`
+}
+
+// NewEdge flattens a relationship into an Edge
+func NewEdge(rel *Relationship) Edge {
+	edge := Edge{Type: rel.Type, Properties: rel.Properties}
+	if edge.Properties == nil {
+		edge.Properties = map[string]any{}
+	}
+	if rel.StartNode != nil {
+		edge.Source = rel.StartNode.identityValue()
+		edge.SourceLabels = rel.StartNode.Labels
+		edge.SourceProperties = rel.StartNode.Properties
+	}
+	if rel.EndNode != nil {
+		edge.Target = rel.EndNode.identityValue()
+		edge.TargetLabels = rel.EndNode.Labels
+		edge.TargetProperties = rel.EndNode.Properties
+	}
+	return edge
+}
+
+// WriteEdges writes relationships to w as JSON Lines, one Edge per line
+func WriteEdges(w io.Writer, rels []*Relationship) error {
+	encoder := json.NewEncoder(w)
+	for _, rel := range rels {
+		if err := encoder.Encode(NewEdge(rel)); err != nil {
+			return fmt.Errorf("failed to encode %s edge: %w", rel.Type, err)
+		}
+	}
+	return nil
+}
+
+// identityValue renders the node's unique key values in UniqueKey order
+func (n *Node) identityValue() string {
+	values := make([]string, 0, len(n.UniqueKey))
+	for _, key := range n.UniqueKey {
+		if val, exists := n.Properties[key]; exists {
+			values = append(values, fmt.Sprint(val))
+		}
+	}
+	return strings.Join(values, "|")
+}
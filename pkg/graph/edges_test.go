@@ -0,0 +1,45 @@
+package graph
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteEdges(t *testing.T) {
+	rels := []*Relationship{
+		{
+			Type:       "IAM_PERMISSION",
+			Properties: map[string]any{"action": "sts:AssumeRole"},
+			StartNode:  &Node{Labels: []string{"Principal"}, Properties: map[string]any{"arn": "arn:aws:iam::111122223333:user/dev"}, UniqueKey: []string{"arn"}},
+			EndNode:    &Node{Labels: []string{"Role"}, Properties: map[string]any{"arn": "arn:aws:iam::111122223333:role/Admin"}, UniqueKey: []string{"arn"}},
+		},
+		{
+			Type:      "CONTAINS",
+			StartNode: &Node{Labels: []string{"Account"}, Properties: map[string]any{"account": "111122223333", "region": "us-east-1"}, UniqueKey: []string{"account", "region"}},
+			EndNode:   &Node{Labels: []string{"Bucket"}, Properties: map[string]any{"key": "logs"}, UniqueKey: []string{"key"}},
+		},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteEdges(&buf, rels))
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 2)
+
+	var first Edge
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &first))
+	assert.Equal(t, "arn:aws:iam::111122223333:user/dev", first.Source)
+	assert.Equal(t, "arn:aws:iam::111122223333:role/Admin", first.Target)
+	assert.Equal(t, "IAM_PERMISSION", first.Type)
+	assert.Equal(t, "sts:AssumeRole", first.Properties["action"])
+	assert.Equal(t, []string{"Role"}, first.TargetLabels)
+
+	var second Edge
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &second))
+	assert.Equal(t, "111122223333|us-east-1", second.Source, "composite keys keep UniqueKey order")
+	assert.NotNil(t, second.Properties, "relationships without properties still emit an object")
+}
@@ -3,12 +3,15 @@ package queries
 import (
 	"context"
 	"embed"
+	"errors"
 	"fmt"
 	"io/fs"
 	"log/slog"
 	"path/filepath"
 	"sort"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/praetorian-inc/nebula/pkg/graph"
 	"gopkg.in/yaml.v3"
@@ -155,27 +158,154 @@ func GetPlatformQueries(platform, qType string, categories ...string) ([]Query,
 	return result, nil
 }
 
-func EnrichAWS(db graph.GraphDatabase) ([]*graph.QueryResult, error) {
-	awsEnrichmentQueries, err := GetPlatformQueries("aws", "enrich")
+func EnrichAWS(ctx context.Context, db graph.GraphDatabase) ([]*graph.QueryResult, error) {
+	results, _, err := Enrich(ctx, db, "aws", EnrichOptions{})
+	return results, err
+}
+
+// EnrichOptions controls how enrichment queries are run
+type EnrichOptions struct {
+	// Concurrency bounds how many queries sharing the same order run at once.
+	// Queries with different orders always run one stage after another.
+	// Values below 2 run every query serially.
+	Concurrency int
+	// Only restricts the run to these queries, matched by ID, file name, or name
+	Only []string
+	// Timeout bounds each query. A query still running when it expires is
+	// cancelled and fails the run. Zero leaves queries unbounded.
+	Timeout time.Duration
+}
+
+// QueryTiming records how long a single enrichment query took
+type QueryTiming struct {
+	ID       string
+	Name     string
+	Order    int
+	Duration time.Duration
+	Err      error
+}
+
+// Enrich runs the platform's enrichment queries. Queries with the same order
+// do not depend on each other and run concurrently, up to opts.Concurrency.
+// It stops after the first stage with a failing query, or when ctx is done.
+func Enrich(ctx context.Context, db graph.GraphDatabase, platform string, opts EnrichOptions) ([]*graph.QueryResult, []QueryTiming, error) {
+	enrichmentQueries, err := GetPlatformQueries(platform, "enrich")
+	if err != nil {
+		return []*graph.QueryResult{}, nil, err
+	}
+	enrichmentQueries, err = selectQueries(enrichmentQueries, opts.Only)
 	if err != nil {
-		return []*graph.QueryResult{}, err
+		return []*graph.QueryResult{}, nil, err
 	}
 
-	slog.Debug("Enriching AWS", "queryCount", len(awsEnrichmentQueries))
+	slog.Debug("Enriching", "platform", platform, "queryCount", len(enrichmentQueries), "concurrency", opts.Concurrency, "timeout", opts.Timeout)
 
-	results := make([]*graph.QueryResult, 0)
-	for _, query := range awsEnrichmentQueries {
-		slog.Info("Running enrichment query", "id", query.ID, "name", query.Name)
-		params := make(map[string]any)
-		qr, err := db.Query(context.Background(), query.Cypher, params)
-		if err != nil {
-			slog.Error("Error running enrichment query", "id", query.ID, "name", query.Name, "error", err)
-			return results, fmt.Errorf("error running query %s (%s): %w", query.ID, query.Name, err)
+	results := make([]*graph.QueryResult, 0, len(enrichmentQueries))
+	timings := make([]QueryTiming, 0, len(enrichmentQueries))
+	for _, stage := range queryStages(enrichmentQueries) {
+		stageResults, stageTimings := runStage(ctx, db, stage, opts.Concurrency, opts.Timeout)
+		timings = append(timings, stageTimings...)
+		for i, timing := range stageTimings {
+			if timing.Err != nil {
+				return results, timings, fmt.Errorf("error running query %s (%s): %w", timing.ID, timing.Name, timing.Err)
+			}
+			results = append(results, stageResults[i])
 		}
-		results = append(results, qr)
 	}
 
-	return results, nil
+	return results, timings, nil
+}
+
+// queryStages groups queries sorted by order into runs that share an order
+func queryStages(qs []Query) [][]Query {
+	var stages [][]Query
+	for i, q := range qs {
+		if i == 0 || q.Order != qs[i-1].Order {
+			stages = append(stages, nil)
+		}
+		stages[len(stages)-1] = append(stages[len(stages)-1], q)
+	}
+	return stages
+}
+
+func runStage(ctx context.Context, db graph.GraphDatabase, stage []Query, concurrency int, timeout time.Duration) ([]*graph.QueryResult, []QueryTiming) {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	results := make([]*graph.QueryResult, len(stage))
+	timings := make([]QueryTiming, len(stage))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i, query := range stage {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, query Query) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			if err := ctx.Err(); err != nil {
+				timings[i] = QueryTiming{ID: query.ID, Name: query.Name, Order: query.Order, Err: fmt.Errorf("not started: %w", err)}
+				return
+			}
+
+			queryCtx, cancel := ctx, context.CancelFunc(func() {})
+			if timeout > 0 {
+				queryCtx, cancel = context.WithTimeout(ctx, timeout)
+			}
+			defer cancel()
+
+			slog.Info("Running enrichment query", "id", query.ID, "name", query.Name)
+			start := time.Now()
+			qr, err := db.Query(queryCtx, query.Cypher, make(map[string]any))
+			if err != nil && errors.Is(queryCtx.Err(), context.DeadlineExceeded) && ctx.Err() == nil {
+				err = fmt.Errorf("timed out after %s: %w", timeout, err)
+			}
+			timings[i] = QueryTiming{ID: query.ID, Name: query.Name, Order: query.Order, Duration: time.Since(start), Err: err}
+			results[i] = qr
+			if err != nil {
+				slog.Error("Error running enrichment query", "id", query.ID, "name", query.Name, "duration", timings[i].Duration, "error", err)
+				return
+			}
+			slog.Info("Enrichment query completed", "id", query.ID, "duration", timings[i].Duration, "records", len(qr.Records))
+		}(i, query)
+	}
+	wg.Wait()
+	return results, timings
+}
+
+// selectQueries keeps the queries named in only, matched by ID, file name
+// (with or without .yaml), or display name. An empty list keeps every query.
+func selectQueries(qs []Query, only []string) ([]Query, error) {
+	if len(only) == 0 {
+		return qs, nil
+	}
+
+	var selected []Query
+	matched := make(map[string]bool, len(only))
+	for _, q := range qs {
+		for _, name := range only {
+			if strings.EqualFold(name, q.ID) ||
+				strings.EqualFold(name, q.FileName) ||
+				strings.EqualFold(name, strings.TrimSuffix(q.FileName, filepath.Ext(q.FileName))) ||
+				strings.EqualFold(name, q.Name) {
+				selected = append(selected, q)
+				matched[name] = true
+				break
+			}
+		}
+	}
+
+	var unknown []string
+	for _, name := range only {
+		if !matched[name] {
+			unknown = append(unknown, name)
+		}
+	}
+	if len(unknown) > 0 {
+		return nil, fmt.Errorf("unknown enrichment queries: %s", strings.Join(unknown, ", "))
+	}
+	return selected, nil
 }
 
 // RunPlatformQuery now takes a queryID (e.g., "aws/analysis/privesc/ec2_RunInstances")
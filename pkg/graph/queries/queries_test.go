@@ -0,0 +1,116 @@
+package queries
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/praetorian-inc/nebula/pkg/graph"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingDB records the order queries ran in and the peak number running at once
+type recordingDB struct {
+	graph.GraphDatabase
+	mu      sync.Mutex
+	running int
+	peak    int
+	ran     []string
+}
+
+func (db *recordingDB) Query(ctx context.Context, query string, params map[string]any) (*graph.QueryResult, error) {
+	db.mu.Lock()
+	db.running++
+	db.peak = max(db.peak, db.running)
+	db.ran = append(db.ran, query)
+	db.mu.Unlock()
+
+	time.Sleep(10 * time.Millisecond)
+
+	db.mu.Lock()
+	db.running--
+	db.mu.Unlock()
+	return &graph.QueryResult{}, nil
+}
+
+func TestEnrichRunsStagesInOrderWithBoundedConcurrency(t *testing.T) {
+	enrichQueries, err := GetPlatformQueries("aws", "enrich")
+	require.NoError(t, err)
+
+	db := &recordingDB{}
+	results, timings, err := Enrich(context.Background(), db, "aws", EnrichOptions{Concurrency: 2})
+	require.NoError(t, err)
+	assert.Len(t, results, len(enrichQueries))
+	require.Len(t, timings, len(enrichQueries))
+	assert.LessOrEqual(t, db.peak, 2)
+
+	// Every query of a lower order finished before any query of a higher order started
+	order := make(map[string]int, len(enrichQueries))
+	for _, q := range enrichQueries {
+		order[q.Cypher] = q.Order
+	}
+	for i := 1; i < len(db.ran); i++ {
+		assert.LessOrEqual(t, order[db.ran[i-1]], order[db.ran[i]])
+	}
+}
+
+func TestEnrichOnlySelectedQueries(t *testing.T) {
+	db := &recordingDB{}
+	_, timings, err := Enrich(context.Background(), db, "aws", EnrichOptions{Only: []string{"method_01_iam_create_policy_version", "aws/enrich/accounts"}})
+	require.NoError(t, err)
+	require.Len(t, timings, 2)
+	assert.Equal(t, "aws/enrich/accounts", timings[0].ID, "selected queries still run in order")
+	assert.Equal(t, "aws/enrich/privesc/method_01_iam_create_policy_version", timings[1].ID)
+
+	_, _, err = Enrich(context.Background(), db, "aws", EnrichOptions{Only: []string{"does_not_exist"}})
+	assert.ErrorContains(t, err, "does_not_exist")
+}
+
+// blockingDB never answers a query until its context is done
+type blockingDB struct {
+	graph.GraphDatabase
+}
+
+func (blockingDB) Query(ctx context.Context, query string, params map[string]any) (*graph.QueryResult, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestEnrichTimeout(t *testing.T) {
+	_, timings, err := Enrich(context.Background(), blockingDB{}, "aws", EnrichOptions{Only: []string{"aws/enrich/accounts"}, Timeout: 20 * time.Millisecond})
+	require.Error(t, err)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.ErrorContains(t, err, "aws/enrich/accounts")
+	assert.ErrorContains(t, err, "timed out after 20ms")
+	require.Len(t, timings, 1)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	db := &recordingDB{}
+	_, _, err = Enrich(ctx, db, "aws", EnrichOptions{})
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Empty(t, db.ran, "no query starts once the run is cancelled")
+}
+
+func TestRoleTakeoverRunsAfterAdminFlags(t *testing.T) {
+	enrichQueries, err := GetPlatformQueries("aws", "enrich")
+	require.NoError(t, err)
+
+	var takeover *Query
+	adminOrder := 0
+	for i, q := range enrichQueries {
+		switch {
+		case strings.Contains(q.Cypher, "CAN_TAKEOVER_ROLE"):
+			takeover = &enrichQueries[i]
+		case strings.Contains(q.Cypher, "SET p.admin") || strings.Contains(q.Cypher, "SET p.privileged"):
+			adminOrder = max(adminOrder, q.Order)
+		}
+	}
+	require.NotNil(t, takeover)
+	assert.Greater(t, takeover.Order, adminOrder, "the edge copies the target role's admin and privileged flags")
+
+	assert.Contains(t, LoadedQueries, "aws/analysis/role_takeover")
+}
@@ -0,0 +1,61 @@
+package graph
+
+// DefaultMaxHops is how deep the multi-hop path analyses walk unless told
+// otherwise. Real assume-role and escalation chains are a few hops long;
+// anything deeper is almost always a loop through a wide trust.
+const DefaultMaxHops = 10
+
+// Step is one edge out of a node, labelled with how it is taken
+type Step[N comparable] struct {
+	To  N
+	Via string
+}
+
+// Visit is a node reached by Walk with the shortest path to it. Path starts at
+// the walk's start node and ends at Node; Via labels each step of Path.
+type Visit[N comparable] struct {
+	Node N
+	Hops int
+	Path []N
+	Via  []string
+}
+
+// WalkResult lists the nodes reached by Walk in breadth first order, starting
+// with the start node itself. Capped is set when a node at MaxHops still had
+// steps to nodes the walk had not reached, so the result may be incomplete.
+type WalkResult[N comparable] struct {
+	Visits  []Visit[N]
+	MaxHops int
+	Capped  bool
+}
+
+// Walk follows next breadth first from start. Every node is visited once, so
+// cycles (A -> B -> A) end the walk rather than looping, and each node keeps
+// the first, shortest, path that reached it. Nodes more than maxHops steps
+// from start are not visited; zero or a negative maxHops walks without limit.
+func Walk[N comparable](start N, next func(N) []Step[N], maxHops int) WalkResult[N] {
+	result := WalkResult[N]{MaxHops: max(maxHops, 0)}
+	visited := map[N]bool{start: true}
+	result.Visits = []Visit[N]{{Node: start, Path: []N{start}, Via: []string{}}}
+
+	for i := 0; i < len(result.Visits); i++ {
+		current := result.Visits[i]
+		for _, step := range next(current.Node) {
+			if visited[step.To] {
+				continue
+			}
+			if maxHops > 0 && current.Hops >= maxHops {
+				result.Capped = true
+				break
+			}
+			visited[step.To] = true
+			result.Visits = append(result.Visits, Visit[N]{
+				Node: step.To,
+				Hops: current.Hops + 1,
+				Path: append(append(make([]N, 0, len(current.Path)+1), current.Path...), step.To),
+				Via:  append(append(make([]string, 0, len(current.Via)+1), current.Via...), step.Via),
+			})
+		}
+	}
+	return result
+}
@@ -0,0 +1,68 @@
+package graph
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func adjacency(edges map[string][]string) func(string) []Step[string] {
+	return func(node string) []Step[string] {
+		steps := make([]Step[string], 0, len(edges[node]))
+		for _, to := range edges[node] {
+			steps = append(steps, Step[string]{To: to, Via: node + ">" + to})
+		}
+		return steps
+	}
+}
+
+func visitedNodes(result WalkResult[string]) []string {
+	nodes := make([]string, 0, len(result.Visits))
+	for _, visit := range result.Visits {
+		nodes = append(nodes, visit.Node)
+	}
+	return nodes
+}
+
+func TestWalk(t *testing.T) {
+	t.Run("cycles are visited once", func(t *testing.T) {
+		result := Walk("a", adjacency(map[string][]string{
+			"a": {"b"},
+			"b": {"a", "c"},
+			"c": {"c", "b"},
+		}), 0)
+		assert.Equal(t, []string{"a", "b", "c"}, visitedNodes(result))
+		assert.False(t, result.Capped)
+		assert.Equal(t, []string{"a", "b", "c"}, result.Visits[2].Path)
+		assert.Equal(t, []string{"a>b", "b>c"}, result.Visits[2].Via)
+		assert.Equal(t, 2, result.Visits[2].Hops)
+	})
+
+	t.Run("shortest path wins", func(t *testing.T) {
+		result := Walk("a", adjacency(map[string][]string{
+			"a": {"b", "d"},
+			"b": {"c"},
+			"c": {"d"},
+		}), 0)
+		assert.Equal(t, []string{"a", "b", "d", "c"}, visitedNodes(result))
+		assert.Equal(t, []string{"a", "d"}, result.Visits[2].Path)
+	})
+
+	t.Run("depth is capped", func(t *testing.T) {
+		chain := adjacency(map[string][]string{"a": {"b"}, "b": {"c"}, "c": {"d"}})
+		result := Walk("a", chain, 2)
+		assert.Equal(t, []string{"a", "b", "c"}, visitedNodes(result))
+		assert.True(t, result.Capped)
+		assert.Equal(t, 2, result.MaxHops)
+
+		exact := Walk("a", chain, 3)
+		assert.Equal(t, []string{"a", "b", "c", "d"}, visitedNodes(exact))
+		assert.False(t, exact.Capped, "a limit the graph never reaches is not reported")
+	})
+
+	t.Run("cycle at the limit is not a cap", func(t *testing.T) {
+		result := Walk("a", adjacency(map[string][]string{"a": {"b"}, "b": {"a"}}), 1)
+		assert.Equal(t, []string{"a", "b"}, visitedNodes(result))
+		assert.False(t, result.Capped)
+	})
+}
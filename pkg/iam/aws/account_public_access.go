@@ -0,0 +1,115 @@
+package aws
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/praetorian-inc/nebula/pkg/types"
+)
+
+// AccountPublicAccessBlockType is the resource type AWS Config records the
+// account-level S3 Public Access Block settings under
+const AccountPublicAccessBlockType = "AWS::S3::AccountPublicAccessBlock"
+
+// accountPublicAccessBlockSettings are the four account-level S3 Public Access
+// Block settings, in the order AWS documents them
+var accountPublicAccessBlockSettings = []string{
+	"BlockPublicAcls",
+	"IgnorePublicAcls",
+	"BlockPublicPolicy",
+	"RestrictPublicBuckets",
+}
+
+// AccountPublicAccessFinding is an account whose S3 Public Access Block leaves
+// at least one setting off. The account block is the backstop behind every
+// bucket's own settings, so each bucket in the account is only as private as
+// its own policy and ACLs.
+type AccountPublicAccessFinding struct {
+	AccountID        string   `json:"account_id"`
+	DisabledSettings []string `json:"disabled_settings"`
+	Buckets          int      `json:"buckets"`
+	Severity         string   `json:"severity"`
+	Detail           string   `json:"detail"`
+}
+
+// FindAccountPublicAccessIssues checks the account-level S3 Public Access
+// Block of every account the resources include one for. A setting missing
+// from the configuration is off, which is the AWS default. Accounts with no
+// recorded configuration are not reported, since the inventory cannot show
+// whether the block is set. Findings are ordered by severity, then account.
+func FindAccountPublicAccessIssues(resources []types.EnrichedResourceDescription) []AccountPublicAccessFinding {
+	buckets := make(map[string]int)
+	configs := make(map[string]map[string]bool)
+	for i := range resources {
+		resource := &resources[i]
+		switch resource.TypeName {
+		case "AWS::S3::Bucket":
+			buckets[resource.AccountId]++
+		case AccountPublicAccessBlockType:
+			configs[resource.AccountId] = publicAccessBlockSettings(resource)
+		}
+	}
+
+	findings := make([]AccountPublicAccessFinding, 0)
+	for accountID, settings := range configs {
+		var disabled []string
+		for _, setting := range accountPublicAccessBlockSettings {
+			if !settings[strings.ToLower(setting)] {
+				disabled = append(disabled, setting)
+			}
+		}
+		if len(disabled) == 0 {
+			continue
+		}
+
+		// Without BlockPublicPolicy or RestrictPublicBuckets a bucket policy can
+		// grant anonymous access; the ACL settings only cover legacy ACL grants
+		severity := "Medium"
+		for _, setting := range disabled {
+			if setting == "BlockPublicPolicy" || setting == "RestrictPublicBuckets" {
+				severity = "High"
+			}
+		}
+		findings = append(findings, AccountPublicAccessFinding{
+			AccountID:        accountID,
+			DisabledSettings: disabled,
+			Buckets:          buckets[accountID],
+			Severity:         severity,
+			Detail: fmt.Sprintf("Account-level S3 Public Access Block has %s disabled, so the %d buckets in the account rely on their own settings and policies alone to stay private",
+				strings.Join(disabled, ", "), buckets[accountID]),
+		})
+	}
+
+	sort.SliceStable(findings, func(i, j int) bool {
+		if a, b := severityRank(findings[i].Severity), severityRank(findings[j].Severity); a != b {
+			return a > b
+		}
+		return findings[i].AccountID < findings[j].AccountID
+	})
+	return findings
+}
+
+// publicAccessBlockSettings reads the enabled settings of a Public Access Block
+// configuration, keyed by lowercased name. AWS Config records them in
+// camelCase, s3control get-public-access-block nests them in PascalCase under
+// PublicAccessBlockConfiguration, and Steampipe uses snake_case.
+func publicAccessBlockSettings(resource *types.EnrichedResourceDescription) map[string]bool {
+	var properties map[string]any
+	switch props := resource.Properties.(type) {
+	case map[string]any:
+		properties = props
+	case string:
+		properties, _ = resource.PropertiesAsMap()
+	}
+	if nested, ok := properties["PublicAccessBlockConfiguration"].(map[string]any); ok {
+		properties = nested
+	}
+
+	settings := make(map[string]bool)
+	for key, value := range properties {
+		enabled, _ := value.(bool)
+		settings[strings.ToLower(strings.ReplaceAll(key, "_", ""))] = enabled
+	}
+	return settings
+}
@@ -0,0 +1,46 @@
+package aws
+
+import (
+	"testing"
+
+	"github.com/praetorian-inc/nebula/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindAccountPublicAccessIssues(t *testing.T) {
+	resources, err := types.ParseResourceDescriptions([]byte(`{"configurationItems": [
+		{"resourceType": "AWS::S3::AccountPublicAccessBlock", "resourceId": "111111111111", "accountId": "111111111111", "awsRegion": "us-east-1",
+		 "configuration": {"blockPublicAcls": true, "ignorePublicAcls": true, "blockPublicPolicy": true, "restrictPublicBuckets": true}},
+		{"resourceType": "AWS::S3::AccountPublicAccessBlock", "resourceId": "222222222222", "accountId": "222222222222", "awsRegion": "us-east-1",
+		 "configuration": {"blockPublicAcls": false, "ignorePublicAcls": true, "blockPublicPolicy": true, "restrictPublicBuckets": true}},
+		{"resourceType": "AWS::S3::AccountPublicAccessBlock", "resourceId": "333333333333", "accountId": "333333333333", "awsRegion": "us-east-1",
+		 "configuration": {"blockPublicAcls": true, "ignorePublicAcls": true}},
+		{"resourceType": "AWS::S3::Bucket", "resourceId": "logs", "accountId": "333333333333", "awsRegion": "us-east-1", "arn": "arn:aws:s3:::logs"},
+		{"resourceType": "AWS::S3::Bucket", "resourceId": "assets", "accountId": "333333333333", "awsRegion": "us-east-1", "arn": "arn:aws:s3:::assets"},
+		{"resourceType": "AWS::S3::Bucket", "resourceId": "data", "accountId": "444444444444", "awsRegion": "us-east-1", "arn": "arn:aws:s3:::data"}
+	]}`), types.ResourceFormatConfig)
+	require.NoError(t, err)
+
+	findings := FindAccountPublicAccessIssues(resources)
+	require.Len(t, findings, 2, "a fully enabled block and an account without a recorded block are not reported")
+
+	assert.Equal(t, "333333333333", findings[0].AccountID, "policy settings off ranks above ACL settings off")
+	assert.Equal(t, "High", findings[0].Severity)
+	assert.Equal(t, []string{"BlockPublicPolicy", "RestrictPublicBuckets"}, findings[0].DisabledSettings, "missing settings are off")
+	assert.Equal(t, 2, findings[0].Buckets)
+
+	assert.Equal(t, "222222222222", findings[1].AccountID)
+	assert.Equal(t, "Medium", findings[1].Severity)
+	assert.Equal(t, []string{"BlockPublicAcls"}, findings[1].DisabledSettings)
+}
+
+func TestPublicAccessBlockSettingsShapes(t *testing.T) {
+	nested := types.EnrichedResourceDescription{Properties: map[string]any{
+		"PublicAccessBlockConfiguration": map[string]any{"BlockPublicAcls": true, "RestrictPublicBuckets": false},
+	}}
+	assert.Equal(t, map[string]bool{"blockpublicacls": true, "restrictpublicbuckets": false}, publicAccessBlockSettings(&nested))
+
+	snake := types.EnrichedResourceDescription{Properties: `{"block_public_policy": true}`}
+	assert.Equal(t, map[string]bool{"blockpublicpolicy": true}, publicAccessBlockSettings(&snake))
+}
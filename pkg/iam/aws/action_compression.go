@@ -0,0 +1,181 @@
+package aws
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// ActionCatalog holds every known action name per service, keyed by lowercased
+// service prefix and lowercased action name. It is the reference for deciding
+// whether a set of allowed actions covers a whole service or verb group.
+type ActionCatalog map[string]map[string]bool
+
+// NewActionCatalog builds a catalog from fully qualified action names
+func NewActionCatalog(actions []string) ActionCatalog {
+	catalog := make(ActionCatalog)
+	for _, action := range actions {
+		service, name, ok := strings.Cut(strings.ToLower(action), ":")
+		if !ok || service == "" || name == "" {
+			continue
+		}
+		if catalog[service] == nil {
+			catalog[service] = make(map[string]bool)
+		}
+		catalog[service][name] = true
+	}
+	return catalog
+}
+
+// LoadActionCatalog builds the catalog from the AWS Policy Generator action
+// list, which wildcard expansion has usually already fetched and cached
+func LoadActionCatalog() (ActionCatalog, error) {
+	actions, err := fetchAllAWSActions()
+	if err != nil {
+		return nil, err
+	}
+	return NewActionCatalog(actions), nil
+}
+
+// actionVerbPattern matches the leading verb of an action name, e.g. "Put" in "PutRolePolicy"
+var actionVerbPattern = regexp.MustCompile(`^[A-Z][a-z]+`)
+
+// Compress collapses allowed actions to the shortest equivalent wildcards:
+// svc:* when every catalogued action of the service is present, then
+// svc:Verb* when every catalogued action starting with that verb is present.
+// Services missing from the catalog are left as individual actions, so a
+// wildcard never claims more than was actually allowed.
+func (c ActionCatalog) Compress(actions []string) []string {
+	return sortedGroupNames(c.compressGroups(actions))
+}
+
+func sortedGroupNames(groups map[string][]string) []string {
+	names := make([]string, 0, len(groups))
+	for name := range groups {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// compressGroups maps each compressed action name to the actions it stands for
+func (c ActionCatalog) compressGroups(actions []string) map[string][]string {
+	byService := make(map[string]map[string]string) // service -> lowercased name -> original action
+	for _, action := range actions {
+		service, name, ok := strings.Cut(action, ":")
+		if !ok {
+			service, name = action, ""
+		}
+		service = strings.ToLower(service)
+		if byService[service] == nil {
+			byService[service] = make(map[string]string)
+		}
+		byService[service][strings.ToLower(name)] = action
+	}
+
+	groups := make(map[string][]string)
+	for service, allowed := range byService {
+		known := c[service]
+		if len(known) == 0 || !coversAll(allowed, known) {
+			for _, group := range c.compressVerbs(service, allowed) {
+				groups[group.name] = group.members
+			}
+			continue
+		}
+
+		members := make([]string, 0, len(allowed))
+		for _, action := range allowed {
+			members = append(members, action)
+		}
+		sort.Strings(members)
+		groups[service+":*"] = members
+	}
+	return groups
+}
+
+type actionGroup struct {
+	name    string
+	members []string
+}
+
+// compressVerbs groups a service's allowed actions by leading verb where the
+// allowed set covers every catalogued action with that verb
+func (c ActionCatalog) compressVerbs(service string, allowed map[string]string) []actionGroup {
+	known := c[service]
+	byVerb := make(map[string][]string)
+	var groups []actionGroup
+	for lowerName, action := range allowed {
+		_, name, _ := strings.Cut(action, ":")
+		verb := actionVerbPattern.FindString(name)
+		if verb == "" || !known[lowerName] {
+			groups = append(groups, actionGroup{name: action, members: []string{action}})
+			continue
+		}
+		byVerb[verb] = append(byVerb[verb], action)
+	}
+
+	for verb, actions := range byVerb {
+		prefix := strings.ToLower(verb)
+		verbTotal := 0
+		for name := range known {
+			if strings.HasPrefix(name, prefix) {
+				verbTotal++
+			}
+		}
+		sort.Strings(actions)
+		if len(actions) > 1 && len(actions) == verbTotal {
+			groups = append(groups, actionGroup{name: service + ":" + verb + "*", members: actions})
+			continue
+		}
+		for _, action := range actions {
+			groups = append(groups, actionGroup{name: action, members: []string{action}})
+		}
+	}
+	return groups
+}
+
+func coversAll(allowed map[string]string, known map[string]bool) bool {
+	for name := range known {
+		if _, ok := allowed[name]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// compressed returns a copy of the resource permission with allowed actions
+// collapsed against the catalog. A wildcard entry keeps the evaluation result
+// of its first member and records how many actions it covers.
+func (rp *ResourcePermission) compressed(catalog ActionCatalog) *ResourcePermission {
+	rp.mu.RLock()
+	defer rp.mu.RUnlock()
+
+	byName := make(map[string]*ResourceAction, len(rp.AllowedActions))
+	names := make([]string, 0, len(rp.AllowedActions))
+	for _, action := range rp.AllowedActions {
+		if _, seen := byName[action.Name]; !seen {
+			byName[action.Name] = action
+			names = append(names, action.Name)
+		}
+	}
+
+	groups := catalog.compressGroups(names)
+	out := &ResourcePermission{
+		Resource:       rp.Resource,
+		AllowedActions: make([]*ResourceAction, 0, len(groups)),
+		DeniedActions:  rp.DeniedActions,
+	}
+	for _, name := range sortedGroupNames(groups) {
+		members := groups[name]
+		if len(members) == 1 && members[0] == name {
+			out.AllowedActions = append(out.AllowedActions, byName[name])
+			continue
+		}
+		out.AllowedActions = append(out.AllowedActions, &ResourceAction{
+			Name:             name,
+			EvaluationResult: byName[members[0]].EvaluationResult,
+			Covers:           len(members),
+		})
+	}
+	return out
+}
@@ -0,0 +1,64 @@
+package aws
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testActionCatalog = NewActionCatalog([]string{
+	"sts:AssumeRole", "sts:AssumeRoleWithSAML", "sts:AssumeRoleWithWebIdentity", "sts:GetFederationToken",
+	"iam:PutGroupPolicy", "iam:PutRolePolicy", "iam:PutUserPolicy",
+	"iam:CreateAccessKey", "iam:CreateRole", "iam:CreateUser", "iam:PassRole",
+})
+
+func TestActionCatalogCompress(t *testing.T) {
+	assert.Equal(t, []string{"sts:*"}, testActionCatalog.Compress([]string{
+		"sts:AssumeRole", "sts:AssumeRoleWithSAML", "sts:AssumeRoleWithWebIdentity", "sts:GetFederationToken",
+	}), "a fully allowed service collapses to its wildcard")
+
+	assert.Equal(t, []string{"iam:CreateRole", "iam:PassRole", "iam:Put*"}, testActionCatalog.Compress([]string{
+		"iam:PutRolePolicy", "iam:PutUserPolicy", "iam:PutGroupPolicy", "iam:CreateRole", "iam:PassRole",
+	}), "verbs collapse only when every catalogued action with that verb is allowed")
+
+	assert.Equal(t, []string{"ssm:SendCommand", "ssm:StartSession"}, testActionCatalog.Compress([]string{
+		"ssm:StartSession", "ssm:SendCommand",
+	}), "services missing from the catalog are left alone")
+}
+
+func TestPermissionsSummaryCompressActions(t *testing.T) {
+	summary := NewPermissionsSummary()
+	principal := "arn:aws:iam::111122223333:role/Admin"
+	resource := "arn:aws:iam::111122223333:role/Target"
+	for _, action := range []string{"iam:PutGroupPolicy", "iam:PutRolePolicy", "iam:PutUserPolicy", "iam:PassRole"} {
+		summary.AddPermission(principal, resource, action, true, &EvaluationResult{Allowed: true})
+	}
+
+	decode := func() []map[string]interface{} {
+		data, err := json.Marshal(summary)
+		require.NoError(t, err)
+		var out struct {
+			Permissions map[string]struct {
+				ResourcePerms map[string]struct {
+					AllowedActions []map[string]interface{}
+				} `json:"resource_permissions"`
+			} `json:"permissions"`
+		}
+		require.NoError(t, json.Unmarshal(data, &out))
+		return out.Permissions[principal].ResourcePerms[resource].AllowedActions
+	}
+
+	assert.Len(t, decode(), 4, "output is uncompressed by default")
+
+	summary.CompressActions(testActionCatalog)
+	actions := decode()
+	require.Len(t, actions, 2)
+	assert.Equal(t, "iam:PassRole", actions[0]["Name"])
+	assert.Equal(t, "iam:Put*", actions[1]["Name"])
+	assert.Equal(t, float64(3), actions[1]["Covers"])
+	assert.NotNil(t, actions[1]["EvaluationResult"])
+
+	assert.Len(t, summary.FullResults(), 0, "graph results are built from the uncompressed permissions")
+}
@@ -0,0 +1,281 @@
+package aws
+
+import (
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+	"github.com/praetorian-inc/nebula/pkg/graph"
+	"github.com/praetorian-inc/nebula/pkg/types"
+)
+
+// adminManagedPolicies are the AWS managed policies that make a principal
+// admin-equivalent. These match the set_admin_* enrichment queries so the
+// offline report and the graph agree on which roles are admins.
+var adminManagedPolicies = map[string]bool{
+	"AdministratorAccess": true,
+	"IAMFullAccess":       true,
+}
+
+// RoleAssumer is a principal that can reach an admin role through one or more
+// sts:AssumeRole hops
+type RoleAssumer struct {
+	Principal string   `json:"principal"`
+	Type      string   `json:"type"`
+	AccountID string   `json:"account_id,omitempty"`
+	External  bool     `json:"external"`
+	Hops      int      `json:"hops"`
+	Path      []string `json:"path"`
+}
+
+// AdminRoleAssumers lists every principal that can assume an admin-equivalent
+// role, directly or by chaining through other roles
+type AdminRoleAssumers struct {
+	RoleArn         string        `json:"role_arn"`
+	AccountID       string        `json:"account_id"`
+	AdminReason     string        `json:"admin_reason"`
+	DirectCount     int           `json:"direct_count"`
+	TransitiveCount int           `json:"transitive_count"`
+	ExternalCount   int           `json:"external_count"`
+	AssumerAccounts []string      `json:"assumer_accounts"`
+	Assumers        []RoleAssumer `json:"assumers"`
+	HopsCapped      bool          `json:"hops_capped,omitempty"`
+}
+
+// FindAdminRoleAssumers ranks the admin-equivalent roles in the GAAD by who can
+// assume them. The assume graph is taken from the sts:AssumeRole results in the
+// summary, which already combine trust policies with identity policies, SCPs
+// and boundaries. Roles reachable by external or public principals sort first,
+// then roles with the most assumers. Chains longer than maxHops are not
+// followed, and roles with assumers beyond them are marked HopsCapped.
+func FindAdminRoleAssumers(gaad *types.Gaad, summary *PermissionsSummary, maxHops int) []AdminRoleAssumers {
+	if gaad == nil || summary == nil {
+		return nil
+	}
+
+	assumedBy := assumeRoleEdges(summary)
+	managed := make(map[string]*types.PoliciesDL, len(gaad.Policies))
+	for i := range gaad.Policies {
+		managed[gaad.Policies[i].Arn] = &gaad.Policies[i]
+	}
+
+	report := make([]AdminRoleAssumers, 0)
+	for _, role := range gaad.RoleDetailList {
+		reason := roleAdminReason(role, managed)
+		if reason == "" {
+			continue
+		}
+		entry := AdminRoleAssumers{
+			RoleArn:     role.Arn,
+			AccountID:   accountFromArn(role.Arn),
+			AdminReason: reason,
+		}
+		entry.Assumers, entry.HopsCapped = transitiveAssumers(role.Arn, assumedBy, maxHops)
+		accounts := make(map[string]bool)
+		for _, assumer := range entry.Assumers {
+			if assumer.Hops == 1 {
+				entry.DirectCount++
+			} else {
+				entry.TransitiveCount++
+			}
+			if assumer.External {
+				entry.ExternalCount++
+			}
+			if assumer.AccountID != "" {
+				accounts[assumer.AccountID] = true
+			}
+		}
+		entry.AssumerAccounts = sortedKeys(accounts)
+		report = append(report, entry)
+	}
+
+	sort.SliceStable(report, func(i, j int) bool {
+		if report[i].ExternalCount != report[j].ExternalCount {
+			return report[i].ExternalCount > report[j].ExternalCount
+		}
+		if len(report[i].Assumers) != len(report[j].Assumers) {
+			return len(report[i].Assumers) > len(report[j].Assumers)
+		}
+		return report[i].RoleArn < report[j].RoleArn
+	})
+	return report
+}
+
+// assumeRoleEdges inverts the summary into role ARN -> principals allowed to
+// call sts:AssumeRole on it
+func assumeRoleEdges(summary *PermissionsSummary) map[string][]string {
+	assumedBy := make(map[string][]string)
+	summary.Permissions.Range(func(key, value any) bool {
+		principalArn := key.(string)
+		perms := value.(*PrincipalPermissions)
+		perms.ResourcePerms.Range(func(resKey, resValue any) bool {
+			roleArn := resKey.(string)
+			if strings.Contains(roleArn, ":role/") && principalArn != roleArn &&
+				hasAllowedActionOnResource(perms, "sts:AssumeRole", roleArn) {
+				assumedBy[roleArn] = append(assumedBy[roleArn], principalArn)
+			}
+			return true
+		})
+		return true
+	})
+	for roleArn := range assumedBy {
+		sort.Strings(assumedBy[roleArn])
+	}
+	return assumedBy
+}
+
+// transitiveAssumers walks the assume graph backwards from roleArn, at most
+// maxHops hops. The walk is breadth first, so each assumer is reported with
+// its shortest path. capped reports whether assumers further away were cut.
+func transitiveAssumers(roleArn string, assumedBy map[string][]string, maxHops int) (assumers []RoleAssumer, capped bool) {
+	roleAccount := accountFromArn(roleArn)
+	walk := graph.Walk(roleArn, func(target string) []graph.Step[string] {
+		// Only roles can be assumed onward
+		if assumerType(target) != "role" {
+			return nil
+		}
+		steps := make([]graph.Step[string], 0, len(assumedBy[target]))
+		for _, principal := range assumedBy[target] {
+			steps = append(steps, graph.Step[string]{To: principal, Via: "sts:AssumeRole"})
+		}
+		return steps
+	}, maxHops)
+
+	assumers = make([]RoleAssumer, 0, len(walk.Visits)-1)
+	for _, visit := range walk.Visits[1:] {
+		path := slices.Clone(visit.Path)
+		slices.Reverse(path)
+		accountID := accountFromArn(visit.Node)
+		assumers = append(assumers, RoleAssumer{
+			Principal: visit.Node,
+			Type:      assumerType(visit.Node),
+			AccountID: accountID,
+			External:  visit.Node == "*" || (accountID != "" && accountID != roleAccount),
+			Hops:      visit.Hops,
+			Path:      path,
+		})
+	}
+	return assumers, walk.Capped
+}
+
+// roleAdminReason returns why a role is admin-equivalent, or "" if it is not.
+// A permissions boundary that does not itself grant admin caps the role.
+func roleAdminReason(role types.RoleDL, managed map[string]*types.PoliciesDL) string {
+	reason := policiesAdminReason(role.RolePolicyList, role.AttachedManagedPolicies, managed)
+	if reason == "" || boundaryLimitsAdmin(role.PermissionsBoundary.PolicyArn, managed) {
+		return ""
+	}
+	return reason
+}
+
+// policiesAdminReason returns the first inline or attached policy that grants
+// admin, or "" if none does
+func policiesAdminReason(inline []types.PrincipalPL, attached []types.ManagedPL, managed map[string]*types.PoliciesDL) string {
+	for _, p := range inline {
+		if policyGrantsAdmin(&p.PolicyDocument) {
+			return "inline policy " + p.PolicyName
+		}
+	}
+	for _, a := range attached {
+		if isAWSManagedAdminPolicy(a.PolicyArn) {
+			return "managed policy " + a.PolicyName
+		}
+		if policy, ok := managed[a.PolicyArn]; ok && policyGrantsAdmin(policy.DefaultPolicyDocument()) {
+			return "managed policy " + a.PolicyName
+		}
+	}
+	return ""
+}
+
+// boundaryLimitsAdmin reports whether a permissions boundary caps admin
+// policies. A boundary that is missing from the GAAD is assumed not to.
+func boundaryLimitsAdmin(boundaryArn string, managed map[string]*types.PoliciesDL) bool {
+	if boundaryArn == "" || isAWSManagedAdminPolicy(boundaryArn) {
+		return false
+	}
+	boundary, ok := managed[boundaryArn]
+	return ok && !policyGrantsAdmin(boundary.DefaultPolicyDocument())
+}
+
+// policyGrantsAdmin reports whether a document has an unconditional Allow of
+// "*" or "iam:*" on every resource
+func policyGrantsAdmin(doc *types.Policy) bool {
+	if doc == nil || doc.Statement == nil {
+		return false
+	}
+	for _, stmt := range *doc.Statement {
+		if !strings.EqualFold(stmt.Effect, "Allow") || stmt.Condition != nil || stmt.Action == nil || stmt.Resource == nil {
+			continue
+		}
+		wildcardAction := false
+		for _, action := range *stmt.Action {
+			if action == "*" || strings.EqualFold(action, "iam:*") {
+				wildcardAction = true
+				break
+			}
+		}
+		if wildcardAction && slices.Contains(*stmt.Resource, "*") {
+			return true
+		}
+	}
+	return false
+}
+
+func isAWSManagedAdminPolicy(policyArn string) bool {
+	parsed, err := arn.Parse(policyArn)
+	if err != nil || parsed.AccountID != "aws" {
+		return false
+	}
+	return adminManagedPolicies[strings.TrimPrefix(parsed.Resource, "policy/")]
+}
+
+// assumerType classifies a trust or identity principal for the report
+func assumerType(principal string) string {
+	if principal == "*" {
+		return "public"
+	}
+	parsed, err := arn.Parse(principal)
+	if err != nil {
+		if accountFromArn(principal) != "" {
+			return "account"
+		}
+		if isServicePrincipal(principal) {
+			return "service"
+		}
+		return "federated"
+	}
+	switch {
+	case strings.HasPrefix(parsed.Resource, "role/"):
+		return "role"
+	case strings.HasPrefix(parsed.Resource, "user/"):
+		return "user"
+	case strings.HasPrefix(parsed.Resource, "assumed-role/"):
+		return "session"
+	case parsed.Resource == "root":
+		return "account"
+	case strings.HasPrefix(parsed.Resource, "saml-provider/"), strings.HasPrefix(parsed.Resource, "oidc-provider/"):
+		return "federated"
+	}
+	return "other"
+}
+
+func accountFromArn(principal string) string {
+	if parsed, err := arn.Parse(principal); err == nil {
+		return parsed.AccountID
+	}
+	// Trust policies may name an account by its bare ID
+	if len(principal) == 12 && strings.Trim(principal, "0123456789") == "" {
+		return principal
+	}
+	return ""
+}
+
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,115 @@
+package aws
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/praetorian-inc/nebula/pkg/graph"
+	"github.com/praetorian-inc/nebula/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const adminAssumersGaad = `{
+  "UserDetailList": [{"Arn": "arn:aws:iam::111122223333:user/dev", "UserName": "dev"}],
+  "RoleDetailList": [
+    {
+      "Arn": "arn:aws:iam::111122223333:role/Admin",
+      "RoleName": "Admin",
+      "AttachedManagedPolicies": [{"PolicyName": "AdministratorAccess", "PolicyArn": "arn:aws:iam::aws:policy/AdministratorAccess"}]
+    },
+    {
+      "Arn": "arn:aws:iam::111122223333:role/Deployer",
+      "RoleName": "Deployer",
+      "RolePolicyList": [{
+        "PolicyName": "iam-everything",
+        "PolicyDocument": {"Version": "2012-10-17", "Statement": [{"Effect": "Allow", "Action": ["s3:*", "iam:*"], "Resource": "*"}]}
+      }]
+    },
+    {
+      "Arn": "arn:aws:iam::111122223333:role/Bounded",
+      "RoleName": "Bounded",
+      "RolePolicyList": [{
+        "PolicyName": "star",
+        "PolicyDocument": {"Version": "2012-10-17", "Statement": [{"Effect": "Allow", "Action": "*", "Resource": "*"}]}
+      }],
+      "PermissionsBoundary": {"PolicyName": "ReadOnlyBoundary", "PolicyArn": "arn:aws:iam::111122223333:policy/ReadOnlyBoundary"}
+    },
+    {
+      "Arn": "arn:aws:iam::111122223333:role/Conditional",
+      "RoleName": "Conditional",
+      "RolePolicyList": [{
+        "PolicyName": "mfa-star",
+        "PolicyDocument": {"Version": "2012-10-17", "Statement": [{"Effect": "Allow", "Action": "*", "Resource": "*", "Condition": {"Bool": {"aws:MultiFactorAuthPresent": "true"}}}]}
+      }]
+    },
+    {"Arn": "arn:aws:iam::111122223333:role/Jump", "RoleName": "Jump"}
+  ],
+  "GroupDetailList": [],
+  "Policies": [{
+    "Arn": "arn:aws:iam::111122223333:policy/ReadOnlyBoundary",
+    "PolicyName": "ReadOnlyBoundary",
+    "PolicyVersionList": [{"IsDefaultVersion": true, "Document": {"Version": "2012-10-17", "Statement": [
+      {"Effect": "Allow", "Action": "s3:Get*", "Resource": "*"}
+    ]}}]
+  }]
+}`
+
+func TestFindAdminRoleAssumers(t *testing.T) {
+	var gaad types.Gaad
+	require.NoError(t, json.Unmarshal([]byte(adminAssumersGaad), &gaad))
+
+	allow := &EvaluationResult{Allowed: true}
+	summary := NewPermissionsSummary()
+	// dev -> Jump -> Admin, plus a direct cross-account trust on Admin
+	summary.AddPermission("arn:aws:iam::111122223333:user/dev", "arn:aws:iam::111122223333:role/Jump", "sts:AssumeRole", true, allow)
+	summary.AddPermission("arn:aws:iam::111122223333:role/Jump", "arn:aws:iam::111122223333:role/Admin", "sts:AssumeRole", true, allow)
+	summary.AddPermission("arn:aws:iam::444455556666:root", "arn:aws:iam::111122223333:role/Admin", "sts:AssumeRole", true, allow)
+	// Admin can assume Jump back; the cycle adds nobody
+	summary.AddPermission("arn:aws:iam::111122223333:role/Admin", "arn:aws:iam::111122223333:role/Jump", "sts:AssumeRole", true, allow)
+	summary.AddPermission("ec2.amazonaws.com", "arn:aws:iam::111122223333:role/Deployer", "sts:AssumeRole", true, allow)
+	// Denied and unrelated actions are not assume edges
+	summary.AddPermission("arn:aws:iam::111122223333:user/dev", "arn:aws:iam::111122223333:role/Deployer", "sts:AssumeRole", false, &EvaluationResult{})
+	summary.AddPermission("arn:aws:iam::111122223333:user/dev", "arn:aws:iam::111122223333:role/Deployer", "iam:PassRole", true, allow)
+
+	report := FindAdminRoleAssumers(&gaad, summary, graph.DefaultMaxHops)
+	require.Len(t, report, 2, "boundary-capped and conditional roles are not admin-equivalent")
+
+	admin := report[0]
+	assert.Equal(t, "arn:aws:iam::111122223333:role/Admin", admin.RoleArn)
+	assert.Equal(t, "managed policy AdministratorAccess", admin.AdminReason)
+	assert.Equal(t, 2, admin.DirectCount)
+	assert.Equal(t, 1, admin.TransitiveCount)
+	assert.Equal(t, 1, admin.ExternalCount)
+	assert.Equal(t, []string{"111122223333", "444455556666"}, admin.AssumerAccounts)
+	assert.False(t, admin.HopsCapped)
+
+	byPrincipal := make(map[string]RoleAssumer)
+	for _, assumer := range admin.Assumers {
+		byPrincipal[assumer.Principal] = assumer
+	}
+	external := byPrincipal["arn:aws:iam::444455556666:root"]
+	assert.True(t, external.External)
+	assert.Equal(t, "account", external.Type)
+	dev := byPrincipal["arn:aws:iam::111122223333:user/dev"]
+	assert.False(t, dev.External)
+	assert.Equal(t, 2, dev.Hops)
+	assert.Equal(t, []string{
+		"arn:aws:iam::111122223333:user/dev",
+		"arn:aws:iam::111122223333:role/Jump",
+		"arn:aws:iam::111122223333:role/Admin",
+	}, dev.Path)
+
+	deployer := report[1]
+	assert.Equal(t, "arn:aws:iam::111122223333:role/Deployer", deployer.RoleArn)
+	assert.Equal(t, "inline policy iam-everything", deployer.AdminReason)
+	require.Len(t, deployer.Assumers, 1)
+	assert.Equal(t, "service", deployer.Assumers[0].Type)
+	assert.False(t, deployer.Assumers[0].External)
+
+	capped := FindAdminRoleAssumers(&gaad, summary, 1)
+	assert.Equal(t, "arn:aws:iam::111122223333:role/Admin", capped[0].RoleArn)
+	assert.Equal(t, 0, capped[0].TransitiveCount)
+	assert.True(t, capped[0].HopsCapped, "dev is two hops from Admin")
+	assert.False(t, capped[1].HopsCapped)
+}
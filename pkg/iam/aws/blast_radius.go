@@ -0,0 +1,275 @@
+package aws
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+	"github.com/praetorian-inc/nebula/pkg/graph"
+	"github.com/praetorian-inc/nebula/pkg/types"
+)
+
+// Ways a compromised principal reaches another principal
+const (
+	BlastRadiusStart              = "start"
+	BlastRadiusGroupMembership    = "group-membership"
+	BlastRadiusAssumeRole         = "assume-role"
+	BlastRadiusUpdateTrust        = "update-trust"
+	BlastRadiusCreateAccessKey    = "create-access-key"
+	BlastRadiusCreateLoginProfile = "create-login-profile"
+	BlastRadiusUpdateLoginProfile = "update-login-profile"
+)
+
+// blastRadiusPivot is an action that, allowed on another principal, lets the
+// holder act as that principal
+type blastRadiusPivot struct {
+	action     string
+	targetType string
+	method     string
+}
+
+// blastRadiusPivots are the privilege escalation primitives followed from each
+// reached principal. Rewriting a role's trust policy lets the holder trust
+// itself and then assume the role; new access keys or console passwords for a
+// user are that user's credentials.
+var blastRadiusPivots = []blastRadiusPivot{
+	{action: "sts:AssumeRole", targetType: "role", method: BlastRadiusAssumeRole},
+	{action: "iam:UpdateAssumeRolePolicy", targetType: "role", method: BlastRadiusUpdateTrust},
+	{action: "iam:CreateAccessKey", targetType: "user", method: BlastRadiusCreateAccessKey},
+	{action: "iam:CreateLoginProfile", targetType: "user", method: BlastRadiusCreateLoginProfile},
+	{action: "iam:UpdateLoginProfile", targetType: "user", method: BlastRadiusUpdateLoginProfile},
+}
+
+// BlastRadiusPrincipal is a principal the starting identity can act as, with
+// the shortest chain of pivots that gets there
+type BlastRadiusPrincipal struct {
+	Principal string   `json:"principal"`
+	Type      string   `json:"type"`
+	AccountID string   `json:"account_id,omitempty"`
+	Method    string   `json:"method"`
+	Hops      int      `json:"hops"`
+	Path      []string `json:"path"`
+	Methods   []string `json:"methods"`
+}
+
+// BlastRadiusResource is a resource reachable from the starting identity, with
+// the actions allowed on it and the reached principals that are allowed them
+type BlastRadiusResource struct {
+	Resource   string   `json:"resource"`
+	Actions    []string `json:"actions"`
+	Principals []string `json:"principals"`
+}
+
+// BlastRadius is everything reachable from one compromised principal
+type BlastRadius struct {
+	Start          string                 `json:"start"`
+	StartType      string                 `json:"start_type"`
+	AccountID      string                 `json:"account_id,omitempty"`
+	Admin          bool                   `json:"admin"`
+	AdminReason    string                 `json:"admin_reason,omitempty"`
+	AdminPath      []string               `json:"admin_path,omitempty"`
+	PrincipalCount int                    `json:"principal_count"`
+	ResourceCount  int                    `json:"resource_count"`
+	ActionCount    int                    `json:"action_count"`
+	MaxHops        int                    `json:"max_hops"`
+	HopsCapped     bool                   `json:"hops_capped,omitempty"`
+	Accounts       []string               `json:"accounts"`
+	Principals     []BlastRadiusPrincipal `json:"principals"`
+	Resources      []BlastRadiusResource  `json:"resources"`
+}
+
+// FindBlastRadius walks forward from start through group memberships and the
+// pivots in blastRadiusPivots, and collects every resource and action the
+// reached principals are allowed in the summary. The walk is breadth first, so
+// each principal is reported with its shortest path. The first reached
+// principal that is an effective admin makes the whole start admin-equivalent;
+// self-escalation is taken from summary.EffectiveAdmins. An assumed-role
+// session ARN starts from its role. Principals more than maxHops pivots away
+// are not reached, and HopsCapped marks a report that was cut short.
+func FindBlastRadius(gaad *types.Gaad, summary *PermissionsSummary, start string, maxHops int) (*BlastRadius, error) {
+	if gaad == nil || summary == nil {
+		return nil, fmt.Errorf("GAAD data and a permissions summary are required")
+	}
+	start, err := blastRadiusStart(gaad, start)
+	if err != nil {
+		return nil, err
+	}
+
+	groups := make(map[string]types.GroupDL, len(gaad.GroupDetailList))
+	for _, group := range gaad.GroupDetailList {
+		groups[group.GroupName] = group
+	}
+	userGroups := make(map[string][]string, len(gaad.UserDetailList))
+	for _, user := range gaad.UserDetailList {
+		userGroups[user.Arn] = user.GroupList
+	}
+	admins := make(map[string]EffectiveAdmin, len(summary.EffectiveAdmins))
+	for _, admin := range summary.EffectiveAdmins {
+		// Assume-role chains are followed by the walk itself
+		if admin.Source != AdminSourceAssumeRole {
+			admins[admin.Principal] = admin
+		}
+	}
+	pivots := blastRadiusEdges(summary)
+
+	report := &BlastRadius{
+		Start:      start,
+		StartType:  assumerType(start),
+		AccountID:  accountFromArn(start),
+		Principals: make([]BlastRadiusPrincipal, 0),
+		Resources:  make([]BlastRadiusResource, 0),
+	}
+	walk := graph.Walk(start, func(principal string) []graph.Step[string] {
+		// Group permissions are already part of the user's evaluation; groups
+		// are listed so the report shows every identity the start acts
+		// through, but are not pivoted from
+		if strings.Contains(principal, ":group/") {
+			return nil
+		}
+		var steps []graph.Step[string]
+		for _, groupName := range userGroups[principal] {
+			if group, ok := groups[groupName]; ok {
+				steps = append(steps, graph.Step[string]{To: group.Arn, Via: BlastRadiusGroupMembership})
+			}
+		}
+		for _, edge := range pivots[principal] {
+			steps = append(steps, graph.Step[string]{To: edge.target, Via: edge.method})
+		}
+		return steps
+	}, maxHops)
+	report.MaxHops = walk.MaxHops
+	report.HopsCapped = walk.Capped
+
+	accounts := make(map[string]bool)
+	resources := make(map[string]*blastRadiusAccess)
+	for _, visit := range walk.Visits {
+		principal := visit.Node
+		if admin, ok := admins[principal]; ok && !report.Admin {
+			report.Admin = true
+			report.AdminReason = fmt.Sprintf("%s is admin-equivalent (%s)", principal, admin.Reason)
+			report.AdminPath = visit.Path
+		}
+		if accountID := accountFromArn(principal); accountID != "" {
+			accounts[accountID] = true
+		}
+		if principal != start {
+			report.Principals = append(report.Principals, BlastRadiusPrincipal{
+				Principal: principal,
+				Type:      assumerType(principal),
+				AccountID: accountFromArn(principal),
+				Method:    visit.Via[len(visit.Via)-1],
+				Hops:      visit.Hops,
+				Path:      visit.Path,
+				Methods:   visit.Via,
+			})
+		}
+		value, ok := summary.Permissions.Load(principal)
+		if !ok {
+			continue
+		}
+		value.(*PrincipalPermissions).ResourcePerms.Range(func(key, resValue any) bool {
+			resource := key.(string)
+			rp := resValue.(*ResourcePermission)
+			rp.mu.RLock()
+			defer rp.mu.RUnlock()
+			if len(rp.AllowedActions) == 0 {
+				return true
+			}
+			access, ok := resources[resource]
+			if !ok {
+				access = &blastRadiusAccess{actions: make(map[string]bool), principals: make(map[string]bool)}
+				resources[resource] = access
+			}
+			for _, action := range rp.AllowedActions {
+				access.actions[action.Name] = true
+			}
+			access.principals[principal] = true
+			return true
+		})
+	}
+
+	for resource, access := range resources {
+		report.Resources = append(report.Resources, BlastRadiusResource{
+			Resource:   resource,
+			Actions:    sortedKeys(access.actions),
+			Principals: sortedKeys(access.principals),
+		})
+		report.ActionCount += len(access.actions)
+		if accountID := accountFromArn(resource); accountID != "" {
+			accounts[accountID] = true
+		}
+	}
+	sort.Slice(report.Resources, func(i, j int) bool {
+		return report.Resources[i].Resource < report.Resources[j].Resource
+	})
+	report.PrincipalCount = len(report.Principals)
+	report.ResourceCount = len(report.Resources)
+	report.Accounts = sortedKeys(accounts)
+	return report, nil
+}
+
+// blastRadiusAccess gathers the actions on one resource across the reached
+// principals
+type blastRadiusAccess struct {
+	actions    map[string]bool
+	principals map[string]bool
+}
+
+// blastRadiusEdge is one pivot from a principal to the principal it can act as
+type blastRadiusEdge struct {
+	target string
+	method string
+}
+
+// blastRadiusEdges maps each principal to the principals it can pivot to, in
+// the order of blastRadiusPivots and then by target ARN
+func blastRadiusEdges(summary *PermissionsSummary) map[string][]blastRadiusEdge {
+	edges := make(map[string][]blastRadiusEdge)
+	summary.Permissions.Range(func(key, value any) bool {
+		principalArn := key.(string)
+		perms := value.(*PrincipalPermissions)
+		var targets []string
+		perms.ResourcePerms.Range(func(resKey, _ any) bool {
+			if target := resKey.(string); target != principalArn {
+				targets = append(targets, target)
+			}
+			return true
+		})
+		sort.Strings(targets)
+		for _, pivot := range blastRadiusPivots {
+			for _, target := range targets {
+				if assumerType(target) == pivot.targetType && hasAllowedActionOnResource(perms, pivot.action, target) {
+					edges[principalArn] = append(edges[principalArn], blastRadiusEdge{target: target, method: pivot.method})
+				}
+			}
+		}
+		return true
+	})
+	return edges
+}
+
+// blastRadiusStart checks that start is a user or role in the GAAD, resolving
+// an assumed-role session ARN to its role
+func blastRadiusStart(gaad *types.Gaad, start string) (string, error) {
+	if parsed, err := arn.Parse(start); err == nil && strings.HasPrefix(parsed.Resource, "assumed-role/") {
+		roleName := strings.Split(strings.TrimPrefix(parsed.Resource, "assumed-role/"), "/")[0]
+		for _, role := range gaad.RoleDetailList {
+			if role.RoleName == roleName && accountFromArn(role.Arn) == parsed.AccountID {
+				return role.Arn, nil
+			}
+		}
+		return "", fmt.Errorf("role %s of session %s is not in the GAAD", roleName, start)
+	}
+	for _, user := range gaad.UserDetailList {
+		if user.Arn == start {
+			return start, nil
+		}
+	}
+	for _, role := range gaad.RoleDetailList {
+		if role.Arn == start {
+			return start, nil
+		}
+	}
+	return "", fmt.Errorf("start principal %s is not a user or role in the GAAD", start)
+}
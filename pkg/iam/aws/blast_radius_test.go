@@ -0,0 +1,91 @@
+package aws
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/praetorian-inc/nebula/pkg/graph"
+	"github.com/praetorian-inc/nebula/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const blastRadiusGaad = `{
+  "UserDetailList": [
+    {"Arn": "arn:aws:iam::111122223333:user/leaked", "UserName": "leaked", "GroupList": ["Developers"]},
+    {"Arn": "arn:aws:iam::111122223333:user/ci", "UserName": "ci"},
+    {"Arn": "arn:aws:iam::111122223333:user/bystander", "UserName": "bystander"}
+  ],
+  "RoleDetailList": [
+    {"Arn": "arn:aws:iam::111122223333:role/Deploy", "RoleName": "Deploy"},
+    {"Arn": "arn:aws:iam::111122223333:role/Data", "RoleName": "Data"},
+    {
+      "Arn": "arn:aws:iam::444455556666:role/Admin",
+      "RoleName": "Admin",
+      "AttachedManagedPolicies": [{"PolicyName": "AdministratorAccess", "PolicyArn": "arn:aws:iam::aws:policy/AdministratorAccess"}]
+    }
+  ],
+  "GroupDetailList": [{"GroupName": "Developers", "Arn": "arn:aws:iam::111122223333:group/Developers"}]
+}`
+
+func TestFindBlastRadius(t *testing.T) {
+	var gaad types.Gaad
+	require.NoError(t, json.Unmarshal([]byte(blastRadiusGaad), &gaad))
+
+	allow := &EvaluationResult{Allowed: true}
+	summary := NewPermissionsSummary()
+	summary.AddPermission("arn:aws:iam::111122223333:user/leaked", "arn:aws:s3:::source-bucket", "s3:GetObject", true, allow)
+	summary.AddPermission("arn:aws:iam::111122223333:user/leaked", "arn:aws:iam::111122223333:role/Deploy", "sts:AssumeRole", true, allow)
+	summary.AddPermission("arn:aws:iam::111122223333:user/leaked", "arn:aws:iam::111122223333:user/ci", "iam:CreateAccessKey", true, allow)
+	summary.AddPermission("arn:aws:iam::111122223333:role/Deploy", "arn:aws:s3:::source-bucket", "s3:PutObject", true, allow)
+	summary.AddPermission("arn:aws:iam::111122223333:role/Deploy", "arn:aws:iam::111122223333:role/Data", "iam:UpdateAssumeRolePolicy", true, allow)
+	summary.AddPermission("arn:aws:iam::111122223333:role/Data", "arn:aws:iam::444455556666:role/Admin", "sts:AssumeRole", true, allow)
+	summary.AddPermission("arn:aws:iam::111122223333:role/Data", "arn:aws:dynamodb:us-east-1:111122223333:table/orders", "dynamodb:Scan", true, allow)
+	// Denied actions and principals nobody can reach stay out of the report
+	summary.AddPermission("arn:aws:iam::111122223333:user/ci", "arn:aws:s3:::source-bucket", "s3:DeleteObject", false, &EvaluationResult{})
+	summary.AddPermission("arn:aws:iam::111122223333:user/bystander", "arn:aws:s3:::other-bucket", "s3:GetObject", true, allow)
+	summary.EffectiveAdmins, _ = FindEffectiveAdmins(&gaad, summary, DefaultEffectiveAdminCriteria, graph.DefaultMaxHops)
+
+	report, err := FindBlastRadius(&gaad, summary, "arn:aws:iam::111122223333:user/leaked", graph.DefaultMaxHops)
+	require.NoError(t, err)
+	assert.False(t, report.HopsCapped)
+
+	byPrincipal := make(map[string]BlastRadiusPrincipal)
+	for _, principal := range report.Principals {
+		byPrincipal[principal.Principal] = principal
+	}
+	require.Len(t, report.Principals, 5)
+	assert.Equal(t, BlastRadiusGroupMembership, byPrincipal["arn:aws:iam::111122223333:group/Developers"].Method)
+	assert.Equal(t, BlastRadiusCreateAccessKey, byPrincipal["arn:aws:iam::111122223333:user/ci"].Method)
+	assert.NotContains(t, byPrincipal, "arn:aws:iam::111122223333:user/bystander")
+
+	admin := byPrincipal["arn:aws:iam::444455556666:role/Admin"]
+	assert.Equal(t, 3, admin.Hops)
+	assert.Equal(t, []string{BlastRadiusAssumeRole, BlastRadiusUpdateTrust, BlastRadiusAssumeRole}, admin.Methods)
+
+	assert.True(t, report.Admin)
+	assert.Equal(t, admin.Path, report.AdminPath)
+	assert.Equal(t, []string{"111122223333", "444455556666"}, report.Accounts)
+
+	require.Len(t, report.Resources, 6)
+	bucket := report.Resources[len(report.Resources)-1]
+	assert.Equal(t, "arn:aws:s3:::source-bucket", bucket.Resource)
+	assert.Equal(t, []string{"s3:GetObject", "s3:PutObject"}, bucket.Actions)
+	assert.Equal(t, []string{"arn:aws:iam::111122223333:role/Deploy", "arn:aws:iam::111122223333:user/leaked"}, bucket.Principals)
+	assert.Equal(t, 7, report.ActionCount)
+
+	session, err := FindBlastRadius(&gaad, summary, "arn:aws:sts::111122223333:assumed-role/Data/incident", graph.DefaultMaxHops)
+	require.NoError(t, err)
+	assert.Equal(t, "arn:aws:iam::111122223333:role/Data", session.Start)
+	assert.Equal(t, []string{"arn:aws:iam::111122223333:role/Data", "arn:aws:iam::444455556666:role/Admin"}, session.AdminPath)
+
+	_, err = FindBlastRadius(&gaad, summary, "arn:aws:iam::111122223333:user/unknown", graph.DefaultMaxHops)
+	assert.Error(t, err)
+
+	capped, err := FindBlastRadius(&gaad, summary, "arn:aws:iam::111122223333:user/leaked", 2)
+	require.NoError(t, err)
+	assert.True(t, capped.HopsCapped)
+	assert.Equal(t, 2, capped.MaxHops)
+	assert.Len(t, capped.Principals, 4)
+	assert.False(t, capped.Admin, "the admin role is three hops away")
+}
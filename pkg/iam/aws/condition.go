@@ -137,6 +137,50 @@ func evaluateConditions(conditions *types.Condition, ctx *RequestContext) *Condi
 	return eval
 }
 
+// satisfiableByCaller reports whether every failed key is a request property
+// the caller picks for each request, such as using MFA, the source IP or an
+// AssumeRole external ID, and
+// that the context leaves unset. Account data cannot say how a principal will
+// call, so an Allow gated only on such keys is reachable; the failed keys are
+// marked inconclusive.
+func (c *ConditionEval) satisfiableByCaller(ctx *RequestContext) bool {
+	if c.Result != ConditionFailed {
+		return false
+	}
+	for _, keyResult := range c.KeyResults {
+		if keyResult.Result == ConditionFailed && !callerChosenKeyUnset(keyResult.Key, ctx) {
+			return false
+		}
+	}
+	for key, keyResult := range c.KeyResults {
+		if keyResult.Result == ConditionFailed {
+			keyResult.Result = ConditionInconclusive
+			c.KeyResults[key] = keyResult
+		}
+	}
+	c.Result = ConditionInconclusive
+	return true
+}
+
+// callerChosenKeyUnset reports whether key is a per-request property that the
+// context does not set
+func callerChosenKeyUnset(key string, ctx *RequestContext) bool {
+	switch strings.ToLower(key) {
+	case "aws:multifactorauthpresent":
+		return ctx == nil || ctx.MultiFactorAuthPresent == nil
+	case "aws:multifactorauthage":
+		return ctx == nil || ctx.MultiFactorAuthAge == 0
+	case "aws:sourceip":
+		return ctx == nil || ctx.SourceIP == ""
+	case "aws:securetransport":
+		return ctx == nil || ctx.SecureTransport == nil
+	case "sts:externalid":
+		// Set by whoever calls AssumeRole; knowing it is the caller's problem
+		return !doesContextValueExist(key, ctx)
+	}
+	return false
+}
+
 // Helper function to identify condition keys that should default to inconclusive
 func isCriticalConditionKey(key string) bool {
 	criticalKeys := map[string]bool{
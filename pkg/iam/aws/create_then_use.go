@@ -79,10 +79,11 @@ func applyCreateThenUseEdges(summary *PermissionsSummary) {
 				)
 
 				syntheticResult := &EvaluationResult{
-					Allowed:           true,
-					PolicyResult:      NewPolicyResult(),
-					EvaluationDetails: "Synthetic: create-then-use pattern — principal controls resource name",
-					Action:            Action(useAction),
+					Allowed:                  true,
+					PolicyResult:             NewPolicyResult(),
+					EvaluationDetails:        "Synthetic: create-then-use pattern — principal controls resource name",
+					Action:                   Action(useAction),
+					SessionPolicyUnaccounted: sessionPoliciesMayApply(principalArn),
 				}
 				summary.AddPermission(principalArn, pair.serviceResource, useAction, true, syntheticResult)
 			}
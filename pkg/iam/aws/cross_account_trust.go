@@ -0,0 +1,155 @@
+package aws
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/praetorian-inc/nebula/pkg/types"
+)
+
+// CrossAccountTrust is a resource whose policy grants access to principals in
+// another AWS account. Mutual is set when a policy in that account grants
+// access back to the resource's account; it can only be set for foreign
+// accounts whose GAAD or resources were part of the input, which
+// ForeignAccountAnalyzed reports.
+type CrossAccountTrust struct {
+	ResourceArn            string                             `json:"resource_arn"`
+	Resource               *types.EnrichedResourceDescription `json:"resource"`
+	ResourceAccount        string                             `json:"resource_account"`
+	ForeignAccount         string                             `json:"foreign_account"`
+	Principals             []string                           `json:"principals"`
+	Actions                []string                           `json:"actions"`
+	Conditional            bool                               `json:"conditional"`
+	Mutual                 bool                               `json:"mutual"`
+	ForeignAccountAnalyzed bool                               `json:"foreign_account_analyzed"`
+}
+
+// FindCrossAccountTrusts lists every resource policy, including role trust
+// policies, with an Allow statement naming a principal in an account other
+// than the resource's own. Public "*" principals are left to the public
+// access findings. Trusts are ordered by resource, then foreign account.
+func FindCrossAccountTrusts(pd *PolicyData) []CrossAccountTrust {
+	resources := make(map[string]*types.EnrichedResourceDescription)
+	analyzed := make(map[string]bool)
+	if pd.Resources != nil {
+		for i := range *pd.Resources {
+			resource := &(*pd.Resources)[i]
+			resources[resource.Arn.String()] = resource
+			analyzed[resource.AccountId] = true
+		}
+	}
+	if pd.Gaad != nil {
+		for _, role := range pd.Gaad.RoleDetailList {
+			analyzed[getAccountFromArn(role.Arn)] = true
+		}
+		for _, user := range pd.Gaad.UserDetailList {
+			analyzed[getAccountFromArn(user.Arn)] = true
+		}
+	}
+	delete(analyzed, "")
+
+	trusts := make([]CrossAccountTrust, 0)
+	for resourceArn, policy := range pd.ResourcePolicies {
+		if policy == nil || policy.Statement == nil {
+			continue
+		}
+		resource := crossAccountTrustResource(resourceArn, resources[resourceArn])
+		if resource == nil || resource.AccountId == "" {
+			continue
+		}
+
+		byAccount := make(map[string]*CrossAccountTrust)
+		for _, stmt := range *policy.Statement {
+			if !strings.EqualFold(stmt.Effect, "Allow") || stmt.Principal == nil || stmt.Principal.AWS == nil {
+				continue
+			}
+			for _, principal := range *stmt.Principal.AWS {
+				account := principalAccount(principal)
+				if account == "" || account == resource.AccountId {
+					continue
+				}
+				trust, ok := byAccount[account]
+				if !ok {
+					trust = &CrossAccountTrust{
+						ResourceArn:     resourceArn,
+						Resource:        resource,
+						ResourceAccount: resource.AccountId,
+						ForeignAccount:  account,
+					}
+					byAccount[account] = trust
+				}
+				trust.Principals = append(trust.Principals, principal)
+				trust.Actions = append(trust.Actions, statementActions(stmt)...)
+				trust.Conditional = trust.Conditional || (stmt.Condition != nil && len(*stmt.Condition) > 0)
+			}
+		}
+		for _, trust := range byAccount {
+			trust.Principals = uniqueSorted(trust.Principals)
+			trust.Actions = uniqueSorted(trust.Actions)
+			trust.ForeignAccountAnalyzed = analyzed[trust.ForeignAccount]
+			trusts = append(trusts, *trust)
+		}
+	}
+
+	// A trust is mutual when the foreign account has a trust of its own back
+	trusted := make(map[[2]string]bool)
+	for _, trust := range trusts {
+		trusted[[2]string{trust.ResourceAccount, trust.ForeignAccount}] = true
+	}
+	for i := range trusts {
+		trusts[i].Mutual = trusted[[2]string{trusts[i].ForeignAccount, trusts[i].ResourceAccount}]
+	}
+
+	sort.Slice(trusts, func(i, j int) bool {
+		if trusts[i].ResourceArn != trusts[j].ResourceArn {
+			return trusts[i].ResourceArn < trusts[j].ResourceArn
+		}
+		return trusts[i].ForeignAccount < trusts[j].ForeignAccount
+	})
+	return trusts
+}
+
+// crossAccountTrustResource returns the inventory entry for a resource, or
+// one built from its ARN. S3 bucket ARNs carry no account, so buckets missing
+// from the inventory cannot be placed in an account.
+func crossAccountTrustResource(resourceArn string, known *types.EnrichedResourceDescription) *types.EnrichedResourceDescription {
+	if known != nil {
+		// The report only needs to identify the resource
+		resource := *known
+		resource.Properties = nil
+		return &resource
+	}
+	resource, err := types.NewEnrichedResourceDescriptionFromArn(resourceArn)
+	if err != nil {
+		return nil
+	}
+	return &resource
+}
+
+// principalAccount returns the account of an AWS principal given as an
+// account ID or an IAM or STS ARN, or "" for wildcards and unique IDs
+func principalAccount(principal string) string {
+	if len(principal) == 12 && isNumeric(principal) {
+		return principal
+	}
+	account := getAccountFromArn(principal)
+	if len(account) != 12 || !isNumeric(account) {
+		return ""
+	}
+	return account
+}
+
+// statementActions lists the actions a statement allows, with NotAction
+// statements shown as the actions they exclude
+func statementActions(stmt types.PolicyStatement) []string {
+	var actions []string
+	if stmt.Action != nil {
+		actions = append(actions, *stmt.Action...)
+	}
+	if stmt.NotAction != nil {
+		for _, action := range *stmt.NotAction {
+			actions = append(actions, "NotAction:"+action)
+		}
+	}
+	return actions
+}
@@ -0,0 +1,88 @@
+package aws
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/praetorian-inc/nebula/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const crossAccountTrustGaad = `{
+  "RoleDetailList": [
+    {
+      "Arn": "arn:aws:iam::111122223333:role/vendor-audit",
+      "AssumeRolePolicyDocument": {"Version": "2012-10-17", "Statement": [
+        {"Effect": "Allow", "Action": "sts:AssumeRole", "Principal": {"AWS": "arn:aws:iam::999988887777:root"},
+         "Condition": {"StringEquals": {"sts:ExternalId": "abc"}}},
+        {"Effect": "Allow", "Action": "sts:AssumeRole", "Principal": {"Service": "ec2.amazonaws.com"}}
+      ]}
+    },
+    {
+      "Arn": "arn:aws:iam::111122223333:role/local-only",
+      "AssumeRolePolicyDocument": {"Version": "2012-10-17", "Statement": [
+        {"Effect": "Allow", "Action": "sts:AssumeRole", "Principal": {"AWS": "arn:aws:iam::111122223333:root"}}
+      ]}
+    },
+    {
+      "Arn": "arn:aws:iam::444455556666:role/deploy",
+      "AssumeRolePolicyDocument": {"Version": "2012-10-17", "Statement": [
+        {"Effect": "Allow", "Action": "sts:AssumeRole", "Principal": {"AWS": "arn:aws:iam::111122223333:role/pipeline"}}
+      ]}
+    }
+  ]
+}`
+
+const crossAccountTrustResourcePolicies = `{
+  "arn:aws:s3:::shared-data": {"Version": "2012-10-17", "Statement": [
+    {"Effect": "Allow", "Action": ["s3:GetObject", "s3:ListBucket"], "Principal": {"AWS": ["444455556666", "*"]}, "Resource": "*"},
+    {"Effect": "Allow", "Action": "s3:PutObject", "Principal": {"AWS": "arn:aws:iam::444455556666:role/deploy"}, "Resource": "*"},
+    {"Effect": "Deny", "Action": "s3:DeleteObject", "Principal": {"AWS": "arn:aws:iam::999988887777:root"}, "Resource": "*"}
+  ]},
+  "arn:aws:s3:::unknown-bucket": {"Version": "2012-10-17", "Statement": [
+    {"Effect": "Allow", "Action": "s3:GetObject", "Principal": {"AWS": "444455556666"}, "Resource": "*"}
+  ]}
+}`
+
+func TestFindCrossAccountTrusts(t *testing.T) {
+	var gaad types.Gaad
+	require.NoError(t, json.Unmarshal([]byte(crossAccountTrustGaad), &gaad))
+	resourcePolicies := make(map[string]*types.Policy)
+	require.NoError(t, json.Unmarshal([]byte(crossAccountTrustResourcePolicies), &resourcePolicies))
+	resources := []types.EnrichedResourceDescription{
+		types.NewEnrichedResourceDescription("shared-data", "AWS::S3::Bucket", "us-east-1", "111122223333", map[string]any{"large": "properties"}),
+	}
+
+	pd := NewPolicyData(&gaad, nil, resourcePolicies, &resources)
+	pd.AddResourcePolicies()
+
+	trusts := FindCrossAccountTrusts(pd)
+	require.Len(t, trusts, 3, "the bucket missing from the inventory has no account and is skipped")
+
+	vendor := trusts[0]
+	assert.Equal(t, "arn:aws:iam::111122223333:role/vendor-audit", vendor.ResourceArn)
+	assert.Equal(t, "AWS::IAM::Role", vendor.Resource.TypeName)
+	assert.Equal(t, "999988887777", vendor.ForeignAccount)
+	assert.Equal(t, []string{"sts:AssumeRole"}, vendor.Actions)
+	assert.True(t, vendor.Conditional)
+	assert.False(t, vendor.Mutual)
+	assert.False(t, vendor.ForeignAccountAnalyzed)
+
+	deploy := trusts[1]
+	assert.Equal(t, "arn:aws:iam::444455556666:role/deploy", deploy.ResourceArn)
+	assert.Equal(t, "111122223333", deploy.ForeignAccount)
+	assert.Equal(t, []string{"arn:aws:iam::111122223333:role/pipeline"}, deploy.Principals)
+	assert.True(t, deploy.Mutual, "the bucket in 111122223333 trusts 444455556666 back")
+
+	bucket := trusts[2]
+	assert.Equal(t, "arn:aws:s3:::shared-data", bucket.ResourceArn)
+	assert.Equal(t, "111122223333", bucket.ResourceAccount)
+	assert.Nil(t, bucket.Resource.Properties)
+	assert.Equal(t, "444455556666", bucket.ForeignAccount)
+	assert.Equal(t, []string{"444455556666", "arn:aws:iam::444455556666:role/deploy"}, bucket.Principals)
+	assert.Equal(t, []string{"s3:GetObject", "s3:ListBucket", "s3:PutObject"}, bucket.Actions)
+	assert.False(t, bucket.Conditional)
+	assert.True(t, bucket.Mutual)
+	assert.True(t, bucket.ForeignAccountAnalyzed)
+}
@@ -0,0 +1,213 @@
+package aws
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/praetorian-inc/nebula/pkg/types"
+)
+
+// Sources of admin equivalence, from most to least direct
+const (
+	AdminSourcePolicy         = "policy"
+	AdminSourceGroup          = "group"
+	AdminSourceSelfEscalation = "self-escalation"
+	AdminSourceAssumeRole     = "assume-role"
+)
+
+// EffectiveAdminCriteria defines when a principal that is not granted admin
+// outright still counts as admin-equivalent: it must be allowed at least
+// Threshold of Actions on itself, its groups, or the customer managed policies
+// attached to either, since any one of those lets it grant itself "*".
+type EffectiveAdminCriteria struct {
+	Actions   []string
+	Threshold int
+}
+
+// DefaultEffectiveAdminCriteria treats any single self-targeted policy write
+// as admin-equivalent
+var DefaultEffectiveAdminCriteria = EffectiveAdminCriteria{
+	Actions: []string{
+		"iam:AttachUserPolicy",
+		"iam:PutUserPolicy",
+		"iam:AttachGroupPolicy",
+		"iam:PutGroupPolicy",
+		"iam:AttachRolePolicy",
+		"iam:PutRolePolicy",
+		"iam:CreatePolicyVersion",
+	},
+	Threshold: 1,
+}
+
+// EffectiveAdmin is a principal whose effective permissions are
+// admin-equivalent, with the shortest way it gets there
+type EffectiveAdmin struct {
+	Principal string   `json:"principal"`
+	Type      string   `json:"type"`
+	AccountID string   `json:"account_id,omitempty"`
+	Source    string   `json:"source"`
+	Reason    string   `json:"reason"`
+	Path      []string `json:"path"`
+}
+
+// FindEffectiveAdmins lists every principal that is admin-equivalent, however
+// it gets there: an admin policy on the principal, an admin policy on one of
+// its groups, self-targeted policy writes that meet the criteria, or a chain of
+// sts:AssumeRole hops to any of those. Permissions boundaries that do not
+// themselves grant admin cap the policy sources. Policy-based admins sort
+// first, then self-escalation, then assume-role chains by hop count. Chains
+// longer than maxHops are not followed; capped reports whether any were cut.
+func FindEffectiveAdmins(gaad *types.Gaad, summary *PermissionsSummary, criteria EffectiveAdminCriteria, maxHops int) (report []EffectiveAdmin, capped bool) {
+	if gaad == nil || summary == nil {
+		return nil, false
+	}
+	if criteria.Threshold < 1 {
+		criteria.Threshold = 1
+	}
+
+	managed := make(map[string]*types.PoliciesDL, len(gaad.Policies))
+	for i := range gaad.Policies {
+		managed[gaad.Policies[i].Arn] = &gaad.Policies[i]
+	}
+	groups := make(map[string]types.GroupDL, len(gaad.GroupDetailList))
+	for _, group := range gaad.GroupDetailList {
+		groups[group.GroupName] = group
+	}
+
+	admins := make(map[string]EffectiveAdmin)
+	add := func(principal, source, reason string, path []string) {
+		if _, ok := admins[principal]; ok {
+			return
+		}
+		admins[principal] = EffectiveAdmin{
+			Principal: principal,
+			Type:      assumerType(principal),
+			AccountID: accountFromArn(principal),
+			Source:    source,
+			Reason:    reason,
+			Path:      path,
+		}
+	}
+
+	for _, user := range gaad.UserDetailList {
+		if boundaryLimitsAdmin(user.PermissionsBoundary.PolicyArn, managed) {
+			continue
+		}
+		if reason := policiesAdminReason(user.UserPolicyList, user.AttachedManagedPolicies, managed); reason != "" {
+			add(user.Arn, AdminSourcePolicy, reason, []string{user.Arn})
+			continue
+		}
+		for _, groupName := range user.GroupList {
+			group, ok := groups[groupName]
+			if !ok {
+				continue
+			}
+			if reason := policiesAdminReason(group.GroupPolicyList, group.AttachedManagedPolicies, managed); reason != "" {
+				add(user.Arn, AdminSourceGroup, fmt.Sprintf("%s on group %s", reason, groupName), []string{user.Arn, group.Arn})
+				break
+			}
+		}
+	}
+	for _, role := range gaad.RoleDetailList {
+		if reason := roleAdminReason(role, managed); reason != "" {
+			add(role.Arn, AdminSourcePolicy, reason, []string{role.Arn})
+		}
+	}
+
+	for _, user := range gaad.UserDetailList {
+		targets := []string{user.Arn}
+		targets = append(targets, customerManagedPolicyArns(user.AttachedManagedPolicies)...)
+		for _, groupName := range user.GroupList {
+			if group, ok := groups[groupName]; ok {
+				targets = append(targets, group.Arn)
+				targets = append(targets, customerManagedPolicyArns(group.AttachedManagedPolicies)...)
+			}
+		}
+		if actions := selfEscalationActions(summary, user.Arn, targets, criteria); len(actions) > 0 {
+			add(user.Arn, AdminSourceSelfEscalation, "can "+strings.Join(actions, ", ")+" on itself", []string{user.Arn})
+		}
+	}
+	for _, role := range gaad.RoleDetailList {
+		targets := append([]string{role.Arn}, customerManagedPolicyArns(role.AttachedManagedPolicies)...)
+		if actions := selfEscalationActions(summary, role.Arn, targets, criteria); len(actions) > 0 {
+			add(role.Arn, AdminSourceSelfEscalation, "can "+strings.Join(actions, ", ")+" on itself", []string{role.Arn})
+		}
+	}
+
+	// Anyone who can reach an admin role is an admin too. Walking from every
+	// admin role and keeping the fewest hops gives each assumer its shortest path.
+	assumedBy := assumeRoleEdges(summary)
+	var chained []RoleAssumer
+	for principal, admin := range admins {
+		if admin.Type != "role" {
+			continue
+		}
+		assumers, rolesCapped := transitiveAssumers(principal, assumedBy, maxHops)
+		chained = append(chained, assumers...)
+		capped = capped || rolesCapped
+	}
+	sort.SliceStable(chained, func(i, j int) bool {
+		if chained[i].Hops != chained[j].Hops {
+			return chained[i].Hops < chained[j].Hops
+		}
+		return strings.Join(chained[i].Path, ",") < strings.Join(chained[j].Path, ",")
+	})
+	for _, assumer := range chained {
+		target := assumer.Path[len(assumer.Path)-1]
+		add(assumer.Principal, AdminSourceAssumeRole,
+			fmt.Sprintf("assumes %s in %d hops (%s)", target, assumer.Hops, admins[target].Reason), assumer.Path)
+	}
+
+	report = make([]EffectiveAdmin, 0, len(admins))
+	for _, admin := range admins {
+		report = append(report, admin)
+	}
+	sourceRank := map[string]int{AdminSourcePolicy: 0, AdminSourceGroup: 0, AdminSourceSelfEscalation: 1, AdminSourceAssumeRole: 2}
+	sort.SliceStable(report, func(i, j int) bool {
+		if sourceRank[report[i].Source] != sourceRank[report[j].Source] {
+			return sourceRank[report[i].Source] < sourceRank[report[j].Source]
+		}
+		if len(report[i].Path) != len(report[j].Path) {
+			return len(report[i].Path) < len(report[j].Path)
+		}
+		return report[i].Principal < report[j].Principal
+	})
+	return report, capped
+}
+
+// selfEscalationActions returns the criteria actions the principal is allowed
+// on any of targets, or nil when fewer than the threshold are allowed
+func selfEscalationActions(summary *PermissionsSummary, principal string, targets []string, criteria EffectiveAdminCriteria) []string {
+	value, ok := summary.Permissions.Load(principal)
+	if !ok {
+		return nil
+	}
+	perms := value.(*PrincipalPermissions)
+
+	var allowed []string
+	for _, action := range criteria.Actions {
+		for _, target := range targets {
+			if hasAllowedActionOnResource(perms, action, target) {
+				allowed = append(allowed, action)
+				break
+			}
+		}
+	}
+	if len(allowed) < criteria.Threshold {
+		return nil
+	}
+	return allowed
+}
+
+// customerManagedPolicyArns returns the attached policies a principal could
+// rewrite with iam:CreatePolicyVersion; AWS managed policies are read-only
+func customerManagedPolicyArns(attached []types.ManagedPL) []string {
+	var arns []string
+	for _, policy := range attached {
+		if !isAWSManagedPolicyArn(policy.PolicyArn) {
+			arns = append(arns, policy.PolicyArn)
+		}
+	}
+	return arns
+}
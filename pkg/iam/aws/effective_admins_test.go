@@ -0,0 +1,108 @@
+package aws
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/praetorian-inc/nebula/pkg/graph"
+	"github.com/praetorian-inc/nebula/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const effectiveAdminsGaad = `{
+  "UserDetailList": [
+    {
+      "Arn": "arn:aws:iam::111122223333:user/root-ish",
+      "UserName": "root-ish",
+      "AttachedManagedPolicies": [{"PolicyName": "AdministratorAccess", "PolicyArn": "arn:aws:iam::aws:policy/AdministratorAccess"}]
+    },
+    {"Arn": "arn:aws:iam::111122223333:user/ops", "UserName": "ops", "GroupList": ["Admins"]},
+    {
+      "Arn": "arn:aws:iam::111122223333:user/bounded-ops",
+      "UserName": "bounded-ops",
+      "GroupList": ["Admins"],
+      "PermissionsBoundary": {"PolicyName": "ReadOnlyBoundary", "PolicyArn": "arn:aws:iam::111122223333:policy/ReadOnlyBoundary"}
+    },
+    {"Arn": "arn:aws:iam::111122223333:user/self-writer", "UserName": "self-writer"},
+    {"Arn": "arn:aws:iam::111122223333:user/dev", "UserName": "dev"}
+  ],
+  "RoleDetailList": [
+    {
+      "Arn": "arn:aws:iam::111122223333:role/Admin",
+      "RoleName": "Admin",
+      "AttachedManagedPolicies": [{"PolicyName": "AdministratorAccess", "PolicyArn": "arn:aws:iam::aws:policy/AdministratorAccess"}]
+    },
+    {"Arn": "arn:aws:iam::111122223333:role/Jump", "RoleName": "Jump"}
+  ],
+  "GroupDetailList": [{
+    "GroupName": "Admins",
+    "Arn": "arn:aws:iam::111122223333:group/Admins",
+    "GroupPolicyList": [{
+      "PolicyName": "star",
+      "PolicyDocument": {"Version": "2012-10-17", "Statement": [{"Effect": "Allow", "Action": "*", "Resource": "*"}]}
+    }]
+  }],
+  "Policies": [{
+    "Arn": "arn:aws:iam::111122223333:policy/ReadOnlyBoundary",
+    "PolicyName": "ReadOnlyBoundary",
+    "PolicyVersionList": [{"IsDefaultVersion": true, "Document": {"Version": "2012-10-17", "Statement": [
+      {"Effect": "Allow", "Action": "s3:Get*", "Resource": "*"}
+    ]}}]
+  }]
+}`
+
+func TestFindEffectiveAdmins(t *testing.T) {
+	var gaad types.Gaad
+	require.NoError(t, json.Unmarshal([]byte(effectiveAdminsGaad), &gaad))
+
+	allow := &EvaluationResult{Allowed: true}
+	summary := NewPermissionsSummary()
+	summary.AddPermission("arn:aws:iam::111122223333:user/self-writer", "arn:aws:iam::111122223333:user/self-writer", "iam:PutUserPolicy", true, allow)
+	summary.AddPermission("arn:aws:iam::111122223333:user/dev", "arn:aws:iam::111122223333:role/Jump", "sts:AssumeRole", true, allow)
+	summary.AddPermission("arn:aws:iam::111122223333:role/Jump", "arn:aws:iam::111122223333:role/Admin", "sts:AssumeRole", true, allow)
+	// Writing someone else's policy is privilege escalation, not self-escalation
+	summary.AddPermission("arn:aws:iam::111122223333:user/dev", "arn:aws:iam::111122223333:user/ops", "iam:PutUserPolicy", true, allow)
+
+	admins, capped := FindEffectiveAdmins(&gaad, summary, DefaultEffectiveAdminCriteria, graph.DefaultMaxHops)
+	assert.False(t, capped)
+	byPrincipal := make(map[string]EffectiveAdmin)
+	for _, admin := range admins {
+		byPrincipal[admin.Principal] = admin
+	}
+	require.Len(t, admins, 6, "bounded-ops is capped by its boundary")
+
+	assert.Equal(t, AdminSourcePolicy, byPrincipal["arn:aws:iam::111122223333:user/root-ish"].Source)
+	assert.Equal(t, AdminSourcePolicy, byPrincipal["arn:aws:iam::111122223333:role/Admin"].Source)
+
+	ops := byPrincipal["arn:aws:iam::111122223333:user/ops"]
+	assert.Equal(t, AdminSourceGroup, ops.Source)
+	assert.Equal(t, "inline policy star on group Admins", ops.Reason)
+	assert.Equal(t, []string{"arn:aws:iam::111122223333:user/ops", "arn:aws:iam::111122223333:group/Admins"}, ops.Path)
+
+	selfWriter := byPrincipal["arn:aws:iam::111122223333:user/self-writer"]
+	assert.Equal(t, AdminSourceSelfEscalation, selfWriter.Source)
+	assert.Equal(t, "can iam:PutUserPolicy on itself", selfWriter.Reason)
+
+	dev := byPrincipal["arn:aws:iam::111122223333:user/dev"]
+	assert.Equal(t, AdminSourceAssumeRole, dev.Source)
+	assert.Equal(t, []string{"arn:aws:iam::111122223333:user/dev", "arn:aws:iam::111122223333:role/Jump", "arn:aws:iam::111122223333:role/Admin"}, dev.Path)
+	assert.Equal(t, "user", dev.Type)
+
+	jump := byPrincipal["arn:aws:iam::111122223333:role/Jump"]
+	assert.Equal(t, AdminSourceAssumeRole, jump.Source)
+	assert.Equal(t, AdminSourceAssumeRole, admins[len(admins)-1].Source, "longest assume chain sorts last")
+	assert.Equal(t, "arn:aws:iam::111122223333:user/dev", admins[len(admins)-1].Principal)
+
+	// Raising the threshold above what self-writer can do drops it
+	strict := EffectiveAdminCriteria{Actions: DefaultEffectiveAdminCriteria.Actions, Threshold: 2}
+	strictAdmins, _ := FindEffectiveAdmins(&gaad, summary, strict, graph.DefaultMaxHops)
+	for _, admin := range strictAdmins {
+		assert.NotEqual(t, "arn:aws:iam::111122223333:user/self-writer", admin.Principal)
+	}
+
+	// One hop reaches Jump but not dev, and says so
+	oneHop, capped := FindEffectiveAdmins(&gaad, summary, DefaultEffectiveAdminCriteria, 1)
+	assert.True(t, capped)
+	assert.Len(t, oneHop, 5)
+}
@@ -3,6 +3,7 @@ package aws
 import (
 	"encoding/json"
 	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/aws/aws-sdk-go-v2/aws/arn"
@@ -15,6 +16,11 @@ type PolicyData struct {
 	OrgPolicies      *orgpolicies.OrgPolicies
 	ResourcePolicies map[string]*types.Policy
 	Resources        *[]types.EnrichedResourceDescription
+	IdentityCenter   *types.IdentityCenter
+	// ServiceLastAccessed is IAM last-accessed data keyed by principal ARN
+	ServiceLastAccessed types.ServiceLastAccessedReport
+
+	identityCenterRoles []identityCenterRole
 }
 
 func NewPolicyData(gaad *types.Gaad, orgPolicies *orgpolicies.OrgPolicies, resourcePolicies map[string]*types.Policy, resources *[]types.EnrichedResourceDescription) *PolicyData {
@@ -170,15 +176,68 @@ func (er *EvaluationRequest) String() string {
 	return fmt.Sprintf("Principal: %s, Action: %s, Resource: %s, Context: %v", er.Context.PrincipalArn, er.Action, er.Resource, er.Context)
 }
 
+// EvaluationDecision says how an evaluation was decided where that matters
+// downstream, such as whether a permissions boundary narrowed the result
+type EvaluationDecision string
+
+const (
+	DecisionAllowed EvaluationDecision = "Allowed"
+	// DecisionConditionallyAllowed is an allow that only holds for requests
+	// meeting the result's Conditions. It takes precedence over
+	// DecisionAllowedByBoundaryIntersection.
+	DecisionConditionallyAllowed EvaluationDecision = "ConditionallyAllowed"
+	// DecisionAllowedByBoundaryIntersection is an allow from the identity or
+	// resource policies that the principal's permissions boundary also allows
+	DecisionAllowedByBoundaryIntersection EvaluationDecision = "AllowedByBoundaryIntersection"
+	DecisionDenied                        EvaluationDecision = "Denied"
+	// DecisionDeniedByBoundary is an identity policy allow that the principal's
+	// permissions boundary removes
+	DecisionDeniedByBoundary EvaluationDecision = "DeniedByBoundary"
+	// DecisionDeniedBySCP is an identity or resource policy allow that a
+	// service control policy on the principal's account, or on an OU above
+	// it, removes
+	DecisionDeniedBySCP EvaluationDecision = "DeniedBySCP"
+	// DecisionDeniedByRCP is an identity or resource policy allow that a
+	// resource control policy on the resource's account, or on an OU above
+	// it, removes
+	DecisionDeniedByRCP EvaluationDecision = "DeniedByRCP"
+)
+
 // EvaluationResult represents the final evaluation outcome
 type EvaluationResult struct {
 	Allowed            bool
+	Decision           EvaluationDecision
 	PolicyResult       *PolicyResult
 	EvaluationDetails  string
 	CrossAccountAccess bool
 	Action             Action
 	// SSM-specific fields for tracking document restrictions
 	SSMDocumentRestrictions []string // List of allowed SSM document ARNs/patterns (e.g., ["arn:aws:ssm:*:*:document/AWS-RunShellScript", "*"])
+	// SessionPolicyUnaccounted is set on allowed results for principals that act
+	// through STS sessions. Session policies passed to AssumeRole or
+	// GetFederationToken are not visible in the GAAD, so Allowed is the most the
+	// session could do; a session policy can only narrow it.
+	SessionPolicyUnaccounted bool
+	// Conditions lists the conditions on the statements that allowed the
+	// action. An allowed result with conditions only holds for requests that
+	// satisfy them, such as ones made with MFA or from a given network.
+	Conditions []GrantingCondition `json:",omitempty"`
+	// OrgPath is the root, OU and account IDs whose SCPs or RCPs were walked
+	// when one of them denied the action
+	OrgPath []string `json:",omitempty"`
+}
+
+// GrantingCondition is one condition key on a statement that allowed an action
+type GrantingCondition struct {
+	PolicyType EvaluationType `json:"policy_type"`
+	Origin     string         `json:"origin,omitempty"`
+	KeyEvaluation
+}
+
+// IsConditional reports whether the action is only allowed for requests that
+// satisfy a condition
+func (er *EvaluationResult) IsConditional() bool {
+	return len(er.Conditions) > 0
 }
 
 func (er *EvaluationResult) String() string {
@@ -234,6 +293,78 @@ func policyToStatementList(policy *types.Policy) *types.PolicyStatementList {
 
 // Evaluate performs the full policy evaluation
 func (e *PolicyEvaluator) Evaluate(req *EvaluationRequest) (*EvaluationResult, error) {
+	result, err := e.evaluate(req)
+	if err == nil && result.Allowed && req.Context != nil {
+		result.SessionPolicyUnaccounted = sessionPoliciesMayApply(req.Context.PrincipalArn)
+	}
+	if err == nil && result.Allowed {
+		result.Conditions = grantingConditions(result.PolicyResult)
+	}
+	if err == nil && result.Decision == "" {
+		result.Decision = DecisionDenied
+		if result.Allowed {
+			switch {
+			case result.IsConditional():
+				result.Decision = DecisionConditionallyAllowed
+			case req.BoundaryStatements != nil && len(*req.BoundaryStatements) > 0:
+				result.Decision = DecisionAllowedByBoundaryIntersection
+			default:
+				result.Decision = DecisionAllowed
+			}
+		}
+	}
+	return result, err
+}
+
+// grantingConditions collects the conditions that gate an allowed result. A
+// policy type contributes conditions only when every statement of that type
+// that allowed the action is conditional; one unconditional allow makes the
+// others irrelevant.
+func grantingConditions(pr *PolicyResult) []GrantingCondition {
+	if pr == nil {
+		return nil
+	}
+
+	var conditions []GrantingCondition
+	for _, evalType := range []EvaluationType{EvalTypeIdentity, EvalTypeResource, EvalTypePermBoundary, EvalTypeSCP, EvalTypeRCP} {
+		var typeConditions []GrantingCondition
+		unconditional := false
+		for _, eval := range pr.Evaluations[evalType] {
+			if !eval.IsAllowed() {
+				continue
+			}
+			if eval.ConditionEvaluation == nil || len(eval.ConditionEvaluation.KeyResults) == 0 {
+				unconditional = true
+				break
+			}
+			for _, keyResult := range eval.ConditionEvaluation.KeyResults {
+				typeConditions = append(typeConditions, GrantingCondition{PolicyType: evalType, Origin: eval.Origin, KeyEvaluation: keyResult})
+			}
+		}
+		if unconditional {
+			continue
+		}
+		sort.SliceStable(typeConditions, func(i, j int) bool {
+			if typeConditions[i].Origin != typeConditions[j].Origin {
+				return typeConditions[i].Origin < typeConditions[j].Origin
+			}
+			return typeConditions[i].Key < typeConditions[j].Key
+		})
+		conditions = append(conditions, typeConditions...)
+	}
+	return conditions
+}
+
+// sessionPoliciesMayApply reports whether a principal's requests are made with
+// STS session credentials, which can carry a session policy. IAM users calling
+// with their own access keys cannot.
+func sessionPoliciesMayApply(principalArn string) bool {
+	return strings.Contains(principalArn, ":role/") ||
+		strings.Contains(principalArn, ":assumed-role/") ||
+		strings.Contains(principalArn, ":federated-user/")
+}
+
+func (e *PolicyEvaluator) evaluate(req *EvaluationRequest) (*EvaluationResult, error) {
 	// First validate that the action is valid for the resource type
 	if !IsValidActionForResource(req.Action, req.Resource) {
 		return &EvaluationResult{
@@ -262,10 +393,15 @@ func (e *PolicyEvaluator) Evaluate(req *EvaluationRequest) (*EvaluationResult, e
 	}
 
 	// 2a. Evaluate parent RCPs if present
-	parentRcps := e.policyData.OrgPolicies.GetMergedParentRcpsForTarget(req.Context.ResourceAccount)
+	rcpAccount := req.Context.ResourceAccount
+	parentRcps := e.policyData.OrgPolicies.GetMergedParentRcpsForTarget(rcpAccount)
 	if len(parentRcps) > 0 {
-		// Check that each parent RCP group has at least one allow statement
-		for parentID, policyStatements := range parentRcps {
+		// Check that each parent RCP group, root first, has at least one allow statement
+		for _, parentID := range e.policyData.OrgPolicies.GetOrgPathForTarget(rcpAccount) {
+			policyStatements, ok := parentRcps[parentID]
+			if !ok {
+				continue
+			}
 			parentEvals, err := e.evaluatePolicyType(req.Action, req.Resource, req.Context,
 				policyStatements, EvalTypeRCP)
 			if err != nil {
@@ -288,13 +424,13 @@ func (e *PolicyEvaluator) Evaluate(req *EvaluationRequest) (*EvaluationResult, e
 			if !hasParentAllow {
 				result.Allowed = false
 				result.EvaluationDetails = fmt.Sprintf("No explicit allow in parent RCP from %s", parentID)
-				return result, nil
+				return result, e.markOrgPolicyDenial(req, result, EvalTypeRCP, rcpAccount)
 			}
 		}
 	}
 
 	// 2b. Evaluate directly attached RCPs if present
-	rcps := e.policyData.OrgPolicies.GetDirectRcpStatementsForTarget(req.Context.ResourceAccount)
+	rcps := e.policyData.OrgPolicies.GetDirectRcpStatementsForTarget(rcpAccount)
 	if rcps != nil && len(*rcps) > 0 {
 		rcpEvals, err := e.evaluatePolicyType(req.Action, req.Resource, req.Context,
 			rcps, EvalTypeRCP)
@@ -305,15 +441,22 @@ func (e *PolicyEvaluator) Evaluate(req *EvaluationRequest) (*EvaluationResult, e
 		if !result.PolicyResult.hasTypeAllow(EvalTypeRCP) {
 			result.Allowed = false
 			result.EvaluationDetails = "Denied by RCP"
-			return result, nil
+			return result, e.markOrgPolicyDenial(req, result, EvalTypeRCP, rcpAccount)
 		}
 	}
 
-	// 3a. Evaluate parent SCPs if present
-	parentScps := e.policyData.OrgPolicies.GetMergedParentScpsForTarget(req.Context.ResourceAccount)
+	// 3a. Evaluate parent SCPs if present. An SCP that only lists allowed
+	// actions denies everything it leaves out, so every level from the root
+	// down must allow the action.
+	scpAccount := scpAccountFor(req.Context)
+	parentScps := e.policyData.OrgPolicies.GetMergedParentScpsForTarget(scpAccount)
 	if len(parentScps) > 0 {
-		// Check that each parent SCP group has at least one allow statement
-		for parentID, policyStatements := range parentScps {
+		// Check that each parent SCP group, root first, has at least one allow statement
+		for _, parentID := range e.policyData.OrgPolicies.GetOrgPathForTarget(scpAccount) {
+			policyStatements, ok := parentScps[parentID]
+			if !ok {
+				continue
+			}
 			parentEvals, err := e.evaluatePolicyType(req.Action, req.Resource, req.Context,
 				policyStatements, EvalTypeSCP)
 			if err != nil {
@@ -336,13 +479,13 @@ func (e *PolicyEvaluator) Evaluate(req *EvaluationRequest) (*EvaluationResult, e
 			if !hasParentAllow {
 				result.Allowed = false
 				result.EvaluationDetails = fmt.Sprintf("No explicit allow in parent SCP from %s", parentID)
-				return result, nil
+				return result, e.markOrgPolicyDenial(req, result, EvalTypeSCP, scpAccount)
 			}
 		}
 	}
 
 	// 3b. Evaluate SCPs if present (these are always enforced)
-	scps := e.policyData.OrgPolicies.GetDirectScpStatementsForTarget(req.Context.ResourceAccount)
+	scps := e.policyData.OrgPolicies.GetDirectScpStatementsForTarget(scpAccount)
 	if scps != nil && len(*scps) > 0 {
 		scpEvals, err := e.evaluatePolicyType(req.Action, req.Resource, req.Context,
 			scps, EvalTypeSCP)
@@ -354,7 +497,7 @@ func (e *PolicyEvaluator) Evaluate(req *EvaluationRequest) (*EvaluationResult, e
 		if !result.PolicyResult.hasTypeAllow(EvalTypeSCP) {
 			result.Allowed = false
 			result.EvaluationDetails = "Denied by SCP"
-			return result, nil
+			return result, e.markOrgPolicyDenial(req, result, EvalTypeSCP, scpAccount)
 		}
 	}
 
@@ -369,6 +512,17 @@ func (e *PolicyEvaluator) Evaluate(req *EvaluationRequest) (*EvaluationResult, e
 		if !result.PolicyResult.hasTypeAllow(EvalTypePermBoundary) {
 			result.Allowed = false
 			result.EvaluationDetails = "Denied by permission boundary"
+			// The boundary only changed the outcome if an identity policy
+			// would have allowed the action
+			identityEvals, err := e.evaluatePolicyType(req.Action, req.Resource, req.Context,
+				req.IdentityStatements, EvalTypeIdentity)
+			if err != nil {
+				return nil, err
+			}
+			result.PolicyResult.AddEvaluation(EvalTypeIdentity, identityEvals)
+			if result.PolicyResult.hasTypeAllow(EvalTypeIdentity) {
+				result.Decision = DecisionDeniedByBoundary
+			}
 			return result, nil
 		}
 	}
@@ -516,7 +670,7 @@ func (e *PolicyEvaluator) checkExplicitDenies(req *EvaluationRequest) (*Evaluati
 		statements *types.PolicyStatementList
 		evalType   EvaluationType
 	}{
-		{e.policyData.OrgPolicies.GetAllScpPoliciesForTarget(req.Context.ResourceAccount), EvalTypeSCP},
+		{e.policyData.OrgPolicies.GetAllScpPoliciesForTarget(scpAccountFor(req.Context)), EvalTypeSCP},
 		{e.policyData.OrgPolicies.GetAllRcpPoliciesForTarget(req.Context.ResourceAccount), EvalTypeRCP},
 		{req.BoundaryStatements, EvalTypePermBoundary},
 		{req.IdentityStatements, EvalTypeIdentity},
@@ -533,6 +687,12 @@ func (e *PolicyEvaluator) checkExplicitDenies(req *EvaluationRequest) (*Evaluati
 				if eval.ExplicitDeny {
 					result.Allowed = false
 					result.EvaluationDetails = fmt.Sprintf("Explicitly denied by %s", policy.evalType)
+					switch policy.evalType {
+					case EvalTypeSCP:
+						return result, e.markOrgPolicyDenial(req, result, EvalTypeSCP, scpAccountFor(req.Context))
+					case EvalTypeRCP:
+						return result, e.markOrgPolicyDenial(req, result, EvalTypeRCP, req.Context.ResourceAccount)
+					}
 					return result, nil
 				}
 			}
@@ -563,6 +723,56 @@ func (e *PolicyEvaluator) checkExplicitDenies(req *EvaluationRequest) (*Evaluati
 	return result, nil
 }
 
+// scpAccountFor returns the account whose SCPs govern a request. SCPs limit
+// the principals in an account, so they follow the principal rather than the
+// resource; RCPs follow the resource.
+func scpAccountFor(ctx *RequestContext) string {
+	if ctx.PrincipalAccount != "" {
+		return ctx.PrincipalAccount
+	}
+	return ctx.ResourceAccount
+}
+
+// markOrgPolicyDenial records the org path walked for an SCP or RCP denial
+// and, when the identity or resource policies would have allowed the action
+// without it, marks the result as DeniedBySCP or DeniedByRCP
+func (e *PolicyEvaluator) markOrgPolicyDenial(req *EvaluationRequest, result *EvaluationResult, evalType EvaluationType, account string) error {
+	result.OrgPath = e.policyData.OrgPolicies.GetOrgPathForTarget(account)
+
+	grants := map[EvaluationType]*types.PolicyStatementList{
+		EvalTypeIdentity: req.IdentityStatements,
+	}
+	if resourcePolicy, exists := e.policyData.ResourcePolicies[req.Resource]; exists {
+		grants[EvalTypeResource] = policyToStatementList(resourcePolicy)
+	}
+
+	allowed := false
+	for _, grantType := range []EvaluationType{EvalTypeIdentity, EvalTypeResource} {
+		evals, err := e.evaluatePolicyType(req.Action, req.Resource, req.Context, grants[grantType], grantType)
+		if err != nil {
+			return err
+		}
+		for _, eval := range evals {
+			if eval.ExplicitDeny {
+				return nil
+			}
+			allowed = allowed || eval.ExplicitAllow
+		}
+		if _, seen := result.PolicyResult.Evaluations[grantType]; !seen && evals != nil {
+			result.PolicyResult.AddEvaluation(grantType, evals)
+		}
+	}
+	if !allowed {
+		return nil
+	}
+
+	result.Decision = DecisionDeniedBySCP
+	if evalType == EvalTypeRCP {
+		result.Decision = DecisionDeniedByRCP
+	}
+	return nil
+}
+
 // isCrossAccountRequest determines if a request is cross-account by comparing the principal's account
 // with the resource's account. It handles wildcards and global services by assuming the resource
 // is in the same account as the principal in those cases.
@@ -88,6 +88,183 @@ func TestPolicyEvaluator_BasicIdentityPolicy(t *testing.T) {
 	}
 }
 
+func TestPolicyEvaluator_SessionPolicyUnaccounted(t *testing.T) {
+	identityStatements := &types.PolicyStatementList{
+		{
+			Effect:   "Allow",
+			Action:   types.NewDynaString([]string{"s3:GetObject"}),
+			Resource: types.NewDynaString([]string{"*"}),
+		},
+	}
+	evaluator := NewPolicyEvaluator(&PolicyData{})
+
+	tests := []struct {
+		principal string
+		action    string
+		expected  bool
+	}{
+		{"arn:aws:iam::111122223333:role/app", "s3:GetObject", true},
+		{"arn:aws:sts::111122223333:assumed-role/app/session", "s3:GetObject", true},
+		{"arn:aws:iam::111122223333:user/test-user", "s3:GetObject", false},
+		// Denied results are already the lower bound
+		{"arn:aws:iam::111122223333:role/app", "s3:PutObject", false},
+	}
+	for _, tt := range tests {
+		result, err := evaluator.Evaluate(&EvaluationRequest{
+			Action:             tt.action,
+			Resource:           "arn:aws:s3:::example-bucket/file.txt",
+			Context:            createRequestContext(tt.principal),
+			IdentityStatements: identityStatements,
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, tt.expected, result.SessionPolicyUnaccounted, "%s %s", tt.principal, tt.action)
+	}
+}
+
+func TestPolicyEvaluator_ConditionalAllow(t *testing.T) {
+	mfaOnly := &types.Condition{"Bool": {"aws:MultiFactorAuthPresent": []string{"true"}}}
+	identityStatements := &types.PolicyStatementList{
+		{
+			Effect:    "Allow",
+			Action:    types.NewDynaString([]string{"s3:GetObject", "s3:DeleteObject"}),
+			Resource:  types.NewDynaString([]string{"*"}),
+			Condition: mfaOnly,
+			OriginArn: "arn:aws:iam::111122223333:policy/RequireMFA",
+		},
+		{
+			Effect:   "Allow",
+			Action:   types.NewDynaString([]string{"s3:GetObject", "s3:ListBucket"}),
+			Resource: types.NewDynaString([]string{"*"}),
+		},
+	}
+	evaluator := NewPolicyEvaluator(&PolicyData{})
+	evaluate := func(action string, ctx *RequestContext) *EvaluationResult {
+		result, err := evaluator.Evaluate(&EvaluationRequest{
+			Action:             action,
+			Resource:           "arn:aws:s3:::example-bucket/file.txt",
+			Context:            ctx,
+			IdentityStatements: identityStatements,
+		})
+		assert.NoError(t, err)
+		return result
+	}
+	unknownMFA := &RequestContext{PrincipalArn: "arn:aws:iam::111122223333:user/dev"}
+	unknownMFA.PopulateDefaultRequestConditionKeys("arn:aws:s3:::example-bucket/file.txt")
+
+	// Allowed only with MFA: the account data cannot say whether dev uses MFA
+	deleteResult := evaluate("s3:DeleteObject", unknownMFA)
+	assert.True(t, deleteResult.Allowed)
+	assert.True(t, deleteResult.IsConditional())
+	if assert.Len(t, deleteResult.Conditions, 1) {
+		condition := deleteResult.Conditions[0]
+		assert.Equal(t, EvalTypeIdentity, condition.PolicyType)
+		assert.Equal(t, "arn:aws:iam::111122223333:policy/RequireMFA", condition.Origin)
+		assert.Equal(t, "aws:MultiFactorAuthPresent", condition.Key)
+		assert.Equal(t, "Bool", condition.Operator)
+		assert.Equal(t, []string{"true"}, condition.Values)
+	}
+
+	// A request known to be made without MFA is denied
+	noMFA := createRequestContext("arn:aws:iam::111122223333:user/dev")
+	noMFA.MultiFactorAuthPresent = Bool(false)
+	assert.False(t, evaluate("s3:DeleteObject", noMFA).Allowed)
+
+	// An unconditional statement that also allows the action wins
+	getResult := evaluate("s3:GetObject", unknownMFA)
+	assert.True(t, getResult.Allowed)
+	assert.False(t, getResult.IsConditional())
+	assert.False(t, evaluate("s3:ListBucket", unknownMFA).IsConditional())
+}
+
+func TestPolicyEvaluator_ConditionallyAllowed(t *testing.T) {
+	tests := []struct {
+		operator string
+		key      string
+		values   []string
+	}{
+		{"StringEquals", "aws:PrincipalOrgID", []string{"o-example"}},
+		{"StringEquals", "sts:ExternalId", []string{"shared-secret"}},
+		{"StringLike", "aws:SourceVpce", []string{"vpce-*"}},
+		{"ArnLike", "aws:SourceArn", []string{"arn:aws:sns:*:111122223333:*"}},
+		{"Bool", "aws:SecureTransport", []string{"true"}},
+		{"IpAddress", "aws:SourceIp", []string{"10.0.0.0/8"}},
+	}
+	evaluator := NewPolicyEvaluator(&PolicyData{})
+	for _, tt := range tests {
+		t.Run(tt.operator+" "+tt.key, func(t *testing.T) {
+			// Account data does not say what org, network or external ID a
+			// request comes with, so the allow holds only under the condition
+			ctx := &RequestContext{PrincipalArn: "arn:aws:iam::111122223333:user/dev"}
+			ctx.PopulateDefaultRequestConditionKeys("arn:aws:s3:::example-bucket/file.txt")
+			result, err := evaluator.Evaluate(&EvaluationRequest{
+				Action:   "s3:GetObject",
+				Resource: "arn:aws:s3:::example-bucket/file.txt",
+				Context:  ctx,
+				IdentityStatements: &types.PolicyStatementList{{
+					Effect:    "Allow",
+					Action:    types.NewDynaString([]string{"s3:GetObject"}),
+					Resource:  types.NewDynaString([]string{"*"}),
+					Condition: &types.Condition{tt.operator: {tt.key: tt.values}},
+				}},
+			})
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.True(t, result.Allowed)
+			assert.Equal(t, DecisionConditionallyAllowed, result.Decision)
+			if assert.Len(t, result.Conditions, 1) {
+				assert.Equal(t, tt.operator, result.Conditions[0].Operator)
+				assert.Equal(t, tt.key, result.Conditions[0].Key)
+				assert.Equal(t, tt.values, result.Conditions[0].Values)
+			}
+		})
+	}
+
+	// A request known to come from outside the range is denied outright
+	outside := createRequestContext("arn:aws:iam::111122223333:user/dev")
+	result, err := evaluator.Evaluate(&EvaluationRequest{
+		Action:   "s3:GetObject",
+		Resource: "arn:aws:s3:::example-bucket/file.txt",
+		Context:  outside,
+		IdentityStatements: &types.PolicyStatementList{{
+			Effect:    "Allow",
+			Action:    types.NewDynaString([]string{"s3:GetObject"}),
+			Resource:  types.NewDynaString([]string{"*"}),
+			Condition: &types.Condition{"IpAddress": {"aws:SourceIp": []string{"10.0.0.0/8"}}},
+		}},
+	})
+	assert.NoError(t, err)
+	assert.False(t, result.Allowed)
+	assert.Equal(t, DecisionDenied, result.Decision)
+}
+
+func TestPolicyEvaluator_DenyWithoutMFADoesNotBlockAnalysis(t *testing.T) {
+	identityStatements := &types.PolicyStatementList{
+		{
+			Effect:   "Allow",
+			Action:   types.NewDynaString([]string{"s3:GetObject"}),
+			Resource: types.NewDynaString([]string{"*"}),
+		},
+		{
+			Effect:    "Deny",
+			Action:    types.NewDynaString([]string{"*"}),
+			Resource:  types.NewDynaString([]string{"*"}),
+			Condition: &types.Condition{"BoolIfExists": {"aws:MultiFactorAuthPresent": []string{"false"}}},
+		},
+	}
+	ctx := &RequestContext{PrincipalArn: "arn:aws:iam::111122223333:user/dev"}
+	ctx.PopulateDefaultRequestConditionKeys("arn:aws:s3:::example-bucket/file.txt")
+
+	result, err := NewPolicyEvaluator(&PolicyData{}).Evaluate(&EvaluationRequest{
+		Action:             "s3:GetObject",
+		Resource:           "arn:aws:s3:::example-bucket/file.txt",
+		Context:            ctx,
+		IdentityStatements: identityStatements,
+	})
+	assert.NoError(t, err)
+	assert.True(t, result.Allowed, "a caller who signs in with MFA avoids the deny")
+}
+
 func TestPolicyEvaluator_ExplicitDenyOverridesAllow(t *testing.T) {
 	identityStatements := &types.PolicyStatementList{
 		{
@@ -193,6 +370,57 @@ func TestPolicyEvaluator_PermissionBoundary(t *testing.T) {
 
 }
 
+func TestPolicyEvaluator_BoundaryDecision(t *testing.T) {
+	// Identity policy allows all of S3, the boundary only reads
+	identityStatements := &types.PolicyStatementList{
+		{
+			Effect:   "Allow",
+			Action:   types.NewDynaString([]string{"s3:*"}),
+			Resource: types.NewDynaString([]string{"*"}),
+		},
+	}
+	boundaryStatements := &types.PolicyStatementList{
+		{
+			Effect:    "Allow",
+			Action:    types.NewDynaString([]string{"s3:GetObject", "ec2:DescribeInstances"}),
+			Resource:  types.NewDynaString([]string{"*"}),
+			OriginArn: "arn:aws:iam::111122223333:policy/s3-read-boundary",
+		},
+	}
+
+	evaluator := NewPolicyEvaluator(&PolicyData{})
+	tests := []struct {
+		name     string
+		action   string
+		resource string
+		boundary *types.PolicyStatementList
+		allowed  bool
+		decision EvaluationDecision
+	}{
+		{"in identity policy and boundary", "s3:GetObject", "arn:aws:s3:::example-bucket/file.txt", boundaryStatements, true, DecisionAllowedByBoundaryIntersection},
+		{"in identity policy only", "s3:PutObject", "arn:aws:s3:::example-bucket/file.txt", boundaryStatements, false, DecisionDeniedByBoundary},
+		{"in boundary only", "ec2:DescribeInstances", "ec2.amazonaws.com", boundaryStatements, false, DecisionDenied},
+		{"in neither", "ec2:RunInstances", "ec2.amazonaws.com", boundaryStatements, false, DecisionDenied},
+		{"no boundary", "s3:PutObject", "arn:aws:s3:::example-bucket/file.txt", nil, true, DecisionAllowed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := evaluator.Evaluate(&EvaluationRequest{
+				Action:             tt.action,
+				Resource:           tt.resource,
+				Context:            createRequestContext("arn:aws:iam::111122223333:user/test-user"),
+				IdentityStatements: identityStatements,
+				BoundaryStatements: tt.boundary,
+			})
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.Equal(t, tt.allowed, result.Allowed)
+			assert.Equal(t, tt.decision, result.Decision)
+		})
+	}
+}
+
 func TestPolicyEvaluator_ServiceControlPolicy(t *testing.T) {
 	identityStatements := &types.PolicyStatementList{
 		{
@@ -330,6 +558,138 @@ func TestPolicyEvaluator_ServiceControlPolicy(t *testing.T) {
 	assert.Equal(t, "Explicitly denied by SCP", result2.EvaluationDetails)
 }
 
+func TestPolicyEvaluator_SCPHierarchy(t *testing.T) {
+	scp := func(id string, target string, statements types.PolicyStatementList) orgpolicies.PolicyData {
+		return orgpolicies.PolicyData{
+			PolicySummary: awstypes.PolicySummary{
+				Id:  aws.String(id),
+				Arn: aws.String("arn:aws:organizations::111122223333:policy/o-1234567/service_control_policy/" + id),
+			},
+			PolicyContent: types.Policy{Version: "2012-10-17", Statement: &statements},
+			Targets:       []orgpolicies.PolicyTarget{{TargetID: target}},
+		}
+	}
+	scps := []orgpolicies.PolicyData{
+		scp("p-FullAWSAccess", "r-root", types.PolicyStatementList{{
+			Effect:   "Allow",
+			Action:   types.NewDynaString([]string{"*"}),
+			Resource: types.NewDynaString([]string{"*"}),
+		}}),
+		// A deny two levels above the account
+		scp("p-NoAccessKeys", "ou-prod", types.PolicyStatementList{
+			{
+				Effect:   "Allow",
+				Action:   types.NewDynaString([]string{"*"}),
+				Resource: types.NewDynaString([]string{"*"}),
+			},
+			{
+				Effect:   "Deny",
+				Action:   types.NewDynaString([]string{"iam:CreateAccessKey"}),
+				Resource: types.NewDynaString([]string{"*"}),
+			},
+		}),
+		scp("p-FullAWSAccess-workloads", "ou-workloads", types.PolicyStatementList{{
+			Effect:   "Allow",
+			Action:   types.NewDynaString([]string{"*"}),
+			Resource: types.NewDynaString([]string{"*"}),
+		}}),
+		// An allow list on the account itself, which denies s3 by omission
+		scp("p-IamOnly", "111122223333", types.PolicyStatementList{{
+			Effect:   "Allow",
+			Action:   types.NewDynaString([]string{"iam:*"}),
+			Resource: types.NewDynaString([]string{"*"}),
+		}}),
+	}
+	root := &orgpolicies.OrgUnit{
+		ID: "r-root",
+		Children: []orgpolicies.OrgUnit{{
+			ID: "ou-prod",
+			Children: []orgpolicies.OrgUnit{{
+				ID:       "ou-workloads",
+				Accounts: []orgpolicies.Account{{ID: "111122223333"}},
+			}},
+		}},
+	}
+	orgPolicies := (&orgpolicies.AWSOrganizationPolicies{}).BuildOrgPoliciesFromHierarchy(root, scps, nil)
+	evaluator := NewPolicyEvaluator(&PolicyData{OrgPolicies: orgPolicies})
+
+	allowAll := &types.PolicyStatementList{{
+		Effect:   "Allow",
+		Action:   types.NewDynaString([]string{"iam:*", "s3:*"}),
+		Resource: types.NewDynaString([]string{"*"}),
+	}}
+	orgPath := []string{"r-root", "ou-prod", "ou-workloads", "111122223333"}
+
+	testCases := []struct {
+		name     string
+		action   string
+		resource string
+		identity *types.PolicyStatementList
+		allowed  bool
+		decision EvaluationDecision
+		details  string
+		orgPath  []string
+	}{
+		{
+			name:     "allowed at every level",
+			action:   "iam:PutUserPolicy",
+			resource: "arn:aws:iam::111122223333:user/test-user",
+			identity: allowAll,
+			allowed:  true,
+			decision: DecisionAllowed,
+		},
+		{
+			name:     "explicit deny at an ancestor OU",
+			action:   "iam:CreateAccessKey",
+			resource: "arn:aws:iam::111122223333:user/test-user",
+			identity: allowAll,
+			decision: DecisionDeniedBySCP,
+			details:  "Explicitly denied by SCP",
+			orgPath:  orgPath,
+		},
+		{
+			name:     "left out of the account's allow list",
+			action:   "s3:GetObject",
+			resource: "arn:aws:s3:::example-bucket/file.txt",
+			identity: allowAll,
+			decision: DecisionDeniedBySCP,
+			details:  "Denied by SCP",
+			orgPath:  orgPath,
+		},
+		{
+			name:     "not allowed by identity policies either",
+			action:   "s3:GetObject",
+			resource: "arn:aws:s3:::example-bucket/file.txt",
+			identity: &types.PolicyStatementList{},
+			decision: DecisionDenied,
+			details:  "Denied by SCP",
+			orgPath:  orgPath,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := createRequestContext("arn:aws:iam::111122223333:user/test-user")
+			ctx.PopulateDefaultRequestConditionKeys(tc.resource)
+			result, err := evaluator.Evaluate(&EvaluationRequest{
+				Action:             tc.action,
+				Resource:           tc.resource,
+				Context:            ctx,
+				IdentityStatements: tc.identity,
+			})
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.Equal(t, tc.allowed, result.Allowed, result.EvaluationDetails)
+			assert.Equal(t, tc.decision, result.Decision)
+			if tc.details != "" {
+				assert.Equal(t, tc.details, result.EvaluationDetails)
+			}
+			assert.Equal(t, tc.orgPath, result.OrgPath)
+		})
+	}
+}
+
 func TestPolicyEvaluator_ResourceControlPolicy(t *testing.T) {
 	// Set up same policies but add debug prints
 	identityStatements := &types.PolicyStatementList{
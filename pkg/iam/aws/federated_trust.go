@@ -0,0 +1,241 @@
+package aws
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+	"github.com/praetorian-inc/nebula/pkg/types"
+)
+
+// Identity provider types of a federated trust
+const (
+	FederationSAML = "SAML"
+	FederationOIDC = "OIDC"
+)
+
+const (
+	cognitoIdentityProvider = "cognito-identity.amazonaws.com"
+	samlAudienceKey         = "saml:aud"
+)
+
+// FederatedTrustFinding is a role trust statement that lets an external
+// identity provider's users assume the role without the conditions that tie
+// the trust to the intended subjects or audience
+type FederatedTrustFinding struct {
+	RoleArn          string   `json:"role_arn"`
+	ProviderArn      string   `json:"provider_arn"`
+	ProviderType     string   `json:"provider_type"`
+	Provider         string   `json:"provider"`
+	Actions          []string `json:"actions"`
+	StatementIndex   int      `json:"statement_index"`
+	Sid              string   `json:"sid,omitempty"`
+	Severity         string   `json:"severity"`
+	MissingCondition string   `json:"missing_condition"`
+	Detail           string   `json:"detail"`
+}
+
+// restrictingOperators are the condition operators that narrow who matches;
+// negated operators leave every other subject allowed and do not count
+var restrictingOperators = map[string]bool{
+	"stringequals":           true,
+	"stringequalsignorecase": true,
+	"stringlike":             true,
+}
+
+// FindFederatedTrustIssues checks the trust policy of every role for SAML and
+// OIDC federated principals whose subject or audience is left unrestricted.
+// Findings are ordered by severity, then role.
+func FindFederatedTrustIssues(gaad *types.Gaad) []FederatedTrustFinding {
+	if gaad == nil {
+		return nil
+	}
+
+	findings := make([]FederatedTrustFinding, 0)
+	for _, role := range gaad.RoleDetailList {
+		if role.AssumeRolePolicyDocument.Statement == nil {
+			continue
+		}
+		for i, stmt := range *role.AssumeRolePolicyDocument.Statement {
+			if !strings.EqualFold(stmt.Effect, "Allow") || stmt.Principal == nil || stmt.Principal.Federated == nil {
+				continue
+			}
+			for _, federated := range *stmt.Principal.Federated {
+				providerType, provider := federatedProvider(federated)
+				actions := federatedActions(&stmt, providerType)
+				if len(actions) == 0 {
+					continue
+				}
+				for _, check := range federatedTrustChecks(providerType, provider, stmt.Condition) {
+					findings = append(findings, FederatedTrustFinding{
+						RoleArn:          role.Arn,
+						ProviderArn:      federated,
+						ProviderType:     providerType,
+						Provider:         provider,
+						Actions:          actions,
+						StatementIndex:   i,
+						Sid:              stmt.Sid,
+						Severity:         check.severity,
+						MissingCondition: check.condition,
+						Detail:           check.detail,
+					})
+				}
+			}
+		}
+	}
+
+	sort.SliceStable(findings, func(i, j int) bool {
+		if a, b := severityRank(findings[i].Severity), severityRank(findings[j].Severity); a != b {
+			return a > b
+		}
+		return findings[i].RoleArn < findings[j].RoleArn
+	})
+	return findings
+}
+
+// federatedProvider identifies the IdP behind a Federated principal. IAM
+// provider ARNs name a SAML provider or an OIDC issuer host; a bare host such
+// as cognito-identity.amazonaws.com or accounts.google.com is an OIDC issuer.
+func federatedProvider(federated string) (string, string) {
+	parsed, err := arn.Parse(federated)
+	if err != nil {
+		return FederationOIDC, strings.ToLower(federated)
+	}
+	kind, name, _ := strings.Cut(parsed.Resource, "/")
+	if kind == "saml-provider" {
+		return FederationSAML, name
+	}
+	return FederationOIDC, strings.ToLower(name)
+}
+
+// federatedActions returns the statement actions that sign in through the
+// provider: sts:AssumeRoleWithSAML for SAML, sts:AssumeRoleWithWebIdentity for OIDC
+func federatedActions(stmt *types.PolicyStatement, providerType string) []string {
+	if stmt.Action == nil {
+		return nil
+	}
+	target := "sts:assumerolewithwebidentity"
+	if providerType == FederationSAML {
+		target = "sts:assumerolewithsaml"
+	}
+	var actions []string
+	for _, action := range *stmt.Action {
+		if matchesPattern(strings.ToLower(action), target) {
+			actions = append(actions, action)
+		}
+	}
+	return actions
+}
+
+type federatedTrustCheck struct {
+	severity  string
+	condition string
+	detail    string
+}
+
+// federatedTrustChecks reports the missing or wildcard conditions of one
+// federated principal. GitHub Actions and Cognito issue tokens to anyone, so
+// their subject and audience conditions are what keep the trust to one tenant.
+func federatedTrustChecks(providerType, provider string, condition *types.Condition) []federatedTrustCheck {
+	if providerType == FederationSAML {
+		if !conditionRestricts(condition, samlAudienceKey, nil) {
+			return []federatedTrustCheck{{"Medium", "SAML:aud",
+				"no SAML:aud condition pins the assertion audience to the AWS sign-in endpoint, so assertions the IdP issues for other applications are accepted"}}
+		}
+		return nil
+	}
+
+	subKey, audKey := provider+":sub", provider+":aud"
+	switch {
+	case provider == GitHubActionsOIDCProvider:
+		if !conditionRestricts(condition, subKey, githubSubjectScoped) {
+			return []federatedTrustCheck{{"Critical", subKey,
+				"no sub condition limits the trust to a repository, so a workflow in any GitHub repository can assume the role"}}
+		}
+	case provider == cognitoIdentityProvider:
+		var checks []federatedTrustCheck
+		if !conditionRestricts(condition, audKey, nil) {
+			checks = append(checks, federatedTrustCheck{"Critical", audKey,
+				"no aud condition names the identity pool, so an identity from any Cognito identity pool, in any account, can assume the role"})
+		}
+		if !conditionRestricts(condition, provider+":amr", nil) {
+			checks = append(checks, federatedTrustCheck{"Medium", provider + ":amr",
+				"no amr condition requires authenticated identities, so unauthenticated guests of the pool can assume the role"})
+		}
+		return checks
+	default:
+		hasSub := conditionRestricts(condition, subKey, nil)
+		hasAud := conditionRestricts(condition, audKey, nil)
+		if !hasSub && !hasAud {
+			return []federatedTrustCheck{{"High", subKey,
+				"neither a sub nor an aud condition restricts the trust, so any identity the provider issues tokens to can assume the role"}}
+		}
+		if !hasSub {
+			return []federatedTrustCheck{{"Medium", subKey,
+				"no sub condition restricts the trust, so every subject of the audience, such as any service account of a cluster, can assume the role"}}
+		}
+	}
+	return nil
+}
+
+// conditionRestricts reports whether a restricting operator sets key to
+// values that are not all wildcards. scoped, when set, further requires every
+// value to pass it.
+func conditionRestricts(condition *types.Condition, key string, scoped func(string) bool) bool {
+	if condition == nil {
+		return false
+	}
+	for operator, statement := range *condition {
+		// IfExists and ForAllValues pass when the key is absent from the request
+		op := strings.ToLower(operator)
+		if strings.HasSuffix(op, "ifexists") || strings.HasPrefix(op, "forallvalues:") {
+			continue
+		}
+		op = strings.TrimPrefix(op, "foranyvalue:")
+		if !restrictingOperators[op] {
+			continue
+		}
+		for conditionKey, values := range statement {
+			if !strings.EqualFold(conditionKey, key) || len(values) == 0 {
+				continue
+			}
+			restricted := true
+			for _, value := range values {
+				if strings.Trim(value, "*?") == "" || (scoped != nil && !scoped(value)) {
+					restricted = false
+					break
+				}
+			}
+			if restricted {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// githubSubjectScoped reports whether a GitHub Actions sub pattern is limited
+// to a named organization. "repo:*", "repo:*/app" and patterns that do not
+// start with repo: match workflows of other owners' repositories.
+func githubSubjectScoped(value string) bool {
+	claim, err := ParseGitHubSubjectClaim(value)
+	if err != nil {
+		return false
+	}
+	return strings.Trim(claim.Org, "*?") != "" && !strings.ContainsAny(claim.Org, "*?")
+}
+
+// severityRank orders finding severities for sorting
+func severityRank(severity string) int {
+	switch severity {
+	case "Critical":
+		return 4
+	case "High":
+		return 3
+	case "Medium":
+		return 2
+	case "Low":
+		return 1
+	}
+	return 0
+}
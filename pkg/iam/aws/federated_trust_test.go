@@ -0,0 +1,101 @@
+package aws
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/praetorian-inc/nebula/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const federatedTrustGaad = `{
+  "RoleDetailList": [
+    {
+      "Arn": "arn:aws:iam::111122223333:role/github-any-repo",
+      "AssumeRolePolicyDocument": {"Version": "2012-10-17", "Statement": [{
+        "Effect": "Allow", "Action": "sts:AssumeRoleWithWebIdentity",
+        "Principal": {"Federated": "arn:aws:iam::111122223333:oidc-provider/token.actions.githubusercontent.com"},
+        "Condition": {
+          "StringEquals": {"token.actions.githubusercontent.com:aud": "sts.amazonaws.com"},
+          "StringLike": {"token.actions.githubusercontent.com:sub": "repo:*"}
+        }
+      }]}
+    },
+    {
+      "Arn": "arn:aws:iam::111122223333:role/github-deploy",
+      "AssumeRolePolicyDocument": {"Version": "2012-10-17", "Statement": [{
+        "Effect": "Allow", "Action": "sts:AssumeRoleWithWebIdentity",
+        "Principal": {"Federated": "arn:aws:iam::111122223333:oidc-provider/token.actions.githubusercontent.com"},
+        "Condition": {"StringLike": {"token.actions.githubusercontent.com:sub": ["repo:example-org/app:ref:refs/heads/main", "repo:example-org/app:environment:*"]}}
+      }]}
+    },
+    {
+      "Arn": "arn:aws:iam::111122223333:role/cognito-guest",
+      "AssumeRolePolicyDocument": {"Version": "2012-10-17", "Statement": [{
+        "Sid": "Pool", "Effect": "Allow", "Action": "sts:AssumeRoleWithWebIdentity",
+        "Principal": {"Federated": "cognito-identity.amazonaws.com"},
+        "Condition": {"StringEqualsIfExists": {"cognito-identity.amazonaws.com:aud": "us-east-1:pool"}}
+      }]}
+    },
+    {
+      "Arn": "arn:aws:iam::111122223333:role/eks-pods",
+      "AssumeRolePolicyDocument": {"Version": "2012-10-17", "Statement": [{
+        "Effect": "Allow", "Action": "sts:AssumeRoleWithWebIdentity",
+        "Principal": {"Federated": "arn:aws:iam::111122223333:oidc-provider/oidc.eks.us-east-1.amazonaws.com/id/ABC"},
+        "Condition": {"StringEquals": {"oidc.eks.us-east-1.amazonaws.com/id/ABC:aud": "sts.amazonaws.com"}}
+      }]}
+    },
+    {
+      "Arn": "arn:aws:iam::111122223333:role/okta-admin",
+      "AssumeRolePolicyDocument": {"Version": "2012-10-17", "Statement": [
+        {"Effect": "Allow", "Action": ["sts:AssumeRoleWithSAML", "sts:TagSession"],
+         "Principal": {"Federated": "arn:aws:iam::111122223333:saml-provider/Okta"},
+         "Condition": {"StringLike": {"SAML:aud": "*"}}},
+        {"Effect": "Allow", "Action": "sts:AssumeRole", "Principal": {"Service": "ec2.amazonaws.com"}}
+      ]}
+    },
+    {
+      "Arn": "arn:aws:iam::111122223333:role/okta-readonly",
+      "AssumeRolePolicyDocument": {"Version": "2012-10-17", "Statement": [{
+        "Effect": "Allow", "Action": "sts:AssumeRoleWithSAML",
+        "Principal": {"Federated": "arn:aws:iam::111122223333:saml-provider/Okta"},
+        "Condition": {"StringEquals": {"SAML:aud": "https://signin.aws.amazon.com/saml"}}
+      }]}
+    }
+  ]
+}`
+
+func TestFindFederatedTrustIssues(t *testing.T) {
+	var gaad types.Gaad
+	require.NoError(t, json.Unmarshal([]byte(federatedTrustGaad), &gaad))
+
+	findings := FindFederatedTrustIssues(&gaad)
+	var got []string
+	for _, f := range findings {
+		got = append(got, f.Severity+" "+f.RoleArn[len("arn:aws:iam::111122223333:role/"):]+" "+f.MissingCondition)
+	}
+	assert.Equal(t, []string{
+		"Critical cognito-guest cognito-identity.amazonaws.com:aud",
+		"Critical github-any-repo token.actions.githubusercontent.com:sub",
+		"Medium cognito-guest cognito-identity.amazonaws.com:amr",
+		"Medium eks-pods oidc.eks.us-east-1.amazonaws.com/id/abc:sub",
+		"Medium okta-admin SAML:aud",
+	}, got)
+
+	saml := findings[4]
+	assert.Equal(t, FederationSAML, saml.ProviderType)
+	assert.Equal(t, "Okta", saml.Provider)
+	assert.Equal(t, "arn:aws:iam::111122223333:saml-provider/Okta", saml.ProviderArn)
+	assert.Equal(t, []string{"sts:AssumeRoleWithSAML"}, saml.Actions)
+
+	assert.Equal(t, "Pool", findings[0].Sid)
+	assert.Equal(t, FederationOIDC, findings[0].ProviderType)
+}
+
+func TestGithubSubjectScoped(t *testing.T) {
+	assert.True(t, githubSubjectScoped("repo:example-org/*"))
+	assert.False(t, githubSubjectScoped("repo:*"))
+	assert.False(t, githubSubjectScoped("repo:*/app:*"))
+	assert.False(t, githubSubjectScoped("*:ref:refs/heads/main"))
+}
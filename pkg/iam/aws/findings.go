@@ -0,0 +1,82 @@
+package aws
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/praetorian-inc/nebula/pkg/rules"
+)
+
+// Findings converts the security findings of the summary to the unified
+// finding type, so AWS results can be reported and baselined alongside other
+// providers. Policy issues and unused permissions describe the quality of the
+// input and the least privilege gap rather than a weakness, and are left out.
+func (ps *PermissionsSummary) Findings() []rules.Finding {
+	findings := []rules.Finding{}
+
+	for _, admin := range ps.EffectiveAdmins {
+		findings = append(findings, rules.Finding{
+			"type":         "EffectiveAdmin",
+			"rule":         "effective-admins",
+			"severity":     "High",
+			"principalArn": admin.Principal,
+			"accountId":    admin.AccountID,
+			"description":  fmt.Sprintf("%s %s is admin-equivalent (%s): %s", admin.Type, admin.Principal, admin.Source, admin.Reason),
+		})
+	}
+
+	for _, role := range ps.AdminRoleAssumers {
+		// Admin roles that principals outside the account can reach matter most
+		severity := "Medium"
+		if role.ExternalCount > 0 {
+			severity = "Critical"
+		}
+		findings = append(findings, rules.Finding{
+			"type":        "AdminRoleAssumable",
+			"rule":        "admin-role-assumers",
+			"severity":    severity,
+			"resourceId":  role.RoleArn,
+			"accountId":   role.AccountID,
+			"description": fmt.Sprintf("Admin role %s can be assumed by %d principals directly, %d through role chains and %d from other accounts", role.RoleArn, role.DirectCount, role.TransitiveCount, role.ExternalCount),
+		})
+	}
+
+	for _, trust := range ps.FederatedTrust {
+		findings = append(findings, rules.Finding{
+			"type":        "FederatedTrustMissingCondition",
+			"rule":        "federated-trust",
+			"severity":    trust.Severity,
+			"resourceId":  trust.RoleArn,
+			"policyId":    trust.ProviderArn,
+			"description": trust.Detail,
+		})
+	}
+
+	for _, account := range ps.AccountPublicAccess {
+		findings = append(findings, rules.Finding{
+			"type":        "S3AccountPublicAccessBlockDisabled",
+			"rule":        "account-public-access",
+			"severity":    account.Severity,
+			"resourceId":  account.AccountID,
+			"accountId":   account.AccountID,
+			"description": account.Detail,
+		})
+	}
+
+	for _, access := range ps.IdentityCenterAccess {
+		if !access.Admin {
+			continue
+		}
+		findings = append(findings, rules.Finding{
+			"type":         "IdentityCenterAdmin",
+			"rule":         "identity-center-admins",
+			"severity":     "Medium",
+			"principalArn": access.PrincipalArn,
+			"scope":        access.AccountID,
+			"accountId":    access.AccountID,
+			"description": fmt.Sprintf("Identity Center %s %s is admin in account %s through %s: %s",
+				strings.ToLower(access.PrincipalType), access.PrincipalName, access.AccountID, strings.Join(access.PermissionSets, ", "), access.AdminReason),
+		})
+	}
+	return findings
+}
@@ -0,0 +1,38 @@
+package aws
+
+import (
+	"testing"
+
+	"github.com/praetorian-inc/nebula/pkg/rules"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPermissionsSummaryFindings(t *testing.T) {
+	ps := NewPermissionsSummary()
+	ps.EffectiveAdmins = []EffectiveAdmin{{Principal: "arn:aws:iam::111111111111:user/alice", Type: "user", Source: AdminSourcePolicy, Reason: "AdministratorAccess"}}
+	ps.AdminRoleAssumers = []AdminRoleAssumers{
+		{RoleArn: "arn:aws:iam::111111111111:role/admin", DirectCount: 2},
+		{RoleArn: "arn:aws:iam::111111111111:role/break-glass", ExternalCount: 1},
+	}
+	ps.AccountPublicAccess = []AccountPublicAccessFinding{{AccountID: "111111111111", Severity: "High", Detail: "block off"}}
+	ps.IdentityCenterAccess = []IdentityCenterAccess{
+		{PrincipalArn: "arn:aws:identitystore:::user/u1", PrincipalType: "USER", PrincipalName: "bob", AccountID: "111111111111", Admin: true},
+		{PrincipalArn: "arn:aws:identitystore:::user/u2", AccountID: "111111111111"},
+	}
+	ps.PolicyIssues = []PolicyIssue{{Kind: IssueMissingAction}}
+
+	findings := ps.Findings()
+	require.Len(t, findings, 5, "policy issues and non-admin Identity Center access are not findings")
+
+	byType := map[string][]rules.Finding{}
+	for _, finding := range findings {
+		assert.NotEmpty(t, finding["description"])
+		byType[finding["type"].(string)] = append(byType[finding["type"].(string)], finding)
+	}
+	assert.Equal(t, "High", byType["EffectiveAdmin"][0]["severity"])
+	assert.Equal(t, "Medium", byType["AdminRoleAssumable"][0]["severity"])
+	assert.Equal(t, "Critical", byType["AdminRoleAssumable"][1]["severity"], "admin roles reachable from other accounts rank first")
+	assert.Equal(t, "High", byType["S3AccountPublicAccessBlockDisabled"][0]["severity"])
+	assert.NotEqual(t, rules.Fingerprint(byType["AdminRoleAssumable"][0]), rules.Fingerprint(byType["AdminRoleAssumable"][1]))
+}
@@ -81,71 +81,57 @@ func (fr *FullResult) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
+// FullResults returns one result per allowed action, ordered by principal,
+// resource and action
 func (ps *PermissionsSummary) FullResults() []FullResult {
 	results := make([]FullResult, 0)
 
-	ps.Permissions.Range(func(key, value interface{}) bool {
-		if perms, ok := value.(*PrincipalPermissions); ok {
-			// Convert ResourcePerms sync.Map to map, skipping empty resources
-			perms.ResourcePerms.Range(func(resKey, resValue interface{}) bool {
-				resPerm, ok := resValue.(*ResourcePermission)
-
-				if !ok {
-					slog.Error("Resource permission not found", "resource", resKey)
-					return false
-				}
-
-				// Only include resources that have allowed or denied actions
-				if len(resPerm.AllowedActions) > 0 {
-					resArn := resKey.(string)
-
-					// Get the resource from the cache
-					resource, ok := resourceCache[resArn]
-					if !ok {
-						slog.Error("Resource not found in cache", "resource", resArn)
-						return false
-					}
-
-					for _, action := range resPerm.AllowedActions {
-						if principal, ok := userCache[perms.PrincipalArn]; ok {
-							results = append(results, FullResult{
-								Principal: principal,
-								Resource:  resource,
-								Action:    action.Name,
-								Result:    action.EvaluationResult,
-							})
-						} else if principal, ok := roleCache[perms.PrincipalArn]; ok {
-							results = append(results, FullResult{
-								Principal: principal,
-								Resource:  resource,
-								Action:    action.Name,
-								Result:    action.EvaluationResult,
-							})
-						} else if principal, ok := groupCache[perms.PrincipalArn]; ok {
-							results = append(results, FullResult{
-								Principal: principal,
-								Resource:  resource,
-								Action:    action.Name,
-								Result:    action.EvaluationResult,
-							})
-						} else {
-							results = append(results, FullResult{
-								Principal: perms.PrincipalArn,
-								Resource:  resource,
-								Action:    action.Name,
-								Result:    action.EvaluationResult,
-							})
-						}
-
-					}
-
-				}
-
-				return true
-			})
+	for _, principalArn := range ps.GetPrincipals() {
+		value, _ := ps.Permissions.Load(principalArn)
+		perms, ok := value.(*PrincipalPermissions)
+		if !ok {
+			continue
 		}
-		return true
-	})
+
+		var principal interface{} = perms.PrincipalArn
+		if user, ok := userCache[perms.PrincipalArn]; ok {
+			principal = user
+		} else if role, ok := roleCache[perms.PrincipalArn]; ok {
+			principal = role
+		} else if group, ok := groupCache[perms.PrincipalArn]; ok {
+			principal = group
+		}
+
+		for _, resArn := range perms.GetResources() {
+			resValue, _ := perms.ResourcePerms.Load(resArn)
+			resPerm, ok := resValue.(*ResourcePermission)
+			if !ok {
+				slog.Error("Resource permission not found", "resource", resArn)
+				break
+			}
+
+			// Only include resources that have allowed actions
+			if len(resPerm.AllowedActions) == 0 {
+				continue
+			}
+
+			// Get the resource from the cache
+			resource, ok := resourceCache[resArn]
+			if !ok {
+				slog.Error("Resource not found in cache", "resource", resArn)
+				break
+			}
+
+			for _, action := range resPerm.AllowedActions {
+				results = append(results, FullResult{
+					Principal: principal,
+					Resource:  resource,
+					Action:    action.Name,
+					Result:    action.EvaluationResult,
+				})
+			}
+		}
+	}
 
 	return results
 }
@@ -9,56 +9,90 @@ import (
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws/arn"
+	"github.com/praetorian-inc/nebula/pkg/graph"
 	"github.com/praetorian-inc/nebula/pkg/types"
 )
 
+// DefaultAnalyzerWorkers is the number of workers AnalyzePrincipalPermissions
+// uses when none is set. Evaluation is CPU bound with short waits on shared
+// caches, so a few workers per core keep every core busy.
+var DefaultAnalyzerWorkers = runtime.NumCPU() * 3
+
 // GaadAnalyzer handles efficient analysis of GAAD policy data
 type GaadAnalyzer struct {
-	policyData *PolicyData
-	evaluator  *PolicyEvaluator
+	policyData    *PolicyData
+	evaluator     *PolicyEvaluator
+	policyIssues  []PolicyIssue
+	adminCriteria EffectiveAdminCriteria
+	workers       int
+	maxHops       int
 }
 
 // NewGaadAnalyzer creates a new analyzer and initializes caches
 func NewGaadAnalyzer(pd *PolicyData) *GaadAnalyzer {
 	evaluator := NewPolicyEvaluator(pd)
 	ga := &GaadAnalyzer{
-		policyData: pd,
-		evaluator:  evaluator,
+		policyData:    pd,
+		evaluator:     evaluator,
+		adminCriteria: DefaultEffectiveAdminCriteria,
+		workers:       DefaultAnalyzerWorkers,
+		maxHops:       graph.DefaultMaxHops,
+	}
+	ga.policyIssues = FindPolicyIssues(pd.Gaad)
+	if len(ga.policyIssues) > 0 {
+		slog.Warn(fmt.Sprintf("Found %d policy statements that could not be fully evaluated; results for the affected principals may be incomplete", len(ga.policyIssues)))
+		for _, issue := range ga.policyIssues {
+			slog.Debug("Policy issue", "kind", issue.Kind, "policy", issue.PolicyID, "statement", issue.StatementIndex, "principals", issue.Principals, "detail", issue.Detail)
+		}
 	}
 	initializeCaches(pd)
 	addServicesToResourceCache()
 	return ga
 }
 
-// AnalyzePrincipalPermissions processes permissions for IAM principals concurrently
+// SetEffectiveAdminCriteria overrides the self-escalation actions and threshold
+// used to decide which principals are admin-equivalent
+func (ga *GaadAnalyzer) SetEffectiveAdminCriteria(criteria EffectiveAdminCriteria) {
+	ga.adminCriteria = criteria
+}
+
+// SetMaxHops limits how many sts:AssumeRole hops the admin analyses follow.
+// Zero or a negative value follows chains of any length; every walk still
+// stops at principals it has already reached.
+func (ga *GaadAnalyzer) SetMaxHops(maxHops int) {
+	ga.maxHops = max(maxHops, 0)
+}
+
+// SetWorkers sets how many principals are expanded into evaluation requests,
+// and how many requests are evaluated, at the same time. Zero or a negative
+// value uses DefaultAnalyzerWorkers.
+func (ga *GaadAnalyzer) SetWorkers(workers int) {
+	if workers <= 0 {
+		workers = DefaultAnalyzerWorkers
+	}
+	ga.workers = workers
+}
+
+// AnalyzePrincipalPermissions processes permissions for IAM principals
+// concurrently on a bounded pool of workers. Results do not depend on the
+// number of workers or the order they finish in.
 func (ga *GaadAnalyzer) AnalyzePrincipalPermissions() (*PermissionsSummary, error) {
 	summary := NewPermissionsSummary()
+	summary.PolicyIssues = ga.policyIssues
 	var wg sync.WaitGroup
 
 	// Create buffered channel for evaluation requests
 	evalChan := make(chan *EvaluationRequest, 1000)
 
+	// Resource policy statements are shared by every evaluation that reads
+	// them, so their origins are set before any worker starts
+	ga.setResourcePolicyOrigins()
+	ga.setAssumeRolePolicyResources()
+
 	// Start evaluation workers
 	var evalWg sync.WaitGroup
 	ga.startEvaluationWorkers(evalChan, summary, &evalWg)
 
-	// Process users
-	for _, user := range ga.policyData.Gaad.UserDetailList {
-		wg.Add(1)
-		go func(u types.UserDL) {
-			defer wg.Done()
-			ga.processUserPermissions(u, evalChan)
-		}(user)
-	}
-
-	for _, role := range ga.policyData.Gaad.RoleDetailList {
-		wg.Add(1)
-		go func(r types.RoleDL) {
-			defer wg.Done()
-			ga.processRolePermissions(r, evalChan)
-		}(role)
-	}
-
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
@@ -72,6 +106,25 @@ func (ga *GaadAnalyzer) AnalyzePrincipalPermissions() (*PermissionsSummary, erro
 		ga.processAssumeRolePolicies(evalChan)
 	}()
 
+	// Expand users and roles into evaluation requests, one principal per worker at a time
+	principalChan := make(chan func(), ga.workers)
+	for i := 0; i < ga.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for process := range principalChan {
+				process()
+			}
+		}()
+	}
+	for _, user := range ga.policyData.Gaad.UserDetailList {
+		principalChan <- func() { ga.processUserPermissions(user, evalChan) }
+	}
+	for _, role := range ga.policyData.Gaad.RoleDetailList {
+		principalChan <- func() { ga.processRolePermissions(role, evalChan) }
+	}
+	close(principalChan)
+
 	// Wait for all producers to finish
 	wg.Wait()
 
@@ -81,12 +134,63 @@ func (ga *GaadAnalyzer) AnalyzePrincipalPermissions() (*PermissionsSummary, erro
 	// Wait for all workers to finish
 	evalWg.Wait()
 
+	// Workers record actions in the order they finish; sort them so output is stable
+	summary.sortActions()
+
 	// Post-processing: add synthetic edges for "create-then-use" attack patterns
 	applyCreateThenUseEdges(summary)
 
+	// Identity Center users and groups reach their permission set roles through SAML sign-in
+	applyIdentityCenterAssignments(ga.policyData, summary)
+
+	// Rank admin roles by who can reach them, now that every assume edge is known
+	summary.MaxHops = ga.maxHops
+	summary.AdminRoleAssumers = FindAdminRoleAssumers(ga.policyData.Gaad, summary, ga.maxHops)
+	summary.EffectiveAdmins, summary.EffectiveAdminsHopsCapped = FindEffectiveAdmins(ga.policyData.Gaad, summary, ga.adminCriteria, ga.maxHops)
+	summary.IdentityCenterAccess = FindIdentityCenterAccess(ga.policyData, summary)
+	summary.FederatedTrust = FindFederatedTrustIssues(ga.policyData.Gaad)
+	summary.UnusedPermissions = FindUnusedPermissions(ga.policyData, summary)
+	if ga.policyData.Resources != nil {
+		summary.AccountPublicAccess = FindAccountPublicAccessIssues(*ga.policyData.Resources)
+	}
+
 	return summary, nil
 }
 
+// setResourcePolicyOrigins attributes each resource policy statement to the
+// resource it is attached to. Statements that already name their origin, such
+// as role trust policies, keep it.
+func (ga *GaadAnalyzer) setResourcePolicyOrigins() {
+	for resourceArn, policy := range ga.policyData.ResourcePolicies {
+		if policy == nil || policy.Statement == nil {
+			continue
+		}
+		for i := range *policy.Statement {
+			if (*policy.Statement)[i].OriginArn == "" {
+				(*policy.Statement)[i].OriginArn = resourceArn
+			}
+		}
+	}
+}
+
+// setAssumeRolePolicyResources sets each role trust policy statement's
+// resource to its role; without it, the evaluator won't match the policy.
+// Trust policies are read by the assume role walk and by every principal that
+// can sts:AssumeRole, so they are completed before any worker starts.
+func (ga *GaadAnalyzer) setAssumeRolePolicyResources() {
+	for i := range ga.policyData.Gaad.RoleDetailList {
+		role := &ga.policyData.Gaad.RoleDetailList[i]
+		if role.AssumeRolePolicyDocument.Statement == nil {
+			continue
+		}
+		for j := range *role.AssumeRolePolicyDocument.Statement {
+			if (*role.AssumeRolePolicyDocument.Statement)[j].Resource == nil {
+				(*role.AssumeRolePolicyDocument.Statement)[j].Resource = &types.DynaString{role.Arn}
+			}
+		}
+	}
+}
+
 func (ga *GaadAnalyzer) generateServicePrincipalEvaluations(evalChan chan *EvaluationRequest) {
 
 	// Process resource policies
@@ -102,7 +206,6 @@ func (ga *GaadAnalyzer) generateServicePrincipalEvaluations(evalChan chan *Evalu
 func (ga *GaadAnalyzer) generateServiceEvaluations(resourceArn string, policy *types.Policy) *EvaluationRequest {
 	if policy.Statement != nil {
 		for i := range *policy.Statement {
-			(*policy.Statement)[i].OriginArn = resourceArn
 			if (*policy.Statement)[i].Principal != nil && (*policy.Statement)[i].Principal.Service != nil {
 				for _, service := range *(*policy.Statement)[i].Principal.Service {
 					for _, action := range *(*policy.Statement)[i].Action {
@@ -203,16 +306,12 @@ func (ga *GaadAnalyzer) processUserPermissions(user types.UserDL, evalChan chan<
 			// deepCopy(boundaryStatements, &tempBoundary)
 			for _, resource := range getResourcesByAction(Action(action)) {
 
-				// For AssumeRole actions, update the resource in the policy document
-				// Without the resource, the evaluator won't match the policy
+				// For AssumeRole actions, the role's trust policy already names
+				// the role as its resource (see setAssumeRolePolicyResources)
 				if action == "sts:AssumeRole" {
 					role := roleCache[resource.Arn.String()]
 					if role != nil {
 						arpd := role.AssumeRolePolicyDocument
-						for i := range *arpd.Statement {
-							(*arpd.Statement)[i].Resource = &types.DynaString{resource.Arn.String()}
-						}
-
 						slog.Debug(fmt.Sprintf("AssumeRole policy for %s: %v", role.Arn, arpd.Statement))
 						// tempBoundary = append(tempBoundary, *arpd.Statement...)
 					}
@@ -240,7 +339,7 @@ func (ga *GaadAnalyzer) processUserPermissions(user types.UserDL, evalChan chan<
 }
 
 func (ga *GaadAnalyzer) startEvaluationWorkers(evalChan <-chan *EvaluationRequest, summary *PermissionsSummary, wg *sync.WaitGroup) {
-	numWorkers := runtime.NumCPU() * 3
+	numWorkers := ga.workers
 	slog.Debug(fmt.Sprintf("Starting %d evaluation workers", numWorkers))
 
 	for i := 0; i < numWorkers; i++ {
@@ -336,8 +435,8 @@ func (ga *GaadAnalyzer) processRolePermissions(role types.RoleDL, evalChan chan<
 					continue
 				}
 
-				// For AssumeRole actions, update the resource in the policy document
-				// Without the resource, the evaluator won't match the policy
+				// For AssumeRole actions, evaluate against the role's trust policy,
+				// whose resources setAssumeRolePolicyResources has already set
 				if action == "sts:AssumeRole" {
 					if roleCache == nil {
 						slog.Debug("Role cache is nil, skipping AssumeRole handling")
@@ -356,13 +455,6 @@ func (ga *GaadAnalyzer) processRolePermissions(role types.RoleDL, evalChan chan<
 					}
 
 					arpd := roleObj.AssumeRolePolicyDocument
-					for i := range *arpd.Statement {
-						// Ensure each statement in the policy has its resource set correctly
-						if (*arpd.Statement)[i].Resource == nil {
-							(*arpd.Statement)[i].Resource = &types.DynaString{resource.Arn.String()}
-						}
-					}
-
 					slog.Debug(fmt.Sprintf("AssumeRole policy for %s: %v", roleObj.Arn, arpd.Statement))
 					tempBoundary = append(tempBoundary, *arpd.Statement...)
 				}
@@ -465,6 +557,11 @@ func (ga *GaadAnalyzer) processAssumeRolePolicies(evalChan chan<- *EvaluationReq
 	}
 }
 
+// PolicyIssues returns the policy statements the analyzer could not fully evaluate
+func (ga *GaadAnalyzer) PolicyIssues() []PolicyIssue {
+	return ga.policyIssues
+}
+
 // getGroupByName retrieves a group by name
 func (ga *GaadAnalyzer) getGroupByName(name string) (*types.GroupDL, bool) {
 	for _, group := range ga.policyData.Gaad.GroupDetailList {
@@ -0,0 +1,157 @@
+package aws
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/praetorian-inc/nebula/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// syntheticPolicyData builds an account of users and roles with overlapping
+// S3, Lambda and IAM grants over a set of buckets and functions
+func syntheticPolicyData(t testing.TB, principals int) *PolicyData {
+	var users, roles []string
+	for i := 0; i < principals; i++ {
+		users = append(users, fmt.Sprintf(`{
+			"Arn": "arn:aws:iam::123456789012:user/user-%[1]d", "UserName": "user-%[1]d", "UserId": "AIDA%[1]d",
+			"UserPolicyList": [{"PolicyName": "inline", "PolicyDocument": {"Version": "2012-10-17", "Statement": [
+				{"Effect": "Allow", "Action": ["s3:GetObject", "s3:PutObject", "s3:PutBucketPolicy"], "Resource": "*"},
+				{"Effect": "Allow", "Action": "iam:PassRole", "Resource": "arn:aws:iam::123456789012:role/role-%[1]d"}
+			]}}]
+		}`, i))
+		roles = append(roles, fmt.Sprintf(`{
+			"Arn": "arn:aws:iam::123456789012:role/role-%[1]d", "RoleName": "role-%[1]d", "RoleId": "AROA%[1]d",
+			"AssumeRolePolicyDocument": {"Version": "2012-10-17", "Statement": [
+				{"Effect": "Allow", "Principal": {"AWS": "arn:aws:iam::123456789012:user/user-%[1]d"}, "Action": "sts:AssumeRole"}
+			]},
+			"RolePolicyList": [{"PolicyName": "inline", "PolicyDocument": {"Version": "2012-10-17", "Statement": [
+				{"Effect": "Allow", "Action": ["lambda:UpdateFunctionCode", "lambda:InvokeFunction", "s3:*"], "Resource": "*"},
+				{"Effect": "Deny", "Action": "s3:DeleteBucket", "Resource": "*"}
+			]}}]
+		}`, i))
+	}
+	var gaad types.Gaad
+	require.NoError(t, json.Unmarshal([]byte(fmt.Sprintf(`{"UserDetailList": [%s], "RoleDetailList": [%s]}`,
+		strings.Join(users, ","), strings.Join(roles, ","))), &gaad))
+
+	var resources []types.EnrichedResourceDescription
+	for i := 0; i < principals; i++ {
+		resources = append(resources,
+			types.NewEnrichedResourceDescription(fmt.Sprintf("bucket-%d", i), "AWS::S3::Bucket", "us-east-1", "123456789012", map[string]string{}),
+			types.NewEnrichedResourceDescription(fmt.Sprintf("function-%d", i), "AWS::Lambda::Function", "us-east-1", "123456789012", map[string]string{}),
+		)
+	}
+	return NewPolicyData(&gaad, nil, make(map[string]*types.Policy), &resources)
+}
+
+func TestAnalyzePrincipalPermissionsDeterministic(t *testing.T) {
+	analyze := func(workers int) ([]byte, []string) {
+		ga := NewGaadAnalyzer(syntheticPolicyData(t, 20))
+		ga.SetWorkers(workers)
+		summary, err := ga.AnalyzePrincipalPermissions()
+		require.NoError(t, err)
+
+		raw, err := json.Marshal(summary)
+		require.NoError(t, err)
+		var edges []string
+		for _, result := range summary.FullResults() {
+			edges = append(edges, result.Resource.Arn.String()+" "+result.Action)
+		}
+		return raw, edges
+	}
+
+	serial, serialEdges := analyze(1)
+	require.NotEmpty(t, serialEdges)
+	for _, workers := range []int{4, 32} {
+		parallel, parallelEdges := analyze(workers)
+		assert.JSONEq(t, string(serial), string(parallel), "summary with %d workers", workers)
+		assert.Equal(t, serialEdges, parallelEdges, "full results with %d workers", workers)
+	}
+}
+
+func TestSetWorkersDefault(t *testing.T) {
+	ga := NewGaadAnalyzer(syntheticPolicyData(t, 1))
+	ga.SetWorkers(0)
+	assert.Equal(t, DefaultAnalyzerWorkers, ga.workers)
+	ga.SetWorkers(2)
+	assert.Equal(t, 2, ga.workers)
+}
+
+// BenchmarkAnalyzePrincipalPermissions compares worker counts on a synthetic
+// account, to tune DefaultAnalyzerWorkers:
+//
+//	go test -run '^$' -bench AnalyzePrincipalPermissions ./pkg/iam/aws/
+func BenchmarkAnalyzePrincipalPermissions(b *testing.B) {
+	pd := syntheticPolicyData(b, 50)
+	for _, workers := range []int{1, 4, DefaultAnalyzerWorkers} {
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			ga := NewGaadAnalyzer(pd)
+			ga.SetWorkers(workers)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := ga.AnalyzePrincipalPermissions(); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestAnalyzePrincipalPermissionsBoundary(t *testing.T) {
+	// Boundaries are as GetAccountAuthorizationDetails reports them
+	var gaad types.Gaad
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"UserDetailList": [
+			{
+				"Arn": "arn:aws:iam::123456789012:user/bounded", "UserName": "bounded", "UserId": "AIDABOUNDED",
+				"UserPolicyList": [{"PolicyName": "iam-users", "PolicyDocument": {"Version": "2012-10-17", "Statement": [
+					{"Effect": "Allow", "Action": ["iam:CreateAccessKey", "iam:CreateLoginProfile"], "Resource": "*"}
+				]}}],
+				"PermissionsBoundary": {"PermissionsBoundaryType": "Policy", "PermissionsBoundaryArn": "arn:aws:iam::123456789012:policy/access-key-boundary"}
+			},
+			{
+				"Arn": "arn:aws:iam::123456789012:user/unbounded", "UserName": "unbounded", "UserId": "AIDAUNBOUNDED",
+				"UserPolicyList": [{"PolicyName": "iam-users", "PolicyDocument": {"Version": "2012-10-17", "Statement": [
+					{"Effect": "Allow", "Action": ["iam:CreateAccessKey", "iam:CreateLoginProfile"], "Resource": "*"}
+				]}}]
+			}
+		],
+		"Policies": [{
+			"PolicyName": "access-key-boundary", "Arn": "arn:aws:iam::123456789012:policy/access-key-boundary", "DefaultVersionId": "v1",
+			"PolicyVersionList": [{"VersionId": "v1", "IsDefaultVersion": true, "Document": {"Version": "2012-10-17", "Statement": [
+				{"Effect": "Allow", "Action": "iam:CreateAccessKey", "Resource": "*"}
+			]}}]
+		}]
+	}`), &gaad))
+	require.Equal(t, "arn:aws:iam::123456789012:policy/access-key-boundary", gaad.UserDetailList[0].PermissionsBoundary.PolicyArn)
+
+	var resources []types.EnrichedResourceDescription
+	summary, err := NewGaadAnalyzer(NewPolicyData(&gaad, nil, make(map[string]*types.Policy), &resources)).AnalyzePrincipalPermissions()
+	require.NoError(t, err)
+
+	decisions := func(principal string) map[string]EvaluationDecision {
+		value, ok := summary.Permissions.Load(principal)
+		require.True(t, ok)
+		resValue, ok := value.(*PrincipalPermissions).ResourcePerms.Load("arn:aws:iam::123456789012:user/unbounded")
+		require.True(t, ok)
+		rp := resValue.(*ResourcePermission)
+		byAction := make(map[string]EvaluationDecision)
+		for _, action := range append(rp.AllowedActions, rp.DeniedActions...) {
+			byAction[action.Name] = action.EvaluationResult.Decision
+		}
+		return byAction
+	}
+
+	assert.Equal(t, map[string]EvaluationDecision{
+		"iam:CreateAccessKey":    DecisionAllowedByBoundaryIntersection,
+		"iam:CreateLoginProfile": DecisionDeniedByBoundary,
+	}, decisions("arn:aws:iam::123456789012:user/bounded"))
+	assert.Equal(t, map[string]EvaluationDecision{
+		"iam:CreateAccessKey":    DecisionAllowed,
+		"iam:CreateLoginProfile": DecisionAllowed,
+	}, decisions("arn:aws:iam::123456789012:user/unbounded"))
+}
@@ -0,0 +1,398 @@
+package aws
+
+import (
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+	"github.com/praetorian-inc/nebula/pkg/types"
+)
+
+// Identity Center provisions each assigned permission set into the account as
+// a role under this path named AWSReservedSSO_<permission set>_<suffix>
+const (
+	identityCenterRolePath       = "/aws-reserved/sso.amazonaws.com/"
+	identityCenterRolePrefix     = "AWSReservedSSO_"
+	identityCenterInlinePolicy   = "AwsSSOInlinePolicy"
+	identityCenterAssumeAction   = "sts:AssumeRoleWithSAML"
+	identityCenterPrincipalUser  = "USER"
+	identityCenterPrincipalGroup = "GROUP"
+)
+
+// identityCenterRole is the role a permission set is provisioned as in one account
+type identityCenterRole struct {
+	accountID     string
+	permissionSet *types.IdentityCenterPermissionSet
+	roleArn       string
+	provisioned   bool // found in the GAAD rather than built from the permission set
+}
+
+// IdentityCenterAccess is what one Identity Center user or group can do in one
+// account through the permission sets assigned to it, directly or through a
+// group. AllowedActions are the privilege escalation actions the provisioned
+// roles were found to allow, the same set the GAAD analysis evaluates.
+type IdentityCenterAccess struct {
+	PrincipalArn   string   `json:"principal_arn"`
+	PrincipalType  string   `json:"principal_type"`
+	PrincipalName  string   `json:"principal_name"`
+	AccountID      string   `json:"account_id"`
+	PermissionSets []string `json:"permission_sets"`
+	RoleArns       []string `json:"role_arns"`
+	ViaGroups      []string `json:"via_groups,omitempty"`
+	Admin          bool     `json:"admin"`
+	AdminReason    string   `json:"admin_reason,omitempty"`
+	AllowedActions []string `json:"allowed_actions"`
+}
+
+// AddIdentityCenter adds an Identity Center export to the policy data. Each
+// permission set assigned to an account is matched to the role Identity
+// Center provisioned for it in the GAAD; where the GAAD does not cover the
+// account, the role is built from the permission set's policies and added to
+// the GAAD so the analyzer evaluates it like any other role. Customer managed
+// policies and AWS managed policies that are not in the GAAD cannot be
+// evaluated and are reported.
+func (pd *PolicyData) AddIdentityCenter(ic *types.IdentityCenter) error {
+	if pd.Gaad == nil {
+		pd.Gaad = &types.Gaad{}
+	}
+	roles, err := provisionIdentityCenterRoles(pd.Gaad, ic)
+	if err != nil {
+		return err
+	}
+	pd.IdentityCenter = ic
+	pd.identityCenterRoles = roles
+
+	known := make(map[string]bool, len(pd.Gaad.Policies))
+	for _, policy := range pd.Gaad.Policies {
+		known[policy.Arn] = true
+	}
+	missing := make(map[string]bool)
+	for _, role := range roles {
+		if role.provisioned {
+			continue
+		}
+		for _, attached := range identityCenterRoleByArn(pd.Gaad, role.roleArn).AttachedManagedPolicies {
+			if !known[attached.PolicyArn] {
+				missing[attached.PolicyArn] = true
+			}
+		}
+	}
+	if len(missing) > 0 {
+		slog.Warn(fmt.Sprintf("%d managed policies attached to Identity Center permission sets are not in the GAAD and will not be evaluated", len(missing)))
+		for _, policyArn := range sortedKeys(missing) {
+			slog.Debug("Permission set policy not in GAAD", "policy", policyArn)
+		}
+	}
+	return nil
+}
+
+// provisionIdentityCenterRoles resolves the role for every assigned
+// (account, permission set) pair, appending built roles to the GAAD
+func provisionIdentityCenterRoles(gaad *types.Gaad, ic *types.IdentityCenter) ([]identityCenterRole, error) {
+	partition := "aws"
+	if parsed, err := arn.Parse(ic.InstanceArn); err == nil {
+		partition = parsed.Partition
+	}
+
+	permissionSets := make(map[string]*types.IdentityCenterPermissionSet, len(ic.PermissionSets))
+	for i := range ic.PermissionSets {
+		permissionSets[ic.PermissionSets[i].PermissionSetArn] = &ic.PermissionSets[i]
+	}
+
+	// Roles already provisioned, keyed by account and permission set name
+	existing := make(map[string]string)
+	for _, role := range gaad.RoleDetailList {
+		if !strings.HasPrefix(role.Path, identityCenterRolePath) || !strings.HasPrefix(role.RoleName, identityCenterRolePrefix) {
+			continue
+		}
+		name := strings.TrimPrefix(role.RoleName, identityCenterRolePrefix)
+		if i := strings.LastIndex(name, "_"); i > 0 {
+			name = name[:i]
+		}
+		existing[accountFromArn(role.Arn)+"/"+name] = role.Arn
+	}
+
+	roles := make([]identityCenterRole, 0)
+	seen := make(map[string]bool)
+	for _, assignment := range ic.AccountAssignments {
+		key := assignment.AccountId + "/" + assignment.PermissionSetArn
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		ps, ok := permissionSets[assignment.PermissionSetArn]
+		if !ok {
+			slog.Warn("Account assignment references an unknown permission set", "permission_set", assignment.PermissionSetArn, "account", assignment.AccountId)
+			continue
+		}
+		if roleArn, ok := existing[assignment.AccountId+"/"+ps.Name]; ok {
+			roles = append(roles, identityCenterRole{accountID: assignment.AccountId, permissionSet: ps, roleArn: roleArn, provisioned: true})
+			continue
+		}
+
+		role, err := permissionSetRole(partition, assignment.AccountId, ps)
+		if err != nil {
+			return nil, err
+		}
+		gaad.RoleDetailList = append(gaad.RoleDetailList, role)
+		roles = append(roles, identityCenterRole{accountID: assignment.AccountId, permissionSet: ps, roleArn: role.Arn})
+	}
+	return roles, nil
+}
+
+// permissionSetRole builds the role a permission set is provisioned as. The
+// random suffix Identity Center appends to the role name is not knowable
+// offline, so built roles are named without it.
+func permissionSetRole(partition, accountID string, ps *types.IdentityCenterPermissionSet) (types.RoleDL, error) {
+	roleName := identityCenterRolePrefix + ps.Name
+	role := types.RoleDL{
+		Arn:      fmt.Sprintf("arn:%s:iam::%s:role%s%s", partition, accountID, identityCenterRolePath, roleName),
+		RoleName: roleName,
+		Path:     identityCenterRolePath,
+	}
+
+	if ps.InlinePolicy != "" {
+		policy, err := types.NewPolicyFromJSON([]byte(ps.InlinePolicy))
+		if err != nil {
+			return types.RoleDL{}, fmt.Errorf("failed to parse inline policy of permission set %s: %w", ps.Name, err)
+		}
+		role.RolePolicyList = append(role.RolePolicyList, types.PrincipalPL{PolicyName: identityCenterInlinePolicy, PolicyDocument: *policy})
+	}
+	for _, managed := range ps.AttachedManagedPolicies {
+		role.AttachedManagedPolicies = append(role.AttachedManagedPolicies, types.ManagedPL{PolicyName: managed.Name, PolicyArn: managed.Arn})
+	}
+	for _, ref := range ps.CustomerManagedPolicyReferences {
+		role.AttachedManagedPolicies = append(role.AttachedManagedPolicies, types.ManagedPL{PolicyName: ref.Name, PolicyArn: customerManagedPolicyArn(partition, accountID, ref)})
+	}
+	if boundary := ps.PermissionsBoundary; boundary != nil {
+		if boundary.ManagedPolicyArn != "" {
+			role.PermissionsBoundary = types.ManagedPL{PolicyName: boundary.ManagedPolicyArn[strings.LastIndex(boundary.ManagedPolicyArn, "/")+1:], PolicyArn: boundary.ManagedPolicyArn}
+		} else if ref := boundary.CustomerManagedPolicyReference; ref != nil {
+			role.PermissionsBoundary = types.ManagedPL{PolicyName: ref.Name, PolicyArn: customerManagedPolicyArn(partition, accountID, *ref)}
+		}
+	}
+	return role, nil
+}
+
+// customerManagedPolicyArn is the ARN a customer managed policy reference
+// resolves to in one account
+func customerManagedPolicyArn(partition, accountID string, ref types.IdentityCenterCustomerManagedPolicy) string {
+	path := ref.Path
+	if path == "" {
+		path = "/"
+	}
+	return fmt.Sprintf("arn:%s:iam::%s:policy%s%s", partition, accountID, path, ref.Name)
+}
+
+func identityCenterRoleByArn(gaad *types.Gaad, roleArn string) types.RoleDL {
+	for _, role := range gaad.RoleDetailList {
+		if role.Arn == roleArn {
+			return role
+		}
+	}
+	return types.RoleDL{}
+}
+
+// identityCenterGrant is one principal's path to one provisioned role
+type identityCenterGrant struct {
+	principalArn  string
+	principalType string
+	principalName string
+	viaGroup      string
+	role          identityCenterRole
+}
+
+// identityCenterGrants expands the account assignments into per-principal
+// grants. A group assignment grants the group and each of its members.
+func identityCenterGrants(pd *PolicyData) []identityCenterGrant {
+	ic := pd.IdentityCenter
+	partition := "aws"
+	if parsed, err := arn.Parse(ic.InstanceArn); err == nil {
+		partition = parsed.Partition
+	}
+
+	userNames := make(map[string]string, len(ic.Users))
+	for _, user := range ic.Users {
+		userNames[user.UserId] = user.UserName
+		if user.UserName == "" {
+			userNames[user.UserId] = user.DisplayName
+		}
+	}
+	groupNames := make(map[string]string, len(ic.Groups))
+	for _, group := range ic.Groups {
+		groupNames[group.GroupId] = group.DisplayName
+	}
+	members := make(map[string][]string)
+	for _, membership := range ic.GroupMemberships {
+		if membership.MemberId.UserId != "" {
+			members[membership.GroupId] = append(members[membership.GroupId], membership.MemberId.UserId)
+		}
+	}
+	roles := make(map[string]identityCenterRole, len(pd.identityCenterRoles))
+	for _, role := range pd.identityCenterRoles {
+		roles[role.accountID+"/"+role.permissionSet.PermissionSetArn] = role
+	}
+
+	name := func(names map[string]string, id string) string {
+		if names[id] != "" {
+			return names[id]
+		}
+		return id
+	}
+	user := func(id string, role identityCenterRole, viaGroup string) identityCenterGrant {
+		return identityCenterGrant{
+			principalArn:  identityStorePrincipalArn(partition, "user", id),
+			principalType: identityCenterPrincipalUser,
+			principalName: name(userNames, id),
+			viaGroup:      viaGroup,
+			role:          role,
+		}
+	}
+
+	grants := make([]identityCenterGrant, 0)
+	for _, assignment := range ic.AccountAssignments {
+		role, ok := roles[assignment.AccountId+"/"+assignment.PermissionSetArn]
+		if !ok {
+			continue
+		}
+		switch assignment.PrincipalType {
+		case identityCenterPrincipalUser:
+			grants = append(grants, user(assignment.PrincipalId, role, ""))
+		case identityCenterPrincipalGroup:
+			groupName := name(groupNames, assignment.PrincipalId)
+			grants = append(grants, identityCenterGrant{
+				principalArn:  identityStorePrincipalArn(partition, "group", assignment.PrincipalId),
+				principalType: identityCenterPrincipalGroup,
+				principalName: groupName,
+				role:          role,
+			})
+			for _, userID := range members[assignment.PrincipalId] {
+				grants = append(grants, user(userID, role, groupName))
+			}
+		default:
+			slog.Debug("Skipping account assignment with unknown principal type", "type", assignment.PrincipalType, "principal", assignment.PrincipalId)
+		}
+	}
+	return grants
+}
+
+func identityStorePrincipalArn(partition, kind, id string) string {
+	return fmt.Sprintf("arn:%s:identitystore:::%s/%s", partition, kind, id)
+}
+
+// applyIdentityCenterAssignments records that each assigned user and group
+// can sign in to its provisioned roles, so the assignments appear in
+// FullResults and the graph as sts:AssumeRoleWithSAML edges
+func applyIdentityCenterAssignments(pd *PolicyData, summary *PermissionsSummary) {
+	if pd.IdentityCenter == nil {
+		return
+	}
+	for _, grant := range identityCenterGrants(pd) {
+		details := fmt.Sprintf("Identity Center assignment of permission set %s", grant.role.permissionSet.Name)
+		if grant.viaGroup != "" {
+			details += " through group " + grant.viaGroup
+		}
+		summary.AddPermission(grant.principalArn, grant.role.roleArn, identityCenterAssumeAction, true, &EvaluationResult{
+			Allowed:           true,
+			EvaluationDetails: details,
+			Action:            identityCenterAssumeAction,
+		})
+	}
+}
+
+// FindIdentityCenterAccess rolls the analyzed role permissions up to each
+// Identity Center user and group per account. Principals that are admin in an
+// account sort first, then by account and name.
+func FindIdentityCenterAccess(pd *PolicyData, summary *PermissionsSummary) []IdentityCenterAccess {
+	if pd == nil || pd.IdentityCenter == nil || summary == nil {
+		return nil
+	}
+
+	managed := make(map[string]*types.PoliciesDL, len(pd.Gaad.Policies))
+	for i := range pd.Gaad.Policies {
+		managed[pd.Gaad.Policies[i].Arn] = &pd.Gaad.Policies[i]
+	}
+
+	type accumulator struct {
+		access         IdentityCenterAccess
+		permissionSets map[string]bool
+		roles          map[string]bool
+		groups         map[string]bool
+	}
+	byKey := make(map[string]*accumulator)
+	for _, grant := range identityCenterGrants(pd) {
+		key := grant.principalArn + "|" + grant.role.accountID
+		acc, ok := byKey[key]
+		if !ok {
+			acc = &accumulator{
+				access: IdentityCenterAccess{
+					PrincipalArn:  grant.principalArn,
+					PrincipalType: grant.principalType,
+					PrincipalName: grant.principalName,
+					AccountID:     grant.role.accountID,
+				},
+				permissionSets: make(map[string]bool),
+				roles:          make(map[string]bool),
+				groups:         make(map[string]bool),
+			}
+			byKey[key] = acc
+		}
+		acc.permissionSets[grant.role.permissionSet.Name] = true
+		acc.roles[grant.role.roleArn] = true
+		if grant.viaGroup != "" {
+			acc.groups[grant.viaGroup] = true
+		}
+		if !acc.access.Admin {
+			if reason := roleAdminReason(identityCenterRoleByArn(pd.Gaad, grant.role.roleArn), managed); reason != "" {
+				acc.access.Admin = true
+				acc.access.AdminReason = fmt.Sprintf("permission set %s: %s", grant.role.permissionSet.Name, reason)
+			}
+		}
+	}
+
+	report := make([]IdentityCenterAccess, 0, len(byKey))
+	for _, acc := range byKey {
+		acc.access.PermissionSets = sortedKeys(acc.permissionSets)
+		acc.access.RoleArns = sortedKeys(acc.roles)
+		if len(acc.groups) > 0 {
+			acc.access.ViaGroups = sortedKeys(acc.groups)
+		}
+		acc.access.AllowedActions = allowedActionNames(summary, acc.access.RoleArns)
+		report = append(report, acc.access)
+	}
+	sort.Slice(report, func(i, j int) bool {
+		if report[i].Admin != report[j].Admin {
+			return report[i].Admin
+		}
+		if report[i].AccountID != report[j].AccountID {
+			return report[i].AccountID < report[j].AccountID
+		}
+		return report[i].PrincipalName < report[j].PrincipalName
+	})
+	return report
+}
+
+// allowedActionNames returns the distinct actions the principals are allowed
+// on any resource
+func allowedActionNames(summary *PermissionsSummary, principalArns []string) []string {
+	actions := make(map[string]bool)
+	for _, principalArn := range principalArns {
+		val, ok := summary.Permissions.Load(principalArn)
+		if !ok {
+			continue
+		}
+		val.(*PrincipalPermissions).ResourcePerms.Range(func(_, resValue any) bool {
+			rp := resValue.(*ResourcePermission)
+			rp.mu.RLock()
+			for _, action := range rp.AllowedActions {
+				actions[action.Name] = true
+			}
+			rp.mu.RUnlock()
+			return true
+		})
+	}
+	return sortedKeys(actions)
+}
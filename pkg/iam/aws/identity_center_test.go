@@ -0,0 +1,131 @@
+package aws
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/praetorian-inc/nebula/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const identityCenterGaad = `{
+  "RoleDetailList": [{
+    "Arn": "arn:aws:iam::111122223333:role/aws-reserved/sso.amazonaws.com/AWSReservedSSO_ReadOnly_0a1b2c3d4e5f6a7b",
+    "RoleName": "AWSReservedSSO_ReadOnly_0a1b2c3d4e5f6a7b",
+    "Path": "/aws-reserved/sso.amazonaws.com/",
+    "RolePolicyList": [{
+      "PolicyName": "AwsSSOInlinePolicy",
+      "PolicyDocument": {"Version": "2012-10-17", "Statement": [{"Effect": "Allow", "Action": "s3:Get*", "Resource": "*"}]}
+    }]
+  }]
+}`
+
+const identityCenterExport = `{
+  "InstanceArn": "arn:aws:sso:::instance/ssoins-1111111111111111",
+  "IdentityStoreId": "d-1111111111",
+  "PermissionSets": [
+    {
+      "PermissionSetArn": "arn:aws:sso:::permissionSet/ssoins-1111111111111111/ps-admin",
+      "Name": "Admin",
+      "AttachedManagedPolicies": [{"Arn": "arn:aws:iam::aws:policy/AdministratorAccess", "Name": "AdministratorAccess"}]
+    },
+    {
+      "PermissionSetArn": "arn:aws:sso:::permissionSet/ssoins-1111111111111111/ps-readonly",
+      "Name": "ReadOnly",
+      "InlinePolicy": "{\"Version\":\"2012-10-17\",\"Statement\":[{\"Effect\":\"Allow\",\"Action\":\"s3:Get*\",\"Resource\":\"*\"}]}"
+    },
+    {
+      "PermissionSetArn": "arn:aws:sso:::permissionSet/ssoins-1111111111111111/ps-deploy",
+      "Name": "Deploy",
+      "InlinePolicy": "{\"Version\":\"2012-10-17\",\"Statement\":[{\"Effect\":\"Allow\",\"Action\":\"lambda:*\",\"Resource\":\"*\"}]}",
+      "CustomerManagedPolicyReferences": [{"Name": "deploy-extra", "Path": "/teams/"}],
+      "PermissionsBoundary": {"CustomerManagedPolicyReference": {"Name": "deploy-boundary"}}
+    }
+  ],
+  "AccountAssignments": [
+    {"AccountId": "444455556666", "PermissionSetArn": "arn:aws:sso:::permissionSet/ssoins-1111111111111111/ps-admin", "PrincipalType": "GROUP", "PrincipalId": "g-admins"},
+    {"AccountId": "111122223333", "PermissionSetArn": "arn:aws:sso:::permissionSet/ssoins-1111111111111111/ps-readonly", "PrincipalType": "USER", "PrincipalId": "u-alice"},
+    {"AccountId": "444455556666", "PermissionSetArn": "arn:aws:sso:::permissionSet/ssoins-1111111111111111/ps-deploy", "PrincipalType": "USER", "PrincipalId": "u-bob"}
+  ],
+  "Users": [
+    {"UserId": "u-alice", "UserName": "alice@example.com"},
+    {"UserId": "u-bob", "UserName": "bob@example.com"}
+  ],
+  "Groups": [{"GroupId": "g-admins", "DisplayName": "Admins"}],
+  "GroupMemberships": [{"GroupId": "g-admins", "MemberId": {"UserId": "u-alice"}}]
+}`
+
+func TestAddIdentityCenter(t *testing.T) {
+	var gaad types.Gaad
+	require.NoError(t, json.Unmarshal([]byte(identityCenterGaad), &gaad))
+	var ic types.IdentityCenter
+	require.NoError(t, json.Unmarshal([]byte(identityCenterExport), &ic))
+
+	pd := &PolicyData{Gaad: &gaad}
+	require.NoError(t, pd.AddIdentityCenter(&ic))
+	require.Len(t, gaad.RoleDetailList, 3, "the provisioned ReadOnly role is reused, Admin and Deploy are built")
+
+	deploy := identityCenterRoleByArn(&gaad, "arn:aws:iam::444455556666:role/aws-reserved/sso.amazonaws.com/AWSReservedSSO_Deploy")
+	require.Len(t, deploy.RolePolicyList, 1)
+	assert.Equal(t, "AwsSSOInlinePolicy", deploy.RolePolicyList[0].PolicyName)
+	assert.Equal(t, []types.ManagedPL{{PolicyName: "deploy-extra", PolicyArn: "arn:aws:iam::444455556666:policy/teams/deploy-extra"}}, deploy.AttachedManagedPolicies)
+	assert.Equal(t, "arn:aws:iam::444455556666:policy/deploy-boundary", deploy.PermissionsBoundary.PolicyArn)
+}
+
+func TestAddIdentityCenterInvalidInlinePolicy(t *testing.T) {
+	ic := types.IdentityCenter{
+		PermissionSets:     []types.IdentityCenterPermissionSet{{PermissionSetArn: "ps-broken", Name: "Broken", InlinePolicy: "{}"}},
+		AccountAssignments: []types.IdentityCenterAccountAssignment{{AccountId: "111122223333", PermissionSetArn: "ps-broken", PrincipalType: "USER", PrincipalId: "u-1"}},
+	}
+	pd := &PolicyData{Gaad: &types.Gaad{}}
+	assert.ErrorContains(t, pd.AddIdentityCenter(&ic), "Broken")
+}
+
+func TestIdentityCenterAnalysis(t *testing.T) {
+	var gaad types.Gaad
+	require.NoError(t, json.Unmarshal([]byte(identityCenterGaad), &gaad))
+	gaad.Policies = []types.PoliciesDL{strResourcetoType[types.PoliciesDL](administratorAccessStr)}
+	var ic types.IdentityCenter
+	require.NoError(t, json.Unmarshal([]byte(identityCenterExport), &ic))
+
+	resources := []types.EnrichedResourceDescription{}
+	pd := NewPolicyData(&gaad, nil, nil, &resources)
+	require.NoError(t, pd.AddIdentityCenter(&ic))
+	summary, err := NewGaadAnalyzer(pd).AnalyzePrincipalPermissions()
+	require.NoError(t, err)
+
+	adminRole := "arn:aws:iam::444455556666:role/aws-reserved/sso.amazonaws.com/AWSReservedSSO_Admin"
+	var samlEdges []string
+	for _, result := range summary.FullResults() {
+		if result.Action == "sts:AssumeRoleWithSAML" && result.Resource.Arn.String() == adminRole {
+			samlEdges = append(samlEdges, result.Principal.(string))
+		}
+	}
+	assert.ElementsMatch(t, []string{
+		"arn:aws:identitystore:::group/g-admins",
+		"arn:aws:identitystore:::user/u-alice",
+	}, samlEdges, "a group assignment reaches the group and its members")
+
+	access := summary.IdentityCenterAccess
+	require.Len(t, access, 4)
+
+	assert.Equal(t, "Admins", access[0].PrincipalName)
+	assert.True(t, access[0].Admin)
+	assert.Equal(t, "permission set Admin: managed policy AdministratorAccess", access[0].AdminReason)
+
+	assert.Equal(t, "alice@example.com", access[1].PrincipalName)
+	assert.Equal(t, "444455556666", access[1].AccountID)
+	assert.True(t, access[1].Admin)
+	assert.Equal(t, []string{"Admins"}, access[1].ViaGroups)
+
+	readOnly := access[2]
+	assert.Equal(t, "alice@example.com", readOnly.PrincipalName)
+	assert.Equal(t, "111122223333", readOnly.AccountID)
+	assert.False(t, readOnly.Admin)
+	assert.Equal(t, []string{"arn:aws:iam::111122223333:role/aws-reserved/sso.amazonaws.com/AWSReservedSSO_ReadOnly_0a1b2c3d4e5f6a7b"}, readOnly.RoleArns)
+
+	assert.Equal(t, "bob@example.com", access[3].PrincipalName)
+	assert.Equal(t, []string{"Deploy"}, access[3].PermissionSets)
+	assert.False(t, access[3].Admin)
+}
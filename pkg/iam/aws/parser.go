@@ -36,6 +36,7 @@ type ResourcePermission struct {
 type ResourceAction struct {
 	Name             string
 	EvaluationResult *EvaluationResult
+	Covers           int `json:",omitempty"` // Number of actions a compressed wildcard stands for
 }
 
 // AddAction safely adds an action to the appropriate list
@@ -55,6 +56,21 @@ func (rp *ResourcePermission) AddAction(action string, eval *EvaluationResult) {
 	}
 }
 
+// sortActions orders the allowed and denied actions by name. Actions recorded
+// twice for the same name are ordered by their evaluation result.
+func (rp *ResourcePermission) sortActions() {
+	rp.mu.Lock()
+	defer rp.mu.Unlock()
+	for _, actions := range [][]*ResourceAction{rp.AllowedActions, rp.DeniedActions} {
+		sort.SliceStable(actions, func(i, j int) bool {
+			if actions[i].Name != actions[j].Name {
+				return actions[i].Name < actions[j].Name
+			}
+			return actions[i].EvaluationResult.String() < actions[j].EvaluationResult.String()
+		})
+	}
+}
+
 // func containsString(slice []s
 
 // AddResourcePermission safely adds or updates a resource permission
@@ -86,20 +102,31 @@ func (p *PrincipalPermissions) MarshalJSON() ([]byte, error) {
 	p.mu.RLock()
 	defer p.mu.RUnlock()
 
-	// Convert sync.Map to regular map for marshaling
+	return json.Marshal(p.marshalable(nil))
+}
+
+type principalPermissionsJSON struct {
+	PrincipalArn  string                         `json:"principal_arn"`
+	ResourcePerms map[string]*ResourcePermission `json:"resource_permissions"`
+}
+
+// marshalable converts the sync.Map to a regular map for marshaling, compressing
+// allowed actions when a catalog is given
+func (p *PrincipalPermissions) marshalable(catalog ActionCatalog) principalPermissionsJSON {
 	resourcePerms := make(map[string]*ResourcePermission)
 	p.ResourcePerms.Range(func(key, value interface{}) bool {
-		resourcePerms[key.(string)] = value.(*ResourcePermission)
+		resourcePerm := value.(*ResourcePermission)
+		if catalog != nil {
+			resourcePerm = resourcePerm.compressed(catalog)
+		}
+		resourcePerms[key.(string)] = resourcePerm
 		return true
 	})
 
-	return json.Marshal(struct {
-		PrincipalArn  string                         `json:"principal_arn"`
-		ResourcePerms map[string]*ResourcePermission `json:"resource_permissions"`
-	}{
+	return principalPermissionsJSON{
 		PrincipalArn:  p.PrincipalArn,
 		ResourcePerms: resourcePerms,
-	})
+	}
 }
 
 // PrincipalPermissions contains all permissions for a single principal
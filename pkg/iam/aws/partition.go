@@ -0,0 +1,48 @@
+package aws
+
+import (
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+)
+
+// arnPartitionPattern matches the partition segment of an ARN in the
+// commercial, China and GovCloud partitions
+const arnPartitionPattern = `aws(?:-cn|-us-gov)?`
+
+// awsPartitions are the partitions the analyzer understands
+var awsPartitions = []string{"aws", "aws-cn", "aws-us-gov"}
+
+// Service principals end in amazonaws.com everywhere except for the China
+// regions, where many services use amazonaws.com.cn
+const (
+	servicePrincipalSuffix   = ".amazonaws.com"
+	servicePrincipalSuffixCN = ".amazonaws.com.cn"
+)
+
+// isServicePrincipal reports whether s names an AWS service principal in any partition
+func isServicePrincipal(s string) bool {
+	return strings.HasSuffix(s, servicePrincipalSuffix) || strings.HasSuffix(s, servicePrincipalSuffixCN)
+}
+
+// canonicalServicePrincipal maps a China service principal to its commercial
+// form so one service matches regardless of the partition it was written for
+func canonicalServicePrincipal(s string) string {
+	if strings.HasSuffix(s, servicePrincipalSuffixCN) {
+		return strings.TrimSuffix(s, ".cn")
+	}
+	return s
+}
+
+// hasServicePrincipalDomain reports whether s contains a service principal
+// domain, as in legacy principal ARNs that embed it in the resource
+func hasServicePrincipalDomain(s string) bool {
+	return strings.Contains(s, servicePrincipalSuffix)
+}
+
+// isAWSManagedPolicyArn reports whether an ARN names an AWS managed policy,
+// which lives in the reserved "aws" account of its partition
+func isAWSManagedPolicyArn(policyArn string) bool {
+	parsed, err := arn.Parse(policyArn)
+	return err == nil && parsed.Service == "iam" && parsed.AccountID == "aws"
+}
@@ -0,0 +1,50 @@
+package aws
+
+import (
+	"testing"
+
+	"github.com/praetorian-inc/nebula/pkg/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServicePrincipalPartitions(t *testing.T) {
+	assert.True(t, isServicePrincipal("ec2.amazonaws.com"))
+	assert.True(t, isServicePrincipal("ec2.amazonaws.com.cn"))
+	assert.False(t, isServicePrincipal("arn:aws-cn:iam::123456789012:root"))
+
+	assert.Equal(t, "ec2.amazonaws.com", canonicalServicePrincipal("ec2.amazonaws.com.cn"))
+	assert.Equal(t, "ec2.amazonaws.com", canonicalServicePrincipal("ec2.amazonaws.com"))
+
+	assert.Equal(t, "service", assumerType("lambda.amazonaws.com.cn"))
+	assert.Equal(t, "role", assumerType("arn:aws-us-gov:iam::123456789012:role/Deployer"))
+}
+
+func TestMatchesPrincipalAcrossPartitions(t *testing.T) {
+	chinaTrust := &types.Principal{Service: types.NewDynaString([]string{"ec2.amazonaws.com.cn"})}
+	assert.True(t, matchesPrincipal(chinaTrust, "ec2.amazonaws.com"), "China service principal matches the cached service")
+	assert.False(t, matchesPrincipal(chinaTrust, "lambda.amazonaws.com"))
+
+	govTrust := &types.Principal{AWS: types.NewDynaString([]string{"arn:aws-us-gov:iam::123456789012:root"})}
+	assert.True(t, matchesPrincipal(govTrust, "arn:aws-us-gov:iam::123456789012:role/Deployer"))
+	assert.False(t, matchesPrincipal(govTrust, "arn:aws:iam::123456789012:role/Deployer"), "partitions do not trust each other")
+}
+
+func TestGovCloudManagedPolicies(t *testing.T) {
+	assert.True(t, isAWSManagedPolicyArn("arn:aws-us-gov:iam::aws:policy/ReadOnlyAccess"))
+	assert.True(t, isAWSManagedAdminPolicy("arn:aws-us-gov:iam::aws:policy/AdministratorAccess"))
+	assert.False(t, isAWSManagedPolicyArn("arn:aws-us-gov:iam::123456789012:policy/Custom"))
+
+	attached := []types.ManagedPL{
+		{PolicyName: "AdministratorAccess", PolicyArn: "arn:aws-us-gov:iam::aws:policy/AdministratorAccess"},
+		{PolicyName: "Custom", PolicyArn: "arn:aws-us-gov:iam::123456789012:policy/Custom"},
+	}
+	assert.Equal(t, []string{"arn:aws-us-gov:iam::123456789012:policy/Custom"}, customerManagedPolicyArns(attached))
+
+	role := types.RoleDL{Arn: "arn:aws-us-gov:iam::123456789012:role/Admin", AttachedManagedPolicies: attached[:1]}
+	assert.Equal(t, "managed policy AdministratorAccess", roleAdminReason(role, nil))
+}
+
+func TestDeterminePrincipalTypeGovCloud(t *testing.T) {
+	assert.Equal(t, PrincipalTypeUser, determinePrincipalType("arn:aws-us-gov:iam::123456789012:user/alice"))
+	assert.Equal(t, PrincipalTypeRole, determinePrincipalType("arn:aws-cn:iam::123456789012:role/Deployer"))
+}
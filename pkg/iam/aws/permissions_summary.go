@@ -8,8 +8,24 @@ import (
 
 // PermissionsSummary maps principal ARNs to their permissions
 type PermissionsSummary struct {
-	Permissions sync.Map // Key is principal ARN, value is *PrincipalPermissions
-	mu          sync.RWMutex
+	Permissions       sync.Map // Key is principal ARN, value is *PrincipalPermissions
+	PolicyIssues      []PolicyIssue
+	AdminRoleAssumers []AdminRoleAssumers
+	EffectiveAdmins   []EffectiveAdmin
+	// MaxHops is the assume-role chain depth the admin analyses walked to, zero
+	// for no limit. EffectiveAdminsHopsCapped is set when longer chains were cut.
+	MaxHops                   int
+	EffectiveAdminsHopsCapped bool
+	// FederatedTrust lists SAML and OIDC role trusts with missing subject or audience conditions
+	FederatedTrust []FederatedTrustFinding
+	// IdentityCenterAccess is set when the policy data includes an Identity Center export
+	IdentityCenterAccess []IdentityCenterAccess
+	// UnusedPermissions is set when the policy data includes last-accessed data
+	UnusedPermissions []UnusedPermissions
+	// AccountPublicAccess is set when the resources include account-level S3 Public Access Block settings
+	AccountPublicAccess []AccountPublicAccessFinding
+	actionCatalog       ActionCatalog // When set, allowed actions are compressed in JSON output
+	mu                  sync.RWMutex
 }
 
 // NewPermissionsSummary creates a new empty PermissionsSummary
@@ -32,6 +48,18 @@ func (ps *PermissionsSummary) AddPermission(principalArn, resourceArn, action st
 	perms.AddResourcePermission(resourceArn, action, allowed, eval)
 }
 
+// sortActions orders the actions of every resource permission by name, so the
+// summary is the same however the evaluation workers were scheduled
+func (ps *PermissionsSummary) sortActions() {
+	ps.Permissions.Range(func(_, value interface{}) bool {
+		value.(*PrincipalPermissions).ResourcePerms.Range(func(_, resValue interface{}) bool {
+			resValue.(*ResourcePermission).sortActions()
+			return true
+		})
+		return true
+	})
+}
+
 // GetPrincipals returns a sorted list of all principal ARNs
 func (ps *PermissionsSummary) GetPrincipals() []string {
 	principals := make([]string, 0)
@@ -43,19 +71,65 @@ func (ps *PermissionsSummary) GetPrincipals() []string {
 	return principals
 }
 
+// CompressActions makes JSON output collapse each resource's allowed actions
+// to service and verb wildcards where the catalog shows the whole set is
+// allowed. The graph relationships from FullResults are unaffected because the
+// privilege escalation queries match individual actions.
+func (ps *PermissionsSummary) CompressActions(catalog ActionCatalog) {
+	ps.actionCatalog = catalog
+}
+
 // MarshalJSON implements custom JSON marshaling
 func (ps *PermissionsSummary) MarshalJSON() ([]byte, error) {
 	// Convert sync.Map to regular map for marshaling
-	permissions := make(map[string]*PrincipalPermissions)
+	permissions := make(map[string]principalPermissionsJSON)
 	ps.Permissions.Range(func(key, value interface{}) bool {
-		permissions[key.(string)] = value.(*PrincipalPermissions)
+		perms := value.(*PrincipalPermissions)
+		perms.mu.RLock()
+		permissions[key.(string)] = perms.marshalable(ps.actionCatalog)
+		perms.mu.RUnlock()
 		return true
 	})
 
+	policyIssues := ps.PolicyIssues
+	if policyIssues == nil {
+		policyIssues = []PolicyIssue{}
+	}
+	adminRoleAssumers := ps.AdminRoleAssumers
+	if adminRoleAssumers == nil {
+		adminRoleAssumers = []AdminRoleAssumers{}
+	}
+	effectiveAdmins := ps.EffectiveAdmins
+	if effectiveAdmins == nil {
+		effectiveAdmins = []EffectiveAdmin{}
+	}
+	federatedTrust := ps.FederatedTrust
+	if federatedTrust == nil {
+		federatedTrust = []FederatedTrustFinding{}
+	}
+
 	return json.Marshal(struct {
-		Permissions map[string]*PrincipalPermissions `json:"permissions"`
+		Permissions           map[string]principalPermissionsJSON `json:"permissions"`
+		PolicyIssues          []PolicyIssue                       `json:"policy_issues"`
+		AdminRoleAssumers     []AdminRoleAssumers                 `json:"admin_role_assumers"`
+		EffectiveAdmins       []EffectiveAdmin                    `json:"effective_admins"`
+		MaxHops               int                                 `json:"max_hops"`
+		EffectiveAdminsCapped bool                                `json:"effective_admins_hops_capped,omitempty"`
+		FederatedTrust        []FederatedTrustFinding             `json:"federated_trust_findings"`
+		IdentityCenter        []IdentityCenterAccess              `json:"identity_center_access,omitempty"`
+		UnusedPermissions     []UnusedPermissions                 `json:"unused_permissions,omitempty"`
+		AccountPublicAccess   []AccountPublicAccessFinding        `json:"account_public_access_findings,omitempty"`
 	}{
-		Permissions: permissions,
+		Permissions:           permissions,
+		PolicyIssues:          policyIssues,
+		AdminRoleAssumers:     adminRoleAssumers,
+		EffectiveAdmins:       effectiveAdmins,
+		MaxHops:               ps.MaxHops,
+		EffectiveAdminsCapped: ps.EffectiveAdminsHopsCapped,
+		FederatedTrust:        federatedTrust,
+		IdentityCenter:        ps.IdentityCenterAccess,
+		UnusedPermissions:     ps.UnusedPermissions,
+		AccountPublicAccess:   ps.AccountPublicAccess,
 	})
 }
 
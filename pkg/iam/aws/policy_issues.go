@@ -0,0 +1,272 @@
+package aws
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/praetorian-inc/nebula/pkg/types"
+)
+
+// PolicyIssueKind identifies why a policy or statement could not be fully evaluated
+type PolicyIssueKind string
+
+const (
+	IssueUnresolvedManagedPolicy      PolicyIssueKind = "UnresolvedManagedPolicy"
+	IssueNoDefaultPolicyVersion       PolicyIssueKind = "NoDefaultPolicyVersion"
+	IssueInvalidEffect                PolicyIssueKind = "InvalidEffect"
+	IssueMissingAction                PolicyIssueKind = "MissingAction"
+	IssueMissingResource              PolicyIssueKind = "MissingResource"
+	IssueUnsupportedConditionOperator PolicyIssueKind = "UnsupportedConditionOperator"
+	IssuePolicyVariable               PolicyIssueKind = "PolicyVariable"
+	IssuePolicySizeExceeded           PolicyIssueKind = "PolicySizeExceeded"
+)
+
+// PolicyIssue records a policy statement the evaluator could not fully parse or
+// evaluate. Permissions derived from these statements may be missing or wrong,
+// so principals listed here have incomplete analysis.
+type PolicyIssue struct {
+	Kind           PolicyIssueKind `json:"kind"`
+	PolicyID       string          `json:"policy_id"`
+	PolicyType     string          `json:"policy_type"`
+	Principals     []string        `json:"principals"`
+	StatementIndex int             `json:"statement_index"`
+	Sid            string          `json:"sid,omitempty"`
+	Detail         string          `json:"detail"`
+}
+
+// policySizeLimits are the AWS quotas on policy document size, counted in
+// non-whitespace characters. GAAD documents above these were truncated,
+// mangled, or not produced by IAM and should not be trusted.
+var policySizeLimits = map[string]int{
+	"user-inline":  2048,
+	"group-inline": 5120,
+	"role-inline":  10240,
+	"trust":        4096,
+	"managed":      6144,
+}
+
+// supportedConditionOperators are the base operators evaluateCondition implements.
+// Anything else silently evaluates to false.
+var supportedConditionOperators = map[string]bool{
+	"StringEquals": true, "StringNotEquals": true, "StringEqualsIgnoreCase": true,
+	"StringLike": true, "StringNotLike": true,
+	"NumericEquals": true, "NumericNotEquals": true, "NumericLessThan": true,
+	"NumericLessThanEquals": true, "NumericGreaterThan": true, "NumericGreaterThanEquals": true,
+	"DateEquals": true, "DateNotEquals": true, "DateLessThan": true,
+	"DateLessThanEquals": true, "DateGreaterThan": true, "DateGreaterThanEquals": true,
+	"Bool": true, "IpAddress": true, "NotIpAddress": true,
+	"ArnEquals": true, "ArnLike": true, "ArnNotEquals": true, "ArnNotLike": true,
+	"Null": true,
+}
+
+// FindPolicyIssues checks every policy reachable from the GAAD for constructs
+// the evaluator does not handle. Managed policies are checked once and report
+// all principals they are attached to.
+func FindPolicyIssues(gaad *types.Gaad) []PolicyIssue {
+	if gaad == nil {
+		return nil
+	}
+
+	managed := make(map[string]*types.PoliciesDL, len(gaad.Policies))
+	for i := range gaad.Policies {
+		managed[gaad.Policies[i].Arn] = &gaad.Policies[i]
+	}
+	attachments := make(map[string][]string)
+	attach := func(principal string, policies []types.ManagedPL, boundary types.ManagedPL) {
+		for _, p := range policies {
+			attachments[p.PolicyArn] = append(attachments[p.PolicyArn], principal)
+		}
+		if boundary.PolicyArn != "" {
+			attachments[boundary.PolicyArn] = append(attachments[boundary.PolicyArn], principal)
+		}
+	}
+
+	var issues []PolicyIssue
+	for _, user := range gaad.UserDetailList {
+		for _, p := range user.UserPolicyList {
+			issues = append(issues, checkPolicyDocument(&p.PolicyDocument, inlinePolicyID(user.Arn, p.PolicyName), "user-inline", []string{user.Arn})...)
+		}
+		attach(user.Arn, user.AttachedManagedPolicies, user.PermissionsBoundary)
+	}
+	for _, group := range gaad.GroupDetailList {
+		for _, p := range group.GroupPolicyList {
+			issues = append(issues, checkPolicyDocument(&p.PolicyDocument, inlinePolicyID(group.Arn, p.PolicyName), "group-inline", []string{group.Arn})...)
+		}
+		attach(group.Arn, group.AttachedManagedPolicies, types.ManagedPL{})
+	}
+	for _, role := range gaad.RoleDetailList {
+		for _, p := range role.RolePolicyList {
+			issues = append(issues, checkPolicyDocument(&p.PolicyDocument, inlinePolicyID(role.Arn, p.PolicyName), "role-inline", []string{role.Arn})...)
+		}
+		issues = append(issues, checkPolicyDocument(&role.AssumeRolePolicyDocument, role.Arn+"/AssumeRolePolicyDocument", "trust", []string{role.Arn})...)
+		attach(role.Arn, role.AttachedManagedPolicies, role.PermissionsBoundary)
+	}
+
+	policyArns := make([]string, 0, len(attachments))
+	for policyArn := range attachments {
+		policyArns = append(policyArns, policyArn)
+	}
+	sort.Strings(policyArns)
+
+	for _, policyArn := range policyArns {
+		principals := uniqueSorted(attachments[policyArn])
+		policy, ok := managed[policyArn]
+		if !ok {
+			issues = append(issues, PolicyIssue{
+				Kind:           IssueUnresolvedManagedPolicy,
+				PolicyID:       policyArn,
+				PolicyType:     "managed",
+				Principals:     principals,
+				StatementIndex: -1,
+				Detail:         "attached managed policy is not in the GAAD Policies list, so its permissions are ignored",
+			})
+			continue
+		}
+		doc := policy.DefaultPolicyDocument()
+		if doc == nil {
+			issues = append(issues, PolicyIssue{
+				Kind:           IssueNoDefaultPolicyVersion,
+				PolicyID:       policyArn,
+				PolicyType:     "managed",
+				Principals:     principals,
+				StatementIndex: -1,
+				Detail:         "managed policy has no default version document, so its permissions are ignored",
+			})
+			continue
+		}
+		issues = append(issues, checkPolicyDocument(doc, policyArn, "managed", principals)...)
+	}
+
+	return issues
+}
+
+// checkPolicyDocument reports the issues in a single policy document
+func checkPolicyDocument(doc *types.Policy, policyID, policyType string, principals []string) []PolicyIssue {
+	var issues []PolicyIssue
+	newIssue := func(kind PolicyIssueKind, index int, sid, detail string) PolicyIssue {
+		return PolicyIssue{
+			Kind:           kind,
+			PolicyID:       policyID,
+			PolicyType:     policyType,
+			Principals:     principals,
+			StatementIndex: index,
+			Sid:            sid,
+			Detail:         detail,
+		}
+	}
+
+	if size := policyDocumentSize(doc); size > policySizeLimits[policyType] {
+		issues = append(issues, newIssue(IssuePolicySizeExceeded, -1, "",
+			fmt.Sprintf("document is about %d characters, above the %d character IAM quota for %s policies", size, policySizeLimits[policyType], policyType)))
+	}
+	if doc.Statement == nil {
+		return issues
+	}
+
+	for i, stmt := range *doc.Statement {
+		if stmt.Effect != "Allow" && stmt.Effect != "Deny" {
+			issues = append(issues, newIssue(IssueInvalidEffect, i, stmt.Sid,
+				fmt.Sprintf("Effect %q is not Allow or Deny; the evaluator treats it as Allow", stmt.Effect)))
+		}
+		if stmt.Action == nil && stmt.NotAction == nil {
+			issues = append(issues, newIssue(IssueMissingAction, i, stmt.Sid, "statement has neither Action nor NotAction and never matches"))
+		}
+		// Trust policies get their Resource filled in by the analyzer
+		if policyType != "trust" && stmt.Resource == nil && stmt.NotResource == nil {
+			issues = append(issues, newIssue(IssueMissingResource, i, stmt.Sid, "statement has neither Resource nor NotResource and never matches"))
+		}
+		if stmt.Condition != nil {
+			for _, operator := range sortedConditionOperators(*stmt.Condition) {
+				if !isSupportedConditionOperator(operator) {
+					issues = append(issues, newIssue(IssueUnsupportedConditionOperator, i, stmt.Sid,
+						fmt.Sprintf("condition operator %s is not supported by the evaluator and always fails to match", operator)))
+				}
+			}
+		}
+		if variables := policyVariables(stmt); len(variables) > 0 {
+			issues = append(issues, newIssue(IssuePolicyVariable, i, stmt.Sid,
+				fmt.Sprintf("policy variables %s are not substituted, so resource matching is literal", strings.Join(variables, ", "))))
+		}
+	}
+	return issues
+}
+
+// isSupportedConditionOperator reports whether the evaluator implements
+// operator. Set operators only support string comparisons.
+func isSupportedConditionOperator(operator string) bool {
+	base := strings.TrimSuffix(operator, "IfExists")
+	if strings.HasPrefix(base, "ForAllValues:") || strings.HasPrefix(base, "ForAnyValue:") {
+		base = base[strings.Index(base, ":")+1:]
+		return strings.HasPrefix(base, "String") && supportedConditionOperators[base]
+	}
+	return supportedConditionOperators[base]
+}
+
+func sortedConditionOperators(condition types.Condition) []string {
+	operators := make([]string, 0, len(condition))
+	for operator := range condition {
+		operators = append(operators, operator)
+	}
+	sort.Strings(operators)
+	return operators
+}
+
+// policyVariables returns the ${...} variables used in a statement's resources
+func policyVariables(stmt types.PolicyStatement) []string {
+	var variables []string
+	for _, resources := range []*types.DynaString{stmt.Resource, stmt.NotResource} {
+		if resources == nil {
+			continue
+		}
+		for _, resource := range *resources {
+			for rest := resource; ; {
+				start := strings.Index(rest, "${")
+				if start < 0 {
+					break
+				}
+				end := strings.Index(rest[start:], "}")
+				if end < 0 {
+					break
+				}
+				variables = append(variables, rest[start:start+end+1])
+				rest = rest[start+end+1:]
+			}
+		}
+	}
+	return uniqueSorted(variables)
+}
+
+// policyDocumentSize approximates the size IAM counts against its quotas
+func policyDocumentSize(doc *types.Policy) int {
+	raw, err := json.Marshal(doc)
+	if err != nil {
+		return 0
+	}
+	size := 0
+	for _, r := range string(raw) {
+		if !unicode.IsSpace(r) {
+			size++
+		}
+	}
+	return size
+}
+
+func inlinePolicyID(principalArn, policyName string) string {
+	return fmt.Sprintf("%s/inline/%s", principalArn, policyName)
+}
+
+func uniqueSorted(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	unique := make([]string, 0, len(values))
+	for _, v := range values {
+		if !seen[v] {
+			seen[v] = true
+			unique = append(unique, v)
+		}
+	}
+	sort.Strings(unique)
+	return unique
+}
@@ -0,0 +1,109 @@
+package aws
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/praetorian-inc/nebula/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const policyIssuesGaad = `{
+  "UserDetailList": [{
+    "Arn": "arn:aws:iam::111122223333:user/alice",
+    "UserName": "alice",
+    "UserPolicyList": [{
+      "PolicyName": "self-service",
+      "PolicyDocument": {"Version": "2012-10-17", "Statement": [
+        {"Sid": "OwnKeys", "Effect": "Allow", "Action": "iam:CreateAccessKey", "Resource": "arn:aws:iam::111122223333:user/${aws:username}"},
+        {"Sid": "Lowercase", "Effect": "allow", "Action": "s3:GetObject", "Resource": "*"}
+      ]}
+    }],
+    "AttachedManagedPolicies": [
+      {"PolicyName": "Missing", "PolicyArn": "arn:aws:iam::111122223333:policy/Missing"},
+      {"PolicyName": "Tagged", "PolicyArn": "arn:aws:iam::111122223333:policy/Tagged"}
+    ]
+  }],
+  "RoleDetailList": [{
+    "Arn": "arn:aws:iam::111122223333:role/builder",
+    "RoleName": "builder",
+    "AssumeRolePolicyDocument": {"Version": "2012-10-17", "Statement": [
+      {"Effect": "Allow", "Principal": {"Service": "codebuild.amazonaws.com"}, "Action": "sts:AssumeRole"}
+    ]},
+    "RolePolicyList": [{
+      "PolicyName": "no-resource",
+      "PolicyDocument": {"Version": "2012-10-17", "Statement": [{"Effect": "Allow", "Action": "ec2:RunInstances"}]}
+    }],
+    "AttachedManagedPolicies": [{"PolicyName": "Tagged", "PolicyArn": "arn:aws:iam::111122223333:policy/Tagged"}]
+  }],
+  "GroupDetailList": [],
+  "Policies": [{
+    "Arn": "arn:aws:iam::111122223333:policy/Tagged",
+    "PolicyName": "Tagged",
+    "PolicyVersionList": [{"IsDefaultVersion": true, "Document": {"Version": "2012-10-17", "Statement": [
+      {"Effect": "Allow", "Action": "lambda:*", "Resource": "*", "Condition": {
+        "StringEqualsIgnoreCaseIfExists": {"aws:ResourceTag/team": "build"},
+        "StringNotEqualsIgnoreCase": {"aws:PrincipalTag/team": "contractors"},
+        "ForAnyValue:ArnLike": {"aws:SourceArn": "arn:aws:codebuild:*"}
+      }}
+    ]}}]
+  }]
+}`
+
+func TestFindPolicyIssues(t *testing.T) {
+	var gaad types.Gaad
+	require.NoError(t, json.Unmarshal([]byte(policyIssuesGaad), &gaad))
+
+	issues := FindPolicyIssues(&gaad)
+
+	byKind := make(map[PolicyIssueKind][]PolicyIssue)
+	for _, issue := range issues {
+		byKind[issue.Kind] = append(byKind[issue.Kind], issue)
+	}
+
+	require.Len(t, byKind[IssuePolicyVariable], 1)
+	variable := byKind[IssuePolicyVariable][0]
+	assert.Equal(t, "arn:aws:iam::111122223333:user/alice/inline/self-service", variable.PolicyID)
+	assert.Equal(t, "OwnKeys", variable.Sid)
+	assert.Equal(t, []string{"arn:aws:iam::111122223333:user/alice"}, variable.Principals)
+	assert.Contains(t, variable.Detail, "${aws:username}")
+
+	require.Len(t, byKind[IssueInvalidEffect], 1)
+	assert.Equal(t, 1, byKind[IssueInvalidEffect][0].StatementIndex)
+
+	require.Len(t, byKind[IssueMissingResource], 1, "trust policies without Resource should not be reported")
+	assert.Equal(t, "arn:aws:iam::111122223333:role/builder/inline/no-resource", byKind[IssueMissingResource][0].PolicyID)
+
+	require.Len(t, byKind[IssueUnresolvedManagedPolicy], 1)
+	assert.Equal(t, "arn:aws:iam::111122223333:policy/Missing", byKind[IssueUnresolvedManagedPolicy][0].PolicyID)
+	assert.Equal(t, -1, byKind[IssueUnresolvedManagedPolicy][0].StatementIndex)
+
+	unsupported := byKind[IssueUnsupportedConditionOperator]
+	require.Len(t, unsupported, 2, "IfExists variants of supported operators should not be reported")
+	assert.True(t, strings.Contains(unsupported[0].Detail, "ForAnyValue:ArnLike"))
+	assert.True(t, strings.Contains(unsupported[1].Detail, "StringNotEqualsIgnoreCase"))
+	assert.Equal(t, []string{"arn:aws:iam::111122223333:role/builder", "arn:aws:iam::111122223333:user/alice"}, unsupported[0].Principals,
+		"managed policy issues should list every principal the policy is attached to")
+
+	assert.Empty(t, byKind[IssuePolicySizeExceeded])
+	assert.Empty(t, byKind[IssueMissingAction])
+}
+
+func TestPolicyDocumentSizeExceeded(t *testing.T) {
+	resources := make(types.DynaString, 0, 100)
+	for i := 0; i < 100; i++ {
+		resources = append(resources, "arn:aws:s3:::an-example-bucket-name-that-is-long/*")
+	}
+	doc := &types.Policy{Version: "2012-10-17", Statement: &types.PolicyStatementList{{
+		Effect:   "Allow",
+		Action:   types.NewDynaString([]string{"s3:GetObject"}),
+		Resource: &resources,
+	}}}
+
+	issues := checkPolicyDocument(doc, "arn:aws:iam::111122223333:user/bob/inline/big", "user-inline", []string{"arn:aws:iam::111122223333:user/bob"})
+	require.Len(t, issues, 1)
+	assert.Equal(t, IssuePolicySizeExceeded, issues[0].Kind)
+	assert.Empty(t, checkPolicyDocument(doc, "arn:aws:iam::111122223333:role/r/inline/big", "role-inline", nil))
+}
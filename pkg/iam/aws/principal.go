@@ -194,7 +194,7 @@ func extractPrincipalFromResource(resource *types.DynaString) []types.Principal
 		}
 
 		// Check if resource is a service principal
-		if strings.Contains(resourceStr, ".amazonaws.com") {
+		if hasServicePrincipalDomain(resourceStr) {
 			principals = append(principals, types.Principal{
 				Service: types.NewDynaString([]string{resourceStr}),
 			})
@@ -208,13 +208,17 @@ func extractPrincipalFromResource(resource *types.DynaString) []types.Principal
 func IsAWSPrincipal(id string) bool {
 	// Check common AWS principal patterns
 	validPatterns := []string{
-		"arn:aws:iam::",         // IAM ARNs
-		"arn:aws:sts::",         // STS ARNs
-		"arn:aws:service-role/", // Service-linked roles
-		"arn:aws:root",          // Account root
-		"AIDA",                  // IAM user ID prefix
-		"AROA",                  // IAM role ID prefix
-		"AGPA",                  // IAM group ID prefix
+		"AIDA", // IAM user ID prefix
+		"AROA", // IAM role ID prefix
+		"AGPA", // IAM group ID prefix
+	}
+	for _, partition := range awsPartitions {
+		validPatterns = append(validPatterns,
+			"arn:"+partition+":iam::",         // IAM ARNs
+			"arn:"+partition+":sts::",         // STS ARNs
+			"arn:"+partition+":service-role/", // Service-linked roles
+			"arn:"+partition+":root",          // Account root
+		)
 	}
 
 	for _, pattern := range validPatterns {
@@ -563,6 +563,21 @@ func TestIsAWSPrincipal(t *testing.T) {
 			id:   "arn:aws:iam::123456789012:root",
 			want: true,
 		},
+		{
+			name: "Valid GovCloud IAM ARN",
+			id:   "arn:aws-us-gov:iam::123456789012:role/example-role",
+			want: true,
+		},
+		{
+			name: "Valid China STS ARN",
+			id:   "arn:aws-cn:sts::123456789012:assumed-role/example-role/example-session",
+			want: true,
+		},
+		{
+			name: "Invalid GovCloud ARN",
+			id:   "arn:aws-us-gov:s3:::example-bucket",
+			want: false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -79,7 +79,7 @@ func getResourcePatternsFromAction(action Action) []*regexp.Regexp {
 		slog.Debug("Service not found in serviceResourceMaps", "service", service)
 	}
 
-	return []*regexp.Regexp{regexp.MustCompile(fmt.Sprintf("arn:aws:%s:*:*:*", service))}
+	return []*regexp.Regexp{regexp.MustCompile(fmt.Sprintf("arn:%s:%s:*:*:*", arnPartitionPattern, service))}
 
 }
 
@@ -99,16 +99,16 @@ var serviceResourceMaps = map[string]ServiceResourceMap{
 	},
 	"iam": {
 		ResourcePatterns: map[string]*regexp.Regexp{
-			"user":               regexp.MustCompile(`^arn:aws:iam::\d{12}:user/.*`),
-			"group":              regexp.MustCompile(`^arn:aws:iam::\d{12}:group/.*`),
-			"role":               regexp.MustCompile(`^arn:aws:iam::\d{12}:role/.*`),
-			"policy":             regexp.MustCompile(`^arn:aws:iam::(\d{12}|aws):policy/.*`),
-			"custom-policy":      regexp.MustCompile(`^arn:aws:iam::(\d{12}):policy/.*`),
-			"instance-profile":   regexp.MustCompile(`^arn:aws:iam::\d{12}:instance-profile/.*`),
-			"mfa":                regexp.MustCompile(`^arn:aws:iam::\d{12}:mfa/.*`),
-			"oidc-provider":      regexp.MustCompile(`^arn:aws:iam::\d{12}:oidc-provider/.*`),
-			"saml-provider":      regexp.MustCompile(`^arn:aws:iam::\d{12}:saml-provider/.*`),
-			"server-certificate": regexp.MustCompile(`^arn:aws:iam::\d{12}:server-certificate/.*`),
+			"user":               regexp.MustCompile(`^arn:aws(?:-cn|-us-gov)?:iam::\d{12}:user/.*`),
+			"group":              regexp.MustCompile(`^arn:aws(?:-cn|-us-gov)?:iam::\d{12}:group/.*`),
+			"role":               regexp.MustCompile(`^arn:aws(?:-cn|-us-gov)?:iam::\d{12}:role/.*`),
+			"policy":             regexp.MustCompile(`^arn:aws(?:-cn|-us-gov)?:iam::(\d{12}|aws):policy/.*`),
+			"custom-policy":      regexp.MustCompile(`^arn:aws(?:-cn|-us-gov)?:iam::(\d{12}):policy/.*`),
+			"instance-profile":   regexp.MustCompile(`^arn:aws(?:-cn|-us-gov)?:iam::\d{12}:instance-profile/.*`),
+			"mfa":                regexp.MustCompile(`^arn:aws(?:-cn|-us-gov)?:iam::\d{12}:mfa/.*`),
+			"oidc-provider":      regexp.MustCompile(`^arn:aws(?:-cn|-us-gov)?:iam::\d{12}:oidc-provider/.*`),
+			"saml-provider":      regexp.MustCompile(`^arn:aws(?:-cn|-us-gov)?:iam::\d{12}:saml-provider/.*`),
+			"server-certificate": regexp.MustCompile(`^arn:aws(?:-cn|-us-gov)?:iam::\d{12}:server-certificate/.*`),
 			"service":            regexp.MustCompile(`^iam.amazonaws.com$`),
 		},
 		ActionResourceMap: map[string][]string{
@@ -247,11 +247,11 @@ var serviceResourceMaps = map[string]ServiceResourceMap{
 	"ec2": {
 		ResourcePatterns: map[string]*regexp.Regexp{
 			"service":         regexp.MustCompile(`^ec2.amazonaws.com$`),
-			"instance":        regexp.MustCompile(`^arn:aws:ec2:[a-z-0-9]+:\d{12}:instance/.*`),
-			"volume":          regexp.MustCompile(`^arn:aws:ec2:[a-z-0-9]+:\d{12}:volume/.*`),
-			"snapshot":        regexp.MustCompile(`^arn:aws:ec2:[a-z-0-9]+:\d{12}:snapshot/.*`),
-			"image":           regexp.MustCompile(`^arn:aws:ec2:[a-z-0-9]+:\d{12}:image/.*`),
-			"launch-template": regexp.MustCompile(`^arn:aws:ec2:[a-z-0-9]+:\d{12}:launch-template/.*`),
+			"instance":        regexp.MustCompile(`^arn:aws(?:-cn|-us-gov)?:ec2:[a-z-0-9]+:\d{12}:instance/.*`),
+			"volume":          regexp.MustCompile(`^arn:aws(?:-cn|-us-gov)?:ec2:[a-z-0-9]+:\d{12}:volume/.*`),
+			"snapshot":        regexp.MustCompile(`^arn:aws(?:-cn|-us-gov)?:ec2:[a-z-0-9]+:\d{12}:snapshot/.*`),
+			"image":           regexp.MustCompile(`^arn:aws(?:-cn|-us-gov)?:ec2:[a-z-0-9]+:\d{12}:image/.*`),
+			"launch-template": regexp.MustCompile(`^arn:aws(?:-cn|-us-gov)?:ec2:[a-z-0-9]+:\d{12}:launch-template/.*`),
 		},
 		ActionResourceMap: map[string][]string{
 			"runinstances":         {"service"},
@@ -261,8 +261,8 @@ var serviceResourceMaps = map[string]ServiceResourceMap{
 	"cloudformation": {
 		ResourcePatterns: map[string]*regexp.Regexp{
 			"service":  regexp.MustCompile(`^cloudformation.amazonaws.com$`),
-			"stack":    regexp.MustCompile(`^arn:aws:cloudformation:[a-z-0-9]+:\d{12}:stack/.*`),
-			"stackset": regexp.MustCompile(`^arn:aws:cloudformation:[a-z-0-9]+:\d{12}:stackset/.*`),
+			"stack":    regexp.MustCompile(`^arn:aws(?:-cn|-us-gov)?:cloudformation:[a-z-0-9]+:\d{12}:stack/.*`),
+			"stackset": regexp.MustCompile(`^arn:aws(?:-cn|-us-gov)?:cloudformation:[a-z-0-9]+:\d{12}:stackset/.*`),
 		},
 		ActionResourceMap: map[string][]string{
 			"createstack":      {"service"},
@@ -276,8 +276,8 @@ var serviceResourceMaps = map[string]ServiceResourceMap{
 	},
 	"sts": {
 		ResourcePatterns: map[string]*regexp.Regexp{
-			"role":   regexp.MustCompile(`^arn:aws:iam::\d{12}:role/.*`),
-			"policy": regexp.MustCompile(`^arn:aws:iam::(\d{12}|aws):policy/.*`),
+			"role":   regexp.MustCompile(`^arn:aws(?:-cn|-us-gov)?:iam::\d{12}:role/.*`),
+			"policy": regexp.MustCompile(`^arn:aws(?:-cn|-us-gov)?:iam::(\d{12}|aws):policy/.*`),
 		},
 		ActionResourceMap: map[string][]string{
 			"assumerole": {"role"},
@@ -287,9 +287,9 @@ var serviceResourceMaps = map[string]ServiceResourceMap{
 	},
 	"lambda": {
 		ResourcePatterns: map[string]*regexp.Regexp{
-			"function":    regexp.MustCompile(`^arn:aws:lambda:[a-z-0-9]+:\d{12}:function:.*$`),
-			"layer":       regexp.MustCompile(`^arn:aws:lambda:[a-z-0-9]+:\d{12}:layer:.*$`),
-			"eventconfig": regexp.MustCompile(`^arn:aws:lambda:[a-z-0-9]+:\d{12}:event-source-mapping:.*$`),
+			"function":    regexp.MustCompile(`^arn:aws(?:-cn|-us-gov)?:lambda:[a-z-0-9]+:\d{12}:function:.*$`),
+			"layer":       regexp.MustCompile(`^arn:aws(?:-cn|-us-gov)?:lambda:[a-z-0-9]+:\d{12}:layer:.*$`),
+			"eventconfig": regexp.MustCompile(`^arn:aws(?:-cn|-us-gov)?:lambda:[a-z-0-9]+:\d{12}:event-source-mapping:.*$`),
 			"service":     regexp.MustCompile(`^lambda.amazonaws.com$`),
 		},
 		ActionResourceMap: map[string][]string{
@@ -354,10 +354,10 @@ var serviceResourceMaps = map[string]ServiceResourceMap{
 	"ecs": {
 		ResourcePatterns: map[string]*regexp.Regexp{
 			"service":        regexp.MustCompile(`^ecs.amazonaws.com$`),
-			"cluster":        regexp.MustCompile(`^arn:aws:ecs:[a-z0-9-]+:\d{12}:cluster/.*$`),
-			"task":           regexp.MustCompile(`^arn:aws:ecs:[a-z0-9-]+:\d{12}:task/.*$`),
-			"task-def":       regexp.MustCompile(`^arn:aws:ecs:[a-z0-9-]+:\d{12}:task-definition/.*$`),
-			"container-inst": regexp.MustCompile(`^arn:aws:ecs:[a-z0-9-]+:\d{12}:container-instance/.*$`),
+			"cluster":        regexp.MustCompile(`^arn:aws(?:-cn|-us-gov)?:ecs:[a-z0-9-]+:\d{12}:cluster/.*$`),
+			"task":           regexp.MustCompile(`^arn:aws(?:-cn|-us-gov)?:ecs:[a-z0-9-]+:\d{12}:task/.*$`),
+			"task-def":       regexp.MustCompile(`^arn:aws(?:-cn|-us-gov)?:ecs:[a-z0-9-]+:\d{12}:task-definition/.*$`),
+			"container-inst": regexp.MustCompile(`^arn:aws(?:-cn|-us-gov)?:ecs:[a-z0-9-]+:\d{12}:container-instance/.*$`),
 		},
 		ActionResourceMap: map[string][]string{
 			"runtask":                {"cluster", "task-def", "service"},
@@ -368,10 +368,10 @@ var serviceResourceMaps = map[string]ServiceResourceMap{
 	},
 	"ssm": {
 		ResourcePatterns: map[string]*regexp.Regexp{
-			"instance":         regexp.MustCompile(`^arn:aws:ec2:[a-z0-9-]+:\d{12}:instance/.*$`),
-			"managed-instance": regexp.MustCompile(`^arn:aws:ssm:[a-z0-9-]+:\d{12}:managed-instance/.*$`),
-			"document":         regexp.MustCompile(`^arn:aws:ssm:[a-z0-9-]+:(\d{12}|aws):document/.*$`),
-			"automation":       regexp.MustCompile(`^arn:aws:ssm:[a-z0-9-]+:\d{12}:automation-definition/.*$`),
+			"instance":         regexp.MustCompile(`^arn:aws(?:-cn|-us-gov)?:ec2:[a-z0-9-]+:\d{12}:instance/.*$`),
+			"managed-instance": regexp.MustCompile(`^arn:aws(?:-cn|-us-gov)?:ssm:[a-z0-9-]+:\d{12}:managed-instance/.*$`),
+			"document":         regexp.MustCompile(`^arn:aws(?:-cn|-us-gov)?:ssm:[a-z0-9-]+:(\d{12}|aws):document/.*$`),
+			"automation":       regexp.MustCompile(`^arn:aws(?:-cn|-us-gov)?:ssm:[a-z0-9-]+:\d{12}:automation-definition/.*$`),
 			"service":          regexp.MustCompile(`^ssm.amazonaws.com$`),
 		},
 		ActionResourceMap: map[string][]string{
@@ -383,7 +383,7 @@ var serviceResourceMaps = map[string]ServiceResourceMap{
 	},
 	"glue": {
 		ResourcePatterns: map[string]*regexp.Regexp{
-			"devEndpoint": regexp.MustCompile(`^arn:aws:glue:[a-z0-9-]+:\d{12}:devEndpoint/.*$`),
+			"devEndpoint": regexp.MustCompile(`^arn:aws(?:-cn|-us-gov)?:glue:[a-z0-9-]+:\d{12}:devEndpoint/.*$`),
 			"service":     regexp.MustCompile(`^glue.amazonaws.com$`),
 		},
 		ActionResourceMap: map[string][]string{
@@ -393,7 +393,7 @@ var serviceResourceMaps = map[string]ServiceResourceMap{
 	},
 	"codebuild": {
 		ResourcePatterns: map[string]*regexp.Regexp{
-			"project": regexp.MustCompile(`^arn:aws:codebuild:[a-z0-9-]+:\d{12}:project/.*$`),
+			"project": regexp.MustCompile(`^arn:aws(?:-cn|-us-gov)?:codebuild:[a-z0-9-]+:\d{12}:project/.*$`),
 			"service": regexp.MustCompile(`^codebuild.amazonaws.com$`),
 		},
 		ActionResourceMap: map[string][]string{
@@ -405,9 +405,9 @@ var serviceResourceMaps = map[string]ServiceResourceMap{
 	},
 	"sagemaker": {
 		ResourcePatterns: map[string]*regexp.Regexp{
-			"notebook-instance": regexp.MustCompile(`^arn:aws:sagemaker:[a-z0-9-]+:\d{12}:notebook-instance/.*$`),
-			"training-job":      regexp.MustCompile(`^arn:aws:sagemaker:[a-z0-9-]+:\d{12}:training-job/.*$`),
-			"processing-job":    regexp.MustCompile(`^arn:aws:sagemaker:[a-z0-9-]+:\d{12}:processing-job/.*$`),
+			"notebook-instance": regexp.MustCompile(`^arn:aws(?:-cn|-us-gov)?:sagemaker:[a-z0-9-]+:\d{12}:notebook-instance/.*$`),
+			"training-job":      regexp.MustCompile(`^arn:aws(?:-cn|-us-gov)?:sagemaker:[a-z0-9-]+:\d{12}:training-job/.*$`),
+			"processing-job":    regexp.MustCompile(`^arn:aws(?:-cn|-us-gov)?:sagemaker:[a-z0-9-]+:\d{12}:processing-job/.*$`),
 			"service":           regexp.MustCompile(`^sagemaker.amazonaws.com$`),
 		},
 		ActionResourceMap: map[string][]string{
@@ -420,9 +420,9 @@ var serviceResourceMaps = map[string]ServiceResourceMap{
 	},
 	"autoscaling": {
 		ResourcePatterns: map[string]*regexp.Regexp{
-			"autoScalingGroup":    regexp.MustCompile(`^arn:aws:autoscaling:[a-z0-9-]+:\d{12}:autoScalingGroup:.*$`),
-			"launchTemplate":      regexp.MustCompile(`^arn:aws:ec2:[a-z0-9-]+:\d{12}:launch-template/.*$`),
-			"launchConfiguration": regexp.MustCompile(`^arn:aws:autoscaling:[a-z0-9-]+:\d{12}:launchConfiguration:.*$`),
+			"autoScalingGroup":    regexp.MustCompile(`^arn:aws(?:-cn|-us-gov)?:autoscaling:[a-z0-9-]+:\d{12}:autoScalingGroup:.*$`),
+			"launchTemplate":      regexp.MustCompile(`^arn:aws(?:-cn|-us-gov)?:ec2:[a-z0-9-]+:\d{12}:launch-template/.*$`),
+			"launchConfiguration": regexp.MustCompile(`^arn:aws(?:-cn|-us-gov)?:autoscaling:[a-z0-9-]+:\d{12}:launchConfiguration:.*$`),
 			"service":             regexp.MustCompile(`^autoscaling.amazonaws.com$`),
 		},
 		ActionResourceMap: map[string][]string{
@@ -109,6 +109,18 @@ func TestIsValidActionForResource(t *testing.T) {
 			resource: "arn:aws:iam::123456789012:user/test-user",
 			expected: false,
 		},
+		{
+			name:     "GovCloud user ARN",
+			action:   "iam:PutUserPolicy",
+			resource: "arn:aws-us-gov:iam::123456789012:user/test-user",
+			expected: true,
+		},
+		{
+			name:     "China role ARN",
+			action:   "sts:AssumeRole",
+			resource: "arn:aws-cn:iam::123456789012:role/test-role",
+			expected: true,
+		},
 		{
 			name:     "Non-existent service",
 			action:   "nonexistent:Action",
@@ -136,22 +148,22 @@ func TestGetResourcePatternsFromAction(t *testing.T) {
 			name:   "Put user policy",
 			action: "iam:PutUserPolicy",
 			expected: []*regexp.Regexp{
-				regexp.MustCompile(`^arn:aws:iam::\d{12}:user/.*`),
+				regexp.MustCompile(`^arn:aws(?:-cn|-us-gov)?:iam::\d{12}:user/.*`),
 			},
 		},
 		{
 			name:     "Valid action with single resource pattern",
 			action:   "iam:AddUserToGroup",
-			expected: []*regexp.Regexp{regexp.MustCompile(`^arn:aws:iam::\d{12}:group/.*`)},
+			expected: []*regexp.Regexp{regexp.MustCompile(`^arn:aws(?:-cn|-us-gov)?:iam::\d{12}:group/.*`)},
 		},
 		{
 			name:   "Valid action with multiple resource patterns",
 			action: "iam:GenerateServiceLastAccessedDetails",
 			expected: []*regexp.Regexp{
-				regexp.MustCompile(`^arn:aws:iam::\d{12}:group/.*`),
-				regexp.MustCompile(`^arn:aws:iam::\d{12}:role/.*`),
-				regexp.MustCompile(`^arn:aws:iam::\d{12}:user/.*`),
-				regexp.MustCompile(`^arn:aws:iam::(\d{12}|aws):policy/.*`),
+				regexp.MustCompile(`^arn:aws(?:-cn|-us-gov)?:iam::\d{12}:group/.*`),
+				regexp.MustCompile(`^arn:aws(?:-cn|-us-gov)?:iam::\d{12}:role/.*`),
+				regexp.MustCompile(`^arn:aws(?:-cn|-us-gov)?:iam::\d{12}:user/.*`),
+				regexp.MustCompile(`^arn:aws(?:-cn|-us-gov)?:iam::(\d{12}|aws):policy/.*`),
 			},
 		},
 		{
@@ -162,7 +174,7 @@ func TestGetResourcePatternsFromAction(t *testing.T) {
 		{
 			name:     "Valid action with non-existent service",
 			action:   "nonexistent:Action",
-			expected: []*regexp.Regexp{regexp.MustCompile(`arn:aws:nonexistent:*:*:*`)},
+			expected: []*regexp.Regexp{regexp.MustCompile(`arn:aws(?:-cn|-us-gov)?:nonexistent:*:*:*`)},
 		},
 		{
 			name:     "Valid action with EC2 service",
@@ -172,12 +184,12 @@ func TestGetResourcePatternsFromAction(t *testing.T) {
 		{
 			name:     "sts:AssumeRole",
 			action:   "sts:AssumeRole",
-			expected: []*regexp.Regexp{regexp.MustCompile(`^arn:aws:iam::\d{12}:role/.*`)},
+			expected: []*regexp.Regexp{regexp.MustCompile(`^arn:aws(?:-cn|-us-gov)?:iam::\d{12}:role/.*`)},
 		},
 		{
 			name:     "lambda:CreateFuntion returns lambda service",
 			action:   "lambda:CreateFunction",
-			expected: []*regexp.Regexp{regexp.MustCompile(`^arn:aws:lambda:[a-z-0-9]+:\d{12}:function:.*$`)},
+			expected: []*regexp.Regexp{regexp.MustCompile(`^arn:aws(?:-cn|-us-gov)?:lambda:[a-z-0-9]+:\d{12}:function:.*$`)},
 		},
 	}
 
@@ -71,8 +71,11 @@ func evaluateStatement(stmt *types.PolicyStatement, requestedAction, requestedRe
 		conditionEval := evaluateConditions(stmt.Condition, context)
 		eval.ConditionEvaluation = conditionEval
 
-		// If conditions explicitly failed, return with implicit deny
-		if !conditionEval.Allowed() {
+		// If conditions explicitly failed, return with implicit deny. An Allow
+		// that only fails on how the caller makes the request, such as without
+		// MFA, still grants the action to a caller who meets it; a Deny on the
+		// same keys is avoided by meeting it, so it does not apply.
+		if !conditionEval.Allowed() && (strings.EqualFold(stmt.Effect, "Deny") || !conditionEval.satisfiableByCaller(context)) {
 			return eval
 		}
 	}
@@ -352,7 +355,7 @@ func determinePrincipalType(principalArn string) PrincipalType {
 	}
 
 	// Check for service principals
-	if strings.HasSuffix(arnParsed.AccountID, ".amazonaws.com") {
+	if isServicePrincipal(arnParsed.AccountID) {
 		return PrincipalTypeService
 	}
 
@@ -466,16 +469,16 @@ func getServiceNameFromArn(principalArn string) string {
 
 	// For service principals, the service is in the ARN service field
 	// or in the resource for legacy formats
-	if strings.HasSuffix(arnParsed.Service, ".amazonaws.com") {
+	if isServicePrincipal(arnParsed.Service) {
 		return arnParsed.Service
 	}
 
 	// Check for service name in resource for legacy formats
 	resource := arnParsed.Resource
-	if strings.Contains(resource, ".amazonaws.com") {
+	if hasServicePrincipalDomain(resource) {
 		parts := strings.Split(resource, ".")
 		if len(parts) > 0 {
-			return parts[0] + ".amazonaws.com"
+			return parts[0] + servicePrincipalSuffix
 		}
 	}
 
@@ -509,7 +512,7 @@ func (rc *RequestContext) GetPrincipalType() PrincipalType {
 		return PrincipalTypeFederatedUser
 	case strings.Contains(rc.PrincipalArn, ":root"):
 		return PrincipalTypeRoot
-	case strings.Contains(resource, ".amazonaws.com"):
+	case hasServicePrincipalDomain(resource):
 		return PrincipalTypeServiceAccount
 	default:
 		return PrincipalTypeUnknown
@@ -536,7 +539,7 @@ func matchesPrincipal(principal *types.Principal, requestedPrincipal string) boo
 
 	if principal.Service != nil {
 		for _, service := range *principal.Service {
-			if matchesPattern(service, requestedPrincipal) {
+			if matchesPattern(canonicalServicePrincipal(service), canonicalServicePrincipal(requestedPrincipal)) {
 				return true
 			}
 		}
@@ -0,0 +1,148 @@
+package aws
+
+import (
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+	"github.com/praetorian-inc/nebula/pkg/types"
+)
+
+// UnusedPermissions lists the services a principal's policies grant but that
+// IAM last-accessed data shows it has never used within the tracking period.
+// Removing those grants is the least-privilege remediation for the principal.
+type UnusedPermissions struct {
+	PrincipalArn    string          `json:"principal_arn"`
+	AccountID       string          `json:"account_id"`
+	GrantedServices int             `json:"granted_services"`
+	UnusedServices  []UnusedService `json:"unused_services"`
+}
+
+// UnusedService is one granted but unused service. EffectiveActions are the
+// privilege escalation actions in the service the analysis found the principal
+// allowed; unused grants that carry them are the first to remove.
+type UnusedService struct {
+	Namespace        string   `json:"service_namespace"`
+	Name             string   `json:"service_name"`
+	EffectiveActions []string `json:"effective_actions,omitempty"`
+}
+
+// AddServiceLastAccessed adds IAM last-accessed data to the policy data.
+// Every key must be a principal ARN and every job must have completed, since
+// a partial job would report used services as unused.
+func (pd *PolicyData) AddServiceLastAccessed(report types.ServiceLastAccessedReport) error {
+	known := make(map[string]bool)
+	if pd.Gaad != nil {
+		for _, user := range pd.Gaad.UserDetailList {
+			known[user.Arn] = true
+		}
+		for _, role := range pd.Gaad.RoleDetailList {
+			known[role.Arn] = true
+		}
+		for _, group := range pd.Gaad.GroupDetailList {
+			known[group.Arn] = true
+		}
+	}
+
+	unknown := 0
+	for principalArn, details := range report {
+		if !arn.IsARN(principalArn) {
+			return fmt.Errorf("last-accessed key %q is not a principal ARN", principalArn)
+		}
+		if details == nil {
+			return fmt.Errorf("last-accessed data for %s is null", principalArn)
+		}
+		if details.JobStatus != "" && details.JobStatus != "COMPLETED" {
+			return fmt.Errorf("last-accessed job for %s is %s, not COMPLETED", principalArn, details.JobStatus)
+		}
+		if details.IsTruncated {
+			return fmt.Errorf("last-accessed data for %s is truncated; include every page of the job", principalArn)
+		}
+		if !known[principalArn] {
+			unknown++
+			slog.Debug("Last-accessed principal not in GAAD", "principal", principalArn)
+		}
+	}
+	if unknown > 0 {
+		slog.Warn(fmt.Sprintf("%d principals in the last-accessed data are not in the GAAD; their unused services are reported without effective actions", unknown))
+	}
+
+	pd.ServiceLastAccessed = report
+	return nil
+}
+
+// FindUnusedPermissions cross-references last-accessed data with the analyzed
+// permissions to list, per principal, the granted services it never used.
+// Principals with the most unused grants that carry effective privilege
+// escalation actions sort first.
+func FindUnusedPermissions(pd *PolicyData, summary *PermissionsSummary) []UnusedPermissions {
+	if pd == nil || len(pd.ServiceLastAccessed) == 0 || summary == nil {
+		return nil
+	}
+
+	report := make([]UnusedPermissions, 0)
+	for principalArn, details := range pd.ServiceLastAccessed {
+		effective := make(map[string][]string)
+		for _, action := range allowedActionNames(summary, []string{principalArn}) {
+			service, _, found := strings.Cut(action, ":")
+			if found {
+				effective[strings.ToLower(service)] = append(effective[strings.ToLower(service)], action)
+			}
+		}
+
+		entry := UnusedPermissions{
+			PrincipalArn:    principalArn,
+			GrantedServices: len(details.ServicesLastAccessed),
+			UnusedServices:  make([]UnusedService, 0),
+		}
+		if parsed, err := arn.Parse(principalArn); err == nil {
+			entry.AccountID = parsed.AccountID
+		}
+		for _, service := range details.ServicesLastAccessed {
+			if service.LastAuthenticated != "" {
+				continue
+			}
+			entry.UnusedServices = append(entry.UnusedServices, UnusedService{
+				Namespace:        service.ServiceNamespace,
+				Name:             service.ServiceName,
+				EffectiveActions: effective[strings.ToLower(service.ServiceNamespace)],
+			})
+		}
+		if len(entry.UnusedServices) == 0 {
+			continue
+		}
+		sort.Slice(entry.UnusedServices, func(i, j int) bool {
+			a, b := entry.UnusedServices[i], entry.UnusedServices[j]
+			if (len(a.EffectiveActions) > 0) != (len(b.EffectiveActions) > 0) {
+				return len(a.EffectiveActions) > 0
+			}
+			return a.Namespace < b.Namespace
+		})
+		report = append(report, entry)
+	}
+
+	sort.Slice(report, func(i, j int) bool {
+		ri, rj := riskyUnusedServices(report[i]), riskyUnusedServices(report[j])
+		if ri != rj {
+			return ri > rj
+		}
+		if len(report[i].UnusedServices) != len(report[j].UnusedServices) {
+			return len(report[i].UnusedServices) > len(report[j].UnusedServices)
+		}
+		return report[i].PrincipalArn < report[j].PrincipalArn
+	})
+	return report
+}
+
+// riskyUnusedServices counts the unused services that carry effective actions
+func riskyUnusedServices(entry UnusedPermissions) int {
+	count := 0
+	for _, service := range entry.UnusedServices {
+		if len(service.EffectiveActions) > 0 {
+			count++
+		}
+	}
+	return count
+}
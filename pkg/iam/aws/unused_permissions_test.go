@@ -0,0 +1,81 @@
+package aws
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/praetorian-inc/nebula/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const lastAccessedReport = `{
+  "arn:aws:iam::111122223333:role/deploy": {
+    "JobStatus": "COMPLETED",
+    "ServicesLastAccessed": [
+      {"ServiceName": "AWS Lambda", "ServiceNamespace": "lambda", "LastAuthenticated": "2024-05-01T10:00:00Z", "TotalAuthenticatedEntities": 1},
+      {"ServiceName": "Amazon S3", "ServiceNamespace": "s3", "TotalAuthenticatedEntities": 0},
+      {"ServiceName": "AWS Identity and Access Management", "ServiceNamespace": "iam", "TotalAuthenticatedEntities": 0}
+    ]
+  },
+  "arn:aws:iam::111122223333:user/alice": {
+    "JobStatus": "COMPLETED",
+    "ServicesLastAccessed": [
+      {"ServiceName": "Amazon S3", "ServiceNamespace": "s3", "LastAuthenticated": "2024-05-02T10:00:00Z", "TotalAuthenticatedEntities": 1}
+    ]
+  },
+  "arn:aws:iam::111122223333:user/bob": {
+    "JobStatus": "COMPLETED",
+    "ServicesLastAccessed": [
+      {"ServiceName": "Amazon EC2", "ServiceNamespace": "ec2", "TotalAuthenticatedEntities": 0},
+      {"ServiceName": "Amazon SQS", "ServiceNamespace": "sqs", "TotalAuthenticatedEntities": 0}
+    ]
+  }
+}`
+
+func TestFindUnusedPermissions(t *testing.T) {
+	var report types.ServiceLastAccessedReport
+	require.NoError(t, json.Unmarshal([]byte(lastAccessedReport), &report))
+
+	pd := &PolicyData{Gaad: &types.Gaad{
+		RoleDetailList: []types.RoleDL{{Arn: "arn:aws:iam::111122223333:role/deploy"}},
+		UserDetailList: []types.UserDL{{Arn: "arn:aws:iam::111122223333:user/alice"}, {Arn: "arn:aws:iam::111122223333:user/bob"}},
+	}}
+	require.NoError(t, pd.AddServiceLastAccessed(report))
+
+	summary := NewPermissionsSummary()
+	allowed := &EvaluationResult{Allowed: true}
+	summary.AddPermission("arn:aws:iam::111122223333:role/deploy", "arn:aws:iam::111122223333:role/admin", "iam:PassRole", true, allowed)
+	summary.AddPermission("arn:aws:iam::111122223333:role/deploy", "arn:aws:lambda:us-east-1:111122223333:function:app", "lambda:UpdateFunctionCode", true, allowed)
+
+	unused := FindUnusedPermissions(pd, summary)
+	require.Len(t, unused, 2, "alice used every granted service")
+
+	deploy := unused[0]
+	assert.Equal(t, "arn:aws:iam::111122223333:role/deploy", deploy.PrincipalArn, "unused grants with effective actions sort first")
+	assert.Equal(t, "111122223333", deploy.AccountID)
+	assert.Equal(t, 3, deploy.GrantedServices)
+	require.Len(t, deploy.UnusedServices, 2)
+	assert.Equal(t, "iam", deploy.UnusedServices[0].Namespace)
+	assert.Equal(t, []string{"iam:PassRole"}, deploy.UnusedServices[0].EffectiveActions)
+	assert.Equal(t, "s3", deploy.UnusedServices[1].Namespace)
+	assert.Empty(t, deploy.UnusedServices[1].EffectiveActions)
+
+	bob := unused[1]
+	assert.Equal(t, "arn:aws:iam::111122223333:user/bob", bob.PrincipalArn)
+	assert.Len(t, bob.UnusedServices, 2)
+}
+
+func TestAddServiceLastAccessedRejectsBadInput(t *testing.T) {
+	pd := &PolicyData{Gaad: &types.Gaad{}}
+
+	err := pd.AddServiceLastAccessed(types.ServiceLastAccessedReport{"deploy": {JobStatus: "COMPLETED"}})
+	assert.ErrorContains(t, err, "not a principal ARN")
+
+	err = pd.AddServiceLastAccessed(types.ServiceLastAccessedReport{"arn:aws:iam::111122223333:role/deploy": {JobStatus: "IN_PROGRESS"}})
+	assert.ErrorContains(t, err, "IN_PROGRESS")
+
+	err = pd.AddServiceLastAccessed(types.ServiceLastAccessedReport{"arn:aws:iam::111122223333:role/deploy": {JobStatus: "COMPLETED", IsTruncated: true}})
+	assert.ErrorContains(t, err, "truncated")
+	assert.Nil(t, pd.ServiceLastAccessed)
+}
@@ -5,7 +5,6 @@ import (
 	"encoding/json"
 	"fmt"
 	"log/slog"
-	"os"
 	"strings"
 
 	"github.com/aws/aws-sdk-go-v2/aws/arn"
@@ -21,6 +20,7 @@ import (
 	"github.com/praetorian-inc/nebula/pkg/links/options"
 	"github.com/praetorian-inc/nebula/pkg/outputters"
 	"github.com/praetorian-inc/nebula/pkg/types"
+	"github.com/praetorian-inc/nebula/pkg/utils"
 	"github.com/praetorian-inc/tabularium/pkg/model/model"
 )
 
@@ -52,7 +52,10 @@ func (a *AwsApolloControlFlow) Params() []cfg.Param {
 	params := a.AwsReconLink.Params()
 	params = append(params, options.AwsCommonReconOptions()...)
 	params = append(params, options.AwsOrgPolicies())
+	params = append(params, options.AwsAdminActions(), options.AwsAdminActionThreshold(), options.AwsMaxHops(), options.AwsAnalyzerWorkers())
 	params = append(params, options.Neo4jOptions()...)
+	params = append(params, options.Neo4jEnrichOptions()...)
+	params = append(params, options.EdgesOut(), options.Neo4jAssumeYes())
 	return params
 }
 
@@ -81,7 +84,7 @@ func (a *AwsApolloControlFlow) loadOrgPolicies() error {
 
 	orgPolFile := orgPol.(string)
 	if orgPolFile != "" {
-		fileBytes, err := os.ReadFile(orgPolFile)
+		fileBytes, err := utils.ReadInputFile(orgPolFile)
 		if err != nil {
 			return fmt.Errorf("failed to read org policies file: %w", err)
 		}
@@ -103,6 +106,12 @@ func (a *AwsApolloControlFlow) loadOrgPolicies() error {
 			}
 			a.pd.OrgPolicies = orgPolicies
 		}
+		if a.pd.OrgPolicies == nil {
+			return fmt.Errorf("org policies file '%s' is null", orgPolFile)
+		}
+		if err := a.pd.OrgPolicies.Validate(); err != nil {
+			return fmt.Errorf("failed to load org policies from '%s': %w", orgPolFile, err)
+		}
 	} else {
 		slog.Warn("Empty organization policies file path provided, assuming p-FullAWSAccess.")
 		a.pd.OrgPolicies = orgpolicies.NewDefaultOrgPolicies()
@@ -154,10 +163,22 @@ func (a *AwsApolloControlFlow) Process(resourceType string) error {
 	a.pd.AddResourcePolicies()
 
 	analyzer := iam.NewGaadAnalyzer(a.pd)
+	analyzer.SetEffectiveAdminCriteria(effectiveAdminCriteria(a.Arg))
+	if maxHops, err := cfg.As[int](a.Arg(options.AwsMaxHops().Name())); err == nil {
+		analyzer.SetMaxHops(maxHops)
+	}
+	if workers, err := cfg.As[int](a.Arg(options.AwsAnalyzerWorkers().Name())); err == nil {
+		analyzer.SetWorkers(workers)
+	}
 	summary, err := analyzer.AnalyzePrincipalPermissions()
 	if err != nil {
 		return err
 	}
+	logAdminRoleAssumers(a.Logger, summary.AdminRoleAssumers)
+	logEffectiveAdmins(a.Logger, summary.EffectiveAdmins)
+	logHopsCapped(a.Logger, summary)
+	logFederatedTrust(a.Logger, summary.FederatedTrust)
+	logAccountPublicAccess(a.Logger, summary.AccountPublicAccess)
 
 	// Transform and send IAM permission relationships
 	fullResults := summary.FullResults()
@@ -191,6 +212,88 @@ func (a *AwsApolloControlFlow) Process(resourceType string) error {
 	return nil
 }
 
+// logAdminRoleAssumers reports the admin roles with the widest assumer sets
+func logAdminRoleAssumers(logger *cfg.Logger, report []iam.AdminRoleAssumers) {
+	if len(report) == 0 {
+		return
+	}
+	logger.Info(fmt.Sprintf("Found %d admin-equivalent roles", len(report)))
+	for _, entry := range report[:min(len(report), 10)] {
+		if len(entry.Assumers) == 0 {
+			break
+		}
+		logger.Info(fmt.Sprintf("Admin role %s is assumable by %d principals (%d direct, %d external) from accounts %s",
+			entry.RoleArn, len(entry.Assumers), entry.DirectCount, entry.ExternalCount, strings.Join(entry.AssumerAccounts, ", ")))
+	}
+}
+
+// logHopsCapped warns when an admin analysis stopped at --max-hops with
+// principals left unwalked, since its results may be incomplete
+func logHopsCapped(logger *cfg.Logger, summary *iam.PermissionsSummary) {
+	cappedRoles := 0
+	for _, entry := range summary.AdminRoleAssumers {
+		if entry.HopsCapped {
+			cappedRoles++
+		}
+	}
+	if cappedRoles > 0 {
+		logger.Warn(fmt.Sprintf("Assume-role chains to %d admin roles are longer than --max-hops %d; their assumers may be incomplete", cappedRoles, summary.MaxHops))
+	}
+	if summary.EffectiveAdminsHopsCapped {
+		logger.Warn(fmt.Sprintf("Effective admins stop at assume-role chains of --max-hops %d; raise it to report longer chains", summary.MaxHops))
+	}
+}
+
+// effectiveAdminCriteria reads the --admin-actions and --admin-action-threshold
+// options, falling back to the analyzer defaults for anything unset
+func effectiveAdminCriteria(arg func(string) any) iam.EffectiveAdminCriteria {
+	criteria := iam.DefaultEffectiveAdminCriteria
+	if actions, err := cfg.As[[]string](arg(options.AwsAdminActions().Name())); err == nil && len(actions) > 0 {
+		criteria.Actions = actions
+	}
+	if threshold, err := cfg.As[int](arg(options.AwsAdminActionThreshold().Name())); err == nil && threshold > 0 {
+		criteria.Threshold = threshold
+	}
+	return criteria
+}
+
+// logEffectiveAdmins reports how many principals are admin-equivalent and how
+func logEffectiveAdmins(logger *cfg.Logger, admins []iam.EffectiveAdmin) {
+	if len(admins) == 0 {
+		return
+	}
+	bySource := make(map[string]int)
+	for _, admin := range admins {
+		bySource[admin.Source]++
+	}
+	logger.Info(fmt.Sprintf("Found %d effective admins (%d by policy, %d by group, %d by self-escalation, %d by assume-role)",
+		len(admins), bySource[iam.AdminSourcePolicy], bySource[iam.AdminSourceGroup], bySource[iam.AdminSourceSelfEscalation], bySource[iam.AdminSourceAssumeRole]))
+	for _, admin := range admins {
+		logger.Debug("Effective admin", "principal", admin.Principal, "source", admin.Source, "reason", admin.Reason, "path", strings.Join(admin.Path, " -> "))
+	}
+}
+
+// logFederatedTrust reports role trusts that let SAML or OIDC identities in
+// without the conditions that restrict which ones
+func logFederatedTrust(logger *cfg.Logger, findings []iam.FederatedTrustFinding) {
+	if len(findings) == 0 {
+		return
+	}
+	logger.Warn(fmt.Sprintf("Found %d federated role trusts with missing subject or audience conditions", len(findings)))
+	for _, finding := range findings {
+		logger.Warn("Weak federated trust", "role", finding.RoleArn, "provider", finding.ProviderArn, "severity", finding.Severity, "missing", finding.MissingCondition)
+	}
+}
+
+// logAccountPublicAccess reports accounts whose S3 Public Access Block leaves a
+// setting off. One such account weakens every bucket in it, so each is logged
+// as a warning.
+func logAccountPublicAccess(logger *cfg.Logger, findings []iam.AccountPublicAccessFinding) {
+	for _, finding := range findings {
+		logger.Warn("Account-level S3 Public Access Block is not fully enabled", "account", finding.AccountID, "disabled", strings.Join(finding.DisabledSettings, ","), "buckets", finding.Buckets, "severity", finding.Severity)
+	}
+}
+
 func (a *AwsApolloControlFlow) gatherResources(resourceType string) error {
 	resourceChain := chain.NewChain(
 		general.NewResourceTypePreprocessor(a)(),
@@ -617,7 +720,7 @@ func (a *AwsApolloControlFlow) sendResourceRoleRelationships() error {
 			// If no ARN format, assume it's a direct role name
 			roleName = roleArn
 			// Use the resource's account ID for constructing the role ARN
-			roleArn = fmt.Sprintf("arn:aws:iam::%s:role/%s", accountId, roleName)
+			roleArn = fmt.Sprintf("arn:%s:iam::%s:role/%s", types.PartitionForRegion(resource.Region), accountId, roleName)
 		}
 
 		// Create the resource node using Tabularium transformers
@@ -5,7 +5,6 @@ import (
 	"encoding/json"
 	"fmt"
 	"log/slog"
-	"os"
 	"strings"
 
 	"github.com/aws/aws-sdk-go-v2/aws/arn"
@@ -18,6 +17,7 @@ import (
 	"github.com/praetorian-inc/nebula/pkg/links/options"
 	"github.com/praetorian-inc/nebula/pkg/outputters"
 	"github.com/praetorian-inc/nebula/pkg/types"
+	"github.com/praetorian-inc/nebula/pkg/utils"
 	"github.com/praetorian-inc/tabularium/pkg/model/model"
 )
 
@@ -38,6 +38,8 @@ func (a *AwsApolloOfflineControlFlow) Params() []cfg.Param {
 	params := []cfg.Param{}
 	params = append(params, options.AwsApolloOfflineOptions()...)
 	params = append(params, options.Neo4jOptions()...)
+	params = append(params, options.Neo4jEnrichOptions()...)
+	params = append(params, options.EdgesOut(), options.Neo4jAssumeYes())
 	return params
 }
 
@@ -46,13 +48,6 @@ func (a *AwsApolloOfflineControlFlow) Initialize() error {
 		return err
 	}
 
-	// Initialize PolicyData with empty resources slice and ResourcePolicies map
-	resources := make([]types.EnrichedResourceDescription, 0)
-	a.pd = &iam.PolicyData{
-		Resources:        &resources,
-		ResourcePolicies: make(map[string]*types.Policy),
-	}
-
 	// Initialize Neo4j connection
 	graphConfig := &graph.Config{
 		URI:      a.Args()[options.Neo4jURI().Name()].(string),
@@ -77,25 +72,24 @@ func (a *AwsApolloOfflineControlFlow) Initialize() error {
 
 func (a *AwsApolloOfflineControlFlow) Process(input any) error {
 	// Load all data from files
-	if err := a.loadDataFromFiles(); err != nil {
+	pd, err := LoadOfflinePolicyData(a.Arg)
+	if err != nil {
 		return err
 	}
-
-	// Validate that we have the required data
-	if a.pd.Gaad == nil {
-		return fmt.Errorf("GAAD data is required but not loaded")
-	}
-
-	// Add resource policies (trust policies) from GAAD roles
-	// This must be called after GAAD is loaded to populate ResourcePolicies map
-	a.pd.AddResourcePolicies()
+	a.pd = pd
 
 	// Perform the same analysis as online Apollo
-	analyzer := iam.NewGaadAnalyzer(a.pd)
-	summary, err := analyzer.AnalyzePrincipalPermissions()
+	summary, err := AnalyzeOfflinePolicyData(a.Arg, a.pd)
 	if err != nil {
 		return err
 	}
+	logAdminRoleAssumers(a.Logger, summary.AdminRoleAssumers)
+	logEffectiveAdmins(a.Logger, summary.EffectiveAdmins)
+	logHopsCapped(a.Logger, summary)
+	logFederatedTrust(a.Logger, summary.FederatedTrust)
+	logAccountPublicAccess(a.Logger, summary.AccountPublicAccess)
+	logIdentityCenterAdmins(a.Logger, summary.IdentityCenterAccess)
+	logUnusedPermissions(a.Logger, summary.UnusedPermissions)
 
 	// Create graph relationships (reuse existing logic)
 	a.graph(summary)
@@ -106,14 +100,79 @@ func (a *AwsApolloOfflineControlFlow) Process(input any) error {
 		a.Logger.Error("Failed to create assume role relationships: " + err.Error())
 	}
 
-	// Send the analysis summary as output
+	// Send the analysis summary as output, with action lists compressed unless full fidelity was requested
+	if noCompress, _ := cfg.As[bool](a.Arg(options.AwsNoCompressActions().Name())); !noCompress {
+		if catalog, err := iam.LoadActionCatalog(); err != nil {
+			a.Logger.Warn("Could not load the AWS action catalog, writing uncompressed action lists", "error", err)
+		} else {
+			summary.CompressActions(catalog)
+		}
+	}
 	a.Send(outputters.NewNamedOutputData(summary, "apollo-offline-analysis"))
 	a.Logger.Info("Apollo offline analysis completed successfully")
 
 	return nil
 }
 
-func (a *AwsApolloOfflineControlFlow) loadDataFromFiles() error {
+// offlinePolicyLoader reads the apollo-offline input files into policy data
+type offlinePolicyLoader struct {
+	arg func(string) any
+	pd  *iam.PolicyData
+}
+
+// LoadOfflinePolicyData reads the GAAD and the optional org policy, resource
+// policy, resource inventory, Identity Center and last-accessed files named by
+// the apollo-offline options. The GAAD is required.
+func LoadOfflinePolicyData(arg func(string) any) (*iam.PolicyData, error) {
+	resources := make([]types.EnrichedResourceDescription, 0)
+	a := &offlinePolicyLoader{
+		arg: arg,
+		pd: &iam.PolicyData{
+			Resources:        &resources,
+			ResourcePolicies: make(map[string]*types.Policy),
+		},
+	}
+	if err := a.loadDataFromFiles(); err != nil {
+		return nil, err
+	}
+
+	// Validate that we have the required data
+	if a.pd.Gaad == nil {
+		return nil, fmt.Errorf("GAAD data is required but not loaded")
+	}
+
+	// Add resource policies (trust policies) from GAAD roles
+	// This must be called after GAAD is loaded to populate ResourcePolicies map
+	a.pd.AddResourcePolicies()
+	return a.pd, nil
+}
+
+// AnalyzeOfflinePolicyData runs the Apollo analysis over loaded policy data
+// with the effective admin criteria, hop limit and worker count from the
+// options
+func AnalyzeOfflinePolicyData(arg func(string) any, pd *iam.PolicyData) (*iam.PermissionsSummary, error) {
+	analyzer := iam.NewGaadAnalyzer(pd)
+	analyzer.SetEffectiveAdminCriteria(effectiveAdminCriteria(arg))
+	if maxHops, err := cfg.As[int](arg(options.AwsMaxHops().Name())); err == nil {
+		analyzer.SetMaxHops(maxHops)
+	}
+	if workers, err := cfg.As[int](arg(options.AwsAnalyzerWorkers().Name())); err == nil {
+		analyzer.SetWorkers(workers)
+	}
+	return analyzer.AnalyzePrincipalPermissions()
+}
+
+func (a *offlinePolicyLoader) loadDataFromFiles() error {
+	// Standard input can only be consumed once
+	var paths []string
+	for _, name := range []string{"org-policies", "gaad-file", "resource-policies-file", "resources-file", "identity-center-file", "last-accessed"} {
+		path, _ := cfg.As[string](a.arg(name))
+		paths = append(paths, path)
+	}
+	if err := utils.CheckStdinInputs(paths...); err != nil {
+		return err
+	}
+
 	// Load organization policies
 	if err := a.loadOrgPoliciesFromFile(); err != nil {
 		return err
@@ -129,11 +188,26 @@ func (a *AwsApolloOfflineControlFlow) loadDataFromFiles() error {
 		return err
 	}
 
+	// Load resource inventory
+	if err := a.loadResourcesFromFile(); err != nil {
+		return err
+	}
+
+	// Load Identity Center permission sets and assignments; their roles join the GAAD
+	if err := a.loadIdentityCenterFromFile(); err != nil {
+		return err
+	}
+
+	// Load service last accessed data for the unused permissions report
+	if err := a.loadLastAccessedFromFile(); err != nil {
+		return err
+	}
+
 	return nil
 }
 
-func (a *AwsApolloOfflineControlFlow) loadOrgPoliciesFromFile() error {
-	orgPoliciesFile, err := cfg.As[string](a.Arg("org-policies"))
+func (a *offlinePolicyLoader) loadOrgPoliciesFromFile() error {
+	orgPoliciesFile, err := cfg.As[string](a.arg("org-policies"))
 	if err != nil {
 		slog.Warn("No organization policies file provided, using default policies")
 		a.pd.OrgPolicies = orgpolicies.NewDefaultOrgPolicies()
@@ -146,7 +220,7 @@ func (a *AwsApolloOfflineControlFlow) loadOrgPoliciesFromFile() error {
 		return nil
 	}
 
-	fileBytes, err := os.ReadFile(orgPoliciesFile)
+	fileBytes, err := utils.ReadInputFile(orgPoliciesFile)
 	if err != nil {
 		return fmt.Errorf("failed to read org policies file '%s': %w", orgPoliciesFile, err)
 	}
@@ -168,13 +242,19 @@ func (a *AwsApolloOfflineControlFlow) loadOrgPoliciesFromFile() error {
 		}
 		a.pd.OrgPolicies = orgPolicies
 	}
+	if a.pd.OrgPolicies == nil {
+		return fmt.Errorf("org policies file '%s' is null", orgPoliciesFile)
+	}
+	if err := a.pd.OrgPolicies.Validate(); err != nil {
+		return fmt.Errorf("failed to load org policies from '%s': %w", orgPoliciesFile, err)
+	}
 
 	slog.Info("Successfully loaded organization policies", "file", orgPoliciesFile)
 	return nil
 }
 
-func (a *AwsApolloOfflineControlFlow) loadGaadFromFile() error {
-	gaadFile, err := cfg.As[string](a.Arg("gaad-file"))
+func (a *offlinePolicyLoader) loadGaadFromFile() error {
+	gaadFile, err := cfg.As[string](a.arg("gaad-file"))
 	if err != nil {
 		return fmt.Errorf("gaad-file parameter is required for offline Apollo analysis: %w", err)
 	}
@@ -183,34 +263,24 @@ func (a *AwsApolloOfflineControlFlow) loadGaadFromFile() error {
 		return fmt.Errorf("gaad-file parameter cannot be empty")
 	}
 
-	fileBytes, err := os.ReadFile(gaadFile)
+	fileBytes, err := utils.ReadInputFile(gaadFile)
 	if err != nil {
 		return fmt.Errorf("failed to read GAAD file '%s': %w", gaadFile, err)
 	}
 
-	// Try to unmarshal as array first (matching account-auth-details module output)
-	var gaadArray []types.Gaad
-	if err := json.Unmarshal(fileBytes, &gaadArray); err == nil {
-		if len(gaadArray) > 0 {
-			a.pd.Gaad = &gaadArray[0]
-		} else {
-			return fmt.Errorf("GAAD file '%s' contains empty array", gaadFile)
-		}
-	} else {
-		// Fallback to single object format
-		var gaad types.Gaad
-		if err := json.Unmarshal(fileBytes, &gaad); err != nil {
-			return fmt.Errorf("failed to unmarshal GAAD data from '%s': %w", gaadFile, err)
-		}
-		a.pd.Gaad = &gaad
+	// Accepts both the account-auth-details module's array output and a single GAAD object
+	gaad, err := types.DecodeGaad(fileBytes)
+	if err != nil {
+		return fmt.Errorf("failed to load GAAD data from '%s': %w", gaadFile, err)
 	}
+	a.pd.Gaad = gaad
 
 	slog.Info("Successfully loaded GAAD data", "file", gaadFile)
 	return nil
 }
 
-func (a *AwsApolloOfflineControlFlow) loadResourcePoliciesFromFile() error {
-	resourcePoliciesFile, err := cfg.As[string](a.Arg("resource-policies-file"))
+func (a *offlinePolicyLoader) loadResourcePoliciesFromFile() error {
+	resourcePoliciesFile, err := cfg.As[string](a.arg("resource-policies-file"))
 	if err != nil {
 		slog.Warn("No resource policies file provided, proceeding without resource policies")
 		a.pd.ResourcePolicies = make(map[string]*types.Policy)
@@ -223,7 +293,7 @@ func (a *AwsApolloOfflineControlFlow) loadResourcePoliciesFromFile() error {
 		return nil
 	}
 
-	fileBytes, err := os.ReadFile(resourcePoliciesFile)
+	fileBytes, err := utils.ReadInputFile(resourcePoliciesFile)
 	if err != nil {
 		return fmt.Errorf("failed to read resource policies file '%s': %w", resourcePoliciesFile, err)
 	}
@@ -247,11 +317,121 @@ func (a *AwsApolloOfflineControlFlow) loadResourcePoliciesFromFile() error {
 	if a.pd.ResourcePolicies == nil {
 		a.pd.ResourcePolicies = make(map[string]*types.Policy)
 	}
+	if err := types.ValidateResourcePolicies(a.pd.ResourcePolicies); err != nil {
+		return fmt.Errorf("failed to load resource policies from '%s': %w", resourcePoliciesFile, err)
+	}
 
 	slog.Info("Successfully loaded resource policies", "file", resourcePoliciesFile, "count", len(a.pd.ResourcePolicies))
 	return nil
 }
 
+func (a *offlinePolicyLoader) loadResourcesFromFile() error {
+	resourcesFile, err := cfg.As[string](a.arg("resources-file"))
+	if err != nil || resourcesFile == "" {
+		slog.Debug("No resources file provided, proceeding without resource inventory")
+		return nil
+	}
+	format, _ := cfg.As[string](a.arg("resource-format"))
+
+	fileBytes, err := utils.ReadInputFile(resourcesFile)
+	if err != nil {
+		return fmt.Errorf("failed to read resources file '%s': %w", resourcesFile, err)
+	}
+
+	resources, err := types.ParseResourceDescriptions(fileBytes, format)
+	if err != nil {
+		return fmt.Errorf("failed to load resources from '%s': %w", resourcesFile, err)
+	}
+	if len(resources) == 0 {
+		return fmt.Errorf("resources file '%s' appears empty or malformed — did you pass the right file and --resource-format (%s)?", resourcesFile, format)
+	}
+	*a.pd.Resources = append(*a.pd.Resources, resources...)
+
+	slog.Info("Successfully loaded resources", "file", resourcesFile, "format", format, "count", len(resources))
+	return nil
+}
+
+// logIdentityCenterAdmins reports the Identity Center users and groups that are admin in an account
+func logIdentityCenterAdmins(logger *cfg.Logger, access []iam.IdentityCenterAccess) {
+	if len(access) == 0 {
+		return
+	}
+	admins := 0
+	for _, entry := range access {
+		if !entry.Admin {
+			continue
+		}
+		admins++
+		logger.Debug("Identity Center admin", "principal", entry.PrincipalName, "type", entry.PrincipalType, "account", entry.AccountID, "reason", entry.AdminReason)
+	}
+	logger.Info(fmt.Sprintf("Identity Center grants %d principal and account pairs, %d of them admin", len(access), admins))
+}
+
+func (a *offlinePolicyLoader) loadIdentityCenterFromFile() error {
+	identityCenterFile, err := cfg.As[string](a.arg("identity-center-file"))
+	if err != nil || identityCenterFile == "" {
+		slog.Debug("No Identity Center file provided, proceeding without permission set analysis")
+		return nil
+	}
+
+	fileBytes, err := utils.ReadInputFile(identityCenterFile)
+	if err != nil {
+		return fmt.Errorf("failed to read Identity Center file '%s': %w", identityCenterFile, err)
+	}
+
+	var identityCenter types.IdentityCenter
+	if err := json.Unmarshal(fileBytes, &identityCenter); err != nil {
+		return fmt.Errorf("failed to unmarshal Identity Center data from '%s': %w", identityCenterFile, err)
+	}
+	if err := a.pd.AddIdentityCenter(&identityCenter); err != nil {
+		return fmt.Errorf("failed to load Identity Center data from '%s': %w", identityCenterFile, err)
+	}
+
+	slog.Info("Successfully loaded Identity Center data", "file", identityCenterFile,
+		"permission_sets", len(identityCenter.PermissionSets), "assignments", len(identityCenter.AccountAssignments))
+	return nil
+}
+
+// logUnusedPermissions reports principals granted services they never used
+func logUnusedPermissions(logger *cfg.Logger, unused []iam.UnusedPermissions) {
+	if len(unused) == 0 {
+		return
+	}
+	services := 0
+	for _, entry := range unused {
+		services += len(entry.UnusedServices)
+		logger.Debug("Unused service grants", "principal", entry.PrincipalArn, "unused", len(entry.UnusedServices), "granted", entry.GrantedServices)
+	}
+	logger.Info(fmt.Sprintf("%d principals have %d granted services they never used", len(unused), services))
+}
+
+func (a *offlinePolicyLoader) loadLastAccessedFromFile() error {
+	lastAccessedFile, err := cfg.As[string](a.arg("last-accessed"))
+	if err != nil || lastAccessedFile == "" {
+		slog.Debug("No last-accessed file provided, skipping the unused permissions report")
+		return nil
+	}
+
+	fileBytes, err := utils.ReadInputFile(lastAccessedFile)
+	if err != nil {
+		return fmt.Errorf("failed to read last-accessed file '%s': %w", lastAccessedFile, err)
+	}
+
+	var report types.ServiceLastAccessedReport
+	if err := json.Unmarshal(fileBytes, &report); err != nil {
+		return fmt.Errorf("failed to unmarshal last-accessed data from '%s': %w", lastAccessedFile, err)
+	}
+	if len(report) == 0 {
+		return fmt.Errorf("last-accessed file '%s' has no principals — did you pass the right file?", lastAccessedFile)
+	}
+	if err := a.pd.AddServiceLastAccessed(report); err != nil {
+		return fmt.Errorf("failed to load last-accessed data from '%s': %w", lastAccessedFile, err)
+	}
+
+	slog.Info("Successfully loaded last-accessed data", "file", lastAccessedFile, "principals", len(report))
+	return nil
+}
+
 // Reuse the existing graph method from apollo_control_flow.go
 func (a *AwsApolloOfflineControlFlow) graph(summary *iam.PermissionsSummary) {
 	// Create Neo4j outputter manually and initialize it
@@ -358,7 +538,7 @@ func (a *AwsApolloOfflineControlFlow) sendResourceRoleRelationships(outputChain
 			// If no ARN format, assume it's a direct role name
 			roleName = roleArn
 			// Use the resource's account ID for constructing the role ARN
-			roleArn = fmt.Sprintf("arn:aws:iam::%s:role/%s", accountId, roleName)
+			roleArn = fmt.Sprintf("arn:%s:iam::%s:role/%s", types.PartitionForRegion(resource.Region), accountId, roleName)
 		}
 
 		// Create the resource node using Tabularium transformers
@@ -432,7 +612,7 @@ func (a *AwsApolloOfflineControlFlow) sendResourceRoleRelationshipsDirectly(outp
 			// If no ARN format, assume it's a direct role name
 			roleName = roleArn
 			// Use the resource's account ID for constructing the role ARN
-			roleArn = fmt.Sprintf("arn:aws:iam::%s:role/%s", accountId, roleName)
+			roleArn = fmt.Sprintf("arn:%s:iam::%s:role/%s", types.PartitionForRegion(resource.Region), accountId, roleName)
 		}
 
 		// Create the resource node using Tabularium transformers
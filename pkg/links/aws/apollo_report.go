@@ -32,7 +32,7 @@ func (a *ApolloReport) Params() []cfg.Param {
 	params := a.Base.Params()
 	params = append(params, options.Neo4jOptions()...)
 	params = append(params,
-		cfg.NewParam[string]("report-type", "Type of report to generate: all, privesc, external-trust").
+		cfg.NewParam[string]("report-type", "Type of report to generate: all, privesc, external-trust, role-takeover").
 			WithDefault("all").
 			WithShortcode("t"),
 	)
@@ -87,6 +87,16 @@ func (a *ApolloReport) Process(input any) error {
 		}
 	}
 
+	// Generate role takeover report
+	if reportType == "all" || reportType == "role-takeover" {
+		takeoverReport, err := a.generateRoleTakeoverReport()
+		if err != nil {
+			a.Logger.Error("Failed to generate role takeover report", "error", err)
+		} else {
+			report.RoleTakeover = takeoverReport
+		}
+	}
+
 	return a.Send(report)
 }
 
@@ -237,6 +247,33 @@ func (a *ApolloReport) generateExternalTrustReport() (*types.ExternalTrustReport
 	return report, nil
 }
 
+func (a *ApolloReport) generateRoleTakeoverReport() (*types.RoleTakeoverReport, error) {
+	res, err := queries.RunPlatformQuery(a.db, "aws/analysis/role_takeover", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to run role_takeover query: %w", err)
+	}
+
+	report := &types.RoleTakeoverReport{Takeovers: []types.RoleTakeover{}}
+	for _, record := range res.Records {
+		takeover := types.RoleTakeover{}
+		takeover.Principal, _ = record["principal"].(string)
+		takeover.Role, _ = record["role"].(string)
+		takeover.RoleIsAdmin, _ = record["roleIsAdmin"].(bool)
+		takeover.RoleIsPrivileged, _ = record["roleIsPrivileged"].(bool)
+		if reachable, ok := record["reachableResources"].(int64); ok {
+			takeover.ReachableResources = int(reachable)
+		}
+
+		report.Total++
+		if takeover.RoleIsAdmin {
+			report.AdminRoles++
+		}
+		report.Takeovers = append(report.Takeovers, takeover)
+	}
+
+	return report, nil
+}
+
 func (a *ApolloReport) Close() {
 	if a.db != nil {
 		a.db.Close()
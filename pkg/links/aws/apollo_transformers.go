@@ -60,6 +60,64 @@ func NewSSMIAMRelationship(source, target model.GraphModel, permission string, s
 	}
 }
 
+// EvaluatedIAMRelationship is an IAMRelationship for a permission that is not
+// a plain allow: it only holds under conditions, or is also allowed by the
+// principal's permissions boundary. Plain allows stay IAMRelationships, so
+// queries can tell them apart by the evaluationDecision property.
+type EvaluatedIAMRelationship struct {
+	*model.IAMRelationship
+	// EvaluationDecision is the iam.EvaluationDecision of the permission
+	EvaluationDecision string `neo4j:"evaluationDecision" json:"evaluationDecision"`
+	// PermissionsBoundaries are the boundary policies that allowed the permission
+	PermissionsBoundaries []string `neo4j:"permissionsBoundaries" json:"permissionsBoundaries"`
+	// ConditionKeys and ConditionOperators are the condition keys the permission
+	// depends on and the operator each is tested with, index for index
+	ConditionKeys      []string `neo4j:"conditionKeys" json:"conditionKeys"`
+	ConditionOperators []string `neo4j:"conditionOperators" json:"conditionOperators"`
+	// Conditions is the JSON encoded list of iam.GrantingCondition, with the
+	// policy each condition comes from and the values it tests for
+	Conditions string `neo4j:"conditions" json:"conditions"`
+}
+
+// Label returns the sanitized permission as the relationship label
+func (e *EvaluatedIAMRelationship) Label() string {
+	return e.IAMRelationship.Label()
+}
+
+// NewEvaluatedIAMRelationship creates an IAM relationship that records the
+// conditions and permissions boundaries an allowed permission depends on
+func NewEvaluatedIAMRelationship(source, target model.GraphModel, permission string, result *iam.EvaluationResult) (*EvaluatedIAMRelationship, error) {
+	rel := &EvaluatedIAMRelationship{
+		IAMRelationship:       model.NewIAMRelationship(source, target, permission),
+		EvaluationDecision:    string(result.Decision),
+		PermissionsBoundaries: make([]string, 0),
+		ConditionKeys:         make([]string, 0, len(result.Conditions)),
+		ConditionOperators:    make([]string, 0, len(result.Conditions)),
+		Conditions:            "[]",
+	}
+	if result.PolicyResult != nil {
+		seen := make(map[string]bool)
+		for _, eval := range result.PolicyResult.Evaluations[iam.EvalTypePermBoundary] {
+			if eval.IsAllowed() && eval.Origin != "" && !seen[eval.Origin] {
+				seen[eval.Origin] = true
+				rel.PermissionsBoundaries = append(rel.PermissionsBoundaries, eval.Origin)
+			}
+		}
+	}
+	if len(result.Conditions) > 0 {
+		for _, condition := range result.Conditions {
+			rel.ConditionKeys = append(rel.ConditionKeys, condition.Key)
+			rel.ConditionOperators = append(rel.ConditionOperators, condition.Operator)
+		}
+		conditions, err := json.Marshal(result.Conditions)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode conditions: %w", err)
+		}
+		rel.Conditions = string(conditions)
+	}
+	return rel, nil
+}
+
 // isSSMAction checks if the action is an SSM action that may have document restrictions
 func isSSMAction(action string) bool {
 	return strings.HasPrefix(action, "ssm:")
@@ -453,6 +511,18 @@ func TransformResultToRelationship(result iam.FullResult) (model.GraphRelationsh
 		return ssmRel, nil
 	}
 
+	// Conditional and boundary-limited permissions carry what they depend on
+	if result.Result != nil && result.Result.Decision != "" && result.Result.Decision != iam.DecisionAllowed {
+		evaluatedRel, err := NewEvaluatedIAMRelationship(source, target, result.Action, result.Result)
+		if err != nil {
+			return nil, err
+		}
+		evaluatedRel.Capability = "apollo-iam-analysis"
+		evaluatedRel.Created = model.Now()
+		evaluatedRel.Visited = model.Now()
+		return evaluatedRel, nil
+	}
+
 	// Create the standard IAM permission relationship
 	rel := model.NewIAMRelationship(source, target, result.Action)
 
@@ -6,7 +6,6 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
-	"os"
 	"slices"
 	"strings"
 	"sync"
@@ -29,11 +28,13 @@ import (
 	"github.com/praetorian-inc/nebula/pkg/links/aws/orgpolicies"
 	"github.com/praetorian-inc/nebula/pkg/links/options"
 	"github.com/praetorian-inc/nebula/pkg/types"
+	"github.com/praetorian-inc/nebula/pkg/utils"
 )
 
 type AwsResourcePolicyChecker struct {
 	*base.AwsReconLink
 	orgPolicies *orgpolicies.OrgPolicies
+	orgID       string
 }
 
 func NewAwsResourcePolicyChecker(configs ...cfg.Config) chain.Link {
@@ -44,7 +45,7 @@ func NewAwsResourcePolicyChecker(configs ...cfg.Config) chain.Link {
 
 func (a *AwsResourcePolicyChecker) Params() []cfg.Param {
 	params := a.AwsReconLink.Params()
-	params = append(params, options.AwsOrgPoliciesFile())
+	params = append(params, options.AwsOrgPoliciesFile(), options.AwsOrgID())
 	return params
 }
 
@@ -66,12 +67,18 @@ func (a *AwsResourcePolicyChecker) Initialize() error {
 		slog.Info("Successfully loaded organization policies", "file", orgPoliciesFile, "scps", len(orgPolicies.SCPs), "rcps", len(orgPolicies.RCPs))
 	}
 
+	// Grants restricted to the account's own organization are internal, not public
+	a.orgID, _ = cfg.As[string](a.Arg("org-id"))
+	if a.orgID != "" {
+		slog.Debug("Treating grants restricted to organization as internal", "org_id", a.orgID)
+	}
+
 	return nil
 }
 
 // loadOrgPoliciesFromFile loads organization policies from a JSON file
 func loadOrgPoliciesFromFile(filePath string) (*orgpolicies.OrgPolicies, error) {
-	data, err := os.ReadFile(filePath)
+	data, err := utils.ReadInputFile(filePath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read file: %w", err)
 	}
@@ -80,6 +87,9 @@ func loadOrgPoliciesFromFile(filePath string) (*orgpolicies.OrgPolicies, error)
 	if err := json.Unmarshal(data, &orgPolicies); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal JSON: %w", err)
 	}
+	if err := orgPolicies.Validate(); err != nil {
+		return nil, err
+	}
 
 	return &orgPolicies, nil
 }
@@ -129,7 +139,12 @@ func (a *AwsResourcePolicyChecker) Process(resource *types.EnrichedResourceDescr
 		return a.processS3Bucket(resource, awsCfg, props, identifierStr, serviceConfig)
 	}
 
-	// Standard flow for non-S3 resources
+	// Delegate to KMS-specific handler (resolves the principals in key policies and grants)
+	if resource.TypeName == kmsKeyType {
+		return a.processKMSKey(resource, awsCfg, props, identifierStr, serviceConfig)
+	}
+
+	// Standard flow for other resources
 	return a.processStandardResource(resource, awsCfg, props, identifierStr, serviceConfig)
 }
 
@@ -161,7 +176,7 @@ func (a *AwsResourcePolicyChecker) processStandardResource(
 	}
 
 	// Check if the policy allows public access
-	res, err := a.analyzePolicy(resource.Arn.String(), policy, resource.AccountId, resource.TypeName)
+	res, err := a.analyzeResourcePolicy(resource, policy)
 	if err != nil {
 		slog.Error("Failed to analyze policy", "resource", identifierStr, "error", err)
 		return err
@@ -348,6 +363,9 @@ func (a *AwsResourcePolicyChecker) flagPublicResource(
 	props["EvaluationReasons"] = getUniqueDetails(results)
 	props["NeedsManualTriage"] = hasInconclusiveConditions(results)
 	props["Actions"] = getAllowedActions(results)
+	for key, value := range servicePublicAccessFinding(resource.TypeName, results) {
+		props[key] = value
+	}
 
 	enriched := types.EnrichedResourceDescription{
 		Identifier: resource.Identifier,
@@ -577,6 +595,19 @@ func GetEvaluationContexts(resourceType string) []*iam.RequestContext {
 		}
 		return generator.GenerateAllPermutations()
 
+	case "AWS::ApiGateway::RestApi":
+		generator := ContextGenerator{
+			BasePrincipals: []string{
+				"arn:aws:iam::111122223333:role/praetorian", // Generic cross-account
+			},
+			Conditions: []ConditionPermutation{
+				{"aws:PrincipalType", []string{"Anonymous", "AssumedRole", ""}},
+				{"aws:SourceIp", []string{"0.0.0.0/0", ""}},
+				{"aws:SourceVpce", []string{"vpce-0123abcd4ef567890", ""}},
+			},
+		}
+		return generator.GenerateAllPermutations()
+
 	case "AWS::OpenSearchServerless::Collection":
 		generator := ContextGenerator{
 			BasePrincipals: []string{
@@ -594,6 +625,19 @@ func GetEvaluationContexts(resourceType string) []*iam.RequestContext {
 		}
 		return generator.GenerateAllPermutations()
 
+	case "AWS::KMS::Key":
+		generator := ContextGenerator{
+			BasePrincipals: []string{
+				"arn:aws:iam::111122223333:role/praetorian", // Generic cross-account
+			},
+			Conditions: []ConditionPermutation{
+				{"aws:PrincipalType", []string{"Anonymous", "AssumedRole", "User", ""}},
+				{"kms:CallerAccount", []string{"111122223333", ""}},
+				{"kms:ViaService", []string{"s3.us-east-1.amazonaws.com", ""}}, // Use through an integrated service
+			},
+		}
+		return generator.GenerateAllPermutations()
+
 	default:
 		// Default fallback for unknown resource types
 		return []*iam.RequestContext{
@@ -604,6 +648,14 @@ func GetEvaluationContexts(resourceType string) []*iam.RequestContext {
 
 // evaluatePolicyWithContext evaluates a policy with a specific RequestContext (DRY helper)
 func (a *AwsResourcePolicyChecker) evaluatePolicyWithContext(reqCtx *iam.RequestContext, policy *types.Policy, resource string) ([]*iam.EvaluationResult, error) {
+	if policy.Statement == nil {
+		return nil, errors.New("policy statement is nil")
+	}
+	return a.evaluateActionsWithContext(reqCtx, policy, resource, iam.ExtractActions(policy.Statement))
+}
+
+// evaluateActionsWithContext evaluates the given actions against a policy with a specific RequestContext
+func (a *AwsResourcePolicyChecker) evaluateActionsWithContext(reqCtx *iam.RequestContext, policy *types.Policy, resource string, actions []string) ([]*iam.EvaluationResult, error) {
 	pd := iam.NewPolicyData(
 		nil,           // GAAD - not needed for resource policy analysis
 		a.orgPolicies, // Organization policies from loaded file
@@ -620,8 +672,6 @@ func (a *AwsResourcePolicyChecker) evaluatePolicyWithContext(reqCtx *iam.Request
 	}
 
 	results := []*iam.EvaluationResult{}
-	actions := iam.ExtractActions(policy.Statement)
-
 	for _, action := range actions {
 		er := &iam.EvaluationRequest{
 			Action:             action,
@@ -666,7 +716,7 @@ func (a *AwsResourcePolicyChecker) analyzePolicy(resource string, policy *types.
 		// Only store allowed results to reduce memory usage
 		// Denied results don't contribute to public access detection
 		for _, res := range results {
-			if res.Allowed {
+			if res.Allowed && !isOrgInternal(res, a.orgID) {
 				allowedResults = append(allowedResults, res)
 			}
 		}
@@ -675,6 +725,21 @@ func (a *AwsResourcePolicyChecker) analyzePolicy(resource string, policy *types.
 	return allowedResults, nil
 }
 
+// analyzeResourcePolicy analyzes the policy of a resource against every
+// resource ARN its statements are written for, see policyEvaluationTargets
+func (a *AwsResourcePolicyChecker) analyzeResourcePolicy(resource *types.EnrichedResourceDescription, policy *types.Policy) ([]*iam.EvaluationResult, error) {
+	evalPolicy, targets := policyEvaluationTargets(resource, policy)
+	var results []*iam.EvaluationResult
+	for _, target := range targets {
+		targetResults, err := a.analyzePolicy(target, evalPolicy, resource.AccountId, resource.TypeName)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, targetResults...)
+	}
+	return results, nil
+}
+
 // s3ObjectLevelActions contains S3 actions that operate on objects (not buckets)
 // These actions typically require bucket/* resource ARNs in policies
 var s3ObjectLevelActions = map[string]bool{
@@ -739,7 +804,7 @@ func (a *AwsResourcePolicyChecker) analyzeS3ObjectPolicy(bucketArn string, polic
 
 		// Only include allowed results for object-level actions to reduce memory usage
 		for _, res := range results {
-			if res.Allowed && s3ObjectLevelActions[string(res.Action)] {
+			if res.Allowed && s3ObjectLevelActions[string(res.Action)] && !isOrgInternal(res, a.orgID) {
 				allowedResults = append(allowedResults, res)
 			}
 		}
@@ -788,6 +853,74 @@ func hasInconclusiveConditions(results []*iam.EvaluationResult) bool {
 	return false
 }
 
+// isOrgInternal reports whether every resource policy statement that allowed
+// the request is restricted to orgID by an aws:PrincipalOrgID or
+// aws:PrincipalOrgPaths condition. Such grants only reach principals inside
+// the account's own organization, so they are internal rather than external.
+func isOrgInternal(res *iam.EvaluationResult, orgID string) bool {
+	if orgID == "" || res.PolicyResult == nil {
+		return false
+	}
+
+	allowing := 0
+	for _, stmt := range res.PolicyResult.Evaluations[iam.EvalTypeResource] {
+		if !stmt.IsAllowed() {
+			continue
+		}
+		allowing++
+		if !restrictsToOrg(stmt.ConditionEvaluation, orgID) {
+			return false
+		}
+	}
+	return allowing > 0
+}
+
+// restrictsToOrg checks whether a statement's conditions limit principals to orgID
+func restrictsToOrg(eval *iam.ConditionEval, orgID string) bool {
+	if eval == nil {
+		return false
+	}
+
+	for _, keyResult := range eval.KeyResults {
+		var inOrg func(string) bool
+		switch {
+		case strings.EqualFold(keyResult.Key, "aws:PrincipalOrgID"):
+			inOrg = func(v string) bool { return strings.EqualFold(v, orgID) }
+		case strings.EqualFold(keyResult.Key, "aws:PrincipalOrgPaths"):
+			inOrg = func(v string) bool { return strings.HasPrefix(strings.ToLower(v), strings.ToLower(orgID)+"/") }
+		default:
+			continue
+		}
+
+		// Only positive matches restrict principals; StringNotEquals, and
+		// IfExists and ForAllValues variants, which pass when the key is
+		// absent, let principals outside the organization through
+		operator := strings.ToLower(keyResult.Operator)
+		if strings.HasPrefix(operator, "forallvalues:") {
+			continue
+		}
+		operator = strings.TrimPrefix(operator, "foranyvalue:")
+		if operator != "stringequals" && operator != "stringequalsignorecase" && operator != "stringlike" {
+			continue
+		}
+		if len(keyResult.Values) == 0 {
+			continue
+		}
+
+		restricted := true
+		for _, value := range keyResult.Values {
+			if !inOrg(value) {
+				restricted = false
+				break
+			}
+		}
+		if restricted {
+			return true
+		}
+	}
+	return false
+}
+
 func strToPolicy(s string) (*types.Policy, error) {
 	var p types.Policy
 	err := json.Unmarshal([]byte(s), &p)
@@ -852,9 +985,21 @@ var ServiceMap = map[string]ServicePolicyConfig{
 		IdentifierField: "Name",
 		PolicyField:     "AccessPolicy",
 	},
+	"AWS::ApiGateway::RestApi": {
+		GetPolicy:       ServicePolicyFuncMap["AWS::ApiGateway::RestApi"],
+		IdentifierField: "RestApiId",
+		PolicyField:     "AccessPolicy",
+	},
+	"AWS::KMS::Key": {
+		GetPolicy:       ServicePolicyFuncMap["AWS::KMS::Key"],
+		IdentifierField: "KeyId",
+		PolicyField:     "KeyPolicy",
+	},
 }
 
 var ServicePolicyFuncMap = map[string]PolicyGetter{
+	"AWS::ApiGateway::RestApi": getRestAPIPolicy,
+	"AWS::KMS::Key":            getKMSKeyPolicy,
 	"AWS::Lambda::Function": func(ctx context.Context, cfg aws.Config, functionName string, allowedRegions []string) (*types.Policy, error) {
 		client := lambda.NewFromConfig(cfg)
 		resp, err := client.GetPolicy(ctx, &lambda.GetPolicyInput{
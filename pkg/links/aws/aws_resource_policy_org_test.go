@@ -0,0 +1,82 @@
+package aws
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnalyzePolicyOrgInternalGrants(t *testing.T) {
+	const queueArn = "arn:aws:sqs:us-east-1:123456789012:orders"
+
+	tests := []struct {
+		name       string
+		orgID      string
+		policy     string
+		wantPublic bool
+	}{
+		{
+			name:  "PrincipalOrgID matching own org is internal",
+			orgID: "o-own1234567",
+			policy: `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Principal":"*","Action":"sqs:SendMessage","Resource":"` + queueArn + `",
+				"Condition":{"StringEquals":{"aws:PrincipalOrgID":"o-own1234567"}}}]}`,
+			wantPublic: false,
+		},
+		{
+			name:  "PrincipalOrgPaths under own org is internal",
+			orgID: "o-own1234567",
+			policy: `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Principal":"*","Action":"sqs:SendMessage","Resource":"` + queueArn + `",
+				"Condition":{"ForAnyValue:StringLike":{"aws:PrincipalOrgPaths":"o-own1234567/r-ab12/ou-ab12-11111111/*"}}}]}`,
+			wantPublic: false,
+		},
+		{
+			name:  "PrincipalOrgID for another org stays external",
+			orgID: "o-own1234567",
+			policy: `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Principal":"*","Action":"sqs:SendMessage","Resource":"` + queueArn + `",
+				"Condition":{"StringEquals":{"aws:PrincipalOrgID":"o-partner9999"}}}]}`,
+			wantPublic: true,
+		},
+		{
+			name:  "without --org-id the grant stays external",
+			orgID: "",
+			policy: `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Principal":"*","Action":"sqs:SendMessage","Resource":"` + queueArn + `",
+				"Condition":{"StringEquals":{"aws:PrincipalOrgID":"o-own1234567"}}}]}`,
+			wantPublic: true,
+		},
+		{
+			name:  "StringNotEquals on own org is not a restriction",
+			orgID: "o-own1234567",
+			policy: `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Principal":"*","Action":"sqs:SendMessage","Resource":"` + queueArn + `",
+				"Condition":{"StringNotEquals":{"aws:PrincipalOrgID":"o-own1234567"}}}]}`,
+			wantPublic: true,
+		},
+		{
+			name:  "ForAllValues on own org passes without the key and stays external",
+			orgID: "o-own1234567",
+			policy: `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Principal":"*","Action":"sqs:SendMessage","Resource":"` + queueArn + `",
+				"Condition":{"ForAllValues:StringEquals":{"aws:PrincipalOrgID":"o-own1234567"}}}]}`,
+			wantPublic: true,
+		},
+		{
+			name:  "an unrestricted statement alongside an org-restricted one stays external",
+			orgID: "o-own1234567",
+			policy: `{"Version":"2012-10-17","Statement":[
+				{"Effect":"Allow","Principal":"*","Action":"sqs:SendMessage","Resource":"` + queueArn + `","Condition":{"StringEquals":{"aws:PrincipalOrgID":"o-own1234567"}}},
+				{"Effect":"Allow","Principal":"*","Action":"sqs:SendMessage","Resource":"` + queueArn + `"}]}`,
+			wantPublic: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy, err := strToPolicy(tt.policy)
+			require.NoError(t, err)
+
+			checker := &AwsResourcePolicyChecker{orgID: tt.orgID}
+			results, err := checker.analyzePolicy(queueArn, policy, "123456789012", "AWS::SQS::Queue")
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantPublic, isPublic(results))
+		})
+	}
+}
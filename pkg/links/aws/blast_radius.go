@@ -0,0 +1,81 @@
+package aws
+
+import (
+	"strings"
+
+	"github.com/praetorian-inc/janus-framework/pkg/chain"
+	"github.com/praetorian-inc/janus-framework/pkg/chain/cfg"
+	"github.com/praetorian-inc/nebula/internal/message"
+	iam "github.com/praetorian-inc/nebula/pkg/iam/aws"
+	"github.com/praetorian-inc/nebula/pkg/links/options"
+	"github.com/praetorian-inc/nebula/pkg/outputters"
+)
+
+// blastRadiusTopItems is how many reached principals are printed
+const blastRadiusTopItems = 10
+
+// BlastRadiusLink runs the offline analysis over a GAAD and reports everything
+// reachable from one compromised principal
+type BlastRadiusLink struct {
+	*chain.Base
+}
+
+func NewBlastRadiusLink(configs ...cfg.Config) chain.Link {
+	l := &BlastRadiusLink{}
+	l.Base = chain.NewBase(l, configs...)
+	return l
+}
+
+func (l *BlastRadiusLink) Params() []cfg.Param {
+	return []cfg.Param{
+		options.AwsBlastRadiusStart(),
+		options.AwsGaadFile(),
+		options.AwsOrgPoliciesFile(),
+		options.AwsResourcePoliciesFile(),
+		options.AwsResourcesFile(),
+		options.AwsResourceFormat(),
+		options.AwsAdminActions(),
+		options.AwsAdminActionThreshold(),
+		options.AwsMaxHops(),
+		options.AwsAnalyzerWorkers(),
+	}
+}
+
+func (l *BlastRadiusLink) Process(input any) error {
+	start, _ := cfg.As[string](l.Arg(options.AwsBlastRadiusStart().Name()))
+
+	pd, err := LoadOfflinePolicyData(l.Arg)
+	if err != nil {
+		return err
+	}
+	summary, err := AnalyzeOfflinePolicyData(l.Arg, pd)
+	if err != nil {
+		return err
+	}
+	report, err := iam.FindBlastRadius(pd.Gaad, summary, start, summary.MaxHops)
+	if err != nil {
+		return err
+	}
+
+	logBlastRadius(report)
+	return l.Send(outputters.NewNamedOutputData(report, "blast-radius"))
+}
+
+// logBlastRadius prints the reach of the start principal and the closest
+// principals it can act as
+func logBlastRadius(report *iam.BlastRadius) {
+	message.Section("Blast radius of %s: %d principals, %d resources, %d actions across %d accounts",
+		report.Start, report.PrincipalCount, report.ResourceCount, report.ActionCount, len(report.Accounts))
+	if report.Admin {
+		message.Warning("Admin-equivalent: %s via %s", report.AdminReason, strings.Join(report.AdminPath, " -> "))
+	}
+	for _, principal := range report.Principals[:min(len(report.Principals), blastRadiusTopItems)] {
+		message.Info("  %s (%s, %d hops)", principal.Principal, strings.Join(principal.Methods, " -> "), principal.Hops)
+	}
+	if len(report.Principals) > blastRadiusTopItems {
+		message.Info("  ... and %d more", len(report.Principals)-blastRadiusTopItems)
+	}
+	if report.HopsCapped {
+		message.Warning("Stopped at --max-hops %d with principals left to pivot to; the blast radius may be larger", report.MaxHops)
+	}
+}
@@ -0,0 +1,73 @@
+package aws
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/praetorian-inc/janus-framework/pkg/chain"
+	"github.com/praetorian-inc/janus-framework/pkg/chain/cfg"
+	"github.com/praetorian-inc/nebula/internal/message"
+	iam "github.com/praetorian-inc/nebula/pkg/iam/aws"
+	"github.com/praetorian-inc/nebula/pkg/links/options"
+	"github.com/praetorian-inc/nebula/pkg/outputters"
+)
+
+// CrossAccountTrustLink reports the resources whose policies, including role
+// trust policies, grant access to principals in other AWS accounts
+type CrossAccountTrustLink struct {
+	*chain.Base
+}
+
+func NewCrossAccountTrustLink(configs ...cfg.Config) chain.Link {
+	l := &CrossAccountTrustLink{}
+	l.Base = chain.NewBase(l, configs...)
+	return l
+}
+
+func (l *CrossAccountTrustLink) Params() []cfg.Param {
+	return []cfg.Param{
+		options.AwsGaadFile(),
+		options.AwsResourcePoliciesFile(),
+		options.AwsResourcesFile(),
+		options.AwsResourceFormat(),
+	}
+}
+
+func (l *CrossAccountTrustLink) Process(input any) error {
+	pd, err := LoadOfflinePolicyData(l.Arg)
+	if err != nil {
+		return err
+	}
+
+	trusts := iam.FindCrossAccountTrusts(pd)
+	logCrossAccountTrusts(trusts)
+	return l.Send(outputters.NewNamedOutputData(trusts, "cross-account-trust"))
+}
+
+// logCrossAccountTrusts prints a table of the cross-account trusts
+func logCrossAccountTrusts(trusts []iam.CrossAccountTrust) {
+	resources := make(map[string]bool)
+	foreign := make(map[string]bool)
+	mutual := 0
+	for _, trust := range trusts {
+		resources[trust.ResourceArn] = true
+		foreign[trust.ForeignAccount] = true
+		if trust.Mutual {
+			mutual++
+		}
+	}
+	message.Section("Cross-account trust: %d resources trusted by %d foreign accounts, %d mutual", len(resources), len(foreign), mutual)
+	if len(trusts) == 0 {
+		return
+	}
+
+	rows := make([][]string, 0, len(trusts))
+	for _, trust := range trusts {
+		mutual := strconv.FormatBool(trust.Mutual)
+		if !trust.ForeignAccountAnalyzed {
+			mutual = "unknown"
+		}
+		rows = append(rows, []string{trust.ResourceArn, trust.ForeignAccount, strings.Join(trust.Actions, ","), mutual})
+	}
+	message.Table([]string{"RESOURCE", "FOREIGN ACCOUNT", "ACTIONS", "MUTUAL"}, rows)
+}
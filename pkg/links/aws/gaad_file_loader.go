@@ -1,15 +1,14 @@
 package aws
 
 import (
-	"encoding/json"
 	"fmt"
-	"os"
 
 	"github.com/praetorian-inc/janus-framework/pkg/chain"
 	"github.com/praetorian-inc/janus-framework/pkg/chain/cfg"
 	"github.com/praetorian-inc/nebula/pkg/links/aws/base"
 	"github.com/praetorian-inc/nebula/pkg/outputters"
 	"github.com/praetorian-inc/nebula/pkg/types"
+	"github.com/praetorian-inc/nebula/pkg/utils"
 )
 
 type AwsGaadFileLoader struct {
@@ -33,28 +32,19 @@ func (g *AwsGaadFileLoader) Process(input any) error {
 	}
 
 	// Read the GAAD file
-	data, err := os.ReadFile(gaadFile)
+	data, err := utils.ReadInputFile(gaadFile)
 	if err != nil {
 		return fmt.Errorf("failed to read GAAD file '%s': %w", gaadFile, err)
 	}
 
-	// Parse as array first (matching account-auth-details module output format)
-	var gaadArray []types.Gaad
-	if err := json.Unmarshal(data, &gaadArray); err == nil && len(gaadArray) > 0 {
-		// Send the GAAD data as NamedOutputData for consistent handling
-		g.Send(outputters.NewNamedOutputData(gaadArray[0], "gaad-data"))
-		g.Logger.Info(fmt.Sprintf("Successfully loaded GAAD data from %s", gaadFile))
-		return nil
-	}
-
-	// Fallback: try parsing as single GAAD object
-	var gaad types.Gaad
-	if err := json.Unmarshal(data, &gaad); err != nil {
-		return fmt.Errorf("failed to parse GAAD file '%s' as JSON (tried both array and single object): %w", gaadFile, err)
+	// Accepts both the account-auth-details module's array output and a single GAAD object
+	gaad, err := types.DecodeGaad(data)
+	if err != nil {
+		return fmt.Errorf("failed to load GAAD file '%s': %w", gaadFile, err)
 	}
 
-	// Send the GAAD data
-	g.Send(outputters.NewNamedOutputData(gaad, "gaad-data"))
+	// Send the GAAD data as NamedOutputData for consistent handling
+	g.Send(outputters.NewNamedOutputData(*gaad, "gaad-data"))
 	g.Logger.Info(fmt.Sprintf("Successfully loaded GAAD data from %s", gaadFile))
 	return nil
 }
@@ -0,0 +1,440 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	kmstypes "github.com/aws/aws-sdk-go-v2/service/kms/types"
+	iam "github.com/praetorian-inc/nebula/pkg/iam/aws"
+	"github.com/praetorian-inc/nebula/pkg/types"
+)
+
+const kmsKeyType = "AWS::KMS::Key"
+
+// Access levels of a principal allowed to use a KMS key
+const (
+	kmsAccessPublic       = "Public"
+	kmsAccessCrossAccount = "CrossAccount"
+	kmsAccessAccount      = "Account"
+	kmsAccessService      = "Service"
+	kmsAccessUnknown      = "Unknown"
+)
+
+// kmsKeyUsageActions are the operations that encrypt or decrypt data under a
+// key. kms:CreateGrant is included because it lets a principal grant itself
+// the others.
+var kmsKeyUsageActions = []string{
+	"kms:Decrypt",
+	"kms:Encrypt",
+	"kms:ReEncryptFrom",
+	"kms:ReEncryptTo",
+	"kms:GenerateDataKey",
+	"kms:GenerateDataKeyWithoutPlaintext",
+	"kms:GenerateDataKeyPair",
+	"kms:GenerateDataKeyPairWithoutPlaintext",
+	"kms:CreateGrant",
+}
+
+// KMSPrincipalAccess is a principal the key policy or a grant allows to use a
+// KMS key, with the usage actions it is allowed
+type KMSPrincipalAccess struct {
+	Principal   string
+	Account     string `json:",omitempty"`
+	Access      string
+	Source      string
+	GrantId     string `json:",omitempty"`
+	Actions     []string
+	Conditional bool
+}
+
+// external reports whether the principal is outside the key's account
+func (p KMSPrincipalAccess) external() bool {
+	return p.Access == kmsAccessPublic || p.Access == kmsAccessCrossAccount
+}
+
+// getKMSKeyPolicy reads the default key policy, the only policy name KMS supports
+func getKMSKeyPolicy(ctx context.Context, cfg aws.Config, keyID string, allowedRegions []string) (*types.Policy, error) {
+	client := kms.NewFromConfig(cfg)
+	resp, err := client.GetKeyPolicy(ctx, &kms.GetKeyPolicyInput{
+		KeyId:      aws.String(keyID),
+		PolicyName: aws.String("default"),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if resp.Policy == nil {
+		return nil, nil
+	}
+	return strToPolicy(*resp.Policy)
+}
+
+// processKMSKey flags keys that the key policy or a grant lets principals
+// outside the key's account use to encrypt or decrypt data
+func (a *AwsResourcePolicyChecker) processKMSKey(
+	resource *types.EnrichedResourceDescription,
+	awsCfg aws.Config,
+	props map[string]any,
+	keyID string,
+	serviceConfig ServicePolicyConfig,
+) error {
+	ctx := context.TODO()
+
+	policy, err := serviceConfig.GetPolicy(ctx, awsCfg, keyID, a.Regions)
+	if err != nil {
+		slog.Debug("Failed to get key policy", "key", keyID, "error", err)
+		return nil // Continue with other resources
+	}
+
+	var principals []KMSPrincipalAccess
+	var results []*iam.EvaluationResult
+	if policy != nil {
+		results, principals, err = a.analyzeKMSKeyPolicy(resource, policy)
+		if err != nil {
+			slog.Error("Failed to analyze key policy", "key", keyID, "error", err)
+			return err
+		}
+	}
+
+	grants, err := listKMSGrants(ctx, kms.NewFromConfig(awsCfg), keyID)
+	if err != nil {
+		slog.Debug("Failed to list key grants", "key", keyID, "error", err)
+	}
+	principals = append(principals, kmsGrantPrincipals(resource.AccountId, grants)...)
+
+	var external []KMSPrincipalAccess
+	for _, principal := range principals {
+		if principal.external() {
+			external = append(external, principal)
+		}
+	}
+	if len(external) == 0 {
+		return nil
+	}
+
+	a.flagKMSKey(resource, props, policy, results, principals, external, serviceConfig)
+	return nil
+}
+
+// analyzeKMSKeyPolicy resolves the principals a key policy allows to use the
+// key. Access for any principal comes from the standard public access
+// contexts; each principal the policy names is then evaluated as the caller,
+// so Deny statements and conditions apply to it as they would in AWS. Only
+// the usage actions are evaluated, which also covers statements granting
+// them through wildcards such as kms:*.
+func (a *AwsResourcePolicyChecker) analyzeKMSKeyPolicy(resource *types.EnrichedResourceDescription, policy *types.Policy) ([]*iam.EvaluationResult, []KMSPrincipalAccess, error) {
+	policy = normalizeKMSKeyPolicy(policy, types.PartitionForRegion(resource.Region))
+	_, targets := policyEvaluationTargets(resource, policy)
+	keyArn := targets[0]
+
+	evaluate := func(reqCtx *iam.RequestContext) ([]*iam.EvaluationResult, error) {
+		if a.orgPolicies != nil && resource.AccountId != "" {
+			reqCtx.ResourceAccount = resource.AccountId
+		}
+		reqCtx.PopulateDefaultRequestConditionKeys(keyArn)
+
+		results, err := a.evaluateActionsWithContext(reqCtx, policy, keyArn, kmsKeyUsageActions)
+		if err != nil {
+			return nil, err
+		}
+		allowed := []*iam.EvaluationResult{}
+		for _, res := range results {
+			if res.Allowed && !isOrgInternal(res, a.orgID) {
+				allowed = append(allowed, res)
+			}
+		}
+		return allowed, nil
+	}
+
+	var results []*iam.EvaluationResult
+	for _, reqCtx := range GetEvaluationContexts(kmsKeyType) {
+		allowed, err := evaluate(reqCtx)
+		if err != nil {
+			return nil, nil, err
+		}
+		results = append(results, allowed...)
+	}
+
+	var principals []KMSPrincipalAccess
+	if isPublic(results) {
+		principals = append(principals, KMSPrincipalAccess{
+			Principal:   "*",
+			Access:      kmsAccessPublic,
+			Source:      "Policy",
+			Actions:     sortedUsageActions(getAllowedActions(results)),
+			Conditional: hasInconclusiveConditions(results),
+		})
+	}
+
+	for _, principal := range kmsPolicyPrincipals(policy) {
+		allowed, err := evaluate(&iam.RequestContext{PrincipalArn: principal})
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(allowed) == 0 {
+			continue
+		}
+
+		access, account := classifyKMSPrincipal(principal, resource.AccountId)
+		principals = append(principals, KMSPrincipalAccess{
+			Principal:   principal,
+			Account:     account,
+			Access:      access,
+			Source:      "Policy",
+			Actions:     sortedUsageActions(getAllowedActions(allowed)),
+			Conditional: hasInconclusiveConditions(allowed),
+		})
+	}
+
+	return results, principals, nil
+}
+
+// normalizeKMSKeyPolicy returns a copy of a key policy with AWS principals
+// given as bare account IDs written as the account root ARN they stand for
+func normalizeKMSKeyPolicy(policy *types.Policy, partition string) *types.Policy {
+	if policy.Statement == nil {
+		return policy
+	}
+	normalized := *policy
+	statements := make(types.PolicyStatementList, 0, len(*policy.Statement))
+	for _, stmt := range *policy.Statement {
+		for _, p := range []**types.Principal{&stmt.Principal, &stmt.NotPrincipal} {
+			if *p == nil || (*p).AWS == nil {
+				continue
+			}
+			principal := **p
+			ids := make(types.DynaString, len(*principal.AWS))
+			for i, id := range *principal.AWS {
+				if isAccountID(id) {
+					id = fmt.Sprintf("arn:%s:iam::%s:root", partition, id)
+				}
+				ids[i] = id
+			}
+			principal.AWS = &ids
+			*p = &principal
+		}
+		statements = append(statements, stmt)
+	}
+	normalized.Statement = &statements
+	return &normalized
+}
+
+// kmsPolicyPrincipals lists the AWS and service principals named by the
+// Allow statements of a key policy. The "*" principal is left to the public
+// access contexts.
+func kmsPolicyPrincipals(policy *types.Policy) []string {
+	var principals []string
+	if policy == nil || policy.Statement == nil {
+		return principals
+	}
+	for _, stmt := range *policy.Statement {
+		if !strings.EqualFold(stmt.Effect, "Allow") || stmt.Principal == nil {
+			continue
+		}
+		for _, list := range []*types.DynaString{stmt.Principal.AWS, stmt.Principal.Service} {
+			if list == nil {
+				continue
+			}
+			for _, principal := range *list {
+				if principal != "" && principal != "*" && !slices.Contains(principals, principal) {
+					principals = append(principals, principal)
+				}
+			}
+		}
+	}
+	return principals
+}
+
+// classifyKMSPrincipal returns the access level of a principal relative to
+// the key's account and the account of AWS principals
+func classifyKMSPrincipal(principal, keyAccount string) (string, string) {
+	switch {
+	case principal == "*":
+		return kmsAccessPublic, ""
+	case strings.HasSuffix(principal, ".amazonaws.com"):
+		return kmsAccessService, ""
+	}
+	parsed, err := arn.Parse(principal)
+	if err != nil || !isAccountID(parsed.AccountID) {
+		return kmsAccessUnknown, ""
+	}
+	return kmsAccountAccess(parsed.AccountID, keyAccount), parsed.AccountID
+}
+
+// kmsAccountAccess reports whether an account is the key's own or another
+func kmsAccountAccess(account, keyAccount string) string {
+	if account == keyAccount {
+		return kmsAccessAccount
+	}
+	return kmsAccessCrossAccount
+}
+
+// isAccountID reports whether s is a 12-digit AWS account ID
+func isAccountID(s string) bool {
+	if len(s) != 12 {
+		return false
+	}
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// listKMSGrants lists every grant on a key
+func listKMSGrants(ctx context.Context, client *kms.Client, keyID string) ([]kmstypes.GrantListEntry, error) {
+	var grants []kmstypes.GrantListEntry
+	paginator := kms.NewListGrantsPaginator(client, &kms.ListGrantsInput{
+		KeyId: aws.String(keyID),
+		Limit: aws.Int32(100),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return grants, err
+		}
+		grants = append(grants, page.Grants...)
+	}
+	return grants, nil
+}
+
+// kmsGrantPrincipals returns the grantees of the grants that allow a usage
+// action. Grants with encryption context constraints are conditional, since
+// the grantee can only use the key for matching requests.
+func kmsGrantPrincipals(keyAccount string, grants []kmstypes.GrantListEntry) []KMSPrincipalAccess {
+	var principals []KMSPrincipalAccess
+	for _, grant := range grants {
+		grantee := aws.ToString(grant.GranteePrincipal)
+		if grantee == "" {
+			continue
+		}
+		var actions []string
+		for _, op := range grant.Operations {
+			action := "kms:" + string(op)
+			if slices.Contains(kmsKeyUsageActions, action) {
+				actions = append(actions, action)
+			}
+		}
+		if len(actions) == 0 {
+			continue
+		}
+
+		access, account := classifyKMSPrincipal(grantee, keyAccount)
+		principals = append(principals, KMSPrincipalAccess{
+			Principal:   grantee,
+			Account:     account,
+			Access:      access,
+			Source:      "Grant",
+			GrantId:     aws.ToString(grant.GrantId),
+			Actions:     sortedUsageActions(actions),
+			Conditional: grant.Constraints != nil,
+		})
+	}
+	return principals
+}
+
+// kmsUsageResults keeps the evaluation results for key usage actions
+func kmsUsageResults(results []*iam.EvaluationResult) []*iam.EvaluationResult {
+	var usage []*iam.EvaluationResult
+	for _, res := range results {
+		if slices.Contains(kmsKeyUsageActions, string(res.Action)) {
+			usage = append(usage, res)
+		}
+	}
+	return usage
+}
+
+// sortedUsageActions orders actions as kmsKeyUsageActions does
+func sortedUsageActions(actions []string) []string {
+	sort.Slice(actions, func(i, j int) bool {
+		return slices.Index(kmsKeyUsageActions, actions[i]) < slices.Index(kmsKeyUsageActions, actions[j])
+	})
+	return actions
+}
+
+// kmsKeyFinding classifies the external principals allowed to use a key
+func kmsKeyFinding(external []KMSPrincipalAccess) map[string]any {
+	var accounts []string
+	for _, principal := range external {
+		if principal.Access == kmsAccessPublic {
+			return map[string]any{
+				"PublicAccessFinding": "KmsKeyPublicAccess",
+				"Severity":            "High",
+				"FindingDetail":       "Key policy allows any principal to use the key to encrypt or decrypt data",
+			}
+		}
+		if !slices.Contains(accounts, principal.Account) {
+			accounts = append(accounts, principal.Account)
+		}
+	}
+	sort.Strings(accounts)
+	return map[string]any{
+		"PublicAccessFinding": "KmsKeyCrossAccountAccess",
+		"Severity":            "Medium",
+		"ExternalAccounts":    accounts,
+		"FindingDetail": fmt.Sprintf("Key policy or grants allow principals in %s to use the key; confirm the accounts are trusted",
+			strings.Join(accounts, ", ")),
+	}
+}
+
+// flagKMSKey sends an enriched resource for a key usable outside its account
+func (a *AwsResourcePolicyChecker) flagKMSKey(
+	resource *types.EnrichedResourceDescription,
+	props map[string]any,
+	policy *types.Policy,
+	results []*iam.EvaluationResult,
+	principals []KMSPrincipalAccess,
+	external []KMSPrincipalAccess,
+	serviceConfig ServicePolicyConfig,
+) {
+	var sources, actions []string
+	reasons := getUniqueDetails(results)
+	triage := hasInconclusiveConditions(results)
+	for _, principal := range external {
+		if !slices.Contains(sources, principal.Source) {
+			sources = append(sources, principal.Source)
+		}
+		for _, action := range principal.Actions {
+			if !slices.Contains(actions, action) {
+				actions = append(actions, action)
+			}
+		}
+		if principal.Access == kmsAccessCrossAccount {
+			reason := fmt.Sprintf("Key policy allows %s to %s", principal.Principal, strings.Join(principal.Actions, ", "))
+			if principal.Source == "Grant" {
+				reason = fmt.Sprintf("Grant %s allows %s to %s", principal.GrantId, principal.Principal, strings.Join(principal.Actions, ", "))
+			}
+			reasons = append(reasons, reason)
+		}
+		triage = triage || principal.Conditional
+	}
+
+	if policy != nil {
+		props[serviceConfig.PolicyField] = policy
+	}
+	props["PublicAccessSource"] = strings.Join(sources, ",")
+	props["EvaluationReasons"] = reasons
+	props["NeedsManualTriage"] = triage
+	props["Actions"] = sortedUsageActions(actions)
+	props["KMSEffectivePrincipals"] = principals
+	for key, value := range kmsKeyFinding(external) {
+		props[key] = value
+	}
+
+	enriched := types.EnrichedResourceDescription{
+		Identifier: resource.Identifier,
+		TypeName:   resource.TypeName,
+		Region:     resource.Region,
+		Properties: props,
+		AccountId:  resource.AccountId,
+		Arn:        resource.Arn,
+	}
+	a.Send(enriched)
+}
@@ -0,0 +1,137 @@
+package aws
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	kmstypes "github.com/aws/aws-sdk-go-v2/service/kms/types"
+	"github.com/praetorian-inc/nebula/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnalyzeKMSKeyPolicy(t *testing.T) {
+	const account = "123456789012"
+	key := types.NewEnrichedResourceDescription("1234abcd-12ab-34cd-56ef-1234567890ab", kmsKeyType, "us-east-1", account, nil)
+	rootStatement := `{"Sid":"Enable IAM User Permissions","Effect":"Allow","Principal":{"AWS":"arn:aws:iam::` + account + `:root"},"Action":"kms:*","Resource":"*"}`
+
+	tests := []struct {
+		name       string
+		policy     string
+		wantAccess map[string]string
+		wantPublic bool
+	}{
+		{
+			name:       "default key policy stays in the account",
+			policy:     `{"Version":"2012-10-17","Statement":[` + rootStatement + `]}`,
+			wantAccess: map[string]string{"arn:aws:iam::" + account + ":root": kmsAccessAccount},
+		},
+		{
+			name: "decrypt granted to any principal",
+			policy: `{"Version":"2012-10-17","Statement":[` + rootStatement + `,
+				{"Effect":"Allow","Principal":"*","Action":"kms:Decrypt","Resource":"*"}]}`,
+			wantAccess: map[string]string{"*": kmsAccessPublic, "arn:aws:iam::" + account + ":root": kmsAccessAccount},
+			wantPublic: true,
+		},
+		{
+			name: "any principal limited to callers in the account",
+			policy: `{"Version":"2012-10-17","Statement":[` + rootStatement + `,
+				{"Effect":"Allow","Principal":{"AWS":"*"},"Action":["kms:Encrypt","kms:Decrypt","kms:GenerateDataKey*"],"Resource":"*",
+				"Condition":{"StringEquals":{"kms:CallerAccount":"` + account + `"}}}]}`,
+			wantAccess: map[string]string{"arn:aws:iam::" + account + ":root": kmsAccessAccount},
+		},
+		{
+			name: "decrypt granted to another account by ID and a role in it",
+			policy: `{"Version":"2012-10-17","Statement":[` + rootStatement + `,
+				{"Effect":"Allow","Principal":{"AWS":["444455556666","arn:aws:iam::777788889999:role/reader"]},"Action":["kms:Decrypt","kms:DescribeKey"],"Resource":"*"}]}`,
+			wantAccess: map[string]string{
+				"arn:aws:iam::" + account + ":root":     kmsAccessAccount,
+				"arn:aws:iam::444455556666:root":        kmsAccessCrossAccount,
+				"arn:aws:iam::777788889999:role/reader": kmsAccessCrossAccount,
+			},
+		},
+		{
+			name: "cross-account decrypt taken away by a Deny statement",
+			policy: `{"Version":"2012-10-17","Statement":[` + rootStatement + `,
+				{"Effect":"Allow","Principal":{"AWS":"444455556666"},"Action":"kms:Decrypt","Resource":"*"},
+				{"Effect":"Deny","Principal":{"AWS":"444455556666"},"Action":"kms:Decrypt","Resource":"*"}]}`,
+			wantAccess: map[string]string{"arn:aws:iam::" + account + ":root": kmsAccessAccount},
+		},
+		{
+			name: "service principal and key administration only",
+			policy: `{"Version":"2012-10-17","Statement":[` + rootStatement + `,
+				{"Effect":"Allow","Principal":{"Service":"logs.us-east-1.amazonaws.com"},"Action":["kms:Encrypt*","kms:Decrypt*"],"Resource":"*"},
+				{"Effect":"Allow","Principal":{"AWS":"arn:aws:iam::444455556666:role/admin"},"Action":["kms:PutKeyPolicy","kms:DescribeKey"],"Resource":"*"}]}`,
+			wantAccess: map[string]string{
+				"arn:aws:iam::" + account + ":root": kmsAccessAccount,
+				"logs.us-east-1.amazonaws.com":      kmsAccessService,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy, err := strToPolicy(tt.policy)
+			require.NoError(t, err)
+
+			checker := &AwsResourcePolicyChecker{}
+			results, principals, err := checker.analyzeKMSKeyPolicy(&key, policy)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantPublic, isPublic(results))
+
+			access := make(map[string]string)
+			for _, principal := range principals {
+				assert.Equal(t, "Policy", principal.Source)
+				assert.NotEmpty(t, principal.Actions)
+				for _, action := range principal.Actions {
+					assert.Contains(t, kmsKeyUsageActions, action)
+				}
+				access[principal.Principal] = principal.Access
+			}
+			assert.Equal(t, tt.wantAccess, access)
+		})
+	}
+}
+
+func TestKMSGrantPrincipals(t *testing.T) {
+	const account = "123456789012"
+	grants := []kmstypes.GrantListEntry{
+		{
+			GrantId:          aws.String("grant-cross"),
+			GranteePrincipal: aws.String("arn:aws:iam::444455556666:role/backup"),
+			Operations:       []kmstypes.GrantOperation{kmstypes.GrantOperationDescribeKey, kmstypes.GrantOperationDecrypt, kmstypes.GrantOperationEncrypt},
+		},
+		{
+			GrantId:          aws.String("grant-internal"),
+			GranteePrincipal: aws.String("arn:aws:iam::" + account + ":role/app"),
+			Operations:       []kmstypes.GrantOperation{kmstypes.GrantOperationGenerateDataKey},
+			Constraints:      &kmstypes.GrantConstraints{EncryptionContextSubset: map[string]string{"app": "orders"}},
+		},
+		{
+			GrantId:          aws.String("grant-describe"),
+			GranteePrincipal: aws.String("arn:aws:iam::444455556666:role/auditor"),
+			Operations:       []kmstypes.GrantOperation{kmstypes.GrantOperationDescribeKey},
+		},
+	}
+
+	principals := kmsGrantPrincipals(account, grants)
+	require.Len(t, principals, 2, "grants without usage operations are left out")
+
+	assert.Equal(t, KMSPrincipalAccess{
+		Principal: "arn:aws:iam::444455556666:role/backup",
+		Account:   "444455556666",
+		Access:    kmsAccessCrossAccount,
+		Source:    "Grant",
+		GrantId:   "grant-cross",
+		Actions:   []string{"kms:Decrypt", "kms:Encrypt"},
+	}, principals[0])
+	assert.Equal(t, kmsAccessAccount, principals[1].Access)
+	assert.True(t, principals[1].Conditional, "encryption context constraints limit the grant")
+
+	finding := kmsKeyFinding([]KMSPrincipalAccess{principals[0]})
+	assert.Equal(t, "KmsKeyCrossAccountAccess", finding["PublicAccessFinding"])
+	assert.Equal(t, []string{"444455556666"}, finding["ExternalAccounts"])
+	finding = kmsKeyFinding([]KMSPrincipalAccess{principals[0], {Principal: "*", Access: kmsAccessPublic}})
+	assert.Equal(t, "KmsKeyPublicAccess", finding["PublicAccessFinding"])
+	assert.Equal(t, "High", finding["Severity"])
+}
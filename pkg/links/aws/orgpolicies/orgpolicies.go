@@ -3,6 +3,7 @@ package orgpolicies
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	"sync"
@@ -157,6 +158,21 @@ func (o *OrgPolicies) GetMergedParentScpsForTarget(accountID string) map[string]
 	return mergedPolicies
 }
 
+// GetOrgPathForTarget returns the IDs of the root and OUs above an account,
+// root first, followed by the account itself
+func (o *OrgPolicies) GetOrgPathForTarget(accountID string) []string {
+	orgPolicyTarget := o.GetPolicyForTarget(accountID)
+	if orgPolicyTarget == nil {
+		return nil
+	}
+
+	path := make([]string, 0, len(orgPolicyTarget.SCPs.ParentPolicies)+1)
+	for _, parent := range orgPolicyTarget.SCPs.ParentPolicies {
+		path = append(path, parent.ID)
+	}
+	return append(path, orgPolicyTarget.ID)
+}
+
 func (o *OrgPolicies) GetMergedParentRcpsForTarget(accountID string) map[string]*types.PolicyStatementList {
 	orgPolicyTarget := o.GetPolicyForTarget(accountID)
 	if orgPolicyTarget == nil {
@@ -273,6 +289,35 @@ func (o *OrgPolicies) TargetHasParentAllowed(targetID string) bool {
 	return false
 }
 
+// ErrMalformedOrgPolicies is returned for org policies input that decodes but
+// holds no policies or targets, which would otherwise be analyzed as an
+// organization without SCPs or RCPs
+var ErrMalformedOrgPolicies = errors.New("org policies appear empty or malformed — did you pass the right file?")
+
+// Validate checks that org policies loaded from a file have SCPs or targets
+// and that every SCP and RCP has a policy document
+func (o *OrgPolicies) Validate() error {
+	if len(o.SCPs) == 0 && len(o.Targets) == 0 {
+		return fmt.Errorf("%w: it has no scps or targets", ErrMalformedOrgPolicies)
+	}
+	for i, policy := range o.SCPs {
+		if policy.PolicyContent.Statement == nil || len(*policy.PolicyContent.Statement) == 0 {
+			return fmt.Errorf("%w: scps[%d] has no policyContent statements", ErrMalformedOrgPolicies, i)
+		}
+	}
+	for i, policy := range o.RCPs {
+		if policy.PolicyContent.Statement == nil || len(*policy.PolicyContent.Statement) == 0 {
+			return fmt.Errorf("%w: rcps[%d] has no policyContent statements", ErrMalformedOrgPolicies, i)
+		}
+	}
+	for i, target := range o.Targets {
+		if target.ID == "" || target.Type == "" {
+			return fmt.Errorf("%w: targets[%d] has no id or type", ErrMalformedOrgPolicies, i)
+		}
+	}
+	return nil
+}
+
 type OrgPolicyTarget struct {
 	Name    string                  `json:"name"`
 	ID      string                  `json:"id"`
@@ -375,13 +420,15 @@ func (ad *AWSOrganizationPolicies) BuildOrgPoliciesFromHierarchy(ou *OrgUnit, sc
 			},
 		})
 
-		parentSCPsForChildren := append(parentSCPs, ParentPolicy{
+		// Copy before appending so sibling units never share, and overwrite,
+		// the same parent chain
+		parentSCPsForChildren := append(append(make([]ParentPolicy, 0, len(parentSCPs)+1), parentSCPs...), ParentPolicy{
 			Name:     unit.Name,
 			ID:       unit.ID,
 			Policies: targetToSCPs[unit.ID],
 		})
 
-		parentRCPsForChildren := append(parentRCPs, ParentPolicy{
+		parentRCPsForChildren := append(append(make([]ParentPolicy, 0, len(parentRCPs)+1), parentRCPs...), ParentPolicy{
 			Name:     unit.Name,
 			ID:       unit.ID,
 			Policies: targetToRCPs[unit.ID],
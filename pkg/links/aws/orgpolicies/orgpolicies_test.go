@@ -2,6 +2,8 @@ package orgpolicies
 
 import (
 	"encoding/json"
+	"errors"
+	"strings"
 	"testing"
 )
 
@@ -288,3 +290,63 @@ func TestGetAllScpPoliciesForTarget(t *testing.T) {
 		t.Error("Expected nil statements for non-existent target")
 	}
 }
+
+func TestOrgPoliciesValidate(t *testing.T) {
+	if err := NewDefaultOrgPolicies().Validate(); err != nil {
+		t.Errorf("Expected default org policies to be valid, got %v", err)
+	}
+
+	testCases := map[string]string{
+		"empty object":        `{}`,
+		"wrong file":          `{"UserDetailList": [], "RoleDetailList": []}`,
+		"scp without content": `{"scps": [{"policySummary": {"Id": "p-123"}}], "targets": []}`,
+		"target without type": `{"scps": [], "targets": [{"id": "123"}]}`,
+	}
+	for name, input := range testCases {
+		var orgPolicies OrgPolicies
+		if err := json.Unmarshal([]byte(input), &orgPolicies); err != nil {
+			t.Fatalf("%s: failed to unmarshal: %v", name, err)
+		}
+		if err := orgPolicies.Validate(); !errors.Is(err, ErrMalformedOrgPolicies) {
+			t.Errorf("%s: expected ErrMalformedOrgPolicies, got %v", name, err)
+		}
+	}
+}
+
+func TestBuildOrgPoliciesFromHierarchyPaths(t *testing.T) {
+	root := &OrgUnit{
+		ID:   "r-root",
+		Name: "Root",
+		Children: []OrgUnit{{
+			ID:   "ou-prod",
+			Name: "Prod",
+			Children: []OrgUnit{{
+				ID:   "ou-workloads",
+				Name: "Workloads",
+				Children: []OrgUnit{
+					{ID: "ou-web", Name: "Web", Accounts: []Account{{ID: "111111111111"}}},
+					{ID: "ou-data", Name: "Data", Accounts: []Account{{ID: "222222222222"}}},
+				},
+			}},
+		}},
+	}
+
+	orgPolicies := (&AWSOrganizationPolicies{}).BuildOrgPoliciesFromHierarchy(root, nil, nil)
+
+	// Sibling OUs deep enough to share a parent slice's spare capacity must
+	// still each keep their own path
+	expected := map[string][]string{
+		"111111111111": {"r-root", "ou-prod", "ou-workloads", "ou-web", "111111111111"},
+		"222222222222": {"r-root", "ou-prod", "ou-workloads", "ou-data", "222222222222"},
+	}
+	for accountID, want := range expected {
+		got := orgPolicies.GetOrgPathForTarget(accountID)
+		if strings.Join(got, "/") != strings.Join(want, "/") {
+			t.Errorf("Expected path %v for %s, got %v", want, accountID, got)
+		}
+	}
+
+	if path := orgPolicies.GetOrgPathForTarget("333333333333"); path != nil {
+		t.Errorf("Expected nil path for non-existent target, got %v", path)
+	}
+}
@@ -142,6 +142,20 @@ func (a *AwsPublicResources) ResourceMap() map[string]func() chain.Chain {
 		)
 	}
 
+	resourceMap["AWS::OpenSearchService::Domain"] = func() chain.Chain {
+		return chain.NewChain(
+			cloudcontrol.NewCloudControlGet(),
+			NewAwsResourcePolicyChecker(),
+		)
+	}
+
+	resourceMap["AWS::ApiGateway::RestApi"] = func() chain.Chain {
+		return chain.NewChain(
+			cloudcontrol.NewCloudControlGet(),
+			NewAwsResourcePolicyChecker(),
+		)
+	}
+
 	// resourceMap["AWS::Elasticsearch::Domain"] = func() chain.Chain {
 	// 	return chain.NewChain(
 	// 		NewAwsResourcePolicyChecker(),
@@ -155,6 +169,13 @@ func (a *AwsPublicResources) ResourceMap() map[string]func() chain.Chain {
 		)
 	}
 
+	resourceMap["AWS::KMS::Key"] = func() chain.Chain {
+		return chain.NewChain(
+			cloudcontrol.NewCloudControlGet(),
+			NewAwsResourcePolicyChecker(),
+		)
+	}
+
 	resourceMap["AWS::Cognito::UserPool"] = func() chain.Chain {
 		return chain.NewChain(
 			cloudcontrol.NewCloudControlGet(),
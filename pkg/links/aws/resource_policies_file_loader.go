@@ -3,13 +3,13 @@ package aws
 import (
 	"encoding/json"
 	"fmt"
-	"os"
 
 	"github.com/praetorian-inc/janus-framework/pkg/chain"
 	"github.com/praetorian-inc/janus-framework/pkg/chain/cfg"
 	"github.com/praetorian-inc/nebula/pkg/links/aws/base"
 	"github.com/praetorian-inc/nebula/pkg/outputters"
 	"github.com/praetorian-inc/nebula/pkg/types"
+	"github.com/praetorian-inc/nebula/pkg/utils"
 )
 
 type AwsResourcePoliciesFileLoader struct {
@@ -33,7 +33,7 @@ func (r *AwsResourcePoliciesFileLoader) Process(input any) error {
 	}
 
 	// Read the resource policies file
-	data, err := os.ReadFile(resourcePoliciesFile)
+	data, err := utils.ReadInputFile(resourcePoliciesFile)
 	if err != nil {
 		return fmt.Errorf("failed to read resource policies file '%s': %w", resourcePoliciesFile, err)
 	}
@@ -41,6 +41,9 @@ func (r *AwsResourcePoliciesFileLoader) Process(input any) error {
 	// Parse the file as array first (in case it was output from resource-policies module in array format)
 	var resourcePoliciesArray []map[string]*types.Policy
 	if err := json.Unmarshal(data, &resourcePoliciesArray); err == nil && len(resourcePoliciesArray) > 0 {
+		if err := types.ValidateResourcePolicies(resourcePoliciesArray[0]); err != nil {
+			return fmt.Errorf("failed to load resource policies file '%s': %w", resourcePoliciesFile, err)
+		}
 		// Take the first element if it's in array format
 		r.Send(outputters.NewNamedOutputData(resourcePoliciesArray[0], "resource-policies"))
 		r.Logger.Info(fmt.Sprintf("Successfully loaded resource policies from %s (%d policies)", resourcePoliciesFile, len(resourcePoliciesArray[0])))
@@ -53,7 +56,9 @@ func (r *AwsResourcePoliciesFileLoader) Process(input any) error {
 		return fmt.Errorf("failed to parse resource policies file '%s' as JSON (tried both array and map format): %w", resourcePoliciesFile, err)
 	}
 
-	// Validate that it's not empty
+	if err := types.ValidateResourcePolicies(resourcePolicies); err != nil {
+		return fmt.Errorf("failed to load resource policies file '%s': %w", resourcePoliciesFile, err)
+	}
 	if len(resourcePolicies) == 0 {
 		r.Logger.Warn("Resource policies file contains no policies")
 	}
@@ -40,6 +40,8 @@ func (a *AwsResourcePolicyCollector) SupportedResourceTypes() []model.CloudResou
 		// Using string conversion as fallback
 		model.CloudResourceType("AWS::EFS::FileSystem"),
 		model.CloudResourceType("AWS::ElasticSearch::Domain"),
+		model.CloudResourceType("AWS::OpenSearchService::Domain"),
+		model.CloudResourceType("AWS::ApiGateway::RestApi"),
 	}
 }
 
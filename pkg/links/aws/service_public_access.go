@@ -0,0 +1,258 @@
+package aws
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+	awscloudcontrol "github.com/aws/aws-sdk-go-v2/service/cloudcontrol"
+	iam "github.com/praetorian-inc/nebula/pkg/iam/aws"
+	"github.com/praetorian-inc/nebula/pkg/types"
+)
+
+const (
+	apiGatewayRestAPIType = "AWS::ApiGateway::RestApi"
+	openSearchDomainType  = "AWS::OpenSearchService::Domain"
+	elasticsearchType     = "AWS::ElasticSearch::Domain"
+
+	// executeAPIShorthand prefixes API Gateway policy resources written
+	// relative to the API they are attached to, e.g. execute-api:/prod/GET/*
+	executeAPIShorthand = "execute-api:/"
+)
+
+// openSourceIPRanges are aws:SourceIp values that do not restrict the caller
+var openSourceIPRanges = []string{"0.0.0.0/0", "::/0"}
+
+// getRestAPIPolicy reads the resource policy of an API Gateway REST API from
+// Cloud Control, which returns it as the Policy property
+func getRestAPIPolicy(ctx context.Context, cfg aws.Config, restAPIID string, allowedRegions []string) (*types.Policy, error) {
+	client := awscloudcontrol.NewFromConfig(cfg)
+	resp, err := client.GetResource(ctx, &awscloudcontrol.GetResourceInput{
+		TypeName:   aws.String(apiGatewayRestAPIType),
+		Identifier: aws.String(restAPIID),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if resp.ResourceDescription == nil || resp.ResourceDescription.Properties == nil {
+		return nil, nil
+	}
+
+	var props map[string]any
+	if err := json.Unmarshal([]byte(*resp.ResourceDescription.Properties), &props); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal REST API properties: %w", err)
+	}
+	return restAPIPolicy(props["Policy"])
+}
+
+// restAPIPolicy parses the Policy property of a REST API, which is a JSON
+// object, or a string that the API Gateway API returns with escaped quotes
+func restAPIPolicy(raw any) (*types.Policy, error) {
+	var document string
+	switch p := raw.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		document = strings.ReplaceAll(p, `\"`, `"`)
+	default:
+		encoded, err := json.Marshal(p)
+		if err != nil {
+			return nil, err
+		}
+		document = string(encoded)
+	}
+	if strings.TrimSpace(document) == "" {
+		return nil, nil
+	}
+	return strToPolicy(document)
+}
+
+// policyEvaluationTargets returns the policy to evaluate and the resource
+// ARNs to evaluate it against. API Gateway and OpenSearch policies name
+// execute-api and es ARNs rather than the ARN the resource is listed under,
+// so evaluating them against that ARN never matches a statement. KMS keys
+// are evaluated against their key ARN.
+func policyEvaluationTargets(resource *types.EnrichedResourceDescription, policy *types.Policy) (*types.Policy, []string) {
+	switch resource.TypeName {
+	case openSearchDomainType, elasticsearchType:
+		domain := arn.ARN{
+			Partition: types.PartitionForRegion(resource.Region),
+			Service:   "es",
+			Region:    resource.Region,
+			AccountID: resource.AccountId,
+			Resource:  "domain/" + resource.Identifier + "/*",
+		}
+		return policy, []string{domain.String()}
+
+	case apiGatewayRestAPIType:
+		api := arn.ARN{
+			Partition: types.PartitionForRegion(resource.Region),
+			Service:   "execute-api",
+			Region:    resource.Region,
+			AccountID: resource.AccountId,
+			Resource:  resource.Identifier + "/",
+		}
+		return expandExecuteAPIResources(policy, api.String())
+
+	case kmsKeyType:
+		// Keys are listed by key ID, which is not the key/ resource of the ARN
+		if strings.HasPrefix(resource.Identifier, "arn:") {
+			break
+		}
+		key := arn.ARN{
+			Partition: types.PartitionForRegion(resource.Region),
+			Service:   "kms",
+			Region:    resource.Region,
+			AccountID: resource.AccountId,
+			Resource:  "key/" + resource.Identifier,
+		}
+		return policy, []string{key.String()}
+	}
+	return policy, []string{resource.Arn.String()}
+}
+
+// expandExecuteAPIResources rewrites execute-api:/ shorthand in a REST API
+// policy to full ARNs under apiPrefix and returns the rewritten policy with
+// the API resources its Allow statements name. A statement for one method
+// only matches requests for that method, so each is evaluated on its own.
+func expandExecuteAPIResources(policy *types.Policy, apiPrefix string) (*types.Policy, []string) {
+	expand := func(resources *types.DynaString) *types.DynaString {
+		if resources == nil {
+			return nil
+		}
+		expanded := make(types.DynaString, 0, len(*resources))
+		for _, r := range *resources {
+			if strings.HasPrefix(r, executeAPIShorthand) {
+				r = apiPrefix + strings.TrimPrefix(r, executeAPIShorthand)
+			}
+			expanded = append(expanded, r)
+		}
+		return &expanded
+	}
+
+	rewritten := *policy
+	var targets []string
+	if policy.Statement != nil {
+		statements := make(types.PolicyStatementList, 0, len(*policy.Statement))
+		for _, stmt := range *policy.Statement {
+			stmt.Resource = expand(stmt.Resource)
+			stmt.NotResource = expand(stmt.NotResource)
+			if strings.EqualFold(stmt.Effect, "Allow") && stmt.Resource != nil {
+				for _, r := range *stmt.Resource {
+					if strings.HasPrefix(r, apiPrefix) && !slices.Contains(targets, r) {
+						targets = append(targets, r)
+					}
+				}
+			}
+			statements = append(statements, stmt)
+		}
+		rewritten.Statement = &statements
+	}
+
+	if len(targets) == 0 {
+		targets = []string{apiPrefix + "*"}
+	}
+	return &rewritten, targets
+}
+
+// servicePublicAccessFinding classifies public access to API Gateway and
+// OpenSearch resources into a service-specific finding. It returns nil for
+// other resource types and for results that grant nothing the finding covers.
+//
+// An OpenSearch access policy open to everyone but limited by aws:SourceIp is
+// a common deliberate setup for dashboards, so it is still reported, at
+// Medium rather than High, with the allowed ranges for triage.
+func servicePublicAccessFinding(resourceType string, results []*iam.EvaluationResult) map[string]any {
+	switch resourceType {
+	case apiGatewayRestAPIType:
+		for _, res := range results {
+			if strings.EqualFold(string(res.Action), "execute-api:Invoke") {
+				return map[string]any{
+					"PublicAccessFinding": "ApiGatewayPublicInvoke",
+					"Severity":            "High",
+					"FindingDetail":       "REST API resource policy allows execute-api:Invoke from any principal",
+				}
+			}
+		}
+		return nil
+
+	case openSearchDomainType, elasticsearchType:
+		if len(results) == 0 {
+			return nil
+		}
+		ranges, restricted := sourceIPRestriction(results)
+		if restricted {
+			return map[string]any{
+				"PublicAccessFinding": "OpenSearchOpenAccessPolicy",
+				"Severity":            "Medium",
+				"SourceIpRestricted":  true,
+				"AllowedSourceIps":    ranges,
+				"FindingDetail": fmt.Sprintf("Domain access policy allows any principal from %s; confirm the ranges are intended and owned by the organization",
+					strings.Join(ranges, ", ")),
+			}
+		}
+		return map[string]any{
+			"PublicAccessFinding": "OpenSearchOpenAccessPolicy",
+			"Severity":            "High",
+			"SourceIpRestricted":  false,
+			"FindingDetail":       "Domain access policy allows any principal from any network",
+		}
+	}
+	return nil
+}
+
+// sourceIPRestriction reports whether every resource policy statement that
+// allowed a result limits callers with an IpAddress condition on
+// aws:SourceIp, and returns the allowed ranges. Ranges covering every address
+// do not count as a restriction.
+func sourceIPRestriction(results []*iam.EvaluationResult) ([]string, bool) {
+	seen := make(map[string]bool)
+	allowing := 0
+	for _, res := range results {
+		if res.PolicyResult == nil {
+			continue
+		}
+		for _, stmt := range res.PolicyResult.Evaluations[iam.EvalTypeResource] {
+			if !stmt.IsAllowed() {
+				continue
+			}
+			allowing++
+			ranges := statementSourceIPs(stmt.ConditionEvaluation)
+			if len(ranges) == 0 {
+				return nil, false
+			}
+			for _, r := range ranges {
+				if slices.Contains(openSourceIPRanges, r) {
+					return nil, false
+				}
+				seen[r] = true
+			}
+		}
+	}
+
+	ranges := make([]string, 0, len(seen))
+	for r := range seen {
+		ranges = append(ranges, r)
+	}
+	sort.Strings(ranges)
+	return ranges, allowing > 0
+}
+
+// statementSourceIPs returns the ranges of an IpAddress condition on
+// aws:SourceIp. NotIpAddress and IfExists variants let other callers through.
+func statementSourceIPs(eval *iam.ConditionEval) []string {
+	if eval == nil {
+		return nil
+	}
+	for _, keyResult := range eval.KeyResults {
+		if strings.EqualFold(keyResult.Key, "aws:SourceIp") && strings.EqualFold(keyResult.Operator, "IpAddress") {
+			return keyResult.Values
+		}
+	}
+	return nil
+}
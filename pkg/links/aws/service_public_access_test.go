@@ -0,0 +1,109 @@
+package aws
+
+import (
+	"testing"
+
+	"github.com/praetorian-inc/nebula/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServicePublicAccessFindings(t *testing.T) {
+	const account = "123456789012"
+	restAPI := types.NewEnrichedResourceDescription("abc123", apiGatewayRestAPIType, "us-east-1", account, nil)
+	domain := types.NewEnrichedResourceDescription("logs", openSearchDomainType, "us-east-1", account, nil)
+	domainResource := `"Resource":"arn:aws:es:us-east-1:` + account + `:domain/logs/*"`
+
+	tests := []struct {
+		name        string
+		resource    types.EnrichedResourceDescription
+		policy      string
+		wantFinding string
+		wantSev     string
+		wantRanges  []string
+	}{
+		{
+			name:        "REST API open to everyone through execute-api shorthand",
+			resource:    restAPI,
+			policy:      `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Principal":"*","Action":"execute-api:Invoke","Resource":"execute-api:/*"}]}`,
+			wantFinding: "ApiGatewayPublicInvoke",
+			wantSev:     "High",
+		},
+		{
+			name:        "REST API open for a single method",
+			resource:    restAPI,
+			policy:      `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Principal":"*","Action":"execute-api:Invoke","Resource":"arn:aws:execute-api:us-east-1:` + account + `:abc123/prod/GET/pets"}]}`,
+			wantFinding: "ApiGatewayPublicInvoke",
+			wantSev:     "High",
+		},
+		{
+			name:     "REST API limited to one account",
+			resource: restAPI,
+			policy:   `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Principal":{"AWS":"arn:aws:iam::` + account + `:root"},"Action":"execute-api:Invoke","Resource":"execute-api:/*"}]}`,
+		},
+		{
+			name:        "OpenSearch domain open to everyone",
+			resource:    domain,
+			policy:      `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Principal":{"AWS":"*"},"Action":"es:ESHttpGet",` + domainResource + `}]}`,
+			wantFinding: "OpenSearchOpenAccessPolicy",
+			wantSev:     "High",
+		},
+		{
+			name:        "OpenSearch domain open to an IP allowlist",
+			resource:    domain,
+			policy:      `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Principal":{"AWS":"*"},"Action":"es:ESHttpGet",` + domainResource + `,"Condition":{"IpAddress":{"aws:SourceIp":["203.0.113.0/24","198.51.100.7/32"]}}}]}`,
+			wantFinding: "OpenSearchOpenAccessPolicy",
+			wantSev:     "Medium",
+			wantRanges:  []string{"198.51.100.7/32", "203.0.113.0/24"},
+		},
+		{
+			name:        "OpenSearch domain IP condition covering every address",
+			resource:    domain,
+			policy:      `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Principal":{"AWS":"*"},"Action":"es:ESHttpGet",` + domainResource + `,"Condition":{"IpAddress":{"aws:SourceIp":"0.0.0.0/0"}}}]}`,
+			wantFinding: "OpenSearchOpenAccessPolicy",
+			wantSev:     "High",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy, err := strToPolicy(tt.policy)
+			require.NoError(t, err)
+
+			checker := &AwsResourcePolicyChecker{}
+			results, err := checker.analyzeResourcePolicy(&tt.resource, policy)
+			require.NoError(t, err)
+
+			finding := servicePublicAccessFinding(tt.resource.TypeName, results)
+			if tt.wantFinding == "" {
+				assert.False(t, isPublic(results))
+				assert.Nil(t, finding)
+				return
+			}
+			require.True(t, isPublic(results))
+			require.NotNil(t, finding)
+			assert.Equal(t, tt.wantFinding, finding["PublicAccessFinding"])
+			assert.Equal(t, tt.wantSev, finding["Severity"])
+			if tt.wantRanges != nil {
+				assert.Equal(t, true, finding["SourceIpRestricted"])
+				assert.Equal(t, tt.wantRanges, finding["AllowedSourceIps"])
+			}
+		})
+	}
+}
+
+func TestRestAPIPolicy(t *testing.T) {
+	escaped := `{\"Version\":\"2012-10-17\",\"Statement\":[{\"Effect\":\"Allow\",\"Principal\":\"*\",\"Action\":\"execute-api:Invoke\",\"Resource\":\"execute-api:/*\"}]}`
+	policy, err := restAPIPolicy(escaped)
+	require.NoError(t, err)
+	require.NotNil(t, policy.Statement)
+	assert.Len(t, *policy.Statement, 1)
+
+	policy, err = restAPIPolicy(map[string]any{"Version": "2012-10-17", "Statement": []any{map[string]any{"Effect": "Allow", "Principal": "*", "Action": "execute-api:Invoke", "Resource": "*"}}})
+	require.NoError(t, err)
+	assert.Len(t, *policy.Statement, 1)
+
+	policy, err = restAPIPolicy(nil)
+	require.NoError(t, err)
+	assert.Nil(t, policy, "a REST API without a policy has none to analyze")
+}
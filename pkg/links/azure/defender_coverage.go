@@ -0,0 +1,189 @@
+package azure
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/praetorian-inc/janus-framework/pkg/chain"
+	"github.com/praetorian-inc/janus-framework/pkg/chain/cfg"
+	"github.com/praetorian-inc/nebula/internal/helpers"
+	"github.com/praetorian-inc/nebula/internal/message"
+	"github.com/praetorian-inc/nebula/pkg/outputters"
+)
+
+// defenderPricingAPIVersion is the Microsoft.Security/pricings API version
+const defenderPricingAPIVersion = "2024-01-01"
+
+// keyDefenderPlans are the Defender for Cloud plans that cover the most common
+// workloads, keyed by pricing name with the name shown in the portal
+var keyDefenderPlans = map[string]string{
+	"VirtualMachines": "Servers",
+	"StorageAccounts": "Storage",
+	"SqlServers":      "Azure SQL Databases",
+	"Containers":      "Containers",
+}
+
+// DefenderPlan is the pricing tier of one Defender for Cloud plan in a subscription
+type DefenderPlan struct {
+	Name        string `json:"name"`
+	DisplayName string `json:"displayName,omitempty"`
+	PricingTier string `json:"pricingTier"`
+	SubPlan     string `json:"subPlan,omitempty"`
+	Enabled     bool   `json:"enabled"`
+}
+
+// DefenderCoverageFinding flags a key Defender plan that is not enabled
+type DefenderCoverageFinding struct {
+	SubscriptionID string `json:"subscriptionId"`
+	Plan           string `json:"plan"`
+	DisplayName    string `json:"displayName"`
+	PricingTier    string `json:"pricingTier"`
+	Severity       string `json:"severity"`
+	Description    string `json:"description"`
+}
+
+// DefenderCoverage is the Defender for Cloud coverage of a subscription
+type DefenderCoverage struct {
+	SubscriptionID string                    `json:"subscriptionId"`
+	Plans          []DefenderPlan            `json:"plans"`
+	DisabledPlans  []string                  `json:"disabledPlans"`
+	Findings       []DefenderCoverageFinding `json:"findings"`
+}
+
+// AzureDefenderCoverageLink collects Defender for Cloud pricing tiers for a
+// subscription and flags key plans left on the Free tier
+type AzureDefenderCoverageLink struct {
+	*chain.Base
+}
+
+func NewAzureDefenderCoverageLink(configs ...cfg.Config) chain.Link {
+	l := &AzureDefenderCoverageLink{}
+	l.Base = chain.NewBase(l, configs...)
+	return l
+}
+
+func (l *AzureDefenderCoverageLink) Process(subscription string) error {
+	l.Logger.Info("Collecting Defender for Cloud pricing", "subscription", subscription)
+
+	cred, err := helpers.NewAzureCredential()
+	if err != nil {
+		l.Logger.Error("Failed to get Azure credentials", "error", err)
+		return err
+	}
+
+	url := fmt.Sprintf("https://management.azure.com/subscriptions/%s/providers/Microsoft.Security/pricings?api-version=%s",
+		subscription, defenderPricingAPIVersion)
+	resp, err := helpers.MakeAzureRestRequest(l.Context(), http.MethodGet, url, cred)
+	if err != nil {
+		l.Logger.Error("Failed to get Defender pricing", "subscription", subscription, "error", err)
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read Defender pricing response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		l.Logger.Error("Defender pricing request failed", "subscription", subscription, "status", resp.StatusCode, "body", string(body))
+		return fmt.Errorf("Defender pricing request for subscription %s failed with status %d", subscription, resp.StatusCode)
+	}
+
+	plans, err := parseDefenderPricings(body)
+	if err != nil {
+		return fmt.Errorf("failed to parse Defender pricing for subscription %s: %w", subscription, err)
+	}
+
+	coverage := newDefenderCoverage(subscription, plans)
+	if len(coverage.Findings) > 0 {
+		message.Warning("Subscription %s has Defender for Cloud plans disabled: %s", subscription, strings.Join(coverage.DisabledPlans, ", "))
+	} else {
+		message.Success("Subscription %s has all key Defender for Cloud plans enabled", subscription)
+	}
+
+	outputDir, _ := cfg.As[string](l.Arg("output"))
+	filename := filepath.Join(outputDir, fmt.Sprintf("defender-coverage-%s.json", subscription))
+	return l.Send(outputters.NewNamedOutputData(coverage, filename))
+}
+
+// parseDefenderPricings reads the plans from a Microsoft.Security/pricings list response
+func parseDefenderPricings(body []byte) ([]DefenderPlan, error) {
+	var response struct {
+		Value []struct {
+			Name       string `json:"name"`
+			Properties struct {
+				PricingTier string `json:"pricingTier"`
+				SubPlan     string `json:"subPlan"`
+			} `json:"properties"`
+		} `json:"value"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, err
+	}
+
+	plans := make([]DefenderPlan, 0, len(response.Value))
+	for _, pricing := range response.Value {
+		plans = append(plans, DefenderPlan{
+			Name:        pricing.Name,
+			DisplayName: keyDefenderPlans[pricing.Name],
+			PricingTier: pricing.Properties.PricingTier,
+			SubPlan:     pricing.Properties.SubPlan,
+			Enabled:     strings.EqualFold(pricing.Properties.PricingTier, "Standard"),
+		})
+	}
+	sort.Slice(plans, func(i, j int) bool { return plans[i].Name < plans[j].Name })
+	return plans, nil
+}
+
+// newDefenderCoverage builds the coverage report for a subscription. Key plans
+// missing from the pricing list are reported as Free, the tier Azure applies
+// when a plan has never been configured.
+func newDefenderCoverage(subscriptionID string, plans []DefenderPlan) *DefenderCoverage {
+	coverage := &DefenderCoverage{
+		SubscriptionID: subscriptionID,
+		Plans:          plans,
+		DisabledPlans:  []string{},
+		Findings:       []DefenderCoverageFinding{},
+	}
+
+	tiers := make(map[string]string, len(plans))
+	for _, plan := range plans {
+		tiers[plan.Name] = plan.PricingTier
+		if !plan.Enabled {
+			coverage.DisabledPlans = append(coverage.DisabledPlans, plan.Name)
+		}
+	}
+
+	keyPlans := make([]string, 0, len(keyDefenderPlans))
+	for name := range keyDefenderPlans {
+		keyPlans = append(keyPlans, name)
+	}
+	sort.Strings(keyPlans)
+
+	for _, name := range keyPlans {
+		tier, found := tiers[name]
+		if !found {
+			tier = "Free"
+			coverage.DisabledPlans = append(coverage.DisabledPlans, name)
+		}
+		if strings.EqualFold(tier, "Standard") {
+			continue
+		}
+		coverage.Findings = append(coverage.Findings, DefenderCoverageFinding{
+			SubscriptionID: subscriptionID,
+			Plan:           name,
+			DisplayName:    keyDefenderPlans[name],
+			PricingTier:    tier,
+			Severity:       "medium",
+			Description: fmt.Sprintf("Microsoft Defender for %s is on the %s tier in subscription %s, so these workloads are not monitored for threats",
+				keyDefenderPlans[name], tier, subscriptionID),
+		})
+	}
+	sort.Strings(coverage.DisabledPlans)
+	return coverage
+}
@@ -0,0 +1,34 @@
+package azure
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefenderCoverage(t *testing.T) {
+	body := []byte(`{"value":[
+		{"name":"VirtualMachines","properties":{"pricingTier":"Standard","subPlan":"P2"}},
+		{"name":"StorageAccounts","properties":{"pricingTier":"Free"}},
+		{"name":"SqlServers","properties":{"pricingTier":"Standard"}},
+		{"name":"KeyVaults","properties":{"pricingTier":"Free"}}
+	]}`)
+
+	plans, err := parseDefenderPricings(body)
+	require.NoError(t, err)
+	require.Len(t, plans, 4)
+	assert.Equal(t, "KeyVaults", plans[0].Name, "plans should be sorted by name")
+	assert.Equal(t, "Servers", plans[3].DisplayName)
+	assert.True(t, plans[3].Enabled)
+
+	coverage := newDefenderCoverage("sub-1", plans)
+	assert.Equal(t, []string{"Containers", "KeyVaults", "StorageAccounts"}, coverage.DisabledPlans)
+
+	require.Len(t, coverage.Findings, 2, "only key plans produce findings")
+	assert.Equal(t, "Containers", coverage.Findings[0].Plan, "a key plan absent from the pricing list is treated as Free")
+	assert.Equal(t, "Free", coverage.Findings[0].PricingTier)
+	assert.Equal(t, "StorageAccounts", coverage.Findings[1].Plan)
+	assert.Equal(t, "sub-1", coverage.Findings[1].SubscriptionID)
+	assert.Contains(t, coverage.Findings[1].Description, "Defender for Storage")
+}
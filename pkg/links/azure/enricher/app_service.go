@@ -20,7 +20,8 @@ func (a *AppServiceEnricher) CanEnrich(templateID string) bool {
 	return templateID == "app_services_public_access" ||
 		templateID == "app_service_remote_debugging_enabled" ||
 		templateID == "function_app_http_anonymous_access" ||
-		templateID == "app_service_auth_disabled"
+		templateID == "app_service_auth_disabled" ||
+		templateID == "app_service_public_unauthenticated"
 }
 
 func (a *AppServiceEnricher) Enrich(ctx context.Context, resource *model.AzureResource) []Command {
@@ -37,6 +38,8 @@ func (a *AppServiceEnricher) Enrich(ctx context.Context, resource *model.AzureRe
 		return a.checkFunctionAppAnonymousAccess(ctx, resource)
 	case "app_service_auth_disabled":
 		return a.checkAuthenticationDisabled(ctx, resource)
+	case "app_service_public_unauthenticated":
+		return a.checkPublicUnauthenticated(ctx, resource)
 	default:
 		return []Command{}
 	}
@@ -181,8 +184,11 @@ func (a *AppServiceEnricher) checkPublicAccess(ctx context.Context, resource *mo
 // probeMainPage sends an HTTP GET to the App Service default page and returns a clean
 // status summary instead of the raw HTML body (which breaks markdown rendering).
 func (a *AppServiceEnricher) probeMainPage(client *http.Client, appName string) Command {
-	appURL := fmt.Sprintf("https://%s.azurewebsites.net", appName)
+	return a.probeURL(client, fmt.Sprintf("https://%s.azurewebsites.net", appName))
+}
 
+// probeURL sends an HTTP GET to an App Service endpoint and summarizes the response.
+func (a *AppServiceEnricher) probeURL(client *http.Client, appURL string) Command {
 	cmd := Command{
 		Command:                   fmt.Sprintf("curl -i --max-redirects 0 '%s' --max-time 10", appURL),
 		Description:               "Test HTTP GET to App Service default page",
@@ -629,6 +635,33 @@ func (a *AppServiceEnricher) checkFunctionAppAnonymousAccess(ctx context.Context
 	}}
 }
 
+// checkPublicUnauthenticated confirms Easy Auth is disabled for an internet-reachable App Service
+// and probes the endpoint projected by the template so the exposure can be verified.
+func (a *AppServiceEnricher) checkPublicUnauthenticated(ctx context.Context, resource *model.AzureResource) []Command {
+	commands := a.checkAuthenticationDisabled(ctx, resource)
+	if len(commands) > 0 && commands[0].ExitCode == 0 {
+		// Easy Auth is enabled, nothing further to verify
+		return commands
+	}
+
+	endpoint, _ := resource.Properties["endpoint"].(string)
+	if endpoint == "" {
+		if resource.Name == "" {
+			return commands
+		}
+		endpoint = fmt.Sprintf("https://%s.azurewebsites.net", resource.Name)
+	}
+
+	httpClient := &http.Client{
+		Timeout: 10 * time.Second,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	return append(commands, a.probeURL(httpClient, endpoint))
+}
+
 // checkAuthenticationDisabled checks if App Service Authentication (Easy Auth) is disabled
 func (a *AppServiceEnricher) checkAuthenticationDisabled(ctx context.Context, resource *model.AzureResource) []Command {
 	appServiceName := resource.Name
@@ -0,0 +1,386 @@
+package iam
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/praetorian-inc/janus-framework/pkg/chain/cfg"
+)
+
+// passwordResetRoles are the Entra ID roles that reset user passwords, keyed
+// by role template ID. Scoped to an administrative unit, they reset the
+// passwords of the users that are members of the unit.
+var passwordResetRoles = map[string]string{
+	"729827e3-9c14-49f7-bb1b-9608f156bbb8": "Helpdesk Administrator",
+	"966707d0-3269-4727-9be2-8c3a10f19b9d": "Password Administrator",
+	"fe930be7-5e62-47db-91af-98c3a49a38b1": "User Administrator",
+	"c4e39bd9-1100-46d3-8c65-fb160da0071f": "Authentication Administrator",
+}
+
+const administrativeUnitScopePrefix = "/administrativeUnits/"
+
+const administrativeUnitsEndpoint = "/directory/administrativeUnits?$select=id,displayName,description,visibility,membershipType,membershipRule"
+
+// administrativeUnitMembersEndpoint lists the members of one administrative unit
+func administrativeUnitMembersEndpoint(unitID string) string {
+	return fmt.Sprintf("/directory/administrativeUnits/%s/members?$select=id,displayName,userPrincipalName", unitID)
+}
+
+// administrativeUnitScopedRoleMembersEndpoint lists the directory roles scoped
+// to one administrative unit, whatever the role
+func administrativeUnitScopedRoleMembersEndpoint(unitID string) string {
+	return fmt.Sprintf("/directory/administrativeUnits/%s/scopedRoleMembers", unitID)
+}
+
+// collectAdministrativeUnits collects administrative units into
+// administrativeUnits, the members of each unit into administrativeUnitMembers,
+// the scoped role memberships of each unit into
+// administrativeUnitScopedRoleMembers and the password reset roles scoped to a
+// unit into administrativeUnitRoleAssignments, using fetch to page through
+// Graph. Directory roles must already be collected to name the scoped roles.
+func collectAdministrativeUnits(logger *cfg.Logger, errs *collectionErrorLog, fetch func(version, endpoint string) ([]interface{}, error), azureADData map[string]interface{}) {
+	units, err := fetch(graphV1, administrativeUnitsEndpoint)
+	if err != nil {
+		logger.Warn("Failed to collect administrative units, continuing without them", "error", err)
+		errs.record("administrativeUnits", "tenant", err)
+		units = []interface{}{}
+	}
+	azureADData["administrativeUnits"] = units
+	unitNames := make(map[string]interface{})
+	roles := indexDirectoryRoles(azureADData)
+
+	members := []interface{}{}
+	scopedRoleMembers := []interface{}{}
+	for _, unit := range units {
+		unitMap, ok := unit.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		unitID, _ := unitMap["id"].(string)
+		if unitID == "" {
+			continue
+		}
+		unitNames[strings.ToLower(unitID)] = unitMap["displayName"]
+
+		memberships, err := fetch(graphV1, administrativeUnitScopedRoleMembersEndpoint(unitID))
+		if err != nil {
+			logger.Warn("Failed to collect administrative unit scoped role members", "administrative_unit", unitMap["displayName"], "error", err)
+			errs.record("administrativeUnitScopedRoleMembers", "tenant", err)
+		}
+		for _, membership := range memberships {
+			if membershipMap, ok := membership.(map[string]interface{}); ok {
+				scopedRoleMembers = append(scopedRoleMembers, newScopedRoleMemberRecord(unitID, unitMap["displayName"], membershipMap, roles))
+			}
+		}
+
+		unitMembers, err := fetch(graphV1, administrativeUnitMembersEndpoint(unitID))
+		if err != nil {
+			logger.Warn("Failed to collect administrative unit members", "administrative_unit", unitMap["displayName"], "error", err)
+			errs.record("administrativeUnitMembers", "tenant", err)
+			continue
+		}
+		for _, member := range unitMembers {
+			memberMap, ok := member.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			members = append(members, map[string]interface{}{
+				"administrativeUnitId":    unitID,
+				"administrativeUnitName":  unitMap["displayName"],
+				"memberId":                memberMap["id"],
+				"memberName":              memberMap["displayName"],
+				"memberUserPrincipalName": memberMap["userPrincipalName"],
+				"memberType":              normalizeODataType(memberMap["@odata.type"]),
+			})
+		}
+	}
+	azureADData["administrativeUnitMembers"] = members
+	azureADData["administrativeUnitScopedRoleMembers"] = scopedRoleMembers
+
+	assignments := []interface{}{}
+	templateIDs := make([]string, 0, len(passwordResetRoles))
+	for templateID := range passwordResetRoles {
+		templateIDs = append(templateIDs, templateID)
+	}
+	sort.Strings(templateIDs)
+	for _, templateID := range templateIDs {
+		roleAssignments, err := fetch(graphV1, roleAssignmentsEndpoint(templateID))
+		if err != nil {
+			logger.Warn("Failed to collect administrative unit role assignments", "role", passwordResetRoles[templateID], "error", err)
+			errs.record("administrativeUnitRoleAssignments", "tenant", err)
+			continue
+		}
+		for _, assignment := range roleAssignments {
+			assignmentMap, ok := assignment.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			if record := newAdministrativeUnitRoleAssignmentRecord(templateID, assignmentMap, unitNames); record != nil {
+				assignments = append(assignments, record)
+			}
+		}
+	}
+	azureADData["administrativeUnitRoleAssignments"] = assignments
+	logger.Info("Collected administrative units", "units", len(units), "members", len(members),
+		"scoped_role_members", len(scopedRoleMembers), "scoped_role_assignments", len(assignments))
+}
+
+// indexDirectoryRoles maps the lowercased object ID of each activated
+// directory role to its record
+func indexDirectoryRoles(azureADData map[string]interface{}) map[string]map[string]interface{} {
+	index := make(map[string]map[string]interface{})
+	roles, _ := azureADData["directoryRoles"].([]interface{})
+	for _, role := range roles {
+		if roleMap, ok := role.(map[string]interface{}); ok {
+			if roleID, _ := roleMap["id"].(string); roleID != "" {
+				index[strings.ToLower(roleID)] = roleMap
+			}
+		}
+	}
+	return index
+}
+
+// newScopedRoleMemberRecord flattens a scopedRoleMembership of a unit. Graph
+// names the role by the object ID of the activated directory role, which is
+// resolved to its display name and template ID.
+func newScopedRoleMemberRecord(unitID string, unitName interface{}, membership map[string]interface{}, roles map[string]map[string]interface{}) map[string]interface{} {
+	roleID, _ := membership["roleId"].(string)
+	record := map[string]interface{}{
+		"id":                     membership["id"],
+		"roleId":                 roleID,
+		"administrativeUnitId":   unitID,
+		"administrativeUnitName": unitName,
+	}
+	if role, ok := roles[strings.ToLower(roleID)]; ok {
+		record["roleName"] = role["displayName"]
+		record["roleTemplateId"] = role["roleTemplateId"]
+	}
+	if info, ok := membership["roleMemberInfo"].(map[string]interface{}); ok {
+		record["principalId"] = info["id"]
+		record["principalDisplayName"] = info["displayName"]
+	}
+	return record
+}
+
+// newAdministrativeUnitRoleAssignmentRecord flattens a unifiedRoleAssignment
+// scoped to an administrative unit. It returns nil for assignments with any
+// other scope, which directoryRoleAssignments already covers.
+func newAdministrativeUnitRoleAssignmentRecord(templateID string, assignment map[string]interface{}, unitNames map[string]interface{}) map[string]interface{} {
+	scope, _ := assignment["directoryScopeId"].(string)
+	if !strings.HasPrefix(scope, administrativeUnitScopePrefix) {
+		return nil
+	}
+	unitID := strings.TrimPrefix(scope, administrativeUnitScopePrefix)
+	record := map[string]interface{}{
+		"id":                     assignment["id"],
+		"principalId":            assignment["principalId"],
+		"roleTemplateId":         templateID,
+		"roleName":               passwordResetRoles[templateID],
+		"directoryScopeId":       scope,
+		"administrativeUnitId":   unitID,
+		"administrativeUnitName": unitNames[strings.ToLower(unitID)],
+	}
+	if principal, ok := assignment["principal"].(map[string]interface{}); ok {
+		record["principalType"] = normalizeODataType(principal["@odata.type"])
+		record["principalDisplayName"] = principal["displayName"]
+	}
+	return record
+}
+
+// administrativeUnitReset is a password reset role scoped to an
+// administrative unit, with the users of the unit it can reset
+type administrativeUnitReset struct {
+	assignmentID string
+	principalID  string
+	roleName     string
+	unitID       string
+	unitName     interface{}
+	eligible     bool
+	users        []map[string]interface{}
+}
+
+// administrativeUnitResets lists the password reset roles scoped to an
+// administrative unit, standing or PIM eligible, with the users each one can
+// reset. Only direct user members are in scope: a role scoped to a unit
+// manages the properties of its groups, not the passwords of their members.
+func administrativeUnitResets(o *ConsolidatedOutput) []administrativeUnitReset {
+	usersByUnit := make(map[string][]map[string]interface{})
+	unitNames := make(map[string]interface{})
+	members, _ := o.AzureAD["administrativeUnitMembers"].([]interface{})
+	for _, member := range members {
+		m, ok := member.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		unitID := strings.ToLower(fmt.Sprint(m["administrativeUnitId"]))
+		unitNames[unitID] = m["administrativeUnitName"]
+		if principalTypeFromODataType(m["memberType"]) == "User" {
+			usersByUnit[unitID] = append(usersByUnit[unitID], m)
+		}
+	}
+	units, _ := o.AzureAD["administrativeUnits"].([]interface{})
+	for _, unit := range units {
+		if unitMap, ok := unit.(map[string]interface{}); ok {
+			unitNames[strings.ToLower(fmt.Sprint(unitMap["id"]))] = unitMap["displayName"]
+		}
+	}
+
+	resets := []administrativeUnitReset{}
+	seen := make(map[string]bool)
+	add := func(assignmentID, principalID, templateID, scope string, eligible bool) {
+		roleName, ok := passwordResetRoles[strings.ToLower(templateID)]
+		if !ok || principalID == "" || !strings.HasPrefix(scope, administrativeUnitScopePrefix) {
+			return
+		}
+		unitID := strings.TrimPrefix(scope, administrativeUnitScopePrefix)
+		key := strings.ToLower(strings.Join([]string{principalID, templateID, unitID, fmt.Sprint(eligible)}, "|"))
+		if seen[key] {
+			return
+		}
+		seen[key] = true
+		resets = append(resets, administrativeUnitReset{
+			assignmentID: assignmentID,
+			principalID:  principalID,
+			roleName:     roleName,
+			unitID:       unitID,
+			unitName:     unitNames[strings.ToLower(unitID)],
+			eligible:     eligible,
+			users:        usersByUnit[strings.ToLower(unitID)],
+		})
+	}
+
+	assignments, _ := o.AzureAD["administrativeUnitRoleAssignments"].([]interface{})
+	for _, assignment := range assignments {
+		if a, ok := assignment.(map[string]interface{}); ok {
+			assignmentID, _ := a["id"].(string)
+			principalID, _ := a["principalId"].(string)
+			templateID, _ := a["roleTemplateId"].(string)
+			scope, _ := a["directoryScopeId"].(string)
+			add(assignmentID, principalID, templateID, scope, false)
+		}
+	}
+
+	// Scoped role memberships cover the same standing assignments for every
+	// role; the ones of a password reset role not seen above are added
+	scopedRoleMembers, _ := o.AzureAD["administrativeUnitScopedRoleMembers"].([]interface{})
+	for _, membership := range scopedRoleMembers {
+		if m, ok := membership.(map[string]interface{}); ok {
+			membershipID, _ := m["id"].(string)
+			principalID, _ := m["principalId"].(string)
+			templateID, _ := m["roleTemplateId"].(string)
+			unitID, _ := m["administrativeUnitId"].(string)
+			add(membershipID, principalID, templateID, administrativeUnitScopePrefix+unitID, false)
+		}
+	}
+
+	eligible, _ := o.PIM["eligible_assignments"].([]interface{})
+	for _, assignment := range eligible {
+		if a, ok := assignment.(map[string]interface{}); ok {
+			assignmentID, _ := a["id"].(string)
+			principalID, templateID := pimAssignmentPrincipalAndRole(a)
+			scope, _ := a["directoryScopeId"].(string)
+			add(assignmentID, principalID, templateID, scope, true)
+		}
+	}
+	return resets
+}
+
+// buildAdministrativeUnitFindings flags password reset roles scoped to an
+// administrative unit that contains privileged users. The scoped admin can
+// take over those users by resetting their passwords, the scoped version of
+// the User Administrator escalation.
+//
+// Entra ID refuses password resets of most directory role holders by lower
+// privileged admins, so members privileged only through Azure RBAC or classic
+// administrator grants are High, and members holding a directory role are
+// Medium with roleProtected set for triage.
+func buildAdministrativeUnitFindings(o *ConsolidatedOutput) []interface{} {
+	findings := []interface{}{}
+	principals := indexReportPrincipals(o)
+	grants := privilegedPrincipalGrants(o)
+
+	for _, reset := range administrativeUnitResets(o) {
+		privilegedUsers := []interface{}{}
+		var names []string
+		unprotected := false
+		for _, user := range reset.users {
+			userID := strings.ToLower(fmt.Sprint(user["memberId"]))
+			userGrants := grants[userID]
+			if len(userGrants) == 0 || strings.EqualFold(userID, reset.principalID) {
+				continue
+			}
+			roleProtected := false
+			for _, grant := range userGrants {
+				if grant["type"] == "directoryRole" || grant["type"] == "pimEligibleDirectoryRole" {
+					roleProtected = true
+				}
+			}
+			if !roleProtected {
+				unprotected = true
+			}
+			privilegedUsers = append(privilegedUsers, map[string]interface{}{
+				"principalId":       user["memberId"],
+				"principalName":     user["memberName"],
+				"userPrincipalName": user["memberUserPrincipalName"],
+				"privilegedGrants":  userGrants,
+				"roleProtected":     roleProtected,
+			})
+			names = append(names, fmt.Sprint(user["memberName"]))
+		}
+		if len(privilegedUsers) == 0 {
+			continue
+		}
+		sort.Strings(names)
+
+		finding := reportRow(principals, reset.principalID)
+		finding["type"] = "AdministrativeUnitPasswordReset"
+		finding["severity"] = "Medium"
+		if unprotected {
+			finding["severity"] = "High"
+		}
+		assignment := reset.roleName
+		if reset.eligible {
+			assignment = "eligible " + reset.roleName
+		}
+		finding["description"] = fmt.Sprintf("%s holds %s scoped to administrative unit %v and can reset the passwords of privileged members %s",
+			finding["principalName"], assignment, reset.unitName, strings.Join(names, ", "))
+		finding["roleName"] = reset.roleName
+		finding["eligible"] = reset.eligible
+		finding["assignmentId"] = reset.assignmentID
+		finding["administrativeUnitId"] = reset.unitID
+		finding["administrativeUnitName"] = reset.unitName
+		finding["inScopeUsers"] = len(reset.users)
+		finding["privilegedUsers"] = privilegedUsers
+		findings = append(findings, finding)
+	}
+
+	sortFindings(findings, "description")
+	return findings
+}
+
+// logAdministrativeUnitFindings reports scoped admins that can reset privileged users
+func logAdministrativeUnitFindings(logger *cfg.Logger, findings []interface{}) {
+	logFindings(logger, findings, "🚨 %d administrative unit scoped admins can reset the passwords of privileged users", "Administrative unit scoped password reset",
+		"principal", "principalName", "role", "roleName", "administrative_unit", "administrativeUnitName", "severity", "severity")
+}
+
+// administrativeUnitResetsReport lists every user each administrative unit
+// scoped password reset role can reset
+func administrativeUnitResetsReport(o *ConsolidatedOutput, principals map[string]reportPrincipal) []map[string]interface{} {
+	grants := privilegedPrincipalGrants(o)
+	rows := []map[string]interface{}{}
+	for _, reset := range administrativeUnitResets(o) {
+		for _, user := range reset.users {
+			row := reportRow(principals, reset.principalID)
+			row["roleName"] = reset.roleName
+			row["eligible"] = reset.eligible
+			row["administrativeUnitName"] = reset.unitName
+			row["userName"] = user["memberName"]
+			row["userPrincipalName"] = user["memberUserPrincipalName"]
+			row["userPrivileged"] = len(grants[strings.ToLower(fmt.Sprint(user["memberId"]))]) > 0
+			rows = append(rows, row)
+		}
+	}
+	return rows
+}
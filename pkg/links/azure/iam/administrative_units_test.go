@@ -0,0 +1,138 @@
+package iam
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/praetorian-inc/janus-framework/pkg/chain/cfg"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const helpdeskAdminTemplateID = "729827e3-9c14-49f7-bb1b-9608f156bbb8"
+
+func TestCollectAdministrativeUnits(t *testing.T) {
+	fetch := func(version, endpoint string) ([]interface{}, error) {
+		switch {
+		case strings.HasPrefix(endpoint, "/directory/administrativeUnits?"):
+			return []interface{}{map[string]interface{}{"id": "au-1", "displayName": "Finance"}}, nil
+		case endpoint == administrativeUnitMembersEndpoint("au-1"):
+			return []interface{}{
+				map[string]interface{}{"@odata.type": "#microsoft.graph.user", "id": "u-cfo", "displayName": "CFO"},
+				map[string]interface{}{"@odata.type": "#microsoft.graph.group", "id": "g-finance", "displayName": "Finance Team"},
+			}, nil
+		case endpoint == administrativeUnitScopedRoleMembersEndpoint("au-1"):
+			return []interface{}{
+				map[string]interface{}{"id": "srm-1", "administrativeUnitId": "au-1", "roleId": "role-obj-1",
+					"roleMemberInfo": map[string]interface{}{"id": "u-groups", "displayName": "Groups Admin"}},
+			}, nil
+		case endpoint == roleAssignmentsEndpoint(helpdeskAdminTemplateID):
+			return []interface{}{
+				map[string]interface{}{"id": "ra-1", "principalId": "u-helpdesk", "directoryScopeId": "/administrativeUnits/au-1",
+					"principal": map[string]interface{}{"@odata.type": "#microsoft.graph.user", "displayName": "Helpdesk"}},
+				map[string]interface{}{"id": "ra-2", "principalId": "u-tenant-helpdesk", "directoryScopeId": "/"},
+			}, nil
+		}
+		return []interface{}{}, nil
+	}
+
+	azureAD := map[string]interface{}{
+		"directoryRoles": []interface{}{
+			map[string]interface{}{"id": "ROLE-OBJ-1", "displayName": "Groups Administrator", "roleTemplateId": "fdd7a751-b60b-444a-984c-02652fe8fa1c"},
+		},
+	}
+	errs := collectionErrorLog{}
+	collectAdministrativeUnits(cfg.NewLogger(), &errs, fetch, azureAD)
+	assert.Empty(t, errs.list())
+	assert.Len(t, azureAD["administrativeUnits"], 1)
+
+	members := azureAD["administrativeUnitMembers"].([]interface{})
+	require.Len(t, members, 2)
+	assert.Equal(t, "Finance", members[0].(map[string]interface{})["administrativeUnitName"])
+	assert.Equal(t, odataTypeUser, members[0].(map[string]interface{})["memberType"])
+
+	scoped := azureAD["administrativeUnitScopedRoleMembers"].([]interface{})
+	require.Len(t, scoped, 1)
+	assert.Equal(t, map[string]interface{}{
+		"id": "srm-1", "roleId": "role-obj-1", "administrativeUnitId": "au-1", "administrativeUnitName": "Finance",
+		"roleName": "Groups Administrator", "roleTemplateId": "fdd7a751-b60b-444a-984c-02652fe8fa1c",
+		"principalId": "u-groups", "principalDisplayName": "Groups Admin",
+	}, scoped[0], "the scoped role is named from the activated directory role")
+
+	assignments := azureAD["administrativeUnitRoleAssignments"].([]interface{})
+	require.Len(t, assignments, 1, "tenant-wide assignments are left to directoryRoleAssignments")
+	assignment := assignments[0].(map[string]interface{})
+	assert.Equal(t, "au-1", assignment["administrativeUnitId"])
+	assert.Equal(t, "Finance", assignment["administrativeUnitName"])
+	assert.Equal(t, "Helpdesk Administrator", assignment["roleName"])
+}
+
+func TestAdministrativeUnitFindings(t *testing.T) {
+	member := func(unitID, userID, name, odataType string) map[string]interface{} {
+		return map[string]interface{}{"administrativeUnitId": unitID, "administrativeUnitName": unitID, "memberId": userID, "memberName": name, "memberType": odataType}
+	}
+	o := &ConsolidatedOutput{
+		AzureAD: map[string]interface{}{
+			"users": []interface{}{
+				map[string]interface{}{"id": "u-helpdesk", "displayName": "Helpdesk"},
+				map[string]interface{}{"id": "u-owner", "displayName": "Subscription Owner"},
+				map[string]interface{}{"id": "u-ga", "displayName": "Global Admin"},
+				map[string]interface{}{"id": "u-staff", "displayName": "Staff"},
+			},
+			"administrativeUnitMembers": []interface{}{
+				member("au-finance", "u-owner", "Subscription Owner", odataTypeUser),
+				member("au-finance", "u-staff", "Staff", odataTypeUser),
+				member("au-finance", "u-helpdesk", "Helpdesk", odataTypeUser),
+				member("au-admins", "u-ga", "Global Admin", odataTypeUser),
+				member("au-staff", "u-staff", "Staff", odataTypeUser),
+				member("au-groups", "g-admins", "Admins", "#microsoft.graph.group"),
+			},
+			"administrativeUnitRoleAssignments": []interface{}{
+				map[string]interface{}{"id": "ra-1", "principalId": "u-helpdesk", "roleTemplateId": helpdeskAdminTemplateID, "directoryScopeId": "/administrativeUnits/au-finance"},
+				map[string]interface{}{"id": "ra-2", "principalId": "u-helpdesk", "roleTemplateId": helpdeskAdminTemplateID, "directoryScopeId": "/administrativeUnits/au-staff"},
+				map[string]interface{}{"id": "ra-3", "principalId": "u-helpdesk", "roleTemplateId": helpdeskAdminTemplateID, "directoryScopeId": "/administrativeUnits/au-groups"},
+			},
+			"administrativeUnitScopedRoleMembers": []interface{}{
+				map[string]interface{}{"id": "srm-1", "principalId": "U-HELPDESK", "roleTemplateId": helpdeskAdminTemplateID, "administrativeUnitId": "au-finance"},
+				map[string]interface{}{"id": "srm-2", "principalId": "u-groups", "roleTemplateId": "fdd7a751-b60b-444a-984c-02652fe8fa1c", "administrativeUnitId": "au-finance"},
+			},
+			"directoryRoleAssignments": []interface{}{
+				map[string]interface{}{"principalId": "u-ga", "roleTemplateId": globalAdminTemplateID},
+				map[string]interface{}{"principalId": "g-admins", "roleTemplateId": globalAdminTemplateID},
+			},
+		},
+		PIM: map[string]interface{}{
+			"eligible_assignments": []interface{}{
+				map[string]interface{}{"id": "el-1", "principalId": "u-staff", "roleDefinitionId": "966707d0-3269-4727-9be2-8c3a10f19b9d", "directoryScopeId": "/administrativeUnits/au-admins"},
+			},
+		},
+		AzureResources: map[string]interface{}{
+			"sub-1": map[string]interface{}{
+				"subscriptionRoleAssignments": []interface{}{
+					map[string]interface{}{"principalId": "u-owner", "roleDefinitionId": "/subscriptions/sub-1/providers/Microsoft.Authorization/roleDefinitions/8e3af657-a8ff-443c-a75c-2fe8c4bcb635", "scope": "/subscriptions/sub-1"},
+				},
+			},
+		},
+	}
+
+	assert.Len(t, administrativeUnitResets(o), 4, "a scoped role membership of an assignment already listed is not counted twice, nor one of a role that does not reset passwords")
+
+	findings := buildAdministrativeUnitFindings(o)
+	require.Len(t, findings, 2, "units without privileged user members are not reported, nor are groups in a unit")
+
+	rbac := findings[0].(map[string]interface{})
+	assert.Equal(t, "High", rbac["severity"], "Entra ID does not protect users privileged only through Azure RBAC")
+	assert.Equal(t, "Helpdesk", rbac["principalName"])
+	assert.Equal(t, "au-finance", rbac["administrativeUnitId"])
+	assert.Equal(t, 3, rbac["inScopeUsers"])
+	assert.Len(t, rbac["privilegedUsers"], 1, "the scoped admin is not listed as their own target")
+
+	protected := findings[1].(map[string]interface{})
+	assert.Equal(t, "Medium", protected["severity"])
+	assert.Equal(t, true, protected["eligible"])
+	assert.Equal(t, "Password Administrator", protected["roleName"])
+	assert.Equal(t, true, protected["privilegedUsers"].([]interface{})[0].(map[string]interface{})["roleProtected"])
+
+	rows := runOfflineReports(o, []string{"au-password-resets"})["au-password-resets"]
+	assert.Len(t, rows, 5, "every user in scope of every unit-scoped assignment")
+}
@@ -0,0 +1,243 @@
+package iam
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/praetorian-inc/janus-framework/pkg/chain/cfg"
+)
+
+// keyVaultDataReadRoles are built-in roles that let a principal read secrets or
+// use keys in a vault with RBAC authorization enabled, keyed by role
+// definition GUID
+var keyVaultDataReadRoles = map[string]string{
+	"00482a5a-887f-4fb3-b363-3b7fe8e74483": "Key Vault Administrator",
+	"b86a8fe4-44ce-4948-aee5-eccb2c155cd7": "Key Vault Secrets Officer",
+	"4633458b-17de-408a-b874-0445c86b69e6": "Key Vault Secrets User",
+	"a4417e6f-fecd-4de8-b567-7b0420556985": "Key Vault Certificates Officer",
+	"14b46e9e-c2b7-41e4-b07b-48a6ebf60603": "Key Vault Crypto Officer",
+	"12338af0-0e69-4776-bea7-57ae8d297424": "Key Vault Crypto User",
+}
+
+// accessPolicyReadPermissions are the access policy permissions, per object
+// kind, that return secret material or let the holder use a key
+var accessPolicyReadPermissions = map[string][]string{
+	"secrets":      {"get", "all"},
+	"keys":         {"decrypt", "unwrapkey", "sign", "all"},
+	"certificates": {"all"},
+}
+
+// appIdentity is a managed identity attached to an App Service or Function app
+type appIdentity struct {
+	principalID string
+	kind        string // SystemAssigned or UserAssigned
+	resourceID  string // user-assigned identity resource ID
+}
+
+// webAppIdentities returns the managed identities of a microsoft.web/sites
+// resource as projected by Resource Graph
+func webAppIdentities(app map[string]interface{}) []appIdentity {
+	identity, _ := app["identity"].(map[string]interface{})
+	var identities []appIdentity
+	if principalID, _ := identity["principalId"].(string); principalID != "" {
+		identities = append(identities, appIdentity{principalID: principalID, kind: "SystemAssigned"})
+	}
+	userAssigned, _ := identity["userAssignedIdentities"].(map[string]interface{})
+	for resourceID, details := range userAssigned {
+		detailsMap, _ := details.(map[string]interface{})
+		if principalID, _ := detailsMap["principalId"].(string); principalID != "" {
+			identities = append(identities, appIdentity{principalID: principalID, kind: "UserAssigned", resourceID: resourceID})
+		}
+	}
+	sort.Slice(identities, func(i, j int) bool {
+		return identities[i].kind+identities[i].resourceID < identities[j].kind+identities[j].resourceID
+	})
+	return identities
+}
+
+// keyVaultAccessGrant is one way a principal can read from a vault
+type keyVaultAccessGrant struct {
+	model      string // rbac or accessPolicy
+	permission string
+	scope      string
+}
+
+// keyVaultReadGrants indexes, per lowercased vault ID and principal ID, the
+// grants that let the principal read secrets or use keys. Access policies
+// only count on vaults without RBAC authorization, and RBAC roles only on
+// vaults with it, because Key Vault ignores the other model.
+func keyVaultReadGrants(o *ConsolidatedOutput, vaults []map[string]interface{}) map[string]map[string][]keyVaultAccessGrant {
+	grants := make(map[string]map[string][]keyVaultAccessGrant)
+	add := func(vaultID, principalID string, grant keyVaultAccessGrant) {
+		vaultID, principalID = normalizeScope(vaultID), strings.ToLower(principalID)
+		if grants[vaultID] == nil {
+			grants[vaultID] = make(map[string][]keyVaultAccessGrant)
+		}
+		for _, existing := range grants[vaultID][principalID] {
+			if existing == grant {
+				return
+			}
+		}
+		grants[vaultID][principalID] = append(grants[vaultID][principalID], grant)
+	}
+
+	rbacVaults := make(map[string]string)
+	for _, vault := range vaults {
+		vaultID, _ := vault["id"].(string)
+		properties, _ := vault["properties"].(map[string]interface{})
+		if rbac, _ := properties["enableRbacAuthorization"].(bool); rbac {
+			rbacVaults[normalizeScope(vaultID)] = vaultID
+			continue
+		}
+		policies, _ := properties["accessPolicies"].([]interface{})
+		for _, policy := range policies {
+			if principalID, permission := accessPolicyReadGrant(policy); permission != "" {
+				add(vaultID, principalID, keyVaultAccessGrant{model: "accessPolicy", permission: permission, scope: vaultID})
+			}
+		}
+	}
+
+	for _, subData := range o.AzureResources {
+		subDataMap, ok := subData.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		// keyVaultAccessPolicies holds one row per vault and policy
+		rows, _ := subDataMap["keyVaultAccessPolicies"].([]interface{})
+		for _, row := range rows {
+			rowMap, ok := row.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			vaultID, _ := rowMap["id"].(string)
+			if _, isRBAC := rbacVaults[normalizeScope(vaultID)]; isRBAC {
+				continue
+			}
+			if principalID, permission := accessPolicyReadGrant(rowMap["policy"]); permission != "" {
+				add(vaultID, principalID, keyVaultAccessGrant{model: "accessPolicy", permission: permission, scope: vaultID})
+			}
+		}
+
+		for _, section := range []string{"subscriptionRoleAssignments", "resourceGroupRoleAssignments", "resourceLevelRoleAssignments", "managementGroupRoleAssignments", "tenantRoleAssignments"} {
+			assignments, _ := subDataMap[section].([]interface{})
+			for _, assignment := range assignments {
+				assignmentMap, ok := assignment.(map[string]interface{})
+				if !ok {
+					continue
+				}
+				principalID, roleGUID, scope := rbacAssignmentFields(assignmentMap)
+				roleName, ok := keyVaultDataReadRoles[roleGUID]
+				if !ok || principalID == "" {
+					continue
+				}
+				normalized := normalizeScope(scope)
+				for vaultKey, vaultID := range rbacVaults {
+					if normalized == "" || vaultKey == normalized || strings.HasPrefix(vaultKey, normalized+"/") {
+						add(vaultID, principalID, keyVaultAccessGrant{model: "rbac", permission: roleName, scope: scope})
+					}
+				}
+			}
+		}
+	}
+	return grants
+}
+
+// accessPolicyReadGrant returns the object ID of a vault access policy entry
+// and a description of the read permissions it holds, or "" if it holds none
+func accessPolicyReadGrant(policy interface{}) (string, string) {
+	policyMap, ok := policy.(map[string]interface{})
+	if !ok {
+		return "", ""
+	}
+	principalID, _ := policyMap["objectId"].(string)
+	permissions, _ := policyMap["permissions"].(map[string]interface{})
+
+	var held []string
+	for _, kind := range []string{"secrets", "keys", "certificates"} {
+		granted, _ := permissions[kind].([]interface{})
+		var matched []string
+		for _, permission := range granted {
+			name, _ := permission.(string)
+			for _, readPermission := range accessPolicyReadPermissions[kind] {
+				if strings.EqualFold(name, readPermission) {
+					matched = append(matched, strings.ToLower(name))
+				}
+			}
+		}
+		if len(matched) > 0 {
+			held = append(held, kind+": "+strings.Join(matched, ","))
+		}
+	}
+	return principalID, strings.Join(held, "; ")
+}
+
+// buildAppKeyVaultFindings flags App Service and Function apps whose managed
+// identity can read secrets or use keys in a Key Vault. Anyone who can run
+// code in the app, through a deployment credential, SCM access or a code
+// injection bug, can request a token for that identity and read the vault.
+func buildAppKeyVaultFindings(o *ConsolidatedOutput) []interface{} {
+	findings := []interface{}{}
+
+	var apps, vaults []map[string]interface{}
+	for _, subData := range o.AzureResources {
+		subDataMap, ok := subData.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		resources, _ := subDataMap["azureResources"].([]interface{})
+		for _, resource := range resources {
+			resourceMap, ok := resource.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			switch resourceType, _ := resourceMap["type"].(string); strings.ToLower(resourceType) {
+			case "microsoft.web/sites":
+				apps = append(apps, resourceMap)
+			case "microsoft.keyvault/vaults":
+				vaults = append(vaults, resourceMap)
+			}
+		}
+	}
+	if len(apps) == 0 || len(vaults) == 0 {
+		return findings
+	}
+
+	grants := keyVaultReadGrants(o, vaults)
+	for _, app := range apps {
+		for _, identity := range webAppIdentities(app) {
+			for _, vault := range vaults {
+				vaultID, _ := vault["id"].(string)
+				for _, grant := range grants[normalizeScope(vaultID)][strings.ToLower(identity.principalID)] {
+					appKind, _ := app["kind"].(string)
+					findings = append(findings, map[string]interface{}{
+						"type":     "AppIdentityKeyVaultAccess",
+						"severity": "High",
+						"description": fmt.Sprintf("%s identity of %s can read from Key Vault %s (%s), so code running in the app can retrieve the vault's secrets",
+							identity.kind, app["name"], vault["name"], grant.permission),
+						"appId":                  app["id"],
+						"appName":                app["name"],
+						"appKind":                appKind,
+						"identityType":           identity.kind,
+						"identityPrincipalId":    identity.principalID,
+						"userAssignedIdentityId": identity.resourceID,
+						"vaultId":                vaultID,
+						"vaultName":              vault["name"],
+						"accessModel":            grant.model,
+						"permission":             grant.permission,
+						"scope":                  grant.scope,
+					})
+				}
+			}
+		}
+	}
+
+	sortFindings(findings, "appId", "vaultId")
+	return findings
+}
+
+// logAppKeyVaultFindings reports apps whose managed identity can read Key Vault secrets
+func logAppKeyVaultFindings(logger *cfg.Logger, findings []interface{}) {
+	logFindings(logger, findings, "🚨 %d App Service or Function app identities can read Key Vault secrets or keys", "App identity with Key Vault access",
+		"app", "appName", "vault", "vaultName", "permission", "permission")
+}
@@ -0,0 +1,74 @@
+package iam
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const appKeyVaultFixture = `{
+  "azure_ad": {},
+  "pim": {},
+  "azure_resources": {
+    "sub-1": {
+      "azureResources": [
+        {
+          "id": "/subscriptions/sub-1/resourceGroups/web/providers/Microsoft.Web/sites/orders-api",
+          "name": "orders-api", "type": "Microsoft.Web/sites", "kind": "app",
+          "identity": {"type": "SystemAssigned", "principalId": "app-mi"}
+        },
+        {
+          "id": "/subscriptions/sub-1/resourceGroups/web/providers/Microsoft.Web/sites/billing-func",
+          "name": "billing-func", "type": "Microsoft.Web/sites", "kind": "functionapp",
+          "identity": {"type": "UserAssigned", "userAssignedIdentities": {
+            "/subscriptions/sub-1/resourceGroups/web/providers/Microsoft.ManagedIdentity/userAssignedIdentities/billing": {"principalId": "func-uami"}
+          }}
+        },
+        {"id": "/subscriptions/sub-1/resourceGroups/web/providers/Microsoft.Web/sites/static", "name": "static", "type": "Microsoft.Web/sites"},
+        {
+          "id": "/subscriptions/sub-1/resourceGroups/sec/providers/Microsoft.KeyVault/vaults/legacy-kv",
+          "name": "legacy-kv", "type": "Microsoft.KeyVault/vaults",
+          "properties": {"enableRbacAuthorization": false, "accessPolicies": [
+            {"objectId": "app-mi", "permissions": {"secrets": ["Get", "List"], "keys": ["List"]}},
+            {"objectId": "func-uami", "permissions": {"secrets": ["List"]}}
+          ]}
+        },
+        {
+          "id": "/subscriptions/sub-1/resourceGroups/sec/providers/Microsoft.KeyVault/vaults/rbac-kv",
+          "name": "rbac-kv", "type": "Microsoft.KeyVault/vaults",
+          "properties": {"enableRbacAuthorization": true, "accessPolicies": [
+            {"objectId": "app-mi", "permissions": {"secrets": ["all"]}}
+          ]}
+        }
+      ],
+      "resourceGroupRoleAssignments": [
+        {"properties": {"principalId": "func-uami", "roleDefinitionId": "/subscriptions/sub-1/providers/Microsoft.Authorization/roleDefinitions/4633458b-17de-408a-b874-0445c86b69e6", "scope": "/subscriptions/sub-1/resourceGroups/sec"}},
+        {"properties": {"principalId": "func-uami", "roleDefinitionId": "/subscriptions/sub-1/providers/Microsoft.Authorization/roleDefinitions/acdd72a7-3385-48ef-bd42-f606fba81ae7", "scope": "/subscriptions/sub-1/resourceGroups/sec"}}
+      ]
+    }
+  }
+}`
+
+func TestBuildAppKeyVaultFindings(t *testing.T) {
+	var output ConsolidatedOutput
+	require.NoError(t, json.Unmarshal([]byte(appKeyVaultFixture), &output))
+
+	findings := buildAppKeyVaultFindings(&output)
+	require.Len(t, findings, 2, "list-only policies, Reader, and access policies on RBAC vaults grant no read")
+
+	function := findings[0].(map[string]interface{})
+	assert.Equal(t, "billing-func", function["appName"])
+	assert.Equal(t, "UserAssigned", function["identityType"])
+	assert.Equal(t, "rbac-kv", function["vaultName"])
+	assert.Equal(t, "rbac", function["accessModel"])
+	assert.Equal(t, "Key Vault Secrets User", function["permission"])
+	assert.Equal(t, "/subscriptions/sub-1/resourceGroups/sec", function["scope"])
+
+	app := findings[1].(map[string]interface{})
+	assert.Equal(t, "orders-api", app["appName"])
+	assert.Equal(t, "legacy-kv", app["vaultName"])
+	assert.Equal(t, "accessPolicy", app["accessModel"])
+	assert.Equal(t, "secrets: get", app["permission"])
+}
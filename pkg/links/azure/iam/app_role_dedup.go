@@ -0,0 +1,56 @@
+package iam
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/praetorian-inc/janus-framework/pkg/chain/cfg"
+)
+
+// deduplicateAppRoleAssignments collapses app role assignments collected more
+// than once under the same id. The collectors list both appRoleAssignments
+// and appRoleAssignedTo of every service principal, so an assignment between
+// two collected service principals is returned from both sides. The first
+// record is kept and its directions lists every direction it was seen from.
+// Assignments without an id cannot be matched and are kept as they are.
+func deduplicateAppRoleAssignments(logger *cfg.Logger, assignments []interface{}) []interface{} {
+	kept := make([]interface{}, 0, len(assignments))
+	byID := make(map[string]map[string]interface{})
+	for _, assignment := range assignments {
+		assignmentMap, ok := assignment.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		direction, _ := assignmentMap["direction"].(string)
+		id, _ := assignmentMap["id"].(string)
+		if id == "" {
+			kept = append(kept, assignment)
+			continue
+		}
+		if first, ok := byID[strings.ToLower(id)]; ok {
+			first["directions"] = mergeDirection(first["directions"].([]string), direction)
+			continue
+		}
+		assignmentMap["directions"] = mergeDirection([]string{}, direction)
+		byID[strings.ToLower(id)] = assignmentMap
+		kept = append(kept, assignmentMap)
+	}
+
+	logger.Info("App role assignment deduplication", "original", len(assignments), "unique", len(kept), "duplicates_removed", len(assignments)-len(kept))
+	return kept
+}
+
+// mergeDirection adds direction to the sorted directions of an assignment
+func mergeDirection(directions []string, direction string) []string {
+	if direction == "" {
+		return directions
+	}
+	for _, d := range directions {
+		if d == direction {
+			return directions
+		}
+	}
+	directions = append(directions, direction)
+	sort.Strings(directions)
+	return directions
+}
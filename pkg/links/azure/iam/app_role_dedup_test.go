@@ -0,0 +1,30 @@
+package iam
+
+import (
+	"testing"
+
+	"github.com/praetorian-inc/janus-framework/pkg/chain/cfg"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeduplicateAppRoleAssignments(t *testing.T) {
+	assignment := map[string]interface{}{"id": "Assignment-1", "appRoleId": "role-1", "principalId": "sp-client", "resourceId": "sp-api"}
+	assignments := []interface{}{
+		newAppRoleAssignmentRecord(assignment, "sp-client", "client", "assigned_to"),
+		newAppRoleAssignmentRecord(map[string]interface{}{"id": "assignment-2", "principalId": "u-1", "resourceId": "sp-api"}, "sp-api", "api", "assigned_from"),
+		newAppRoleAssignmentRecord(map[string]interface{}{"id": "assignment-1", "appRoleId": "role-1", "principalId": "sp-client", "resourceId": "sp-api"}, "sp-api", "api", "assigned_from"),
+		newAppRoleAssignmentRecord(assignment, "sp-client", "client", "assigned_to"),
+		newAppRoleAssignmentRecord(map[string]interface{}{"principalId": "u-2"}, "sp-api", "api", "assigned_from"),
+	}
+
+	unique := deduplicateAppRoleAssignments(cfg.NewLogger(), assignments)
+	require.Len(t, unique, 3, "an assignment seen from both service principals is kept once")
+
+	first := unique[0].(map[string]interface{})
+	assert.Equal(t, "Assignment-1", first["id"])
+	assert.Equal(t, "assigned_to", first["direction"], "the first record is kept")
+	assert.Equal(t, []string{"assigned_from", "assigned_to"}, first["directions"])
+	assert.Equal(t, []string{"assigned_from"}, unique[1].(map[string]interface{})["directions"])
+	assert.Nil(t, unique[2].(map[string]interface{})["id"], "assignments without an id are kept")
+}
@@ -0,0 +1,175 @@
+package iam
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resourcegraph/armresourcegraph"
+	"github.com/praetorian-inc/janus-framework/pkg/chain/cfg"
+	"github.com/praetorian-inc/nebula/pkg/rules"
+	"gopkg.in/yaml.v3"
+)
+
+// ARGRule is a detection declared in an --arg-rules file. Each row its Resource
+// Graph query returns becomes one finding carrying the rule's severity and
+// labels and the row fields named in Fields, or every field when Fields is
+// empty.
+type ARGRule struct {
+	Name        string   `yaml:"name"`
+	Severity    string   `yaml:"severity"`
+	Description string   `yaml:"description,omitempty"`
+	Labels      []string `yaml:"labels,omitempty"`
+	Query       string   `yaml:"query"`
+	Fields      []string `yaml:"fields,omitempty"`
+}
+
+// argRulesFile is the layout of an --arg-rules file. JSON is valid YAML, so
+// the same layout can be written in either.
+type argRulesFile struct {
+	Rules []ARGRule `yaml:"rules"`
+}
+
+// loadARGRules reads and validates an --arg-rules file. It returns no rules
+// when path is empty.
+func loadARGRules(path string) ([]ARGRule, error) {
+	if path == "" {
+		return nil, nil
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ARG rules file: %v", err)
+	}
+	var file argRulesFile
+	if err := yaml.Unmarshal(raw, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse ARG rules file %s: %v", path, err)
+	}
+	if len(file.Rules) == 0 {
+		return nil, fmt.Errorf("ARG rules file %s defines no rules", path)
+	}
+
+	seen := make(map[string]bool)
+	for i := range file.Rules {
+		rule := &file.Rules[i]
+		switch {
+		case rule.Name == "":
+			return nil, fmt.Errorf("ARG rule %d in %s has no name", i+1, path)
+		case seen[rule.Name]:
+			return nil, fmt.Errorf("ARG rule %q is defined more than once in %s", rule.Name, path)
+		case strings.TrimSpace(rule.Query) == "":
+			return nil, fmt.Errorf("ARG rule %q has no query", rule.Name)
+		case rules.SeverityRank(rule.Severity) == 0:
+			return nil, fmt.Errorf("ARG rule %q has severity %q, expected one of: %s", rule.Name, rule.Severity, strings.Join(rules.Severities, ", "))
+		}
+		seen[rule.Name] = true
+		// Severities are matched case-insensitively; report them as spelled in rules.Severities
+		for _, severity := range rules.Severities {
+			if strings.EqualFold(rule.Severity, severity) {
+				rule.Severity = severity
+			}
+		}
+	}
+	return file.Rules, nil
+}
+
+// runARGRules runs each rule's query through query and turns the returned rows
+// into findings. A failed query is recorded and the other rules still run.
+func runARGRules(logger *cfg.Logger, errs *collectionErrorLog, argRules []ARGRule, query func(query string) ([]interface{}, error)) []interface{} {
+	findings := []interface{}{}
+	for _, rule := range argRules {
+		rows, err := query(rule.Query)
+		if err != nil {
+			logger.Warn("Failed to run ARG rule, continuing without it", "rule", rule.Name, "error", err)
+			errs.record("argRuleFindings", rule.Name, err)
+			continue
+		}
+		for _, row := range rows {
+			if rowMap, ok := row.(map[string]interface{}); ok {
+				findings = append(findings, newARGRuleFinding(rule, rowMap))
+			}
+		}
+		logger.Info("Ran ARG rule", "rule", rule.Name, "findings", len(rows))
+	}
+
+	sortFindings(findings, "description")
+	return findings
+}
+
+// newARGRuleFinding builds the finding for one row of a rule's query. The
+// row's id is kept as resourceId so the finding fingerprints per resource.
+func newARGRuleFinding(rule ARGRule, row map[string]interface{}) map[string]interface{} {
+	finding := make(map[string]interface{})
+	if len(rule.Fields) == 0 {
+		for key, value := range row {
+			finding[key] = value
+		}
+	} else {
+		for _, field := range rule.Fields {
+			finding[field] = row[field]
+		}
+	}
+
+	// type names the rule; the row's resource type moves to resourceType
+	if resourceType, ok := finding["type"]; ok {
+		finding["resourceType"] = resourceType
+	}
+
+	resourceID, _ := row["id"].(string)
+	resourceName, _ := row["name"].(string)
+	if resourceName == "" {
+		resourceName = resourceID
+	}
+	summary := rule.Description
+	if summary == "" {
+		summary = rule.Name
+	}
+	labels := rule.Labels
+	if labels == nil {
+		labels = []string{}
+	}
+
+	finding["type"] = rule.Name
+	finding["rule"] = rule.Name
+	finding["severity"] = rule.Severity
+	finding["labels"] = labels
+	finding["resourceId"] = resourceID
+	finding["description"] = fmt.Sprintf("%s: %s", summary, resourceName)
+	finding["fingerprint"] = rules.Fingerprint(finding)
+	return finding
+}
+
+// logARGRuleFindings reports the findings of the --arg-rules detections
+func logARGRuleFindings(logger *cfg.Logger, findings []interface{}) {
+	logFindings(logger, findings, "🚨 %d findings from ARG rules", "ARG rule finding",
+		"rule", "rule", "severity", "severity", "description", "description")
+}
+
+// queryResourceGraphSDK runs a Resource Graph query over the given
+// subscriptions through the Resource Graph SDK client, following the skip
+// token until all rows are read
+func (l *SDKComprehensiveCollectorLink) queryResourceGraphSDK(ctx context.Context, subscriptionIDs []string, query string) ([]interface{}, error) {
+	resultFormat := armresourcegraph.ResultFormatObjectArray
+	queryRequest := armresourcegraph.QueryRequest{
+		Query:   &query,
+		Options: &armresourcegraph.QueryRequestOptions{ResultFormat: &resultFormat},
+	}
+	for i := range subscriptionIDs {
+		queryRequest.Subscriptions = append(queryRequest.Subscriptions, &subscriptionIDs[i])
+	}
+
+	var rows []interface{}
+	for {
+		response, err := l.resourceGraphClient.Resources(ctx, queryRequest, nil)
+		if err != nil {
+			return nil, fmt.Errorf("Resource Graph query failed: %v", err)
+		}
+		if response.Data != nil {
+			decodeResourceGraphData(response.Data, &rows)
+		}
+		if response.SkipToken == nil || len(*response.SkipToken) == 0 {
+			return rows, nil
+		}
+		queryRequest.Options.SkipToken = response.SkipToken
+	}
+}
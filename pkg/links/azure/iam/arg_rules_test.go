@@ -0,0 +1,95 @@
+package iam
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/praetorian-inc/janus-framework/pkg/chain/cfg"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadARGRules(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+		return path
+	}
+
+	yamlRules, err := loadARGRules(write("rules.yaml", `
+rules:
+  - name: keyvault-access-policies
+    severity: high
+    description: Key vault uses access policies instead of RBAC
+    labels: [keyvault, authorization]
+    query: |
+      resources
+      | where type =~ 'microsoft.keyvault/vaults'
+      | where properties.enableRbacAuthorization != true
+    fields: [id, name, subscriptionId]
+`))
+	require.NoError(t, err)
+	require.Len(t, yamlRules, 1)
+	assert.Equal(t, "High", yamlRules[0].Severity, "severity is normalized to its canonical spelling")
+	assert.Equal(t, []string{"keyvault", "authorization"}, yamlRules[0].Labels)
+
+	jsonRules, err := loadARGRules(write("rules.json", `{"rules": [{"name": "public-ips", "severity": "Low", "query": "resources | where type =~ 'microsoft.network/publicipaddresses'"}]}`))
+	require.NoError(t, err)
+	require.Len(t, jsonRules, 1)
+
+	none, err := loadARGRules("")
+	require.NoError(t, err)
+	assert.Empty(t, none)
+
+	for name, content := range map[string]string{
+		"empty.yaml":     "rules: []",
+		"severity.yaml":  "rules: [{name: a, severity: Severe, query: resources}]",
+		"query.yaml":     "rules: [{name: a, severity: Low}]",
+		"duplicate.yaml": "rules: [{name: a, severity: Low, query: resources}, {name: a, severity: Low, query: resources}]",
+	} {
+		_, err := loadARGRules(write(name, content))
+		assert.Error(t, err, name)
+	}
+}
+
+func TestRunARGRules(t *testing.T) {
+	argRules := []ARGRule{
+		{Name: "keyvault-access-policies", Severity: "High", Description: "Key vault uses access policies", Labels: []string{"keyvault"}, Query: "kv", Fields: []string{"id", "name", "type"}},
+		{Name: "broken", Severity: "Low", Query: "broken"},
+		{Name: "public-ips", Severity: "Low", Query: "ips"},
+	}
+	query := func(query string) ([]interface{}, error) {
+		switch query {
+		case "kv":
+			return []interface{}{map[string]interface{}{"id": "/subscriptions/s/vaults/kv1", "name": "kv1", "type": "microsoft.keyvault/vaults", "properties": map[string]interface{}{}}}, nil
+		case "ips":
+			return []interface{}{map[string]interface{}{"id": "/subscriptions/s/publicIPAddresses/ip1", "ipAddress": "203.0.113.10"}}, nil
+		}
+		return nil, errors.New("query failed")
+	}
+
+	errs := collectionErrorLog{}
+	findings := runARGRules(cfg.NewLogger(), &errs, argRules, query)
+	require.Len(t, findings, 2, "a failed query does not stop the other rules")
+	require.Len(t, errs.list(), 1)
+	assert.Equal(t, "broken", errs.list()[0].Scope)
+
+	vault := findings[0].(map[string]interface{})
+	assert.Equal(t, "keyvault-access-policies", vault["type"])
+	assert.Equal(t, "High", vault["severity"])
+	assert.Equal(t, []string{"keyvault"}, vault["labels"])
+	assert.Equal(t, "microsoft.keyvault/vaults", vault["resourceType"])
+	assert.Equal(t, "Key vault uses access policies: kv1", vault["description"])
+	assert.NotContains(t, vault, "properties", "only the projected fields are kept")
+	assert.NotEmpty(t, vault["fingerprint"])
+
+	ip := findings[1].(map[string]interface{})
+	assert.Equal(t, "203.0.113.10", ip["ipAddress"], "every field is kept without a projection")
+	assert.Equal(t, []string{}, ip["labels"])
+
+	o := &ConsolidatedOutput{AzureAD: map[string]interface{}{"argRuleFindings": findings}}
+	assert.Len(t, consolidatedFindings(o, nil), 2, "ARG rule findings are reported with the other findings")
+}
@@ -0,0 +1,200 @@
+package iam
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/praetorian-inc/janus-framework/pkg/chain/cfg"
+)
+
+const (
+	// defaultARMMaxPages is used when arm-max-pages is unset or not positive
+	defaultARMMaxPages = 100
+	// armPageMaxAttempts bounds the requests made for one page
+	armPageMaxAttempts = 4
+)
+
+// armRetryBaseDelay is the backoff before the first retry of a page, doubled
+// for each further attempt unless the response carries Retry-After
+var armRetryBaseDelay = time.Second
+
+// armPaginationDataset is the collection_errors dataset of truncated ARM lists.
+// Its scope is the URL of the list call.
+const armPaginationDataset = "arm_pagination"
+
+// armPager reads every page of an ARM list call, following nextLink
+type armPager struct {
+	client   *http.Client
+	logger   *cfg.Logger
+	errs     *collectionErrorLog
+	maxPages int
+	maxItems int // --sample size; zero reads every item
+	// throttle, when set, is shared by every pager of a collection so one
+	// throttled response pauses all workers, not just the one that got it
+	throttle *armThrottle
+}
+
+// armThrottle holds requests back until the Retry-After of the last throttled
+// response has passed
+type armThrottle struct {
+	mu    sync.Mutex
+	until time.Time
+}
+
+// holdFor pauses requests for d, unless an earlier hold already lasts longer
+func (t *armThrottle) holdFor(d time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if until := time.Now().Add(d); until.After(t.until) {
+		t.until = until
+	}
+}
+
+// wait blocks until the current hold has passed
+func (t *armThrottle) wait(ctx context.Context) error {
+	t.mu.Lock()
+	delay := time.Until(t.until)
+	t.mu.Unlock()
+	if delay <= 0 {
+		return nil
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(delay):
+		return nil
+	}
+}
+
+type armPage struct {
+	Value    []interface{} `json:"value"`
+	NextLink string        `json:"nextLink"`
+}
+
+// armMaxPagesArg reads arm-max-pages, falling back to the default
+func armMaxPagesArg(arg any) int {
+	maxPages, err := cfg.As[int](arg)
+	if err != nil || maxPages <= 0 {
+		return defaultARMMaxPages
+	}
+	return maxPages
+}
+
+// collect returns the items of every page of url. A failure on the first page
+// is returned as an error. Later failures and the page cap stop pagination with
+// the items read so far, and the truncation is recorded in collection_errors so
+// partial results are never mistaken for complete ones.
+func (p *armPager) collect(ctx context.Context, accessToken, url string) ([]interface{}, error) {
+	allData := []interface{}{}
+	seenLinks := make(map[string]bool) // Detect circular nextLink references
+	pageCount := 0
+
+	for nextLink := url; nextLink != ""; {
+		if seenLinks[nextLink] {
+			p.logger.Warn("Detected circular nextLink reference, breaking pagination loop", "url", nextLink)
+			break
+		}
+		if pageCount >= p.maxPages {
+			p.logger.Warn("Reached maximum page limit for ARM pagination, results are partial", "maxPages", p.maxPages, "url", url)
+			p.errs.record(armPaginationDataset, url, fmt.Errorf("results are partial: stopped at the %d page limit (--arm-max-pages) with %d items read", p.maxPages, len(allData)))
+			break
+		}
+		seenLinks[nextLink] = true
+		pageCount++
+
+		p.logger.Debug("Fetching paginated ARM data", "page", pageCount, "url", nextLink)
+		page, err := p.getPage(ctx, accessToken, nextLink)
+		if err != nil {
+			if pageCount == 1 {
+				return nil, err
+			}
+			p.logger.Warn("ARM pagination failed, results are partial", "page", pageCount, "url", url, "error", err)
+			p.errs.record(armPaginationDataset, url, fmt.Errorf("results are partial: page %d failed after %d items were read: %w", pageCount, len(allData), err))
+			break
+		}
+
+		p.logger.Debug("Retrieved ARM data page", "page", pageCount, "items", len(page.Value), "hasNextLink", page.NextLink != "")
+		allData = append(allData, page.Value...)
+		nextLink = page.NextLink
+		if sampleReached(p.maxItems, len(allData)) {
+			allData = sampleObjects(allData, p.maxItems)
+			break
+		}
+
+		if nextLink != "" {
+			// Small delay to avoid throttling
+			time.Sleep(100 * time.Millisecond)
+		}
+	}
+
+	p.logger.Debug("ARM pagination completed", "totalPages", pageCount, "totalItems", len(allData))
+	return allData, nil
+}
+
+// getPage fetches one page, retrying network errors, 429 and 5xx responses
+// with exponential backoff or the Retry-After the service asked for
+func (p *armPager) getPage(ctx context.Context, accessToken, pageURL string) (*armPage, error) {
+	for attempt := 1; ; attempt++ {
+		if p.throttle != nil {
+			if err := p.throttle.wait(ctx); err != nil {
+				return nil, err
+			}
+		}
+		req, err := http.NewRequestWithContext(ctx, "GET", pageURL, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create request: %v", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+accessToken)
+		req.Header.Set("Accept", "application/json")
+
+		retryAfter := armRetryBaseDelay << (attempt - 1)
+		resp, err := p.client.Do(req)
+		switch {
+		case err != nil:
+			if ctx.Err() != nil || attempt >= armPageMaxAttempts {
+				return nil, fmt.Errorf("request failed after %d attempts: %v", attempt, err)
+			}
+			p.logger.Debug("ARM request failed, retrying", "attempt", attempt, "retry_after", retryAfter, "error", err)
+
+		case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+			if attempt >= armPageMaxAttempts {
+				apiErr := newAPIStatusError(resp)
+				resp.Body.Close()
+				return nil, fmt.Errorf("%d attempts: %w", attempt, apiErr)
+			}
+			resp.Body.Close()
+			if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds > 0 && seconds <= 120 {
+				retryAfter = time.Duration(seconds) * time.Second
+			}
+			if resp.StatusCode == http.StatusTooManyRequests && p.throttle != nil {
+				p.throttle.holdFor(retryAfter)
+			}
+			p.logger.Debug("ARM request throttled, retrying", "status", resp.StatusCode, "attempt", attempt, "retry_after", retryAfter)
+
+		case resp.StatusCode != http.StatusOK:
+			apiErr := newAPIStatusError(resp)
+			resp.Body.Close()
+			return nil, apiErr
+
+		default:
+			var page armPage
+			err = json.NewDecoder(resp.Body).Decode(&page)
+			resp.Body.Close()
+			if err != nil {
+				return nil, fmt.Errorf("failed to decode response: %v", err)
+			}
+			return &page, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(retryAfter):
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package iam
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/praetorian-inc/janus-framework/pkg/chain/cfg"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newARMPageServer serves pages of one item each, following ?page=N nextLinks
+// until lastPage. failures lists, per page, the status codes returned before
+// the page succeeds.
+func newARMPageServer(t *testing.T, lastPage int, failures map[int][]int) *httptest.Server {
+	var mu sync.Mutex
+	requests := make(map[int]int)
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		if page == 0 {
+			page = 1
+		}
+		mu.Lock()
+		requests[page]++
+		attempt := requests[page]
+		mu.Unlock()
+		if codes := failures[page]; attempt <= len(codes) {
+			w.WriteHeader(codes[attempt-1])
+			return
+		}
+		body := map[string]interface{}{"value": []interface{}{map[string]interface{}{"id": fmt.Sprintf("item-%d", page)}}}
+		if page < lastPage {
+			body["nextLink"] = fmt.Sprintf("%s/list?page=%d", server.URL, page+1)
+		}
+		json.NewEncoder(w).Encode(body)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestARMPagerCollect(t *testing.T) {
+	defer func(delay time.Duration) { armRetryBaseDelay = delay }(armRetryBaseDelay)
+	armRetryBaseDelay = time.Millisecond
+
+	t.Run("retries transient failures", func(t *testing.T) {
+		server := newARMPageServer(t, 3, map[int][]int{2: {http.StatusTooManyRequests, http.StatusBadGateway}})
+		var errs collectionErrorLog
+		pager := &armPager{client: server.Client(), logger: cfg.NewLogger(), errs: &errs, maxPages: defaultARMMaxPages}
+
+		items, err := pager.collect(context.Background(), "token", server.URL+"/list")
+		require.NoError(t, err)
+		assert.Len(t, items, 3)
+		assert.Empty(t, errs.list())
+	})
+
+	t.Run("records the page cap", func(t *testing.T) {
+		server := newARMPageServer(t, 5, nil)
+		var errs collectionErrorLog
+		pager := &armPager{client: server.Client(), logger: cfg.NewLogger(), errs: &errs, maxPages: 2}
+
+		items, err := pager.collect(context.Background(), "token", server.URL+"/list")
+		require.NoError(t, err)
+		assert.Len(t, items, 2)
+		require.Len(t, errs.list(), 1)
+		entry := errs.list()[0]
+		assert.Equal(t, armPaginationDataset, entry.Dataset)
+		assert.Equal(t, server.URL+"/list", entry.Scope)
+		assert.Contains(t, entry.Message, "results are partial")
+	})
+
+	t.Run("does not record a list that ends at the cap", func(t *testing.T) {
+		server := newARMPageServer(t, 2, nil)
+		var errs collectionErrorLog
+		pager := &armPager{client: server.Client(), logger: cfg.NewLogger(), errs: &errs, maxPages: 2}
+
+		items, err := pager.collect(context.Background(), "token", server.URL+"/list")
+		require.NoError(t, err)
+		assert.Len(t, items, 2)
+		assert.Empty(t, errs.list())
+	})
+
+	t.Run("keeps earlier pages when a later page fails", func(t *testing.T) {
+		unavailable := make([]int, armPageMaxAttempts)
+		for i := range unavailable {
+			unavailable[i] = http.StatusServiceUnavailable
+		}
+		server := newARMPageServer(t, 3, map[int][]int{3: unavailable})
+		var errs collectionErrorLog
+		pager := &armPager{client: server.Client(), logger: cfg.NewLogger(), errs: &errs, maxPages: defaultARMMaxPages}
+
+		items, err := pager.collect(context.Background(), "token", server.URL+"/list")
+		require.NoError(t, err)
+		assert.Len(t, items, 2)
+		require.Len(t, errs.list(), 1)
+		entry := errs.list()[0]
+		assert.Equal(t, http.StatusServiceUnavailable, entry.StatusCode)
+		assert.True(t, strings.HasPrefix(entry.Message, "results are partial: page 3 failed"))
+	})
+
+	t.Run("returns first page errors", func(t *testing.T) {
+		server := newARMPageServer(t, 3, map[int][]int{1: {http.StatusForbidden}})
+		var errs collectionErrorLog
+		pager := &armPager{client: server.Client(), logger: cfg.NewLogger(), errs: &errs, maxPages: defaultARMMaxPages}
+
+		_, err := pager.collect(context.Background(), "token", server.URL+"/list")
+		require.Error(t, err)
+		assert.Empty(t, errs.list(), "the caller records failures of the whole dataset")
+	})
+}
+
+func TestARMThrottle(t *testing.T) {
+	var throttle armThrottle
+	require.NoError(t, throttle.wait(context.Background()), "an unused throttle does not block")
+
+	throttle.holdFor(50 * time.Millisecond)
+	throttle.holdFor(time.Millisecond) // a shorter hold does not cut the longer one short
+	start := time.Now()
+	require.NoError(t, throttle.wait(context.Background()))
+	assert.GreaterOrEqual(t, time.Since(start), 40*time.Millisecond)
+
+	throttle.holdFor(time.Minute)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.ErrorIs(t, throttle.wait(ctx), context.Canceled)
+}
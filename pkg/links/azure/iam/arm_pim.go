@@ -0,0 +1,185 @@
+package iam
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/praetorian-inc/janus-framework/pkg/chain/cfg"
+)
+
+// armPIMAPIVersion is the Microsoft.Authorization role schedule API version
+const armPIMAPIVersion = "2020-10-01"
+
+// armPIMCollection is one kind of PIM for Azure resources schedule instance.
+// PIM for Azure resources is separate from directory role PIM: its eligible
+// and active role assignments live in ARM, not Graph or the mspim API.
+type armPIMCollection struct {
+	section  string // pim section it fills, also the dataset of its collection errors
+	resource string // Microsoft.Authorization resource type listed per subscription
+}
+
+var armPIMCollections = []armPIMCollection{
+	{section: "arm_eligible_assignments", resource: "roleEligibilityScheduleInstances"},
+	{section: "arm_active_assignments", resource: "roleAssignmentScheduleInstances"},
+}
+
+// armPIMURL is the ARM path listing the schedule instances that apply at,
+// above or below a subscription, so management group and resource scoped
+// ones are included
+func armPIMURL(subscriptionID, resource string) string {
+	return fmt.Sprintf("/subscriptions/%s/providers/Microsoft.Authorization/%s?api-version=%s",
+		subscriptionID, resource, armPIMAPIVersion)
+}
+
+// newARMPIMRecord flattens a role schedule instance into a pim entry
+func newARMPIMRecord(subscriptionID string, instance map[string]interface{}) map[string]interface{} {
+	record := map[string]interface{}{
+		"id":             instance["id"],
+		"name":           instance["name"],
+		"subscriptionId": subscriptionID,
+	}
+	properties, _ := instance["properties"].(map[string]interface{})
+	for _, key := range []string{"principalId", "principalType", "roleDefinitionId", "memberType", "status", "startDateTime", "endDateTime", "assignmentType", "linkedRoleEligibilityScheduleInstanceId"} {
+		if value, ok := properties[key]; ok && value != nil {
+			record[key] = value
+		}
+	}
+	if scope, ok := properties["scope"].(string); ok {
+		// The tenant root scope "/" would normalize to an empty string
+		if scope = normalizeScope(scope); scope == "" {
+			scope = "/"
+		}
+		record["scope"] = scope
+	}
+	return record
+}
+
+// mergeARMPIMInstances adds the instances listed for one subscription to
+// records, skipping those already seen through another subscription. Instances
+// inherited from a management group are listed by every subscription under it.
+func mergeARMPIMInstances(records []interface{}, seen map[string]bool, subscriptionID string, instances []interface{}) []interface{} {
+	for _, instance := range instances {
+		instanceMap, ok := instance.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		id, _ := instanceMap["id"].(string)
+		if id != "" {
+			if seen[strings.ToLower(id)] {
+				continue
+			}
+			seen[strings.ToLower(id)] = true
+		}
+		records = append(records, newARMPIMRecord(subscriptionID, instanceMap))
+	}
+	return records
+}
+
+// collectARMPIMData lists PIM for Azure resources eligible and active role
+// assignments for each subscription into pimData, recording failures per
+// subscription. Reading them requires Microsoft.Authorization/*/read on the
+// subscription, which Reader grants.
+func collectARMPIMData(logger *cfg.Logger, errs *collectionErrorLog, subscriptionIDs []string, fetch func(url string) ([]interface{}, error), pimData map[string]interface{}) {
+	for _, collection := range armPIMCollections {
+		records := []interface{}{}
+		seen := make(map[string]bool)
+		for _, subscriptionID := range subscriptionIDs {
+			instances, err := fetch(armPIMURL(subscriptionID, collection.resource))
+			if err != nil {
+				logger.Error("Failed to collect PIM for Azure resources", "collection", collection.resource, "subscription", subscriptionID, "error", err)
+				errs.record(collection.section, subscriptionID, err)
+				continue
+			}
+			records = mergeARMPIMInstances(records, seen, subscriptionID, instances)
+		}
+		logger.Info("Collected PIM for Azure resources", "collection", collection.resource, "count", len(records))
+		pimData[collection.section] = records
+	}
+}
+
+// collectARMPIMDataSDK collects PIM for Azure resources with the SDK
+// collector's credential
+func (l *SDKComprehensiveCollectorLink) collectARMPIMDataSDK(ctx context.Context, subscriptionIDs []string, pimData map[string]interface{}) {
+	token, err := l.getManagementAccessToken(ctx)
+	if err != nil {
+		l.Logger.Error("Failed to collect PIM for Azure resources", "error", err)
+		for _, collection := range armPIMCollections {
+			l.collectionErrors.record(collection.section, "tenant", err)
+		}
+		return
+	}
+	collectARMPIMData(l.Logger, &l.collectionErrors, subscriptionIDs, func(url string) ([]interface{}, error) {
+		return l.collectPaginatedARMDataSDK(ctx, token, url)
+	}, pimData)
+}
+
+// buildARMEligibilityFindings reports principals eligible for a privileged
+// Azure role through PIM for Azure resources. Eligibility is not standing
+// access, but the principal can activate the role on its own whenever the
+// role's activation requirements allow, so it belongs in any review of who
+// can control a subscription. Eligibility at the tenant root or a management
+// group reaches every subscription below it and ranks highest.
+func buildARMEligibilityFindings(o *ConsolidatedOutput) []interface{} {
+	findings := []interface{}{}
+	principals := indexReportPrincipals(o)
+
+	eligible, _ := o.PIM["arm_eligible_assignments"].([]interface{})
+	for _, assignment := range eligible {
+		a, ok := assignment.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		principalID, roleGUID, scope := rbacAssignmentFields(a)
+		roleName, privileged := highPrivilegeRBACRoles[roleGUID]
+		if principalID == "" || !privileged {
+			continue
+		}
+		if status, _ := a["status"].(string); status != "" && !strings.EqualFold(status, "Provisioned") {
+			continue
+		}
+
+		scopeLevel := rbacScopeLevel(scope)
+		severity := "Low"
+		switch scopeLevel {
+		case "root", "managementGroup":
+			severity = "High"
+		case "subscription":
+			severity = "Medium"
+		}
+		endDateTime, _ := a["endDateTime"].(string)
+
+		finding := reportRow(principals, principalID)
+		if finding["principalType"] == "Unknown" {
+			if principalType, _ := a["principalType"].(string); principalType != "" {
+				finding["principalType"] = principalType
+			}
+		}
+		finding["type"] = "PrivilegedARMEligibility"
+		finding["severity"] = severity
+		expiry := "with no expiry"
+		if endDateTime != "" {
+			expiry = "until " + endDateTime
+		}
+		finding["description"] = fmt.Sprintf("%s is eligible to activate %s at %s %s",
+			finding["principalName"], roleName, scope, expiry)
+		finding["roleDefinitionId"] = roleGUID
+		finding["roleName"] = roleName
+		finding["scope"] = scope
+		finding["scopeLevel"] = scopeLevel
+		finding["memberType"] = a["memberType"]
+		finding["endDateTime"] = endDateTime
+		finding["permanent"] = endDateTime == ""
+		finding["assignmentId"] = a["id"]
+		findings = append(findings, finding)
+	}
+
+	sortFindings(findings, "scope", "principalName")
+	return findings
+}
+
+// logARMEligibilityFindings reports principals eligible for privileged Azure roles
+func logARMEligibilityFindings(logger *cfg.Logger, findings []interface{}) {
+	logFindings(logger, findings, "🚨 %d PIM eligible assignments grant privileged Azure roles", "Privileged Azure role eligibility",
+		"principal", "principalName", "role", "roleName", "scope", "scope", "severity", "severity")
+}
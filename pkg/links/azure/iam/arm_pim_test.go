@@ -0,0 +1,104 @@
+package iam
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/praetorian-inc/janus-framework/pkg/chain/cfg"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func armScheduleInstance(id, principalID, roleGUID, scope, endDateTime string) map[string]interface{} {
+	return map[string]interface{}{
+		"id":   id,
+		"name": id[strings.LastIndex(id, "/")+1:],
+		"properties": map[string]interface{}{
+			"principalId":      principalID,
+			"principalType":    "User",
+			"roleDefinitionId": "/subscriptions/sub-a/providers/Microsoft.Authorization/roleDefinitions/" + roleGUID,
+			"scope":            scope,
+			"memberType":       "Direct",
+			"status":           "Provisioned",
+			"endDateTime":      endDateTime,
+		},
+	}
+}
+
+func TestCollectARMPIMData(t *testing.T) {
+	mgEligibility := armScheduleInstance("/providers/Microsoft.Management/managementGroups/corp/providers/Microsoft.Authorization/roleEligibilityScheduleInstances/mg-owner",
+		"u-alice", "8e3af657-a8ff-443c-a75c-2fe8c4bcb635", "/providers/Microsoft.Management/managementGroups/corp", "")
+	subEligibility := armScheduleInstance("/subscriptions/sub-b/providers/Microsoft.Authorization/roleEligibilityScheduleInstances/sub-b-uaa",
+		"u-bob", "18d7d88d-d35e-4fb5-a5c3-7773c20a72d9", "/subscriptions/SUB-B/", "2030-01-01T00:00:00Z")
+
+	fetch := func(url string) ([]interface{}, error) {
+		switch {
+		case strings.Contains(url, "roleAssignmentScheduleInstances"):
+			return nil, errors.New("forbidden")
+		case strings.Contains(url, "/subscriptions/sub-a/"):
+			return []interface{}{mgEligibility}, nil
+		}
+		return []interface{}{mgEligibility, subEligibility}, nil
+	}
+
+	pimData := map[string]interface{}{}
+	var errs collectionErrorLog
+	collectARMPIMData(cfg.NewLogger(), &errs, []string{"sub-a", "sub-b"}, fetch, pimData)
+
+	eligible := pimData["arm_eligible_assignments"].([]interface{})
+	require.Len(t, eligible, 2, "management group eligibility listed by both subscriptions is kept once")
+	first := eligible[0].(map[string]interface{})
+	assert.Equal(t, "sub-a", first["subscriptionId"])
+	assert.Equal(t, "/providers/microsoft.management/managementgroups/corp", first["scope"])
+	assert.Equal(t, "/subscriptions/sub-b", eligible[1].(map[string]interface{})["scope"])
+
+	assert.Empty(t, pimData["arm_active_assignments"])
+	require.Len(t, errs.list(), 2, "failures are recorded per subscription")
+	for _, entry := range errs.list() {
+		assert.Equal(t, "arm_active_assignments", entry.Dataset)
+	}
+}
+
+func TestBuildARMEligibilityFindings(t *testing.T) {
+	output := &ConsolidatedOutput{
+		AzureAD: map[string]interface{}{
+			"users": []interface{}{
+				map[string]interface{}{"id": "u-alice", "userPrincipalName": "alice@contoso.com"},
+				map[string]interface{}{"id": "u-bob", "userPrincipalName": "bob@contoso.com"},
+			},
+		},
+		PIM: map[string]interface{}{
+			"arm_eligible_assignments": []interface{}{
+				newARMPIMRecord("sub-a", armScheduleInstance("/subscriptions/sub-a/providers/Microsoft.Authorization/roleEligibilityScheduleInstances/reader",
+					"u-bob", "acdd72a7-3385-48ef-bd42-f606fba81ae7", "/subscriptions/sub-a", "")),
+				newARMPIMRecord("sub-a", armScheduleInstance("/subscriptions/sub-a/providers/Microsoft.Authorization/roleEligibilityScheduleInstances/rg-contrib",
+					"u-bob", "b24988ac-6180-42a0-ab88-20f7382dd24c", "/subscriptions/sub-a/resourceGroups/app", "2030-01-01T00:00:00Z")),
+				newARMPIMRecord("sub-a", armScheduleInstance("/subscriptions/sub-a/providers/Microsoft.Authorization/roleEligibilityScheduleInstances/sub-owner",
+					"u-alice", "8e3af657-a8ff-443c-a75c-2fe8c4bcb635", "/subscriptions/sub-a", "")),
+				newARMPIMRecord("sub-a", armScheduleInstance("/providers/Microsoft.Authorization/roleEligibilityScheduleInstances/root-uaa",
+					"u-gone", "18d7d88d-d35e-4fb5-a5c3-7773c20a72d9", "/", "")),
+			},
+		},
+	}
+
+	findings := buildARMEligibilityFindings(output)
+	require.Len(t, findings, 3, "Reader is not a privileged role")
+
+	root := findings[0].(map[string]interface{})
+	assert.Equal(t, "High", root["severity"])
+	assert.Equal(t, "/", root["scope"])
+	assert.Equal(t, "root", root["scopeLevel"])
+	assert.Equal(t, "User", root["principalType"], "principals missing from the dump keep the type ARM reported")
+
+	owner := findings[1].(map[string]interface{})
+	assert.Equal(t, "Medium", owner["severity"])
+	assert.Equal(t, "alice@contoso.com", owner["principalName"])
+	assert.Equal(t, "Owner", owner["roleName"])
+	assert.Equal(t, true, owner["permanent"])
+
+	rg := findings[2].(map[string]interface{})
+	assert.Equal(t, "Low", rg["severity"])
+	assert.Equal(t, "Contributor", rg["roleName"])
+	assert.Equal(t, false, rg["permanent"])
+}
@@ -0,0 +1,228 @@
+package iam
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/praetorian-inc/nebula/internal/message"
+)
+
+// auditLogPageSize is the largest $top accepted by both audit log endpoints
+const auditLogPageSize = 999
+
+// auditLogMaxRetries bounds retries of a single page on throttling or server errors
+const auditLogMaxRetries = 5
+
+// AuditLogs holds Azure AD sign-in and directory audit log entries collected
+// for incident response. It is only present when a log window was requested.
+type AuditLogs struct {
+	Window          AuditLogWindow `json:"window"`
+	SignIns         []interface{}  `json:"sign_ins"`
+	DirectoryAudits []interface{}  `json:"directory_audits"`
+}
+
+// AuditLogWindow describes which log entries were requested
+type AuditLogWindow struct {
+	Start        string `json:"start"`
+	End          string `json:"end"`
+	FailuresOnly bool   `json:"failures_only"`
+	User         string `json:"user,omitempty"`
+}
+
+// newAuditLogWindow builds a log window from the --log-start/--log-end
+// arguments. It returns nil when no window was requested. A missing end
+// defaults to now.
+func newAuditLogWindow(start, end string, failuresOnly bool, user string, now time.Time) (*AuditLogWindow, error) {
+	if start == "" {
+		if end != "" {
+			return nil, fmt.Errorf("log-end requires log-start")
+		}
+		return nil, nil
+	}
+
+	startTime, err := parseLogTime(start)
+	if err != nil {
+		return nil, fmt.Errorf("invalid log-start: %v", err)
+	}
+	endTime := now.UTC()
+	if end != "" {
+		endTime, err = parseLogTime(end)
+		if err != nil {
+			return nil, fmt.Errorf("invalid log-end: %v", err)
+		}
+	}
+	if !endTime.After(startTime) {
+		return nil, fmt.Errorf("log-end (%s) must be after log-start (%s)", endTime.Format(time.RFC3339), startTime.Format(time.RFC3339))
+	}
+
+	return &AuditLogWindow{
+		Start:        startTime.Format(time.RFC3339),
+		End:          endTime.Format(time.RFC3339),
+		FailuresOnly: failuresOnly,
+		User:         strings.TrimSpace(user),
+	}, nil
+}
+
+// parseLogTime accepts either an RFC3339 timestamp or a plain date (midnight UTC)
+func parseLogTime(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t.UTC(), nil
+	}
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%q is not RFC3339 or YYYY-MM-DD", value)
+	}
+	return t, nil
+}
+
+// signInFilter builds the OData $filter for /auditLogs/signIns
+func (w *AuditLogWindow) signInFilter() string {
+	clauses := []string{
+		fmt.Sprintf("createdDateTime ge %s", w.Start),
+		fmt.Sprintf("createdDateTime le %s", w.End),
+	}
+	if w.FailuresOnly {
+		clauses = append(clauses, "status/errorCode ne 0")
+	}
+	if w.User != "" {
+		clauses = append(clauses, fmt.Sprintf("userPrincipalName eq '%s'", odataEscape(w.User)))
+	}
+	return strings.Join(clauses, " and ")
+}
+
+// directoryAuditFilter builds the OData $filter for /auditLogs/directoryAudits
+func (w *AuditLogWindow) directoryAuditFilter() string {
+	clauses := []string{
+		fmt.Sprintf("activityDateTime ge %s", w.Start),
+		fmt.Sprintf("activityDateTime le %s", w.End),
+	}
+	if w.FailuresOnly {
+		clauses = append(clauses, "result eq 'failure'")
+	}
+	if w.User != "" {
+		clauses = append(clauses, fmt.Sprintf("initiatedBy/user/userPrincipalName eq '%s'", odataEscape(w.User)))
+	}
+	return strings.Join(clauses, " and ")
+}
+
+// odataEscape escapes a string literal for use inside single quotes in an OData filter
+func odataEscape(value string) string {
+	return strings.ReplaceAll(value, "'", "''")
+}
+
+// collectAuditLogs collects sign-in and directory audit logs for the window.
+// Each endpoint is collected independently so a missing AuditLog.Read.All
+// permission or Entra ID P1 license for sign-ins does not discard the other.
+func (l *IAMComprehensiveCollectorLink) collectAuditLogs(accessToken string, window *AuditLogWindow) *AuditLogs {
+	logs := &AuditLogs{
+		Window:          *window,
+		SignIns:         []interface{}{},
+		DirectoryAudits: []interface{}{},
+	}
+
+	signIns, err := l.collectAuditLogEndpoint(accessToken, "/auditLogs/signIns", window.signInFilter())
+	if err != nil {
+		l.Logger.Warn("Failed to collect sign-in logs, continuing without them", "error", err)
+		message.Info("Warning: Failed to collect sign-in logs (requires AuditLog.Read.All and Entra ID P1): %v", err)
+		l.collectionErrors.record("signIns", "tenant", err)
+	} else {
+		logs.SignIns = signIns
+	}
+
+	audits, err := l.collectAuditLogEndpoint(accessToken, "/auditLogs/directoryAudits", window.directoryAuditFilter())
+	if err != nil {
+		l.Logger.Warn("Failed to collect directory audit logs, continuing without them", "error", err)
+		message.Info("Warning: Failed to collect directory audit logs (requires AuditLog.Read.All): %v", err)
+		l.collectionErrors.record("directoryAudits", "tenant", err)
+	} else {
+		logs.DirectoryAudits = audits
+	}
+
+	return logs
+}
+
+// collectAuditLogEndpoint pages through an audit log endpoint. Log volume can
+// be large, so pages are requested at the maximum size and throttled pages are
+// retried honoring Retry-After instead of failing the whole collection.
+func (l *IAMComprehensiveCollectorLink) collectAuditLogEndpoint(accessToken, endpoint, filter string) ([]interface{}, error) {
+	params := url.Values{}
+	params.Set("$filter", filter)
+	params.Set("$top", strconv.Itoa(auditLogPageSize))
+	nextLink := l.cloud.graphURL(graphV1, endpoint) + "?" + params.Encode()
+
+	allData := []interface{}{}
+	pageCount := 0
+	for nextLink != "" {
+		pageCount++
+		page, err := l.getAuditLogPage(accessToken, nextLink)
+		if err != nil {
+			return nil, fmt.Errorf("%s page %d: %v", endpoint, pageCount, err)
+		}
+
+		allData = append(allData, page.Value...)
+		nextLink = page.NextLink
+		l.Logger.Debug("Retrieved audit log page", "endpoint", endpoint, "page", pageCount, "items", len(page.Value), "total", len(allData))
+
+		if nextLink != "" {
+			time.Sleep(100 * time.Millisecond)
+		}
+	}
+
+	message.Info("Collected %d entries from %s", len(allData), endpoint)
+	return allData, nil
+}
+
+type auditLogPage struct {
+	Value    []interface{} `json:"value"`
+	NextLink string        `json:"@odata.nextLink"`
+}
+
+// getAuditLogPage fetches a single page, retrying on 429 and 5xx responses
+func (l *IAMComprehensiveCollectorLink) getAuditLogPage(accessToken, pageURL string) (*auditLogPage, error) {
+	for attempt := 1; ; attempt++ {
+		req, err := http.NewRequestWithContext(l.Context(), "GET", pageURL, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create request: %v", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+accessToken)
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := l.httpClient.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("request failed: %v", err)
+		}
+
+		if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+			resp.Body.Close()
+			if attempt >= auditLogMaxRetries {
+				return nil, fmt.Errorf("API call failed with status %d after %d attempts", resp.StatusCode, attempt)
+			}
+			retryAfter := time.Duration(attempt) * time.Second
+			if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds > 0 && seconds <= 120 {
+				retryAfter = time.Duration(seconds) * time.Second
+			}
+			l.Logger.Debug("Audit log request throttled, retrying", "status", resp.StatusCode, "attempt", attempt, "retry_after", retryAfter)
+			time.Sleep(retryAfter)
+			continue
+		}
+
+		if resp.StatusCode != http.StatusOK {
+			apiErr := newAPIStatusError(resp)
+			resp.Body.Close()
+			return nil, apiErr
+		}
+
+		var page auditLogPage
+		err = json.NewDecoder(resp.Body).Decode(&page)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode response: %v", err)
+		}
+		return &page, nil
+	}
+}
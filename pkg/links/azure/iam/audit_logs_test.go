@@ -0,0 +1,98 @@
+package iam
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewAuditLogWindow(t *testing.T) {
+	now := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	window, err := newAuditLogWindow("", "", false, "", now)
+	require.NoError(t, err)
+	assert.Nil(t, window, "no window should be built when log-start is unset")
+
+	_, err = newAuditLogWindow("", "2024-03-01", false, "", now)
+	assert.Error(t, err, "log-end without log-start should be rejected")
+
+	window, err = newAuditLogWindow("2024-03-01", "", true, " alice@contoso.com ", now)
+	require.NoError(t, err)
+	assert.Equal(t, "2024-03-01T00:00:00Z", window.Start)
+	assert.Equal(t, "2024-03-10T12:00:00Z", window.End, "log-end should default to now")
+	assert.Equal(t, "alice@contoso.com", window.User)
+
+	window, err = newAuditLogWindow("2024-03-01T08:00:00+02:00", "2024-03-02T00:00:00Z", false, "", now)
+	require.NoError(t, err)
+	assert.Equal(t, "2024-03-01T06:00:00Z", window.Start, "timestamps should be normalized to UTC")
+
+	_, err = newAuditLogWindow("2024-03-02", "2024-03-01", false, "", now)
+	assert.Error(t, err, "an inverted window should be rejected")
+
+	_, err = newAuditLogWindow("last tuesday", "", false, "", now)
+	assert.Error(t, err)
+}
+
+func TestAuditLogFilters(t *testing.T) {
+	window := &AuditLogWindow{Start: "2024-03-01T00:00:00Z", End: "2024-03-02T00:00:00Z"}
+	assert.Equal(t, "createdDateTime ge 2024-03-01T00:00:00Z and createdDateTime le 2024-03-02T00:00:00Z", window.signInFilter())
+	assert.Equal(t, "activityDateTime ge 2024-03-01T00:00:00Z and activityDateTime le 2024-03-02T00:00:00Z", window.directoryAuditFilter())
+
+	window.FailuresOnly = true
+	window.User = "o'brien@contoso.com"
+	assert.Equal(t,
+		"createdDateTime ge 2024-03-01T00:00:00Z and createdDateTime le 2024-03-02T00:00:00Z and status/errorCode ne 0 and userPrincipalName eq 'o''brien@contoso.com'",
+		window.signInFilter())
+	assert.Equal(t,
+		"activityDateTime ge 2024-03-01T00:00:00Z and activityDateTime le 2024-03-02T00:00:00Z and result eq 'failure' and initiatedBy/user/userPrincipalName eq 'o''brien@contoso.com'",
+		window.directoryAuditFilter())
+}
+
+// auditLogFixture pages sign-ins across two responses and denies directory audits
+type auditLogFixture struct {
+	requests []string
+}
+
+func (f *auditLogFixture) RoundTrip(req *http.Request) (*http.Response, error) {
+	f.requests = append(f.requests, req.URL.String())
+	switch {
+	case strings.HasSuffix(req.URL.Path, "/auditLogs/signIns") && req.URL.Query().Get("$skiptoken") == "":
+		return jsonResponse(map[string]interface{}{
+			"value":           []interface{}{map[string]interface{}{"id": "signin-1"}},
+			"@odata.nextLink": "https://graph.microsoft.com/v1.0/auditLogs/signIns?$skiptoken=page2",
+		})
+	case strings.HasSuffix(req.URL.Path, "/auditLogs/signIns"):
+		return jsonResponse(map[string]interface{}{
+			"value": []interface{}{map[string]interface{}{"id": "signin-2"}},
+		})
+	}
+	return &http.Response{StatusCode: http.StatusForbidden, Body: io.NopCloser(strings.NewReader("{}")), Header: make(http.Header)}, nil
+}
+
+func TestCollectAuditLogs(t *testing.T) {
+	fixture := &auditLogFixture{}
+	l := NewIAMComprehensiveCollectorLink().(*IAMComprehensiveCollectorLink)
+	l.httpClient = &http.Client{Transport: fixture}
+
+	window := &AuditLogWindow{Start: "2024-03-01T00:00:00Z", End: "2024-03-02T00:00:00Z", FailuresOnly: true}
+	logs := l.collectAuditLogs("fixture-token", window)
+
+	require.Len(t, logs.SignIns, 2, "sign-ins should be collected across pages")
+	assert.NotNil(t, logs.DirectoryAudits, "a denied endpoint should leave an empty section rather than nil")
+	assert.Empty(t, logs.DirectoryAudits)
+	assert.Equal(t, *window, logs.Window)
+
+	require.NotEmpty(t, fixture.requests)
+	assert.Contains(t, fixture.requests[0], "%24top=999")
+	assert.Contains(t, fixture.requests[0], "status%2FerrorCode+ne+0")
+
+	output := &ConsolidatedOutput{AuditLogs: logs}
+	summary := output.Summarize()
+	assert.Equal(t, 2, summary.TotalAuditLogEntries)
+	assert.Equal(t, 2, summary.TotalObjects)
+}
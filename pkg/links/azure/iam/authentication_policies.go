@@ -0,0 +1,270 @@
+package iam
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/praetorian-inc/janus-framework/pkg/chain/cfg"
+)
+
+// authenticationMethodsPolicyEndpoint returns the tenant authentication methods
+// policy, with each method's configuration inlined
+const authenticationMethodsPolicyEndpoint = "/policies/authenticationMethodsPolicy"
+
+// tokenLifetimePoliciesEndpoint lists the configurable token lifetime policies
+const tokenLifetimePoliciesEndpoint = "/policies/tokenLifetimePolicies"
+
+// maxAccessTokenLifetime is the longest access token lifetime not reported.
+// Entra issues 60-90 minute access tokens by default, so anything longer
+// widens the window in which a stolen token stays usable.
+const maxAccessTokenLifetime = 90 * time.Minute
+
+// fetchGraphObject reads a single, unpaginated Graph object
+func fetchGraphObject(ctx context.Context, client *http.Client, accessToken, objectURL string) (map[string]interface{}, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", objectURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %v", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		return nil, newAPIStatusError(resp)
+	}
+
+	var object map[string]interface{}
+	if err := json.NewDecoder(resp.Body).Decode(&object); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %v", err)
+	}
+	return object, nil
+}
+
+// authenticationMethodConfigurations pulls the per-method configurations out of
+// an authentication methods policy
+func authenticationMethodConfigurations(policy map[string]interface{}) []interface{} {
+	configurations, _ := policy["authenticationMethodConfigurations"].([]interface{})
+	if configurations == nil {
+		return []interface{}{}
+	}
+	return configurations
+}
+
+// collectAuthenticationMethodConfigurations reads the tenant authentication
+// methods policy
+func (l *IAMComprehensiveCollectorLink) collectAuthenticationMethodConfigurations(accessToken string) ([]interface{}, error) {
+	policy, err := fetchGraphObject(l.Context(), l.httpClient, accessToken, l.cloud.graphURL(graphV1, authenticationMethodsPolicyEndpoint))
+	if err != nil {
+		return nil, err
+	}
+	return authenticationMethodConfigurations(policy), nil
+}
+
+// collectAuthenticationMethodConfigurationsSDK reads the tenant authentication
+// methods policy
+func (l *SDKComprehensiveCollectorLink) collectAuthenticationMethodConfigurationsSDK(ctx context.Context) ([]interface{}, error) {
+	accessToken, err := l.getAccessToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+	policy, err := fetchGraphObject(ctx, l.httpClient, accessToken, l.cloud.graphURL(graphV1, authenticationMethodsPolicyEndpoint))
+	if err != nil {
+		return nil, err
+	}
+	return authenticationMethodConfigurations(policy), nil
+}
+
+// collectTokenLifetimePoliciesSDK lists the tenant token lifetime policies
+func (l *SDKComprehensiveCollectorLink) collectTokenLifetimePoliciesSDK(ctx context.Context) ([]interface{}, error) {
+	accessToken, err := l.getAccessToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return l.collectPaginatedGraphDataSDK(accessToken, graphV1, tokenLifetimePoliciesEndpoint)
+}
+
+// buildAuthenticationPolicyFindings flags weak authentication method settings
+// and long token lifetimes. SMS and voice are reported as High when no enabled
+// Conditional Access policy requires an authentication strength, because then
+// every MFA prompt in the tenant can be satisfied by a phishable method.
+func buildAuthenticationPolicyFindings(o *ConsolidatedOutput) []interface{} {
+	findings := []interface{}{}
+
+	configurations, _ := o.AzureAD["authenticationMethodConfigurations"].([]interface{})
+	strengthEnforced := conditionalAccessRequiresAuthenticationStrength(o)
+	for _, configuration := range configurations {
+		c, ok := configuration.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		method, _ := c["id"].(string)
+		state, _ := c["state"].(string)
+
+		switch strings.ToLower(method) {
+		case "sms", "voice":
+			if !strings.EqualFold(state, "enabled") {
+				continue
+			}
+			finding := map[string]interface{}{
+				"type":     "WeakMfaMethodEnabled",
+				"severity": "Medium",
+				"method":   method,
+				"description": fmt.Sprintf("%s is enabled as an authentication method; it can be intercepted through SIM swapping or phishing",
+					method),
+				"conditionalAccessEnforcesStrength": strengthEnforced,
+			}
+			if !strengthEnforced {
+				finding["severity"] = "High"
+				finding["description"] = fmt.Sprintf("%s is enabled as an authentication method and no enabled Conditional Access policy requires an authentication strength, so %s satisfies MFA everywhere",
+					method, method)
+			}
+			findings = append(findings, finding)
+
+		case "microsoftauthenticator":
+			if !strings.EqualFold(state, "enabled") {
+				findings = append(findings, map[string]interface{}{
+					"type":        "AuthenticatorDisabled",
+					"severity":    "Low",
+					"method":      method,
+					"description": "Microsoft Authenticator is not enabled, so users fall back to weaker MFA methods",
+				})
+				continue
+			}
+			if authenticatorFeatureState(c, "numberMatchingRequiredState") == "disabled" {
+				findings = append(findings, map[string]interface{}{
+					"type":        "AuthenticatorNumberMatchingDisabled",
+					"severity":    "Medium",
+					"method":      method,
+					"description": "Microsoft Authenticator push notifications do not require number matching, which leaves users open to MFA fatigue attacks",
+				})
+			}
+		}
+	}
+
+	tokenPolicies, _ := o.AzureAD["tokenLifetimePolicies"].([]interface{})
+	for _, policy := range tokenPolicies {
+		p, ok := policy.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		lifetime, ok := accessTokenLifetime(p)
+		if !ok || lifetime <= maxAccessTokenLifetime {
+			continue
+		}
+		isDefault, _ := p["isOrganizationDefault"].(bool)
+		displayName, _ := p["displayName"].(string)
+		scope := "the applications it is assigned to"
+		if isDefault {
+			scope = "every application in the tenant"
+		}
+		findings = append(findings, map[string]interface{}{
+			"type":                  "LongTokenLifetime",
+			"severity":              "Medium",
+			"policyId":              p["id"],
+			"policyName":            displayName,
+			"isOrganizationDefault": isDefault,
+			"accessTokenLifetime":   lifetime.String(),
+			"description": fmt.Sprintf("Token lifetime policy %s issues access tokens valid for %s to %s",
+				displayName, lifetime, scope),
+		})
+	}
+
+	sortFindings(findings, "type", "method", "policyName")
+	return findings
+}
+
+// conditionalAccessRequiresAuthenticationStrength reports whether any enabled
+// Conditional Access policy grants access only with an authentication strength
+func conditionalAccessRequiresAuthenticationStrength(o *ConsolidatedOutput) bool {
+	policies, _ := o.AzureAD["conditionalAccessPolicies"].([]interface{})
+	for _, policy := range policies {
+		p, ok := policy.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if state, _ := p["state"].(string); !strings.EqualFold(state, "enabled") {
+			continue
+		}
+		grantControls, _ := p["grantControls"].(map[string]interface{})
+		if strength, ok := grantControls["authenticationStrength"].(map[string]interface{}); ok && len(strength) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// authenticatorFeatureState reads a Microsoft Authenticator feature setting
+// state such as numberMatchingRequiredState, lowercased
+func authenticatorFeatureState(configuration map[string]interface{}, feature string) string {
+	featureSettings, _ := configuration["featureSettings"].(map[string]interface{})
+	setting, _ := featureSettings[feature].(map[string]interface{})
+	state, _ := setting["state"].(string)
+	return strings.ToLower(state)
+}
+
+// accessTokenLifetime reads AccessTokenLifetime from a token lifetime policy.
+// The policy definition is a JSON string such as
+// {"TokenLifetimePolicy":{"Version":1,"AccessTokenLifetime":"08:00:00"}}.
+func accessTokenLifetime(policy map[string]interface{}) (time.Duration, bool) {
+	definitions, _ := policy["definition"].([]interface{})
+	for _, definition := range definitions {
+		raw, ok := definition.(string)
+		if !ok {
+			continue
+		}
+		var parsed struct {
+			TokenLifetimePolicy struct {
+				AccessTokenLifetime string `json:"AccessTokenLifetime"`
+			} `json:"TokenLifetimePolicy"`
+		}
+		if err := json.Unmarshal([]byte(raw), &parsed); err != nil {
+			continue
+		}
+		if lifetime, ok := parseTimeSpan(parsed.TokenLifetimePolicy.AccessTokenLifetime); ok {
+			return lifetime, true
+		}
+	}
+	return 0, false
+}
+
+// parseTimeSpan parses a .NET TimeSpan of the form [d.]hh:mm:ss
+func parseTimeSpan(s string) (time.Duration, bool) {
+	var days int
+	if dot := strings.Index(s, "."); dot >= 0 && dot < strings.Index(s, ":") {
+		d, err := strconv.Atoi(s[:dot])
+		if err != nil {
+			return 0, false
+		}
+		days, s = d, s[dot+1:]
+	}
+	parts := strings.Split(s, ":")
+	if len(parts) != 3 {
+		return 0, false
+	}
+	var units [3]int
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return 0, false
+		}
+		units[i] = n
+	}
+	return time.Duration(days)*24*time.Hour + time.Duration(units[0])*time.Hour +
+		time.Duration(units[1])*time.Minute + time.Duration(units[2])*time.Second, true
+}
+
+// logAuthenticationPolicyFindings reports weak authentication method and token
+// lifetime settings
+func logAuthenticationPolicyFindings(logger *cfg.Logger, findings []interface{}) {
+	logFindings(logger, findings, "🚨 %d weak authentication method or token lifetime settings found", "Weak authentication setting",
+		"type", "type", "severity", "severity", "description", "description")
+}
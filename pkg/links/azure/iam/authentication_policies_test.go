@@ -0,0 +1,78 @@
+package iam
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const authenticationPoliciesFixture = `{
+  "azure_ad": {
+    "authenticationMethodConfigurations": [
+      {"@odata.type": "#microsoft.graph.smsAuthenticationMethodConfiguration", "id": "Sms", "state": "enabled"},
+      {"@odata.type": "#microsoft.graph.voiceAuthenticationMethodConfiguration", "id": "Voice", "state": "disabled"},
+      {
+        "@odata.type": "#microsoft.graph.microsoftAuthenticatorAuthenticationMethodConfiguration",
+        "id": "MicrosoftAuthenticator",
+        "state": "enabled",
+        "featureSettings": {"numberMatchingRequiredState": {"state": "disabled"}}
+      }
+    ],
+    "tokenLifetimePolicies": [
+      {"id": "tlp-1", "displayName": "Long sessions", "isOrganizationDefault": true, "definition": ["{\"TokenLifetimePolicy\":{\"Version\":1,\"AccessTokenLifetime\":\"1.00:00:00\"}}"]},
+      {"id": "tlp-2", "displayName": "Default-ish", "isOrganizationDefault": false, "definition": ["{\"TokenLifetimePolicy\":{\"Version\":1,\"AccessTokenLifetime\":\"01:00:00\"}}"]}
+    ],
+    "conditionalAccessPolicies": [
+      {"id": "ca-1", "state": "enabledForReportingButNotEnforced", "grantControls": {"authenticationStrength": {"id": "00000000-0000-0000-0000-000000000004"}}}
+    ]
+  },
+  "pim": {}
+}`
+
+func TestBuildAuthenticationPolicyFindings(t *testing.T) {
+	var output ConsolidatedOutput
+	require.NoError(t, json.Unmarshal([]byte(authenticationPoliciesFixture), &output))
+
+	findings := buildAuthenticationPolicyFindings(&output)
+	require.Len(t, findings, 3)
+
+	numberMatching := findings[1].(map[string]interface{})
+	assert.Equal(t, "AuthenticatorNumberMatchingDisabled", numberMatching["type"])
+
+	lifetime := findings[2].(map[string]interface{})
+	assert.Equal(t, "LongTokenLifetime", lifetime["type"])
+	assert.Equal(t, "24h0m0s", lifetime["accessTokenLifetime"])
+	assert.Equal(t, true, lifetime["isOrganizationDefault"])
+
+	// The report-only CA policy does not enforce its authentication strength
+	sms := findings[0].(map[string]interface{})
+	assert.Equal(t, "WeakMfaMethodEnabled", sms["type"])
+	assert.Equal(t, "Sms", sms["method"])
+	assert.Equal(t, "High", sms["severity"])
+
+	output.AzureAD["conditionalAccessPolicies"].([]interface{})[0].(map[string]interface{})["state"] = "enabled"
+	for _, finding := range buildAuthenticationPolicyFindings(&output) {
+		if f := finding.(map[string]interface{}); f["type"] == "WeakMfaMethodEnabled" {
+			assert.Equal(t, "Medium", f["severity"], "an enforced authentication strength limits where SMS counts as MFA")
+		}
+	}
+}
+
+func TestParseTimeSpan(t *testing.T) {
+	for input, want := range map[string]time.Duration{
+		"01:30:00":   90 * time.Minute,
+		"1.00:00:00": 24 * time.Hour,
+		"00:10:30":   10*time.Minute + 30*time.Second,
+	} {
+		got, ok := parseTimeSpan(input)
+		assert.True(t, ok, input)
+		assert.Equal(t, want, got, input)
+	}
+	for _, input := range []string{"", "90", "until-revoked", "1.xx:00:00"} {
+		_, ok := parseTimeSpan(input)
+		assert.False(t, ok, input)
+	}
+}
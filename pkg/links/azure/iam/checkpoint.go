@@ -0,0 +1,206 @@
+package iam
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/praetorian-inc/janus-framework/pkg/chain/cfg"
+	"github.com/praetorian-inc/nebula/internal/message"
+)
+
+// Collection phases saved to a --checkpoint. Each subscription's ARM data is
+// its own phase, named by subscriptionCheckpointPhase.
+const (
+	checkpointAzureAD             = "azure_ad"
+	checkpointAuditLogs           = "audit_logs"
+	checkpointPIM                 = "pim"
+	checkpointManagementGroups    = "management_groups"
+	checkpointManagementGroupRBAC = "management_group_rbac"
+	checkpointResourceLocks       = "resource_locks"
+)
+
+func subscriptionCheckpointPhase(subscriptionID string) string {
+	return "subscription-" + subscriptionID
+}
+
+// checkpointManifest is the --checkpoint file. It lists the completed phases,
+// whose data is kept in one file per phase next to the manifest so that a
+// finished subscription does not rewrite the whole tenant's data.
+type checkpointManifest struct {
+	SchemaVersion string                     `json:"schema_version"`
+	TenantID      string                     `json:"tenant_id"`
+	Phases        map[string]checkpointPhase `json:"phases"`
+}
+
+// checkpointPhase is one completed phase and the collection errors recorded
+// while it ran, which a resumed run restores with its data
+type checkpointPhase struct {
+	File             string            `json:"file"`
+	CompletedAt      string            `json:"completed_at"`
+	CollectionErrors []CollectionError `json:"collection_errors,omitempty"`
+}
+
+// collectionCheckpoint saves each completed collection phase of a
+// comprehensive collection and, when the run is restarted with the same
+// checkpoint, hands the saved data back so the phase is skipped
+type collectionCheckpoint struct {
+	path   string
+	dir    string
+	minify bool
+	logger *cfg.Logger
+
+	mu       sync.Mutex
+	manifest checkpointManifest
+}
+
+// checkpointDataDir is the directory holding the phase data of a checkpoint
+func checkpointDataDir(path string) string {
+	return path + ".phases"
+}
+
+// loadCollectionCheckpoint opens --checkpoint, resuming from it when the file
+// exists. It returns nil when path is empty, and a nil checkpoint ignores
+// every call. Phase data is written without indentation when minify is set.
+func loadCollectionCheckpoint(path, tenantID string, minify bool, logger *cfg.Logger) (*collectionCheckpoint, error) {
+	if path == "" {
+		return nil, nil
+	}
+	c := &collectionCheckpoint{
+		path:     path,
+		dir:      checkpointDataDir(path),
+		minify:   minify,
+		logger:   logger,
+		manifest: checkpointManifest{SchemaVersion: ConsolidatedSchemaVersion, TenantID: tenantID, Phases: make(map[string]checkpointPhase)},
+	}
+
+	raw, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read checkpoint: %v", err)
+	}
+	var manifest checkpointManifest
+	if err := json.Unmarshal(raw, &manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse checkpoint %s: %v", path, err)
+	}
+	if err := checkSchemaVersion(manifest.SchemaVersion); err != nil {
+		return nil, fmt.Errorf("checkpoint %s: %v", path, err)
+	}
+	if manifest.TenantID != "" && !strings.EqualFold(manifest.TenantID, tenantID) {
+		return nil, fmt.Errorf("checkpoint %s is from tenant %s, not %s", path, manifest.TenantID, tenantID)
+	}
+	if manifest.Phases != nil {
+		c.manifest.Phases = manifest.Phases
+	}
+	message.Info("Resuming from checkpoint %s with %d completed phases", path, len(c.manifest.Phases))
+	return c, nil
+}
+
+// restore reads a completed phase's data into v and its collection errors
+// into errs. It returns false when the phase has to be collected, including
+// when its data can no longer be read.
+func (c *collectionCheckpoint) restore(phase string, v interface{}, errs *collectionErrorLog) bool {
+	if c == nil {
+		return false
+	}
+	c.mu.Lock()
+	saved, ok := c.manifest.Phases[phase]
+	c.mu.Unlock()
+	if !ok {
+		return false
+	}
+
+	raw, err := os.ReadFile(filepath.Join(c.dir, saved.File))
+	if err == nil {
+		err = json.Unmarshal(raw, v)
+	}
+	if err != nil {
+		c.logger.Warn("Failed to read checkpointed phase, collecting it again", "phase", phase, "error", err)
+		return false
+	}
+	errs.restore(saved.CollectionErrors)
+	c.logger.Info("Restored phase from checkpoint", "phase", phase, "completed_at", saved.CompletedAt)
+	return true
+}
+
+// save writes a completed phase's data and then the manifest listing it,
+// both replaced atomically so a crash mid-write leaves the previous
+// checkpoint intact. A failure is logged and the run continues.
+func (c *collectionCheckpoint) save(phase string, data interface{}, errs []CollectionError) {
+	if c == nil {
+		return
+	}
+	if err := c.write(phase, data, errs); err != nil {
+		c.logger.Warn("Failed to write checkpoint", "phase", phase, "error", err)
+	}
+}
+
+func (c *collectionCheckpoint) write(phase string, data interface{}, errs []CollectionError) error {
+	if err := os.MkdirAll(c.dir, 0755); err != nil {
+		return err
+	}
+	raw, err := marshalDumpFile(data, c.minify)
+	if err != nil {
+		return err
+	}
+	file := phase + ".json"
+	if err := writeFileAtomic(filepath.Join(c.dir, file), raw); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.manifest.Phases[phase] = checkpointPhase{
+		File:             file,
+		CompletedAt:      time.Now().UTC().Format(time.RFC3339),
+		CollectionErrors: errs,
+	}
+	manifest, err := json.MarshalIndent(c.manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(c.path, manifest)
+}
+
+// remove deletes the checkpoint once the run has finished, so the next run
+// collects fresh data instead of resuming a completed one
+func (c *collectionCheckpoint) remove() {
+	if c == nil {
+		return
+	}
+	if err := os.RemoveAll(c.dir); err != nil {
+		c.logger.Warn("Failed to remove checkpoint data", "dir", c.dir, "error", err)
+	}
+	if err := os.Remove(c.path); err != nil && !os.IsNotExist(err) {
+		c.logger.Warn("Failed to remove checkpoint", "path", c.path, "error", err)
+	}
+}
+
+// writeFileAtomic replaces path with data through a temporary file
+func writeFileAtomic(path string, data []byte) error {
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, path)
+}
+
+// subscriptionCollectionErrors keeps the errors whose scope is the
+// subscription or an ARM ID inside it
+func subscriptionCollectionErrors(errs []CollectionError, subscriptionID string) []CollectionError {
+	prefix := "/subscriptions/" + strings.ToLower(subscriptionID) + "/"
+	var kept []CollectionError
+	for _, e := range errs {
+		scope := strings.ToLower(e.Scope)
+		if scope == strings.ToLower(subscriptionID) || strings.HasPrefix(scope, prefix) {
+			kept = append(kept, e)
+		}
+	}
+	return kept
+}
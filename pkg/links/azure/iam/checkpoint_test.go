@@ -0,0 +1,64 @@
+package iam
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/praetorian-inc/janus-framework/pkg/chain/cfg"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCollectionCheckpoint(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "run.checkpoint.json")
+	checkpoint, err := loadCollectionCheckpoint(path, "tenant-1", false, cfg.NewLogger())
+	require.NoError(t, err)
+
+	var errs collectionErrorLog
+	mark := errs.mark()
+	errs.record("users", "tenant", errors.New("connection reset"))
+	checkpoint.save(checkpointAzureAD, map[string]interface{}{"users": []interface{}{map[string]interface{}{"id": "u-1"}}}, errs.since(mark))
+
+	mark = errs.mark()
+	errs.record("roleAssignments", "/subscriptions/SUB-A/resourceGroups/rg-1", errors.New("timeout"))
+	errs.record("roleAssignments", "sub-b", errors.New("timeout"))
+	errs.record("lighthouse", "sub-a", errors.New("timeout"))
+	subErrs := subscriptionCollectionErrors(errs.since(mark), "sub-a")
+	require.Len(t, subErrs, 2, "errors scoped to the subscription or an ID inside it belong to its phase")
+	checkpoint.save(subscriptionCheckpointPhase("sub-a"), map[string]interface{}{"virtualMachines": []interface{}{}}, subErrs)
+
+	// A restarted run skips the saved phases and restores their errors
+	resumed, err := loadCollectionCheckpoint(path, "TENANT-1", false, cfg.NewLogger())
+	require.NoError(t, err)
+	var restoredErrs collectionErrorLog
+
+	var azureADData map[string]interface{}
+	require.True(t, resumed.restore(checkpointAzureAD, &azureADData, &restoredErrs))
+	assert.Len(t, azureADData["users"], 1)
+	var subData map[string]interface{}
+	require.True(t, resumed.restore(subscriptionCheckpointPhase("sub-a"), &subData, &restoredErrs))
+	assert.Contains(t, subData, "virtualMachines")
+	assert.False(t, resumed.restore(subscriptionCheckpointPhase("sub-b"), &subData, &restoredErrs), "unfinished subscriptions are collected again")
+	assert.False(t, resumed.restore(checkpointPIM, &subData, &restoredErrs))
+	assert.Len(t, restoredErrs.list(), 3)
+
+	_, err = loadCollectionCheckpoint(path, "tenant-2", false, cfg.NewLogger())
+	assert.Error(t, err, "a checkpoint from another tenant is refused")
+
+	// Unreadable phase data is collected again rather than failing the run
+	require.NoError(t, os.Remove(filepath.Join(checkpointDataDir(path), checkpointAzureAD+".json")))
+	assert.False(t, resumed.restore(checkpointAzureAD, &azureADData, &restoredErrs))
+
+	resumed.remove()
+	_, err = os.Stat(path)
+	assert.True(t, os.IsNotExist(err))
+	_, err = os.Stat(checkpointDataDir(path))
+	assert.True(t, os.IsNotExist(err))
+
+	var disabled *collectionCheckpoint
+	disabled.save(checkpointPIM, map[string]interface{}{}, nil)
+	assert.False(t, disabled.restore(checkpointPIM, &subData, &restoredErrs))
+	disabled.remove()
+}
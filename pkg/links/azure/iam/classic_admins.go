@@ -0,0 +1,137 @@
+package iam
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/praetorian-inc/janus-framework/pkg/chain/cfg"
+)
+
+// classicAdministratorsAPIVersion is the only Microsoft.Authorization API
+// version that serves classic subscription administrators
+const classicAdministratorsAPIVersion = "2015-07-01"
+
+// classicAdministratorsURL is the ARM path listing a subscription's classic
+// service administrator and co-administrators
+func classicAdministratorsURL(subscriptionID string) string {
+	return fmt.Sprintf("/subscriptions/%s/providers/Microsoft.Authorization/classicAdministrators?api-version=%s",
+		subscriptionID, classicAdministratorsAPIVersion)
+}
+
+// newClassicAdministratorSections builds the classic administrator sections of
+// a subscription's AzureRM data from the raw classicAdministrators response
+func newClassicAdministratorSections(subscriptionID string, administrators []interface{}) map[string]interface{} {
+	records := buildClassicAdministrators(subscriptionID, administrators)
+	return map[string]interface{}{
+		"classicAdministrators":        records,
+		"classicAdministratorFindings": buildClassicAdministratorFindings(records),
+	}
+}
+
+// buildClassicAdministrators flattens classicAdministrators entries into one
+// record per administrator. An administrator holding several classic roles,
+// such as the default "ServiceAdministrator;AccountAdministrator", keeps them
+// in one semicolon separated role.
+func buildClassicAdministrators(subscriptionID string, administrators []interface{}) []interface{} {
+	records := []interface{}{}
+	for _, administrator := range administrators {
+		adminMap, ok := administrator.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		props, _ := adminMap["properties"].(map[string]interface{})
+		email, _ := props["emailAddress"].(string)
+		if email == "" {
+			continue
+		}
+		records = append(records, map[string]interface{}{
+			"id":             adminMap["id"],
+			"subscriptionId": subscriptionID,
+			"scope":          "/subscriptions/" + subscriptionID,
+			"emailAddress":   email,
+			"role":           props["role"],
+		})
+	}
+	return records
+}
+
+// buildClassicAdministratorFindings reports every classic administrator.
+// Service administrators and co-administrators have Owner-equivalent access to
+// the subscription that Azure RBAC collection does not show.
+func buildClassicAdministratorFindings(administrators []interface{}) []interface{} {
+	findings := []interface{}{}
+	for _, administrator := range administrators {
+		a, ok := administrator.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		findings = append(findings, map[string]interface{}{
+			"type":     "ClassicAdministrator",
+			"severity": "High",
+			"description": fmt.Sprintf("%s is a classic %s of subscription %s with Owner-equivalent access outside Azure RBAC",
+				a["emailAddress"], a["role"], a["subscriptionId"]),
+			"subscriptionId": a["subscriptionId"],
+			"scope":          a["scope"],
+			"emailAddress":   a["emailAddress"],
+			"role":           a["role"],
+		})
+	}
+
+	sortFindings(findings, "description")
+	return findings
+}
+
+// logClassicAdministratorFindings reports the classic administrators of a subscription
+func logClassicAdministratorFindings(logger *cfg.Logger, subscriptionID string, findings []interface{}) {
+	logFindings(logger, findings, fmt.Sprintf("🚨 %%d classic administrators found in subscription %s", subscriptionID), "Classic administrator",
+		"subscription", "subscriptionId", "email", "emailAddress", "role", "role")
+}
+
+// classicAdministratorPrincipals resolves classic administrator email
+// addresses to collected users by user principal name or mail. Administrators
+// outside the directory, such as Microsoft accounts, do not resolve.
+func classicAdministratorPrincipals(o *ConsolidatedOutput) map[string]string {
+	principals := make(map[string]string)
+	users, _ := o.AzureAD["users"].([]interface{})
+	for _, user := range users {
+		userMap, ok := user.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		id, _ := userMap["id"].(string)
+		if id == "" {
+			continue
+		}
+		for _, field := range []string{"userPrincipalName", "mail"} {
+			if address, _ := userMap[field].(string); address != "" {
+				principals[strings.ToLower(address)] = id
+			}
+		}
+	}
+	return principals
+}
+
+// collectClassicAdministrators collects the classic administrators of a
+// subscription. Subscriptions without classic administrators return empty sections.
+func (l *IAMComprehensiveCollectorLink) collectClassicAdministrators(accessToken, subscriptionID string) (map[string]interface{}, error) {
+	administrators, err := l.collectPaginatedARMData(accessToken, classicAdministratorsURL(subscriptionID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list classic administrators: %v", err)
+	}
+	return newClassicAdministratorSections(subscriptionID, administrators), nil
+}
+
+// collectClassicAdministratorsSDK collects the classic administrators of a
+// subscription using the SDK collector's credential
+func (l *SDKComprehensiveCollectorLink) collectClassicAdministratorsSDK(ctx context.Context, subscriptionID string) (map[string]interface{}, error) {
+	token, err := l.getManagementAccessToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+	administrators, err := l.collectPaginatedARMDataSDK(ctx, token, classicAdministratorsURL(subscriptionID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list classic administrators: %v", err)
+	}
+	return newClassicAdministratorSections(subscriptionID, administrators), nil
+}
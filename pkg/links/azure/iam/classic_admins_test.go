@@ -0,0 +1,73 @@
+package iam
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const classicAdministratorsFixture = `[
+  {
+    "id": "/subscriptions/sub-1/providers/Microsoft.Authorization/classicAdministrators/sa",
+    "properties": {"emailAddress": "owner@contoso.com", "role": "ServiceAdministrator;AccountAdministrator"}
+  },
+  {
+    "id": "/subscriptions/sub-1/providers/Microsoft.Authorization/classicAdministrators/co",
+    "properties": {"emailAddress": "Legacy.Admin@Contoso.com", "role": "CoAdministrator"}
+  },
+  {
+    "id": "/subscriptions/sub-1/providers/Microsoft.Authorization/classicAdministrators/msa",
+    "properties": {"emailAddress": "someone@outlook.com", "role": "CoAdministrator"}
+  },
+  {"id": "/subscriptions/sub-1/providers/Microsoft.Authorization/classicAdministrators/empty", "properties": {}}
+]`
+
+func TestClassicAdministrators(t *testing.T) {
+	var administrators []interface{}
+	require.NoError(t, json.Unmarshal([]byte(classicAdministratorsFixture), &administrators))
+
+	sections := newClassicAdministratorSections("sub-1", administrators)
+	records := sections["classicAdministrators"].([]interface{})
+	require.Len(t, records, 3, "entries without an email address are skipped")
+	first := records[0].(map[string]interface{})
+	assert.Equal(t, "sub-1", first["subscriptionId"])
+	assert.Equal(t, "/subscriptions/sub-1", first["scope"])
+	assert.Equal(t, "owner@contoso.com", first["emailAddress"])
+	assert.Equal(t, "ServiceAdministrator;AccountAdministrator", first["role"])
+
+	findings := sections["classicAdministratorFindings"].([]interface{})
+	require.Len(t, findings, 3)
+	finding := findings[0].(map[string]interface{})
+	assert.Equal(t, "ClassicAdministrator", finding["type"])
+	assert.Equal(t, "High", finding["severity"])
+	assert.Contains(t, finding["description"], "Legacy.Admin@Contoso.com is a classic CoAdministrator of subscription sub-1")
+
+	empty := newClassicAdministratorSections("sub-2", nil)
+	assert.Equal(t, []interface{}{}, empty["classicAdministrators"])
+	assert.Equal(t, []interface{}{}, empty["classicAdministratorFindings"])
+}
+
+func TestClassicAdministratorPrivilegedGrants(t *testing.T) {
+	var administrators []interface{}
+	require.NoError(t, json.Unmarshal([]byte(classicAdministratorsFixture), &administrators))
+
+	o := &ConsolidatedOutput{
+		AzureAD: map[string]interface{}{
+			"users": []interface{}{
+				map[string]interface{}{"id": "u-owner", "userPrincipalName": "owner@contoso.com"},
+				map[string]interface{}{"id": "u-legacy", "userPrincipalName": "ladmin@contoso.onmicrosoft.com", "mail": "legacy.admin@contoso.com"},
+			},
+		},
+		AzureResources: map[string]interface{}{"sub-1": newClassicAdministratorSections("sub-1", administrators)},
+	}
+
+	grants := privilegedPrincipalGrants(o)
+	require.Len(t, grants, 2, "administrators outside the directory do not resolve to a principal")
+	require.Len(t, grants["u-legacy"], 1)
+	assert.Equal(t, "classicAdministrator", grants["u-legacy"][0]["type"])
+	assert.Equal(t, "CoAdministrator", grants["u-legacy"][0]["roleName"])
+	assert.Equal(t, "/subscriptions/sub-1", grants["u-legacy"][0]["scope"])
+	assert.Equal(t, "ServiceAdministrator;AccountAdministrator", grants["u-owner"][0]["roleName"])
+}
@@ -0,0 +1,96 @@
+package iam
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Azure clouds selectable with --cloud
+const (
+	azureCloudPublic = "public"
+	azureCloudUSGov  = "usgov"
+	azureCloudChina  = "china"
+)
+
+// azureCloud holds the base URLs of one Azure cloud. Every request the
+// collectors build goes through armURL, graphURL or pimURL so no function
+// hardcodes the commercial cloud.
+type azureCloud struct {
+	Name string
+	// Login is the Entra ID authority refresh tokens are exchanged at
+	Login string
+	// ARM is the Resource Manager endpoint and ARMResource the audience of its tokens
+	ARM         string
+	ARMResource string
+	Graph       string
+	// PIM is the legacy PIM API, empty where the cloud does not serve it
+	PIM string
+}
+
+// azureClouds is the endpoint map of every supported cloud
+var azureClouds = map[string]azureCloud{
+	azureCloudPublic: {
+		Name:        azureCloudPublic,
+		Login:       "https://login.microsoftonline.com",
+		ARM:         "https://management.azure.com",
+		ARMResource: "https://management.core.windows.net/",
+		Graph:       "https://graph.microsoft.com",
+		PIM:         "https://api.azrbac.mspim.azure.com",
+	},
+	azureCloudUSGov: {
+		Name:        azureCloudUSGov,
+		Login:       "https://login.microsoftonline.us",
+		ARM:         "https://management.usgovcloudapi.net",
+		ARMResource: "https://management.core.usgovcloudapi.net/",
+		Graph:       "https://graph.microsoft.us",
+		PIM:         "https://api.azrbac.mspim.azure.us",
+	},
+	azureCloudChina: {
+		Name:        azureCloudChina,
+		Login:       "https://login.chinacloudapi.cn",
+		ARM:         "https://management.chinacloudapi.cn",
+		ARMResource: "https://management.core.chinacloudapi.cn/",
+		Graph:       "https://microsoftgraph.chinacloudapi.cn",
+	},
+}
+
+// selectAzureCloud resolves a --cloud value. An empty value is the public cloud.
+func selectAzureCloud(name string) (azureCloud, error) {
+	if name == "" {
+		name = azureCloudPublic
+	}
+	cloud, ok := azureClouds[strings.ToLower(name)]
+	if !ok {
+		names := make([]string, 0, len(azureClouds))
+		for n := range azureClouds {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		return azureCloud{}, fmt.Errorf("unknown --cloud %q, expected one of %s", name, strings.Join(names, ", "))
+	}
+	return cloud, nil
+}
+
+// armURL resolves an ARM path such as /subscriptions/{id}/... against the
+// cloud's Resource Manager endpoint. Absolute URLs, such as the nextLink of a
+// page, are returned unchanged.
+func (c azureCloud) armURL(path string) string {
+	if strings.HasPrefix(path, "https://") || strings.HasPrefix(path, "http://") {
+		return path
+	}
+	return c.ARM + path
+}
+
+// graphURL returns the Graph URL of endpoint on the given API version
+func (c azureCloud) graphURL(version, endpoint string) string {
+	return c.Graph + "/" + version + endpoint
+}
+
+// pimURL returns the legacy PIM API URL of path
+func (c azureCloud) pimURL(path string) (string, error) {
+	if c.PIM == "" {
+		return "", fmt.Errorf("the PIM API is not available in the %s cloud", c.Name)
+	}
+	return c.PIM + path, nil
+}
@@ -0,0 +1,50 @@
+package iam
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSelectAzureCloud(t *testing.T) {
+	public, err := selectAzureCloud("")
+	require.NoError(t, err)
+	assert.Equal(t, "https://management.azure.com/subscriptions/sub-1/providers/Microsoft.Authorization/locks?api-version=2020-05-01", public.armURL(resourceLocksURL("sub-1")))
+
+	usgov, err := selectAzureCloud("USGov")
+	require.NoError(t, err, "cloud names match case-insensitively")
+	assert.Equal(t, "https://graph.microsoft.us/v1.0/users", usgov.graphURL(graphV1, "/users"))
+	assert.True(t, strings.HasPrefix(usgov.armURL(classicAdministratorsURL("sub-1")), "https://management.usgovcloudapi.net/subscriptions/sub-1/"))
+	assert.Equal(t, "https://management.azure.com/next?page=2", usgov.armURL("https://management.azure.com/next?page=2"), "a nextLink is followed as returned")
+
+	china, err := selectAzureCloud("china")
+	require.NoError(t, err)
+	assert.Equal(t, "https://microsoftgraph.chinacloudapi.cn/beta/devices", china.graphURL(graphBeta, "/devices"))
+	_, err = china.pimURL("/api/v2/privilegedAccess/aadroles/roleAssignments")
+	assert.Error(t, err, "the legacy PIM API is not served in the china cloud")
+
+	_, err = selectAzureCloud("germany")
+	assert.ErrorContains(t, err, "expected one of china, public, usgov")
+}
+
+func TestAzureTokenSourceUsesCloudEndpoints(t *testing.T) {
+	var resources []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		resources = append(resources, r.URL.Query().Get("resource"))
+		w.Write([]byte(`{"access_token":"token","expires_in":"3599","token_type":"Bearer"}`))
+	}))
+	defer server.Close()
+	t.Setenv("IDENTITY_ENDPOINT", server.URL)
+	t.Setenv("IDENTITY_HEADER", "secret")
+
+	tokens := azureTokenSource{tenantID: "tenant-1", cloud: azureClouds[azureCloudUSGov]}
+	_, err := tokens.graphToken()
+	require.NoError(t, err)
+	_, err = tokens.armToken()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"https://graph.microsoft.us", "https://management.core.usgovcloudapi.net/"}, resources)
+}
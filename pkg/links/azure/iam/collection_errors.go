@@ -0,0 +1,306 @@
+package iam
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/praetorian-inc/nebula/internal/message"
+)
+
+// CollectionError records a dataset that could not be collected. Permission
+// failures carry the permission or role that would have allowed collection so
+// partial runs can be turned into concrete access requests.
+type CollectionError struct {
+	Dataset            string `json:"dataset"`
+	Scope              string `json:"scope"`
+	PermissionDenied   bool   `json:"permission_denied"`
+	RequiredPermission string `json:"required_permission,omitempty"`
+	StatusCode         int    `json:"status_code,omitempty"`
+	ErrorCode          string `json:"error_code,omitempty"`
+	Message            string `json:"message"`
+}
+
+// datasetPermissions maps each dataset to the Graph permission or Azure role
+// the collecting identity needs to read it
+var datasetPermissions = map[string]string{
+	"users":                               "Directory.Read.All",
+	"groups":                              "Directory.Read.All",
+	"servicePrincipals":                   "Directory.Read.All",
+	"applications":                        "Directory.Read.All",
+	"devices":                             "Directory.Read.All",
+	"oauth2PermissionGrants":              "Directory.Read.All",
+	"groupMemberships":                    "Directory.Read.All",
+	"groupOwnership":                      "Directory.Read.All",
+	"servicePrincipalOwnership":           "Directory.Read.All",
+	"applicationOwnership":                "Directory.Read.All",
+	"federatedIdentityCredentials":        "Application.Read.All",
+	"deviceOwnership":                     "Device.Read.All",
+	"administrativeUnits":                 "AdministrativeUnit.Read.All",
+	"administrativeUnitMembers":           "AdministrativeUnit.Read.All",
+	"administrativeUnitRoleAssignments":   "RoleManagement.Read.Directory",
+	"administrativeUnitScopedRoleMembers": "RoleManagement.Read.Directory",
+	"appRoleAssignments":                  "Directory.Read.All",
+	"graphPermissionFindings":             "Directory.Read.All",
+	"directoryRoles":                      "RoleManagement.Read.Directory",
+	"roleDefinitions":                     "RoleManagement.Read.Directory",
+	"directoryRoleAssignments":            "RoleManagement.Read.Directory",
+	"attributeRoleAssignments":            "RoleManagement.Read.Directory",
+	"customSecurityAttributes":            "CustomSecAttributeDefinition.Read.All and Attribute Definition Reader role",
+	"eligible_assignments":                "RoleManagement.Read.Directory",
+	"active_assignments":                  "RoleManagement.Read.Directory",
+	"role_management_policies":            "RoleManagementPolicy.Read.Directory",
+	"role_management_policy_assignments":  "RoleManagementPolicy.Read.Directory",
+	"conditionalAccessPolicies":           "Policy.Read.All",
+	"authenticationMethodConfigurations":  "Policy.Read.All",
+	"tokenLifetimePolicies":               "Policy.Read.All",
+	"signIns":                             "AuditLog.Read.All",
+	"signInActivity":                      "AuditLog.Read.All",
+	"userRegistrationDetails":             "AuditLog.Read.All",
+	"directoryAudits":                     "AuditLog.Read.All",
+	"management_groups":                   "Management Group Reader role",
+	"management_group_rbac":               "Management Group Reader role",
+	"roleAssignments":                     "Reader role",
+	"azureResourceGroups":                 "Reader role",
+	"azureResources":                      "Reader role",
+	"azureRoleDefinitions":                "Reader role",
+	"keyVaultAccessPolicies":              "Reader role",
+	"lighthouse":                          "Reader role",
+	"classicAdministrators":               "Reader role",
+	"storageObjectReplicationPolicies":    "Reader role",
+	"resource_locks":                      "Reader role",
+	"arm_eligible_assignments":            "Reader role",
+	"arm_active_assignments":              "Reader role",
+	"subscription":                        "Reader role",
+}
+
+// apiStatusError is returned for non-success Graph and ARM responses and keeps
+// the status and service error code so permission failures can be told apart
+type apiStatusError struct {
+	StatusCode int
+	Code       string
+	Message    string
+}
+
+func (e *apiStatusError) Error() string {
+	if e.Code == "" {
+		return fmt.Sprintf("API call failed with status %d", e.StatusCode)
+	}
+	return fmt.Sprintf("API call failed with status %d (%s: %s)", e.StatusCode, e.Code, e.Message)
+}
+
+// newAPIStatusError reads the error body of a failed response. The caller still owns resp.Body.
+func newAPIStatusError(resp *http.Response) *apiStatusError {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	return apiStatusErrorFromBody(resp.StatusCode, body)
+}
+
+// apiStatusErrorFromBody parses the {"error":{"code","message"}} envelope
+// shared by Graph and ARM error responses
+func apiStatusErrorFromBody(statusCode int, body []byte) *apiStatusError {
+	apiErr := &apiStatusError{StatusCode: statusCode}
+	var envelope struct {
+		Error struct {
+			Code    string `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if json.Unmarshal(body, &envelope) == nil {
+		apiErr.Code = envelope.Error.Code
+		apiErr.Message = envelope.Error.Message
+	}
+	return apiErr
+}
+
+// permissionDeniedCodes are service error codes that indicate the caller lacks access
+var permissionDeniedCodes = []string{
+	"Authorization_RequestDenied",
+	"AuthorizationFailed",
+	"Authorization_IdentityNotFound",
+	"Forbidden",
+	"InsufficientAccountPermissions",
+}
+
+// isPermissionDenied reports whether err was caused by a 403 or an
+// authorization error code. SDK collectors wrap errors with %v, so the error
+// text is checked when no typed status is available.
+func isPermissionDenied(err error) (bool, int, string) {
+	if err == nil {
+		return false, 0, ""
+	}
+
+	var apiErr *apiStatusError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode == http.StatusForbidden || isPermissionDeniedCode(apiErr.Code), apiErr.StatusCode, apiErr.Code
+	}
+	var respErr *azcore.ResponseError
+	if errors.As(err, &respErr) {
+		return respErr.StatusCode == http.StatusForbidden || isPermissionDeniedCode(respErr.ErrorCode), respErr.StatusCode, respErr.ErrorCode
+	}
+	var statusErr interface{ GetStatusCode() int }
+	if errors.As(err, &statusErr) && statusErr.GetStatusCode() == http.StatusForbidden {
+		return true, http.StatusForbidden, ""
+	}
+
+	text := err.Error()
+	for _, code := range permissionDeniedCodes {
+		if strings.Contains(text, code) {
+			return true, 0, code
+		}
+	}
+	if strings.Contains(text, "status 403") || strings.Contains(text, "Insufficient privileges") {
+		return true, http.StatusForbidden, ""
+	}
+	return false, 0, ""
+}
+
+func isPermissionDeniedCode(code string) bool {
+	for _, denied := range permissionDeniedCodes {
+		if strings.EqualFold(code, denied) {
+			return true
+		}
+	}
+	return false
+}
+
+// collectionErrorLog accumulates collection errors from concurrent collectors
+type collectionErrorLog struct {
+	mu     sync.Mutex
+	errors []CollectionError
+}
+
+// record notes that dataset could not be collected at scope ("tenant" or a subscription ID)
+func (c *collectionErrorLog) record(dataset, scope string, err error) {
+	if err == nil {
+		return
+	}
+	denied, status, code := isPermissionDenied(err)
+	entry := CollectionError{
+		Dataset:          dataset,
+		Scope:            scope,
+		PermissionDenied: denied,
+		StatusCode:       status,
+		ErrorCode:        code,
+		Message:          err.Error(),
+	}
+	if denied {
+		entry.RequiredPermission = datasetPermissions[dataset]
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.errors = append(c.errors, entry)
+}
+
+// recordNoAccess notes that dataset returned nothing at scope because the
+// identity has no access to any of it, which the API reports as an empty
+// result rather than an error
+func (c *collectionErrorLog) recordNoAccess(dataset, scope, msg string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.errors = append(c.errors, CollectionError{
+		Dataset:            dataset,
+		Scope:              scope,
+		PermissionDenied:   true,
+		RequiredPermission: datasetPermissions[dataset],
+		Message:            msg,
+	})
+}
+
+// mark returns the position of the next error recorded, for since
+func (c *collectionErrorLog) mark() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.errors)
+}
+
+// since returns the errors recorded after mark, in the order recorded
+func (c *collectionErrorLog) since(mark int) []CollectionError {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]CollectionError(nil), c.errors[mark:]...)
+}
+
+// restore adds errors recorded by an earlier run of a checkpointed phase
+func (c *collectionErrorLog) restore(errs []CollectionError) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.errors = append(c.errors, errs...)
+}
+
+// list returns the recorded errors ordered by dataset and scope
+func (c *collectionErrorLog) list() []CollectionError {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	errs := make([]CollectionError, len(c.errors))
+	copy(errs, c.errors)
+	sort.SliceStable(errs, func(i, j int) bool {
+		if errs[i].Dataset != errs[j].Dataset {
+			return errs[i].Dataset < errs[j].Dataset
+		}
+		return errs[i].Scope < errs[j].Scope
+	})
+	return errs
+}
+
+// summarizeCollectionErrors groups skipped datasets by missing permission, e.g.
+// "Missing Directory.Read.All caused groups, users to be skipped"
+func summarizeCollectionErrors(errs []CollectionError) []string {
+	byPermission := make(map[string][]string)
+	var other []string
+	for _, e := range errs {
+		name := e.Dataset
+		if e.Scope != "" && e.Scope != "tenant" {
+			name = fmt.Sprintf("%s (%s)", e.Dataset, e.Scope)
+		}
+		switch {
+		case !e.PermissionDenied:
+			other = appendUnique(other, name)
+		case e.RequiredPermission == "":
+			byPermission["an unknown permission"] = appendUnique(byPermission["an unknown permission"], name)
+		default:
+			byPermission[e.RequiredPermission] = appendUnique(byPermission[e.RequiredPermission], name)
+		}
+	}
+
+	permissions := make([]string, 0, len(byPermission))
+	for permission := range byPermission {
+		permissions = append(permissions, permission)
+	}
+	sort.Strings(permissions)
+
+	var lines []string
+	for _, permission := range permissions {
+		lines = append(lines, fmt.Sprintf("Missing %s caused %s to be skipped", permission, strings.Join(byPermission[permission], ", ")))
+	}
+	if len(other) > 0 {
+		lines = append(lines, fmt.Sprintf("Collection failed for other reasons: %s", strings.Join(other, ", ")))
+	}
+	return lines
+}
+
+func appendUnique(list []string, value string) []string {
+	for _, existing := range list {
+		if existing == value {
+			return list
+		}
+	}
+	return append(list, value)
+}
+
+// printCollectionErrorSummary prints the post-run permission guidance
+func printCollectionErrorSummary(errs []CollectionError) {
+	if len(errs) == 0 {
+		return
+	}
+	message.Warning("%d datasets were skipped or incomplete (see collection_errors in the output):", len(errs))
+	for _, line := range summarizeCollectionErrors(errs) {
+		message.Warning("  %s", line)
+	}
+}
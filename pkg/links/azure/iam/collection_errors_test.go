@@ -0,0 +1,95 @@
+package iam
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// kiotaStyleError mimics the status accessor exposed by msgraph ODataError
+type kiotaStyleError struct{ status int }
+
+func (e *kiotaStyleError) Error() string      { return "odata error" }
+func (e *kiotaStyleError) GetStatusCode() int { return e.status }
+
+func TestIsPermissionDenied(t *testing.T) {
+	resp := &http.Response{
+		StatusCode: http.StatusForbidden,
+		Body:       io.NopCloser(strings.NewReader(`{"error":{"code":"Authorization_RequestDenied","message":"Insufficient privileges to complete the operation."}}`)),
+	}
+	apiErr := newAPIStatusError(resp)
+	assert.Equal(t, "Authorization_RequestDenied", apiErr.Code)
+
+	denied, status, code := isPermissionDenied(fmt.Errorf("page 2: %w", apiErr))
+	assert.True(t, denied)
+	assert.Equal(t, http.StatusForbidden, status)
+	assert.Equal(t, "Authorization_RequestDenied", code)
+
+	denied, _, _ = isPermissionDenied(apiStatusErrorFromBody(http.StatusUnauthorized, []byte(`{"error":{"code":"AuthorizationFailed"}}`)))
+	assert.True(t, denied, "ARM AuthorizationFailed should count as a permission failure")
+
+	denied, status, _ = isPermissionDenied(fmt.Errorf("failed to list users: %w", &kiotaStyleError{status: http.StatusForbidden}))
+	assert.True(t, denied)
+	assert.Equal(t, http.StatusForbidden, status)
+
+	denied, _, _ = isPermissionDenied(fmt.Errorf("failed to list users: %v", errors.New("Authorization_RequestDenied: Insufficient privileges")))
+	assert.True(t, denied, "errors flattened with %%v should still be detected from their text")
+
+	denied, _, _ = isPermissionDenied(apiStatusErrorFromBody(http.StatusInternalServerError, []byte("not json")))
+	assert.False(t, denied)
+	denied, _, _ = isPermissionDenied(&kiotaStyleError{status: http.StatusNotFound})
+	assert.False(t, denied)
+}
+
+func TestCollectionErrorSummary(t *testing.T) {
+	var log collectionErrorLog
+	forbidden := apiStatusErrorFromBody(http.StatusForbidden, []byte(`{"error":{"code":"Authorization_RequestDenied"}}`))
+	log.record("users", "tenant", forbidden)
+	log.record("groups", "tenant", forbidden)
+	log.record("signIns", "tenant", forbidden)
+	log.record("lighthouse", "sub-1", apiStatusErrorFromBody(http.StatusForbidden, []byte(`{"error":{"code":"AuthorizationFailed"}}`)))
+	log.record("devices", "tenant", errors.New("connection reset"))
+	log.record("users", "tenant", nil)
+
+	errs := log.list()
+	require.Len(t, errs, 5)
+	assert.Equal(t, "devices", errs[0].Dataset, "errors should be ordered by dataset")
+	assert.False(t, errs[0].PermissionDenied)
+	assert.Empty(t, errs[0].RequiredPermission)
+
+	assert.Equal(t, []string{
+		"Missing AuditLog.Read.All caused signIns to be skipped",
+		"Missing Directory.Read.All caused groups, users to be skipped",
+		"Missing Reader role caused lighthouse (sub-1) to be skipped",
+		"Collection failed for other reasons: devices",
+	}, summarizeCollectionErrors(errs))
+
+	output := &ConsolidatedOutput{CollectionErrors: errs}
+	output.Normalize()
+	raw, err := json.Marshal(output)
+	require.NoError(t, err)
+
+	var decoded struct {
+		CollectionErrors []map[string]interface{} `json:"collection_errors"`
+	}
+	require.NoError(t, json.Unmarshal(raw, &decoded))
+	require.Len(t, decoded.CollectionErrors, 5)
+	groups := decoded.CollectionErrors[1]
+	assert.Equal(t, "groups", groups["dataset"])
+	assert.Equal(t, true, groups["permission_denied"])
+	assert.Equal(t, "Directory.Read.All", groups["required_permission"])
+	assert.Equal(t, float64(http.StatusForbidden), groups["status_code"])
+
+	empty := &ConsolidatedOutput{}
+	empty.Normalize()
+	raw, err = json.Marshal(empty)
+	require.NoError(t, err)
+	assert.Contains(t, string(raw), `"collection_errors":[]`)
+}
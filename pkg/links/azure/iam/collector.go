@@ -2,7 +2,6 @@ package iam
 
 import (
 	"bytes"
-	"crypto/tls"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -15,9 +14,9 @@ import (
 
 	"github.com/praetorian-inc/janus-framework/pkg/chain"
 	"github.com/praetorian-inc/janus-framework/pkg/chain/cfg"
-	"github.com/praetorian-inc/nebula/internal/helpers"
 	"github.com/praetorian-inc/nebula/internal/message"
 	"github.com/praetorian-inc/nebula/pkg/links/options"
+	"github.com/praetorian-inc/nebula/pkg/rules"
 )
 
 // selectedResourceTypes defines the resource types worth collecting RBAC assignments for
@@ -53,25 +52,27 @@ var selectedResourceTypes = []string{
 
 // CompleteGraphPermission represents all types of Graph API permissions
 type CompleteGraphPermission struct {
-	ID                   string `json:"id"`
-	Type                 string `json:"type"` // "ServicePrincipalApplication", "ServicePrincipalDelegated", "UserApplication", "GroupApplication", "UserDelegated"
-	ServicePrincipalID   string `json:"servicePrincipalId,omitempty"`
-	ServicePrincipalName string `json:"servicePrincipalName,omitempty"`
-	UserID               string `json:"userId,omitempty"`
-	UserName             string `json:"userName,omitempty"`
-	GroupID              string `json:"groupId,omitempty"`
-	GroupName            string `json:"groupName,omitempty"`
-	ResourceAppID        string `json:"resourceAppId"`
-	ResourceAppName      string `json:"resourceAppName"`
-	PermissionType       string `json:"permissionType"` // "Application" or "Delegated"
-	Permission           string `json:"permission"`
-	ConsentType          string `json:"consentType"` // "Admin" or "User"
-	GrantedFor           string `json:"grantedFor,omitempty"`
-	CreatedDateTime      string `json:"createdDateTime"`
-	ExpiryDateTime       string `json:"expiryDateTime,omitempty"`
-	AppRoleID            string `json:"appRoleId,omitempty"`
-	Scope                string `json:"scope,omitempty"`
-	Source               string `json:"source"` // "Global", "ServicePrincipal", "User", "Group"
+	ID                    string   `json:"id"`
+	Type                  string   `json:"type"` // "ServicePrincipalApplication", "ServicePrincipalDelegated", "UserApplication", "GroupApplication", "UserDelegated"
+	ServicePrincipalID    string   `json:"servicePrincipalId,omitempty"`
+	ServicePrincipalName  string   `json:"servicePrincipalName,omitempty"`
+	ServicePrincipalAppID string   `json:"servicePrincipalAppId,omitempty"`
+	UserID                string   `json:"userId,omitempty"`
+	UserName              string   `json:"userName,omitempty"`
+	GroupID               string   `json:"groupId,omitempty"`
+	GroupName             string   `json:"groupName,omitempty"`
+	ResourceAppID         string   `json:"resourceAppId"`
+	ResourceAppName       string   `json:"resourceAppName"`
+	PermissionType        string   `json:"permissionType"` // "Application" or "Delegated"
+	Permission            string   `json:"permission"`
+	ConsentType           string   `json:"consentType"` // "Admin" or "User"
+	GrantedFor            string   `json:"grantedFor,omitempty"`
+	CreatedDateTime       string   `json:"createdDateTime"`
+	ExpiryDateTime        string   `json:"expiryDateTime,omitempty"`
+	AppRoleID             string   `json:"appRoleId,omitempty"`
+	Scope                 string   `json:"scope,omitempty"`
+	Source                string   `json:"source"`  // "Global", "ServicePrincipal", "User", "Group"
+	Sources               []string `json:"sources"` // every source the grant was observed in
 }
 
 // ServicePrincipalInfo holds basic service principal information
@@ -98,11 +99,32 @@ type GroupInfo struct {
 // Complete Azure AD, PIM, and ARM resource collection in one link
 type IAMComprehensiveCollectorLink struct {
 	*chain.Base
-	httpClient *http.Client
+	httpClient       *http.Client
+	httpClientOnce   sync.Once
+	httpClientErr    error
+	collectionErrors collectionErrorLog
+	spSuppressions   spSuppressionList
+	// cloud holds the endpoints of the --cloud collected from
+	cloud azureCloud
+	// dangerousGraphPermissions maps each reported Graph permission to its risk
+	dangerousGraphPermissions map[string]string
+	rbacDedup        *rbacDeduplicator
+	sampleSize       int
+	concurrency      int
+	armThrottle      armThrottle
+	shardWriter      *subscriptionShardWriter
+	checkpoint       *collectionCheckpoint
+	ndjson           *ndjsonWriter
+	progress         progressReporter
+	graphObjects     graphObjectSelection
+	// resourceTypeFilter decides which Resource Graph resource types are
+	// collected, unless allResourceTypes collects every resource unfiltered
+	resourceTypeFilter *resourceTypeFilter
+	allResourceTypes   bool
 }
 
 func NewIAMComprehensiveCollectorLink(configs ...cfg.Config) chain.Link {
-	l := &IAMComprehensiveCollectorLink{}
+	l := &IAMComprehensiveCollectorLink{cloud: azureClouds[azureCloudPublic]}
 	l.Base = chain.NewBase(l, configs...)
 	return l
 }
@@ -112,7 +134,41 @@ func (l *IAMComprehensiveCollectorLink) Params() []cfg.Param {
 		options.AzureSubscription(),
 		options.AzureRefreshToken(),
 		options.AzureTenantID(),
+		options.AzureManagedIdentityClientID(),
+		options.AzureCloud(),
 		options.AzureProxy(),
+		options.AzureInsecure(),
+		options.AzureHTTPTimeout(),
+		options.AzureARMMaxPages(),
+		options.AzureConcurrency(),
+		options.AzureRBACDedup(),
+		options.AzureSample(),
+		options.AzureLogStart(),
+		options.AzureLogEnd(),
+		options.AzureLogFailuresOnly(),
+		options.AzureLogUser(),
+		options.AzureSuppressSPFile(),
+		options.AzureGraphPermissions(),
+		options.AzureDangerousGraphPermissionsFile(),
+		options.AzureEstimate(),
+		options.AzureGraphObjects(),
+		options.AzureResourceTypeFilter(),
+		options.AzureAllResourceTypes(),
+		options.AzureUseBeta(),
+		options.AzureRules(),
+		options.AzureARGRules(),
+		options.AzureCompareBaseline(),
+		options.AzureWriteBaseline(),
+		options.AzureSummaryOut(),
+		options.AzureFromDump(),
+		options.AzurePriorDump(),
+		options.AzureChangedSince(),
+		options.AzureCheckpoint(),
+		options.AzureDumpRawResponses(),
+		options.AzureSplitSubscriptions(),
+		options.AzureMinify(),
+		options.AzureProject(),
+		options.AzureOutputFormat(),
 	}
 }
 
@@ -123,26 +179,137 @@ func (l *IAMComprehensiveCollectorLink) Process(input interface{}) error {
 	tenantID, _ := cfg.As[string](l.Arg("tenant"))
 	proxyURL, _ := cfg.As[string](l.Arg("proxy"))
 
-	if refreshToken == "" || tenantID == "" {
-		return fmt.Errorf("refresh-token and tenant are required")
+	logStart, _ := cfg.As[string](l.Arg("log-start"))
+	logEnd, _ := cfg.As[string](l.Arg("log-end"))
+	logFailuresOnly, _ := cfg.As[bool](l.Arg("log-failures-only"))
+	logUser, _ := cfg.As[string](l.Arg("log-user"))
+	logWindow, err := newAuditLogWindow(logStart, logEnd, logFailuresOnly, logUser, time.Now())
+	if err != nil {
+		return err
+	}
+
+	suppressSPFile, _ := cfg.As[string](l.Arg("suppress-sp-file"))
+	l.spSuppressions, err = loadSPSuppressions(suppressSPFile)
+	if err != nil {
+		return err
+	}
+	collectGraphPermissions, _ := cfg.As[bool](l.Arg("graph-permissions"))
+	dangerousGraphPermissionsFile, _ := cfg.As[string](l.Arg("dangerous-graph-permissions-file"))
+	l.dangerousGraphPermissions, err = loadDangerousGraphPermissions(dangerousGraphPermissionsFile)
+	if err != nil {
+		return err
+	}
+
+	graphObjects, _ := cfg.As[[]string](l.Arg("graph-objects"))
+	if l.graphObjects, err = selectGraphObjects(graphObjects, collectGraphPermissions); err != nil {
+		return err
+	}
+	if l.graphObjects != nil {
+		message.Info("Collecting only these Graph object types and their prerequisites: %s", strings.Join(l.graphObjects.names(), ", "))
+	}
+
+	useBeta, _ := cfg.As[[]string](l.Arg("use-beta"))
+	betaSelected, err := selectBetaCollections(useBeta)
+	if err != nil {
+		return err
+	}
+
+	ruleSelectors, _ := cfg.As[[]string](l.Arg("rules"))
+	selectedRules, err := rules.Select(ruleProvider, ruleSelectors)
+	if err != nil {
+		return err
+	}
+	baselineFile, _ := cfg.As[string](l.Arg("compare-baseline"))
+	var baseline *rules.Baseline
+	if baselineFile != "" {
+		if baseline, err = rules.LoadBaseline(baselineFile); err != nil {
+			return err
+		}
+	}
+	argRulesFile, _ := cfg.As[string](l.Arg("arg-rules"))
+	argRules, err := loadARGRules(argRulesFile)
+	if err != nil {
+		return err
+	}
+	resourceTypeFilterFile, _ := cfg.As[string](l.Arg("resource-type-filter"))
+	if l.resourceTypeFilter, err = loadResourceTypeFilter(resourceTypeFilterFile); err != nil {
+		return err
+	}
+	l.allResourceTypes, _ = cfg.As[bool](l.Arg("all-resource-types"))
+
+	if fromDump, _ := cfg.As[string](l.Arg("from-dump")); fromDump != "" {
+		return l.sendReanalyzedDump(fromDump, selectedRules, baseline, baselineFile)
+	}
+	cloudName, _ := cfg.As[string](l.Arg("cloud"))
+	if l.cloud, err = selectAzureCloud(cloudName); err != nil {
+		return err
+	}
+	managedIdentityClientID, _ := cfg.As[string](l.Arg("managed-identity-client-id"))
+	tokens := azureTokenSource{refreshToken: refreshToken, tenantID: tenantID, proxyURL: proxyURL, managedIdentityClientID: managedIdentityClientID, cloud: l.cloud}
+	if err := tokens.resolveTenant(); err != nil {
+		return err
+	}
+	tenantID = tokens.tenantID
+	l.Logger.Info("Authenticating", "method", tokens.method(), "tenant", tenantID, "cloud", l.cloud.Name)
+	message.Info("Authenticating with %s for tenant %s in the %s cloud", tokens.method(), tenantID, l.cloud.Name)
+
+	priorDump, _ := cfg.As[string](l.Arg("prior-dump"))
+	changedSince, _ := cfg.As[string](l.Arg("changed-since"))
+	incremental, err := newIncrementalCollection(priorDump, changedSince)
+	if err != nil {
+		return err
+	}
+	if incremental != nil {
+		if err := incremental.checkTenant(tenantID); err != nil {
+			return err
+		}
 	}
 
 	l.Logger.Info("Starting comprehensive Azure IAM collection", "subscriptions_input", subscriptions, "tenant", tenantID)
+	l.collectionErrors = collectionErrorLog{}
+	l.rbacDedup = newRBACDeduplicator(rbacDedupKeyArg(l.Arg("rbac-dedup")))
+	l.sampleSize = sampleSizeArg(l.Arg("sample"))
+	logSampledRun(l.sampleSize)
+	l.concurrency = concurrencyArg(l.Arg("concurrency"))
+	splitDir, _ := cfg.As[string](l.Arg("split-subscriptions"))
+	minify, _ := cfg.As[bool](l.Arg("minify"))
+	if l.shardWriter, err = newSubscriptionShardWriter(splitDir, tenantID, minify, l.Logger); err != nil {
+		return err
+	}
+	checkpointPath, _ := cfg.As[string](l.Arg("checkpoint"))
+	if l.checkpoint, err = loadCollectionCheckpoint(checkpointPath, tenantID, minify, l.Logger); err != nil {
+		return err
+	}
+	format, _ := cfg.As[string](l.Arg("format"))
+	projectFields, _ := cfg.As[[]string](l.Arg("project"))
+	if format == outputFormatNDJSON && (l.sampleSize > 0 || len(projectFields) > 0 || splitDir != "") {
+		return fmt.Errorf("format ndjson streams data as it is collected and cannot be combined with --sample, --project or --split-subscriptions")
+	}
+	outputDir, _ := cfg.As[string](l.Arg("output"))
+	if outputDir == "" {
+		outputDir = "nebula-output"
+	}
+	if l.ndjson, err = newNDJSONWriter(format, outputDir, tenantID); err != nil {
+		return err
+	}
+	if _, err := l.sharedHTTPClient(); err != nil {
+		return err
+	}
 
 	// Handle subscription discovery internally
 	var subscriptionIDs []string
 	if len(subscriptions) == 0 || (len(subscriptions) == 1 && strings.EqualFold(subscriptions[0], "all")) {
-		l.Logger.Info("Discovering subscriptions using refresh token")
+		l.Logger.Info("Discovering subscriptions")
 
-		// Get Azure Management token from refresh token
-		managementToken, err := helpers.GetAzureRMToken(refreshToken, tenantID, proxyURL)
+		// Get Azure Management token
+		managementToken, err := tokens.armToken()
 		if err != nil {
 			l.Logger.Error("Failed to get management token", "error", err)
 			return fmt.Errorf("failed to get management token: %v", err)
 		}
 
 		// List subscriptions using management API
-		allSubs, err := l.listSubscriptionsWithToken(managementToken.AccessToken, proxyURL)
+		allSubs, err := l.listSubscriptionsWithToken(managementToken.AccessToken)
 		if err != nil {
 			l.Logger.Error("Failed to list subscriptions", "error", err)
 			return err
@@ -150,148 +317,325 @@ func (l *IAMComprehensiveCollectorLink) Process(input interface{}) error {
 
 		subscriptionIDs = allSubs
 		l.Logger.Info("Found subscriptions", "count", len(subscriptionIDs))
+		checkSubscriptionAccess(&l.collectionErrors, subscriptionIDs)
 	} else {
 		// Use the provided subscriptions
 		subscriptionIDs = subscriptions
 		l.Logger.Info("Using provided subscriptions", "subscriptions", subscriptionIDs)
 	}
 
-	// Setup HTTP client
-	l.httpClient = &http.Client{
-		Timeout: 30 * time.Second,
-	}
-	if proxyURL != "" {
-		proxyParsedURL, err := url.Parse(proxyURL)
-		if err != nil {
-			return fmt.Errorf("invalid proxy URL: %v", err)
-		}
-		transport := &http.Transport{
-			Proxy:           http.ProxyURL(proxyParsedURL),
-			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
-		}
-		l.httpClient.Transport = transport
+	if estimate, _ := cfg.As[bool](l.Arg("estimate")); estimate {
+		return l.estimateCollection(tokens, subscriptionIDs, collectGraphPermissions)
 	}
 
 	// STEP 1: Collect Azure AD data ONCE for the entire tenant
 	l.Logger.Info("Collecting Azure AD data via Graph API (once for all subscriptions)")
 	message.Info("Collecting Azure AD data via Graph API...")
 
-	graphToken, err := helpers.GetGraphAPIToken(refreshToken, tenantID, proxyURL)
+	graphToken, err := tokens.graphToken()
 	if err != nil {
 		return fmt.Errorf("failed to get Graph API token: %v", err)
 	}
 
-	azureADData, err := l.collectAllGraphData(graphToken.AccessToken)
-	if err != nil {
-		l.Logger.Error("Failed to collect Graph API data", "error", err)
-		return err
+	var azureADData map[string]interface{}
+	if l.checkpoint.restore(checkpointAzureAD, &azureADData, &l.collectionErrors) {
+		message.Info("Restored Azure AD data from checkpoint (%d object types)", len(azureADData))
+	} else {
+		mark := l.collectionErrors.mark()
+		azureADData, err = l.collectAllGraphData(graphToken.AccessToken)
+		if err != nil {
+			l.Logger.Error("Failed to collect Graph API data", "error", err)
+			return err
+		}
+
+		message.Info("Graph collector completed successfully! Collected %d object types", len(azureADData))
+		if err := checkGraphAccess(&l.collectionErrors, subscriptionIDs); err != nil {
+			return err
+		}
+
+		// STEP 1.1: Collect custom security attributes and who holds the attribute roles
+		if l.graphObjects.wants("customSecurityAttributes") {
+			message.Info("Collecting custom security attributes...")
+			collectCustomSecurityAttributes(l.Logger, &l.collectionErrors, func(version, endpoint string) ([]interface{}, error) {
+				return l.collectPaginatedGraphData(graphToken.AccessToken, version, endpoint)
+			}, azureADData)
+		}
+
+		// STEP 1.2: Collect the registered owners and users of devices
+		if l.graphObjects.wants("deviceOwnership") {
+			message.Info("Collecting device ownership...")
+			collectDeviceOwnership(l.Logger, &l.collectionErrors, func(version, endpoint string) ([]interface{}, error) {
+				return l.collectPaginatedGraphData(graphToken.AccessToken, version, endpoint)
+			}, azureADData)
+		}
+
+		// STEP 1.3: Collect administrative units, their members and the roles scoped to them
+		if l.graphObjects.wants("administrativeUnits") {
+			message.Info("Collecting administrative units...")
+			collectAdministrativeUnits(l.Logger, &l.collectionErrors, func(version, endpoint string) ([]interface{}, error) {
+				return l.collectPaginatedGraphData(graphToken.AccessToken, version, endpoint)
+			}, azureADData)
+		}
+
+		// STEP 1.4: Collect the federated identity credentials of applications
+		if l.graphObjects.wants("federatedIdentityCredentials") {
+			message.Info("Collecting federated identity credentials...")
+			collectFederatedIdentityCredentials(l.Logger, &l.collectionErrors, func(requests []map[string]interface{}) (map[string]interface{}, error) {
+				return l.callGraphBatchAPI(graphToken.AccessToken, requests)
+			}, azureADData)
+		}
+
+		// STEP 1.5: Resolve every Graph API permission grant and report the dangerous ones
+		if collectGraphPermissions {
+			permissions, err := l.collectCompleteGraphPermissions(graphToken.AccessToken, azureADData)
+			if err != nil {
+				l.Logger.Error("Failed to collect Graph API permissions", "error", err)
+				l.collectionErrors.record("graphPermissionFindings", "tenant", err)
+			} else {
+				azureADData["graphPermissionFindings"], azureADData["graphPermissionStatistics"] = l.analyzeComprehensiveGraphPermissions(permissions)
+			}
+		}
+		l.checkpoint.save(checkpointAzureAD, azureADData, l.collectionErrors.since(mark))
 	}
 
-	message.Info("Graph collector completed successfully! Collected %d object types", len(azureADData))
+	// STEP 1.6: Collect sign-in and directory audit logs when a window was requested
+	var auditLogs *AuditLogs
+	if logWindow != nil && !l.checkpoint.restore(checkpointAuditLogs, &auditLogs, &l.collectionErrors) {
+		l.Logger.Info("Collecting Azure AD audit logs", "start", logWindow.Start, "end", logWindow.End, "failures_only", logWindow.FailuresOnly, "user", logWindow.User)
+		message.Info("Collecting sign-in and directory audit logs from %s to %s...", logWindow.Start, logWindow.End)
+		mark := l.collectionErrors.mark()
+		auditLogs = l.collectAuditLogs(graphToken.AccessToken, logWindow)
+		l.checkpoint.save(checkpointAuditLogs, auditLogs, l.collectionErrors.since(mark))
+	}
+	l.ndjson.streamAuditLogs(auditLogs)
 
 	// STEP 2: Collect PIM data ONCE for the entire tenant
 	l.Logger.Info("Collecting PIM data (once for all subscriptions)")
 	message.Info("Collecting PIM data...")
 
-	pimToken, err := helpers.GetPIMToken(refreshToken, tenantID, proxyURL)
-	if err != nil {
-		l.Logger.Error("Failed to get PIM token", "error", err)
-		return fmt.Errorf("failed to get PIM token: %v", err)
-	}
+	var pimData map[string]interface{}
+	if l.checkpoint.restore(checkpointPIM, &pimData, &l.collectionErrors) {
+		message.Info("Restored PIM data from checkpoint (%d assignment types)", len(pimData))
+	} else {
+		// Without a PIM API in the cloud the legacy assignments are recorded as not collected
+		pimAccessToken := ""
+		if l.cloud.PIM != "" {
+			pimToken, err := tokens.pimToken()
+			if err != nil {
+				l.Logger.Error("Failed to get PIM token", "error", err)
+				return fmt.Errorf("failed to get PIM token: %v", err)
+			}
+			pimAccessToken = pimToken.AccessToken
+		}
 
-	pimData, err := l.collectAllPIMData(pimToken.AccessToken, tenantID)
-	if err != nil {
-		l.Logger.Error("Failed to collect PIM data", "error", err)
-		return err
+		mark := l.collectionErrors.mark()
+		pimData, err = l.collectAllPIMData(pimAccessToken, graphToken.AccessToken, tenantID)
+		if err != nil {
+			l.Logger.Error("Failed to collect PIM data", "error", err)
+			return err
+		}
+
+		message.Info("PIM collector completed successfully! Collected %d assignment types", len(pimData))
+		l.checkpoint.save(checkpointPIM, pimData, l.collectionErrors.since(mark))
 	}
 
-	message.Info("PIM collector completed successfully! Collected %d assignment types", len(pimData))
+	// STEP 2.1: Collect the beta-only datasets the user opted into
+	if len(betaSelected) > 0 {
+		collectBetaCollections(l.Logger, &l.collectionErrors, betaSelected, func(version, endpoint string) ([]interface{}, error) {
+			return l.collectPaginatedGraphData(graphToken.AccessToken, version, endpoint)
+		}, azureADData, pimData)
+	}
+	// The beta datasets extend both sections, so they are streamed once STEP 2.1 completes
+	l.ndjson.streamDirectory(azureADData, l.collectionErrors.list())
+	l.ndjson.streamSection("pim", "", pimData)
 
 	// STEP 2.5: Collect Management Groups hierarchy (once for the entire tenant)
 	l.Logger.Info("Collecting Management Groups hierarchy (once for all subscriptions)")
 	message.Info("Collecting Management Groups hierarchy...")
 
-	managementToken, err := helpers.GetAzureRMToken(refreshToken, tenantID, proxyURL)
+	managementToken, err := tokens.armToken()
 	if err != nil {
 		l.Logger.Error("Failed to get management token for Management Groups", "error", err)
 		return fmt.Errorf("failed to get management token for Management Groups: %v", err)
 	}
 
-	managementGroupsData, err := l.getManagementGroupHierarchyViaResourceGraph(managementToken.AccessToken, tenantID, proxyURL)
-	if err != nil {
-		l.Logger.Warn("Failed to collect Management Groups data, continuing without it", "error", err)
-		message.Info("Warning: Failed to collect Management Groups data: %v", err)
-		managementGroupsData = []interface{}{}
+	var managementGroupsData []interface{}
+	if !l.checkpoint.restore(checkpointManagementGroups, &managementGroupsData, &l.collectionErrors) {
+		mark := l.collectionErrors.mark()
+		managementGroupsData, err = l.getManagementGroupHierarchyViaResourceGraph(managementToken.AccessToken, tenantID)
+		if err != nil {
+			l.Logger.Warn("Failed to collect Management Groups data, continuing without it", "error", err)
+			message.Info("Warning: Failed to collect Management Groups data: %v", err)
+			l.collectionErrors.record("management_groups", "tenant", err)
+			managementGroupsData = []interface{}{}
+		}
+		l.checkpoint.save(checkpointManagementGroups, managementGroupsData, l.collectionErrors.since(mark))
 	}
+	l.ndjson.streamList("management_groups", managementGroupsData)
 
 	message.Info("Management Groups collector completed! Collected %d management groups", len(managementGroupsData))
 
-	// STEP 3: Process subscriptions in parallel with 1 worker (Azure RM only) - TESTING CONCURRENCY
-	l.Logger.Info("Processing %d subscriptions with 1 worker", len(subscriptionIDs))
-	allSubscriptionData := l.processSubscriptionsParallel(subscriptionIDs, refreshToken, tenantID, proxyURL)
+	// STEP 2.6: Collect management group and tenant-scoped RBAC assignments (once for the entire tenant)
+	l.Logger.Info("Collecting management group and tenant RBAC assignments via Resource Graph")
+	message.Info("Collecting management group/tenant RBAC assignments...")
 
-	// Create consolidated data structure
-	consolidatedData := map[string]interface{}{
-		"collection_metadata": map[string]interface{}{
-			"tenant_id":               tenantID,
-			"collection_timestamp":    time.Now().UTC().Format("2006-01-02T15:04:05Z"),
-			"subscriptions_processed": len(subscriptionIDs),
-			"collector_versions": map[string]interface{}{
-				"nebula_collector": "comprehensive",
-				"graph_collector":     "completed",
-				"pim_collector":       "completed",
-				"azurerm_collector":   "completed",
-			},
-		},
-		"azure_ad":           azureADData,
-		"pim":                pimData,
-		"management_groups":  managementGroupsData,
-		"azure_resources":    allSubscriptionData,
+	var mgRBACData []interface{}
+	if !l.checkpoint.restore(checkpointManagementGroupRBAC, &mgRBACData, &l.collectionErrors) {
+		mark := l.collectionErrors.mark()
+		mgRBACData, err = l.getManagementGroupAndTenantRBACViaARG(managementToken.AccessToken)
+		if err != nil {
+			l.Logger.Warn("Failed to collect MG/tenant RBAC, continuing without it", "error", err)
+			message.Info("Warning: Failed to collect MG/tenant RBAC: %v", err)
+			l.collectionErrors.record("management_group_rbac", "tenant", err)
+			mgRBACData = []interface{}{}
+		}
+		l.checkpoint.save(checkpointManagementGroupRBAC, mgRBACData, l.collectionErrors.since(mark))
 	}
+	l.ndjson.streamList("management_group_rbac", mgRBACData)
 
-	// Calculate totals for summary
-	adTotal := 0
-	for _, data := range azureADData {
-		if dataSlice, ok := data.([]interface{}); ok {
-			adTotal += len(dataSlice)
+	message.Info("MG/tenant RBAC collection completed! Collected %d assignments", len(mgRBACData))
+
+	// STEP 2.7: With --prior-dump, only subscriptions changed since the watermark are collected again
+	collectSubscriptionIDs := subscriptionIDs
+	var carriedSubscriptionIDs []string
+	if incremental != nil {
+		message.Info("Checking activity logs for subscriptions changed since the prior dump...")
+		if activityToken, err := tokens.armToken(); err != nil {
+			l.Logger.Error("Failed to get management token for activity logs, collecting every subscription", "error", err)
+			l.collectionErrors.record(activityLogDataset, "tenant", err)
+		} else {
+			collectSubscriptionIDs, carriedSubscriptionIDs = incremental.partition(l.Logger, &l.collectionErrors, subscriptionIDs, time.Now().UTC(), func(url string) ([]interface{}, error) {
+				return l.collectPaginatedARMData(activityToken.AccessToken, url)
+			})
 		}
 	}
 
-	pimTotal := 0
-	for _, data := range pimData {
-		if dataSlice, ok := data.([]interface{}); ok {
-			pimTotal += len(dataSlice)
+	// STEP 3: Process subscriptions in parallel (Azure RM only)
+	l.Logger.Info(fmt.Sprintf("Processing %d subscriptions with %d workers", len(collectSubscriptionIDs), min(l.concurrency, len(collectSubscriptionIDs))))
+	allSubscriptionData := l.processSubscriptionsParallel(collectSubscriptionIDs, tokens)
+	var incrementalMetadata *IncrementalCollection
+	if incremental != nil {
+		incrementalMetadata = incremental.carryForward(allSubscriptionData, collectSubscriptionIDs, carriedSubscriptionIDs)
+	}
+
+	// STEP 4: Collect management locks for every subscription
+	l.Logger.Info("Collecting resource locks")
+	message.Info("Collecting resource locks...")
+	resourceLocks := []interface{}{}
+	if !l.checkpoint.restore(checkpointResourceLocks, &resourceLocks, &l.collectionErrors) {
+		if locksToken, err := tokens.armToken(); err != nil {
+			l.Logger.Error("Failed to get management token for resource locks", "error", err)
+			l.collectionErrors.record("resource_locks", "tenant", err)
+		} else {
+			mark := l.collectionErrors.mark()
+			resourceLocks = l.collectResourceLocks(locksToken.AccessToken, subscriptionIDs)
+			l.checkpoint.save(checkpointResourceLocks, resourceLocks, l.collectionErrors.since(mark))
 		}
 	}
+	l.ndjson.streamList("resource_locks", resourceLocks)
+	message.Info("Resource lock collection completed! Collected %d locks", len(resourceLocks))
 
-	azurermTotal := 0
-	for _, subData := range allSubscriptionData {
-		if subDataMap, ok := subData.(map[string]interface{}); ok {
-			for _, data := range subDataMap {
-				if dataSlice, ok := data.([]interface{}); ok {
-					azurermTotal += len(dataSlice)
-				}
-			}
+	// STEP 5: Collect PIM for Azure resources eligible and active role assignments
+	l.Logger.Info("Collecting PIM for Azure resources role assignments")
+	message.Info("Collecting PIM for Azure resources role assignments...")
+	if armPIMToken, err := tokens.armToken(); err != nil {
+		l.Logger.Error("Failed to get management token for PIM for Azure resources", "error", err)
+		for _, collection := range armPIMCollections {
+			l.collectionErrors.record(collection.section, "tenant", err)
 		}
+	} else {
+		collectARMPIMData(l.Logger, &l.collectionErrors, subscriptionIDs, func(url string) ([]interface{}, error) {
+			return l.collectPaginatedARMData(armPIMToken.AccessToken, url)
+		}, pimData)
 	}
 
-	managementGroupsTotal := len(managementGroupsData)
+	// STEP 6: Run the Resource Graph detections from --arg-rules
+	if len(argRules) > 0 && len(subscriptionIDs) > 0 {
+		message.Info("Running %d ARG rules...", len(argRules))
+		if argToken, err := tokens.armToken(); err != nil {
+			l.Logger.Error("Failed to get management token for ARG rules", "error", err)
+			l.collectionErrors.record("argRuleFindings", "tenant", err)
+		} else {
+			azureADData["argRuleFindings"] = runARGRules(l.Logger, &l.collectionErrors, argRules, func(query string) ([]interface{}, error) {
+				return l.queryResourceGraph(argToken.AccessToken, subscriptionIDs, query)
+			})
+		}
+	}
+
+	// Create consolidated data structure
+	consolidatedData := &ConsolidatedOutput{
+		CollectionMetadata: CollectionMetadata{
+			SchemaVersion:          ConsolidatedSchemaVersion,
+			TenantID:               tenantID,
+			CollectionTimestamp:    time.Now().UTC().Format("2006-01-02T15:04:05Z"),
+			SubscriptionsProcessed: len(subscriptionIDs),
+			CollectorVersions:      newCollectorVersions("comprehensive"),
+			RBACDeduplication:      l.rbacDedup.stats(),
+			IncrementalCollection:  incrementalMetadata,
+			GraphObjects:           l.graphObjects.names(),
+		},
+		AzureAD:             azureADData,
+		PIM:                 pimData,
+		ManagementGroups:    managementGroupsData,
+		ManagementGroupRBAC: mgRBACData,
+		AzureResources:      allSubscriptionData,
+		ResourceLocks:       resourceLocks,
+		AuditLogs:           auditLogs,
+		CollectionErrors:    l.collectionErrors.list(),
+	}
 
-	// Add summary metadata
-	consolidatedData["collection_metadata"].(map[string]interface{})["data_summary"] = map[string]interface{}{
-		"total_azure_ad_objects":     adTotal,
-		"total_pim_objects":          pimTotal,
-		"total_management_groups":    managementGroupsTotal,
-		"total_azurerm_objects":      azurermTotal,
-		"total_objects":              adTotal + pimTotal + managementGroupsTotal + azurermTotal,
+	orphanedAssignments, orphansChecked := markOrphanedAssignments(l.Logger, consolidatedData)
+	consolidatedData.ApplySample(l.sampleSize)
+	consolidatedData.Normalize()
+	consolidatedData.PIM["eligible_activations"] = buildEligibleActivations(consolidatedData)
+	evaluateFindingRules(consolidatedData, selectedRules)
+	if baseline != nil {
+		compareFindingsBaseline(consolidatedData, selectedRules, baseline, baselineFile)
 	}
 
+	// Calculate totals for summary
+	summary := consolidatedData.Summarize()
+	adTotal := summary.TotalAzureADObjects
+	pimTotal := summary.TotalPIMObjects
+	managementGroupsTotal := summary.TotalManagementGroups
+	azurermTotal := summary.TotalAzureRMObjects
+
 	message.Info("=== Azure IAM Collection Summary ====")
 	message.Info("Tenant: %s", tenantID)
 	message.Info("Total Azure AD objects: %d", adTotal)
 	message.Info("Total PIM objects: %d", pimTotal)
 	message.Info("Total Management Groups: %d", managementGroupsTotal)
+	message.Info("Total MG/tenant RBAC assignments: %d", summary.TotalMGRBACAssignments)
 	message.Info("Total AzureRM objects: %d", azurermTotal)
+	logRBACDeduplication(consolidatedData.CollectionMetadata.RBACDeduplication)
+	if orphansChecked {
+		message.Info("Orphaned role assignments (principal no longer in the directory): %d", orphanedAssignments)
+	}
+	if auditLogs != nil {
+		message.Info("Total sign-in log entries: %d", len(auditLogs.SignIns))
+		message.Info("Total directory audit entries: %d", len(auditLogs.DirectoryAudits))
+	}
+	printCollectionErrorSummary(consolidatedData.CollectionErrors)
+	logSampledRun(l.sampleSize)
+	if baseline != nil {
+		logBaselineComparison(l.Logger, consolidatedData, selectedRules)
+	} else {
+		logFindingRules(l.Logger, consolidatedData, selectedRules)
+	}
+	if writeBaseline, _ := cfg.As[string](l.Arg("write-baseline")); writeBaseline != "" {
+		writeFindingsBaseline(l.Logger, consolidatedData, selectedRules, writeBaseline)
+	}
+	if summaryOut, _ := cfg.As[string](l.Arg("summary-out")); summaryOut != "" {
+		writeExecutiveSummary(l.Logger, consolidatedData, selectedRules, summaryOut)
+	}
+	consolidatedData.Project(projectFields)
+	if err := l.shardWriter.finish(consolidatedData); err != nil {
+		return err
+	}
+	if err := l.ndjson.finish(consolidatedData); err != nil {
+		return err
+	}
+	l.checkpoint.remove()
 	message.Info("🎉 Azure IAM collection completed successfully!")
 
 	// Send consolidated data to outputter
@@ -299,24 +643,35 @@ func (l *IAMComprehensiveCollectorLink) Process(input interface{}) error {
 	return nil
 }
 
+// sendReanalyzedDump handles --from-dump: the detections run over a saved dump
+// and nothing is collected
+func (l *IAMComprehensiveCollectorLink) sendReanalyzedDump(path string, selectedRules []rules.Rule, baseline *rules.Baseline, baselineFile string) error {
+	writeBaseline, _ := cfg.As[string](l.Arg("write-baseline"))
+	summaryOut, _ := cfg.As[string](l.Arg("summary-out"))
+	project, _ := cfg.As[[]string](l.Arg("project"))
+	output, err := reanalyzeDump(l.Logger, path, dumpReanalysis{
+		selected:      selectedRules,
+		baseline:      baseline,
+		baselineFile:  baselineFile,
+		writeBaseline: writeBaseline,
+		summaryOut:    summaryOut,
+		project:       project,
+	})
+	if err != nil {
+		return err
+	}
+	l.Send(output)
+	return nil
+}
+
 
 // listSubscriptionsWithToken lists subscriptions using the management token directly
-func (l *IAMComprehensiveCollectorLink) listSubscriptionsWithToken(accessToken, proxyURL string) ([]string, error) {
-	subscriptionsURL := "https://management.azure.com/subscriptions?api-version=2022-12-01"
-
-	client := &http.Client{Timeout: 30 * time.Second}
+func (l *IAMComprehensiveCollectorLink) listSubscriptionsWithToken(accessToken string) ([]string, error) {
+	subscriptionsURL := l.cloud.armURL("/subscriptions?api-version=2022-12-01")
 
-	// Apply proxy if specified
-	if proxyURL != "" {
-		proxyParsedURL, err := url.Parse(proxyURL)
-		if err != nil {
-			return nil, fmt.Errorf("invalid proxy URL: %v", err)
-		}
-		transport := &http.Transport{
-			Proxy:           http.ProxyURL(proxyParsedURL),
-			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
-		}
-		client.Transport = transport
+	client, err := l.sharedHTTPClient()
+	if err != nil {
+		return nil, err
 	}
 
 	req, err := http.NewRequestWithContext(l.Context(), "GET", subscriptionsURL, nil)
@@ -334,7 +689,7 @@ func (l *IAMComprehensiveCollectorLink) listSubscriptionsWithToken(accessToken,
 	defer resp.Body.Close()
 
 	if resp.StatusCode != 200 {
-		return nil, fmt.Errorf("API call failed with status %d", resp.StatusCode)
+		return nil, newAPIStatusError(resp)
 	}
 
 	var result struct {
@@ -360,8 +715,8 @@ func (l *IAMComprehensiveCollectorLink) listSubscriptionsWithToken(accessToken,
 }
 
 // getManagementGroupHierarchyViaResourceGraph gets management groups and subscriptions with full hierarchy using Azure Resource Graph
-func (l *IAMComprehensiveCollectorLink) getManagementGroupHierarchyViaResourceGraph(accessToken, tenantID, proxyURL string) ([]interface{}, error) {
-	resourceGraphURL := "https://management.azure.com/providers/Microsoft.ResourceGraph/resources?api-version=2021-03-01"
+func (l *IAMComprehensiveCollectorLink) getManagementGroupHierarchyViaResourceGraph(accessToken, tenantID string) ([]interface{}, error) {
+	resourceGraphURL := l.cloud.armURL(resourceGraphQueryURL)
 
 	// KQL query to get Management Groups and Subscriptions with hierarchy
 	kqlQuery := fmt.Sprintf(`
@@ -400,19 +755,9 @@ func (l *IAMComprehensiveCollectorLink) getManagementGroupHierarchyViaResourceGr
 		return nil, fmt.Errorf("failed to marshal request body: %v", err)
 	}
 
-	client := &http.Client{Timeout: 60 * time.Second}
-
-	// Apply proxy if specified
-	if proxyURL != "" {
-		proxyParsedURL, err := url.Parse(proxyURL)
-		if err != nil {
-			return nil, fmt.Errorf("invalid proxy URL: %v", err)
-		}
-		transport := &http.Transport{
-			Proxy:           http.ProxyURL(proxyParsedURL),
-			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
-		}
-		client.Transport = transport
+	client, err := l.sharedHTTPClient()
+	if err != nil {
+		return nil, err
 	}
 
 	req, err := http.NewRequestWithContext(l.Context(), "POST", resourceGraphURL, bytes.NewBuffer(requestBodyBytes))
@@ -431,7 +776,7 @@ func (l *IAMComprehensiveCollectorLink) getManagementGroupHierarchyViaResourceGr
 
 	if resp.StatusCode != 200 {
 		bodyBytes, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("Resource Graph API call failed with status %d: %s", resp.StatusCode, string(bodyBytes))
+		return nil, fmt.Errorf("Resource Graph query failed: %w", apiStatusErrorFromBody(resp.StatusCode, bodyBytes))
 	}
 
 	var result struct {
@@ -464,23 +809,79 @@ func (l *IAMComprehensiveCollectorLink) getManagementGroupHierarchyViaResourceGr
 	return result.Data, nil
 }
 
-// listManagementGroupsWithToken lists management groups and their hierarchy using the management token (DEPRECATED - use getManagementGroupHierarchyViaResourceGraph instead)
-func (l *IAMComprehensiveCollectorLink) listManagementGroupsWithToken(accessToken, proxyURL string) ([]interface{}, error) {
-	managementGroupsURL := "https://management.azure.com/providers/Microsoft.Management/managementGroups?api-version=2021-04-01&$expand=children&$recurse=true"
+// getManagementGroupAndTenantRBACViaARG gets RBAC assignments scoped to management
+// groups and the tenant root. The per-subscription query filters on subscriptionId,
+// which these assignments do not have, so they are collected once per tenant.
+func (l *IAMComprehensiveCollectorLink) getManagementGroupAndTenantRBACViaARG(accessToken string) ([]interface{}, error) {
+	resourceGraphURL := l.cloud.armURL(resourceGraphQueryURL)
+
+	kqlQuery := `
+		authorizationresources
+		| where type =~ 'microsoft.authorization/roleassignments'
+		| where isempty(subscriptionId)
+			or properties.scope startswith '/providers/Microsoft.Management/managementGroups/'
+			or properties.scope == '/'
+		| extend principalId = tostring(properties.principalId)
+		| extend roleDefinitionId = tostring(properties.roleDefinitionId)
+		| extend scope = tostring(properties.scope)
+		| extend principalType = tostring(properties.principalType)
+		| project id, name, subscriptionId, principalId, roleDefinitionId, scope, principalType, properties`
+
+	requestBodyBytes, err := json.Marshal(map[string]interface{}{"query": kqlQuery})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request body: %v", err)
+	}
+
+	client, err := l.sharedHTTPClient()
+	if err != nil {
+		return nil, err
+	}
 
-	client := &http.Client{Timeout: 30 * time.Second}
+	req, err := http.NewRequestWithContext(l.Context(), "POST", resourceGraphURL, bytes.NewBuffer(requestBodyBytes))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %v", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set("Content-Type", "application/json")
 
-	// Apply proxy if specified
-	if proxyURL != "" {
-		proxyParsedURL, err := url.Parse(proxyURL)
-		if err != nil {
-			return nil, fmt.Errorf("invalid proxy URL: %v", err)
-		}
-		transport := &http.Transport{
-			Proxy:           http.ProxyURL(proxyParsedURL),
-			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("Resource Graph MG/tenant RBAC query failed: %w", apiStatusErrorFromBody(resp.StatusCode, bodyBytes))
+	}
+
+	var result struct {
+		Data []interface{} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode Resource Graph response: %v", err)
+	}
+
+	// Normalize scopes on ingestion, matching the SDK collector
+	for _, assignment := range result.Data {
+		if assignmentMap, ok := assignment.(map[string]interface{}); ok {
+			if scope, ok := assignmentMap["scope"].(string); ok {
+				assignmentMap["scope"] = normalizeScope(scope)
+			}
 		}
-		client.Transport = transport
+	}
+
+	l.Logger.Info("Retrieved management group and tenant RBAC assignments via Resource Graph", "total", len(result.Data))
+	return result.Data, nil
+}
+
+// listManagementGroupsWithToken lists management groups and their hierarchy using the management token (DEPRECATED - use getManagementGroupHierarchyViaResourceGraph instead)
+func (l *IAMComprehensiveCollectorLink) listManagementGroupsWithToken(accessToken string) ([]interface{}, error) {
+	managementGroupsURL := l.cloud.armURL("/providers/Microsoft.Management/managementGroups?api-version=2021-04-01&$expand=children&$recurse=true")
+
+	client, err := l.sharedHTTPClient()
+	if err != nil {
+		return nil, err
 	}
 
 	req, err := http.NewRequestWithContext(l.Context(), "GET", managementGroupsURL, nil)
@@ -499,7 +900,7 @@ func (l *IAMComprehensiveCollectorLink) listManagementGroupsWithToken(accessToke
 
 	if resp.StatusCode != 200 {
 		bodyBytes, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API call failed with status %d: %s", resp.StatusCode, string(bodyBytes))
+		return nil, apiStatusErrorFromBody(resp.StatusCode, bodyBytes)
 	}
 
 	var result struct {
@@ -515,8 +916,8 @@ func (l *IAMComprehensiveCollectorLink) listManagementGroupsWithToken(accessToke
 }
 
 // getAllRBACAssignmentsViaARG gets ALL RBAC assignments across subscriptions using Azure Resource Graph
-func (l *IAMComprehensiveCollectorLink) getAllRBACAssignmentsViaARG(accessToken string, subscriptionIDs []string, proxyURL string) (map[string][]interface{}, error) {
-	resourceGraphURL := "https://management.azure.com/providers/Microsoft.ResourceGraph/resources?api-version=2021-03-01"
+func (l *IAMComprehensiveCollectorLink) getAllRBACAssignmentsViaARG(accessToken string, subscriptionIDs []string) (map[string][]interface{}, error) {
+	resourceGraphURL := l.cloud.armURL(resourceGraphQueryURL)
 
 	// Build KQL query with subscription filtering
 	var kqlQuery string
@@ -554,19 +955,9 @@ func (l *IAMComprehensiveCollectorLink) getAllRBACAssignmentsViaARG(accessToken
 		return nil, fmt.Errorf("failed to marshal request body: %v", err)
 	}
 
-	client := &http.Client{Timeout: 60 * time.Second}
-
-	// Apply proxy if specified
-	if proxyURL != "" {
-		proxyParsedURL, err := url.Parse(proxyURL)
-		if err != nil {
-			return nil, fmt.Errorf("invalid proxy URL: %v", err)
-		}
-		transport := &http.Transport{
-			Proxy:           http.ProxyURL(proxyParsedURL),
-			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
-		}
-		client.Transport = transport
+	client, err := l.sharedHTTPClient()
+	if err != nil {
+		return nil, err
 	}
 
 	req, err := http.NewRequestWithContext(l.Context(), "POST", resourceGraphURL, bytes.NewBuffer(requestBodyBytes))
@@ -585,7 +976,7 @@ func (l *IAMComprehensiveCollectorLink) getAllRBACAssignmentsViaARG(accessToken
 
 	if resp.StatusCode != 200 {
 		bodyBytes, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("Resource Graph API call failed with status %d: %s", resp.StatusCode, string(bodyBytes))
+		return nil, fmt.Errorf("Resource Graph query failed: %w", apiStatusErrorFromBody(resp.StatusCode, bodyBytes))
 	}
 
 	var result struct {
@@ -598,45 +989,7 @@ func (l *IAMComprehensiveCollectorLink) getAllRBACAssignmentsViaARG(accessToken
 
 	l.Logger.Info("Retrieved RBAC assignments via Resource Graph", "total_assignments", len(result.Data))
 
-	// Group assignments by scope type
-	groupedAssignments := map[string][]interface{}{
-		"subscription":    {},
-		"resourceGroup":   {},
-		"resource":        {},
-		"managementGroup": {},
-		"tenant":          {},
-	}
-
-	for _, assignment := range result.Data {
-		if assignmentMap, ok := assignment.(map[string]interface{}); ok {
-			scope, exists := assignmentMap["scope"]
-			if !exists {
-				continue
-			}
-
-			scopeStr := normalizeScope(fmt.Sprintf("%v", scope))
-			assignmentMap["scope"] = scopeStr
-
-			// Determine scope type based on scope path structure
-			if strings.HasPrefix(scopeStr, "/providers/microsoft.management/managementgroups/") {
-				groupedAssignments["managementGroup"] = append(groupedAssignments["managementGroup"], assignment)
-			} else if scopeStr == "/" || scopeStr == "" {
-				groupedAssignments["tenant"] = append(groupedAssignments["tenant"], assignment)
-			} else if strings.Count(scopeStr, "/") == 2 {
-				// /subscriptions/{subscription-id} = subscription level
-				groupedAssignments["subscription"] = append(groupedAssignments["subscription"], assignment)
-			} else if strings.Contains(scopeStr, "/resourcegroups/") && strings.Count(scopeStr, "/") == 4 {
-				// /subscriptions/{sub}/resourcegroups/{rg} = resource group level
-				groupedAssignments["resourceGroup"] = append(groupedAssignments["resourceGroup"], assignment)
-			} else if strings.Contains(scopeStr, "/resourcegroups/") && strings.Count(scopeStr, "/") > 4 {
-				// /subscriptions/{sub}/resourcegroups/{rg}/providers/... = resource level
-				groupedAssignments["resource"] = append(groupedAssignments["resource"], assignment)
-			} else {
-				// Default to subscription level if unsure
-				groupedAssignments["subscription"] = append(groupedAssignments["subscription"], assignment)
-			}
-		}
-	}
+	groupedAssignments := groupRBACAssignmentsByScope(result.Data)
 
 	l.Logger.Info("RBAC assignment breakdown",
 		"subscription_level", len(groupedAssignments["subscription"]),
@@ -649,8 +1002,8 @@ func (l *IAMComprehensiveCollectorLink) getAllRBACAssignmentsViaARG(accessToken
 }
 
 // getAllResourceGroupsViaARG gets all resource groups across subscriptions using Azure Resource Graph
-func (l *IAMComprehensiveCollectorLink) getAllResourceGroupsViaARG(accessToken string, subscriptionIDs []string, proxyURL string) ([]interface{}, error) {
-	resourceGraphURL := "https://management.azure.com/providers/Microsoft.ResourceGraph/resources?api-version=2021-03-01"
+func (l *IAMComprehensiveCollectorLink) getAllResourceGroupsViaARG(accessToken string, subscriptionIDs []string) ([]interface{}, error) {
+	resourceGraphURL := l.cloud.armURL(resourceGraphQueryURL)
 
 	// Build KQL query with subscription filtering
 	var kqlQuery string
@@ -680,19 +1033,9 @@ func (l *IAMComprehensiveCollectorLink) getAllResourceGroupsViaARG(accessToken s
 		return nil, fmt.Errorf("failed to marshal request body: %v", err)
 	}
 
-	client := &http.Client{Timeout: 60 * time.Second}
-
-	// Apply proxy if specified
-	if proxyURL != "" {
-		proxyParsedURL, err := url.Parse(proxyURL)
-		if err != nil {
-			return nil, fmt.Errorf("invalid proxy URL: %v", err)
-		}
-		transport := &http.Transport{
-			Proxy:           http.ProxyURL(proxyParsedURL),
-			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
-		}
-		client.Transport = transport
+	client, err := l.sharedHTTPClient()
+	if err != nil {
+		return nil, err
 	}
 
 	req, err := http.NewRequestWithContext(l.Context(), "POST", resourceGraphURL, bytes.NewBuffer(requestBodyBytes))
@@ -711,7 +1054,7 @@ func (l *IAMComprehensiveCollectorLink) getAllResourceGroupsViaARG(accessToken s
 
 	if resp.StatusCode != 200 {
 		bodyBytes, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("Resource Graph API call failed with status %d: %s", resp.StatusCode, string(bodyBytes))
+		return nil, fmt.Errorf("Resource Graph query failed: %w", apiStatusErrorFromBody(resp.StatusCode, bodyBytes))
 	}
 
 	var result struct {
@@ -740,85 +1083,21 @@ func (l *IAMComprehensiveCollectorLink) getAllResourceGroupsViaARG(accessToken s
 	return result.Data, nil
 }
 
-// getAllResourcesViaARGOptimized gets all Azure resources with a single ARG query (simplified)
-func (l *IAMComprehensiveCollectorLink) getAllResourcesViaARGOptimized(accessToken string, subscriptionIDs []string, proxyURL string) ([]interface{}, error) {
-	resourceGraphURL := "https://management.azure.com/providers/Microsoft.ResourceGraph/resources?api-version=2021-03-01"
-
-	// Single query to get all resources (no type discovery needed)
-	var resourceQuery string
-	if len(subscriptionIDs) > 0 {
-		subscriptionFilter := "'" + strings.Join(subscriptionIDs, "','") + "'"
-		resourceQuery = fmt.Sprintf(`
-			resources
-			| where subscriptionId in (%s)
-			| project id, name, type, location, resourceGroup, subscriptionId, tags, identity, properties, zones, kind, sku, plan
-			| order by subscriptionId asc, type asc`, subscriptionFilter)
-	} else {
-		resourceQuery = `
-			resources
-			| project id, name, type, location, resourceGroup, subscriptionId, tags, identity, properties, zones, kind, sku, plan
-			| order by subscriptionId asc, type asc`
-	}
-
-	l.Logger.Info("Executing single ARG query for all resources")
-
-	client := &http.Client{Timeout: 120 * time.Second} // Increased timeout for large result sets
-
-	// Apply proxy if specified
-	if proxyURL != "" {
-		proxyParsedURL, err := url.Parse(proxyURL)
-		if err != nil {
-			return nil, fmt.Errorf("invalid proxy URL: %v", err)
-		}
-		transport := &http.Transport{
-			Proxy:           http.ProxyURL(proxyParsedURL),
-			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
-		}
-		client.Transport = transport
-	}
-
-	resourceRequestBody := map[string]interface{}{
-		"query": resourceQuery,
-	}
-
-	resourceRequestBodyBytes, err := json.Marshal(resourceRequestBody)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal resource request body: %v", err)
-	}
-
-	resourceReq, err := http.NewRequestWithContext(l.Context(), "POST", resourceGraphURL, bytes.NewBuffer(resourceRequestBodyBytes))
+// getAllResourcesViaARGOptimized gets all Azure resources via Resource Graph,
+// discovering the resource types first and fetching them in shards
+func (l *IAMComprehensiveCollectorLink) getAllResourcesViaARGOptimized(accessToken string, subscriptionIDs []string) ([]interface{}, error) {
+	resources, err := l.collectResourcesByTypeShards(accessToken, subscriptionIDs,
+		"id, name, type, location, resourceGroup, subscriptionId, tags, identity, properties, zones, kind, sku, plan")
 	if err != nil {
-		return nil, fmt.Errorf("failed to create resource request: %v", err)
-	}
-
-	resourceReq.Header.Set("Authorization", "Bearer "+accessToken)
-	resourceReq.Header.Set("Content-Type", "application/json")
-
-	resourceResp, err := client.Do(resourceReq)
-	if err != nil {
-		return nil, fmt.Errorf("failed to make resource request: %v", err)
-	}
-	defer resourceResp.Body.Close()
-
-	if resourceResp.StatusCode != 200 {
-		bodyBytes, _ := io.ReadAll(resourceResp.Body)
-		return nil, fmt.Errorf("Resource query API call failed with status %d: %s", resourceResp.StatusCode, string(bodyBytes))
-	}
-
-	var resourceResult struct {
-		Data []interface{} `json:"data"`
-	}
-
-	if err := json.NewDecoder(resourceResp.Body).Decode(&resourceResult); err != nil {
-		return nil, fmt.Errorf("failed to decode resource response: %v", err)
+		return nil, err
 	}
 
-	l.Logger.Info("Retrieved Azure resources via single ARG query", "total_resources", len(resourceResult.Data))
+	l.Logger.Info("Retrieved Azure resources via Resource Graph", "total_resources", len(resources))
 
 	// Group by resource type for logging
 	typeCounts := make(map[string]int)
 	subCounts := make(map[string]int)
-	for _, resource := range resourceResult.Data {
+	for _, resource := range resources {
 		if resourceMap, ok := resource.(map[string]interface{}); ok {
 			if resType, exists := resourceMap["type"]; exists {
 				resTypeStr := fmt.Sprintf("%v", resType)
@@ -843,10 +1122,11 @@ func (l *IAMComprehensiveCollectorLink) getAllResourcesViaARGOptimized(accessTok
 	}
 	l.Logger.Info("Top resource types", "types", topTypes)
 
-	return resourceResult.Data, nil
+	return resources, nil
 }
 
-// collectAllGraphData collects all Azure AD data using Microsoft Graph API
+// collectAllGraphData collects all Azure AD data using Microsoft Graph API,
+// skipping the object types left out of --graph-objects
 func (l *IAMComprehensiveCollectorLink) collectAllGraphData(accessToken string) (map[string]interface{}, error) {
 	azureADData := make(map[string]interface{})
 
@@ -860,7 +1140,7 @@ func (l *IAMComprehensiveCollectorLink) collectAllGraphData(accessToken string)
 		// Groups - include all fields needed by Neo4j importer
 		{"groups", "/groups?$select=id,displayName,description,groupTypes,membershipRule,mailEnabled,securityEnabled,createdDateTime"},
 		// Service Principals - include all fields needed by Neo4j importer
-		{"servicePrincipals", "/servicePrincipals?$select=id,appId,displayName,servicePrincipalType,accountEnabled,createdDateTime,replyUrls,signInAudience"},
+		{"servicePrincipals", "/servicePrincipals?$select=id,appId,displayName,servicePrincipalType,accountEnabled,createdDateTime,replyUrls,signInAudience,appOwnerOrganizationId,verifiedPublisher"},
 		// Applications - include all fields needed by Neo4j importer including credentials
 		{"applications", "/applications?$select=id,appId,displayName,createdDateTime,signInAudience,replyUrls,keyCredentials,passwordCredentials"},
 		// Devices - include all fields needed by Neo4j importer
@@ -870,15 +1150,20 @@ func (l *IAMComprehensiveCollectorLink) collectAllGraphData(accessToken string)
 		// Role definitions - needed for permission expansion in Neo4j importer
 		{"roleDefinitions", "/roleManagement/directory/roleDefinitions?$select=id,displayName,description,rolePermissions,templateId,isBuiltIn"},
 		{"conditionalAccessPolicies", "/identity/conditionalAccess/policies"},
+		{"tokenLifetimePolicies", tokenLifetimePoliciesEndpoint},
 	}
 
 	for _, collection := range collections {
+		if !l.graphObjects.wants(collection.name) {
+			continue
+		}
 		l.Logger.Info(fmt.Sprintf("Collecting %s", collection.name))
 		message.Info("Collecting %s from Graph API...", collection.name)
 
-		data, err := l.collectPaginatedGraphData(accessToken, collection.endpoint)
+		data, err := l.collectPaginatedGraphData(accessToken, graphV1, collection.endpoint)
 		if err != nil {
 			l.Logger.Error(fmt.Sprintf("Failed to collect %s", collection.name), "error", err)
+			l.collectionErrors.record(collection.name, "tenant", err)
 			continue
 		}
 
@@ -886,73 +1171,105 @@ func (l *IAMComprehensiveCollectorLink) collectAllGraphData(accessToken string)
 		l.Logger.Info(fmt.Sprintf("Collected %d %s", len(data), collection.name))
 	}
 
+	// Authentication methods policy is a single object, not a collection
+	if l.graphObjects.wants("authenticationMethodConfigurations") {
+		message.Info("Collecting authenticationMethodConfigurations from Graph API...")
+		authMethodConfigurations, err := l.collectAuthenticationMethodConfigurations(accessToken)
+		if err != nil {
+			l.Logger.Error("Failed to collect authentication methods policy", "error", err)
+			l.collectionErrors.record("authenticationMethodConfigurations", "tenant", err)
+		} else {
+			azureADData["authenticationMethodConfigurations"] = authMethodConfigurations
+		}
+	}
+
 	// Collect relationships
 	l.Logger.Info("Collecting relationships")
 
 	// Group memberships
-	groupMemberships, err := l.collectGroupMemberships(accessToken)
-	if err != nil {
-		l.Logger.Error("Failed to collect group memberships", "error", err)
-	} else {
-		azureADData["groupMemberships"] = groupMemberships
+	if l.graphObjects.wants("groupMemberships") {
+		groupMemberships, err := l.collectGroupMemberships(accessToken)
+		if err != nil {
+			l.Logger.Error("Failed to collect group memberships", "error", err)
+			l.collectionErrors.record("groupMemberships", "tenant", err)
+		} else {
+			azureADData["groupMemberships"] = groupMemberships
+		}
 	}
 
 	// Group ownership
-	groupOwnership, err := l.collectGroupOwnership(accessToken)
-	if err != nil {
-		l.Logger.Error("Failed to collect group ownership", "error", err)
-	} else {
-		azureADData["groupOwnership"] = groupOwnership
+	if l.graphObjects.wants("groupOwnership") {
+		groupOwnership, err := l.collectGroupOwnership(accessToken)
+		if err != nil {
+			l.Logger.Error("Failed to collect group ownership", "error", err)
+			l.collectionErrors.record("groupOwnership", "tenant", err)
+		} else {
+			azureADData["groupOwnership"] = groupOwnership
+		}
 	}
 
 	// Service Principal ownership
-	servicePrincipalOwnership, err := l.collectServicePrincipalOwnership(accessToken)
-	if err != nil {
-		l.Logger.Error("Failed to collect service principal ownership", "error", err)
-	} else {
-		azureADData["servicePrincipalOwnership"] = servicePrincipalOwnership
+	if l.graphObjects.wants("servicePrincipalOwnership") {
+		servicePrincipalOwnership, err := l.collectServicePrincipalOwnership(accessToken)
+		if err != nil {
+			l.Logger.Error("Failed to collect service principal ownership", "error", err)
+			l.collectionErrors.record("servicePrincipalOwnership", "tenant", err)
+		} else {
+			azureADData["servicePrincipalOwnership"] = servicePrincipalOwnership
+		}
 	}
 
 	// Directory role assignments
-
-	// Get the already-collected service principals to pass to the function
-	var servicePrincipalsForDirectoryRoles []interface{}
-	if spData, exists := azureADData["servicePrincipals"]; exists {
-		if spList, ok := spData.([]interface{}); ok {
-			servicePrincipalsForDirectoryRoles = spList
+	if l.graphObjects.wants("directoryRoleAssignments") {
+		// Get the already-collected service principals to pass to the function
+		var servicePrincipalsForDirectoryRoles []interface{}
+		if spData, exists := azureADData["servicePrincipals"]; exists {
+			if spList, ok := spData.([]interface{}); ok {
+				servicePrincipalsForDirectoryRoles = spList
+			}
 		}
-	}
 
-	roleAssignments, err := l.collectDirectoryRoleAssignments(accessToken, servicePrincipalsForDirectoryRoles)
-	if err != nil {
-		l.Logger.Error("Failed to collect directory role assignments", "error", err)
-	} else {
-		azureADData["directoryRoleAssignments"] = roleAssignments
+		roleAssignments, err := l.collectDirectoryRoleAssignments(accessToken, servicePrincipalsForDirectoryRoles)
+		if err != nil {
+			l.Logger.Error("Failed to collect directory role assignments", "error", err)
+			l.collectionErrors.record("directoryRoleAssignments", "tenant", err)
+		} else {
+			azureADData["directoryRoleAssignments"] = roleAssignments
+		}
 	}
 
 	// OAuth2 permission grants
-	oauth2Grants, err := l.collectPaginatedGraphData(accessToken, "/oauth2PermissionGrants")
-	if err != nil {
-		l.Logger.Error("Failed to collect OAuth2 permission grants", "error", err)
-	} else {
-		azureADData["oauth2PermissionGrants"] = oauth2Grants
+	if l.graphObjects.wants("oauth2PermissionGrants") {
+		oauth2Grants, err := l.collectPaginatedGraphData(accessToken, graphV1, "/oauth2PermissionGrants")
+		if err != nil {
+			l.Logger.Error("Failed to collect OAuth2 permission grants", "error", err)
+			l.collectionErrors.record("oauth2PermissionGrants", "tenant", err)
+		} else {
+			azureADData["oauth2PermissionGrants"] = oauth2Grants
+		}
 	}
 
 	// App role assignments
-	appRoleAssignments, err := l.collectAppRoleAssignments(accessToken)
-	if err != nil {
-		l.Logger.Error("Failed to collect app role assignments", "error", err)
-	} else {
-		azureADData["appRoleAssignments"] = appRoleAssignments
+	if l.graphObjects.wants("appRoleAssignments") {
+		appRoleAssignments, err := l.collectAppRoleAssignments(accessToken)
+		if err != nil {
+			l.Logger.Error("Failed to collect app role assignments", "error", err)
+			l.collectionErrors.record("appRoleAssignments", "tenant", err)
+		} else {
+			azureADData["appRoleAssignments"] = deduplicateAppRoleAssignments(l.Logger, appRoleAssignments)
+		}
 	}
 
 	// Collect application ownership data
-	l.Logger.Info("Collecting application ownership")
-	applicationOwnership, err := l.collectApplicationOwnership(accessToken)
-	if err != nil {
-		l.Logger.Error("Failed to collect application ownership", "error", err)
-	} else {
-		azureADData["applicationOwnership"] = applicationOwnership
+	if l.graphObjects.wants("applicationOwnership") {
+		l.Logger.Info("Collecting application ownership")
+		applicationOwnership, err := l.collectApplicationOwnership(accessToken)
+		if err != nil {
+			l.Logger.Error("Failed to collect application ownership", "error", err)
+			l.collectionErrors.record("applicationOwnership", "tenant", err)
+		} else {
+			azureADData["applicationOwnership"] = applicationOwnership
+		}
 	}
 
 	// Process application credentials and embed metadata
@@ -962,8 +1279,9 @@ func (l *IAMComprehensiveCollectorLink) collectAllGraphData(accessToken string)
 	return azureADData, nil
 }
 
-// collectAllPIMData collects all PIM data
-func (l *IAMComprehensiveCollectorLink) collectAllPIMData(accessToken, tenantID string) (map[string]interface{}, error) {
+// collectAllPIMData collects all PIM data. Assignments come from the PIM API;
+// role management policies are only served by Graph, so they use graphAccessToken.
+func (l *IAMComprehensiveCollectorLink) collectAllPIMData(accessToken, graphAccessToken, tenantID string) (map[string]interface{}, error) {
 	pimData := make(map[string]interface{})
 
 	// Collect eligible assignments
@@ -971,6 +1289,7 @@ func (l *IAMComprehensiveCollectorLink) collectAllPIMData(accessToken, tenantID
 	eligibleAssignments, err := l.collectPIMAssignments(accessToken, "eligible", tenantID)
 	if err != nil {
 		l.Logger.Error("Failed to collect eligible assignments", "error", err)
+		l.collectionErrors.record("eligible_assignments", "tenant", err)
 	} else {
 		pimData["eligible_assignments"] = eligibleAssignments
 	}
@@ -980,15 +1299,26 @@ func (l *IAMComprehensiveCollectorLink) collectAllPIMData(accessToken, tenantID
 	activeAssignments, err := l.collectPIMAssignments(accessToken, "active", tenantID)
 	if err != nil {
 		l.Logger.Error("Failed to collect active assignments", "error", err)
+		l.collectionErrors.record("active_assignments", "tenant", err)
 	} else {
 		pimData["active_assignments"] = activeAssignments
 	}
 
+	// Collect the activation policy of each directory role
+	l.Logger.Info("Collecting PIM role management policy assignments")
+	policyAssignments, err := l.collectRoleManagementPolicyAssignments(graphAccessToken)
+	if err != nil {
+		l.Logger.Error("Failed to collect role management policy assignments", "error", err)
+		l.collectionErrors.record("role_management_policy_assignments", "tenant", err)
+	} else {
+		pimData["role_management_policy_assignments"] = policyAssignments
+	}
+
 	return pimData, nil
 }
 
 // collectAllAzureRMData collects all AzureRM data - optimized with Azure Resource Graph
-func (l *IAMComprehensiveCollectorLink) collectAllAzureRMData(accessToken, subscriptionID, proxyURL string) (map[string]interface{}, error) {
+func (l *IAMComprehensiveCollectorLink) collectAllAzureRMData(accessToken, subscriptionID string) (map[string]interface{}, error) {
 	azurermData := make(map[string]interface{})
 	var mu sync.Mutex
 	var wg sync.WaitGroup
@@ -999,13 +1329,13 @@ func (l *IAMComprehensiveCollectorLink) collectAllAzureRMData(accessToken, subsc
 	subscriptionIDs := []string{subscriptionID}
 
 	// Phase 1: Collect all data in parallel using ARG optimization
-	wg.Add(5)
+	wg.Add(7)
 
 	// 1. All RBAC assignments via single ARG query (replaces subscription, RG, and resource-level RBAC)
 	go func() {
 		defer wg.Done()
 		l.Logger.Info("Collecting ALL RBAC assignments via Azure Resource Graph")
-		if allRBACAssignments, err := l.getAllRBACAssignmentsViaARG(accessToken, subscriptionIDs, proxyURL); err == nil {
+		if allRBACAssignments, err := l.getAllRBACAssignmentsViaARG(accessToken, subscriptionIDs); err == nil {
 			mu.Lock()
 			// Split assignments by scope type for compatibility
 			azurermData["subscriptionRoleAssignments"] = allRBACAssignments["subscription"]
@@ -1026,6 +1356,7 @@ func (l *IAMComprehensiveCollectorLink) collectAllAzureRMData(accessToken, subsc
 				totalCount, subCount, rgCount, resCount, mgCount, tenantCount))
 		} else {
 			l.Logger.Error("Failed to collect RBAC assignments via ARG", "error", err)
+			l.collectionErrors.record("roleAssignments", subscriptionID, err)
 		}
 	}()
 
@@ -1033,27 +1364,29 @@ func (l *IAMComprehensiveCollectorLink) collectAllAzureRMData(accessToken, subsc
 	go func() {
 		defer wg.Done()
 		l.Logger.Info("Collecting resource groups via Azure Resource Graph")
-		if resourceGroups, err := l.getAllResourceGroupsViaARG(accessToken, subscriptionIDs, proxyURL); err == nil {
+		if resourceGroups, err := l.getAllResourceGroupsViaARG(accessToken, subscriptionIDs); err == nil {
 			mu.Lock()
 			azurermData["azureResourceGroups"] = resourceGroups
 			mu.Unlock()
 			l.Logger.Info(fmt.Sprintf("Collected %d resource groups", len(resourceGroups)))
 		} else {
 			l.Logger.Error("Failed to collect resource groups via ARG", "error", err)
+			l.collectionErrors.record("azureResourceGroups", subscriptionID, err)
 		}
 	}()
 
-	// 3. All Azure resources via optimized ARG queries
+	// 3. All Azure resources via ARG queries sharded by resource type
 	go func() {
 		defer wg.Done()
 		l.Logger.Info("Collecting Azure resources via optimized Resource Graph API")
-		if resources, err := l.getAllResourcesViaARGOptimized(accessToken, subscriptionIDs, proxyURL); err == nil {
+		if resources, err := l.getAllResourcesViaARGOptimized(accessToken, subscriptionIDs); err == nil {
 			mu.Lock()
 			azurermData["azureResources"] = resources
 			mu.Unlock()
 			l.Logger.Info(fmt.Sprintf("Collected %d Azure resources", len(resources)))
 		} else {
 			l.Logger.Error("Failed to collect Azure resources via ARG", "error", err)
+			l.collectionErrors.record("azureResources", subscriptionID, err)
 		}
 	}()
 
@@ -1068,6 +1401,7 @@ func (l *IAMComprehensiveCollectorLink) collectAllAzureRMData(accessToken, subsc
 			l.Logger.Info(fmt.Sprintf("Collected %d role definitions", len(roleDefinitions)))
 		} else {
 			l.Logger.Error("Failed to collect role definitions", "error", err)
+			l.collectionErrors.record("azureRoleDefinitions", subscriptionID, err)
 		}
 	}()
 
@@ -1111,61 +1445,54 @@ func (l *IAMComprehensiveCollectorLink) collectAllAzureRMData(accessToken, subsc
 		*/
 	}()
 
-	// Wait for all data collection to complete
-	wg.Wait()
-
-	// Apply deduplication to all role assignment collections
-	seenAssignments := make(map[string]bool)
-
-	// Helper function to deduplicate role assignments
-	deduplicateAssignments := func(assignments []interface{}) []interface{} {
-		var unique []interface{}
-		for _, assignment := range assignments {
-			if assignmentMap, ok := assignment.(map[string]interface{}); ok {
-				if id, ok := assignmentMap["id"].(string); ok && id != "" {
-					if !seenAssignments[id] {
-						seenAssignments[id] = true
-						unique = append(unique, assignment)
-					}
-				}
-			}
+	// 6. Azure Lighthouse delegations to external managing tenants
+	go func() {
+		defer wg.Done()
+		l.Logger.Info("Collecting Azure Lighthouse delegations")
+		lighthouseData, err := l.collectLighthouseData(accessToken, subscriptionID)
+		if err != nil {
+			l.Logger.Error("Failed to collect Lighthouse delegations", "error", err)
+			l.collectionErrors.record("lighthouse", subscriptionID, err)
+			return
 		}
-		return unique
-	}
-
-	// Apply deduplication to subscription assignments
-	if subscriptionAssignments, exists := azurermData["subscriptionRoleAssignments"]; exists {
-		if assignments, ok := subscriptionAssignments.([]interface{}); ok {
-			originalCount := len(assignments)
-			deduplicated := deduplicateAssignments(assignments)
-			azurermData["subscriptionRoleAssignments"] = deduplicated
-			l.Logger.Info("Subscription RBAC deduplication", "original", originalCount, "unique", len(deduplicated), "duplicates_removed", originalCount-len(deduplicated))
+		mu.Lock()
+		for key, value := range lighthouseData {
+			azurermData[key] = value
 		}
-	}
+		mu.Unlock()
+		l.Logger.Info(fmt.Sprintf("Collected %d Lighthouse delegations", len(lighthouseData["lighthouseDelegations"].([]interface{}))))
+		logLighthouseFindings(l.Logger, subscriptionID, lighthouseData["lighthouseFindings"].([]interface{}))
+	}()
 
-	// Apply deduplication to resource group assignments
-	if rgAssignments, exists := azurermData["resourceGroupRoleAssignments"]; exists {
-		if assignments, ok := rgAssignments.([]interface{}); ok {
-			originalCount := len(assignments)
-			deduplicated := deduplicateAssignments(assignments)
-			azurermData["resourceGroupRoleAssignments"] = deduplicated
-			l.Logger.Info("Resource Group RBAC deduplication", "original", originalCount, "unique", len(deduplicated), "duplicates_removed", originalCount-len(deduplicated))
+	// 7. Classic subscription administrators outside Azure RBAC
+	go func() {
+		defer wg.Done()
+		l.Logger.Info("Collecting classic administrators")
+		classicData, err := l.collectClassicAdministrators(accessToken, subscriptionID)
+		if err != nil {
+			l.Logger.Error("Failed to collect classic administrators", "error", err)
+			l.collectionErrors.record("classicAdministrators", subscriptionID, err)
+			return
 		}
-	}
-
-	// Apply deduplication to resource-level assignments
-	if resourceAssignments, exists := azurermData["resourceLevelRoleAssignments"]; exists {
-		if assignments, ok := resourceAssignments.([]interface{}); ok {
-			originalCount := len(assignments)
-			deduplicated := deduplicateAssignments(assignments)
-			azurermData["resourceLevelRoleAssignments"] = deduplicated
-			l.Logger.Info("Resource-level RBAC deduplication", "original", originalCount, "unique", len(deduplicated), "duplicates_removed", originalCount-len(deduplicated))
+		mu.Lock()
+		for key, value := range classicData {
+			azurermData[key] = value
 		}
-	}
+		mu.Unlock()
+		l.Logger.Info(fmt.Sprintf("Collected %d classic administrators", len(classicData["classicAdministrators"].([]interface{}))))
+		logClassicAdministratorFindings(l.Logger, subscriptionID, classicData["classicAdministratorFindings"].([]interface{}))
+	}()
+
+	// Wait for all data collection to complete
+	wg.Wait()
 
-	// Log overall deduplication statistics
-	totalUnique := len(seenAssignments)
-	l.Logger.Info("Total RBAC assignment deduplication complete", "total_unique_assignments", totalUnique)
+	// 8. Object replication policies of the collected storage accounts
+	resources, _ := azurermData["azureResources"].([]interface{})
+	azurermData["storageObjectReplicationPolicies"] = collectObjectReplicationPolicies(l.Logger, &l.collectionErrors, subscriptionID, resources, func(url string) ([]interface{}, error) {
+		return l.collectPaginatedARMData(accessToken, url)
+	})
+
+	l.rbacDedup.deduplicate(l.Logger, azurermData)
 
 	l.Logger.Info("Parallel Azure RM data collection completed")
 	return azurermData, nil
@@ -1173,28 +1500,31 @@ func (l *IAMComprehensiveCollectorLink) collectAllAzureRMData(accessToken, subsc
 
 // Helper methods for API calls
 
-// collectPaginatedGraphData collects paginated Graph API data
-func (l *IAMComprehensiveCollectorLink) collectPaginatedGraphData(accessToken, endpoint string) ([]interface{}, error) {
+// collectPaginatedGraphData collects paginated Graph API data from endpoint on
+// the given API version (graphV1 or graphBeta)
+func (l *IAMComprehensiveCollectorLink) collectPaginatedGraphData(accessToken, version, endpoint string) ([]interface{}, error) {
 	var allData []interface{}
-	nextLink := fmt.Sprintf("https://graph.microsoft.com/v1.0%s", endpoint)
+	nextLink := l.cloud.graphURL(version, endpoint)
 
 	for nextLink != "" {
-		req, err := http.NewRequestWithContext(l.Context(), "GET", nextLink, nil)
-		if err != nil {
-			return nil, fmt.Errorf("failed to create request: %v", err)
-		}
-
-		req.Header.Set("Authorization", "Bearer "+accessToken)
-		req.Header.Set("Content-Type", "application/json")
-
-		resp, err := l.httpClient.Do(req)
+		pageURL := nextLink
+		resp, err := doGraphRequest(l.Context(), l.httpClient, l.Logger, func() (*http.Request, error) {
+			req, err := http.NewRequestWithContext(l.Context(), "GET", pageURL, nil)
+			if err != nil {
+				return nil, fmt.Errorf("failed to create request: %v", err)
+			}
+			req.Header.Set("Authorization", "Bearer "+accessToken)
+			req.Header.Set("Content-Type", "application/json")
+			return req, nil
+		})
 		if err != nil {
 			return nil, fmt.Errorf("request failed: %v", err)
 		}
 
 		if resp.StatusCode != 200 {
+			apiErr := newAPIStatusError(resp)
 			resp.Body.Close()
-			return nil, fmt.Errorf("API call failed with status %d", resp.StatusCode)
+			return nil, apiErr
 		}
 
 		var result struct {
@@ -1211,6 +1541,10 @@ func (l *IAMComprehensiveCollectorLink) collectPaginatedGraphData(accessToken, e
 		allData = append(allData, result.Value...)
 		nextLink = result.NextLink
 
+		if sampleReached(l.sampleSize, len(allData)) {
+			allData = sampleObjects(allData, l.sampleSize)
+			break
+		}
 		if nextLink == "" {
 			break
 		}
@@ -1222,78 +1556,23 @@ func (l *IAMComprehensiveCollectorLink) collectPaginatedGraphData(accessToken, e
 	return allData, nil
 }
 
-// collectPaginatedARMData collects data from Azure ARM APIs with nextLink pagination support
+// collectPaginatedARMData collects data from Azure ARM APIs with nextLink
+// pagination, retrying transient failures and recording truncated results
 func (l *IAMComprehensiveCollectorLink) collectPaginatedARMData(accessToken, url string) ([]interface{}, error) {
-	var allData []interface{}
-	nextLink := url
-	pageCount := 0
-	maxPages := 100 // Safety limit to prevent infinite loops
-	seenLinks := make(map[string]bool) // Detect circular nextLink references
-
-	for nextLink != "" && pageCount < maxPages {
-		// Check for circular references
-		if seenLinks[nextLink] {
-			l.Logger.Warn("Detected circular nextLink reference, breaking pagination loop", "url", nextLink)
-			break
-		}
-		seenLinks[nextLink] = true
-		pageCount++
-
-		l.Logger.Debug("Fetching paginated ARM data", "page", pageCount, "url", nextLink)
-
-		req, err := http.NewRequestWithContext(l.Context(), "GET", nextLink, nil)
-		if err != nil {
-			return nil, fmt.Errorf("failed to create request (page %d): %v", pageCount, err)
-		}
-
-		req.Header.Set("Authorization", "Bearer "+accessToken)
-		req.Header.Set("Content-Type", "application/json")
-
-		resp, err := l.httpClient.Do(req)
-		if err != nil {
-			return nil, fmt.Errorf("request failed (page %d): %v", pageCount, err)
-		}
-
-		if resp.StatusCode != 200 {
-			resp.Body.Close()
-			return nil, fmt.Errorf("API call failed (page %d) with status %d", pageCount, resp.StatusCode)
-		}
-
-		var result struct {
-			Value    []interface{} `json:"value"`
-			NextLink string        `json:"nextLink"`
-		}
-
-		if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-			resp.Body.Close()
-			return nil, fmt.Errorf("failed to decode response (page %d): %v", pageCount, err)
-		}
-		resp.Body.Close()
-
-		l.Logger.Debug("Retrieved ARM data page", "page", pageCount, "items", len(result.Value), "hasNextLink", result.NextLink != "")
-
-		allData = append(allData, result.Value...)
-		nextLink = result.NextLink
-
-		if nextLink == "" {
-			break
-		}
-
-		// Small delay to avoid throttling
-		time.Sleep(100 * time.Millisecond)
-	}
-
-	if pageCount >= maxPages {
-		l.Logger.Warn("Reached maximum page limit for ARM pagination", "maxPages", maxPages, "url", url)
-	}
-
-	l.Logger.Debug("ARM pagination completed", "totalPages", pageCount, "totalItems", len(allData))
-	return allData, nil
+	pager := &armPager{
+		client:   l.httpClient,
+		logger:   l.Logger,
+		errs:     &l.collectionErrors,
+		maxPages: armMaxPagesArg(l.Arg("arm-max-pages")),
+		maxItems: l.sampleSize,
+		throttle: &l.armThrottle,
+	}
+	return pager.collect(l.Context(), accessToken, l.cloud.armURL(url))
 }
 
 // callGraphBatchAPI makes batch Graph API call
 func (l *IAMComprehensiveCollectorLink) callGraphBatchAPI(accessToken string, requests []map[string]interface{}) (map[string]interface{}, error) {
-	batchURL := "https://graph.microsoft.com/v1.0/$batch"
+	batchURL := l.cloud.graphURL(graphV1, "/$batch")
 
 	batchPayload := map[string]interface{}{
 		"requests": requests,
@@ -1304,17 +1583,17 @@ func (l *IAMComprehensiveCollectorLink) callGraphBatchAPI(accessToken string, re
 		return nil, fmt.Errorf("failed to marshal batch payload: %v", err)
 	}
 
-	req, err := http.NewRequestWithContext(l.Context(), "POST", batchURL, strings.NewReader(string(batchPayloadJSON)))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create batch request: %v", err)
-	}
-
-	req.Header.Set("Authorization", "Bearer "+accessToken)
-	req.Header.Set("Content-Type", "application/json")
-
-	l.Logger.Info(fmt.Sprintf("Batch calling %d requests...", len(requests)))
+	l.Logger.Debug(fmt.Sprintf("Batch calling %d requests...", len(requests)))
 
-	resp, err := l.httpClient.Do(req)
+	resp, err := doGraphRequest(l.Context(), l.httpClient, l.Logger, func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(l.Context(), "POST", batchURL, strings.NewReader(string(batchPayloadJSON)))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create batch request: %v", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+accessToken)
+		req.Header.Set("Content-Type", "application/json")
+		return req, nil
+	})
 	if err != nil {
 		return nil, fmt.Errorf("batch request failed: %v", err)
 	}
@@ -1334,7 +1613,7 @@ func (l *IAMComprehensiveCollectorLink) callGraphBatchAPI(accessToken string, re
 
 // collectGroupMemberships collects all group membership relationships using batching
 func (l *IAMComprehensiveCollectorLink) collectGroupMemberships(accessToken string) ([]interface{}, error) {
-	groups, err := l.collectPaginatedGraphData(accessToken, "/groups")
+	groups, err := l.collectPaginatedGraphData(accessToken, graphV1, "/groups")
 	if err != nil {
 		return nil, err
 	}
@@ -1393,8 +1672,6 @@ func (l *IAMComprehensiveCollectorLink) collectGroupMemberships(accessToken stri
 							if value, ok := body["value"].([]interface{}); ok {
 								for _, member := range value {
 									if memberMap, ok := member.(map[string]interface{}); ok {
-										memberID := memberMap["id"].(string)
-
 										// Extract group ID from request ID
 										requestID := respMap["id"].(string)
 										groupIndex := strings.Replace(strings.Replace(requestID, "group_", "", 1), "_members", "", 1)
@@ -1404,12 +1681,7 @@ func (l *IAMComprehensiveCollectorLink) collectGroupMemberships(accessToken stri
 											if groupInfo, ok := groupData.(map[string]interface{}); ok {
 												groupID := groupInfo["id"].(string)
 
-												membership := map[string]interface{}{
-													"groupId":    groupID,
-													"memberId":   memberID,
-													"memberType": memberMap["@odata.type"],
-												}
-												memberships = append(memberships, membership)
+												memberships = append(memberships, newGroupMembershipRecord(groupID, memberMap))
 											}
 										}
 									}
@@ -1430,7 +1702,7 @@ func (l *IAMComprehensiveCollectorLink) collectGroupMemberships(accessToken stri
 
 // collectGroupOwnership collects group ownership relationships using batch API
 func (l *IAMComprehensiveCollectorLink) collectGroupOwnership(accessToken string) ([]interface{}, error) {
-	groups, err := l.collectPaginatedGraphData(accessToken, "/groups")
+	groups, err := l.collectPaginatedGraphData(accessToken, graphV1, "/groups")
 	if err != nil {
 		return nil, err
 	}
@@ -1506,7 +1778,7 @@ func (l *IAMComprehensiveCollectorLink) collectGroupOwnership(accessToken string
 													"groupName":  groupName,
 													"ownerId":    ownerID,
 													"ownerName":  ownerMap["displayName"].(string),
-													"ownerType":  ownerMap["@odata.type"].(string),
+													"ownerType":  normalizeODataType(ownerMap["@odata.type"]),
 													"permissionType": "GroupOwnership",
 													"role":       "Owner",
 												}
@@ -1531,7 +1803,7 @@ func (l *IAMComprehensiveCollectorLink) collectGroupOwnership(accessToken string
 
 // collectServicePrincipalOwnership collects service principal ownership relationships using batch API
 func (l *IAMComprehensiveCollectorLink) collectServicePrincipalOwnership(accessToken string) ([]interface{}, error) {
-	servicePrincipals, err := l.collectPaginatedGraphData(accessToken, "/servicePrincipals")
+	servicePrincipals, err := l.collectPaginatedGraphData(accessToken, graphV1, "/servicePrincipals")
 	if err != nil {
 		return nil, err
 	}
@@ -1607,7 +1879,7 @@ func (l *IAMComprehensiveCollectorLink) collectServicePrincipalOwnership(accessT
 													"servicePrincipalName": spName,
 													"ownerId":    ownerID,
 													"ownerName":  ownerMap["displayName"].(string),
-													"ownerType":  ownerMap["@odata.type"].(string),
+													"ownerType":  normalizeODataType(ownerMap["@odata.type"]),
 													"permissionType": "ServicePrincipalOwnership",
 													"role":       "Owner",
 												}
@@ -1632,7 +1904,7 @@ func (l *IAMComprehensiveCollectorLink) collectServicePrincipalOwnership(accessT
 
 // collectDirectoryRoleAssignments collects directory role assignments
 func (l *IAMComprehensiveCollectorLink) collectDirectoryRoleAssignments(accessToken string, servicePrincipals []interface{}) ([]interface{}, error) {
-	roles, err := l.collectPaginatedGraphData(accessToken, "/directoryRoles")
+	roles, err := l.collectPaginatedGraphData(accessToken, graphV1, "/directoryRoles")
 	if err != nil {
 		return nil, err
 	}
@@ -1656,8 +1928,6 @@ func (l *IAMComprehensiveCollectorLink) collectDirectoryRoleAssignments(accessTo
 		}
 		batchRoles := roles[batchIdx:end]
 
-		l.Logger.Info(fmt.Sprintf("Batch calling %d requests...", len(batchRoles)))
-
 		// Create batch requests for directory role members
 		var batchRequests []map[string]interface{}
 		for i, role := range batchRoles {
@@ -1684,7 +1954,9 @@ func (l *IAMComprehensiveCollectorLink) collectDirectoryRoleAssignments(accessTo
 
 		// Make batch API call
 		batchResponse, err := l.callGraphBatchAPI(accessToken, batchRequests)
+		l.Progress("directory role members", end, len(roles))
 		if err != nil {
+			l.Logger.Debug("Batch request failed for directory role members", "batch_start", batchIdx, "error", err)
 			continue
 		}
 
@@ -1754,7 +2026,7 @@ func (l *IAMComprehensiveCollectorLink) collectDirectoryRoleAssignments(accessTo
 								"roleTemplateId": roleMap["roleTemplateId"], // Add roleTemplateId for Neo4j matching
 								"roleName":       roleMap["displayName"],
 								"principalId":    memberID,
-								"principalType":  memberMap["@odata.type"],
+								"principalType":  normalizeODataType(memberMap["@odata.type"]),
 							}
 							assignments = append(assignments, assignment)
 						}
@@ -1766,13 +2038,13 @@ func (l *IAMComprehensiveCollectorLink) collectDirectoryRoleAssignments(accessTo
 		time.Sleep(500 * time.Millisecond) // Brief pause between batches
 	}
 
-
 	// BUGFIX: Also collect directory roles for service principals using memberOf approach
 	// The /directoryRoles/{roleId}/members endpoint has a known asymmetry bug where service principals
 	// don't appear in role membership lists, but they do appear when querying their memberOf
 	l.Logger.Info("Collecting service principal directory role assignments using memberOf approach...")
 	servicePrincipalAssignments, err := l.collectServicePrincipalDirectoryRoles(accessToken, servicePrincipals)
 	if err != nil {
+		l.Logger.Debug("Skipping service principal directory role assignments", "reason", err)
 	} else {
 		assignments = append(assignments, servicePrincipalAssignments...)
 	}
@@ -1783,13 +2055,11 @@ func (l *IAMComprehensiveCollectorLink) collectDirectoryRoleAssignments(accessTo
 // collectServicePrincipalDirectoryRoles collects directory role assignments for service principals
 // using the memberOf approach to work around Graph API asymmetry bug
 func (l *IAMComprehensiveCollectorLink) collectServicePrincipalDirectoryRoles(accessToken string, servicePrincipals []interface{}) ([]interface{}, error) {
-
 	// Use the already-collected service principals passed as parameter
 	if servicePrincipals == nil || len(servicePrincipals) == 0 {
 		return nil, fmt.Errorf("no service principals provided")
 	}
 
-
 	var assignments []interface{}
 
 	// Process service principals in batches for memberOf collection
@@ -1805,7 +2075,7 @@ func (l *IAMComprehensiveCollectorLink) collectServicePrincipalDirectoryRoles(ac
 			end = len(servicePrincipals)
 		}
 		batchSPs := servicePrincipals[batchIdx:end]
-
+		l.Logger.Debug("Collecting service principal memberOf batch", "batch_start", batchIdx, "batch_size", len(batchSPs))
 
 		// Create batch requests for service principal memberOf
 		var batchRequests []map[string]interface{}
@@ -1831,19 +2101,20 @@ func (l *IAMComprehensiveCollectorLink) collectServicePrincipalDirectoryRoles(ac
 			continue
 		}
 
-
 		// Make batch API call
 		batchResponse, err := l.callGraphBatchAPI(accessToken, batchRequests)
+		l.Progress("service principal directory roles", end, len(servicePrincipals))
 		if err != nil {
+			l.Logger.Debug("Batch request failed for service principal memberOf", "batch_start", batchIdx, "error", err)
 			continue
 		}
 
 		responses, ok := batchResponse["responses"].([]interface{})
 		if !ok {
+			l.Logger.Debug("Invalid batch response format for service principal memberOf", "batch_start", batchIdx)
 			continue
 		}
 
-
 		// Process batch responses - MUST match by response ID, not array index!
 		// Microsoft Graph batch API does NOT guarantee response order matches request order.
 		// Build a map of SP index to SP data for O(1) lookup
@@ -1895,8 +2166,7 @@ func (l *IAMComprehensiveCollectorLink) collectServicePrincipalDirectoryRoles(ac
 							}
 
 							// Filter for directory roles only (client-side since server-side filter not supported)
-							odataType, ok := memberMap["@odata.type"].(string)
-							if !ok || odataType != "#microsoft.graph.directoryRole" {
+							if !isODataType(memberMap["@odata.type"], odataTypeDirectoryRole) {
 								continue
 							}
 
@@ -1910,7 +2180,7 @@ func (l *IAMComprehensiveCollectorLink) collectServicePrincipalDirectoryRoles(ac
 								"roleTemplateId": memberMap["roleTemplateId"],
 								"roleName":       memberMap["displayName"],
 								"principalId":    spID,
-								"principalType":  "#microsoft.graph.servicePrincipal",
+								"principalType":  odataTypeServicePrincipal,
 							}
 							assignments = append(assignments, assignment)
 						}
@@ -1927,7 +2197,7 @@ func (l *IAMComprehensiveCollectorLink) collectServicePrincipalDirectoryRoles(ac
 
 // collectAppRoleAssignments collects application role assignments using batch API
 func (l *IAMComprehensiveCollectorLink) collectAppRoleAssignments(accessToken string) ([]interface{}, error) {
-	servicePrincipals, err := l.collectPaginatedGraphData(accessToken, "/servicePrincipals")
+	servicePrincipals, err := l.collectPaginatedGraphData(accessToken, graphV1, "/servicePrincipals")
 	if err != nil {
 		return nil, err
 	}
@@ -1975,7 +2245,7 @@ func (l *IAMComprehensiveCollectorLink) collectAppRoleAssignments(accessToken st
 			batchRequests = append(batchRequests, map[string]interface{}{
 				"id":     fmt.Sprintf("%d", requestID),
 				"method": "GET",
-				"url":    fmt.Sprintf("/servicePrincipals/%s/appRoleAssignments?$select=id,appRoleId,principalId,principalDisplayName,principalType,resourceId,resourceDisplayName", spID),
+				"url":    fmt.Sprintf("/servicePrincipals/%s/appRoleAssignments?$select=id,appRoleId,principalId,principalDisplayName,principalType,resourceId,resourceDisplayName,createdDateTime", spID),
 			})
 			requestID++
 
@@ -1983,7 +2253,7 @@ func (l *IAMComprehensiveCollectorLink) collectAppRoleAssignments(accessToken st
 			batchRequests = append(batchRequests, map[string]interface{}{
 				"id":     fmt.Sprintf("%d", requestID),
 				"method": "GET",
-				"url":    fmt.Sprintf("/servicePrincipals/%s/appRoleAssignedTo?$select=id,appRoleId,principalId,principalDisplayName,principalType,resourceId,resourceDisplayName", spID),
+				"url":    fmt.Sprintf("/servicePrincipals/%s/appRoleAssignedTo?$select=id,appRoleId,principalId,principalDisplayName,principalType,resourceId,resourceDisplayName,createdDateTime", spID),
 			})
 			requestID++
 		}
@@ -2032,12 +2302,9 @@ func (l *IAMComprehensiveCollectorLink) collectAppRoleAssignments(accessToken st
 							if assignmentsTo, ok := body["value"].([]interface{}); ok {
 								for _, assignment := range assignmentsTo {
 									if assignmentMap, ok := assignment.(map[string]interface{}); ok {
-										assignmentMap["direction"] = "assigned_to"
-										assignmentMap["servicePrincipalId"] = spID
-										assignmentMap["servicePrincipalDisplayName"] = spName
+										allAppRoleAssignments = append(allAppRoleAssignments, newAppRoleAssignmentRecord(assignmentMap, spID, spName, "assigned_to"))
 									}
 								}
-								allAppRoleAssignments = append(allAppRoleAssignments, assignmentsTo...)
 							}
 						}
 					}
@@ -2054,12 +2321,9 @@ func (l *IAMComprehensiveCollectorLink) collectAppRoleAssignments(accessToken st
 							if assignmentsFrom, ok := body["value"].([]interface{}); ok {
 								for _, assignment := range assignmentsFrom {
 									if assignmentMap, ok := assignment.(map[string]interface{}); ok {
-										assignmentMap["direction"] = "assigned_from"
-										assignmentMap["servicePrincipalId"] = spID
-										assignmentMap["servicePrincipalDisplayName"] = spName
+										allAppRoleAssignments = append(allAppRoleAssignments, newAppRoleAssignmentRecord(assignmentMap, spID, spName, "assigned_from"))
 									}
 								}
-								allAppRoleAssignments = append(allAppRoleAssignments, assignmentsFrom...)
 							}
 						}
 					}
@@ -2078,7 +2342,10 @@ func (l *IAMComprehensiveCollectorLink) collectAppRoleAssignments(accessToken st
 // collectPIMAssignments collects PIM assignments
 func (l *IAMComprehensiveCollectorLink) collectPIMAssignments(accessToken, assignmentType, tenantID string) ([]interface{}, error) {
 	// Use URL encoding for query parameters
-	baseURL := "https://api.azrbac.mspim.azure.com/api/v2/privilegedAccess/aadroles/roleAssignments"
+	baseURL, err := l.cloud.pimURL("/api/v2/privilegedAccess/aadroles/roleAssignments")
+	if err != nil {
+		return nil, err
+	}
 
 	// Build query parameters separately to avoid URL truncation
 	params := url.Values{}
@@ -2119,7 +2386,7 @@ func (l *IAMComprehensiveCollectorLink) collectPIMAssignments(accessToken, assig
 	defer resp.Body.Close()
 
 	if resp.StatusCode != 200 {
-		return nil, fmt.Errorf("API call failed with status %d", resp.StatusCode)
+		return nil, newAPIStatusError(resp)
 	}
 
 	var result struct {
@@ -2133,50 +2400,11 @@ func (l *IAMComprehensiveCollectorLink) collectPIMAssignments(accessToken, assig
 	return result.Value, nil
 }
 
-// collectAzureResourcesViaGraph collects all Azure resources using Resource Graph API
+// collectAzureResourcesViaGraph collects all Azure resources in a subscription
+// using Resource Graph, sharded by resource type
 func (l *IAMComprehensiveCollectorLink) collectAzureResourcesViaGraph(accessToken, subscriptionID string) ([]interface{}, error) {
-	resourceGraphURL := "https://management.azure.com/providers/Microsoft.ResourceGraph/resources?api-version=2021-03-01"
-
-	// Use comprehensive Resource Graph query
-	kustoQuery := `resources|where ((type in~ ('microsoft.insights/workbooktemplates','microsoft.insights/workbooks','microsoft.insights/webtests','microsoft.insights/privatelinkscopes','microsoft.insights/components','microsoft.appplatform/spring','microsoft.cache/redis','microsoft.cache/redisenterprise/databases','microsoft.cache/redisenterprise','microsoft.visualstudio/account','astronomer.astro/organizations','microsoft.confluent/organizations','microsoft.datadog/monitors','dell.storage/filesystems','dynatrace.observability/monitors','microsoft.elastic/monitors','informatica.datamanagement/organizations','newrelic.observability/monitors','pinecone.vectordb/organizations','mongodb.atlas/organizations','microsoft.weightsandbiases/instances','liftrbasic.samplerp/organizations','lambdatest.hyperexecute/organizations','commvault.contentstore/cloudaccounts','arizeai.observabilityeval/organizations','neon.postgres/organizations','nginx.nginxplus/nginxdeployments','paloaltonetworks.cloudngfw/localrulestacks','paloaltonetworks.cloudngfw/globalrulestacks','paloaltonetworks.cloudngfw/firewalls','microsoft.liftrpilot/organizations','purestorage.block/storagepools','purestorage.block/reservations','purestorage.block/storagepools/avsstoragecontainers','qumulo.storage/filesystems','microsoft.resources/subscriptions/resourcegroups','microsoft.portal/virtual-privatedashboards','microsoft.portal/dashboards','microsoft.resourcegraph/queries','microsoft.azureactivedirectory/guestusages','microsoft.azureactivedirectory/ciamdirectories','microsoft.azureactivedirectory/b2cdirectories','microsoft.aad/domainservices','microsoft.aadiam/privatelinkforazuread','providers.test/statefulibizaengines','microsoft.edge/disconnectedoperations','microsoft.all/hcivirtualmachines','microsoft.azurestackhci/storagecontainers','microsoft.azurestackhci/clusters','microsoft.azurestackhci/networksecuritygroups','microsoft.azurestackhci/networkinterfaces','microsoft.azurestackhci/marketplacegalleryimages','microsoft.azurestackhci/logicalnetworks','microsoft.azurestackhci/galleryimages','microsoft.azurestackhci/clusters/updatesummaries','microsoft.azurestackhci/clusters/updates/updateruns','microsoft.azurestackhci/devicepools','private.aszlabhardware/labservers','private.aszlabhardware/reservations','private.aszlabhardware/servers','microsoft.deviceupdate/accounts','microsoft.cdn/profiles','microsoft.agfoodplatform/farmbeats','microsoft.agricultureplatform/agriservices','microsoft.analysisservices/servers','microsoft.fabric/capacities','microsoft.network/networkmanagers/verifierworkspaces','microsoft.mobilenetwork/packetcorecontrolplanes/packetcoredataplanes/attacheddatanetworks','microsoft.mobilenetwork/packetcorecontrolplanes','microsoft.mobilenetwork/mobilenetworks/datanetworks','microsoft.mobilenetwork/packetcorecontrolplanes/packetcoredataplanes','microsoft.mobilenetwork/mobilenetworks','microsoft.mobilenetwork/radioaccessnetworks','microsoft.mobilenetwork/mobilenetworks/services','microsoft.mobilenetwork/simgroups/sims','microsoft.mobilenetwork/simgroups','microsoft.mobilenetwork/mobilenetworks/simpolicies','microsoft.mobilenetwork/mobilenetworks/sites','microsoft.mobilenetwork/mobilenetworks/slices','microsoft.apimanagement/service/workspaces','microsoft.apicenter/services/workspaces','microsoft.apimanagement/service','microsoft.apimanagement/gateways','microsoft.apicenter/services','microsoft.solutions/applicationdefinitions','microsoft.solutions/applications','microsoft.vsonline/plans','microsoft.arc/allfairfax','microsoft.arc/all','microsoft.arc/kubernetesresources','microsoft.kubernetes/connectedclusters','microsoft.kubernetes/connectedclusters/microsoft.kubernetesconfiguration/extensions','microsoft.kubernetesruntime/loadbalancers','microsoft.attestation/attestationproviders','microsoft.automanage/serviceprincipals','microsoft.automation/automationaccounts','microsoft.automation/automationaccounts/modules','microsoft.automation/automationaccounts/hybridrunbookworkergroups','microsoft.automation/automationaccounts/runbooks','microsoft.maintenance/maintenanceconfigurationsaumbladeresource','microsoft.updatemanager/updaterules','microsoft.appconfiguration/configurationstores','microsoft.azurefleet/fleets','microsoft.batch/batchaccounts','microsoft.resources/subscriptions','microsoft.botservice/botservices','microsoft.cdn/profiles/endpoints/origins','microsoft.cdn/profiles/securitypolicies','microsoft.cdn/profiles/secrets','microsoft.cdn/profiles/rulesets','microsoft.cdn/profiles/rulesets/rules','microsoft.cdn/profiles/afdendpoints/routes','microsoft.cdn/profiles/origingroups','microsoft.cdn/profiles/origingroups/origins','microsoft.cdn/profiles/afdendpoints','microsoft.cdn/profiles/customdomains','microsoft.cdn/profiles/endpoints','microsoft.cdn/profiles/endpoints/customdomains','microsoft.chaos/workspaces','microsoft.chaos/privateaccesses','microsoft.chaos/experiments','microsoft.classiccompute/virtualmachines','microsoft.classicstorage/storageaccounts/vmimages','microsoft.classicstorage/storageaccounts/osimages','microsoft.classicstorage/storageaccounts/disks','microsoft.sovereign/transparencylogs','microsoft.sovereign/landingzoneaccounts/landingzoneregistrations','microsoft.sovereign/landingzoneaccounts','microsoft.sovereign/landingzoneaccounts/landingzoneconfigurations','microsoft.hardwaresecuritymodules/cloudhsmclusters','microsoft.loadtestservice/supportedresourcetypes','microsoft.loadtestservice/playwrightworkspaces','microsoft.loadtestservice/loadtests','microsoft.loadtestservice/allservices','microsoft.classiccompute/domainnames/slots/roles','microsoft.classiccompute/domainnames','microsoft.compute/cloudservices','microsoft.cloudtest/pools','microsoft.cloudtest/images','microsoft.cloudtest/hostedpools','microsoft.cloudtest/buildcaches','microsoft.cloudtest/accounts','microsoft.codesigning/codesigningaccounts','microsoft.communication/communicationservices','microsoft.voiceservices/communicationsgateways/testlines','microsoft.voiceservices/communicationsgateways','microsoft.community/communitytrainings','microsoft.compute/virtualmachinescalesets','microsoft.compute/virtualmachines','microsoft.all/virtualmachines','microsoft.compute/sshpublickeys','microsoft.compute/proximityplacementgroups','microsoft.compute/virtualmachineflexinstances','microsoft.compute/standbypoolinstance','microsoft.compute/computefleetscalesets','microsoft.compute/computefleetinstances','microsoft.compute/hostgroups/hosts','microsoft.compute/hostgroups','microsoft.compute/capacityreservationgroups','microsoft.compute/availabilitysets','microsoft.computehub/windowsostype','microsoft.computehub/provisioningstatesucceededresources','microsoft.computehub/provisioningstatefailedresources','microsoft.computehub/powerstatestopped','microsoft.computehub/powerstaterunning','microsoft.computehub/powerstatedeallocated','microsoft.computehub/outages','microsoft.computehub/microsoftdefenderstandardsubscription','microsoft.computehub/microsoftdefenderfreetrialsubscription','microsoft.computehub/linuxostype','microsoft.computehub/healthevents','microsoft.computehub/computehubmain','microsoft.computehub/backup','microsoft.computehub/all','microsoft.computehub/advisorsecurity','microsoft.computehub/advisorreliability','microsoft.computehub/advisorperformance','microsoft.computehub/advisoroperationalexcellence','microsoft.computehub/advisorcost','microsoft.confidentialledger/ledgers','microsoft.confidentialledger/managedccfs','microsoft.containerregistry/registries/webhooks','microsoft.containerregistry/registries/tokens','microsoft.containerregistry/registries/scopemaps','microsoft.containerregistry/registries/replications','microsoft.containerregistry/registries','microsoft.kubernetesconfiguration/extensions','microsoft.containerservice/managedclusters/microsoft.kubernetesconfiguration/fluxconfigurations','microsoft.kubernetes/connectedclusters/microsoft.kubernetesconfiguration/fluxconfigurations','microsoft.containerservice/managedclusters/microsoft.kubernetesconfiguration/namespaces','microsoft.kubernetes/connectedclusters/microsoft.kubernetesconfiguration/namespaces','microsoft.containerinstance/containergroups','microsoft.containerservice/managedclusters/managednamespaces','microsoft.containerservice/managedclusters','microsoft.containerservice/managedclusters/microsoft.kubernetesconfiguration/extensions','microsoft.redhatopenshift/openshiftclusters','microsoft.containerstorage/pools','microsoft.portalservices/extensions/deployments','microsoft.portalservices/extensions','microsoft.portalservices/extensions/slots','microsoft.portalservices/extensions/versions','microsoft.azuredatatransfer/pipelines','microsoft.azuredatatransfer/connections/flows','microsoft.azuredatatransfer/connections','microsoft.managedservices/registrationdefinitions','microsoft.dashboard/grafana','microsoft.databasewatcher/watchers','microsoft.databricks/workspaces','microsoft.databricks/accessconnectors','microsoft.datafactory/factories','microsoft.datafactory/datafactories','microsoft.datalakeanalytics/accounts','microsoft.datalakestore/accounts','microsoft.dataprotection/resourceguards','microsoft.dataprotection/backupvaults','microsoft.datashare/accounts','microsoft.securitydetonation/chambers','microsoft.devcenter/projects','microsoft.devcenter/projects/pools','microsoft.devcenter/plans','microsoft.devcenter/networkconnections','microsoft.devcenter/devcenters','microsoft.devcenter/devcenters/devboxdefinitions','microsoft.deviceregistry/convergedassets','microsoft.deviceregistry/schemaregistries','microsoft.deviceregistry/namespaces/assets','microsoft.deviceregistry/namespaces','microsoft.deviceregistry/devices','microsoft.deviceregistry/assetendpointprofiles','microsoft.deviceregistry/assets','microsoft.deviceupdate/updateaccounts','microsoft.deviceupdate/updateaccounts/updates','microsoft.deviceupdate/updateaccounts/deviceclasses','microsoft.deviceupdate/updateaccounts/deployments','microsoft.deviceupdate/updateaccounts/agents','microsoft.deviceupdate/updateaccounts/activedeployments','microsoft.devopsinfrastructure/pools','microsoft.devtestlab/labs/virtualmachines','microsoft.devtestlab/labs','microsoft.devtunnels/tunnelplans','microsoft.digitaltwins/digitaltwinsinstances','microsoft.discovery/workspaces','microsoft.discovery/workflows','microsoft.discovery/tools','microsoft.discovery/supercomputers','microsoft.discovery/storages','microsoft.discovery/workspaces/projects','microsoft.discovery/supercomputers/nodepools','microsoft.discovery/models','microsoft.discovery/datacontainers','microsoft.discovery/datacontainers/dataassets','microsoft.discovery/bookshelves','microsoft.discovery/agents','microsoft.compute/snapshots','microsoft.compute/galleries','microsoft.compute/restorepointcollections','microsoft.compute/restorepointcollections/restorepoints','microsoft.compute/galleries/images/versions','microsoft.virtualmachineimages/imagetemplates','microsoft.compute/images','microsoft.compute/galleries/images','microsoft.compute/galleries/applications/versions','microsoft.compute/galleries/applications','microsoft.compute/diskencryptionsets','microsoft.compute/diskaccesses','microsoft.compute/disks','microsoft.compute/locations/communitygalleries/images','microsoft.datamigration/sqlmigrationservices','microsoft.datamigration/dmscentermain','microsoft.datamigration/all','microsoft.datamigration/services/projects','microsoft.datamigration/services','microsoft.network/trafficmanagerprofiles','microsoft.network/dnszones','microsoft.network/dnsresolvers','microsoft.network/dnsforwardingrulesets','microsoft.network/dnsresolverpolicies','microsoft.network/dnsresolverdomainlists','microsoft.documentdb/mongoclusters','microsoft.dbforpostgresql/servergroupsv2','microsoft.documentdb/cassandraclusters','microsoft.documentdb/garnetclusters','microsoft.documentdb/fleetspacepotentialdatabaseaccountswithlocations','microsoft.documentdb/fleetspacepotentialdatabaseaccounts','microsoft.documentdb/fleets','microsoft.documentdb/databaseaccounts','microsoft.easm/workspaces','private.easm/workspaces','microsoft.impact/connectors','microsoft.cdn/edgeactions','microsoft.databoxedge/databoxedgedevices','microsoft.azurestackhci/edgemachines','microsoft.edgeorder/virtual_orderitems','microsoft.edgeorder/orderitems','microsoft.edgeorder/bootstrapconfigurations','microsoft.edgeorder/addresses','microsoft.elasticsan/elasticsans','microsoft.logic/workflows','microsoft.logic/integrationserviceenvironments/managedapis','microsoft.logic/templates','microsoft.logic/integrationaccounts','microsoft.web/connectiongateways','microsoft.web/customapis','microsoft.web/connections','microsoft.communication/emailservices','microsoft.communication/emailservices/domains','microsoft.eventgrid/namespaces/topicspaces','microsoft.eventgrid/topics','microsoft.eventgrid/systemtopics/eventsubscriptions','microsoft.eventgrid/systemtopics','microsoft.eventgrid/namespaces/topics/eventsubscriptions','microsoft.eventgrid/namespaces','microsoft.eventgrid/partnertopics','microsoft.eventgrid/partnerregistrations','microsoft.eventgrid/partnernamespaces','microsoft.eventgrid/partnerdestinations','microsoft.eventgrid/partnerconfigurations','microsoft.eventgrid/namespaces/topics','microsoft.eventgrid/domains/topics','microsoft.eventgrid/domains','microsoft.eventgrid/partnernamespaces/channels','microsoft.eventhub/namespaces/disasterrecoveryconfigs','microsoft.eventhub/namespaces/schemagroups','microsoft.eventhub/namespaces/eventhubs','microsoft.eventhub/clusters','microsoft.eventhub/namespaces','microsoft.experimentation/experimentworkspaces','microsoft.databox/jobs','microsoft.fairfieldgardens/provisioningresources','microsoft.fairfieldgardens/provisioningresources/provisioningpolicies','microsoft.fileshares/fileshares','microsoft.iotfirmwaredefense/workspaces','microsoft.fluidrelay/fluidrelayservers','microsoft.network/frontdoors','microsoft.hdinsight/clusters','microsoft.healthbot/healthbots','microsoft.healthdataaiservices/deidservices','microsoft.healthmodel/healthmodels','microsoft.hybridcompute/machines/microsoft.connectedvmwarevsphere/virtualmachineinstances','microsoft.connectedvmwarevsphere/virtualmachines','microsoft.connectedvmwarevsphere/vcenters','microsoft.hybridcompute/machinessoftwareassurance','microsoft.hybridcompute/machinespaygo','microsoft.hybridcompute/licenses','microsoft.hybridcompute/machinesesu','microsoft.hybridcompute/gateways','microsoft.hybridcompute/arcgatewayassociatedresources','microsoft.scvmm/vmmservers','microsoft.scvmm/virtualmachines','microsoft.hybridcompute/privatelinkscopes','microsoft.hybridconnectivity/publiccloudconnectors','microsoft.hybridconnectivity/publiccloudconnectors/gcpsyncedresources','microsoft.hybridconnectivity/publiccloudconnectors/awssyncedresources','microsoft.hybridcompute/machinessovereign','microsoft.hybridcompute/machines','microsoft.hybridcompute/arcserverwithwac','microsoft.machineconfiguration/baselinesettingsassignments','microsoft.extendedlocation/customlocations','microsoft.azurestackhci/virtualmachines','microsoft.all/arcvirtualmachines','microsoft.resourceconnector/appliances','microsoft.azurearcdata/sqlserverlicenses','microsoft.azurearcdata/sqlserverinstances/databases','microsoft.azurearcdata/sqlserverinstances','microsoft.azurearcdata/sqlserveresulicenses','microsoft.azurearcdata/sqlmanagedinstances','microsoft.azurearcdata/postgressqlserver','microsoft.azurearcdata/postgresinstances','microsoft.azurearcdata/mysqlserver','microsoft.azurearcdata/datacontrollers','microsoft.network/vpngateways','microsoft.network/networkvirtualappliances','microsoft.network/virtualwans','microsoft.network/virtualhubs','microsoft.network/virtualnetworkgateways','microsoft.network/routefilters','microsoft.network/p2svpngateways','microsoft.networkfunction/meshvpns','microsoft.network/localnetworkgateways','microsoft.network/ipgroups','microsoft.network/firewallpolicies','microsoft.networkfunction/azuretrafficcollectors','microsoft.network/expressroutegateways/expressrouteconnections','microsoft.network/expressroutegateways','microsoft.network/expressrouteports','microsoft.network/expressroutecircuits','microsoft.network/connections','microsoft.network/azurefirewalls','microsoft.network/bastionhosts','microsoft.network/applicationgateways','microsoft.servicenetworking/trafficcontrollers','microsoft.devhub/iacprofiles','microsoft.iotcentral/iotapps','microsoft.devices/iothubs','microsoft.devices/provisioningservices','microsoft.iotoperations/instances','microsoft.network/networkmanagers/ipampools','microsoft.storagesync/storagesyncservices','microsoft.keyvault/vaults','microsoft.containerservice/fleets/managednamespaces','microsoft.containerservice/fleets','microsoft.synapse/workspaces/kustopools/databases','microsoft.synapse/workspaces/kustopools','microsoft.kusto/clusters/databases','microsoft.kusto/clusters','microsoft.maps/accounts','microsoft.maps/accounts/creators','microsoft.computeschedule/scheduledactions','microsoft.maintenance/maintenanceconfigurations','microsoft.keyvault/managedhsms','microsoft.labservices/labaccounts/labs','microsoft.labservices/labplans','microsoft.labservices/labaccounts','microsoft.labservices/labs','microsoft.network/networkmanagers/routingconfigurations','microsoft.network/networkmanagers/securityuserconfigurations','microsoft.network/networkmanagers/securityadminconfigurations','microsoft.network/networkmanagers/networkgroups','microsoft.network/networkmanagers/connectivityconfigurations','microsoft.network/networkmanagers','microsoft.managedidentity/userassignedidentities','microsoft.saas/resources','microsoft.saas/applications','microsoft.saas/saasresources','microsoft.gallery/myareas/galleryitems','microsoft.professionalservice/resources','microsoft.migrate/projects','microsoft.machinelearningservices/workspacescreate','microsoft.machinelearningservices/workspaces','microsoft.machinelearningservices/aistudiocreate','microsoft.machinelearningservices/aistudio','microsoft.machinelearningservices/registries','microsoft.machinelearningservices/workspaces/onlineendpoints','microsoft.machinelearningservices/workspaces/onlineendpoints/deployments','microsoft.dashboard/dashboards','private.monitorgrafana/dashboards','microsoft.alertsmanagement/prometheusrulegroups','microsoft.monitor/accounts','microsoft.insights/datacollectionrulesresources','microsoft.insights/datacollectionrules','microsoft.insights/datacollectionendpoints','microsoft.insights/diagnosticsettings','microsoft.eventhub/namespaces/providers/diagnosticsettings','microsoft.monitor/pipelinegroups','microsoft.alertsmanagement/smartdetectoralertrules','microsoft.insights/metricalerts','microsoft.insights/scheduledqueryrules','microsoft.alertsmanagement/actionrules','microsoft.insights/activitylogalerts','microsoft.insights/actiongroups','microsoft.netapp/netappaccounts/capacitypools/volumes/volumequotarules','microsoft.netapp/netappaccounts/volumegroups','microsoft.netapp/scaleaccounts/scalecapacitypools/scalevolumes','microsoft.netapp/netappaccounts/capacitypools/volumes','microsoft.netapp/scaleaccounts/scalesnapshotpolicies','microsoft.netapp/netappaccounts/snapshotpolicies','microsoft.netapp/scaleaccounts/scalecapacitypools/scalevolumes/scalesnapshots','microsoft.netapp/netappaccounts/capacitypools/volumes/snapshots','microsoft.netapp/scaleaccounts/scalecapacitypools','microsoft.netapp/netappaccounts/capacitypools','microsoft.netapp/scaleaccounts/scalebackupvaults','microsoft.netapp/netappaccounts/backupvaults','microsoft.netapp/scaleaccounts/scalecapacitypools/scalevolumes/scalebackups','microsoft.netapp/netappaccounts/backupvaults/backups','microsoft.netapp/scaleaccounts/scalebackuppolicies','microsoft.netapp/netappaccounts/backuppolicies','microsoft.netapp/scaleaccounts','microsoft.netapp/netappaccounts','microsoft.network/frontdoorwebapplicationfirewallpolicies','microsoft.cdn/cdnwebapplicationfirewallpolicies','microsoft.network/applicationgatewaywebapplicationfirewallpolicies','microsoft.network/virtualnetworktaps','microsoft.network/privatednszones/virtualnetworklinks','microsoft.network/virtualnetworks','microsoft.network/serviceendpointpolicies','microsoft.network/routetables','microsoft.authorization/resourcemanagementprivatelinks','microsoft.classicnetwork/reservedips','microsoft.network/publicipprefixes','microsoft.network/publicipaddresses','microsoft.network/privatelinkservices','microsoft.management/managementgroups/providers/privatelinkassociations','microsoft.network/privateendpoints','microsoft.network/networkwatchers/flowlogs','microsoft.network/networkwatchers','microsoft.network/networksecuritygroups','microsoft.network/networkinterfaces','microsoft.network/natgateways','microsoft.network/loadbalancers','microsoft.connectedvmwarevsphere/virtualmachines/providers/guestconfigurationassignments','microsoft.compute/virtualmachinescalesets/providers/guestconfigurationassignments','microsoft.hybridcompute/machines/providers/guestconfigurationassignments','microsoft.compute/virtualmachines/providers/guestconfigurationassignments','microsoft.network/ddosprotectionplans','microsoft.network/customipprefixes','microsoft.classicnetwork/virtualnetworks','microsoft.classicnetwork/networksecuritygroups','microsoft.network/applicationsecuritygroups','microsoft.managednetworkfabric/routepolicies','microsoft.managednetworkfabric/fabricroutepolicies','microsoft.managednetworkfabric/networktaprules','microsoft.managednetworkfabric/networktaps','microsoft.managednetworkfabric/fabricnetworktaps','microsoft.managednetworkfabric/networkracks','microsoft.managednetworkfabric/networkpacketbrokers','microsoft.managednetworkfabric/fabricnetworkpacketbrokers','microsoft.managednetworkfabric/networkdevices/networkinterfaces','microsoft.managednetworkfabric/networkfabriccontrollers','microsoft.managednetworkfabric/networkfabrics','microsoft.managednetworkfabric/networkdevices','microsoft.managednetworkfabric/fabricnetworkdevices','microsoft.managednetworkfabric/neighborgroups','microsoft.managednetworkfabric/networkfabrics/networktonetworkinterconnects','microsoft.managednetworkfabric/l3isolationdomains','microsoft.managednetworkfabric/l2isolationdomains','microsoft.managednetworkfabric/ipprefixes','microsoft.managednetworkfabric/ipextendedcommunities','microsoft.managednetworkfabric/ipcommunities','microsoft.managednetworkfabric/internetgatewayrules','microsoft.managednetworkfabric/internetgateways','microsoft.managednetworkfabric/l3isolationdomains/internalnetworks','microsoft.managednetworkfabric/fabricresources','microsoft.managednetworkfabric/l3isolationdomains/externalnetworks','microsoft.managednetworkfabric/accesscontrollists','microsoft.networkcloud/volumes','microsoft.networkcloud/clustervolumes','microsoft.networkcloud/virtualmachines/consoles','microsoft.networkcloud/virtualmachines','microsoft.networkcloud/trunkednetworks','microsoft.networkcloud/clustertrunkednetworks','microsoft.networkcloud/storageappliances','microsoft.networkcloud/clusterstorageappliances','microsoft.networkcloud/kubernetesclusters/features','microsoft.networkcloud/kubernetesclusters','microsoft.networkcloud/l3networks','microsoft.networkcloud/clusterl3networks','microsoft.networkcloud/l2networks','microsoft.networkcloud/clusterl2networks','microsoft.networkcloud/racks','microsoft.networkcloud/clusterresources','microsoft.networkcloud/clusternetworks','microsoft.networkcloud/clusters/metricsconfigurations','microsoft.networkcloud/clustermanagers','microsoft.networkcloud/clusters/baremetalmachinekeysets','microsoft.networkcloud/clusters/bmckeysets','microsoft.networkcloud/clusters','microsoft.networkcloud/clustercloudservicesnetworks','microsoft.networkcloud/cloudservicesnetworks','microsoft.networkcloud/baremetalmachines','microsoft.networkcloud/kubernetesclusters/agentpools','microsoft.network/networksecurityperimeters','microsoft.network/networksecurityperimeters/profiles','microsoft.notificationhubs/namespaces/notificationhubs','microsoft.notificationhubs/namespaces','microsoft.resources/resourcegraphvisualizer','microsoft.resources/resourcechange','microsoft.onlineexperimentation/workspaces','microsoft.openenergyplatform/energyservices','microsoft.scom/managedinstances','microsoft.orbital/l2connections','microsoft.orbital/groundstations','microsoft.orbital/geocatalogs','microsoft.orbital/edgesites','microsoft.oriondb/clusters','microsoft.dbforpostgresql/flexibleservers','microsoft.dbformysql/flexibleservers','microsoft.durabletask/schedulers/taskhubs','microsoft.app/agents','microsoft.integrationspaces/spaces','microsoft.durabletask/schedulers','microsoft.logic/businessprocesses','microsoft.peering/peerings/registeredprefixes','microsoft.peering/peerings/registeredasns','microsoft.peering/peeringservices/prefixes','microsoft.peering/peeringservices','microsoft.peering/peerings','microsoft.azureplaywrightservice/accounts','microsoft.portalservices/dashboards','microsoft.powerbidedicated/capacities','microsoft.network/privatednszones','microsoft.programmableconnectivity/operatorapiplans','microsoft.programmableconnectivity/operatorapiconnections','microsoft.programmableconnectivity/gateways','microsoft.purview/accounts','microsoft.cognitiveservices/browsetexttranslation','microsoft.cognitiveservices/browsetextanalytics','microsoft.cognitiveservices/browsespeechservices','microsoft.cognitiveservices/browseqnamaker','microsoft.cognitiveservices/browsepersonalizer','microsoft.cognitiveservices/browseopenai','microsoft.cognitiveservices/browsemetricsadvisor','microsoft.cognitiveservices/browseluis','microsoft.cognitiveservices/browseimmersivereader','microsoft.cognitiveservices/browsehealthinsights','microsoft.cognitiveservices/browsehealthdecisionsupport','microsoft.cognitiveservices/browseformrecognizer','microsoft.cognitiveservices/browseface','microsoft.cognitiveservices/browsecustomvision','microsoft.cognitiveservices/browsecontentsafety','microsoft.cognitiveservices/browsecontentmoderator','microsoft.cognitiveservices/browsecomputervision','microsoft.cognitiveservices/accounts/projects','microsoft.cognitiveservices/accounts','microsoft.cognitiveservices/browseanomalydetector','microsoft.cognitiveservices/browseallservices','microsoft.cognitiveservices/browseallinone','microsoft.cognitiveservices/browseaiservices','microsoft.cognitiveservices/browseaifoundry','microsoft.quantum/workspaces','microsoft.recommendationsservice/accounts','microsoft.recommendationsservice/accounts/modeling','microsoft.recommendationsservice/accounts/serviceendpoints','microsoft.recoveryservices/vaults/backupfabrics/protectioncontainers/protecteditems','microsoft.recoveryservices/vaults','microsoft.relay/namespaces/wcfrelays','microsoft.relay/namespaces','microsoft.relay/namespaces/hybridconnections','microsoft.billingbenefits/savingsplanorders','microsoft.billing/billingaccounts/savingsplanorders','microsoft.capacity/reservationorders','microsoft.billingbenefits/incentiveschedules/milestones','microsoft.billing/billingaccounts/incentiveschedules/milestones','microsoft.billingbenefits/maccs','microsoft.billingbenefits/discounts','microsoft.billingbenefits/savingsplanorders/savingsplans','microsoft.billing/billingaccounts/savingsplanorders/savingsplans','microsoft.capacity/reservationorders/reservations','microsoft.billingbenefits/credits','microsoft.billingbenefits/incentiveschedules','microsoft.billing/billingaccounts/incentiveschedules','microsoft.management/servicegroups','microsoft.relationships/servicegrouprelationships','microsoft.relationships/servicegroupmembernojoin','microsoft.relationships/servicegroupmember','microsoft.relationships/dependencyof','microsoft.resources/virtualsubscriptionsforresourcepicker','microsoft.resources/resourcechanges','microsoft.resources/deletedresources','microsoft.changesafety/stagemaps','microsoft.changesafety/changestates','microsoft.changesafety/changestates/stageprogressions','microsoft.management/managementgroups/microsoft.resources/deploymentstacks','microsoft.resources/deploymentstacks','microsoft.deploymentmanager/rollouts','microsoft.features/featureprovidernamespaces/featureconfigurations','microsoft.saashub/cloudservices/hidden','microsoft.hanaonazure/hanainstances','microsoft.baremetalinfrastructure/baremetalinstances','microsoft.azurelargeinstance/azurelargeinstances','microsoft.workloads/sapvirtualinstances','microsoft.workloads/sapvirtualinstances/databaseinstances','microsoft.workloads/sapvirtualinstances/centralinstances','microsoft.workloads/sapvirtualinstances/applicationinstances','microsoft.search/searchservices','microsoft.security/locations/alerts','microsoft.securityinsightsarg/sentinel','microsoft.servicebus/namespaces/topics','microsoft.servicebus/namespaces/topics/subscriptions','microsoft.servicebus/namespaces/queues','microsoft.servicebus/namespaces/disasterrecoveryconfigs','microsoft.servicebus/namespaces','microsoft.servicefabric/clusters','microsoft.servicefabric/managedclusters','microsoft.providerhub/providerregistrations','microsoft.providerhub/providerregistrations/resourcetyperegistrations','microsoft.providerhub/providerregistrations/resourcetyperegistrations/resourcetyperegistrations','microsoft.providerhub/providerregistrations/customrollouts','microsoft.providerhub/providerregistrations/defaultrollouts','microsoft.signalrservice/webpubsub/replicas','microsoft.signalrservice/webpubsub','microsoft.signalrservice/signalr/replicas','microsoft.signalrservice/signalr','microsoft.edge/sites','microsoft.edge/configurations','microsoft.azuresphere/catalogs','microsoft.datareplication/replicationvaults','microsoft.storage/storageaccounts','microsoft.classicstorage/storageaccounts','microsoft.storagecache/caches','microsoft.storagecache/amlfilesystems','microsoft.storagediscovery/storagediscoveryworkspaces','microsoft.storagehub/policycomplianceresources','microsoft.storagehub/all','microsoft.storagemover/storagemovers','microsoft.storageactions/storagetasks','microsoft.streamanalytics/clusters','microsoft.streamanalytics/streamingjobs','microsoft.support/supporttickets','microsoft.synapse/workspaces','microsoft.synapse/workspaces/sqlpools','microsoft.synapse/workspaces/bigdatapools','microsoft.synapse/workspaces/scopepools','microsoft.synapse/privatelinkhubs','microsoft.resources/deploymentscripts','microsoft.management/managementgroups/providers/templatespecs','microsoft.resources/templatespecs','microsoft.resources/builtintemplatespecs','microsoft.usagebilling/accounts/validationworkspaces/signoffs','microsoft.usagebilling/accounts/validationworkspaces','microsoft.usagebilling/accounts/pipelines/outputselectors','microsoft.usagebilling/accounts/pipelines','microsoft.usagebilling/accounts/pav2outputs','microsoft.usagebilling/accounts/metricexports','microsoft.usagebilling/accounts/inputs','microsoft.usagebilling/accounts/dataexports','microsoft.usagebilling/accounts','microsoft.mission/virtualenclaves/workloads','microsoft.mission/virtualenclaves','microsoft.mission/communities/transithubs','microsoft.mission/virtualenclaves/enclaveendpoints','microsoft.mission/enclaveconnections','microsoft.mission/communities/communityendpoints','microsoft.mission/communities','microsoft.mission/catalogs','microsoft.mission/approvals','microsoft.network/virtualnetworkappliances','microsoft.hybridnetwork/networkfunctions','microsoft.hybridnetwork/vendors','microsoft.hybridnetwork/devices','microsoft.hybridnetwork/sitenetworkservices','microsoft.hybridnetwork/sites','microsoft.hybridnetwork/publishers','microsoft.hybridnetwork/publishers/artifactstores','microsoft.hybridnetwork/publishers/artifactstores/artifactmanifests','microsoft.hybridnetwork/publishers/networkservicedesigngroups/networkservicedesignversions','microsoft.hybridnetwork/publishers/networkservicedesigngroups','microsoft.hybridnetwork/publishers/networkfunctiondefinitiongroups/networkfunctiondefinitionversions','microsoft.hybridnetwork/publishers/networkfunctiondefinitiongroups','microsoft.hybridnetwork/publishers/configurationgroupschemas','microsoft.hybridnetwork/configurationgroupvalues','microsoft.workloads/workloadinstance','microsoft.workloads/insights','microsoft.workloads/monitors','microsoft.desktopvirtualization/workspaces','microsoft.desktopvirtualization/scalingplans','microsoft.desktopvirtualization/hostpools','microsoft.desktopvirtualization/applicationgroups','microsoft.desktopvirtualization/appattachpackages','microsoft.zerotrustsegmentation/segmentationmanagers','private.zerotrustsegmentation/segmentationmanagers','microsoft.bing/accounts','microsoft.cloudhealth/healthmodels','microsoft.mixedreality/remoterenderingaccounts','microsoft.connectedcache/enterprisemcccustomers','microsoft.connectedcache/enterprisemcccustomers/enterprisemcccachenodes','microsoft.connectedcache/ispcustomers','microsoft.healthcareapis/workspaces/fhirservices','microsoft.healthcareapis/workspaces/iotconnectors','microsoft.test/healthdataaiservices','microsoft.healthcareapis/workspaces','microsoft.healthcareapis/services','microsoft.healthcareapis/workspaces/dicomservices','microsoft.manufacturingplatform/manufacturingdataservices','microsoft.operationalinsights/querypacks','microsoft.operationalinsights/workspaces','microsoft.operationsmanagement/solutions','microsoft.operationalinsights/clusters','microsoft.premonition/libraries/samples','microsoft.premonition/libraries','microsoft.premonition/libraries/analyses','microsoft.securitycopilot/capacities','private.serviceshubdev/connectors','microsoft.serviceshub/connectors','microsoft.videoindexer/accounts','oracle.database/resourceanchors','oracle.database/networkanchors','oracle.database/exadbvmclusters','oracle.database/exascaledbstoragevaults','oracle.database/cloudvmclusters','oracle.database/cloudexadatainfrastructures','oracle.database/oraclesubscriptions','oracle.database/dbsystems','oracle.database/autonomousdatabases','microsoft.azurescan/scanningaccounts','microsoft.sql/virtualclusters','microsoft.sqlvirtualmachine/sqlvirtualmachines','microsoft.sql/servers','microsoft.dbforpostgresql/servers','microsoft.dbformysql/servers','microsoft.dbformariadb/servers','microsoft.sql/managedinstances','microsoft.sql/managedinstances/databases','microsoft.sql/instancepools','microsoft.databasefleetmanager/fleets/tiers','microsoft.databasefleetmanager/fleets/fleetspaces','microsoft.databasefleetmanager/fleets/fleetspaces/databases','microsoft.databasefleetmanager/fleets','microsoft.sql/servers/elasticpools','microsoft.sql/servers/jobagents','microsoft.sql/servers/databases','microsoft.sql/azuresqlallresources','microsoft.sql/azuresql','microsoft.avs/privateclouds','microsoft.web/sites/slots','microsoft.web/sites','microsoft.web/serverfarms','microsoft.web/staticsites','microsoft.certificateregistration/certificateorders','microsoft.app/sessionpools','microsoft.app/jobs','microsoft.app/managedenvironments','microsoft.app/connectedenvironments','microsoft.app/containerapps','microsoft.web/kubeenvironments','microsoft.web/hostingenvironments','microsoft.domainregistration/domains')) or (isempty(type)))|where (type !~ ('dell.storage/filesystems'))|where (type !~ ('pinecone.vectordb/organizations'))|where (type !~ ('liftrbasic.samplerp/organizations'))|where (type !~ ('commvault.contentstore/cloudaccounts'))|where (type !~ ('paloaltonetworks.cloudngfw/globalrulestacks'))|where (type !~ ('microsoft.liftrpilot/organizations'))|where (type !~ ('microsoft.agfoodplatform/farmbeats'))|where (type !~ ('microsoft.agricultureplatform/agriservices'))|where (type !~ ('microsoft.arc/allfairfax'))|where (type !~ ('microsoft.arc/all'))|where (type !~ ('microsoft.cdn/profiles/securitypolicies'))|where (type !~ ('microsoft.cdn/profiles/secrets'))|where (type !~ ('microsoft.cdn/profiles/rulesets'))|where (type !~ ('microsoft.cdn/profiles/rulesets/rules'))|where (type !~ ('microsoft.cdn/profiles/afdendpoints/routes'))|where (type !~ ('microsoft.cdn/profiles/origingroups'))|where (type !~ ('microsoft.cdn/profiles/origingroups/origins'))|where (type !~ ('microsoft.cdn/profiles/afdendpoints'))|where (type !~ ('microsoft.cdn/profiles/customdomains'))|where (type !~ ('microsoft.chaos/workspaces'))|where (type !~ ('microsoft.chaos/privateaccesses'))|where (type !~ ('microsoft.sovereign/transparencylogs'))|where (type !~ ('microsoft.classiccompute/domainnames/slots/roles'))|where (type !~ ('microsoft.classiccompute/domainnames'))|where (type !~ ('microsoft.cloudtest/pools'))|where (type !~ ('microsoft.cloudtest/images'))|where (type !~ ('microsoft.cloudtest/hostedpools'))|where (type !~ ('microsoft.cloudtest/buildcaches'))|where (type !~ ('microsoft.cloudtest/accounts'))|where (type !~ ('microsoft.compute/virtualmachineflexinstances'))|where (type !~ ('microsoft.compute/standbypoolinstance'))|where (type !~ ('microsoft.compute/computefleetscalesets'))|where (type !~ ('microsoft.compute/computefleetinstances'))|where (type !~ ('microsoft.containerservice/managedclusters/microsoft.kubernetesconfiguration/fluxconfigurations'))|where (type !~ ('microsoft.kubernetes/connectedclusters/microsoft.kubernetesconfiguration/fluxconfigurations'))|where (type !~ ('microsoft.containerservice/managedclusters/microsoft.kubernetesconfiguration/namespaces'))|where (type !~ ('microsoft.kubernetes/connectedclusters/microsoft.kubernetesconfiguration/namespaces'))|where (type !~ ('microsoft.containerservice/managedclusters/microsoft.kubernetesconfiguration/extensions'))|where (type !~ ('microsoft.portalservices/extensions/deployments'))|where (type !~ ('microsoft.portalservices/extensions'))|where (type !~ ('microsoft.portalservices/extensions/slots'))|where (type !~ ('microsoft.portalservices/extensions/versions'))|where (type !~ ('microsoft.deviceregistry/convergedassets'))|where (type !~ ('microsoft.deviceregistry/devices'))|where (type !~ ('microsoft.deviceupdate/updateaccounts'))|where (type !~ ('microsoft.deviceupdate/updateaccounts/updates'))|where (type !~ ('microsoft.deviceupdate/updateaccounts/deviceclasses'))|where (type !~ ('microsoft.deviceupdate/updateaccounts/deployments'))|where (type !~ ('microsoft.deviceupdate/updateaccounts/agents'))|where (type !~ ('microsoft.deviceupdate/updateaccounts/activedeployments'))|where (type !~ ('microsoft.discovery/supercomputers/nodepools'))|where (type !~ ('microsoft.discovery/datacontainers/dataassets'))|where (type !~ ('microsoft.documentdb/garnetclusters'))|where (type !~ ('microsoft.documentdb/fleetspacepotentialdatabaseaccountswithlocations'))|where (type !~ ('microsoft.documentdb/fleetspacepotentialdatabaseaccounts'))|where (type !~ ('private.easm/workspaces'))|where (type !~ ('microsoft.fairfieldgardens/provisioningresources'))|where (type !~ ('microsoft.fairfieldgardens/provisioningresources/provisioningpolicies'))|where (type !~ ('microsoft.healthmodel/healthmodels'))|where (type !~ ('microsoft.hybridcompute/machinessoftwareassurance'))|where (type !~ ('microsoft.hybridcompute/machinespaygo'))|where (type !~ ('microsoft.hybridcompute/machinesesu'))|where (type !~ ('microsoft.hybridcompute/arcgatewayassociatedresources'))|where (type !~ ('microsoft.hybridconnectivity/publiccloudconnectors/gcpsyncedresources'))|where (type !~ ('microsoft.hybridconnectivity/publiccloudconnectors/awssyncedresources'))|where (type !~ ('microsoft.hybridcompute/machinessovereign'))|where (type !~ ('microsoft.hybridcompute/arcserverwithwac'))|where (type !~ ('microsoft.network/networkvirtualappliances'))|where (type !~ ('microsoft.network/virtualhubs')) or ((kind =~ ('routeserver')))|where (type !~ ('microsoft.devhub/iacprofiles'))|where (type !~ ('microsoft.containerservice/fleets/managednamespaces'))|where (type !~ ('microsoft.gallery/myareas/galleryitems'))|where (type !~ ('private.monitorgrafana/dashboards'))|where (type !~ ('microsoft.insights/diagnosticsettings'))|where (type !~ ('microsoft.network/privatednszones/virtualnetworklinks'))|where not((type =~ ('microsoft.network/serviceendpointpolicies')) and ((kind =~ ('internal'))))|where (type !~ ('microsoft.managednetworkfabric/fabricroutepolicies'))|where (type !~ ('microsoft.managednetworkfabric/fabricnetworktaps'))|where (type !~ ('microsoft.managednetworkfabric/fabricnetworkpacketbrokers'))|where (type !~ ('microsoft.managednetworkfabric/fabricnetworkdevices'))|where (type !~ ('microsoft.managednetworkfabric/fabricresources'))|where (type !~ ('microsoft.networkcloud/clustervolumes'))|where (type !~ ('microsoft.networkcloud/clustertrunkednetworks'))|where (type !~ ('microsoft.networkcloud/clusterstorageappliances'))|where (type !~ ('microsoft.networkcloud/clusterl3networks'))|where (type !~ ('microsoft.networkcloud/clusterl2networks'))|where (type !~ ('microsoft.networkcloud/clusterresources'))|where (type !~ ('microsoft.networkcloud/clusternetworks'))|where (type !~ ('microsoft.networkcloud/clustercloudservicesnetworks'))|where (type !~ ('microsoft.resources/resourcegraphvisualizer'))|where (type !~ ('microsoft.orbital/l2connections'))|where (type !~ ('microsoft.orbital/groundstations'))|where (type !~ ('microsoft.orbital/edgesites'))|where (type !~ ('microsoft.oriondb/clusters'))|where (type !~ ('microsoft.recommendationsservice/accounts/modeling'))|where (type !~ ('microsoft.recommendationsservice/accounts/serviceendpoints'))|where (type !~ ('microsoft.relationships/servicegrouprelationships'))|where (type !~ ('microsoft.resources/virtualsubscriptionsforresourcepicker'))|where (type !~ ('microsoft.resources/deletedresources'))|where (type !~ ('microsoft.deploymentmanager/rollouts'))|where (type !~ ('microsoft.features/featureprovidernamespaces/featureconfigurations'))|where (type !~ ('microsoft.saashub/cloudservices/hidden'))|where (type !~ ('microsoft.providerhub/providerregistrations'))|where (type !~ ('microsoft.providerhub/providerregistrations/customrollouts'))|where (type !~ ('microsoft.providerhub/providerregistrations/defaultrollouts'))|where (type !~ ('microsoft.edge/configurations'))|where (type !~ ('microsoft.storagecache/caches'))|where not((type =~ ('microsoft.synapse/workspaces/sqlpools')) and ((kind =~ ('v3'))))|where (type !~ ('microsoft.mission/virtualenclaves/workloads'))|where (type !~ ('microsoft.mission/virtualenclaves'))|where (type !~ ('microsoft.mission/communities/transithubs'))|where (type !~ ('microsoft.mission/virtualenclaves/enclaveendpoints'))|where (type !~ ('microsoft.mission/enclaveconnections'))|where (type !~ ('microsoft.mission/communities/communityendpoints'))|where (type !~ ('microsoft.mission/communities'))|where (type !~ ('microsoft.mission/catalogs'))|where (type !~ ('microsoft.mission/approvals'))|where (type !~ ('microsoft.network/virtualnetworkappliances'))|where (type !~ ('microsoft.workloads/insights'))|where (type !~ ('microsoft.zerotrustsegmentation/segmentationmanagers'))|where (type !~ ('private.zerotrustsegmentation/segmentationmanagers'))|where (type !~ ('microsoft.connectedcache/enterprisemcccustomers/enterprisemcccachenodes'))|where (type !~ ('microsoft.premonition/libraries/samples'))|where (type !~ ('microsoft.premonition/libraries/analyses'))|where not((type =~ ('microsoft.sql/servers')) and ((kind =~ ('v12.0,analytics'))))|where not((type =~ ('microsoft.sql/servers/databases')) and ((kind in~ ('system','v2.0,system','v12.0,system','v12.0,system,serverless','v12.0,user,datawarehouse,gen2,analytics'))))|project id,name,type,kind,location,subscriptionId,resourceGroup,tags,extendedLocation,identity|sort by (tolower(tostring(name))) asc`
-
-	requestBody := map[string]interface{}{
-		"subscriptions": []string{subscriptionID},
-		"query":         kustoQuery,
-	}
-
-	requestBodyJSON, err := json.Marshal(requestBody)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal request body: %v", err)
-	}
-
-	req, err := http.NewRequestWithContext(l.Context(), "POST", resourceGraphURL, strings.NewReader(string(requestBodyJSON)))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %v", err)
-	}
-
-	req.Header.Set("Authorization", "Bearer "+accessToken)
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := l.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("request failed: %v", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != 200 {
-		return nil, fmt.Errorf("API call failed with status %d", resp.StatusCode)
-	}
-
-	var result struct {
-		Data []interface{} `json:"data"`
-	}
-
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %v", err)
-	}
-
-	return result.Data, nil
+	return l.collectResourcesByTypeShards(accessToken, []string{subscriptionID},
+		"id,name,type,kind,location,subscriptionId,resourceGroup,tags,extendedLocation,identity")
 }
 
 // collectAllRoleAssignments collects role assignments at subscription, resource group, and resource levels
@@ -2220,7 +2448,7 @@ func (l *IAMComprehensiveCollectorLink) collectAllRoleAssignments(accessToken, s
 
 // getRoleAssignmentsForScope gets role assignments for a specific scope with pagination support
 func (l *IAMComprehensiveCollectorLink) getRoleAssignmentsForScope(accessToken, scope string) ([]interface{}, error) {
-	roleAssignmentsURL := fmt.Sprintf("https://management.azure.com%s/providers/Microsoft.Authorization/roleAssignments?api-version=2020-04-01-preview&$filter=atScope()", scope)
+	roleAssignmentsURL := l.cloud.armURL(fmt.Sprintf("%s/providers/Microsoft.Authorization/roleAssignments?api-version=2020-04-01-preview&$filter=atScope()", scope))
 
 	// Use paginated ARM data collection to handle nextLink properly
 	return l.collectPaginatedARMData(accessToken, roleAssignmentsURL)
@@ -2228,7 +2456,7 @@ func (l *IAMComprehensiveCollectorLink) getRoleAssignmentsForScope(accessToken,
 
 // getResourceGroups gets all resource groups in the subscription with pagination support
 func (l *IAMComprehensiveCollectorLink) getResourceGroups(accessToken, subscriptionID string) ([]interface{}, error) {
-	resourceGroupsURL := fmt.Sprintf("https://management.azure.com/subscriptions/%s/resourcegroups?api-version=2021-04-01", subscriptionID)
+	resourceGroupsURL := l.cloud.armURL(fmt.Sprintf("/subscriptions/%s/resourcegroups?api-version=2021-04-01", subscriptionID))
 
 	// Use paginated ARM data collection to handle nextLink properly
 	return l.collectPaginatedARMData(accessToken, resourceGroupsURL)
@@ -2236,7 +2464,7 @@ func (l *IAMComprehensiveCollectorLink) getResourceGroups(accessToken, subscript
 
 // collectRoleDefinitions collects all role definitions with pagination support
 func (l *IAMComprehensiveCollectorLink) collectRoleDefinitions(accessToken, subscriptionID string) ([]interface{}, error) {
-	roleDefinitionsURL := fmt.Sprintf("https://management.azure.com/subscriptions/%s/providers/Microsoft.Authorization/roleDefinitions?api-version=2018-01-01-preview", subscriptionID)
+	roleDefinitionsURL := l.cloud.armURL(fmt.Sprintf("/subscriptions/%s/providers/Microsoft.Authorization/roleDefinitions?api-version=2018-01-01-preview", subscriptionID))
 
 	// Use paginated ARM data collection to handle nextLink properly
 	return l.collectPaginatedARMData(accessToken, roleDefinitionsURL)
@@ -2245,7 +2473,7 @@ func (l *IAMComprehensiveCollectorLink) collectRoleDefinitions(accessToken, subs
 // collectKeyVaultAccessPolicies collects Key Vault access policies
 func (l *IAMComprehensiveCollectorLink) collectKeyVaultAccessPolicies(accessToken, subscriptionID string) ([]interface{}, error) {
 	// First get all Key Vaults in the subscription
-	keyVaultsURL := fmt.Sprintf("https://management.azure.com/subscriptions/%s/providers/Microsoft.KeyVault/vaults?api-version=2021-10-01", subscriptionID)
+	keyVaultsURL := l.cloud.armURL(fmt.Sprintf("/subscriptions/%s/providers/Microsoft.KeyVault/vaults?api-version=2021-10-01", subscriptionID))
 
 	req, err := http.NewRequestWithContext(l.Context(), "GET", keyVaultsURL, nil)
 	if err != nil {
@@ -2261,7 +2489,7 @@ func (l *IAMComprehensiveCollectorLink) collectKeyVaultAccessPolicies(accessToke
 	defer resp.Body.Close()
 
 	if resp.StatusCode != 200 {
-		return nil, fmt.Errorf("API call failed with status %d", resp.StatusCode)
+		return nil, newAPIStatusError(resp)
 	}
 
 	var keyVaultsResult struct {
@@ -2314,7 +2542,7 @@ func (l *IAMComprehensiveCollectorLink) collectKeyVaultAccessPolicies(accessToke
 
 // collectSubscriptionRBACAssignments collects subscription-level RBAC assignments with pagination support
 func (l *IAMComprehensiveCollectorLink) collectSubscriptionRBACAssignments(accessToken, subscriptionID string) ([]interface{}, error) {
-	rbacURL := fmt.Sprintf("https://management.azure.com/subscriptions/%s/providers/Microsoft.Authorization/roleAssignments?api-version=2022-04-01&$filter=atScope()", subscriptionID)
+	rbacURL := l.cloud.armURL(fmt.Sprintf("/subscriptions/%s/providers/Microsoft.Authorization/roleAssignments?api-version=2022-04-01&$filter=atScope()", subscriptionID))
 
 	// Use paginated ARM data collection to handle nextLink properly
 	return l.collectPaginatedARMData(accessToken, rbacURL)
@@ -2342,7 +2570,7 @@ func (l *IAMComprehensiveCollectorLink) collectResourceGroupRBACAssignments(acce
 			continue
 		}
 
-		rgRBACURL := fmt.Sprintf("https://management.azure.com/subscriptions/%s/resourceGroups/%s/providers/Microsoft.Authorization/roleAssignments?api-version=2022-04-01&$filter=atScope()", subscriptionID, rgName)
+		rgRBACURL := l.cloud.armURL(fmt.Sprintf("/subscriptions/%s/resourceGroups/%s/providers/Microsoft.Authorization/roleAssignments?api-version=2022-04-01&$filter=atScope()", subscriptionID, rgName))
 
 		req, err := http.NewRequestWithContext(l.Context(), "GET", rgRBACURL, nil)
 		if err != nil {
@@ -2426,7 +2654,7 @@ func (l *IAMComprehensiveCollectorLink) collectSelectedResourceRBACAssignments(a
 
 		processedCount++
 
-		resourceRBACURL := fmt.Sprintf("https://management.azure.com%s/providers/Microsoft.Authorization/roleAssignments?api-version=2020-04-01-preview&$filter=atScope()", resourceID)
+		resourceRBACURL := l.cloud.armURL(fmt.Sprintf("%s/providers/Microsoft.Authorization/roleAssignments?api-version=2020-04-01-preview&$filter=atScope()", resourceID))
 
 		req, err := http.NewRequestWithContext(l.Context(), "GET", resourceRBACURL, nil)
 		if err != nil {
@@ -2473,7 +2701,7 @@ func (l *IAMComprehensiveCollectorLink) collectSelectedResourceRBACAssignments(a
 	return allResourceAssignments, nil
 }
 
-// collectResourceGroupRBACParallel collects resource group RBAC assignments using 3 workers
+// collectResourceGroupRBACParallel collects resource group RBAC assignments on --concurrency workers
 func (l *IAMComprehensiveCollectorLink) collectResourceGroupRBACParallel(accessToken, subscriptionID string) ([]interface{}, error) {
 	// First get all resource groups
 	resourceGroups, err := l.getResourceGroups(accessToken, subscriptionID)
@@ -2485,53 +2713,29 @@ func (l *IAMComprehensiveCollectorLink) collectResourceGroupRBACParallel(accessT
 		return []interface{}{}, nil
 	}
 
-	l.Logger.Info(fmt.Sprintf("Processing %d resource groups with 5 workers", len(resourceGroups)))
+	l.Logger.Info(fmt.Sprintf("Processing %d resource groups with %d workers", len(resourceGroups), min(l.concurrency, len(resourceGroups))))
 
 	type result struct {
-		rbac []interface{}
-		err  error
+		rgName string
+		rbac   []interface{}
+		err    error
 	}
 
-	rgChan := make(chan map[string]interface{}, len(resourceGroups))
 	resultChan := make(chan result, len(resourceGroups))
-
-	// Use 1 worker for resource groups - TESTING CONCURRENCY
-	var wg sync.WaitGroup
-	numWorkers := 1
-	if len(resourceGroups) < 1 {
-		numWorkers = len(resourceGroups)
-	}
-
-	for i := 0; i < numWorkers; i++ {
-		wg.Add(1)
-		go func(workerID int) {
-			defer wg.Done()
-			for rg := range rgChan {
-				rgName := rg["name"].(string)
-				l.Logger.Debug("Worker processing resource group", "worker", workerID, "rg", rgName)
-				rbac, err := l.getRGRoleAssignments(accessToken, subscriptionID, rgName)
-				resultChan <- result{rbac: rbac, err: err}
-			}
-		}(i)
-	}
-
-	// Send work to workers
-	for _, rg := range resourceGroups {
-		rgChan <- rg.(map[string]interface{})
-	}
-	close(rgChan)
-
-	// Wait for workers and close result channel
-	go func() {
-		wg.Wait()
-		close(resultChan)
-	}()
+	runPool(resourceGroups, l.concurrency, func(workerID int, rg interface{}) {
+		rgName, _ := rg.(map[string]interface{})["name"].(string)
+		l.Logger.Debug("Worker processing resource group", "worker", workerID, "rg", rgName)
+		rbac, err := l.getRGRoleAssignments(accessToken, subscriptionID, rgName)
+		resultChan <- result{rgName: rgName, rbac: rbac, err: err}
+	})
+	close(resultChan)
 
 	// Collect results
 	var allRGAssignments []interface{}
 	for res := range resultChan {
 		if res.err != nil {
-			l.Logger.Debug("Failed to get resource group RBAC assignments", "error", res.err)
+			l.Logger.Debug("Failed to get resource group RBAC assignments", "rg", res.rgName, "error", res.err)
+			l.collectionErrors.record("roleAssignments", fmt.Sprintf("/subscriptions/%s/resourceGroups/%s", subscriptionID, res.rgName), res.err)
 			continue
 		}
 		if res.rbac != nil {
@@ -2542,7 +2746,7 @@ func (l *IAMComprehensiveCollectorLink) collectResourceGroupRBACParallel(accessT
 	return allRGAssignments, nil
 }
 
-// collectSelectedResourceRBACParallel collects resource-level RBAC assignments using 3 workers
+// collectSelectedResourceRBACParallel collects resource-level RBAC assignments on --concurrency workers
 func (l *IAMComprehensiveCollectorLink) collectSelectedResourceRBACParallel(accessToken, subscriptionID string, resources []interface{}) ([]interface{}, error) {
 	// Filter for selected resource types first
 	var selectedResources []map[string]interface{}
@@ -2565,54 +2769,30 @@ func (l *IAMComprehensiveCollectorLink) collectSelectedResourceRBACParallel(acce
 		return []interface{}{}, nil
 	}
 
-	l.Logger.Info(fmt.Sprintf("Processing %d selected resources with 5 workers", len(selectedResources)))
+	l.Logger.Info(fmt.Sprintf("Processing %d selected resources with %d workers", len(selectedResources), min(l.concurrency, len(selectedResources))))
 
 	type result struct {
-		rbac []interface{}
-		err  error
+		resourceID string
+		rbac       []interface{}
+		err        error
 	}
 
-	resourceChan := make(chan map[string]interface{}, len(selectedResources))
 	resultChan := make(chan result, len(selectedResources))
-
-	// Use 1 worker for resources - TESTING CONCURRENCY
-	var wg sync.WaitGroup
-	numWorkers := 1
-	if len(selectedResources) < 1 {
-		numWorkers = len(selectedResources)
-	}
-
-	for i := 0; i < numWorkers; i++ {
-		wg.Add(1)
-		go func(workerID int) {
-			defer wg.Done()
-			for resource := range resourceChan {
-				resourceID := resource["id"].(string)
-				resourceType := resource["type"].(string)
-				l.Logger.Debug("Worker processing resource", "worker", workerID, "type", resourceType, "id", resourceID)
-				rbac, err := l.getResourceRoleAssignments(accessToken, resourceID)
-				resultChan <- result{rbac: rbac, err: err}
-			}
-		}(i)
-	}
-
-	// Send work to workers
-	for _, resource := range selectedResources {
-		resourceChan <- resource
-	}
-	close(resourceChan)
-
-	// Wait for workers and close result channel
-	go func() {
-		wg.Wait()
-		close(resultChan)
-	}()
+	runPool(selectedResources, l.concurrency, func(workerID int, resource map[string]interface{}) {
+		resourceID := resource["id"].(string)
+		resourceType := resource["type"].(string)
+		l.Logger.Debug("Worker processing resource", "worker", workerID, "type", resourceType, "id", resourceID)
+		rbac, err := l.getResourceRoleAssignments(accessToken, resourceID)
+		resultChan <- result{resourceID: resourceID, rbac: rbac, err: err}
+	})
+	close(resultChan)
 
 	// Collect results
 	var allResourceAssignments []interface{}
 	for res := range resultChan {
 		if res.err != nil {
-			l.Logger.Debug("Failed to get resource RBAC assignments", "error", res.err)
+			l.Logger.Debug("Failed to get resource RBAC assignments", "resource", res.resourceID, "error", res.err)
+			l.collectionErrors.record("roleAssignments", res.resourceID, res.err)
 			continue
 		}
 		if res.rbac != nil {
@@ -2623,7 +2803,7 @@ func (l *IAMComprehensiveCollectorLink) collectSelectedResourceRBACParallel(acce
 	return allResourceAssignments, nil
 }
 
-// collectKeyVaultAccessPoliciesParallel collects Key Vault access policies using 3 workers
+// collectKeyVaultAccessPoliciesParallel collects Key Vault access policies on --concurrency workers
 func (l *IAMComprehensiveCollectorLink) collectKeyVaultAccessPoliciesParallel(accessToken, subscriptionID string, resources []interface{}) ([]interface{}, error) {
 	// Filter for Key Vault resources only
 	var keyVaults []map[string]interface{}
@@ -2646,53 +2826,30 @@ func (l *IAMComprehensiveCollectorLink) collectKeyVaultAccessPoliciesParallel(ac
 		return []interface{}{}, nil
 	}
 
-	l.Logger.Info(fmt.Sprintf("Processing %d Key Vaults with 5 workers", len(keyVaults)))
+	l.Logger.Info(fmt.Sprintf("Processing %d Key Vaults with %d workers", len(keyVaults), min(l.concurrency, len(keyVaults))))
 
 	type result struct {
+		kvID     string
 		policies []interface{}
 		err      error
 	}
 
-	kvChan := make(chan map[string]interface{}, len(keyVaults))
 	resultChan := make(chan result, len(keyVaults))
-
-	// Use 1 worker for Key Vaults - TESTING CONCURRENCY
-	var wg sync.WaitGroup
-	numWorkers := 1
-	if len(keyVaults) < 1 {
-		numWorkers = len(keyVaults)
-	}
-
-	for i := 0; i < numWorkers; i++ {
-		wg.Add(1)
-		go func(workerID int) {
-			defer wg.Done()
-			for kv := range kvChan {
-				kvName := kv["name"].(string)
-				l.Logger.Debug("Worker processing Key Vault", "worker", workerID, "kv", kvName)
-				policies, err := l.getKeyVaultAccessPolicies(accessToken, subscriptionID, kvName)
-				resultChan <- result{policies: policies, err: err}
-			}
-		}(i)
-	}
-
-	// Send work to workers
-	for _, kv := range keyVaults {
-		kvChan <- kv
-	}
-	close(kvChan)
-
-	// Wait for workers and close result channel
-	go func() {
-		wg.Wait()
-		close(resultChan)
-	}()
+	runPool(keyVaults, l.concurrency, func(workerID int, kv map[string]interface{}) {
+		kvName := kv["name"].(string)
+		kvID, _ := kv["id"].(string)
+		l.Logger.Debug("Worker processing Key Vault", "worker", workerID, "kv", kvName)
+		policies, err := l.getKeyVaultAccessPolicies(accessToken, subscriptionID, kvName)
+		resultChan <- result{kvID: kvID, policies: policies, err: err}
+	})
+	close(resultChan)
 
 	// Collect results
 	var allPolicies []interface{}
 	for res := range resultChan {
 		if res.err != nil {
-			l.Logger.Debug("Failed to get Key Vault access policies", "error", res.err)
+			l.Logger.Debug("Failed to get Key Vault access policies", "kv", res.kvID, "error", res.err)
+			l.collectionErrors.record("keyVaultAccessPolicies", res.kvID, res.err)
 			continue
 		}
 		if res.policies != nil {
@@ -2705,7 +2862,7 @@ func (l *IAMComprehensiveCollectorLink) collectKeyVaultAccessPoliciesParallel(ac
 
 // getRGRoleAssignments gets RBAC assignments for a single resource group with pagination support
 func (l *IAMComprehensiveCollectorLink) getRGRoleAssignments(accessToken, subscriptionID, rgName string) ([]interface{}, error) {
-	rgRBACURL := fmt.Sprintf("https://management.azure.com/subscriptions/%s/resourceGroups/%s/providers/Microsoft.Authorization/roleAssignments?api-version=2020-10-01-preview", subscriptionID, rgName)
+	rgRBACURL := l.cloud.armURL(fmt.Sprintf("/subscriptions/%s/resourceGroups/%s/providers/Microsoft.Authorization/roleAssignments?api-version=2020-10-01-preview", subscriptionID, rgName))
 
 	// Use paginated ARM data collection to handle nextLink properly
 	return l.collectPaginatedARMData(accessToken, rgRBACURL)
@@ -2713,7 +2870,7 @@ func (l *IAMComprehensiveCollectorLink) getRGRoleAssignments(accessToken, subscr
 
 // getResourceRoleAssignments gets RBAC assignments for a single resource with pagination support
 func (l *IAMComprehensiveCollectorLink) getResourceRoleAssignments(accessToken, resourceID string) ([]interface{}, error) {
-	resourceRBACURL := fmt.Sprintf("https://management.azure.com%s/providers/Microsoft.Authorization/roleAssignments?api-version=2020-04-01-preview&$filter=atScope()", resourceID)
+	resourceRBACURL := l.cloud.armURL(fmt.Sprintf("%s/providers/Microsoft.Authorization/roleAssignments?api-version=2020-04-01-preview&$filter=atScope()", resourceID))
 
 	// Use paginated ARM data collection to handle nextLink properly
 	return l.collectPaginatedARMData(accessToken, resourceRBACURL)
@@ -2722,7 +2879,7 @@ func (l *IAMComprehensiveCollectorLink) getResourceRoleAssignments(accessToken,
 // getKeyVaultAccessPolicies gets access policies for a single Key Vault
 func (l *IAMComprehensiveCollectorLink) getKeyVaultAccessPolicies(accessToken, subscriptionID, kvName string) ([]interface{}, error) {
 	// Use paginated ARM data collection to handle nextLink properly
-	kvURL := fmt.Sprintf("https://management.azure.com/subscriptions/%s/providers/Microsoft.KeyVault/vaults?api-version=2019-09-01", subscriptionID)
+	kvURL := l.cloud.armURL(fmt.Sprintf("/subscriptions/%s/providers/Microsoft.KeyVault/vaults?api-version=2019-09-01", subscriptionID))
 
 	allVaults, err := l.collectPaginatedARMData(accessToken, kvURL)
 	if err != nil {
@@ -2755,10 +2912,10 @@ func (l *IAMComprehensiveCollectorLink) getKeyVaultAccessPolicies(accessToken, s
 	return policies, nil
 }
 
-// processSubscriptionsParallel processes multiple subscriptions in parallel with 5 workers
+// processSubscriptionsParallel processes multiple subscriptions in parallel on --concurrency workers
 func (l *IAMComprehensiveCollectorLink) processSubscriptionsParallel(
 	subscriptionIDs []string,
-	refreshToken, tenantID, proxyURL string,
+	tokens azureTokenSource,
 ) map[string]interface{} {
 
 	type subResult struct {
@@ -2767,49 +2924,48 @@ func (l *IAMComprehensiveCollectorLink) processSubscriptionsParallel(
 		err            error
 	}
 
-	subChan := make(chan string, len(subscriptionIDs))
-	resultChan := make(chan subResult, len(subscriptionIDs))
-
-	// Use 1 worker for processing subscriptions - TESTING CONCURRENCY
-	var wg sync.WaitGroup
-	numWorkers := 1
-	if len(subscriptionIDs) < 1 {
-		numWorkers = len(subscriptionIDs)
-	}
-
-	for i := 0; i < numWorkers; i++ {
-		wg.Add(1)
-		go func(workerID int) {
-			defer wg.Done()
-			for subID := range subChan {
-				l.Logger.Info("Worker processing subscription", "worker", workerID, "subscription", subID)
-				message.Info("Collecting AzureRM data for subscription %s...", subID)
-				data, err := l.processSubscriptionRM(subID, refreshToken, tenantID, proxyURL)
-				resultChan <- subResult{subscriptionID: subID, data: data, err: err}
-			}
-		}(i)
-	}
-
-	// Send work to workers
+	// Subscriptions finished by an earlier run of the same --checkpoint are
+	// restored instead of collected
+	allData := make(map[string]interface{})
+	pending := make([]string, 0, len(subscriptionIDs))
 	for _, subID := range subscriptionIDs {
-		subChan <- subID
+		var data map[string]interface{}
+		if l.checkpoint.restore(subscriptionCheckpointPhase(subID), &data, &l.collectionErrors) {
+			message.Info("Restored AzureRM data for subscription %s from checkpoint", subID)
+			allData[subID] = data
+			l.shardWriter.stream(subID, data)
+			l.ndjson.streamSection("azure_resources", subID, data)
+			continue
+		}
+		pending = append(pending, subID)
 	}
-	close(subChan)
+	mark := l.collectionErrors.mark()
 
-	// Wait for workers and close result channel
+	// Results are read as they arrive so finished subscriptions stream to
+	// their shards while the rest are still being collected
+	resultChan := make(chan subResult, len(pending))
 	go func() {
-		wg.Wait()
+		runPool(pending, l.concurrency, func(workerID int, subID string) {
+			l.Logger.Info("Worker processing subscription", "worker", workerID, "subscription", subID)
+			message.Info("Collecting AzureRM data for subscription %s...", subID)
+			data, err := l.processSubscriptionRM(subID, tokens)
+			resultChan <- subResult{subscriptionID: subID, data: data, err: err}
+		})
 		close(resultChan)
 	}()
 
 	// Collect results
-	allData := make(map[string]interface{})
 	for result := range resultChan {
 		if result.err != nil {
 			l.Logger.Error("Failed to process subscription", "subscription", result.subscriptionID, "error", result.err)
+			l.collectionErrors.record("subscription", result.subscriptionID, result.err)
 			continue
 		}
 		allData[result.subscriptionID] = result.data
+		l.shardWriter.stream(result.subscriptionID, result.data)
+		l.ndjson.streamSection("azure_resources", result.subscriptionID, result.data)
+		l.checkpoint.save(subscriptionCheckpointPhase(result.subscriptionID), result.data,
+			subscriptionCollectionErrors(l.collectionErrors.since(mark), result.subscriptionID))
 
 		// Calculate totals for this subscription
 		dataTypeCount := len(result.data)
@@ -2821,19 +2977,20 @@ func (l *IAMComprehensiveCollectorLink) processSubscriptionsParallel(
 
 // processSubscriptionRM processes a single subscription for Azure RM data only
 func (l *IAMComprehensiveCollectorLink) processSubscriptionRM(
-	subscriptionID, refreshToken, tenantID, proxyURL string,
+	subscriptionID string,
+	tokens azureTokenSource,
 ) (map[string]interface{}, error) {
 
 	l.Logger.Info("Collecting AzureRM data", "subscription", subscriptionID)
 
 	// Get Azure RM token
-	azurermToken, err := helpers.GetAzureRMToken(refreshToken, tenantID, proxyURL)
+	azurermToken, err := tokens.armToken()
 	if err != nil {
 		return nil, fmt.Errorf("failed to get AzureRM token: %v", err)
 	}
 
 	// Collect ONLY Azure RM data (no Graph/PIM duplication!)
-	azurermData, err := l.collectAllAzureRMData(azurermToken.AccessToken, subscriptionID, proxyURL)
+	azurermData, err := l.collectAllAzureRMData(azurermToken.AccessToken, subscriptionID)
 	if err != nil {
 		l.Logger.Error("Failed to collect AzureRM data", "error", err)
 		return nil, err
@@ -2912,12 +3069,70 @@ func (l *IAMComprehensiveCollectorLink) collectCompleteGraphPermissions(accessTo
 		allPermissions = append(allPermissions, groupPermissions...)
 	}
 
+	// The same grant is returned globally and again per principal
+	collected := len(allPermissions)
+	allPermissions = mergeGraphPermissions(allPermissions)
+	if duplicates := collected - len(allPermissions); duplicates > 0 {
+		l.Logger.Debug(fmt.Sprintf("Merged %d duplicate Graph API permissions observed in multiple sources", duplicates))
+	}
+
 	l.Logger.Info(fmt.Sprintf("Collected %d total comprehensive Graph API permissions", len(allPermissions)))
 	message.Info("Comprehensive Graph permissions collection completed: %d permissions found", len(allPermissions))
 
 	return allPermissions, nil
 }
 
+// mergeGraphPermissions collapses permissions that share a grant ID and
+// permission into a single entry, recording every source it was seen in.
+// Fields missing from the first observation are filled from later ones.
+func mergeGraphPermissions(permissions []CompleteGraphPermission) []CompleteGraphPermission {
+	merged := make([]CompleteGraphPermission, 0, len(permissions))
+	index := make(map[string]int, len(permissions))
+
+	for _, permission := range permissions {
+		sources := permission.Sources
+		if len(sources) == 0 && permission.Source != "" {
+			sources = []string{permission.Source}
+		}
+
+		key := permission.ID + "|" + permission.Permission
+		i, seen := index[key]
+		if permission.ID == "" || !seen {
+			permission.Sources = nil
+			for _, source := range sources {
+				permission.Sources = appendUnique(permission.Sources, source)
+			}
+			if permission.ID != "" {
+				index[key] = len(merged)
+			}
+			merged = append(merged, permission)
+			continue
+		}
+
+		existing := &merged[i]
+		for _, source := range sources {
+			existing.Sources = appendUnique(existing.Sources, source)
+		}
+		fillEmpty(&existing.ServicePrincipalID, permission.ServicePrincipalID)
+		fillEmpty(&existing.ServicePrincipalName, permission.ServicePrincipalName)
+		fillEmpty(&existing.ServicePrincipalAppID, permission.ServicePrincipalAppID)
+		fillEmpty(&existing.UserID, permission.UserID)
+		fillEmpty(&existing.UserName, permission.UserName)
+		fillEmpty(&existing.GroupID, permission.GroupID)
+		fillEmpty(&existing.GroupName, permission.GroupName)
+		fillEmpty(&existing.GrantedFor, permission.GrantedFor)
+		fillEmpty(&existing.AppRoleID, permission.AppRoleID)
+	}
+
+	return merged
+}
+
+func fillEmpty(field *string, value string) {
+	if *field == "" {
+		*field = value
+	}
+}
+
 // buildServicePrincipalsMap creates a map of service principal ID to basic info for name resolution
 func (l *IAMComprehensiveCollectorLink) buildServicePrincipalsMap(servicePrincipals []interface{}) map[string]ServicePrincipalInfo {
 	spMap := make(map[string]ServicePrincipalInfo)
@@ -3041,7 +3256,7 @@ func (l *IAMComprehensiveCollectorLink) buildAppRolesMap(accessToken string, spM
 func (l *IAMComprehensiveCollectorLink) getServicePrincipalRoles(accessToken, servicePrincipalID string) (map[string]string, map[string]string, error) {
 	endpoint := fmt.Sprintf("/servicePrincipals/%s?$select=appRoles,oauth2PermissionScopes", servicePrincipalID)
 
-	req, err := http.NewRequestWithContext(l.Context(), "GET", fmt.Sprintf("https://graph.microsoft.com/v1.0%s", endpoint), nil)
+	req, err := http.NewRequestWithContext(l.Context(), "GET", l.cloud.graphURL(graphV1, endpoint), nil)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -3056,7 +3271,7 @@ func (l *IAMComprehensiveCollectorLink) getServicePrincipalRoles(accessToken, se
 	defer resp.Body.Close()
 
 	if resp.StatusCode != 200 {
-		return nil, nil, fmt.Errorf("API call failed with status %d", resp.StatusCode)
+		return nil, nil, newAPIStatusError(resp)
 	}
 
 	var result struct {
@@ -3125,20 +3340,21 @@ func (l *IAMComprehensiveCollectorLink) processGlobalOAuth2Grants(grants []inter
 			}
 
 			permission := CompleteGraphPermission{
-				ID:                   grantID,
-				Type:                 "ServicePrincipalDelegated",
-				ServicePrincipalID:   clientID,
-				ServicePrincipalName: clientSP.DisplayName,
-				ResourceAppID:        resourceSP.AppID,
-				ResourceAppName:      resourceSP.DisplayName,
-				PermissionType:       "Delegated",
-				Permission:           strings.TrimSpace(individualScope),
-				ConsentType:          consentType,
-				GrantedFor:           principalID,
-				CreatedDateTime:      startTime,
-				ExpiryDateTime:       expiryTime,
-				Scope:                scope,
-				Source:               "Global",
+				ID:                    grantID,
+				Type:                  "ServicePrincipalDelegated",
+				ServicePrincipalID:    clientID,
+				ServicePrincipalName:  clientSP.DisplayName,
+				ServicePrincipalAppID: clientSP.AppID,
+				ResourceAppID:         resourceSP.AppID,
+				ResourceAppName:       resourceSP.DisplayName,
+				PermissionType:        "Delegated",
+				Permission:            strings.TrimSpace(individualScope),
+				ConsentType:           consentType,
+				GrantedFor:            principalID,
+				CreatedDateTime:       startTime,
+				ExpiryDateTime:        expiryTime,
+				Scope:                 scope,
+				Source:                "Global",
 			}
 
 			// Add user info if available
@@ -3205,6 +3421,7 @@ func (l *IAMComprehensiveCollectorLink) processGlobalAppRoleAssignments(assignme
 				permission.Type = "ServicePrincipalApplication"
 				permission.ServicePrincipalID = sp.ID
 				permission.ServicePrincipalName = sp.DisplayName
+				permission.ServicePrincipalAppID = sp.AppID
 			}
 		case "User":
 			if user, exists := userMap[principalID]; exists {
@@ -3646,20 +3863,21 @@ func (l *IAMComprehensiveCollectorLink) buildDelegatedPermissionsFromGrant(grant
 		}
 
 		permission := CompleteGraphPermission{
-			ID:                   grantID,
-			Type:                 fmt.Sprintf("%sDelegated", principalType),
-			ServicePrincipalID:   clientID,
-			ServicePrincipalName: clientSP.DisplayName,
-			ResourceAppID:        resourceSP.AppID,
-			ResourceAppName:      resourceSP.DisplayName,
-			PermissionType:       "Delegated",
-			Permission:           strings.TrimSpace(individualScope),
-			ConsentType:          consentType,
-			GrantedFor:           principalID,
-			CreatedDateTime:      startTime,
-			ExpiryDateTime:       expiryTime,
-			Scope:                scope,
-			Source:               principalType,
+			ID:                    grantID,
+			Type:                  fmt.Sprintf("%sDelegated", principalType),
+			ServicePrincipalID:    clientID,
+			ServicePrincipalName:  clientSP.DisplayName,
+			ServicePrincipalAppID: clientSP.AppID,
+			ResourceAppID:         resourceSP.AppID,
+			ResourceAppName:       resourceSP.DisplayName,
+			PermissionType:        "Delegated",
+			Permission:            strings.TrimSpace(individualScope),
+			ConsentType:           consentType,
+			GrantedFor:            principalID,
+			CreatedDateTime:       startTime,
+			ExpiryDateTime:        expiryTime,
+			Scope:                 scope,
+			Source:                principalType,
 		}
 
 		// Add user info if available
@@ -3676,67 +3894,36 @@ func (l *IAMComprehensiveCollectorLink) buildDelegatedPermissionsFromGrant(grant
 	return permissions
 }
 
-// analyzeComprehensiveGraphPermissions analyzes collected permissions for security risks
-func (l *IAMComprehensiveCollectorLink) analyzeComprehensiveGraphPermissions(permissions []CompleteGraphPermission) {
-	dangerousPermissions := map[string]string{
-		"Directory.ReadWrite.All":                      "Full directory read/write access",
-		"Directory.Read.All":                           "Full directory read access",
-		"Directory.AccessAsUser.All":                   "Access directory as signed-in user",
-		"User.ReadWrite.All":                           "Read/write all user profiles",
-		"User.Read.All":                                "Read all user profiles",
-		"User.Export.All":                              "Export user data",
-		"Application.ReadWrite.All":                    "Manage all applications",
-		"Application.Read.All":                         "Read all applications",
-		"RoleManagement.ReadWrite.Directory":           "Manage directory roles",
-		"RoleManagement.Read.Directory":                "Read directory roles",
-		"DeviceManagementConfiguration.ReadWrite.All":  "Manage device configuration",
-		"DeviceManagementManagedDevices.ReadWrite.All": "Manage all devices",
-		"Policy.ReadWrite.All":                         "Manage all policies",
-		"Policy.Read.All":                              "Read all policies",
-		"Policy.ReadWrite.ConditionalAccess":           "Manage conditional access policies",
-		"PrivilegedAccess.ReadWrite.AzureAD":           "Manage privileged access",
-		"Sites.FullControl.All":                        "Full control of all sites",
-		"Files.ReadWrite.All":                          "Read/write all files",
-		"Mail.ReadWrite":                               "Read/write mail",
-		"Calendars.ReadWrite":                          "Read/write calendars",
-		"MailboxSettings.ReadWrite":                    "Manage mailbox settings",
-		"Group.ReadWrite.All":                          "Manage all groups",
-		"GroupMember.ReadWrite.All":                    "Manage group membership",
-	}
-
-	dangerousFindings := make(map[string][]string)
-	typeStats := make(map[string]int)
-	consentStats := make(map[string]int)
-
-	for _, permission := range permissions {
-		typeStats[permission.Type]++
-		consentStats[permission.ConsentType]++
-
-		if description, isDangerous := dangerousPermissions[permission.Permission]; isDangerous {
-			key := fmt.Sprintf("%s (%s)", permission.Permission, description)
-			principalName := ""
-			if permission.ServicePrincipalName != "" {
-				principalName = permission.ServicePrincipalName
-			} else if permission.UserName != "" {
-				principalName = permission.UserName
-			} else if permission.GroupName != "" {
-				principalName = permission.GroupName
-			}
-			dangerousFindings[key] = append(dangerousFindings[key], fmt.Sprintf("%s (%s)", principalName, permission.Type))
-		}
-	}
+// analyzeComprehensiveGraphPermissions analyzes collected permissions for
+// security risks, logs them and returns the graphPermissionFindings and
+// graphPermissionStatistics sections
+func (l *IAMComprehensiveCollectorLink) analyzeComprehensiveGraphPermissions(permissions []CompleteGraphPermission) ([]interface{}, map[string]interface{}) {
+	findings, statistics := buildGraphPermissionFindings(permissions, l.dangerousGraphPermissions, l.spSuppressions)
 
 	// Log statistics
 	l.Logger.Info("Graph Permission Statistics:")
 	l.Logger.Info("By Type:")
-	for permType, count := range typeStats {
+	for permType, count := range statistics["byType"].(map[string]int) {
 		l.Logger.Info(fmt.Sprintf("  %s: %d", permType, count))
 	}
 	l.Logger.Info("By Consent:")
-	for consent, count := range consentStats {
+	for consent, count := range statistics["byConsentType"].(map[string]int) {
 		l.Logger.Info(fmt.Sprintf("  %s: %d", consent, count))
 	}
 
+	dangerousFindings := make(map[string][]string)
+	informationalFindings := make(map[string][]string)
+	for _, f := range findings {
+		finding := f.(map[string]interface{})
+		key := fmt.Sprintf("%s (%s)", finding["permission"], finding["risk"])
+		principal := fmt.Sprintf("%s (%s)", finding["principalName"], finding["grantType"])
+		if reason, suppressed := finding["suppressionReason"]; suppressed {
+			informationalFindings[key] = append(informationalFindings[key], fmt.Sprintf("%s [%s]", principal, reason))
+			continue
+		}
+		dangerousFindings[key] = append(dangerousFindings[key], principal)
+	}
+
 	if len(dangerousFindings) > 0 {
 		l.Logger.Warn(fmt.Sprintf("Found %d types of dangerous Graph API permissions", len(dangerousFindings)))
 		message.Info("🚨 Dangerous Graph API permissions detected:")
@@ -3746,12 +3933,25 @@ func (l *IAMComprehensiveCollectorLink) analyzeComprehensiveGraphPermissions(per
 			l.Logger.Warn(fmt.Sprintf("  %s: %s", permission, strings.Join(principals, ", ")))
 		}
 	}
+
+	if len(informationalFindings) > 0 {
+		message.Info("Dangerous Graph API permissions downgraded to informational by --suppress-sp-file:")
+		for permission, principals := range informationalFindings {
+			l.Logger.Info(fmt.Sprintf("  %s: %s", permission, strings.Join(principals, ", ")))
+		}
+	}
+
+	if suppressed := statistics["suppressedFindings"].(int); suppressed > 0 {
+		message.Info("Suppressed %d dangerous Graph API permission findings for service principals in --suppress-sp-file", suppressed)
+	}
+
+	return findings, statistics
 }
 
 func (l *IAMComprehensiveCollectorLink) collectApplicationOwnership(accessToken string) ([]interface{}, error) {
 	var applicationOwnerships []interface{}
 
-	applications, err := l.collectPaginatedGraphData(accessToken, "/applications?$expand=owners")
+	applications, err := l.collectPaginatedGraphData(accessToken, graphV1, "/applications?$expand=owners")
 	if err != nil {
 		return nil, err
 	}
@@ -3775,7 +3975,7 @@ func (l *IAMComprehensiveCollectorLink) collectApplicationOwnership(accessToken
 				}
 
 				ownerID, _ := ownerMap["id"].(string)
-				ownerType, _ := ownerMap["@odata.type"].(string)
+				ownerType := normalizeODataType(ownerMap["@odata.type"])
 				ownerName, _ := ownerMap["displayName"].(string)
 
 				ownership := map[string]interface{}{
@@ -0,0 +1,237 @@
+package iam
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// graphFixture serves a small, fixed Graph tenant to both collectors so their
+// output can be compared field by field.
+type graphFixture struct{}
+
+var (
+	fixtureServicePrincipals = []interface{}{
+		map[string]interface{}{"id": "sp-1", "displayName": "Backend API"},
+		map[string]interface{}{"id": "sp-2", "displayName": "Frontend"},
+	}
+	fixtureGroups = []interface{}{
+		map[string]interface{}{"id": "grp-1", "displayName": "Admins"},
+	}
+	fixtureAppRoleAssignment = map[string]interface{}{
+		"id":                   "ara-1",
+		"appRoleId":            "role-1",
+		"principalId":          "sp-2",
+		"principalDisplayName": "Frontend",
+		"principalType":        "ServicePrincipal",
+		"resourceId":           "sp-1",
+		"resourceDisplayName":  "Backend API",
+		"createdDateTime":      "2024-01-01T00:00:00Z",
+	}
+	fixtureGroupMembers = []interface{}{
+		map[string]interface{}{"@odata.type": "#microsoft.graph.user", "id": "user-1", "displayName": "Alice"},
+		map[string]interface{}{"@odata.type": "#microsoft.graph.servicePrincipal", "id": "sp-2", "displayName": "Frontend"},
+	}
+)
+
+func (graphFixture) RoundTrip(req *http.Request) (*http.Response, error) {
+	switch {
+	case req.Method == http.MethodGet && strings.HasSuffix(req.URL.Path, "/v1.0/servicePrincipals"):
+		return jsonResponse(map[string]interface{}{"value": fixtureServicePrincipals})
+	case req.Method == http.MethodGet && strings.HasSuffix(req.URL.Path, "/v1.0/groups"):
+		return jsonResponse(map[string]interface{}{"value": fixtureGroups})
+	case req.Method == http.MethodPost && strings.HasSuffix(req.URL.Path, "/$batch"):
+		var batch struct {
+			Requests []struct {
+				ID  string `json:"id"`
+				URL string `json:"url"`
+			} `json:"requests"`
+		}
+		if err := json.NewDecoder(req.Body).Decode(&batch); err != nil {
+			return nil, err
+		}
+		responses := make([]interface{}, 0, len(batch.Requests))
+		for _, r := range batch.Requests {
+			responses = append(responses, map[string]interface{}{
+				"id":     r.ID,
+				"status": 200,
+				"body":   map[string]interface{}{"value": fixtureBatchValue(r.URL)},
+			})
+		}
+		return jsonResponse(map[string]interface{}{"responses": responses})
+	}
+	return &http.Response{StatusCode: http.StatusNotFound, Body: io.NopCloser(strings.NewReader("{}")), Header: make(http.Header)}, nil
+}
+
+// fixtureBatchValue returns the value array for a single batched Graph request
+func fixtureBatchValue(requestURL string) []interface{} {
+	path := strings.SplitN(requestURL, "?", 2)[0]
+	switch {
+	case path == "/servicePrincipals/sp-1/appRoleAssignedTo", path == "/servicePrincipals/sp-2/appRoleAssignments":
+		return []interface{}{fixtureAppRoleAssignment}
+	case path == "/groups/grp-1/members":
+		return fixtureGroupMembers
+	}
+	return []interface{}{}
+}
+
+func jsonResponse(body interface{}) (*http.Response, error) {
+	raw, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Body:       io.NopCloser(bytes.NewReader(raw)),
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+	}, nil
+}
+
+type fakeTokenCredential struct{}
+
+func (fakeTokenCredential) GetToken(context.Context, policy.TokenRequestOptions) (azcore.AccessToken, error) {
+	return azcore.AccessToken{Token: "fixture-token", ExpiresOn: time.Now().Add(time.Hour)}, nil
+}
+
+// recordFieldSets returns the sorted key set of every record, keyed by the
+// given identity fields so the two collectors can be compared independent of
+// ordering.
+func recordFieldSets(t *testing.T, records []interface{}, identity ...string) map[string][]string {
+	t.Helper()
+	sets := make(map[string][]string)
+	for _, record := range records {
+		recordMap, ok := record.(map[string]interface{})
+		require.True(t, ok, "record should be a map")
+
+		var idParts []string
+		for _, field := range identity {
+			value, _ := recordMap[field].(string)
+			idParts = append(idParts, value)
+		}
+
+		keys := make([]string, 0, len(recordMap))
+		for key := range recordMap {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		sets[strings.Join(idParts, "|")] = keys
+	}
+	return sets
+}
+
+// jsonKeySet marshals v and returns the sorted top-level keys of the result
+func jsonKeySet(t *testing.T, v interface{}) []string {
+	t.Helper()
+	raw, err := json.Marshal(v)
+	require.NoError(t, err)
+	var decoded map[string]interface{}
+	require.NoError(t, json.Unmarshal(raw, &decoded))
+	keys := make([]string, 0, len(decoded))
+	for key := range decoded {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// TestHTTPAndSDKCollectorsProduceSameShape runs the relationship collectors of
+// both implementations against the same Graph fixture and fails if the record
+// field sets or the consolidated envelope drift apart. PIM records are not
+// compared: the HTTP collector reads the legacy PIM API and the SDK collector
+// reads Graph roleManagement, so their payloads differ by design.
+func TestHTTPAndSDKCollectorsProduceSameShape(t *testing.T) {
+	client := &http.Client{Transport: graphFixture{}}
+
+	httpLink := NewIAMComprehensiveCollectorLink().(*IAMComprehensiveCollectorLink)
+	httpLink.httpClient = client
+
+	sdkLink := NewSDKComprehensiveCollectorLink().(*SDKComprehensiveCollectorLink)
+	sdkLink.httpClient = client
+	sdkLink.credential = fakeTokenCredential{}
+
+	httpAppRoles, err := httpLink.collectAppRoleAssignments("fixture-token")
+	require.NoError(t, err)
+	sdkAppRoles, err := sdkLink.collectAllAppRoleAssignmentsWithPagination(context.Background(), fixtureServicePrincipals)
+	require.NoError(t, err)
+
+	httpMemberships, err := httpLink.collectGroupMemberships("fixture-token")
+	require.NoError(t, err)
+	sdkMemberships, err := sdkLink.collectAllGroupMembershipsWithPagination(context.Background(), fixtureGroups)
+	require.NoError(t, err)
+
+	require.Len(t, httpAppRoles, 2, "assignment should be seen from both the resource and the principal side")
+	require.Len(t, httpMemberships, 2)
+
+	assert.Equal(t,
+		recordFieldSets(t, httpAppRoles, "id", "direction"),
+		recordFieldSets(t, sdkAppRoles, "id", "direction"),
+		"appRoleAssignments records drifted between collectors")
+	assert.Equal(t, httpAppRoles, sdkAppRoles, "appRoleAssignments values drifted between collectors")
+
+	assert.Equal(t,
+		recordFieldSets(t, httpMemberships, "groupId", "memberId"),
+		recordFieldSets(t, sdkMemberships, "groupId", "memberId"),
+		"groupMemberships records drifted between collectors")
+
+	// The HTTP collector leaves sections it could not collect unset while the
+	// SDK collector always emits them; Normalize must reconcile the envelopes.
+	httpOutput := &ConsolidatedOutput{
+		CollectionMetadata: CollectionMetadata{SchemaVersion: ConsolidatedSchemaVersion},
+		AzureAD: map[string]interface{}{
+			"appRoleAssignments": httpAppRoles,
+			"groupMemberships":   httpMemberships,
+		},
+		AzureResources: map[string]interface{}{"sub-1": map[string]interface{}{}},
+	}
+	sdkOutput := &ConsolidatedOutput{
+		CollectionMetadata: CollectionMetadata{SchemaVersion: ConsolidatedSchemaVersion},
+		AzureAD: map[string]interface{}{
+			"appRoleAssignments":   sdkAppRoles,
+			"groupMemberships":     sdkMemberships,
+			"applicationOwnership": []interface{}{},
+		},
+		PIM:                 map[string]interface{}{"eligible_assignments": []interface{}{}},
+		ManagementGroupRBAC: []interface{}{},
+		AzureResources: map[string]interface{}{
+			"sub-1": map[string]interface{}{"tenantRoleAssignments": []interface{}{}},
+		},
+	}
+	httpOutput.Normalize()
+	sdkOutput.Normalize()
+
+	// Sections filled in by Normalize must stay distinguishable from
+	// sections that were collected and came back empty.
+	assert.Subset(t, httpOutput.CollectionMetadata.NotCollected, []string{
+		"management_group_rbac",
+		"azure_resources.sub-1.managementGroupRoleAssignments",
+		"azure_resources.sub-1.tenantRoleAssignments",
+	})
+	assert.NotContains(t, sdkOutput.CollectionMetadata.NotCollected, "management_group_rbac")
+	assert.NotContains(t, sdkOutput.CollectionMetadata.NotCollected, "azure_resources.sub-1.tenantRoleAssignments")
+	assert.NotContains(t, httpOutput.CollectionMetadata.NotCollected, "azure_ad.appRoleAssignments")
+	assert.NotContains(t, httpOutput.CollectionMetadata.NotCollected, "azure_ad.ruleFindings", "findings are computed after Normalize")
+
+	notCollected := httpOutput.CollectionMetadata.NotCollected
+	httpOutput.Normalize()
+	assert.Equal(t, notCollected, httpOutput.CollectionMetadata.NotCollected, "Normalize must not forget sections on a second pass")
+
+	assert.Equal(t, jsonKeySet(t, httpOutput), jsonKeySet(t, sdkOutput), "top-level keys drifted")
+	assert.Equal(t, jsonKeySet(t, httpOutput.CollectionMetadata), jsonKeySet(t, sdkOutput.CollectionMetadata), "metadata keys drifted")
+	assert.Equal(t, jsonKeySet(t, httpOutput.AzureAD), jsonKeySet(t, sdkOutput.AzureAD), "azure_ad section keys drifted")
+	assert.Equal(t, jsonKeySet(t, httpOutput.PIM), jsonKeySet(t, sdkOutput.PIM), "pim section keys drifted")
+	assert.Equal(t,
+		jsonKeySet(t, httpOutput.AzureResources["sub-1"]),
+		jsonKeySet(t, sdkOutput.AzureResources["sub-1"]),
+		"subscription section keys drifted")
+}
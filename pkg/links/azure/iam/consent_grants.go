@@ -0,0 +1,177 @@
+package iam
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/praetorian-inc/janus-framework/pkg/chain/cfg"
+)
+
+// sensitiveDelegatedScopes are delegated permissions that, consented for every
+// user, let an app read or act on all mail, files, chats or directory objects
+// those users can reach. They are the usual targets of consent phishing.
+var sensitiveDelegatedScopes = map[string]bool{
+	"mail.read":                          true,
+	"mail.readwrite":                     true,
+	"mail.read.shared":                   true,
+	"mail.readwrite.shared":              true,
+	"mail.send":                          true,
+	"mailboxsettings.readwrite":          true,
+	"ews.accessasuser.all":               true,
+	"full_access_as_user":                true,
+	"files.read.all":                     true,
+	"files.readwrite.all":                true,
+	"sites.read.all":                     true,
+	"sites.readwrite.all":                true,
+	"sites.fullcontrol.all":              true,
+	"notes.read.all":                     true,
+	"notes.readwrite.all":                true,
+	"contacts.readwrite":                 true,
+	"calendars.readwrite":                true,
+	"chat.read":                          true,
+	"chat.readwrite":                     true,
+	"channelmessage.read.all":            true,
+	"directory.accessasuser.all":         true,
+	"directory.readwrite.all":            true,
+	"user.readwrite.all":                 true,
+	"group.readwrite.all":                true,
+	"application.readwrite.all":          true,
+	"approleassignment.readwrite.all":    true,
+	"rolemanagement.readwrite.directory": true,
+}
+
+// microsoftTenants own Microsoft's first-party apps
+var microsoftTenants = map[string]bool{
+	"f8cdef31-a31e-4b4a-93e4-5f571e91255a": true,
+	"72f988bf-86f1-41af-91ab-2d7cd011db47": true,
+}
+
+// multiTenantAudiences are signInAudience values that let users of other
+// tenants sign in to an app
+var multiTenantAudiences = map[string]bool{
+	"azureadmultipleorgs":                true,
+	"azureadandpersonalmicrosoftaccount": true,
+	"personalmicrosoftaccount":           true,
+}
+
+// consentPublisher classifies who owns the client app of a grant: the
+// collected tenant, Microsoft, a third party, or unknown when the service
+// principal was not collected
+func consentPublisher(sp map[string]interface{}, tenantID string) string {
+	if sp == nil {
+		return "unknown"
+	}
+	owner, _ := sp["appOwnerOrganizationId"].(string)
+	owner = strings.ToLower(owner)
+	switch {
+	case owner == "":
+		return "unknown"
+	case owner == strings.ToLower(tenantID):
+		return "tenant"
+	case microsoftTenants[owner]:
+		return "microsoft"
+	}
+	return "thirdParty"
+}
+
+// verifiedPublisherName returns the verified publisher of a service principal, if any
+func verifiedPublisherName(sp map[string]interface{}) string {
+	publisher, _ := sp["verifiedPublisher"].(map[string]interface{})
+	name, _ := publisher["displayName"].(string)
+	return name
+}
+
+// buildConsentGrantFindings flags delegated grants consented for all users
+// (consentType AllPrincipals) that include sensitive scopes. Grants to third
+// party apps without a verified publisher are the strongest consent phishing
+// indicators and rank highest.
+func buildConsentGrantFindings(o *ConsolidatedOutput) []interface{} {
+	findings := []interface{}{}
+	servicePrincipals := make(map[string]map[string]interface{})
+	sps, _ := o.AzureAD["servicePrincipals"].([]interface{})
+	for _, sp := range sps {
+		if spMap, ok := sp.(map[string]interface{}); ok {
+			if id, _ := spMap["id"].(string); id != "" {
+				servicePrincipals[strings.ToLower(id)] = spMap
+			}
+		}
+	}
+
+	grants, _ := o.AzureAD["oauth2PermissionGrants"].([]interface{})
+	for _, grant := range grants {
+		grantMap, ok := grant.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if consentType, _ := grantMap["consentType"].(string); !strings.EqualFold(consentType, "AllPrincipals") {
+			continue
+		}
+		scope, _ := grantMap["scope"].(string)
+		scopes := strings.Fields(scope)
+		var sensitive []string
+		for _, s := range scopes {
+			if sensitiveDelegatedScopes[strings.ToLower(s)] {
+				sensitive = append(sensitive, s)
+			}
+		}
+		if len(sensitive) == 0 {
+			continue
+		}
+		sort.Strings(sensitive)
+
+		clientID, _ := grantMap["clientId"].(string)
+		resourceID, _ := grantMap["resourceId"].(string)
+		client := servicePrincipals[strings.ToLower(clientID)]
+		resource := servicePrincipals[strings.ToLower(resourceID)]
+		publisher := consentPublisher(client, o.CollectionMetadata.TenantID)
+		verifiedPublisher := verifiedPublisherName(client)
+		signInAudience, _ := client["signInAudience"].(string)
+
+		severity := "Low"
+		switch {
+		case publisher == "thirdParty" && verifiedPublisher == "":
+			severity = "High"
+		case publisher == "thirdParty" || publisher == "unknown":
+			severity = "Medium"
+		}
+
+		appName := clientID
+		if name, _ := client["displayName"].(string); name != "" {
+			appName = name
+		}
+		resourceName := resourceID
+		if name, _ := resource["displayName"].(string); name != "" {
+			resourceName = name
+		}
+		findings = append(findings, map[string]interface{}{
+			"type":     "TenantWideSensitiveConsent",
+			"severity": severity,
+			"description": fmt.Sprintf("%s has tenant-wide consent to %s on %s (%s app)",
+				appName, strings.Join(sensitive, ", "), resourceName, publisher),
+			"grantId":             grantMap["id"],
+			"clientId":            clientID,
+			"appId":               client["appId"],
+			"appDisplayName":      client["displayName"],
+			"resourceId":          resourceID,
+			"resourceDisplayName": resource["displayName"],
+			"consentType":         grantMap["consentType"],
+			"scopes":              scopes,
+			"sensitiveScopes":     sensitive,
+			"publisher":           publisher,
+			"verifiedPublisher":   verifiedPublisher,
+			"appOwnerTenantId":    client["appOwnerOrganizationId"],
+			"signInAudience":      signInAudience,
+			"multiTenant":         multiTenantAudiences[strings.ToLower(signInAudience)],
+		})
+	}
+
+	sortFindings(findings, "appDisplayName")
+	return findings
+}
+
+// logConsentGrantFindings reports tenant-wide consents to sensitive scopes
+func logConsentGrantFindings(logger *cfg.Logger, findings []interface{}) {
+	logFindings(logger, findings, "🚨 %d apps have tenant-wide consent to sensitive delegated scopes", "Tenant-wide sensitive consent",
+		"app", "appDisplayName", "publisher", "publisher", "scopes", "sensitiveScopes", "severity", "severity")
+}
@@ -0,0 +1,58 @@
+package iam
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const consentGrantsFixture = `{
+  "collection_metadata": {"tenant_id": "11111111-1111-1111-1111-111111111111"},
+  "azure_ad": {
+    "servicePrincipals": [
+      {"id": "sp-graph", "appId": "00000003-0000-0000-c000-000000000000", "displayName": "Microsoft Graph", "appOwnerOrganizationId": "f8cdef31-a31e-4b4a-93e4-5f571e91255a"},
+      {"id": "sp-phish", "appId": "app-phish", "displayName": "Mail Sync Pro", "signInAudience": "AzureADMultipleOrgs", "appOwnerOrganizationId": "99999999-9999-9999-9999-999999999999"},
+      {"id": "sp-vendor", "appId": "app-vendor", "displayName": "Backup Vendor", "signInAudience": "AzureADMultipleOrgs", "appOwnerOrganizationId": "88888888-8888-8888-8888-888888888888", "verifiedPublisher": {"displayName": "Backup Vendor Inc"}},
+      {"id": "sp-internal", "appId": "app-internal", "displayName": "HR Portal", "signInAudience": "AzureADMyOrg", "appOwnerOrganizationId": "11111111-1111-1111-1111-111111111111"}
+    ],
+    "oauth2PermissionGrants": [
+      {"id": "grant-phish", "clientId": "sp-phish", "resourceId": "sp-graph", "consentType": "AllPrincipals", "scope": "User.Read mail.readwrite offline_access Files.ReadWrite.All"},
+      {"id": "grant-vendor", "clientId": "sp-vendor", "resourceId": "sp-graph", "consentType": "AllPrincipals", "scope": "Sites.Read.All"},
+      {"id": "grant-internal", "clientId": "sp-internal", "resourceId": "sp-graph", "consentType": "AllPrincipals", "scope": "Mail.Send User.Read"},
+      {"id": "grant-user", "clientId": "sp-phish", "resourceId": "sp-graph", "consentType": "Principal", "principalId": "user-1", "scope": "Mail.ReadWrite"},
+      {"id": "grant-benign", "clientId": "sp-vendor", "resourceId": "sp-graph", "consentType": "AllPrincipals", "scope": "User.Read openid profile"},
+      {"id": "grant-orphan", "clientId": "sp-gone", "resourceId": "sp-graph", "consentType": "AllPrincipals", "scope": "Chat.Read"}
+    ]
+  },
+  "pim": {}
+}`
+
+func TestBuildConsentGrantFindings(t *testing.T) {
+	var output ConsolidatedOutput
+	require.NoError(t, json.Unmarshal([]byte(consentGrantsFixture), &output))
+
+	findings := buildConsentGrantFindings(&output)
+	require.Len(t, findings, 4, "user consents and grants without sensitive scopes are not reported")
+
+	phish := findings[0].(map[string]interface{})
+	assert.Equal(t, "grant-phish", phish["grantId"])
+	assert.Equal(t, "High", phish["severity"])
+	assert.Equal(t, "thirdParty", phish["publisher"])
+	assert.Equal(t, true, phish["multiTenant"])
+	assert.Equal(t, []string{"Files.ReadWrite.All", "mail.readwrite"}, phish["sensitiveScopes"])
+	assert.Equal(t, "Microsoft Graph", phish["resourceDisplayName"])
+
+	medium := []string{findings[1].(map[string]interface{})["grantId"].(string), findings[2].(map[string]interface{})["grantId"].(string)}
+	assert.ElementsMatch(t, []string{"grant-vendor", "grant-orphan"}, medium)
+	for _, finding := range findings[1:3] {
+		assert.Equal(t, "Medium", finding.(map[string]interface{})["severity"])
+	}
+
+	internal := findings[3].(map[string]interface{})
+	assert.Equal(t, "grant-internal", internal["grantId"])
+	assert.Equal(t, "tenant", internal["publisher"])
+	assert.Equal(t, "Low", internal["severity"])
+	assert.Equal(t, false, internal["multiTenant"])
+}
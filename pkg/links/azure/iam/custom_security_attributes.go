@@ -0,0 +1,183 @@
+package iam
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/praetorian-inc/janus-framework/pkg/chain/cfg"
+)
+
+// attributeRoles are the Entra ID roles that read or manage custom security
+// attributes, keyed by role template ID. Global Administrator holds none of
+// these permissions, so these roles are the only way to the attributes.
+var attributeRoles = map[string]string{
+	"8424c6f0-a189-499e-bbd0-26c1753c96d4": "Attribute Definition Administrator",
+	"1d336d2c-4ae8-42ef-9711-b3604ce3fc2c": "Attribute Definition Reader",
+	"58a13ea3-c632-46ae-9ee0-9c0d43cd7f3d": "Attribute Assignment Administrator",
+	"ffd52fa5-98dc-465c-991d-fc073eb59f8f": "Attribute Assignment Reader",
+}
+
+// attributeManagementSeverity ranks the attribute roles that change
+// attributes. Assignment administrators set the values that ABAC conditions
+// evaluate; definition administrators create the attributes and their allowed
+// values.
+var attributeManagementSeverity = map[string]string{
+	"58a13ea3-c632-46ae-9ee0-9c0d43cd7f3d": "High",
+	"8424c6f0-a189-499e-bbd0-26c1753c96d4": "Medium",
+}
+
+const attributeSetScopePrefix = "/attributeSets/"
+
+// roleAssignmentsEndpoint lists the assignments of one directory role
+// with the assigned principal expanded, including those scoped to an attribute
+// set or administrative unit
+func roleAssignmentsEndpoint(roleTemplateID string) string {
+	return fmt.Sprintf("/roleManagement/directory/roleAssignments?$filter=roleDefinitionId%%20eq%%20'%s'&$expand=principal", roleTemplateID)
+}
+
+// collectCustomSecurityAttributes collects attribute sets with their attribute
+// definitions into customSecurityAttributes and the holders of the attribute
+// roles into attributeRoleAssignments, using fetch to page through Graph
+func collectCustomSecurityAttributes(logger *cfg.Logger, errs *collectionErrorLog, fetch func(version, endpoint string) ([]interface{}, error), azureADData map[string]interface{}) {
+	sets, err := fetch(graphV1, "/directory/attributeSets")
+	if err != nil {
+		logger.Warn("Failed to collect custom security attribute sets, continuing without them", "error", err)
+		errs.record("customSecurityAttributes", "tenant", err)
+	} else {
+		definitions, err := fetch(graphV1, "/directory/customSecurityAttributeDefinitions")
+		if err != nil {
+			logger.Warn("Failed to collect custom security attribute definitions, continuing without them", "error", err)
+			errs.record("customSecurityAttributes", "tenant", err)
+		}
+		azureADData["customSecurityAttributes"] = newAttributeSetRecords(sets, definitions)
+	}
+
+	assignments := []interface{}{}
+	templateIDs := make([]string, 0, len(attributeRoles))
+	for templateID := range attributeRoles {
+		templateIDs = append(templateIDs, templateID)
+	}
+	sort.Strings(templateIDs)
+	for _, templateID := range templateIDs {
+		roleAssignments, err := fetch(graphV1, roleAssignmentsEndpoint(templateID))
+		if err != nil {
+			logger.Warn("Failed to collect attribute role assignments", "role", attributeRoles[templateID], "error", err)
+			errs.record("attributeRoleAssignments", "tenant", err)
+			continue
+		}
+		for _, assignment := range roleAssignments {
+			if assignmentMap, ok := assignment.(map[string]interface{}); ok {
+				assignments = append(assignments, newAttributeRoleAssignmentRecord(templateID, assignmentMap))
+			}
+		}
+	}
+	azureADData["attributeRoleAssignments"] = assignments
+	logger.Info("Collected custom security attributes", "attribute_sets", len(sets), "role_assignments", len(assignments))
+}
+
+// newAttributeSetRecords nests each attribute definition under its attribute set
+func newAttributeSetRecords(sets, definitions []interface{}) []interface{} {
+	bySet := make(map[string][]interface{})
+	for _, definition := range definitions {
+		if definitionMap, ok := definition.(map[string]interface{}); ok {
+			set, _ := definitionMap["attributeSet"].(string)
+			bySet[strings.ToLower(set)] = append(bySet[strings.ToLower(set)], definitionMap)
+		}
+	}
+
+	records := []interface{}{}
+	for _, set := range sets {
+		setMap, ok := set.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		id, _ := setMap["id"].(string)
+		setDefinitions := bySet[strings.ToLower(id)]
+		if setDefinitions == nil {
+			setDefinitions = []interface{}{}
+		}
+		records = append(records, map[string]interface{}{
+			"id":                  id,
+			"description":         setMap["description"],
+			"maxAttributesPerSet": setMap["maxAttributesPerSet"],
+			"definitions":         setDefinitions,
+		})
+	}
+	return records
+}
+
+// newAttributeRoleAssignmentRecord flattens a unifiedRoleAssignment of an
+// attribute role. attributeSet names the set the assignment is scoped to and
+// is empty for tenant-wide assignments.
+func newAttributeRoleAssignmentRecord(templateID string, assignment map[string]interface{}) map[string]interface{} {
+	scope, _ := assignment["directoryScopeId"].(string)
+	record := map[string]interface{}{
+		"id":               assignment["id"],
+		"principalId":      assignment["principalId"],
+		"roleTemplateId":   templateID,
+		"roleName":         attributeRoles[templateID],
+		"directoryScopeId": scope,
+		"attributeSet":     "",
+	}
+	if strings.HasPrefix(scope, attributeSetScopePrefix) {
+		record["attributeSet"] = strings.TrimPrefix(scope, attributeSetScopePrefix)
+	}
+	if principal, ok := assignment["principal"].(map[string]interface{}); ok {
+		record["principalType"] = normalizeODataType(principal["@odata.type"])
+		record["principalDisplayName"] = principal["displayName"]
+	}
+	return record
+}
+
+// buildAttributeManagementFindings flags attribute administrator roles
+// assigned tenant-wide. Those holders can change attributes in every set,
+// including the sets that storage ABAC conditions or access policies rely on,
+// where a set-scoped assignment would limit them to one set.
+func buildAttributeManagementFindings(o *ConsolidatedOutput) []interface{} {
+	findings := []interface{}{}
+	principals := indexReportPrincipals(o)
+
+	assignments, _ := o.AzureAD["attributeRoleAssignments"].([]interface{})
+	for _, assignment := range assignments {
+		a, ok := assignment.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		templateID, _ := a["roleTemplateId"].(string)
+		severity, manages := attributeManagementSeverity[strings.ToLower(templateID)]
+		scope, _ := a["directoryScopeId"].(string)
+		principalID, _ := a["principalId"].(string)
+		if !manages || scope != "/" || principalID == "" {
+			continue
+		}
+
+		finding := reportRow(principals, principalID)
+		if finding["principalType"] == "Unknown" {
+			// "#microsoft.graph.servicePrincipal" becomes "ServicePrincipal", as for collected principals
+			finding["principalType"] = principalTypeFromODataType(a["principalType"])
+			if name, _ := a["principalDisplayName"].(string); name != "" {
+				finding["principalName"] = name
+			}
+		}
+		roleName := attributeRoles[strings.ToLower(templateID)]
+		finding["type"] = "TenantWideAttributeManagement"
+		finding["severity"] = severity
+		finding["description"] = fmt.Sprintf("%s holds %s for every attribute set in the tenant",
+			finding["principalName"], roleName)
+		finding["roleTemplateId"] = templateID
+		finding["roleName"] = roleName
+		finding["scope"] = scope
+		finding["assignmentId"] = a["id"]
+		findings = append(findings, finding)
+	}
+
+	sortFindings(findings, "principalName")
+	return findings
+}
+
+// logAttributeManagementFindings reports tenant-wide attribute administrators
+func logAttributeManagementFindings(logger *cfg.Logger, findings []interface{}) {
+	logFindings(logger, findings, "🚨 %d principals manage custom security attributes tenant-wide", "Tenant-wide attribute management",
+		"principal", "principalName", "role", "roleName", "severity", "severity")
+}
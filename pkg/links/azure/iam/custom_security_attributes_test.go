@@ -0,0 +1,80 @@
+package iam
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/praetorian-inc/janus-framework/pkg/chain/cfg"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCollectCustomSecurityAttributes(t *testing.T) {
+	fetch := func(version, endpoint string) ([]interface{}, error) {
+		switch {
+		case endpoint == "/directory/attributeSets":
+			return []interface{}{
+				map[string]interface{}{"id": "Engineering", "maxAttributesPerSet": 25},
+				map[string]interface{}{"id": "Empty"},
+			}, nil
+		case endpoint == "/directory/customSecurityAttributeDefinitions":
+			return []interface{}{map[string]interface{}{"id": "Engineering_Project", "attributeSet": "Engineering", "name": "Project"}}, nil
+		case strings.Contains(endpoint, "58a13ea3-c632-46ae-9ee0-9c0d43cd7f3d"):
+			return []interface{}{
+				map[string]interface{}{"id": "ra-1", "principalId": "sp-hr", "directoryScopeId": "/", "principal": map[string]interface{}{"@odata.type": "#microsoft.graph.servicePrincipal", "displayName": "hr-sync"}},
+				map[string]interface{}{"id": "ra-2", "principalId": "u-bob", "directoryScopeId": "/attributeSets/Engineering"},
+			}, nil
+		case strings.Contains(endpoint, "1d336d2c-4ae8-42ef-9711-b3604ce3fc2c"):
+			return nil, errors.New("forbidden")
+		}
+		return nil, nil
+	}
+
+	azureADData := map[string]interface{}{}
+	var errs collectionErrorLog
+	collectCustomSecurityAttributes(cfg.NewLogger(), &errs, fetch, azureADData)
+
+	sets := azureADData["customSecurityAttributes"].([]interface{})
+	require.Len(t, sets, 2)
+	assert.Len(t, sets[0].(map[string]interface{})["definitions"], 1)
+	assert.Empty(t, sets[1].(map[string]interface{})["definitions"])
+
+	assignments := azureADData["attributeRoleAssignments"].([]interface{})
+	require.Len(t, assignments, 2)
+	tenantWide := assignments[0].(map[string]interface{})
+	assert.Equal(t, "Attribute Assignment Administrator", tenantWide["roleName"])
+	assert.Equal(t, "", tenantWide["attributeSet"])
+	assert.Equal(t, "hr-sync", tenantWide["principalDisplayName"])
+	assert.Equal(t, "Engineering", assignments[1].(map[string]interface{})["attributeSet"])
+
+	require.Len(t, errs.list(), 1)
+	assert.Equal(t, "attributeRoleAssignments", errs.list()[0].Dataset)
+}
+
+func TestBuildAttributeManagementFindings(t *testing.T) {
+	output := &ConsolidatedOutput{
+		AzureAD: map[string]interface{}{
+			"users": []interface{}{map[string]interface{}{"id": "u-alice", "userPrincipalName": "alice@contoso.com"}},
+			"attributeRoleAssignments": []interface{}{
+				map[string]interface{}{"id": "ra-1", "principalId": "u-alice", "roleTemplateId": "8424c6f0-a189-499e-bbd0-26c1753c96d4", "directoryScopeId": "/"},
+				map[string]interface{}{"id": "ra-2", "principalId": "sp-hr", "principalType": "#microsoft.graph.servicePrincipal", "principalDisplayName": "hr-sync", "roleTemplateId": "58a13ea3-c632-46ae-9ee0-9c0d43cd7f3d", "directoryScopeId": "/"},
+				map[string]interface{}{"id": "ra-3", "principalId": "u-bob", "roleTemplateId": "58a13ea3-c632-46ae-9ee0-9c0d43cd7f3d", "directoryScopeId": "/attributeSets/Engineering"},
+				map[string]interface{}{"id": "ra-4", "principalId": "u-carol", "roleTemplateId": "ffd52fa5-98dc-465c-991d-fc073eb59f8f", "directoryScopeId": "/"},
+			},
+		},
+	}
+
+	findings := buildAttributeManagementFindings(output)
+	require.Len(t, findings, 2, "set-scoped assignments and reader roles are not reported")
+
+	assignmentAdmin := findings[0].(map[string]interface{})
+	assert.Equal(t, "High", assignmentAdmin["severity"])
+	assert.Equal(t, "hr-sync", assignmentAdmin["principalName"])
+	assert.Equal(t, "ServicePrincipal", assignmentAdmin["principalType"])
+
+	definitionAdmin := findings[1].(map[string]interface{})
+	assert.Equal(t, "Medium", definitionAdmin["severity"])
+	assert.Equal(t, "alice@contoso.com", definitionAdmin["principalName"])
+	assert.Equal(t, "Attribute Definition Administrator", definitionAdmin["roleName"])
+}
@@ -0,0 +1,268 @@
+package iam
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/praetorian-inc/janus-framework/pkg/chain/cfg"
+)
+
+// deviceLocalCredentialReadAllAppRoleID is the Microsoft Graph
+// DeviceLocalCredential.Read.All application permission, which reads the
+// Windows LAPS password of every device
+const deviceLocalCredentialReadAllAppRoleID = "884b599e-4d48-43a5-ba94-15c414d00588"
+
+// lapsReaderRoles are the built-in Entra ID roles that read Windows LAPS
+// passwords backed up to Entra ID, keyed by role template ID
+var lapsReaderRoles = map[string]string{
+	globalAdminTemplateID:                  "Global Administrator",
+	"7698a772-787b-4ac8-901f-60d6b08affd2": "Cloud Device Administrator",
+	"3a2c62db-5318-420d-8d74-23affee5d9d5": "Intune Administrator",
+}
+
+// deviceRelationships are the device navigation properties collected into
+// deviceOwnership, with the role recorded for each
+var deviceRelationships = []struct {
+	property string
+	role     string
+}{
+	{"registeredOwners", "RegisteredOwner"},
+	{"registeredUsers", "RegisteredUser"},
+}
+
+// collectDeviceOwnership collects the registered owners and users of every
+// device into deviceOwnership, using fetch to page through Graph
+func collectDeviceOwnership(logger *cfg.Logger, errs *collectionErrorLog, fetch func(version, endpoint string) ([]interface{}, error), azureADData map[string]interface{}) {
+	records := []interface{}{}
+	for _, relationship := range deviceRelationships {
+		devices, err := fetch(graphV1, fmt.Sprintf("/devices?$select=id,displayName&$expand=%s($select=id,displayName,userPrincipalName)", relationship.property))
+		if err != nil {
+			logger.Warn("Failed to collect device relationships, continuing without them", "relationship", relationship.property, "error", err)
+			errs.record("deviceOwnership", "tenant", err)
+			continue
+		}
+		records = append(records, newDeviceOwnershipRecords(devices, relationship.property, relationship.role)...)
+	}
+	azureADData["deviceOwnership"] = records
+	logger.Info("Collected device ownership", "records", len(records))
+}
+
+// newDeviceOwnershipRecords builds one deviceOwnership entry per device and
+// principal of the expanded relationship property
+func newDeviceOwnershipRecords(devices []interface{}, property, role string) []interface{} {
+	records := []interface{}{}
+	for _, device := range devices {
+		deviceMap, ok := device.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		principals, _ := deviceMap[property].([]interface{})
+		for _, principal := range principals {
+			principalMap, ok := principal.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			records = append(records, map[string]interface{}{
+				"deviceObjectId":         deviceMap["id"],
+				"deviceName":             deviceMap["displayName"],
+				"ownerId":                principalMap["id"],
+				"ownerName":              principalMap["displayName"],
+				"ownerUserPrincipalName": principalMap["userPrincipalName"],
+				"ownerType":              normalizeODataType(principalMap["@odata.type"]),
+				"role":                   role,
+				"permissionType":         "DeviceOwnership",
+			})
+		}
+	}
+	return records
+}
+
+// lapsPasswordReaders lists the principals that can read Windows LAPS
+// passwords from Entra ID: holders of the LAPS reader roles, standing or PIM
+// eligible, and service principals granted DeviceLocalCredential.Read.All
+func lapsPasswordReaders(o *ConsolidatedOutput) []map[string]interface{} {
+	readers := []map[string]interface{}{}
+	seen := make(map[string]bool)
+	add := func(principalID string, reader map[string]interface{}) {
+		key := strings.ToLower(fmt.Sprint(principalID, reader["roleName"], reader["eligible"]))
+		if principalID == "" || seen[key] {
+			return
+		}
+		seen[key] = true
+		reader["principalId"] = principalID
+		readers = append(readers, reader)
+	}
+
+	assignments, _ := o.AzureAD["directoryRoleAssignments"].([]interface{})
+	for _, assignment := range assignments {
+		a, ok := assignment.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		templateID, _ := a["roleTemplateId"].(string)
+		if roleName, ok := lapsReaderRoles[strings.ToLower(templateID)]; ok {
+			principalID, _ := a["principalId"].(string)
+			add(principalID, map[string]interface{}{"roleName": roleName, "roleTemplateId": strings.ToLower(templateID), "eligible": false})
+		}
+	}
+
+	eligible, _ := o.PIM["eligible_assignments"].([]interface{})
+	for _, assignment := range eligible {
+		a, ok := assignment.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		principalID, templateID := pimAssignmentPrincipalAndRole(a)
+		if roleName, ok := lapsReaderRoles[strings.ToLower(templateID)]; ok {
+			add(principalID, map[string]interface{}{"roleName": roleName, "roleTemplateId": strings.ToLower(templateID), "eligible": true})
+		}
+	}
+
+	appRoleAssignments, _ := o.AzureAD["appRoleAssignments"].([]interface{})
+	for _, assignment := range appRoleAssignments {
+		a, ok := assignment.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if appRoleID, _ := a["appRoleId"].(string); strings.EqualFold(appRoleID, deviceLocalCredentialReadAllAppRoleID) {
+			principalID, _ := a["principalId"].(string)
+			add(principalID, map[string]interface{}{"permission": "DeviceLocalCredential.Read.All", "eligible": false})
+		}
+	}
+	return readers
+}
+
+// buildDeviceFindings reports the devices of privileged users that give a
+// way onto their sessions: devices not marked compliant, which conditional
+// access requiring a compliant device does not protect, and Windows devices
+// whose LAPS password can be read by principals other than Global
+// Administrators, who then have local admin on the device.
+func buildDeviceFindings(o *ConsolidatedOutput) []interface{} {
+	findings := []interface{}{}
+	ownership, _ := o.AzureAD["deviceOwnership"].([]interface{})
+	if len(ownership) == 0 {
+		return findings
+	}
+
+	devices := make(map[string]map[string]interface{})
+	deviceList, _ := o.AzureAD["devices"].([]interface{})
+	for _, device := range deviceList {
+		if deviceMap, ok := device.(map[string]interface{}); ok {
+			devices[strings.ToLower(fmt.Sprint(deviceMap["id"]))] = deviceMap
+		}
+	}
+
+	// Global Administrators can already do anything a LAPS password gives
+	broadReaders := []map[string]interface{}{}
+	globalAdmins := make(map[string]bool)
+	readers := lapsPasswordReaders(o)
+	for _, reader := range readers {
+		if reader["roleTemplateId"] == globalAdminTemplateID {
+			globalAdmins[strings.ToLower(fmt.Sprint(reader["principalId"]))] = true
+		}
+	}
+	for _, reader := range readers {
+		if !globalAdmins[strings.ToLower(fmt.Sprint(reader["principalId"]))] {
+			broadReaders = append(broadReaders, reader)
+		}
+	}
+
+	grants := privilegedPrincipalGrants(o)
+	privilegedUsers := make(map[string][]map[string]interface{})
+	var deviceIDs []string
+	for _, record := range ownership {
+		r, ok := record.(map[string]interface{})
+		if !ok || len(grants[strings.ToLower(fmt.Sprint(r["ownerId"]))]) == 0 {
+			continue
+		}
+		deviceID := strings.ToLower(fmt.Sprint(r["deviceObjectId"]))
+		if _, ok := privilegedUsers[deviceID]; !ok {
+			deviceIDs = append(deviceIDs, deviceID)
+		}
+		privilegedUsers[deviceID] = append(privilegedUsers[deviceID], r)
+	}
+
+	for _, deviceID := range deviceIDs {
+		records := privilegedUsers[deviceID]
+		device := devices[deviceID]
+		deviceName := records[0]["deviceName"]
+
+		users := []interface{}{}
+		var names []string
+		seen := make(map[string]bool)
+		for _, r := range records {
+			userID := strings.ToLower(fmt.Sprint(r["ownerId"]))
+			if seen[userID] {
+				continue
+			}
+			seen[userID] = true
+			users = append(users, map[string]interface{}{
+				"principalId":       r["ownerId"],
+				"principalName":     r["ownerName"],
+				"userPrincipalName": r["ownerUserPrincipalName"],
+				"role":              r["role"],
+				"privilegedGrants":  grants[userID],
+			})
+			names = append(names, fmt.Sprint(r["ownerName"]))
+		}
+		sort.Strings(names)
+
+		base := map[string]interface{}{
+			"deviceObjectId":  records[0]["deviceObjectId"],
+			"deviceName":      deviceName,
+			"operatingSystem": device["operatingSystem"],
+			"isCompliant":     device["isCompliant"],
+			"isManaged":       device["isManaged"],
+			"privilegedUsers": users,
+		}
+		finding := func(findingType, severity, description string) map[string]interface{} {
+			f := map[string]interface{}{"type": findingType, "severity": severity, "description": description}
+			for key, value := range base {
+				f[key] = value
+			}
+			return f
+		}
+
+		if device["isCompliant"] != true {
+			findings = append(findings, finding("PrivilegedUserNonCompliantDevice", "Medium",
+				fmt.Sprintf("Device %v of privileged users %s is not marked compliant", deviceName, strings.Join(names, ", "))))
+		}
+
+		operatingSystem, _ := device["operatingSystem"].(string)
+		if len(broadReaders) > 0 && strings.HasPrefix(strings.ToLower(operatingSystem), "windows") {
+			f := finding("PrivilegedUserDeviceLAPSReadable", "High",
+				fmt.Sprintf("The LAPS password of device %v of privileged users %s can be read by %d principals that are not Global Administrators",
+					deviceName, strings.Join(names, ", "), len(broadReaders)))
+			lapsReaders := make([]interface{}, 0, len(broadReaders))
+			for _, reader := range broadReaders {
+				lapsReaders = append(lapsReaders, reader)
+			}
+			f["lapsReaders"] = lapsReaders
+			findings = append(findings, f)
+		}
+	}
+
+	sortFindings(findings, "description")
+	return findings
+}
+
+// logDeviceFindings reports privileged users' devices that expose their sessions
+func logDeviceFindings(logger *cfg.Logger, findings []interface{}) {
+	logFindings(logger, findings, "🚨 %d devices of privileged users are non-compliant or have broadly readable LAPS passwords", "Privileged user device",
+		"device", "deviceName", "type", "type", "description", "description")
+}
+
+func lapsReadersReport(o *ConsolidatedOutput, principals map[string]reportPrincipal) []map[string]interface{} {
+	rows := []map[string]interface{}{}
+	for _, reader := range lapsPasswordReaders(o) {
+		row := reportRow(principals, fmt.Sprint(reader["principalId"]))
+		for _, key := range []string{"roleName", "permission", "eligible"} {
+			if value, ok := reader[key]; ok {
+				row[key] = value
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
@@ -0,0 +1,85 @@
+package iam
+
+import (
+	"testing"
+
+	"github.com/praetorian-inc/janus-framework/pkg/chain/cfg"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCollectDeviceOwnership(t *testing.T) {
+	fetch := func(version, endpoint string) ([]interface{}, error) {
+		owner := map[string]interface{}{"@odata.type": "microsoft.graph.user", "id": "u-admin", "displayName": "Admin", "userPrincipalName": "admin@contoso.com"}
+		return []interface{}{
+			map[string]interface{}{"id": "d-1", "displayName": "ADMIN-LAPTOP", "registeredOwners": []interface{}{owner}, "registeredUsers": []interface{}{owner}},
+			map[string]interface{}{"id": "d-2", "displayName": "KIOSK"},
+		}, nil
+	}
+
+	azureAD := map[string]interface{}{}
+	errs := collectionErrorLog{}
+	collectDeviceOwnership(cfg.NewLogger(), &errs, fetch, azureAD)
+	records := azureAD["deviceOwnership"].([]interface{})
+	require.Len(t, records, 2, "one record per relationship")
+	assert.Empty(t, errs.list())
+
+	owner := records[0].(map[string]interface{})
+	assert.Equal(t, "d-1", owner["deviceObjectId"])
+	assert.Equal(t, "RegisteredOwner", owner["role"])
+	assert.Equal(t, odataTypeUser, owner["ownerType"])
+	assert.Equal(t, "RegisteredUser", records[1].(map[string]interface{})["role"])
+}
+
+func TestDeviceFindings(t *testing.T) {
+	o := &ConsolidatedOutput{
+		AzureAD: map[string]interface{}{
+			"users": []interface{}{
+				map[string]interface{}{"id": "u-ga", "displayName": "Global Admin"},
+				map[string]interface{}{"id": "u-staff", "displayName": "Staff"},
+			},
+			"devices": []interface{}{
+				map[string]interface{}{"id": "d-ga", "displayName": "GA-LAPTOP", "operatingSystem": "Windows", "isCompliant": true},
+				map[string]interface{}{"id": "d-ga-mac", "displayName": "GA-MAC", "operatingSystem": "MacMDM", "isCompliant": false},
+				map[string]interface{}{"id": "d-staff", "displayName": "STAFF-PC", "operatingSystem": "Windows"},
+			},
+			"deviceOwnership": []interface{}{
+				map[string]interface{}{"deviceObjectId": "d-ga", "deviceName": "GA-LAPTOP", "ownerId": "u-ga", "ownerName": "Global Admin", "role": "RegisteredOwner"},
+				map[string]interface{}{"deviceObjectId": "d-ga", "deviceName": "GA-LAPTOP", "ownerId": "u-ga", "ownerName": "Global Admin", "role": "RegisteredUser"},
+				map[string]interface{}{"deviceObjectId": "d-ga-mac", "deviceName": "GA-MAC", "ownerId": "u-ga", "ownerName": "Global Admin", "role": "RegisteredOwner"},
+				map[string]interface{}{"deviceObjectId": "d-staff", "deviceName": "STAFF-PC", "ownerId": "u-staff", "ownerName": "Staff", "role": "RegisteredOwner"},
+			},
+			"directoryRoleAssignments": []interface{}{
+				map[string]interface{}{"principalId": "u-ga", "roleTemplateId": globalAdminTemplateID},
+			},
+			"appRoleAssignments": []interface{}{
+				map[string]interface{}{"principalId": "sp-laps", "appRoleId": deviceLocalCredentialReadAllAppRoleID},
+			},
+		},
+		PIM: map[string]interface{}{
+			"eligible_assignments": []interface{}{
+				map[string]interface{}{"principalId": "u-helpdesk", "roleDefinitionId": "7698a772-787b-4ac8-901f-60d6b08affd2"},
+			},
+		},
+	}
+
+	readers := lapsPasswordReaders(o)
+	require.Len(t, readers, 3)
+
+	findings := buildDeviceFindings(o)
+	require.Len(t, findings, 2, "the staff device has no privileged user, the compliant GA laptop only a LAPS finding")
+
+	laps := findings[0].(map[string]interface{})
+	assert.Equal(t, "PrivilegedUserDeviceLAPSReadable", laps["type"])
+	assert.Equal(t, "GA-LAPTOP", laps["deviceName"])
+	assert.Len(t, laps["lapsReaders"], 2, "Global Administrators are not counted as broad readers")
+	assert.Len(t, laps["privilegedUsers"], 1, "an owner who is also a registered user is listed once")
+
+	nonCompliant := findings[1].(map[string]interface{})
+	assert.Equal(t, "PrivilegedUserNonCompliantDevice", nonCompliant["type"])
+	assert.Equal(t, "GA-MAC", nonCompliant["deviceName"])
+	assert.Equal(t, "Medium", nonCompliant["severity"])
+
+	rows := runOfflineReports(o, []string{"laps-readers"})["laps-readers"]
+	require.Len(t, rows, 3)
+}
@@ -0,0 +1,63 @@
+package iam
+
+import (
+	"encoding/json"
+	"sort"
+)
+
+// alwaysProjectedFields are kept by every --project, so each resource can
+// still be identified, attributed to its subscription and imported
+var alwaysProjectedFields = []string{"id", "type", "subscriptionId"}
+
+// Project keeps only the given fields, plus alwaysProjectedFields, on every
+// entry of each subscription's azureResources and records the kept fields in
+// the collection metadata. The full properties of a resource are usually most
+// of a dump and are not needed for IAM analysis. It must run after the
+// findings are computed, since several detections read resource properties.
+// No fields leaves the output unchanged.
+func (o *ConsolidatedOutput) Project(fields []string) {
+	if len(fields) == 0 {
+		return
+	}
+	keep := make(map[string]bool, len(fields)+len(alwaysProjectedFields))
+	for _, field := range append(append([]string{}, alwaysProjectedFields...), fields...) {
+		keep[field] = true
+	}
+
+	for _, subData := range o.AzureResources {
+		subDataMap, ok := subData.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		resources, _ := subDataMap["azureResources"].([]interface{})
+		for i, resource := range resources {
+			resourceMap, ok := resource.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			projected := make(map[string]interface{}, len(keep))
+			for field, value := range resourceMap {
+				if keep[field] {
+					projected[field] = value
+				}
+			}
+			resources[i] = projected
+		}
+	}
+
+	projection := make([]string, 0, len(keep))
+	for field := range keep {
+		projection = append(projection, field)
+	}
+	sort.Strings(projection)
+	o.CollectionMetadata.ResourceProjection = projection
+}
+
+// marshalDumpFile encodes a file the collectors write next to the consolidated
+// output, indented unless --minify is set
+func marshalDumpFile(v interface{}, minify bool) ([]byte, error) {
+	if minify {
+		return json.Marshal(v)
+	}
+	return json.MarshalIndent(v, "", "  ")
+}
@@ -0,0 +1,48 @@
+package iam
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProject(t *testing.T) {
+	newOutput := func() *ConsolidatedOutput {
+		return &ConsolidatedOutput{AzureResources: map[string]interface{}{
+			"sub-1": map[string]interface{}{
+				"azureResources": []interface{}{map[string]interface{}{
+					"id": "vm-1", "type": "microsoft.compute/virtualmachines", "subscriptionId": "sub-1", "name": "vm",
+					"identity":   map[string]interface{}{"type": "SystemAssigned"},
+					"properties": map[string]interface{}{"osProfile": map[string]interface{}{"adminUsername": "azureuser"}},
+				}},
+				"subscriptionRoleAssignments": []interface{}{map[string]interface{}{"id": "ra-1", "properties": map[string]interface{}{}}},
+			},
+		}}
+	}
+
+	o := newOutput()
+	o.Project(nil)
+	assert.Contains(t, o.AzureResources["sub-1"].(map[string]interface{})["azureResources"].([]interface{})[0], "properties")
+	assert.Empty(t, o.CollectionMetadata.ResourceProjection)
+
+	o = newOutput()
+	o.Project([]string{"identity"})
+	subData := o.AzureResources["sub-1"].(map[string]interface{})
+	resource := subData["azureResources"].([]interface{})[0].(map[string]interface{})
+	assert.Len(t, resource, 4)
+	assert.NotContains(t, resource, "properties")
+	assert.Equal(t, map[string]interface{}{"type": "SystemAssigned"}, resource["identity"])
+	assert.Contains(t, subData["subscriptionRoleAssignments"].([]interface{})[0], "properties", "only resource entries are projected")
+	assert.Equal(t, []string{"id", "identity", "subscriptionId", "type"}, o.CollectionMetadata.ResourceProjection)
+}
+
+func TestMarshalDumpFile(t *testing.T) {
+	data := map[string]interface{}{"a": []interface{}{1}}
+	indented, err := marshalDumpFile(data, false)
+	require.NoError(t, err)
+	minified, err := marshalDumpFile(data, true)
+	require.NoError(t, err)
+	assert.Equal(t, `{"a":[1]}`, string(minified))
+	assert.Greater(t, len(indented), len(minified))
+}
@@ -0,0 +1,286 @@
+package iam
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/praetorian-inc/janus-framework/pkg/chain/cfg"
+)
+
+// privilegedDirectoryRoles are Entra ID roles that lead to tenant takeover or
+// broad control over identities, keyed by role template ID
+var privilegedDirectoryRoles = map[string]string{
+	"62e90394-69f5-4237-9190-012177145e10": "Global Administrator",
+	"e8611ab8-c189-46e8-94e1-60213ab1f814": "Privileged Role Administrator",
+	"7be44c8a-adaf-4e2a-84d6-ab2649e08a13": "Privileged Authentication Administrator",
+	"9b895d92-2cd3-44c7-9d02-a6ac2d5ea5c3": "Application Administrator",
+	"158c047a-c907-4556-b7ef-446551a6b5f7": "Cloud Application Administrator",
+	"fe930be7-5e62-47db-91af-98c3a49a38b1": "User Administrator",
+	"c4e39bd9-1100-46d3-8c65-fb160da0071f": "Authentication Administrator",
+	"fdd7a751-b60b-444a-984c-02652fe8fa1c": "Groups Administrator",
+	"8ac3fc64-6eca-42ea-9e69-59f4c7b60eb2": "Hybrid Identity Administrator",
+	"b1be1c3e-b65d-4f19-8427-f6fa0d97feb9": "Conditional Access Administrator",
+	"194ae4cb-b126-40b2-bd5b-6091b380977d": "Security Administrator",
+	"29232cdf-9323-42fd-ade2-1d097af3e4de": "Exchange Administrator",
+	"3a2c62db-5318-420d-8d74-23affee5d9d5": "Intune Administrator",
+}
+
+// selfJoinAttributePrefixes are user attributes that can be changed without
+// holding the privileged role a dynamic group grants, mapped to who can set them
+var selfJoinAttributePrefixes = []struct {
+	prefix     string
+	settableBy string
+}{
+	{"extension_", "owners of the application that registered the directory extension"},
+	{"extensionattribute", "Exchange or on-premises AD administrators (synced attribute)"},
+}
+
+var selfJoinAttributes = func() map[string]string {
+	const profileEditors = "User, Helpdesk or Groups Administrators, HR provisioning, or the user when self-service profile editing is allowed"
+	attributes := make(map[string]string)
+	for _, name := range []string{
+		"department", "jobtitle", "companyname", "employeeid", "employeetype",
+		"physicaldeliveryofficename", "city", "state", "country", "streetaddress",
+		"postalcode", "usagelocation", "displayname", "givenname", "surname",
+		"telephonenumber", "mobile", "facsimiletelephonenumber", "othermails",
+		"preferredlanguage", "employeehiredate", "employeeorgdata",
+	} {
+		attributes[name] = profileEditors
+	}
+	return attributes
+}()
+
+// ruleAttributePattern matches user properties referenced in a dynamic membership rule
+var ruleAttributePattern = regexp.MustCompile(`(?i)\buser\.([a-z0-9_]+)`)
+
+// editableRuleAttributes returns the user-settable attributes a membership rule keys on
+func editableRuleAttributes(rule string) []map[string]interface{} {
+	seen := make(map[string]bool)
+	attributes := []map[string]interface{}{}
+	for _, match := range ruleAttributePattern.FindAllStringSubmatch(rule, -1) {
+		name := strings.ToLower(match[1])
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+
+		settableBy, ok := selfJoinAttributes[name]
+		for i := 0; !ok && i < len(selfJoinAttributePrefixes); i++ {
+			if strings.HasPrefix(name, selfJoinAttributePrefixes[i].prefix) {
+				settableBy, ok = selfJoinAttributePrefixes[i].settableBy, true
+			}
+		}
+		if ok {
+			attributes = append(attributes, map[string]interface{}{
+				"attribute":  "user." + match[1],
+				"settableBy": settableBy,
+			})
+		}
+	}
+	return attributes
+}
+
+// buildDynamicGroupFindings flags dynamic groups whose membership rule keys on
+// user-settable attributes and that hold a privileged directory role, PIM
+// eligibility, or high-privilege Azure RBAC role. Anyone who can set the
+// attribute on an account they control joins the group and inherits the grant.
+func buildDynamicGroupFindings(o *ConsolidatedOutput) []interface{} {
+	findings := []interface{}{}
+	grants := privilegedPrincipalGrants(o)
+
+	groups, _ := o.AzureAD["groups"].([]interface{})
+	for _, group := range groups {
+		groupMap, ok := group.(map[string]interface{})
+		if !ok || !isDynamicGroup(groupMap) {
+			continue
+		}
+		groupID, _ := groupMap["id"].(string)
+		rule, _ := groupMap["membershipRule"].(string)
+		groupGrants := grants[strings.ToLower(groupID)]
+		if len(groupGrants) == 0 {
+			continue
+		}
+		attributes := editableRuleAttributes(rule)
+		if len(attributes) == 0 {
+			continue
+		}
+
+		groupName, _ := groupMap["displayName"].(string)
+		var roleNames []string
+		for _, grant := range groupGrants {
+			roleNames = appendUnique(roleNames, fmt.Sprint(grant["roleName"]))
+		}
+		attributeNames := make([]string, 0, len(attributes))
+		for _, attribute := range attributes {
+			attributeNames = append(attributeNames, fmt.Sprint(attribute["attribute"]))
+		}
+
+		findings = append(findings, map[string]interface{}{
+			"type":     "DynamicGroupSelfJoin",
+			"severity": "High",
+			"description": fmt.Sprintf("Dynamic group %s grants %s and its membership rule keys on %s, which can be set without those roles",
+				groupName, strings.Join(roleNames, ", "), strings.Join(attributeNames, ", ")),
+			"groupId":            groupID,
+			"groupName":          groupName,
+			"membershipRule":     rule,
+			"editableAttributes": attributes,
+			"privilegedGrants":   groupGrants,
+		})
+	}
+
+	sortFindings(findings, "groupName")
+	return findings
+}
+
+func isDynamicGroup(group map[string]interface{}) bool {
+	rule, _ := group["membershipRule"].(string)
+	if strings.TrimSpace(rule) == "" {
+		return false
+	}
+	switch groupTypes := group["groupTypes"].(type) {
+	case []interface{}:
+		for _, t := range groupTypes {
+			if s, ok := t.(string); ok && strings.EqualFold(s, "DynamicMembership") {
+				return true
+			}
+		}
+	case []string:
+		for _, t := range groupTypes {
+			if strings.EqualFold(t, "DynamicMembership") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// privilegedPrincipalGrants indexes privileged role grants by lowercased
+// principal ID. Role-assignable groups cannot be dynamic, so for dynamic groups
+// the directory role and PIM grants only match stale or hand-built data; Azure
+// RBAC is the common path.
+func privilegedPrincipalGrants(o *ConsolidatedOutput) map[string][]map[string]interface{} {
+	grants := make(map[string][]map[string]interface{})
+	add := func(principalID string, grant map[string]interface{}) {
+		if principalID != "" {
+			key := strings.ToLower(principalID)
+			grants[key] = append(grants[key], grant)
+		}
+	}
+
+	assignments, _ := o.AzureAD["directoryRoleAssignments"].([]interface{})
+	for _, assignment := range assignments {
+		a, ok := assignment.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		templateID, _ := a["roleTemplateId"].(string)
+		if roleName, ok := privilegedDirectoryRoles[strings.ToLower(templateID)]; ok {
+			principalID, _ := a["principalId"].(string)
+			add(principalID, map[string]interface{}{"type": "directoryRole", "roleName": roleName, "roleTemplateId": templateID, "scope": "/"})
+		}
+	}
+
+	eligible, _ := o.PIM["eligible_assignments"].([]interface{})
+	for _, assignment := range eligible {
+		a, ok := assignment.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		principalID, templateID := pimAssignmentPrincipalAndRole(a)
+		if roleName, ok := privilegedDirectoryRoles[strings.ToLower(templateID)]; ok {
+			add(principalID, map[string]interface{}{"type": "pimEligibleDirectoryRole", "roleName": roleName, "roleTemplateId": templateID, "scope": "/"})
+		}
+	}
+
+	addRBAC := func(assignments []interface{}) {
+		for _, assignment := range assignments {
+			a, ok := assignment.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			principalID, roleGUID, scope := rbacAssignmentFields(a)
+			if roleName, ok := highPrivilegeRBACRoles[roleGUID]; ok {
+				add(principalID, map[string]interface{}{"type": "azureRBAC", "roleName": roleName, "roleDefinitionId": roleGUID, "scope": scope})
+			}
+		}
+	}
+	for _, subData := range o.AzureResources {
+		subDataMap, ok := subData.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		for _, section := range []string{"subscriptionRoleAssignments", "resourceGroupRoleAssignments", "resourceLevelRoleAssignments", "managementGroupRoleAssignments", "tenantRoleAssignments"} {
+			sectionData, _ := subDataMap[section].([]interface{})
+			addRBAC(sectionData)
+		}
+	}
+	addRBAC(o.ManagementGroupRBAC)
+
+	// Classic administrators are listed by email and only count once resolved to a user
+	classicPrincipals := classicAdministratorPrincipals(o)
+	for _, subData := range o.AzureResources {
+		subDataMap, _ := subData.(map[string]interface{})
+		administrators, _ := subDataMap["classicAdministrators"].([]interface{})
+		for _, administrator := range administrators {
+			a, ok := administrator.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			email, _ := a["emailAddress"].(string)
+			add(classicPrincipals[strings.ToLower(email)], map[string]interface{}{"type": "classicAdministrator", "roleName": a["role"], "emailAddress": email, "scope": a["scope"]})
+		}
+	}
+
+	// The same grant is often reported by more than one section
+	for principalID, principalGrants := range grants {
+		seen := make(map[string]bool)
+		deduped := principalGrants[:0]
+		for _, grant := range principalGrants {
+			key := fmt.Sprint(grant["type"], grant["roleName"], strings.ToLower(fmt.Sprint(grant["scope"])))
+			if !seen[key] {
+				seen[key] = true
+				deduped = append(deduped, grant)
+			}
+		}
+		grants[principalID] = deduped
+	}
+	return grants
+}
+
+// rbacAssignmentFields reads the principal, lowercased role definition GUID and
+// scope from either an ARM role assignment (fields under "properties") or the
+// SDK collector's flattened one
+func rbacAssignmentFields(assignment map[string]interface{}) (string, string, string) {
+	if properties, ok := assignment["properties"].(map[string]interface{}); ok {
+		assignment = properties
+	}
+	principalID, _ := assignment["principalId"].(string)
+	roleDefinitionID, _ := assignment["roleDefinitionId"].(string)
+	scope, _ := assignment["scope"].(string)
+	return principalID, strings.ToLower(roleDefinitionID[strings.LastIndex(roleDefinitionID, "/")+1:]), scope
+}
+
+// pimAssignmentPrincipalAndRole reads the principal and role template ID from
+// either the SDK's flat PIM records or the legacy PIM API's nested ones
+func pimAssignmentPrincipalAndRole(assignment map[string]interface{}) (string, string) {
+	principalID, _ := assignment["principalId"].(string)
+	if principalID == "" {
+		if subject, ok := assignment["subject"].(map[string]interface{}); ok {
+			principalID, _ = subject["id"].(string)
+		}
+	}
+	templateID := ""
+	if roleDefinitionID, _ := assignment["roleDefinitionId"].(string); roleDefinitionID != "" {
+		templateID = roleDefinitionID[strings.LastIndex(roleDefinitionID, "/")+1:]
+	} else if roleDefinition, ok := assignment["roleDefinition"].(map[string]interface{}); ok {
+		templateID, _ = roleDefinition["templateId"].(string)
+	}
+	return principalID, templateID
+}
+
+// logDynamicGroupFindings reports dynamic groups that can be self-joined into privileged access
+func logDynamicGroupFindings(logger *cfg.Logger, findings []interface{}) {
+	logFindings(logger, findings, "🚨 %d privileged dynamic groups can be joined by editing user attributes", "Self-joinable dynamic group",
+		"group", "groupName", "rule", "membershipRule", "description", "description")
+}
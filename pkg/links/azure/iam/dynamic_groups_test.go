@@ -0,0 +1,64 @@
+package iam
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const dynamicGroupsFixture = `{
+  "azure_ad": {
+    "groups": [
+      {"id": "G-DEPT", "displayName": "IT Operators", "groupTypes": ["DynamicMembership"], "membershipRule": "(user.department -eq \"IT\") -and (user.accountEnabled -eq true)"},
+      {"id": "g-ext", "displayName": "Partner Admins", "groupTypes": ["DynamicMembership"], "membershipRule": "user.extension_b7d8e5fa_partnerTier -eq \"gold\""},
+      {"id": "g-objectid", "displayName": "Pinned Admins", "groupTypes": ["DynamicMembership"], "membershipRule": "user.objectId -in [\"a\", \"b\"]"},
+      {"id": "g-reader", "displayName": "Everyone in Sales", "groupTypes": ["DynamicMembership"], "membershipRule": "user.department -eq \"Sales\""},
+      {"id": "g-static", "displayName": "Static Owners", "groupTypes": [], "membershipRule": null}
+    ],
+    "directoryRoleAssignments": [
+      {"roleTemplateId": "fe930be7-5e62-47db-91af-98c3a49a38b1", "roleName": "User Administrator", "principalId": "g-ext"}
+    ]
+  },
+  "pim": {},
+  "azure_resources": {
+    "sub-1": {
+      "subscriptionRoleAssignments": [
+        {"properties": {"principalId": "g-dept", "roleDefinitionId": "/subscriptions/sub-1/providers/Microsoft.Authorization/roleDefinitions/8e3af657-a8ff-443c-a75c-2fe8c4bcb635", "scope": "/subscriptions/sub-1"}},
+        {"properties": {"principalId": "g-objectid", "roleDefinitionId": "/subscriptions/sub-1/providers/Microsoft.Authorization/roleDefinitions/8e3af657-a8ff-443c-a75c-2fe8c4bcb635", "scope": "/subscriptions/sub-1"}},
+        {"properties": {"principalId": "g-reader", "roleDefinitionId": "/subscriptions/sub-1/providers/Microsoft.Authorization/roleDefinitions/acdd72a7-3385-48ef-bd42-f606fba81ae7", "scope": "/subscriptions/sub-1"}}
+      ],
+      "resourceGroupRoleAssignments": [
+        {"principalId": "g-dept", "roleDefinitionId": "8e3af657-a8ff-443c-a75c-2fe8c4bcb635", "scope": "/subscriptions/sub-1"}
+      ]
+    }
+  },
+  "management_groups": [],
+  "management_group_rbac": []
+}`
+
+func TestBuildDynamicGroupFindings(t *testing.T) {
+	var output ConsolidatedOutput
+	require.NoError(t, json.Unmarshal([]byte(dynamicGroupsFixture), &output))
+
+	findings := buildDynamicGroupFindings(&output)
+	require.Len(t, findings, 2, "only dynamic groups keyed on editable attributes with privileged grants are reported")
+
+	dept := findings[0].(map[string]interface{})
+	assert.Equal(t, "IT Operators", dept["groupName"])
+	assert.Equal(t, []map[string]interface{}{
+		{"attribute": "user.department", "settableBy": selfJoinAttributes["department"]},
+	}, dept["editableAttributes"], "accountEnabled is not user-settable")
+	grants := dept["privilegedGrants"].([]map[string]interface{})
+	require.Len(t, grants, 1, "the same Owner grant reported twice is deduplicated")
+	assert.Equal(t, "azureRBAC", grants[0]["type"])
+	assert.Equal(t, "Owner", grants[0]["roleName"])
+	assert.Equal(t, "/subscriptions/sub-1", grants[0]["scope"])
+
+	partner := findings[1].(map[string]interface{})
+	assert.Equal(t, "Partner Admins", partner["groupName"])
+	assert.Equal(t, "user.extension_b7d8e5fa_partnerTier", partner["editableAttributes"].([]map[string]interface{})[0]["attribute"])
+	assert.Equal(t, "directoryRole", partner["privilegedGrants"].([]map[string]interface{})[0]["type"])
+	assert.Contains(t, partner["description"], "User Administrator")
+}
@@ -0,0 +1,232 @@
+package iam
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/praetorian-inc/nebula/internal/message"
+)
+
+// graphPageSize is the page size Graph returns for the collector's list calls
+const graphPageSize = 100
+
+// armListCallsPerSubscription are the ARM list calls made for every
+// subscription besides Resource Graph: role definitions, Lighthouse
+// registration definitions and assignments, classic administrators, resource
+// locks, and PIM eligibility and assignment schedule instances
+const armListCallsPerSubscription = 7
+
+// subscriptionSizeQuery counts the resources and resource types of each
+// subscription, which drive how many Resource Graph pages and shards it takes
+const subscriptionSizeQuery = `resources | summarize resources = count(), types = dcount(type) by subscriptionId`
+
+// graphObjectCounts are the directory object counts the Graph collection scales with
+type graphObjectCounts struct {
+	users             int
+	groups            int
+	servicePrincipals int
+	applications      int
+	devices           int
+	directoryRoles    int
+}
+
+// subscriptionSize is the resource count of one subscription
+type subscriptionSize struct {
+	resources     int
+	resourceTypes int
+}
+
+// estimateStep is one part of a collection: how many requests it makes and
+// the pause the collector takes after each one to stay under throttling
+type estimateStep struct {
+	name     string
+	requests int
+	pause    time.Duration
+}
+
+// collectionEstimate is the projected request volume of a collection
+type collectionEstimate struct {
+	graph         []estimateStep
+	arm           []estimateStep
+	subscriptions int
+}
+
+// ceilDiv returns how many groups of size it takes to hold n items
+func ceilDiv(n, size int) int {
+	return (n + size - 1) / size
+}
+
+// projectCollection projects the Graph and ARM requests iam-pull makes for a
+// tenant of the given size, mirroring the batch sizes and pauses of the
+// collection functions. Groups and service principals are listed again by the
+// relationship collections, so their pages are counted for each listing.
+func projectCollection(counts graphObjectCounts, subscriptions []subscriptionSize, graphPermissions bool) collectionEstimate {
+	pages := func(n int) int { return max(1, ceilDiv(n, graphPageSize)) }
+	pagePause := 100 * time.Millisecond
+
+	estimate := collectionEstimate{subscriptions: len(subscriptions)}
+	estimate.graph = []estimateStep{
+		{"users", pages(counts.users), pagePause},
+		{"groups (listed 3 times)", 3 * pages(counts.groups), pagePause},
+		{"servicePrincipals (listed 3 times)", 3 * pages(counts.servicePrincipals), pagePause},
+		{"applications (listed 2 times)", 2 * pages(counts.applications), pagePause},
+		{"devices and deviceOwnership", 3 * pages(counts.devices), pagePause},
+		{"groupMemberships batches", ceilDiv(counts.groups, 10), 200 * time.Millisecond},
+		{"groupOwnership batches", ceilDiv(counts.groups, 10), 200 * time.Millisecond},
+		{"servicePrincipalOwnership batches", ceilDiv(counts.servicePrincipals, 10), 200 * time.Millisecond},
+		{"appRoleAssignments batches", ceilDiv(counts.servicePrincipals, 10), 500 * time.Millisecond},
+		{"directoryRoleAssignments batches", ceilDiv(counts.directoryRoles, 20) + ceilDiv(counts.servicePrincipals, 20), 500 * time.Millisecond},
+		{"federatedIdentityCredentials batches", ceilDiv(counts.applications, graphBatchLimit), 0},
+	}
+	if graphPermissions {
+		estimate.graph = append(estimate.graph, estimateStep{"graph-permissions batches",
+			ceilDiv(counts.servicePrincipals, 5) + ceilDiv(counts.users, 5) + ceilDiv(counts.groups, 10), 200 * time.Millisecond})
+	}
+
+	var resourceGraph, list int
+	for _, subscription := range subscriptions {
+		// role assignments, resource groups and resource type discovery,
+		// then one query per type shard plus a query per extra page
+		resourceGraph += 3 + ceilDiv(subscription.resourceTypes, resourceTypeShardSize) + ceilDiv(subscription.resources, resourceGraphPageSize)
+		list += armListCallsPerSubscription
+	}
+	estimate.arm = []estimateStep{
+		{"Resource Graph queries", resourceGraph, 0},
+		{"ARM list calls", list, 0},
+	}
+	return estimate
+}
+
+// totalRequests sums the requests of steps
+func totalRequests(steps []estimateStep) int {
+	total := 0
+	for _, step := range steps {
+		total += step.requests
+	}
+	return total
+}
+
+// eta projects how long the collection takes when each request takes
+// latency. Graph steps run one after another, pausing after each request as
+// the collector does; subscriptions are shared among workers.
+func (e collectionEstimate) eta(latency time.Duration, workers int) time.Duration {
+	var total time.Duration
+	for _, step := range e.graph {
+		total += time.Duration(step.requests) * (latency + step.pause)
+	}
+	workers = max(1, min(workers, e.subscriptions))
+	return total + time.Duration(totalRequests(e.arm))*latency/time.Duration(workers)
+}
+
+// countGraphObjects reads the size of a Graph collection with $count, which
+// needs the eventual consistency level
+func (l *IAMComprehensiveCollectorLink) countGraphObjects(accessToken, collection string) (int, error) {
+	countURL := l.cloud.graphURL(graphV1, "/"+collection+"/$count")
+	resp, err := doGraphRequest(l.Context(), l.httpClient, l.Logger, func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(l.Context(), "GET", countURL, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Authorization", "Bearer "+accessToken)
+		req.Header.Set("ConsistencyLevel", "eventual")
+		return req, nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return 0, newAPIStatusError(resp)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, err
+	}
+	// Graph prefixes the plain text count with a byte order mark
+	count, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(string(body), "\ufeff")))
+	if err != nil {
+		return 0, fmt.Errorf("unexpected %s count %q", collection, body)
+	}
+	return count, nil
+}
+
+// estimateCollection counts the directory objects and the resources of the
+// subscriptions, then prints the requests a collection would make and how
+// long it would take at the latency of the counting requests. Nothing is
+// collected.
+func (l *IAMComprehensiveCollectorLink) estimateCollection(tokens azureTokenSource, subscriptionIDs []string, graphPermissions bool) error {
+	graphToken, err := tokens.graphToken()
+	if err != nil {
+		return fmt.Errorf("failed to get Graph token: %v", err)
+	}
+
+	var counts graphObjectCounts
+	started := time.Now()
+	countRequests := 0
+	for _, c := range []struct {
+		collection string
+		count      *int
+	}{
+		{"users", &counts.users},
+		{"groups", &counts.groups},
+		{"servicePrincipals", &counts.servicePrincipals},
+		{"applications", &counts.applications},
+		{"devices", &counts.devices},
+	} {
+		if *c.count, err = l.countGraphObjects(graphToken.AccessToken, c.collection); err != nil {
+			return fmt.Errorf("failed to count %s: %w", c.collection, err)
+		}
+		countRequests++
+	}
+	roles, err := l.collectPaginatedGraphData(graphToken.AccessToken, graphV1, "/directoryRoles?$select=id")
+	if err != nil {
+		return fmt.Errorf("failed to list directory roles: %w", err)
+	}
+	counts.directoryRoles = len(roles)
+	countRequests++
+	latency := time.Since(started) / time.Duration(countRequests)
+
+	subscriptions := make([]subscriptionSize, len(subscriptionIDs))
+	if len(subscriptionIDs) > 0 {
+		armToken, err := tokens.armToken()
+		if err != nil {
+			return fmt.Errorf("failed to get management token: %v", err)
+		}
+		rows, err := l.queryResourceGraph(armToken.AccessToken, subscriptionIDs, subscriptionSizeQuery)
+		if err != nil {
+			return fmt.Errorf("failed to count subscription resources: %w", err)
+		}
+		index := make(map[string]int, len(subscriptionIDs))
+		for i, id := range subscriptionIDs {
+			index[strings.ToLower(id)] = i
+		}
+		for _, row := range rows {
+			r, _ := row.(map[string]interface{})
+			id, _ := r["subscriptionId"].(string)
+			if i, ok := index[strings.ToLower(id)]; ok {
+				resources, _ := r["resources"].(float64)
+				types, _ := r["types"].(float64)
+				subscriptions[i] = subscriptionSize{resources: int(resources), resourceTypes: int(types)}
+			}
+		}
+	}
+
+	estimate := projectCollection(counts, subscriptions, graphPermissions)
+	message.Info("=== Azure IAM Collection Estimate ===")
+	message.Info("Tenant: %s", tokens.tenantID)
+	message.Info("Directory: %d users, %d groups, %d service principals, %d applications, %d devices, %d directory roles",
+		counts.users, counts.groups, counts.servicePrincipals, counts.applications, counts.devices, counts.directoryRoles)
+	message.Info("Subscriptions: %d", len(subscriptionIDs))
+	for _, step := range append(estimate.graph, estimate.arm...) {
+		message.Info("  %-40s %8d requests", step.name, step.requests)
+	}
+	message.Info("Projected Graph requests: %d", totalRequests(estimate.graph))
+	message.Info("Projected ARM requests: %d", totalRequests(estimate.arm))
+	message.Info("Estimated duration: %s at %s per request with %d workers, not counting throttling retries",
+		estimate.eta(latency, l.concurrency).Round(time.Second), latency.Round(time.Millisecond), l.concurrency)
+	return nil
+}
@@ -0,0 +1,74 @@
+package iam
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProjectCollection(t *testing.T) {
+	counts := graphObjectCounts{users: 250, groups: 35, servicePrincipals: 101, applications: 41, devices: 0, directoryRoles: 12}
+	subscriptions := []subscriptionSize{{resources: 2500, resourceTypes: 30}, {}}
+
+	estimate := projectCollection(counts, subscriptions, false)
+	steps := make(map[string]int)
+	for _, step := range append(estimate.graph, estimate.arm...) {
+		steps[step.name] = step.requests
+	}
+	assert.Equal(t, 3, steps["users"])
+	assert.Equal(t, 3, steps["groups (listed 3 times)"])
+	assert.Equal(t, 6, steps["servicePrincipals (listed 3 times)"])
+	assert.Equal(t, 3, steps["devices and deviceOwnership"], "an empty collection still takes a request")
+	assert.Equal(t, 4, steps["groupMemberships batches"])
+	assert.Equal(t, 11, steps["appRoleAssignments batches"])
+	assert.Equal(t, 1+6, steps["directoryRoleAssignments batches"])
+	assert.Equal(t, 3, steps["federatedIdentityCredentials batches"])
+	assert.NotContains(t, steps, "graph-permissions batches")
+	assert.Equal(t, (3+2+3)+3, steps["Resource Graph queries"])
+	assert.Equal(t, 2*armListCallsPerSubscription, steps["ARM list calls"])
+
+	withPermissions := projectCollection(counts, subscriptions, true)
+	assert.Equal(t, totalRequests(estimate.graph)+21+50+4, totalRequests(withPermissions.graph))
+}
+
+func TestCollectionEstimateETA(t *testing.T) {
+	estimate := collectionEstimate{
+		graph:         []estimateStep{{"pages", 10, 100 * time.Millisecond}, {"batches", 2, 0}},
+		arm:           []estimateStep{{"list", 40, 0}},
+		subscriptions: 4,
+	}
+	assert.Equal(t, 10*300*time.Millisecond+2*200*time.Millisecond+10*200*time.Millisecond, estimate.eta(200*time.Millisecond, 4))
+	assert.Equal(t, estimate.eta(200*time.Millisecond, 4), estimate.eta(200*time.Millisecond, 16), "there are no more workers than subscriptions")
+}
+
+func TestCountGraphObjects(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("ConsistencyLevel") != "eventual" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		switch r.URL.Path {
+		case "/v1.0/users/$count":
+			w.Write([]byte("\ufeff1234"))
+		default:
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"error":{"code":"Authorization_RequestDenied","message":"Insufficient privileges"}}`))
+		}
+	}))
+	defer server.Close()
+
+	l := NewIAMComprehensiveCollectorLink().(*IAMComprehensiveCollectorLink)
+	l.httpClient = server.Client()
+	l.cloud = azureCloud{Name: "test", Graph: server.URL}
+
+	count, err := l.countGraphObjects("token", "users")
+	require.NoError(t, err)
+	assert.Equal(t, 1234, count)
+
+	_, err = l.countGraphObjects("token", "devices")
+	assert.ErrorContains(t, err, "Authorization_RequestDenied")
+}
@@ -0,0 +1,158 @@
+package iam
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/praetorian-inc/janus-framework/pkg/chain/cfg"
+	"github.com/praetorian-inc/nebula/internal/message"
+	"github.com/praetorian-inc/nebula/pkg/rules"
+)
+
+// executiveSummaryTopItems is how many findings the summary lists by name
+const executiveSummaryTopItems = 10
+
+// executiveSummarySeverities are the severities the summary counts, most
+// severe first
+var executiveSummarySeverities = []string{"Critical", "High", "Medium", "Low"}
+
+// executiveSummary is the handful of numbers a client reads first, aggregated
+// from a finished dump
+type executiveSummary struct {
+	tenantID             string
+	collected            string
+	sampleSize           int
+	users                int
+	groups               int
+	servicePrincipals    int
+	privilegedPrincipals int
+	publicResources      int
+	findingsBySeverity   map[string]int
+	totalFindings        int
+	topFindings          []rules.Finding
+}
+
+// buildExecutiveSummary aggregates principal, privilege, exposure and finding
+// counts from a dump whose findings have already been evaluated
+func buildExecutiveSummary(o *ConsolidatedOutput, selected []rules.Rule) executiveSummary {
+	summary := executiveSummary{
+		tenantID:           o.CollectionMetadata.TenantID,
+		collected:          o.CollectionMetadata.CollectionTimestamp,
+		sampleSize:         o.CollectionMetadata.SampleSize,
+		findingsBySeverity: make(map[string]int),
+	}
+	for section, count := range map[string]*int{"users": &summary.users, "groups": &summary.groups, "servicePrincipals": &summary.servicePrincipals} {
+		objects, _ := o.AzureAD[section].([]interface{})
+		*count = len(objects)
+	}
+	summary.privilegedPrincipals = len(privilegedPrincipalGrants(o))
+	summary.publicResources = len(publicResourceIDs(o))
+
+	findings := consolidatedFindings(o, selected)
+	for _, finding := range findings {
+		summary.findingsBySeverity[fmt.Sprint(finding["severity"])]++
+	}
+	summary.totalFindings = len(findings)
+
+	sort.SliceStable(findings, func(i, j int) bool {
+		return compareFindings(findings[i], findings[j], "rule", "description") < 0
+	})
+	summary.topFindings = findings[:min(len(findings), executiveSummaryTopItems)]
+	return summary
+}
+
+// publicResourceIDs returns the lowercased IDs of resources reachable from the
+// internet: those with public network access or anonymous blob access enabled,
+// and attached NSGs that open a management port to any source
+func publicResourceIDs(o *ConsolidatedOutput) map[string]bool {
+	public := make(map[string]bool)
+	for _, subData := range o.AzureResources {
+		subDataMap, ok := subData.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		resources, _ := subDataMap["azureResources"].([]interface{})
+		for _, resource := range resources {
+			resourceMap, ok := resource.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			properties, _ := resourceMap["properties"].(map[string]interface{})
+			access, _ := properties["publicNetworkAccess"].(string)
+			blobAccess, _ := properties["allowBlobPublicAccess"].(bool)
+			if id, _ := resourceMap["id"].(string); id != "" && (strings.EqualFold(access, "Enabled") || blobAccess) {
+				public[strings.ToLower(id)] = true
+			}
+		}
+	}
+
+	exposures, _ := o.AzureAD["networkExposureFindings"].([]interface{})
+	for _, exposure := range exposures {
+		exposureMap, ok := exposure.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if attached, _ := exposureMap["attached"].(bool); attached && exposureMap["sourceExposure"] == "Internet" {
+			if nsgID, _ := exposureMap["nsgId"].(string); nsgID != "" {
+				public[strings.ToLower(nsgID)] = true
+			}
+		}
+	}
+	return public
+}
+
+// markdown renders the summary. The output depends only on the dump, so two
+// runs over the same data produce the same file.
+func (s executiveSummary) markdown() string {
+	var sb strings.Builder
+
+	sb.WriteString("# Azure IAM Executive Summary\n\n")
+	sb.WriteString(fmt.Sprintf("**Tenant:** %s  \n", s.tenantID))
+	sb.WriteString(fmt.Sprintf("**Collected:** %s\n\n", s.collected))
+	if s.sampleSize > 0 {
+		sb.WriteString(fmt.Sprintf("> **Sampled run:** each collection holds at most %d objects. These numbers are not a complete assessment.\n\n", s.sampleSize))
+	}
+
+	sb.WriteString("## At a Glance\n\n")
+	sb.WriteString("| Metric | Count |\n")
+	sb.WriteString("|--------|-------|\n")
+	sb.WriteString(fmt.Sprintf("| Principals (users, groups, service principals) | %d |\n", s.users+s.groups+s.servicePrincipals))
+	sb.WriteString(fmt.Sprintf("| Users | %d |\n", s.users))
+	sb.WriteString(fmt.Sprintf("| Groups | %d |\n", s.groups))
+	sb.WriteString(fmt.Sprintf("| Service principals | %d |\n", s.servicePrincipals))
+	sb.WriteString(fmt.Sprintf("| Admin-equivalent principals | %d |\n", s.privilegedPrincipals))
+	sb.WriteString(fmt.Sprintf("| Internet-reachable resources | %d |\n", s.publicResources))
+	sb.WriteString("\n")
+
+	sb.WriteString("## Findings by Severity\n\n")
+	sb.WriteString("| Severity | Count |\n")
+	sb.WriteString("|----------|-------|\n")
+	for _, severity := range executiveSummarySeverities {
+		sb.WriteString(fmt.Sprintf("| %s | %d |\n", severity, s.findingsBySeverity[severity]))
+	}
+	sb.WriteString(fmt.Sprintf("| **Total** | %d |\n\n", s.totalFindings))
+
+	sb.WriteString(fmt.Sprintf("## Top %d Risks\n\n", executiveSummaryTopItems))
+	if len(s.topFindings) == 0 {
+		sb.WriteString("No findings.\n")
+		return sb.String()
+	}
+	sb.WriteString("| # | Severity | Rule | Finding |\n")
+	sb.WriteString("|---|----------|------|---------|\n")
+	for i, finding := range s.topFindings {
+		description := strings.ReplaceAll(fmt.Sprint(finding["description"]), "|", "\\|")
+		sb.WriteString(fmt.Sprintf("| %d | %v | %v | %s |\n", i+1, finding["severity"], finding["rule"], description))
+	}
+	return sb.String()
+}
+
+// writeExecutiveSummary writes the markdown executive summary for --summary-out
+func writeExecutiveSummary(logger *cfg.Logger, o *ConsolidatedOutput, selected []rules.Rule, path string) {
+	if err := os.WriteFile(path, []byte(buildExecutiveSummary(o, selected).markdown()), 0644); err != nil {
+		logger.Error("Failed to write executive summary", "file", path, "error", err)
+		return
+	}
+	message.Info("Wrote executive summary to %s", path)
+}
@@ -0,0 +1,61 @@
+package iam
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/praetorian-inc/nebula/pkg/rules"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExecutiveSummary(t *testing.T) {
+	var lockFindings []interface{}
+	for i := 0; i < 12; i++ {
+		lockFindings = append(lockFindings, map[string]interface{}{"rule": "unlocked-high-value-resources", "severity": "Medium", "description": fmt.Sprintf("vault-%02d has no lock", i)})
+	}
+	output := &ConsolidatedOutput{
+		CollectionMetadata: CollectionMetadata{TenantID: "tenant-1", CollectionTimestamp: "2026-01-01T00:00:00Z"},
+		AzureAD: map[string]interface{}{
+			"users":             []interface{}{map[string]interface{}{"id": "u1"}, map[string]interface{}{"id": "u2"}},
+			"servicePrincipals": []interface{}{map[string]interface{}{"id": "sp1"}},
+			"directoryRoleAssignments": []interface{}{
+				map[string]interface{}{"principalId": "u1", "roleTemplateId": globalAdminTemplateID},
+			},
+			"resourceLockFindings": lockFindings,
+			"networkExposureFindings": []interface{}{
+				map[string]interface{}{"rule": "nsg-internet-management-ports", "severity": "High", "description": "NSG web | allows SSH", "nsgId": "/nsg/web", "attached": true, "sourceExposure": "Internet"},
+				map[string]interface{}{"rule": "nsg-internet-management-ports", "severity": "Low", "description": "NSG spare allows SSH", "nsgId": "/nsg/spare", "attached": false, "sourceExposure": "Internet"},
+			},
+		},
+		AzureResources: map[string]interface{}{
+			"sub-1": map[string]interface{}{"azureResources": []interface{}{
+				map[string]interface{}{"id": "/sa/public", "properties": map[string]interface{}{"allowBlobPublicAccess": true}},
+				map[string]interface{}{"id": "/kv/public", "properties": map[string]interface{}{"publicNetworkAccess": "Enabled"}},
+				map[string]interface{}{"id": "/kv/private", "properties": map[string]interface{}{"publicNetworkAccess": "Disabled"}},
+			}},
+		},
+	}
+	output.Normalize()
+	selected, err := rules.Select(ruleProvider, nil)
+	require.NoError(t, err)
+
+	summary := buildExecutiveSummary(output, selected)
+	assert.Equal(t, 2, summary.users)
+	assert.Equal(t, 1, summary.servicePrincipals)
+	assert.Equal(t, 1, summary.privilegedPrincipals)
+	assert.Equal(t, 3, summary.publicResources, "an NSG that is not attached to anything is not a public resource")
+	assert.Equal(t, map[string]int{"High": 1, "Medium": 12, "Low": 1}, summary.findingsBySeverity)
+	require.Len(t, summary.topFindings, executiveSummaryTopItems)
+	assert.Equal(t, "High", summary.topFindings[0]["severity"])
+	assert.Equal(t, "vault-00 has no lock", summary.topFindings[1]["description"])
+
+	markdown := summary.markdown()
+	assert.Contains(t, markdown, "| Admin-equivalent principals | 1 |")
+	assert.Contains(t, markdown, "| **Total** | 14 |")
+	assert.Contains(t, markdown, `NSG web \| allows SSH`)
+	assert.NotContains(t, markdown, "Sampled run")
+	assert.Equal(t, markdown, buildExecutiveSummary(output, selected).markdown(), "the summary is deterministic")
+	assert.Equal(t, 1, strings.Count(markdown, "| 1 | High |"))
+}
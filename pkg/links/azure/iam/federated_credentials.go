@@ -0,0 +1,122 @@
+package iam
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/praetorian-inc/janus-framework/pkg/chain/cfg"
+)
+
+// graphBatchLimit is the most requests Graph accepts in one $batch call
+const graphBatchLimit = 20
+
+// federatedIdentityCredentialsEndpoint lists the federated identity
+// credentials of one application. An application holds at most 20, so they
+// always fit one page.
+func federatedIdentityCredentialsEndpoint(applicationID string) string {
+	return fmt.Sprintf("/applications/%s/federatedIdentityCredentials?$select=id,name,issuer,subject,audiences,description", applicationID)
+}
+
+// collectFederatedIdentityCredentials collects the federated identity
+// credentials of every collected application into
+// federatedIdentityCredentials, using batch to send Graph $batch requests.
+// Each credential lets tokens issued by an external OIDC issuer, such as
+// GitHub Actions or another cloud, for the matching subject be exchanged for
+// tokens of the application. Applications without credentials add nothing.
+func collectFederatedIdentityCredentials(logger *cfg.Logger, errs *collectionErrorLog, batch func(requests []map[string]interface{}) (map[string]interface{}, error), azureADData map[string]interface{}) {
+	applications, _ := azureADData["applications"].([]interface{})
+	byRequest := make(map[string]map[string]interface{})
+	var requests []map[string]interface{}
+	for _, application := range applications {
+		appMap, ok := application.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		applicationID, _ := appMap["id"].(string)
+		if applicationID == "" {
+			continue
+		}
+		requestID := strconv.Itoa(len(requests))
+		byRequest[requestID] = appMap
+		requests = append(requests, map[string]interface{}{
+			"id":     requestID,
+			"method": "GET",
+			"url":    federatedIdentityCredentialsEndpoint(applicationID),
+		})
+	}
+
+	credentials := []interface{}{}
+	var failed error
+	for start := 0; start < len(requests); start += graphBatchLimit {
+		end := start + graphBatchLimit
+		if end > len(requests) {
+			end = len(requests)
+		}
+		result, err := batch(requests[start:end])
+		if err != nil {
+			logger.Warn("Failed to collect federated identity credentials batch", "error", err)
+			failed = err
+			continue
+		}
+		responses, _ := result["responses"].([]interface{})
+		for _, response := range responses {
+			respMap, ok := response.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			requestID, _ := respMap["id"].(string)
+			appMap, ok := byRequest[requestID]
+			if !ok {
+				continue
+			}
+			status, _ := respMap["status"].(float64)
+			switch {
+			case status == http.StatusNotFound:
+				// The application was deleted after it was listed
+				continue
+			case status < 200 || status >= 300:
+				body, _ := json.Marshal(respMap["body"])
+				failed = apiStatusErrorFromBody(int(status), body)
+				logger.Warn("Failed to collect federated identity credentials", "application", appMap["displayName"], "error", failed)
+				continue
+			}
+			body, _ := respMap["body"].(map[string]interface{})
+			value, _ := body["value"].([]interface{})
+			credentials = append(credentials, newFederatedIdentityCredentialRecords(appMap, value)...)
+		}
+	}
+	errs.record("federatedIdentityCredentials", "tenant", failed)
+
+	azureADData["federatedIdentityCredentials"] = credentials
+	logger.Info("Collected federated identity credentials", "applications", len(requests), "credentials", len(credentials))
+}
+
+// newFederatedIdentityCredentialRecords flattens the federated identity
+// credentials of one application
+func newFederatedIdentityCredentialRecords(application map[string]interface{}, credentials []interface{}) []interface{} {
+	records := []interface{}{}
+	for _, credential := range credentials {
+		credentialMap, ok := credential.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		audiences, _ := credentialMap["audiences"].([]interface{})
+		if audiences == nil {
+			audiences = []interface{}{}
+		}
+		records = append(records, map[string]interface{}{
+			"id":              credentialMap["id"],
+			"name":            credentialMap["name"],
+			"issuer":          credentialMap["issuer"],
+			"subject":         credentialMap["subject"],
+			"audiences":       audiences,
+			"description":     credentialMap["description"],
+			"applicationId":   application["id"],
+			"appId":           application["appId"],
+			"applicationName": application["displayName"],
+		})
+	}
+	return records
+}
@@ -0,0 +1,82 @@
+package iam
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/praetorian-inc/janus-framework/pkg/chain/cfg"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCollectFederatedIdentityCredentials(t *testing.T) {
+	applications := []interface{}{
+		map[string]interface{}{"id": "app-github", "appId": "client-github", "displayName": "deploy-from-github"},
+		map[string]interface{}{"id": "app-none", "appId": "client-none", "displayName": "no-federation"},
+		map[string]interface{}{"id": "app-deleted", "appId": "client-deleted", "displayName": "deleted"},
+	}
+	for i := 0; i < graphBatchLimit; i++ {
+		applications = append(applications, map[string]interface{}{"id": fmt.Sprintf("app-%d", i), "displayName": fmt.Sprintf("filler-%d", i)})
+	}
+	azureADData := map[string]interface{}{"applications": applications}
+
+	var batches [][]map[string]interface{}
+	batch := func(requests []map[string]interface{}) (map[string]interface{}, error) {
+		batches = append(batches, requests)
+		var responses []interface{}
+		for _, request := range requests {
+			url := request["url"].(string)
+			switch {
+			case strings.HasPrefix(url, "/applications/app-github/"):
+				responses = append(responses, map[string]interface{}{"id": request["id"], "status": float64(200), "body": map[string]interface{}{"value": []interface{}{
+					map[string]interface{}{"id": "fic-1", "name": "main", "issuer": "https://token.actions.githubusercontent.com", "subject": "repo:contoso/app:ref:refs/heads/main", "audiences": []interface{}{"api://AzureADTokenExchange"}},
+				}}})
+			case strings.HasPrefix(url, "/applications/app-deleted/"):
+				responses = append(responses, map[string]interface{}{"id": request["id"], "status": float64(404), "body": map[string]interface{}{"error": map[string]interface{}{"code": "Request_ResourceNotFound"}}})
+			default:
+				responses = append(responses, map[string]interface{}{"id": request["id"], "status": float64(200), "body": map[string]interface{}{"value": []interface{}{}}})
+			}
+		}
+		return map[string]interface{}{"responses": responses}, nil
+	}
+
+	var errs collectionErrorLog
+	collectFederatedIdentityCredentials(cfg.NewLogger(), &errs, batch, azureADData)
+
+	require.Len(t, batches, 2, "requests are split into batches of at most 20")
+	assert.Len(t, batches[0], graphBatchLimit)
+	assert.Empty(t, errs.list(), "applications without credentials and deleted applications are not errors")
+
+	credentials := azureADData["federatedIdentityCredentials"].([]interface{})
+	require.Len(t, credentials, 1)
+	credential := credentials[0].(map[string]interface{})
+	assert.Equal(t, "https://token.actions.githubusercontent.com", credential["issuer"])
+	assert.Equal(t, "repo:contoso/app:ref:refs/heads/main", credential["subject"])
+	assert.Equal(t, []interface{}{"api://AzureADTokenExchange"}, credential["audiences"])
+	assert.Equal(t, "app-github", credential["applicationId"])
+	assert.Equal(t, "client-github", credential["appId"])
+	assert.Equal(t, "deploy-from-github", credential["applicationName"])
+}
+
+func TestCollectFederatedIdentityCredentialsRecordsDeniedAccess(t *testing.T) {
+	azureADData := map[string]interface{}{"applications": []interface{}{map[string]interface{}{"id": "app-1"}}}
+	var errs collectionErrorLog
+	collectFederatedIdentityCredentials(cfg.NewLogger(), &errs, func(requests []map[string]interface{}) (map[string]interface{}, error) {
+		return map[string]interface{}{"responses": []interface{}{
+			map[string]interface{}{"id": requests[0]["id"], "status": float64(403), "body": map[string]interface{}{"error": map[string]interface{}{"code": "Authorization_RequestDenied", "message": "Insufficient privileges"}}},
+		}}, nil
+	}, azureADData)
+
+	require.Len(t, errs.list(), 1)
+	assert.Equal(t, "federatedIdentityCredentials", errs.list()[0].Dataset)
+	assert.True(t, errs.list()[0].PermissionDenied)
+	assert.Equal(t, "Application.Read.All", errs.list()[0].RequiredPermission)
+	assert.Equal(t, []interface{}{}, azureADData["federatedIdentityCredentials"])
+
+	collectFederatedIdentityCredentials(cfg.NewLogger(), &errs, func([]map[string]interface{}) (map[string]interface{}, error) {
+		return nil, errors.New("batch API call failed with status 400")
+	}, azureADData)
+	assert.Len(t, errs.list(), 2, "a failed batch is recorded")
+}
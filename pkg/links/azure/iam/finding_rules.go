@@ -0,0 +1,201 @@
+package iam
+
+import (
+	"github.com/praetorian-inc/janus-framework/pkg/chain/cfg"
+	"github.com/praetorian-inc/nebula/internal/message"
+	"github.com/praetorian-inc/nebula/pkg/rules"
+)
+
+// ruleProvider is the provider Azure IAM detection rules register under. Rules
+// for this provider evaluate a *ConsolidatedOutput.
+const ruleProvider = "azure"
+
+// consolidatedRule adapts one of the built-in findings builders to the rules
+// registry. Its findings keep their own azure_ad section so the dump shape does
+// not depend on which rules ran.
+type consolidatedRule struct {
+	name     string
+	severity string
+	section  string
+	build    func(o *ConsolidatedOutput) []interface{}
+	log      func(logger *cfg.Logger, findings []interface{})
+}
+
+func init() {
+	rules.Register(consolidatedRule{
+		name:     "dynamic-group-escalation",
+		severity: "High",
+		section:  "dynamicGroupFindings",
+		build:    buildDynamicGroupFindings,
+		log:      logDynamicGroupFindings,
+	})
+	rules.Register(consolidatedRule{
+		name:     "group-owner-escalation",
+		severity: "High",
+		section:  "groupOwnerFindings",
+		build:    buildGroupOwnerFindings,
+		log:      logGroupOwnerFindings,
+	})
+	rules.Register(consolidatedRule{
+		name:     "tenant-root-rbac",
+		severity: "High",
+		section:  "tenantRootRBACFindings",
+		build:    buildTenantRootRBACFindings,
+		log:      logTenantRootRBACFindings,
+	})
+	rules.Register(consolidatedRule{
+		name:     "unlocked-high-value-resources",
+		severity: "Medium",
+		section:  "resourceLockFindings",
+		build:    buildResourceLockFindings,
+		log:      logResourceLockFindings,
+	})
+	rules.Register(consolidatedRule{
+		name:     "weak-authentication-methods",
+		severity: "Medium",
+		section:  "authenticationPolicyFindings",
+		build:    buildAuthenticationPolicyFindings,
+		log:      logAuthenticationPolicyFindings,
+	})
+	rules.Register(consolidatedRule{
+		name:     "app-identity-keyvault-access",
+		severity: "High",
+		section:  "appKeyVaultFindings",
+		build:    buildAppKeyVaultFindings,
+		log:      logAppKeyVaultFindings,
+	})
+	rules.Register(consolidatedRule{
+		name:     "pim-weak-activation",
+		severity: "High",
+		section:  "pimGuardrailFindings",
+		build:    buildPIMGuardrailFindings,
+		log:      logPIMGuardrailFindings,
+	})
+	rules.Register(consolidatedRule{
+		name:     "nsg-internet-management-ports",
+		severity: "High",
+		section:  "networkExposureFindings",
+		build:    buildNSGExposureFindings,
+		log:      logNSGExposureFindings,
+	})
+	rules.Register(consolidatedRule{
+		name:     "illicit-consent-grants",
+		severity: "High",
+		section:  "consentGrantFindings",
+		build:    buildConsentGrantFindings,
+		log:      logConsentGrantFindings,
+	})
+	rules.Register(consolidatedRule{
+		name:     "privileged-arm-eligibility",
+		severity: "Medium",
+		section:  "armEligibilityFindings",
+		build:    buildARMEligibilityFindings,
+		log:      logARMEligibilityFindings,
+	})
+	rules.Register(consolidatedRule{
+		name:     "tenant-wide-attribute-management",
+		severity: "High",
+		section:  "attributeManagementFindings",
+		build:    buildAttributeManagementFindings,
+		log:      logAttributeManagementFindings,
+	})
+	rules.Register(consolidatedRule{
+		name:     "automation-privileged-access",
+		severity: "High",
+		section:  "logicAppFindings",
+		build:    buildLogicAppFindings,
+		log:      logLogicAppFindings,
+	})
+	rules.Register(consolidatedRule{
+		name:     "privileged-user-devices",
+		severity: "High",
+		section:  "deviceFindings",
+		build:    buildDeviceFindings,
+		log:      logDeviceFindings,
+	})
+	rules.Register(consolidatedRule{
+		name:     "au-scoped-password-reset",
+		severity: "High",
+		section:  "administrativeUnitFindings",
+		build:    buildAdministrativeUnitFindings,
+		log:      logAdministrativeUnitFindings,
+	})
+	rules.Register(consolidatedRule{
+		name:     "storage-replication-outside-tenant",
+		severity: "High",
+		section:  "storageReplicationFindings",
+		build:    buildObjectReplicationFindings,
+		log:      logObjectReplicationFindings,
+	})
+}
+
+func (r consolidatedRule) Name() string     { return r.name }
+func (r consolidatedRule) Provider() string { return ruleProvider }
+func (r consolidatedRule) Severity() string { return r.severity }
+
+func (r consolidatedRule) Evaluate(dataset interface{}) []rules.Finding {
+	o, ok := dataset.(*ConsolidatedOutput)
+	if !ok {
+		return nil
+	}
+	var findings []rules.Finding
+	for _, finding := range r.build(o) {
+		if findingMap, ok := finding.(map[string]interface{}); ok {
+			findings = append(findings, rules.Finding(findingMap))
+		}
+	}
+	return findings
+}
+
+// evaluateFindingRules runs the selected rules over a normalized dump. Built-in
+// rules fill their own sections; findings from any other registered rule are
+// collected in ruleFindings. Every finding is tagged with the rule that
+// produced it and its fingerprint.
+func evaluateFindingRules(o *ConsolidatedOutput, selected []rules.Rule) {
+	ruleFindings := []interface{}{}
+	for _, rule := range selected {
+		if builtin, ok := rule.(consolidatedRule); ok {
+			findings := builtin.build(o)
+			for _, finding := range findings {
+				if findingMap, ok := finding.(map[string]interface{}); ok {
+					findingMap["rule"] = builtin.name
+					findingMap["fingerprint"] = rules.Fingerprint(findingMap)
+				}
+			}
+			o.AzureAD[builtin.section] = findings
+			continue
+		}
+		for _, finding := range rule.Evaluate(o) {
+			finding["rule"] = rule.Name()
+			if _, ok := finding["severity"]; !ok {
+				finding["severity"] = rule.Severity()
+			}
+			finding["fingerprint"] = rules.Fingerprint(finding)
+			ruleFindings = append(ruleFindings, map[string]interface{}(finding))
+		}
+	}
+	o.AzureAD["ruleFindings"] = ruleFindings
+}
+
+// logFindingRules reports the findings of the selected rules
+func logFindingRules(logger *cfg.Logger, o *ConsolidatedOutput, selected []rules.Rule) {
+	for _, rule := range selected {
+		if builtin, ok := rule.(consolidatedRule); ok {
+			findings, _ := o.AzureAD[builtin.section].([]interface{})
+			builtin.log(logger, findings)
+		}
+	}
+	argFindings, _ := o.AzureAD["argRuleFindings"].([]interface{})
+	logARGRuleFindings(logger, argFindings)
+
+	ruleFindings, _ := o.AzureAD["ruleFindings"].([]interface{})
+	if len(ruleFindings) == 0 {
+		return
+	}
+	message.Info("🚨 %d findings from additional detection rules", len(ruleFindings))
+	for _, finding := range ruleFindings {
+		if findingMap, ok := finding.(map[string]interface{}); ok {
+			logger.Warn("Detection rule finding", "rule", findingMap["rule"], "severity", findingMap["severity"], "description", findingMap["description"])
+		}
+	}
+}
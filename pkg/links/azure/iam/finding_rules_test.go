@@ -0,0 +1,83 @@
+package iam
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/praetorian-inc/nebula/pkg/rules"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type guestCountRule struct{}
+
+func (guestCountRule) Name() string     { return "guest-count" }
+func (guestCountRule) Provider() string { return ruleProvider }
+func (guestCountRule) Severity() string { return "Low" }
+func (guestCountRule) Evaluate(dataset interface{}) []rules.Finding {
+	users, _ := dataset.(*ConsolidatedOutput).AzureAD["users"].([]interface{})
+	return []rules.Finding{{"type": "GuestCount", "description": "users in tenant", "count": len(users)}}
+}
+
+func init() {
+	rules.Register(guestCountRule{})
+}
+
+func TestEvaluateFindingRules(t *testing.T) {
+	load := func() *ConsolidatedOutput {
+		var output ConsolidatedOutput
+		require.NoError(t, json.Unmarshal([]byte(groupOwnersFixture), &output))
+		output.Normalize()
+		return &output
+	}
+
+	selected, err := rules.Select(ruleProvider, []string{"group-owner-escalation", "guest-count"})
+	require.NoError(t, err)
+	output := load()
+	evaluateFindingRules(output, selected)
+
+	assert.Len(t, output.AzureAD["groupOwnerFindings"], 2)
+	assert.Empty(t, output.AzureAD["dynamicGroupFindings"], "unselected built-in rules leave their section empty")
+	ruleFindings := output.AzureAD["ruleFindings"].([]interface{})
+	require.Len(t, ruleFindings, 1)
+	assert.Equal(t, "guest-count", ruleFindings[0].(map[string]interface{})["rule"])
+	assert.Equal(t, "Low", ruleFindings[0].(map[string]interface{})["severity"])
+
+	selected, err = rules.Select(ruleProvider, []string{"severity:high"})
+	require.NoError(t, err)
+	output = load()
+	evaluateFindingRules(output, selected)
+	assert.NotEmpty(t, output.AzureAD["dynamicGroupFindings"])
+	assert.Empty(t, output.AzureAD["ruleFindings"])
+}
+
+func TestCompareFindingsBaseline(t *testing.T) {
+	var output ConsolidatedOutput
+	require.NoError(t, json.Unmarshal([]byte(groupOwnersFixture), &output))
+	output.Normalize()
+	selected, err := rules.Select(ruleProvider, []string{"group-owner-escalation"})
+	require.NoError(t, err)
+	evaluateFindingRules(&output, selected)
+
+	owners := output.AzureAD["groupOwnerFindings"].([]interface{})
+	require.Len(t, owners, 2)
+	first := owners[0].(map[string]interface{})
+	assert.Equal(t, "group-owner-escalation", first["rule"])
+	assert.NotEmpty(t, first["fingerprint"])
+
+	baseline := &rules.Baseline{Findings: []rules.BaselineEntry{
+		{Fingerprint: first["fingerprint"].(string), Rule: "group-owner-escalation"},
+		{Fingerprint: "gone", Rule: "group-owner-escalation", Type: "GroupOwnerEscalation"},
+		{Fingerprint: "not-run", Rule: "tenant-root-rbac"},
+	}}
+	compareFindingsBaseline(&output, selected, baseline, "baseline.json")
+
+	assert.Equal(t, rules.BaselineExisting, first["baselineStatus"])
+	assert.Equal(t, rules.BaselineNew, owners[1].(map[string]interface{})["baselineStatus"])
+	comparison := output.BaselineComparison
+	require.NotNil(t, comparison)
+	assert.Equal(t, 1, comparison.New)
+	assert.Equal(t, 1, comparison.Existing)
+	require.Len(t, comparison.Resolved, 1, "entries of rules that did not run are not resolved")
+	assert.Equal(t, "gone", comparison.Resolved[0].Fingerprint)
+}
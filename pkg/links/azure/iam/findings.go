@@ -0,0 +1,71 @@
+package iam
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/praetorian-inc/janus-framework/pkg/chain/cfg"
+	"github.com/praetorian-inc/nebula/internal/message"
+)
+
+// findingSeverityRank orders finding severities, highest first when sorted descending
+func findingSeverityRank(severity interface{}) int {
+	switch severity {
+	case "Critical":
+		return 4
+	case "High":
+		return 3
+	case "Medium":
+		return 2
+	case "Low":
+		return 1
+	}
+	return 0
+}
+
+// compareFindings orders two findings by severity, highest first, then by
+// the given fields compared case-insensitively. Findings without a severity
+// are ordered by the fields alone.
+func compareFindings(a, b map[string]interface{}, fields ...string) int {
+	if ra, rb := findingSeverityRank(a["severity"]), findingSeverityRank(b["severity"]); ra != rb {
+		return rb - ra
+	}
+	for _, field := range fields {
+		if c := strings.Compare(strings.ToLower(fmt.Sprint(a[field])), strings.ToLower(fmt.Sprint(b[field]))); c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+// sortFindings orders detector findings with compareFindings so every
+// detector reports the same way
+func sortFindings(findings []interface{}, fields ...string) {
+	sort.SliceStable(findings, func(i, j int) bool {
+		a, _ := findings[i].(map[string]interface{})
+		b, _ := findings[j].(map[string]interface{})
+		return compareFindings(a, b, fields...) < 0
+	})
+}
+
+// logFindings prints summary, a format taking the number of findings, and
+// logs each finding as a warning titled title. fields pairs the key each
+// value is logged under with the finding field it comes from.
+func logFindings(logger *cfg.Logger, findings []interface{}, summary, title string, fields ...string) {
+	if len(findings) == 0 {
+		return
+	}
+	message.Info(summary, len(findings))
+	for _, finding := range findings {
+		findingMap, ok := finding.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		attrs := make([]any, 0, len(fields))
+		for i := 0; i+1 < len(fields); i += 2 {
+			attrs = append(attrs, fields[i], findingMap[fields[i+1]])
+		}
+		logger.Warn(title, attrs...)
+	}
+}
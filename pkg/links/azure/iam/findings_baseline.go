@@ -0,0 +1,97 @@
+package iam
+
+import (
+	"github.com/praetorian-inc/janus-framework/pkg/chain/cfg"
+	"github.com/praetorian-inc/nebula/internal/message"
+	"github.com/praetorian-inc/nebula/pkg/rules"
+)
+
+// BaselineComparison records how the findings of a --compare-baseline run
+// differ from the accepted findings in the baseline file. Each finding is also
+// tagged with its baselineStatus.
+type BaselineComparison struct {
+	BaselineFile string                `json:"baseline_file"`
+	New          int                   `json:"new"`
+	Existing     int                   `json:"existing"`
+	Resolved     []rules.BaselineEntry `json:"resolved"`
+}
+
+// consolidatedFindings returns every finding the selected rules and the
+// --arg-rules detections produced. The findings share their maps with the
+// dump, so changes to them are written out.
+func consolidatedFindings(o *ConsolidatedOutput, selected []rules.Rule) []rules.Finding {
+	var findings []rules.Finding
+	sections := []string{"ruleFindings", "argRuleFindings"}
+	for _, rule := range selected {
+		if builtin, ok := rule.(consolidatedRule); ok {
+			sections = append(sections, builtin.section)
+		}
+	}
+	for _, section := range sections {
+		entries, _ := o.AzureAD[section].([]interface{})
+		for _, entry := range entries {
+			if findingMap, ok := entry.(map[string]interface{}); ok {
+				findings = append(findings, rules.Finding(findingMap))
+			}
+		}
+	}
+	return findings
+}
+
+// compareFindingsBaseline classifies the findings against a baseline. Baseline
+// entries of rules that did not run this time are not reported as resolved.
+func compareFindingsBaseline(o *ConsolidatedOutput, selected []rules.Rule, baseline *rules.Baseline, baselineFile string) {
+	comparison := baseline.Compare(consolidatedFindings(o, selected))
+	for _, finding := range comparison.New {
+		finding["baselineStatus"] = rules.BaselineNew
+	}
+	for _, finding := range comparison.Existing {
+		finding["baselineStatus"] = rules.BaselineExisting
+	}
+
+	ran := make(map[string]bool, len(selected))
+	for _, rule := range selected {
+		ran[rule.Name()] = true
+	}
+	resolved := []rules.BaselineEntry{}
+	for _, entry := range comparison.Resolved {
+		if entry.Rule == "" || ran[entry.Rule] {
+			resolved = append(resolved, entry)
+		}
+	}
+
+	o.BaselineComparison = &BaselineComparison{
+		BaselineFile: baselineFile,
+		New:          len(comparison.New),
+		Existing:     len(comparison.Existing),
+		Resolved:     resolved,
+	}
+}
+
+// logBaselineComparison reports only what changed since the baseline
+func logBaselineComparison(logger *cfg.Logger, o *ConsolidatedOutput, selected []rules.Rule) {
+	comparison := o.BaselineComparison
+	if comparison == nil {
+		return
+	}
+	message.Info("Compared findings with baseline %s: %d new, %d existing, %d resolved",
+		comparison.BaselineFile, comparison.New, comparison.Existing, len(comparison.Resolved))
+	for _, finding := range consolidatedFindings(o, selected) {
+		if finding["baselineStatus"] == rules.BaselineNew {
+			logger.Warn("New finding", "rule", finding["rule"], "type", finding["type"], "severity", finding["severity"], "description", finding["description"])
+		}
+	}
+	for _, entry := range comparison.Resolved {
+		logger.Info("Resolved finding", "rule", entry.Rule, "type", entry.Type, "description", entry.Description)
+	}
+}
+
+// writeFindingsBaseline records the current findings as a baseline file
+func writeFindingsBaseline(logger *cfg.Logger, o *ConsolidatedOutput, selected []rules.Rule, path string) {
+	baseline := rules.NewBaseline(consolidatedFindings(o, selected))
+	if err := rules.WriteBaseline(path, baseline); err != nil {
+		logger.Error("Failed to write findings baseline", "file", path, "error", err)
+		return
+	}
+	message.Info("Wrote %d findings to baseline %s", len(baseline.Findings), path)
+}
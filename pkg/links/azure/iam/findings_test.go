@@ -0,0 +1,27 @@
+package iam
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSortFindings(t *testing.T) {
+	findings := []interface{}{
+		map[string]interface{}{"severity": "Medium", "scope": "/b", "principalName": "zed"},
+		map[string]interface{}{"severity": "High", "scope": "/b", "principalName": "bob"},
+		map[string]interface{}{"severity": "Medium", "scope": "/a", "principalName": "Yan"},
+		map[string]interface{}{"severity": "Medium", "scope": "/a", "principalName": "amy"},
+		map[string]interface{}{"scope": "/a", "principalName": "ann"},
+		map[string]interface{}{"severity": "Critical", "scope": "/c", "principalName": "cal"},
+	}
+
+	sortFindings(findings, "scope", "principalName")
+
+	var order []string
+	for _, finding := range findings {
+		order = append(order, finding.(map[string]interface{})["principalName"].(string))
+	}
+	assert.Equal(t, []string{"cal", "bob", "amy", "Yan", "zed", "ann"}, order,
+		"severity first, then each field case-insensitively, findings without a severity last")
+}
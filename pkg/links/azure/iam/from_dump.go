@@ -0,0 +1,85 @@
+package iam
+
+import (
+	"github.com/praetorian-inc/janus-framework/pkg/chain/cfg"
+	"github.com/praetorian-inc/nebula/internal/message"
+	"github.com/praetorian-inc/nebula/pkg/rules"
+)
+
+// dumpReanalysis holds the detection options a --from-dump run applies to a
+// saved dump instead of a fresh collection
+type dumpReanalysis struct {
+	selected      []rules.Rule
+	baseline      *rules.Baseline
+	baselineFile  string
+	writeBaseline string
+	summaryOut    string
+	project       []string
+}
+
+// clearDumpFindings drops the findings and baseline comparison a dump was saved
+// with, so only the rules selected now contribute and a rule that was updated
+// since the dump was written does not leave its old findings behind
+func clearDumpFindings(o *ConsolidatedOutput) {
+	for _, rule := range rules.GetRules(ruleProvider) {
+		if builtin, ok := rule.(consolidatedRule); ok {
+			delete(o.AzureAD, builtin.section)
+		}
+	}
+	delete(o.AzureAD, "ruleFindings")
+	o.BaselineComparison = nil
+}
+
+// reanalyzeDump loads a consolidated dump from an earlier iam-pull or
+// iam-pull-sdk run and runs the detections over it again without contacting
+// Azure. The result has the same shape as a fresh collection.
+func reanalyzeDump(logger *cfg.Logger, path string, analysis dumpReanalysis) (*ConsolidatedOutput, error) {
+	o, err := loadConsolidatedDump(path)
+	if err != nil {
+		return nil, err
+	}
+	message.Info("Re-running detections over dump %s (tenant %s, collected %s)", path, o.CollectionMetadata.TenantID, o.CollectionMetadata.CollectionTimestamp)
+	if len(o.CollectionMetadata.ResourceProjection) > 0 {
+		logger.Warn("Dump was written with --project, detections that read resource properties may miss findings", "fields", o.CollectionMetadata.ResourceProjection)
+	}
+	if len(o.CollectionMetadata.GraphObjects) > 0 {
+		logger.Warn("Dump was written with --graph-objects, detections that read other Azure AD data may miss findings", "graph_objects", o.CollectionMetadata.GraphObjects)
+	}
+	if len(o.CollectionMetadata.NotCollected) > 0 {
+		logger.Warn("Dump has sections that were not collected, detections over them report nothing", "not_collected", o.CollectionMetadata.NotCollected)
+	}
+
+	clearDumpFindings(o)
+	evaluateFindingRules(o, analysis.selected)
+	if analysis.baseline != nil {
+		compareFindingsBaseline(o, analysis.selected, analysis.baseline, analysis.baselineFile)
+		logBaselineComparison(logger, o, analysis.selected)
+	} else {
+		logFindingRules(logger, o, analysis.selected)
+	}
+	if analysis.writeBaseline != "" {
+		writeFindingsBaseline(logger, o, analysis.selected, analysis.writeBaseline)
+	}
+	if analysis.summaryOut != "" {
+		writeExecutiveSummary(logger, o, analysis.selected, analysis.summaryOut)
+	}
+	o.Project(analysis.project)
+	return o, nil
+}
+
+// AnalyzeDumpFindings runs the detections selected by ruleSelectors (the
+// --rules syntax) over a consolidated dump and returns the tenant and its
+// findings, for reports that combine Azure with other providers
+func AnalyzeDumpFindings(path string, ruleSelectors []string) (string, []rules.Finding, error) {
+	selected, err := rules.Select(ruleProvider, ruleSelectors)
+	if err != nil {
+		return "", nil, err
+	}
+	o, err := loadConsolidatedDump(path)
+	if err != nil {
+		return "", nil, err
+	}
+	clearDumpFindings(o)
+	evaluateFindingRules(o, selected)
+	return o.CollectionMetadata.TenantID, consolidatedFindings(o, selected), nil
+}
@@ -0,0 +1,51 @@
+package iam
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/praetorian-inc/janus-framework/pkg/chain/cfg"
+	"github.com/praetorian-inc/nebula/pkg/rules"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReanalyzeDump(t *testing.T) {
+	var saved ConsolidatedOutput
+	require.NoError(t, json.Unmarshal([]byte(groupOwnersFixture), &saved))
+	saved.Normalize()
+	stale := []interface{}{map[string]interface{}{"rule": "old", "description": "written by an older rule"}}
+	saved.AzureAD["groupOwnerFindings"] = stale
+	saved.AzureAD["dynamicGroupFindings"] = stale
+	saved.BaselineComparison = &BaselineComparison{BaselineFile: "old.json"}
+
+	dir := t.TempDir()
+	dumpPath := filepath.Join(dir, "dump.json")
+	raw, err := json.Marshal([]interface{}{saved})
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(dumpPath, raw, 0644))
+
+	selected, err := rules.Select(ruleProvider, []string{"group-owner-escalation"})
+	require.NoError(t, err)
+	baselinePath := filepath.Join(dir, "baseline.json")
+	output, err := reanalyzeDump(cfg.NewLogger(), dumpPath, dumpReanalysis{selected: selected, writeBaseline: baselinePath})
+	require.NoError(t, err)
+
+	owners := output.AzureAD["groupOwnerFindings"].([]interface{})
+	require.Len(t, owners, 2, "findings are rebuilt from the collected data")
+	assert.Equal(t, "group-owner-escalation", owners[0].(map[string]interface{})["rule"])
+	assert.NotContains(t, output.AzureAD, "dynamicGroupFindings", "findings of rules that did not run are dropped")
+	assert.Nil(t, output.BaselineComparison)
+	assert.Len(t, output.AzureAD["groups"], 3, "collected data is kept")
+
+	baseline, err := rules.LoadBaseline(baselinePath)
+	require.NoError(t, err)
+	assert.Len(t, baseline.Findings, 2)
+}
+
+func TestReanalyzeDumpMissingFile(t *testing.T) {
+	_, err := reanalyzeDump(cfg.NewLogger(), filepath.Join(t.TempDir(), "missing.json"), dumpReanalysis{})
+	assert.Error(t, err)
+}
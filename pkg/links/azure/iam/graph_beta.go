@@ -0,0 +1,127 @@
+package iam
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/praetorian-inc/janus-framework/pkg/chain/cfg"
+	"github.com/praetorian-inc/nebula/internal/message"
+)
+
+// Graph API versions. Collections use v1.0; the datasets in betaCollections
+// are only served by beta and are collected when opted in with --use-beta.
+const (
+	graphV1   = "v1.0"
+	graphBeta = "beta"
+)
+
+// betaCollection is a dataset only the Graph beta endpoint serves. Beta
+// responses can change without notice, so each one must be opted into.
+type betaCollection struct {
+	name     string // --use-beta value
+	dataset  string // section it fills, also the dataset of its collection errors
+	pim      bool   // the section is under pim rather than azure_ad
+	endpoint string
+}
+
+var betaCollections = []betaCollection{
+	{
+		name:     "role-management-policies",
+		dataset:  "role_management_policies",
+		pim:      true,
+		endpoint: "/policies/roleManagementPolicies?$filter=scopeId%20eq%20'/'%20and%20scopeType%20eq%20'DirectoryRole'&$expand=rules",
+	},
+	{
+		name:     "sign-in-activity",
+		dataset:  "signInActivity",
+		endpoint: "/users?$select=id,signInActivity",
+	},
+	{
+		name:     "user-registration-details",
+		dataset:  "userRegistrationDetails",
+		endpoint: "/reports/authenticationMethods/userRegistrationDetails",
+	},
+}
+
+// selectBetaCollections resolves --use-beta values, collection names or "all"
+func selectBetaCollections(values []string) ([]betaCollection, error) {
+	wanted := make(map[string]bool)
+	for _, value := range values {
+		value = strings.ToLower(strings.TrimSpace(value))
+		if value == "" {
+			continue
+		}
+		if value == "all" {
+			return betaCollections, nil
+		}
+		wanted[value] = true
+	}
+
+	var selected []betaCollection
+	for _, collection := range betaCollections {
+		if wanted[collection.name] {
+			selected = append(selected, collection)
+			delete(wanted, collection.name)
+		}
+	}
+	for name := range wanted {
+		var known []string
+		for _, collection := range betaCollections {
+			known = append(known, collection.name)
+		}
+		return nil, fmt.Errorf("unknown --use-beta collection %q (available: all, %s)", name, strings.Join(known, ", "))
+	}
+	return selected, nil
+}
+
+// collectBetaCollections collects the opted-in beta datasets with fetch, which
+// pages through a Graph endpoint on the given version. Sign-in activity is
+// merged into the users it belongs to; the other datasets fill their section.
+func collectBetaCollections(logger *cfg.Logger, errs *collectionErrorLog, selected []betaCollection, fetch func(version, endpoint string) ([]interface{}, error), azureADData, pimData map[string]interface{}) {
+	for _, collection := range selected {
+		message.Info("Collecting %s from the Graph beta endpoint...", collection.name)
+		data, err := fetch(graphBeta, collection.endpoint)
+		if err != nil {
+			logger.Warn("Failed to collect beta dataset, continuing without it", "collection", collection.name, "error", err)
+			errs.record(collection.dataset, "tenant", err)
+			continue
+		}
+		logger.Info("Collected beta dataset", "collection", collection.name, "count", len(data))
+
+		switch {
+		case collection.dataset == "signInActivity":
+			mergeSignInActivity(azureADData, data)
+		case collection.pim:
+			pimData[collection.dataset] = data
+		default:
+			azureADData[collection.dataset] = data
+		}
+	}
+}
+
+// mergeSignInActivity copies the signInActivity of each beta user onto the
+// collected user with the same ID
+func mergeSignInActivity(azureADData map[string]interface{}, activity []interface{}) {
+	byID := make(map[string]interface{}, len(activity))
+	for _, entry := range activity {
+		entryMap, ok := entry.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if id, _ := entryMap["id"].(string); id != "" && entryMap["signInActivity"] != nil {
+			byID[strings.ToLower(id)] = entryMap["signInActivity"]
+		}
+	}
+
+	users, _ := azureADData["users"].([]interface{})
+	for _, user := range users {
+		userMap, ok := user.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		id, _ := userMap["id"].(string)
+		if signInActivity, ok := byID[strings.ToLower(id)]; ok {
+			userMap["signInActivity"] = signInActivity
+		}
+	}
+}
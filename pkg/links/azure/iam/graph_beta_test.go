@@ -0,0 +1,63 @@
+package iam
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/praetorian-inc/janus-framework/pkg/chain/cfg"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSelectBetaCollections(t *testing.T) {
+	selected, err := selectBetaCollections(nil)
+	require.NoError(t, err)
+	assert.Empty(t, selected, "beta datasets are opt-in")
+
+	selected, err = selectBetaCollections([]string{"all"})
+	require.NoError(t, err)
+	assert.Len(t, selected, len(betaCollections))
+
+	selected, err = selectBetaCollections([]string{"Sign-In-Activity"})
+	require.NoError(t, err)
+	require.Len(t, selected, 1)
+	assert.Equal(t, "signInActivity", selected[0].dataset)
+
+	_, err = selectBetaCollections([]string{"sign-ins"})
+	assert.ErrorContains(t, err, `unknown --use-beta collection "sign-ins"`)
+}
+
+func TestCollectBetaCollections(t *testing.T) {
+	azureADData := map[string]interface{}{
+		"users": []interface{}{
+			map[string]interface{}{"id": "user-1"},
+			map[string]interface{}{"id": "user-2"},
+		},
+	}
+	pimData := map[string]interface{}{}
+	var requested []string
+	fetch := func(version, endpoint string) ([]interface{}, error) {
+		requested = append(requested, azureClouds[azureCloudPublic].graphURL(version, endpoint))
+		switch endpoint {
+		case "/users?$select=id,signInActivity":
+			return []interface{}{map[string]interface{}{"id": "USER-1", "signInActivity": map[string]interface{}{"lastSignInDateTime": "2024-05-01T00:00:00Z"}}}, nil
+		case "/reports/authenticationMethods/userRegistrationDetails":
+			return nil, errors.New("forbidden")
+		}
+		return []interface{}{map[string]interface{}{"id": "policy-1"}}, nil
+	}
+
+	var errs collectionErrorLog
+	collectBetaCollections(cfg.NewLogger(), &errs, betaCollections, fetch, azureADData, pimData)
+
+	for _, url := range requested {
+		assert.Contains(t, url, "https://graph.microsoft.com/beta/")
+	}
+	users := azureADData["users"].([]interface{})
+	assert.Equal(t, "2024-05-01T00:00:00Z", users[0].(map[string]interface{})["signInActivity"].(map[string]interface{})["lastSignInDateTime"])
+	assert.NotContains(t, users[1].(map[string]interface{}), "signInActivity")
+	assert.Len(t, pimData["role_management_policies"], 1)
+	assert.NotContains(t, azureADData, "userRegistrationDetails")
+	require.Len(t, errs.list(), 1)
+	assert.Equal(t, "userRegistrationDetails", errs.list()[0].Dataset)
+}
@@ -0,0 +1,114 @@
+package iam
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// graphObjectTypes are the --graph-objects values, the azure_ad sections the
+// Graph collection fills. customSecurityAttributes also fills
+// attributeRoleAssignments, and administrativeUnits fills the members, scoped
+// role members and role assignments of the units.
+var graphObjectTypes = []string{
+	"users",
+	"groups",
+	"servicePrincipals",
+	"applications",
+	"devices",
+	"directoryRoles",
+	"roleDefinitions",
+	"conditionalAccessPolicies",
+	"tokenLifetimePolicies",
+	"authenticationMethodConfigurations",
+	"groupMemberships",
+	"groupOwnership",
+	"servicePrincipalOwnership",
+	"directoryRoleAssignments",
+	"oauth2PermissionGrants",
+	"appRoleAssignments",
+	"applicationOwnership",
+	"customSecurityAttributes",
+	"deviceOwnership",
+	"administrativeUnits",
+	"federatedIdentityCredentials",
+}
+
+// graphObjectPrerequisites are the object types another type is built from,
+// which are collected with it even when they were not asked for
+var graphObjectPrerequisites = map[string][]string{
+	// service principals are looked up through memberOf, since role members
+	// do not list them
+	"directoryRoleAssignments": {"servicePrincipals"},
+	// unit-scoped role assignments are resolved against the directory roles
+	"administrativeUnits":          {"directoryRoles"},
+	"federatedIdentityCredentials": {"applications"},
+}
+
+// graphPermissionPrerequisites are the object types --graph-permissions
+// resolves permission grants from
+var graphPermissionPrerequisites = []string{"servicePrincipals", "users", "groups", "oauth2PermissionGrants"}
+
+// graphObjectSelection is the set of Graph object types to collect. A nil
+// selection collects every type.
+type graphObjectSelection map[string]bool
+
+// wants reports whether objectType is collected
+func (s graphObjectSelection) wants(objectType string) bool {
+	return s == nil || s[objectType]
+}
+
+// names lists the selected object types in sorted order, or nothing when
+// every type is collected
+func (s graphObjectSelection) names() []string {
+	if s == nil {
+		return nil
+	}
+	names := make([]string, 0, len(s))
+	for name := range s {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// selectGraphObjects resolves --graph-objects values, object types or "all",
+// and adds the prerequisites of the selected types and of --graph-permissions.
+// No values collects every type.
+func selectGraphObjects(values []string, graphPermissions bool) (graphObjectSelection, error) {
+	known := make(map[string]string, len(graphObjectTypes))
+	for _, objectType := range graphObjectTypes {
+		known[strings.ToLower(objectType)] = objectType
+	}
+
+	selected := graphObjectSelection{}
+	for _, value := range values {
+		value = strings.ToLower(strings.TrimSpace(value))
+		if value == "" {
+			continue
+		}
+		if value == "all" {
+			return nil, nil
+		}
+		objectType, ok := known[value]
+		if !ok {
+			return nil, fmt.Errorf("unknown --graph-objects type %q (available: all, %s)", value, strings.Join(graphObjectTypes, ", "))
+		}
+		selected[objectType] = true
+	}
+	if len(selected) == 0 {
+		return nil, nil
+	}
+
+	for objectType := range selected {
+		for _, prerequisite := range graphObjectPrerequisites[objectType] {
+			selected[prerequisite] = true
+		}
+	}
+	if graphPermissions {
+		for _, prerequisite := range graphPermissionPrerequisites {
+			selected[prerequisite] = true
+		}
+	}
+	return selected, nil
+}
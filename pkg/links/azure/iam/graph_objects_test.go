@@ -0,0 +1,45 @@
+package iam
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSelectGraphObjects(t *testing.T) {
+	selected, err := selectGraphObjects(nil, false)
+	require.NoError(t, err)
+	assert.Nil(t, selected, "every type is collected by default")
+	assert.True(t, selected.wants("users"))
+
+	selected, err = selectGraphObjects([]string{"servicePrincipals", "all"}, false)
+	require.NoError(t, err)
+	assert.Nil(t, selected)
+
+	selected, err = selectGraphObjects([]string{"ServicePrincipals", " appRoleAssignments", "oauth2PermissionGrants"}, false)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"appRoleAssignments", "oauth2PermissionGrants", "servicePrincipals"}, selected.names())
+	assert.False(t, selected.wants("users"))
+
+	selected, err = selectGraphObjects([]string{"directoryRoleAssignments", "federatedIdentityCredentials"}, false)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"applications", "directoryRoleAssignments", "federatedIdentityCredentials", "servicePrincipals"}, selected.names(), "prerequisites are collected too")
+
+	selected, err = selectGraphObjects([]string{"devices"}, true)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"devices", "groups", "oauth2PermissionGrants", "servicePrincipals", "users"}, selected.names(), "--graph-permissions brings the types it resolves grants from")
+
+	_, err = selectGraphObjects([]string{"mailboxes"}, false)
+	assert.ErrorContains(t, err, `unknown --graph-objects type "mailboxes"`)
+}
+
+func TestCollectAllGraphDataSkipsUnselectedTypes(t *testing.T) {
+	l := NewIAMComprehensiveCollectorLink().(*IAMComprehensiveCollectorLink)
+	l.graphObjects = graphObjectSelection{}
+
+	azureADData, err := l.collectAllGraphData("token")
+	require.NoError(t, err)
+	assert.Empty(t, azureADData, "no Graph request is made for unselected types")
+	assert.Empty(t, l.collectionErrors.list())
+}
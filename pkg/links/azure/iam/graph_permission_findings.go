@@ -0,0 +1,153 @@
+package iam
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// defaultDangerousGraphPermissions are the Graph permissions reported in
+// graphPermissionFindings, with the risk each one carries.
+// --dangerous-graph-permissions-file adds to or rewords this list.
+var defaultDangerousGraphPermissions = map[string]string{
+	"Directory.ReadWrite.All":                      "Full directory read/write access",
+	"Directory.Read.All":                           "Full directory read access",
+	"Directory.AccessAsUser.All":                   "Access directory as signed-in user",
+	"User.ReadWrite.All":                           "Read/write all user profiles",
+	"User.Read.All":                                "Read all user profiles",
+	"User.Export.All":                              "Export user data",
+	"Application.ReadWrite.All":                    "Manage all applications",
+	"Application.Read.All":                         "Read all applications",
+	"RoleManagement.ReadWrite.Directory":           "Manage directory roles",
+	"RoleManagement.Read.Directory":                "Read directory roles",
+	"DeviceManagementConfiguration.ReadWrite.All":  "Manage device configuration",
+	"DeviceManagementManagedDevices.ReadWrite.All": "Manage all devices",
+	"Policy.ReadWrite.All":                         "Manage all policies",
+	"Policy.Read.All":                              "Read all policies",
+	"Policy.ReadWrite.ConditionalAccess":           "Manage conditional access policies",
+	"PrivilegedAccess.ReadWrite.AzureAD":           "Manage privileged access",
+	"Sites.FullControl.All":                        "Full control of all sites",
+	"Files.ReadWrite.All":                          "Read/write all files",
+	"Mail.ReadWrite":                               "Read/write mail",
+	"Calendars.ReadWrite":                          "Read/write calendars",
+	"MailboxSettings.ReadWrite":                    "Manage mailbox settings",
+	"Group.ReadWrite.All":                          "Manage all groups",
+	"GroupMember.ReadWrite.All":                    "Manage group membership",
+	"AppRoleAssignment.ReadWrite.All":              "Grant any app role, including itself any Graph application permission",
+	"Application.ReadWrite.OwnedBy":                "Add credentials to and take over the applications it owns",
+	"RoleManagement.ReadWrite.Exchange":            "Manage Exchange Online role assignments",
+	"Synchronization.ReadWrite.All":                "Manage provisioning jobs and the credentials they hold",
+}
+
+// loadDangerousGraphPermissions returns the built-in dangerous permissions
+// merged with a --dangerous-graph-permissions-file, a JSON object mapping a
+// permission such as "Sites.Selected" to the risk it carries. File entries
+// add permissions or replace the risk of built-in ones. An empty path returns
+// the built-in list.
+func loadDangerousGraphPermissions(path string) (map[string]string, error) {
+	dangerous := make(map[string]string, len(defaultDangerousGraphPermissions))
+	for permission, description := range defaultDangerousGraphPermissions {
+		dangerous[permission] = description
+	}
+	if path == "" {
+		return dangerous, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read dangerous-graph-permissions-file: %w", err)
+	}
+	var entries map[string]string
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("invalid dangerous-graph-permissions-file %s: %w", path, err)
+	}
+	for permission, description := range entries {
+		permission = strings.TrimSpace(permission)
+		if permission == "" || strings.TrimSpace(description) == "" {
+			return nil, fmt.Errorf("invalid dangerous-graph-permissions-file %s: every entry needs a permission and a risk description", path)
+		}
+		dangerous[permission] = description
+	}
+	return dangerous, nil
+}
+
+// buildGraphPermissionFindings reports each grant of a dangerous permission
+// as a DangerousGraphPermission finding and counts every grant by type and
+// consent type. Read-only permissions are Medium and the rest High. Grants to
+// service principals in --suppress-sp-file are left out, or kept as
+// Informational for entries with the informational action.
+func buildGraphPermissionFindings(permissions []CompleteGraphPermission, dangerous map[string]string, suppressions spSuppressionList) ([]interface{}, map[string]interface{}) {
+	// Count each grant once even if the caller passes unmerged permissions
+	permissions = mergeGraphPermissions(permissions)
+
+	findings := []interface{}{}
+	typeStats := make(map[string]int)
+	consentStats := make(map[string]int)
+	suppressed := 0
+	for _, permission := range permissions {
+		typeStats[permission.Type]++
+		consentStats[permission.ConsentType]++
+
+		description, isDangerous := dangerous[permission.Permission]
+		if !isDangerous {
+			continue
+		}
+		principalID, principalName, principalType := graphPermissionPrincipal(permission)
+		finding := map[string]interface{}{
+			"type":            "DangerousGraphPermission",
+			"severity":        graphPermissionSeverity(permission.Permission),
+			"principalId":     principalID,
+			"principalName":   principalName,
+			"principalType":   principalType,
+			"permission":      permission.Permission,
+			"permissionType":  permission.PermissionType,
+			"grantType":       permission.Type,
+			"consentType":     permission.ConsentType,
+			"resourceAppName": permission.ResourceAppName,
+			"grantId":         permission.ID,
+			"risk":            description,
+			"description":     fmt.Sprintf("%s holds %s (%s): %s", principalName, permission.Permission, permission.PermissionType, description),
+		}
+		if suppression, ok := suppressions.match(permission); ok {
+			if suppression.Action != suppressionActionInformational {
+				suppressed++
+				continue
+			}
+			finding["severity"] = "Informational"
+			finding["suppressionReason"] = suppression.Reason
+		}
+		findings = append(findings, finding)
+	}
+
+	sortFindings(findings, "description")
+	statistics := map[string]interface{}{
+		"totalPermissions":   len(permissions),
+		"byType":             typeStats,
+		"byConsentType":      consentStats,
+		"suppressedFindings": suppressed,
+	}
+	return findings, statistics
+}
+
+// graphPermissionPrincipal names the principal a grant is reported against:
+// the service principal when there is one, otherwise the user or group
+func graphPermissionPrincipal(permission CompleteGraphPermission) (string, string, string) {
+	switch {
+	case permission.ServicePrincipalID != "" || permission.ServicePrincipalName != "":
+		return permission.ServicePrincipalID, permission.ServicePrincipalName, "ServicePrincipal"
+	case permission.UserID != "" || permission.UserName != "":
+		return permission.UserID, permission.UserName, "User"
+	default:
+		return permission.GroupID, permission.GroupName, "Group"
+	}
+}
+
+// graphPermissionSeverity rates read-only permissions Medium and any other
+// dangerous permission High
+func graphPermissionSeverity(permission string) string {
+	if strings.Contains(permission, ".Read.") || strings.HasSuffix(permission, ".Read") {
+		return "Medium"
+	}
+	return "High"
+}
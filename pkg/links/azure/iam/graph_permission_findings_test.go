@@ -0,0 +1,93 @@
+package iam
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildGraphPermissionFindings(t *testing.T) {
+	permissions := []CompleteGraphPermission{
+		{ID: "g-1", Type: "ServicePrincipalApplication", ServicePrincipalID: "sp-1", ServicePrincipalName: "deploy-app", Permission: "Directory.Read.All", PermissionType: "Application", ConsentType: "Admin", Source: "Global"},
+		{ID: "g-1", Type: "ServicePrincipalApplication", ServicePrincipalID: "sp-1", ServicePrincipalName: "deploy-app", Permission: "Directory.Read.All", PermissionType: "Application", ConsentType: "Admin", Source: "ServicePrincipal"},
+		{ID: "g-2", Type: "ServicePrincipalApplication", ServicePrincipalID: "sp-1", ServicePrincipalName: "deploy-app", Permission: "RoleManagement.ReadWrite.Directory", PermissionType: "Application", ConsentType: "Admin"},
+		{ID: "g-3", Type: "GroupApplication", GroupID: "grp-1", GroupName: "helpdesk", Permission: "User.Read.All", PermissionType: "Application", ConsentType: "Admin"},
+		{ID: "g-4", Type: "ServicePrincipalApplication", ServicePrincipalID: "sp-backup", ServicePrincipalName: "backup", Permission: "Files.ReadWrite.All", PermissionType: "Application", ConsentType: "Admin"},
+		{ID: "g-5", Type: "ServicePrincipalApplication", ServicePrincipalID: "sp-graph", ServicePrincipalName: "first-party", Permission: "Directory.ReadWrite.All", PermissionType: "Application", ConsentType: "Admin"},
+		{ID: "g-6", Type: "UserDelegated", UserID: "u-1", UserName: "alice", Permission: "openid", PermissionType: "Delegated", ConsentType: "User"},
+	}
+	suppressions := spSuppressionList{
+		"sp-backup": {ID: "sp-backup", Reason: "Approved backup tool", Action: suppressionActionInformational},
+		"sp-graph":  {ID: "sp-graph", Reason: "First-party app", Action: suppressionActionSuppress},
+	}
+
+	findings, statistics := buildGraphPermissionFindings(permissions, defaultDangerousGraphPermissions, suppressions)
+	require.Len(t, findings, 4, "a grant seen in several sources is reported once and suppressed grants are left out")
+
+	high := findings[0].(map[string]interface{})
+	assert.Equal(t, "High", high["severity"])
+	assert.Equal(t, "RoleManagement.ReadWrite.Directory", high["permission"])
+	assert.Equal(t, "sp-1", high["principalId"])
+	assert.Equal(t, "deploy-app", high["principalName"])
+	assert.Equal(t, "ServicePrincipal", high["principalType"])
+	assert.Equal(t, "Manage directory roles", high["risk"])
+
+	medium := findings[1].(map[string]interface{})
+	assert.Equal(t, "Medium", medium["severity"], "read-only permissions are Medium")
+	assert.Equal(t, "Directory.Read.All", medium["permission"])
+	assert.Equal(t, "Group", findings[2].(map[string]interface{})["principalType"])
+
+	informational := findings[3].(map[string]interface{})
+	assert.Equal(t, "Informational", informational["severity"])
+	assert.Equal(t, "Approved backup tool", informational["suppressionReason"])
+
+	assert.Equal(t, 6, statistics["totalPermissions"])
+	assert.Equal(t, map[string]int{"ServicePrincipalApplication": 4, "GroupApplication": 1, "UserDelegated": 1}, statistics["byType"])
+	assert.Equal(t, map[string]int{"Admin": 5, "User": 1}, statistics["byConsentType"])
+	assert.Equal(t, 1, statistics["suppressedFindings"])
+}
+
+func TestLoadDangerousGraphPermissions(t *testing.T) {
+	defaults, err := loadDangerousGraphPermissions("")
+	require.NoError(t, err)
+	assert.Equal(t, defaultDangerousGraphPermissions, defaults)
+
+	path := filepath.Join(t.TempDir(), "dangerous.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"Sites.Selected": "Access to selected sites", "User.Read.All": "Enumerate every user"}`), 0600))
+	dangerous, err := loadDangerousGraphPermissions(path)
+	require.NoError(t, err)
+	assert.Equal(t, "Access to selected sites", dangerous["Sites.Selected"])
+	assert.Equal(t, "Enumerate every user", dangerous["User.Read.All"])
+	assert.Equal(t, "Read all user profiles", defaultDangerousGraphPermissions["User.Read.All"], "the built-in list is not modified")
+	assert.Equal(t, "Manage directory roles", dangerous["RoleManagement.ReadWrite.Directory"])
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"Sites.Selected": ""}`), 0600))
+	_, err = loadDangerousGraphPermissions(path)
+	assert.Error(t, err, "an entry needs a risk description")
+}
+
+func TestBuildGraphPermissionFindingsFlagsAppCredentialAbuseScopes(t *testing.T) {
+	var permissions []CompleteGraphPermission
+	for i, permission := range []string{"AppRoleAssignment.ReadWrite.All", "Application.ReadWrite.OwnedBy", "RoleManagement.ReadWrite.Exchange", "Synchronization.ReadWrite.All", "User.ReadBasic.All"} {
+		permissions = append(permissions, CompleteGraphPermission{
+			ID: fmt.Sprintf("g-%d", i), Type: "ServicePrincipalApplication", ServicePrincipalID: "sp-1", ServicePrincipalName: "automation",
+			Permission: permission, PermissionType: "Application", ConsentType: "Admin",
+		})
+	}
+
+	findings, _ := buildGraphPermissionFindings(permissions, defaultDangerousGraphPermissions, nil)
+	require.Len(t, findings, 4, "User.ReadBasic.All is not dangerous")
+	flagged := map[string]string{}
+	for _, f := range findings {
+		finding := f.(map[string]interface{})
+		flagged[finding["permission"].(string)] = finding["severity"].(string)
+	}
+	assert.Equal(t, "High", flagged["AppRoleAssignment.ReadWrite.All"], "an app that can grant itself any app role is flagged")
+	assert.Equal(t, "High", flagged["Application.ReadWrite.OwnedBy"])
+	assert.Equal(t, "High", flagged["RoleManagement.ReadWrite.Exchange"])
+	assert.Equal(t, "High", flagged["Synchronization.ReadWrite.All"])
+}
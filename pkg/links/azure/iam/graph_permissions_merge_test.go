@@ -0,0 +1,43 @@
+package iam
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMergeGraphPermissionsCollapsesGlobalAndPerPrincipal(t *testing.T) {
+	global := CompleteGraphPermission{
+		ID:                   "grant-1",
+		Type:                 "ServicePrincipalDelegated",
+		ServicePrincipalID:   "sp-1",
+		ServicePrincipalName: "Backend API",
+		Permission:           "Directory.ReadWrite.All",
+		Source:               "Global",
+	}
+	perSP := global
+	perSP.Source = "ServicePrincipal"
+	perSP.GrantedFor = "user-1"
+	otherScope := global
+	otherScope.Permission = "User.Read"
+
+	merged := mergeGraphPermissions([]CompleteGraphPermission{global, perSP, otherScope})
+
+	require.Len(t, merged, 2, "the same grant and permission should appear once")
+	assert.Equal(t, "Directory.ReadWrite.All", merged[0].Permission)
+	assert.Equal(t, []string{"Global", "ServicePrincipal"}, merged[0].Sources)
+	assert.Equal(t, "Global", merged[0].Source)
+	assert.Equal(t, "user-1", merged[0].GrantedFor, "fields missing from the first observation should be filled in")
+	assert.Equal(t, []string{"Global"}, merged[1].Sources)
+
+	assert.Len(t, mergeGraphPermissions(merged), 2, "merging should be idempotent")
+}
+
+func TestMergeGraphPermissionsKeepsGrantsWithoutID(t *testing.T) {
+	permission := CompleteGraphPermission{Permission: "User.Read", Source: "User"}
+
+	merged := mergeGraphPermissions([]CompleteGraphPermission{permission, permission})
+
+	assert.Len(t, merged, 2, "permissions without a grant ID cannot be matched and are kept")
+}
@@ -0,0 +1,58 @@
+package iam
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/praetorian-inc/janus-framework/pkg/chain/cfg"
+)
+
+const (
+	// graphMaxAttempts bounds the requests made for one Graph page or batch
+	graphMaxAttempts = 5
+	// graphRetryMaxDelay caps the backoff and any Retry-After Graph asks for
+	graphRetryMaxDelay = 2 * time.Minute
+)
+
+// graphRetryBaseDelay is the backoff before the first retry of a Graph
+// request, doubled for each further attempt unless the response carries
+// Retry-After
+var graphRetryBaseDelay = time.Second
+
+// doGraphRequest sends the request built by newRequest, retrying 429 and 5xx
+// responses with capped exponential backoff or the Retry-After Graph asked
+// for. Network errors are retried by the client's transport. The last
+// response is returned whatever its status; the caller closes its body.
+func doGraphRequest(ctx context.Context, client *http.Client, logger *cfg.Logger, newRequest func() (*http.Request, error)) (*http.Response, error) {
+	for attempt := 1; ; attempt++ {
+		req, err := newRequest()
+		if err != nil {
+			return nil, err
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		if (resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode < 500) || attempt >= graphMaxAttempts {
+			return resp, nil
+		}
+		resp.Body.Close()
+
+		retryAfter := graphRetryBaseDelay << (attempt - 1)
+		if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds > 0 {
+			retryAfter = time.Duration(seconds) * time.Second
+		}
+		if retryAfter > graphRetryMaxDelay {
+			retryAfter = graphRetryMaxDelay
+		}
+		logger.Debug("Graph request throttled, retrying", "status", resp.StatusCode, "attempt", attempt, "retry_after", retryAfter)
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(retryAfter):
+		}
+	}
+}
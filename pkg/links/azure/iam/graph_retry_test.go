@@ -0,0 +1,54 @@
+package iam
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCollectPaginatedGraphDataRetriesThrottling(t *testing.T) {
+	defer func(delay time.Duration) { graphRetryBaseDelay = delay }(graphRetryBaseDelay)
+	graphRetryBaseDelay = time.Millisecond
+
+	var usersRequests, groupsRequests int
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1.0/users":
+			usersRequests++
+			switch {
+			case usersRequests == 1:
+				w.Header().Set("Retry-After", "0")
+				w.WriteHeader(http.StatusTooManyRequests)
+			case usersRequests == 2:
+				w.WriteHeader(http.StatusServiceUnavailable)
+			case r.URL.Query().Get("page") == "":
+				json.NewEncoder(w).Encode(map[string]interface{}{"value": []interface{}{map[string]interface{}{"id": "u-1"}}, "@odata.nextLink": server.URL + "/v1.0/users?page=2"})
+			default:
+				json.NewEncoder(w).Encode(map[string]interface{}{"value": []interface{}{map[string]interface{}{"id": "u-2"}}})
+			}
+		case "/v1.0/groups":
+			groupsRequests++
+			w.WriteHeader(http.StatusTooManyRequests)
+		}
+	}))
+	defer server.Close()
+
+	l := NewIAMComprehensiveCollectorLink().(*IAMComprehensiveCollectorLink)
+	l.httpClient = server.Client()
+	l.cloud = azureCloud{Name: "test", Graph: server.URL}
+
+	users, err := l.collectPaginatedGraphData("token", graphV1, "/users")
+	require.NoError(t, err, "a throttled or failing page is retried instead of aborting the collection")
+	assert.Len(t, users, 2)
+	assert.Equal(t, 4, usersRequests)
+
+	_, err = l.collectPaginatedGraphData("token", graphV1, "/groups")
+	assert.Error(t, err)
+	assert.Equal(t, graphMaxAttempts, groupsRequests, "retries are bounded")
+}
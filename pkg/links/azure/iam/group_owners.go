@@ -0,0 +1,81 @@
+package iam
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/praetorian-inc/janus-framework/pkg/chain/cfg"
+)
+
+// buildGroupOwnerFindings flags owners of groups that hold a privileged
+// directory role, PIM eligibility, or high-privilege Azure RBAC role. Owners
+// manage membership, so they can add themselves (or an account they control)
+// and inherit the grant. Owners of dynamic groups cannot add members directly
+// but can rewrite the membership rule to the same effect.
+func buildGroupOwnerFindings(o *ConsolidatedOutput) []interface{} {
+	findings := []interface{}{}
+	grants := privilegedPrincipalGrants(o)
+
+	dynamicGroups := make(map[string]bool)
+	groups, _ := o.AzureAD["groups"].([]interface{})
+	for _, group := range groups {
+		if groupMap, ok := group.(map[string]interface{}); ok && isDynamicGroup(groupMap) {
+			groupID, _ := groupMap["id"].(string)
+			dynamicGroups[strings.ToLower(groupID)] = true
+		}
+	}
+
+	seen := make(map[string]bool)
+	ownerships, _ := o.AzureAD["groupOwnership"].([]interface{})
+	for _, ownership := range ownerships {
+		ownershipMap, ok := ownership.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		groupID, _ := ownershipMap["groupId"].(string)
+		ownerID, _ := ownershipMap["ownerId"].(string)
+		groupGrants := grants[strings.ToLower(groupID)]
+		key := strings.ToLower(ownerID + "|" + groupID)
+		if ownerID == "" || len(groupGrants) == 0 || seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		capability := "AddMembers"
+		if dynamicGroups[strings.ToLower(groupID)] {
+			capability = "EditMembershipRule"
+		}
+		groupName, _ := ownershipMap["groupName"].(string)
+		ownerName, _ := ownershipMap["ownerName"].(string)
+		if ownerName == "" {
+			ownerName = ownerID
+		}
+		var roleNames []string
+		for _, grant := range groupGrants {
+			roleNames = appendUnique(roleNames, fmt.Sprint(grant["roleName"]))
+		}
+
+		findings = append(findings, map[string]interface{}{
+			"type":     "GroupOwnerEscalation",
+			"severity": "High",
+			"description": fmt.Sprintf("%s owns group %s, which grants %s; as an owner they can add themselves and inherit it",
+				ownerName, groupName, strings.Join(roleNames, ", ")),
+			"ownerId":          ownerID,
+			"ownerName":        ownerName,
+			"ownerType":        ownershipMap["ownerType"],
+			"groupId":          groupID,
+			"groupName":        groupName,
+			"capability":       capability,
+			"privilegedGrants": groupGrants,
+		})
+	}
+
+	sortFindings(findings, "groupName", "ownerName")
+	return findings
+}
+
+// logGroupOwnerFindings reports group owners who can escalate through the group they own
+func logGroupOwnerFindings(logger *cfg.Logger, findings []interface{}) {
+	logFindings(logger, findings, "🚨 %d owners of privileged groups can add themselves to the group", "Privileged group owner",
+		"owner", "ownerName", "group", "groupName", "description", "description")
+}
@@ -0,0 +1,55 @@
+package iam
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const groupOwnersFixture = `{
+  "azure_ad": {
+    "groups": [
+      {"id": "g-admins", "displayName": "Tenant Admins", "groupTypes": []},
+      {"id": "g-dyn", "displayName": "Ops Dynamic", "groupTypes": ["DynamicMembership"], "membershipRule": "user.department -eq \"Ops\""},
+      {"id": "g-plain", "displayName": "Book Club", "groupTypes": []}
+    ],
+    "groupOwnership": [
+      {"groupId": "g-admins", "groupName": "Tenant Admins", "ownerId": "u-alice", "ownerName": "Alice", "ownerType": "#microsoft.graph.user"},
+      {"groupId": "g-admins", "groupName": "Tenant Admins", "ownerId": "u-alice", "ownerName": "Alice", "ownerType": "#microsoft.graph.user"},
+      {"groupId": "g-dyn", "groupName": "Ops Dynamic", "ownerId": "sp-bot", "ownerName": "Ops Bot", "ownerType": "#microsoft.graph.servicePrincipal"},
+      {"groupId": "g-plain", "groupName": "Book Club", "ownerId": "u-bob", "ownerName": "Bob", "ownerType": "#microsoft.graph.user"}
+    ],
+    "directoryRoleAssignments": [
+      {"roleTemplateId": "62e90394-69f5-4237-9190-012177145e10", "principalId": "g-admins"}
+    ]
+  },
+  "pim": {},
+  "azure_resources": {
+    "sub-1": {
+      "subscriptionRoleAssignments": [
+        {"properties": {"principalId": "g-dyn", "roleDefinitionId": "/providers/Microsoft.Authorization/roleDefinitions/18d7d88d-d35e-4fb5-a5c3-7773c20a72d9", "scope": "/subscriptions/sub-1"}}
+      ]
+    }
+  }
+}`
+
+func TestBuildGroupOwnerFindings(t *testing.T) {
+	var output ConsolidatedOutput
+	require.NoError(t, json.Unmarshal([]byte(groupOwnersFixture), &output))
+
+	findings := buildGroupOwnerFindings(&output)
+	require.Len(t, findings, 2, "owners of unprivileged groups are not reported and duplicate ownership rows collapse")
+
+	dynamic := findings[0].(map[string]interface{})
+	assert.Equal(t, "Ops Bot", dynamic["ownerName"])
+	assert.Equal(t, "EditMembershipRule", dynamic["capability"])
+	assert.Equal(t, "User Access Administrator", dynamic["privilegedGrants"].([]map[string]interface{})[0]["roleName"])
+
+	admins := findings[1].(map[string]interface{})
+	assert.Equal(t, "GroupOwnerEscalation", admins["type"])
+	assert.Equal(t, "u-alice", admins["ownerId"])
+	assert.Equal(t, "AddMembers", admins["capability"])
+	assert.Contains(t, admins["description"], "Global Administrator")
+}
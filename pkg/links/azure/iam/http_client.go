@@ -0,0 +1,58 @@
+package iam
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/praetorian-inc/janus-framework/pkg/chain/cfg"
+	"github.com/praetorian-inc/nebula/internal/message"
+)
+
+// newCollectorHTTPClient builds an HTTP client that sends requests through
+// proxyURL, if set, and only skips TLS verification when insecure is set.
+// Transient network errors are retried inside the client, so one DNS or
+// connection blip does not abort a collection phase.
+func newCollectorHTTPClient(proxyURL string, insecure bool, timeout time.Duration) (*http.Client, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if proxyURL != "" {
+		proxyParsedURL, err := url.Parse(proxyURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy URL: %v", err)
+		}
+		transport.Proxy = http.ProxyURL(proxyParsedURL)
+	}
+	if insecure {
+		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	}
+	// Subscriptions are collected in parallel against the same few hosts
+	transport.MaxIdleConnsPerHost = 16
+	return &http.Client{Transport: newNetworkRetryTransport(transport), Timeout: timeout}, nil
+}
+
+// sharedHTTPClient returns the link's HTTP client, building it from the proxy,
+// insecure, http-timeout and dump-raw-responses arguments on first use. Every
+// Graph, PIM, ARM and Resource Graph request shares it so connections are reused.
+func (l *IAMComprehensiveCollectorLink) sharedHTTPClient() (*http.Client, error) {
+	l.httpClientOnce.Do(func() {
+		proxyURL, _ := cfg.As[string](l.Arg("proxy"))
+		insecure, _ := cfg.As[bool](l.Arg("insecure"))
+		timeout, err := cfg.As[int](l.Arg("http-timeout"))
+		if err != nil || timeout <= 0 {
+			timeout = 60
+		}
+		l.httpClient, l.httpClientErr = newCollectorHTTPClient(proxyURL, insecure, time.Duration(timeout)*time.Second)
+		if rawDir, _ := cfg.As[string](l.Arg("dump-raw-responses")); rawDir != "" && l.httpClientErr == nil {
+			recorder, err := newRawResponseRecorder(l.httpClient.Transport, rawDir, l.Logger)
+			if err != nil {
+				l.httpClient, l.httpClientErr = nil, err
+				return
+			}
+			message.Warning("Writing raw API responses to %s, they contain tenant data", rawDir)
+			l.httpClient.Transport = recorder
+		}
+	})
+	return l.httpClient, l.httpClientErr
+}
@@ -0,0 +1,29 @@
+package iam
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewCollectorHTTPClient(t *testing.T) {
+	client, err := newCollectorHTTPClient("http://127.0.0.1:8080", false, 45*time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, 45*time.Second, client.Timeout)
+
+	transport := client.Transport.(*networkRetryTransport).base.(*http.Transport)
+	proxy, err := transport.Proxy(&http.Request{})
+	require.NoError(t, err)
+	assert.Equal(t, "127.0.0.1:8080", proxy.Host)
+	assert.True(t, transport.TLSClientConfig == nil || !transport.TLSClientConfig.InsecureSkipVerify, "a proxy alone keeps TLS verification on")
+
+	insecure, err := newCollectorHTTPClient("", true, time.Minute)
+	require.NoError(t, err)
+	assert.True(t, insecure.Transport.(*networkRetryTransport).base.(*http.Transport).TLSClientConfig.InsecureSkipVerify)
+
+	_, err = newCollectorHTTPClient("://bad", false, time.Minute)
+	assert.Error(t, err)
+}
@@ -0,0 +1,162 @@
+package iam
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/praetorian-inc/janus-framework/pkg/chain/cfg"
+	"github.com/praetorian-inc/nebula/internal/message"
+)
+
+// activityLogRetention is how far back the Azure activity log reaches. A
+// watermark older than this cannot show that a subscription is unchanged.
+const activityLogRetention = 90 * 24 * time.Hour
+
+// activityLogDataset is the collection_errors dataset of failed activity log
+// queries. Its scope is the subscription ID.
+const activityLogDataset = "activity_log"
+
+// IncrementalCollection records a --prior-dump run, in which subscriptions
+// without ARM changes since the watermark were carried forward from the prior
+// dump instead of being collected again
+type IncrementalCollection struct {
+	ChangedSince                string   `json:"changed_since"`
+	PriorCollectionTimestamp    string   `json:"prior_collection_timestamp"`
+	CollectedSubscriptions      []string `json:"collected_subscriptions"`
+	CarriedForwardSubscriptions []string `json:"carried_forward_subscriptions"`
+}
+
+// incrementalCollection holds the prior dump and watermark of a --prior-dump run
+type incrementalCollection struct {
+	prior *ConsolidatedOutput
+	since time.Time
+}
+
+// newIncrementalCollection loads --prior-dump. The watermark is --changed-since
+// when set and otherwise the prior dump's collection timestamp. It returns nil
+// when no prior dump was given, so every subscription is collected.
+func newIncrementalCollection(priorDump, changedSince string) (*incrementalCollection, error) {
+	if priorDump == "" {
+		if changedSince != "" {
+			return nil, fmt.Errorf("changed-since requires prior-dump")
+		}
+		return nil, nil
+	}
+
+	prior, err := loadConsolidatedDump(priorDump)
+	if err != nil {
+		return nil, fmt.Errorf("invalid prior-dump: %v", err)
+	}
+	if prior.CollectionMetadata.Sampled {
+		return nil, fmt.Errorf("prior-dump %s is a --sample run and cannot be carried forward", priorDump)
+	}
+
+	watermark := changedSince
+	if watermark == "" {
+		watermark = prior.CollectionMetadata.CollectionTimestamp
+	}
+	since, err := parseLogTime(watermark)
+	if err != nil {
+		return nil, fmt.Errorf("invalid changed-since: %v", err)
+	}
+	return &incrementalCollection{prior: prior, since: since}, nil
+}
+
+// checkTenant refuses to carry data forward from a dump of another tenant
+func (c *incrementalCollection) checkTenant(tenantID string) error {
+	priorTenant := c.prior.CollectionMetadata.TenantID
+	if priorTenant != "" && !strings.EqualFold(priorTenant, tenantID) {
+		return fmt.Errorf("prior-dump is from tenant %s, not %s", priorTenant, tenantID)
+	}
+	return nil
+}
+
+// activityLogURL is the ARM path listing the activity log events of a
+// subscription in a window.
+// The activity log only accepts eventTimestamp with one other field in its
+// filter, so the events are classified client side.
+func activityLogURL(subscriptionID string, since, until time.Time) string {
+	filter := fmt.Sprintf("eventTimestamp ge '%s' and eventTimestamp le '%s'", since.Format(time.RFC3339), until.Format(time.RFC3339))
+	return fmt.Sprintf("/subscriptions/%s/providers/Microsoft.Insights/eventtypes/management/values?api-version=2015-04-01&$filter=%s&$select=operationName,status,resourceId",
+		subscriptionID, url.QueryEscape(filter))
+}
+
+// isARMChangeEvent reports whether an activity log event is a successful write
+// or delete, which covers role assignments, role definitions and every other
+// resource the collectors read. Actions such as listKeys and policy audits do
+// not change collected data.
+func isARMChangeEvent(event map[string]interface{}) bool {
+	status, _ := event["status"].(map[string]interface{})
+	if value, _ := status["value"].(string); !strings.EqualFold(value, "Succeeded") {
+		return false
+	}
+	operation, _ := event["operationName"].(map[string]interface{})
+	name, _ := operation["value"].(string)
+	name = strings.ToLower(name)
+	return strings.HasSuffix(name, "/write") || strings.HasSuffix(name, "/delete")
+}
+
+// partition splits subscriptionIDs into those to collect and those to carry
+// forward. A subscription is collected again when it is not in the prior dump,
+// when the watermark is beyond the activity log retention, when its activity
+// log cannot be read, or when the log shows a change since the watermark.
+func (c *incrementalCollection) partition(logger *cfg.Logger, errs *collectionErrorLog, subscriptionIDs []string, now time.Time, fetch func(url string) ([]interface{}, error)) (collect, carry []string) {
+	if now.Sub(c.since) > activityLogRetention {
+		message.Warning("changed-since %s is older than the 90 day activity log retention, collecting every subscription", c.since.Format(time.RFC3339))
+		return subscriptionIDs, nil
+	}
+
+	for _, subscriptionID := range subscriptionIDs {
+		if _, ok := c.prior.AzureResources[subscriptionID]; !ok {
+			logger.Info("Subscription is not in the prior dump, collecting it", "subscription", subscriptionID)
+			collect = append(collect, subscriptionID)
+			continue
+		}
+
+		events, err := fetch(activityLogURL(subscriptionID, c.since, now))
+		if err != nil {
+			logger.Warn("Failed to read activity log, collecting subscription", "subscription", subscriptionID, "error", err)
+			errs.record(activityLogDataset, subscriptionID, err)
+			collect = append(collect, subscriptionID)
+			continue
+		}
+
+		changes := 0
+		for _, event := range events {
+			if eventMap, ok := event.(map[string]interface{}); ok && isARMChangeEvent(eventMap) {
+				changes++
+			}
+		}
+		logger.Info("Checked activity log for changes", "subscription", subscriptionID, "since", c.since.Format(time.RFC3339), "changes", changes)
+		if changes > 0 {
+			collect = append(collect, subscriptionID)
+		} else {
+			carry = append(carry, subscriptionID)
+		}
+	}
+	return collect, carry
+}
+
+// carryForward copies the prior dump's data for the unchanged subscriptions
+// into the subscription data of this run and describes the run in metadata
+func (c *incrementalCollection) carryForward(allSubscriptionData map[string]interface{}, collect, carry []string) *IncrementalCollection {
+	for _, subscriptionID := range carry {
+		allSubscriptionData[subscriptionID] = c.prior.AzureResources[subscriptionID]
+	}
+	message.Info("Incremental collection: collected %d changed subscriptions, carried forward %d unchanged since %s",
+		len(collect), len(carry), c.since.Format(time.RFC3339))
+
+	collected := append([]string{}, collect...)
+	carried := append([]string{}, carry...)
+	sort.Strings(collected)
+	sort.Strings(carried)
+	return &IncrementalCollection{
+		ChangedSince:                c.since.Format(time.RFC3339),
+		PriorCollectionTimestamp:    c.prior.CollectionMetadata.CollectionTimestamp,
+		CollectedSubscriptions:      collected,
+		CarriedForwardSubscriptions: carried,
+	}
+}
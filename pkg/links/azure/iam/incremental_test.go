@@ -0,0 +1,104 @@
+package iam
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/praetorian-inc/janus-framework/pkg/chain/cfg"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writePriorDump(t *testing.T, prior ConsolidatedOutput) string {
+	path := filepath.Join(t.TempDir(), "prior.json")
+	raw, err := json.Marshal([]interface{}{prior})
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(path, raw, 0644))
+	return path
+}
+
+func activityEvent(operation, status string) interface{} {
+	return map[string]interface{}{
+		"operationName": map[string]interface{}{"value": operation},
+		"status":        map[string]interface{}{"value": status},
+	}
+}
+
+func TestIncrementalCollection(t *testing.T) {
+	priorData := map[string]interface{}{"roleAssignments": []interface{}{"carried"}}
+	path := writePriorDump(t, ConsolidatedOutput{
+		CollectionMetadata: CollectionMetadata{TenantID: "tenant-a", CollectionTimestamp: "2026-10-01T00:00:00Z"},
+		AzureResources: map[string]interface{}{
+			"sub-quiet":   priorData,
+			"sub-changed": priorData,
+			"sub-failing": priorData,
+		},
+	})
+
+	incremental, err := newIncrementalCollection(path, "")
+	require.NoError(t, err)
+	assert.Equal(t, "2026-10-01T00:00:00Z", incremental.since.Format(time.RFC3339), "the watermark defaults to the prior collection")
+	assert.NoError(t, incremental.checkTenant("TENANT-A"))
+	assert.Error(t, incremental.checkTenant("tenant-b"))
+
+	events := map[string][]interface{}{
+		"sub-quiet": {
+			activityEvent("Microsoft.Storage/storageAccounts/listKeys/action", "Succeeded"),
+			activityEvent("Microsoft.Authorization/roleAssignments/write", "Failed"),
+		},
+		"sub-changed": {
+			activityEvent("Microsoft.Authorization/roleAssignments/write", "Started"),
+			activityEvent("Microsoft.Authorization/roleAssignments/write", "Succeeded"),
+		},
+	}
+	fetch := func(url string) ([]interface{}, error) {
+		assert.Contains(t, url, "eventTimestamp+ge+%272026-10-01T00%3A00%3A00Z%27")
+		for subscriptionID, subscriptionEvents := range events {
+			if strings.Contains(url, "/subscriptions/"+subscriptionID+"/") {
+				return subscriptionEvents, nil
+			}
+		}
+		return nil, errors.New("forbidden")
+	}
+
+	errs := collectionErrorLog{}
+	now := time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)
+	collect, carry := incremental.partition(cfg.NewLogger(), &errs,
+		[]string{"sub-quiet", "sub-changed", "sub-failing", "sub-new"}, now, fetch)
+	assert.Equal(t, []string{"sub-changed", "sub-failing", "sub-new"}, collect)
+	assert.Equal(t, []string{"sub-quiet"}, carry)
+	require.Len(t, errs.list(), 1, "an unreadable activity log is recorded")
+
+	collected := map[string]interface{}{"sub-changed": map[string]interface{}{"roleAssignments": []interface{}{"fresh"}}}
+	metadata := incremental.carryForward(collected, collect, carry)
+	assert.Equal(t, []interface{}{"carried"}, collected["sub-quiet"].(map[string]interface{})["roleAssignments"])
+	assert.Equal(t, []string{"sub-quiet"}, metadata.CarriedForwardSubscriptions)
+	assert.Equal(t, "2026-10-01T00:00:00Z", metadata.PriorCollectionTimestamp)
+
+	collect, carry = incremental.partition(cfg.NewLogger(), &errs, []string{"sub-quiet"}, now.AddDate(0, 6, 0), fetch)
+	assert.Equal(t, []string{"sub-quiet"}, collect, "a watermark beyond activity log retention collects everything")
+	assert.Empty(t, carry)
+}
+
+func TestNewIncrementalCollectionOptions(t *testing.T) {
+	incremental, err := newIncrementalCollection("", "")
+	assert.NoError(t, err)
+	assert.Nil(t, incremental)
+
+	_, err = newIncrementalCollection("", "2026-10-01")
+	assert.Error(t, err, "changed-since needs a prior dump to carry data from")
+
+	sampled := writePriorDump(t, ConsolidatedOutput{CollectionMetadata: CollectionMetadata{Sampled: true, SampleSize: 5}})
+	_, err = newIncrementalCollection(sampled, "2026-10-01")
+	assert.Error(t, err)
+
+	full := writePriorDump(t, ConsolidatedOutput{CollectionMetadata: CollectionMetadata{CollectionTimestamp: "2026-10-01T00:00:00Z"}})
+	incremental, err = newIncrementalCollection(full, "2026-10-10")
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2026, 10, 10, 0, 0, 0, 0, time.UTC), incremental.since)
+}
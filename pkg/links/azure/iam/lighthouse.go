@@ -0,0 +1,214 @@
+package iam
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/praetorian-inc/janus-framework/pkg/chain/cfg"
+)
+
+// lighthouseAPIVersion is the Microsoft.ManagedServices API version used for
+// Azure Lighthouse registration definitions and assignments
+const lighthouseAPIVersion = "2022-10-01"
+
+// highPrivilegeRBACRoles are built-in Azure roles that give control over the
+// scope they are assigned at, keyed by role definition GUID
+var highPrivilegeRBACRoles = map[string]string{
+	"8e3af657-a8ff-443c-a75c-2fe8c4bcb635": "Owner",
+	"b24988ac-6180-42a0-ab88-20f7382dd24c": "Contributor",
+	"18d7d88d-d35e-4fb5-a5c3-7773c20a72d9": "User Access Administrator",
+	"f58310d9-a9f6-439a-9e8d-f62e7b41a168": "Role Based Access Control Administrator",
+}
+
+// lighthouseAssignmentsURL is the ARM path listing a subscription's Lighthouse
+// registration assignments with their registration definitions expanded inline
+func lighthouseAssignmentsURL(subscriptionID string) string {
+	return fmt.Sprintf("/subscriptions/%s/providers/Microsoft.ManagedServices/registrationAssignments?api-version=%s&$expandRegistrationDefinition=true",
+		subscriptionID, lighthouseAPIVersion)
+}
+
+// lighthouseDefinitionsURL is the ARM path listing a subscription's Lighthouse
+// registration definitions
+func lighthouseDefinitionsURL(subscriptionID string) string {
+	return fmt.Sprintf("/subscriptions/%s/providers/Microsoft.ManagedServices/registrationDefinitions?api-version=%s",
+		subscriptionID, lighthouseAPIVersion)
+}
+
+// newLighthouseSections builds the Lighthouse sections of a subscription's
+// AzureRM data from the raw registration definitions and assignments
+func newLighthouseSections(subscriptionID string, definitions, assignments []interface{}) map[string]interface{} {
+	if definitions == nil {
+		definitions = []interface{}{}
+	}
+	if assignments == nil {
+		assignments = []interface{}{}
+	}
+	delegations := buildLighthouseDelegations(subscriptionID, assignments)
+	return map[string]interface{}{
+		"lighthouseRegistrationDefinitions": definitions,
+		"lighthouseRegistrationAssignments": assignments,
+		"lighthouseDelegations":             delegations,
+		"lighthouseFindings":                buildLighthouseFindings(delegations),
+	}
+}
+
+// buildLighthouseDelegations flattens registration assignments into one record
+// per authorization, so each external principal and role pair granted to a
+// managing tenant can be reviewed and imported on its own.
+func buildLighthouseDelegations(subscriptionID string, assignments []interface{}) []interface{} {
+	delegations := []interface{}{}
+	for _, assignment := range assignments {
+		assignmentMap, ok := assignment.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		assignmentID, _ := assignmentMap["id"].(string)
+		assignmentProps, _ := assignmentMap["properties"].(map[string]interface{})
+		definition, _ := assignmentProps["registrationDefinition"].(map[string]interface{})
+		definitionProps, _ := definition["properties"].(map[string]interface{})
+		if definitionProps == nil {
+			continue
+		}
+
+		base := map[string]interface{}{
+			"subscriptionId":             subscriptionID,
+			"scope":                      lighthouseAssignmentScope(assignmentID),
+			"registrationAssignmentId":   assignmentID,
+			"registrationDefinitionId":   assignmentProps["registrationDefinitionId"],
+			"registrationDefinitionName": definitionProps["registrationDefinitionName"],
+			"managedByTenantId":          definitionProps["managedByTenantId"],
+			"managedByTenantName":        definitionProps["managedByTenantName"],
+		}
+
+		for _, authType := range []string{"authorizations", "eligibleAuthorizations"} {
+			authorizations, _ := definitionProps[authType].([]interface{})
+			for _, authorization := range authorizations {
+				authMap, ok := authorization.(map[string]interface{})
+				if !ok {
+					continue
+				}
+				roleDefinitionID, _ := authMap["roleDefinitionId"].(string)
+				roleGUID := strings.ToLower(roleDefinitionID[strings.LastIndex(roleDefinitionID, "/")+1:])
+				roleName, highPrivilege := highPrivilegeRBACRoles[roleGUID]
+
+				delegation := make(map[string]interface{}, len(base)+7)
+				for k, v := range base {
+					delegation[k] = v
+				}
+				delegation["principalId"] = authMap["principalId"]
+				delegation["principalDisplayName"] = authMap["principalIdDisplayName"]
+				delegation["roleDefinitionId"] = roleGUID
+				delegation["roleName"] = roleName
+				delegation["eligible"] = authType == "eligibleAuthorizations"
+				delegation["isHighPrivilege"] = highPrivilege
+				delegations = append(delegations, delegation)
+			}
+		}
+	}
+	return delegations
+}
+
+// buildLighthouseFindings reports delegations that give an external tenant a
+// high-privilege role over the delegated scope
+func buildLighthouseFindings(delegations []interface{}) []interface{} {
+	findings := []interface{}{}
+	for _, delegation := range delegations {
+		d, ok := delegation.(map[string]interface{})
+		if !ok || d["isHighPrivilege"] != true {
+			continue
+		}
+
+		tenant, _ := d["managedByTenantName"].(string)
+		if tenant == "" {
+			tenant, _ = d["managedByTenantId"].(string)
+		}
+		principal, _ := d["principalDisplayName"].(string)
+		if principal == "" {
+			principal, _ = d["principalId"].(string)
+		}
+		access := "standing"
+		if d["eligible"] == true {
+			access = "eligible (JIT)"
+		}
+
+		findings = append(findings, map[string]interface{}{
+			"type":     "LighthouseHighPrivilegeDelegation",
+			"severity": "High",
+			"description": fmt.Sprintf("External tenant %s has %s %s access to %s via Azure Lighthouse (principal %s)",
+				tenant, access, d["roleName"], d["scope"], principal),
+			"subscriptionId":           d["subscriptionId"],
+			"scope":                    d["scope"],
+			"managedByTenantId":        d["managedByTenantId"],
+			"principalId":              d["principalId"],
+			"roleDefinitionId":         d["roleDefinitionId"],
+			"roleName":                 d["roleName"],
+			"eligible":                 d["eligible"],
+			"registrationAssignmentId": d["registrationAssignmentId"],
+		})
+	}
+
+	sortFindings(findings, "description")
+	return findings
+}
+
+// logLighthouseFindings reports high-privilege Lighthouse delegations for a subscription
+func logLighthouseFindings(logger *cfg.Logger, subscriptionID string, findings []interface{}) {
+	logFindings(logger, findings, fmt.Sprintf("🚨 %%d high-privilege Azure Lighthouse delegations found in subscription %s", subscriptionID), "High-privilege Lighthouse delegation",
+		"subscription", "subscriptionId", "description", "description")
+}
+
+// lighthouseAssignmentScope returns the subscription or resource group a
+// registration assignment delegates, derived from the assignment ID
+func lighthouseAssignmentScope(assignmentID string) string {
+	if idx := strings.Index(strings.ToLower(assignmentID), "/providers/microsoft.managedservices/"); idx > 0 {
+		return assignmentID[:idx]
+	}
+	return assignmentID
+}
+
+// collectLighthouseData collects Azure Lighthouse registration definitions and
+// assignments for a subscription. Subscriptions without delegations return empty sections.
+func (l *IAMComprehensiveCollectorLink) collectLighthouseData(accessToken, subscriptionID string) (map[string]interface{}, error) {
+	definitions, err := l.collectPaginatedARMData(accessToken, lighthouseDefinitionsURL(subscriptionID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list Lighthouse registration definitions: %v", err)
+	}
+	assignments, err := l.collectPaginatedARMData(accessToken, lighthouseAssignmentsURL(subscriptionID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list Lighthouse registration assignments: %v", err)
+	}
+	return newLighthouseSections(subscriptionID, definitions, assignments), nil
+}
+
+// collectLighthouseDataSDK collects Azure Lighthouse registration definitions
+// and assignments for a subscription using the SDK collector's credential
+func (l *SDKComprehensiveCollectorLink) collectLighthouseDataSDK(ctx context.Context, subscriptionID string) (map[string]interface{}, error) {
+	token, err := l.getManagementAccessToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+	definitions, err := l.collectPaginatedARMDataSDK(ctx, token, lighthouseDefinitionsURL(subscriptionID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list Lighthouse registration definitions: %v", err)
+	}
+	assignments, err := l.collectPaginatedARMDataSDK(ctx, token, lighthouseAssignmentsURL(subscriptionID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list Lighthouse registration assignments: %v", err)
+	}
+	return newLighthouseSections(subscriptionID, definitions, assignments), nil
+}
+
+// collectPaginatedARMDataSDK follows ARM nextLink pagination for endpoints that
+// have no typed SDK client in this module
+func (l *SDKComprehensiveCollectorLink) collectPaginatedARMDataSDK(ctx context.Context, accessToken, url string) ([]interface{}, error) {
+	pager := &armPager{
+		client:   l.httpClient,
+		logger:   l.Logger,
+		errs:     &l.collectionErrors,
+		maxPages: armMaxPagesArg(l.Arg("arm-max-pages")),
+		maxItems: l.sampleSize,
+		throttle: &l.armThrottle,
+	}
+	return pager.collect(ctx, accessToken, l.cloud.armURL(url))
+}
@@ -0,0 +1,84 @@
+package iam
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const lighthouseAssignmentsFixture = `[
+  {
+    "id": "/subscriptions/sub-1/providers/Microsoft.ManagedServices/registrationAssignments/assign-1",
+    "properties": {
+      "registrationDefinitionId": "/subscriptions/sub-1/providers/Microsoft.ManagedServices/registrationDefinitions/def-1",
+      "registrationDefinition": {
+        "properties": {
+          "registrationDefinitionName": "Contoso MSP",
+          "managedByTenantId": "msp-tenant",
+          "managedByTenantName": "Contoso MSP Ltd",
+          "authorizations": [
+            {"principalId": "msp-admins", "principalIdDisplayName": "MSP Admins", "roleDefinitionId": "b24988ac-6180-42a0-ab88-20f7382dd24c"},
+            {"principalId": "msp-readers", "principalIdDisplayName": "MSP Readers", "roleDefinitionId": "acdd72a7-3385-48ef-bd42-f606fba81ae7"}
+          ],
+          "eligibleAuthorizations": [
+            {"principalId": "msp-breakglass", "principalIdDisplayName": "MSP Break Glass", "roleDefinitionId": "18D7D88D-D35E-4FB5-A5C3-7773C20A72D9"}
+          ]
+        }
+      }
+    }
+  },
+  {
+    "id": "/subscriptions/sub-1/resourceGroups/rg-app/providers/Microsoft.ManagedServices/registrationAssignments/assign-2",
+    "properties": {
+      "registrationDefinitionId": "/subscriptions/sub-1/providers/Microsoft.ManagedServices/registrationDefinitions/def-2"
+    }
+  }
+]`
+
+func TestLighthouseDelegationsAndFindings(t *testing.T) {
+	var assignments []interface{}
+	require.NoError(t, json.Unmarshal([]byte(lighthouseAssignmentsFixture), &assignments))
+
+	sections := newLighthouseSections("sub-1", nil, assignments)
+	assert.Equal(t, []interface{}{}, sections["lighthouseRegistrationDefinitions"])
+	assert.Len(t, sections["lighthouseRegistrationAssignments"], 2)
+
+	delegations := sections["lighthouseDelegations"].([]interface{})
+	require.Len(t, delegations, 3, "one delegation per authorization; assignments without an expanded definition are skipped")
+
+	byPrincipal := make(map[string]map[string]interface{})
+	for _, d := range delegations {
+		dm := d.(map[string]interface{})
+		byPrincipal[dm["principalId"].(string)] = dm
+	}
+
+	contributor := byPrincipal["msp-admins"]
+	assert.Equal(t, "/subscriptions/sub-1", contributor["scope"])
+	assert.Equal(t, "msp-tenant", contributor["managedByTenantId"])
+	assert.Equal(t, "Contributor", contributor["roleName"])
+	assert.Equal(t, true, contributor["isHighPrivilege"])
+	assert.Equal(t, false, contributor["eligible"])
+
+	assert.Equal(t, false, byPrincipal["msp-readers"]["isHighPrivilege"])
+
+	breakGlass := byPrincipal["msp-breakglass"]
+	assert.Equal(t, "User Access Administrator", breakGlass["roleName"], "role GUIDs should match case-insensitively")
+	assert.Equal(t, true, breakGlass["eligible"])
+
+	findings := sections["lighthouseFindings"].([]interface{})
+	require.Len(t, findings, 2)
+	for _, f := range findings {
+		fm := f.(map[string]interface{})
+		assert.Equal(t, "High", fm["severity"])
+		assert.Equal(t, "LighthouseHighPrivilegeDelegation", fm["type"])
+		assert.Contains(t, fm["description"], "Contoso MSP Ltd")
+	}
+}
+
+func TestLighthouseAssignmentScope(t *testing.T) {
+	assert.Equal(t, "/subscriptions/sub-1/resourceGroups/rg-app",
+		lighthouseAssignmentScope("/subscriptions/sub-1/resourceGroups/rg-app/providers/Microsoft.ManagedServices/registrationAssignments/a"))
+	assert.Equal(t, "not-an-arm-id", lighthouseAssignmentScope("not-an-arm-id"))
+}
@@ -0,0 +1,272 @@
+package iam
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/praetorian-inc/janus-framework/pkg/chain/cfg"
+)
+
+// highPrivilegeConnectors are managed API connectors whose stored connection
+// acts on the directory, ARM, secrets or a mailbox with the rights of whoever
+// authorized it, keyed by the managed API name
+var highPrivilegeConnectors = map[string]string{
+	"arm":                 "Azure Resource Manager",
+	"azuread":             "Azure AD",
+	"azureautomation":     "Azure Automation",
+	"keyvault":            "Azure Key Vault",
+	"webcontents":         "HTTP with Microsoft Entra ID",
+	"office365":           "Office 365 Outlook",
+	"office365users":      "Office 365 Users",
+	"sharepointonline":    "SharePoint",
+	"onedriveforbusiness": "OneDrive for Business",
+	"sql":                 "SQL Server",
+	"documentdb":          "Azure Cosmos DB",
+	"azureblob":           "Azure Blob Storage",
+}
+
+// embeddedCredentialFields are the authentication fields of an HTTP action
+// that hold a secret, per authentication type
+var embeddedCredentialFields = map[string][]string{
+	"basic":                {"password"},
+	"clientcertificate":    {"pfx", "password"},
+	"activedirectoryoauth": {"secret", "pfx"},
+	"raw":                  {"value"},
+}
+
+// workflowConnection is one API connection a Logic App references
+type workflowConnection struct {
+	name            string
+	api             string
+	connectionID    string
+	managedIdentity bool
+}
+
+// workflowConnections reads the API connections a workflow references from
+// its $connections parameter
+func workflowConnections(properties map[string]interface{}) []workflowConnection {
+	parameters, _ := properties["parameters"].(map[string]interface{})
+	connectionsParam, _ := parameters["$connections"].(map[string]interface{})
+	value, _ := connectionsParam["value"].(map[string]interface{})
+
+	connections := make([]workflowConnection, 0, len(value))
+	for name, entry := range value {
+		entryMap, _ := entry.(map[string]interface{})
+		apiID, _ := entryMap["id"].(string)
+		connection := workflowConnection{name: name, api: strings.ToLower(apiID[strings.LastIndex(apiID, "/")+1:])}
+		connection.connectionID, _ = entryMap["connectionId"].(string)
+		connectionProperties, _ := entryMap["connectionProperties"].(map[string]interface{})
+		authentication, _ := connectionProperties["authentication"].(map[string]interface{})
+		authType, _ := authentication["type"].(string)
+		connection.managedIdentity = strings.EqualFold(authType, "ManagedServiceIdentity")
+		connections = append(connections, connection)
+	}
+	sort.Slice(connections, func(i, j int) bool { return connections[i].name < connections[j].name })
+	return connections
+}
+
+// workflowActionAuth records, for every action in a workflow definition,
+// whether it authenticates with a managed identity or carries a literal
+// credential. Scopes, conditions, switches and loops nest their own actions.
+func workflowActionAuth(actions map[string]interface{}, managedIdentity, credentials map[string]bool) {
+	for name, action := range actions {
+		actionMap, ok := action.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		inputs, _ := actionMap["inputs"].(map[string]interface{})
+		if authentication, ok := inputs["authentication"].(map[string]interface{}); ok {
+			authType, _ := authentication["type"].(string)
+			if strings.EqualFold(authType, "ManagedServiceIdentity") {
+				managedIdentity[name] = true
+			}
+			for _, field := range embeddedCredentialFields[strings.ToLower(authType)] {
+				if isLiteralSecret(authentication[field]) {
+					credentials[name] = true
+				}
+			}
+		}
+		headers, _ := inputs["headers"].(map[string]interface{})
+		for header, value := range headers {
+			if strings.EqualFold(header, "Authorization") && isLiteralSecret(value) {
+				credentials[name] = true
+			}
+		}
+
+		nested, _ := actionMap["actions"].(map[string]interface{})
+		workflowActionAuth(nested, managedIdentity, credentials)
+		elseBranch, _ := actionMap["else"].(map[string]interface{})
+		nested, _ = elseBranch["actions"].(map[string]interface{})
+		workflowActionAuth(nested, managedIdentity, credentials)
+		defaultBranch, _ := actionMap["default"].(map[string]interface{})
+		nested, _ = defaultBranch["actions"].(map[string]interface{})
+		workflowActionAuth(nested, managedIdentity, credentials)
+		cases, _ := actionMap["cases"].(map[string]interface{})
+		for _, c := range cases {
+			caseMap, _ := c.(map[string]interface{})
+			nested, _ = caseMap["actions"].(map[string]interface{})
+			workflowActionAuth(nested, managedIdentity, credentials)
+		}
+	}
+}
+
+// isLiteralSecret reports whether a credential field holds a value written
+// into the definition rather than a workflow expression such as
+// @parameters('password') that resolves it at run time
+func isLiteralSecret(value interface{}) bool {
+	s, _ := value.(string)
+	s = strings.TrimSpace(s)
+	return s != "" && !strings.HasPrefix(s, "@")
+}
+
+// sortedActionNames returns the action names in a set in order
+func sortedActionNames(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// buildLogicAppFindings flags Logic App workflows that are a path to privilege:
+// an attached managed identity holding a privileged role, a connection to a
+// high-privilege connector, or a credential written into the definition.
+// Anyone who can edit or read the workflow can run actions with that identity
+// or connection, or lift the credential. Automation accounts are flagged when
+// their identity holds a privileged role, since anyone who can edit a runbook
+// runs code as that identity.
+func buildLogicAppFindings(o *ConsolidatedOutput) []interface{} {
+	findings := []interface{}{}
+
+	var workflows, automationAccounts []map[string]interface{}
+	for _, subData := range o.AzureResources {
+		subDataMap, ok := subData.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		resources, _ := subDataMap["azureResources"].([]interface{})
+		for _, resource := range resources {
+			resourceMap, ok := resource.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			switch resourceType, _ := resourceMap["type"].(string); strings.ToLower(resourceType) {
+			case "microsoft.logic/workflows":
+				workflows = append(workflows, resourceMap)
+			case "microsoft.automation/automationaccounts":
+				automationAccounts = append(automationAccounts, resourceMap)
+			}
+		}
+	}
+	if len(workflows) == 0 && len(automationAccounts) == 0 {
+		return findings
+	}
+
+	grants := privilegedPrincipalGrants(o)
+	identityAccess := func(resource map[string]interface{}) ([]interface{}, []interface{}) {
+		identities, access := []interface{}{}, []interface{}{}
+		for _, identity := range webAppIdentities(resource) {
+			identities = append(identities, map[string]interface{}{
+				"identityType":           identity.kind,
+				"identityPrincipalId":    identity.principalID,
+				"userAssignedIdentityId": identity.resourceID,
+			})
+			for _, grant := range grants[strings.ToLower(identity.principalID)] {
+				entry := map[string]interface{}{"identityPrincipalId": identity.principalID}
+				for key, value := range grant {
+					entry[key] = value
+				}
+				access = append(access, entry)
+			}
+		}
+		return identities, access
+	}
+
+	for _, workflow := range workflows {
+		properties, _ := workflow["properties"].(map[string]interface{})
+		identities, access := identityAccess(workflow)
+
+		connectors, privilegedConnectors := []interface{}{}, []interface{}{}
+		for _, connection := range workflowConnections(properties) {
+			entry := map[string]interface{}{
+				"name":            connection.name,
+				"api":             connection.api,
+				"connectionId":    connection.connectionID,
+				"managedIdentity": connection.managedIdentity,
+			}
+			connectors = append(connectors, entry)
+			if displayName, ok := highPrivilegeConnectors[connection.api]; ok {
+				entry["displayName"] = displayName
+				privilegedConnectors = append(privilegedConnectors, entry)
+			}
+		}
+
+		managedIdentityActions, credentialActions := map[string]bool{}, map[string]bool{}
+		definition, _ := properties["definition"].(map[string]interface{})
+		actions, _ := definition["actions"].(map[string]interface{})
+		workflowActionAuth(actions, managedIdentityActions, credentialActions)
+
+		if len(access) == 0 && len(privilegedConnectors) == 0 && len(credentialActions) == 0 {
+			continue
+		}
+
+		var reasons []string
+		severity := "Medium"
+		if len(access) > 0 {
+			severity = "High"
+			reasons = append(reasons, fmt.Sprintf("its managed identity holds %d privileged role grants", len(access)))
+		}
+		if len(credentialActions) > 0 {
+			severity = "High"
+			reasons = append(reasons, fmt.Sprintf("%d actions embed credentials in the definition", len(credentialActions)))
+		}
+		if len(privilegedConnectors) > 0 {
+			reasons = append(reasons, fmt.Sprintf("it uses %d high-privilege connectors", len(privilegedConnectors)))
+		}
+
+		state, _ := properties["state"].(string)
+		findings = append(findings, map[string]interface{}{
+			"type":                      "LogicAppPrivilegedAccess",
+			"severity":                  severity,
+			"description":               fmt.Sprintf("Logic App %s is a privilege path: %s", workflow["name"], strings.Join(reasons, "; ")),
+			"resourceId":                workflow["id"],
+			"resourceName":              workflow["name"],
+			"resourceType":              "microsoft.logic/workflows",
+			"state":                     state,
+			"identities":                identities,
+			"effectiveAccess":           access,
+			"connectors":                connectors,
+			"highPrivilegeConnectors":   privilegedConnectors,
+			"managedIdentityActions":    sortedActionNames(managedIdentityActions),
+			"embeddedCredentialActions": sortedActionNames(credentialActions),
+		})
+	}
+
+	for _, account := range automationAccounts {
+		identities, access := identityAccess(account)
+		if len(access) == 0 {
+			continue
+		}
+		findings = append(findings, map[string]interface{}{
+			"type":            "AutomationAccountPrivilegedIdentity",
+			"severity":        "High",
+			"description":     fmt.Sprintf("Automation account %s runs runbooks as an identity holding %d privileged role grants", account["name"], len(access)),
+			"resourceId":      account["id"],
+			"resourceName":    account["name"],
+			"resourceType":    "microsoft.automation/automationaccounts",
+			"identities":      identities,
+			"effectiveAccess": access,
+		})
+	}
+
+	sortFindings(findings, "resourceId")
+	return findings
+}
+
+// logLogicAppFindings reports Logic Apps and Automation accounts that lead to privileged access
+func logLogicAppFindings(logger *cfg.Logger, findings []interface{}) {
+	logFindings(logger, findings, "🚨 %d Logic Apps or Automation accounts lead to privileged access", "Automation with privileged access",
+		"resource", "resourceName", "type", "type", "severity", "severity")
+}
@@ -0,0 +1,107 @@
+package iam
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const logicAppFixture = `{
+  "azure_ad": {},
+  "pim": {},
+  "azure_resources": {
+    "sub-1": {
+      "azureResources": [
+        {
+          "id": "/subscriptions/sub-1/resourceGroups/ops/providers/Microsoft.Logic/workflows/rotate-keys",
+          "name": "rotate-keys", "type": "Microsoft.Logic/workflows",
+          "identity": {"type": "SystemAssigned", "principalId": "la-mi"},
+          "properties": {
+            "state": "Enabled",
+            "definition": {"actions": {
+              "Call_ARM": {"type": "Http", "inputs": {"authentication": {"type": "ManagedServiceIdentity", "audience": "https://management.azure.com/"}}},
+              "Check": {"type": "If", "actions": {}, "else": {"actions": {
+                "Call_Legacy": {"type": "Http", "inputs": {"authentication": {"type": "Basic", "username": "svc", "password": "Winter2024!"}}}
+              }}},
+              "Call_Param": {"type": "Http", "inputs": {"authentication": {"type": "Basic", "username": "svc", "password": "@parameters('password')"}}}
+            }},
+            "parameters": {"$connections": {"value": {
+              "office365": {"id": "/subscriptions/sub-1/providers/Microsoft.Web/locations/eastus/managedApis/office365", "connectionId": "/subscriptions/sub-1/resourceGroups/ops/providers/Microsoft.Web/connections/office365"},
+              "slack": {"id": "/subscriptions/sub-1/providers/Microsoft.Web/locations/eastus/managedApis/slack", "connectionId": "/subscriptions/sub-1/resourceGroups/ops/providers/Microsoft.Web/connections/slack"}
+            }}}
+          }
+        },
+        {
+          "id": "/subscriptions/sub-1/resourceGroups/ops/providers/Microsoft.Logic/workflows/notify",
+          "name": "notify", "type": "Microsoft.Logic/workflows",
+          "properties": {"parameters": {"$connections": {"value": {
+            "arm": {"id": "/subscriptions/sub-1/providers/Microsoft.Web/locations/eastus/managedApis/arm", "connectionId": "c-arm", "connectionProperties": {"authentication": {"type": "ManagedServiceIdentity"}}}
+          }}}}
+        },
+        {
+          "id": "/subscriptions/sub-1/resourceGroups/ops/providers/Microsoft.Logic/workflows/harmless",
+          "name": "harmless", "type": "Microsoft.Logic/workflows",
+          "properties": {"parameters": {"$connections": {"value": {
+            "slack": {"id": "/subscriptions/sub-1/providers/Microsoft.Web/locations/eastus/managedApis/slack"}
+          }}}}
+        },
+        {
+          "id": "/subscriptions/sub-1/resourceGroups/ops/providers/Microsoft.Automation/automationAccounts/patching",
+          "name": "patching", "type": "Microsoft.Automation/automationAccounts",
+          "identity": {"type": "UserAssigned", "userAssignedIdentities": {
+            "/subscriptions/sub-1/resourceGroups/ops/providers/Microsoft.ManagedIdentity/userAssignedIdentities/patching": {"principalId": "aa-uami"}
+          }}
+        }
+      ],
+      "subscriptionRoleAssignments": [
+        {"properties": {"principalId": "la-mi", "roleDefinitionId": "/subscriptions/sub-1/providers/Microsoft.Authorization/roleDefinitions/b24988ac-6180-42a0-ab88-20f7382dd24c", "scope": "/subscriptions/sub-1"}},
+        {"properties": {"principalId": "aa-uami", "roleDefinitionId": "/subscriptions/sub-1/providers/Microsoft.Authorization/roleDefinitions/acdd72a7-3385-48ef-bd42-f606fba81ae7", "scope": "/subscriptions/sub-1"}}
+      ]
+    }
+  }
+}`
+
+func TestBuildLogicAppFindings(t *testing.T) {
+	var output ConsolidatedOutput
+	require.NoError(t, json.Unmarshal([]byte(logicAppFixture), &output))
+
+	findings := buildLogicAppFindings(&output)
+	require.Len(t, findings, 2, "a Reader automation identity and a workflow with only ordinary connectors are not reported")
+
+	rotate := findings[0].(map[string]interface{})
+	assert.Equal(t, "LogicAppPrivilegedAccess", rotate["type"])
+	assert.Equal(t, "rotate-keys", rotate["resourceName"])
+	assert.Equal(t, "High", rotate["severity"])
+	access := rotate["effectiveAccess"].([]interface{})
+	require.Len(t, access, 1)
+	assert.Equal(t, "Contributor", access[0].(map[string]interface{})["roleName"])
+	assert.Len(t, rotate["connectors"], 2)
+	privileged := rotate["highPrivilegeConnectors"].([]interface{})
+	require.Len(t, privileged, 1)
+	assert.Equal(t, "office365", privileged[0].(map[string]interface{})["api"])
+	assert.Equal(t, []string{"Call_ARM"}, rotate["managedIdentityActions"])
+	assert.Equal(t, []string{"Call_Legacy"}, rotate["embeddedCredentialActions"], "nested literal password is found; a parameter reference is not a credential")
+
+	notify := findings[1].(map[string]interface{})
+	assert.Equal(t, "notify", notify["resourceName"])
+	assert.Equal(t, "Medium", notify["severity"])
+	assert.Equal(t, true, notify["highPrivilegeConnectors"].([]interface{})[0].(map[string]interface{})["managedIdentity"])
+}
+
+func TestBuildLogicAppFindingsAutomationAccount(t *testing.T) {
+	var output ConsolidatedOutput
+	require.NoError(t, json.Unmarshal([]byte(logicAppFixture), &output))
+	assignments := output.AzureResources["sub-1"].(map[string]interface{})["subscriptionRoleAssignments"].([]interface{})
+	// Promote the automation identity from Reader to Owner
+	assignments[1].(map[string]interface{})["properties"].(map[string]interface{})["roleDefinitionId"] = "/providers/Microsoft.Authorization/roleDefinitions/8e3af657-a8ff-443c-a75c-2fe8c4bcb635"
+
+	findings := buildLogicAppFindings(&output)
+	require.Len(t, findings, 3)
+	account := findings[0].(map[string]interface{})
+	assert.Equal(t, "AutomationAccountPrivilegedIdentity", account["type"])
+	assert.Equal(t, "patching", account["resourceName"])
+	identities := account["identities"].([]interface{})
+	assert.Equal(t, "UserAssigned", identities[0].(map[string]interface{})["identityType"])
+}
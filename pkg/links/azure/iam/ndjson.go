@@ -0,0 +1,326 @@
+package iam
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/praetorian-inc/nebula/internal/message"
+)
+
+// Output formats selected by --format
+const (
+	outputFormatJSON   = "json"
+	outputFormatNDJSON = "ndjson"
+)
+
+// ndjsonRecord is one line of --format ndjson output: a single collected
+// object tagged with the section (category) and, for azure_resources, the
+// subscription it belongs to. Type is the key the object is listed under in
+// the consolidated output, e.g. users or azureResources.
+type ndjsonRecord struct {
+	Category     string      `json:"category"`
+	Subscription string      `json:"subscription,omitempty"`
+	Type         string      `json:"type,omitempty"`
+	Data         interface{} `json:"data"`
+}
+
+// ndjsonWriter streams the collected data of a --format ndjson run to a file
+// as each phase completes, so consumers can start before the run ends and the
+// consolidated output does not have to be encoded as one document. Each
+// collection is written once, so records are brought to their final form
+// before they are streamed: member and owner types are normalized and
+// orphaned role assignments are flagged as in the JSON output, and derived
+// sections such as the findings are left to finish. finish writes whatever
+// the phases did not stream, releasing each section as soon as it is written,
+// and ends with a collection_metadata record.
+type ndjsonWriter struct {
+	path string
+
+	mu       sync.Mutex
+	file     *os.File
+	buf      *bufio.Writer
+	enc      *json.Encoder
+	streamed map[string]bool
+	records  int
+	err      error
+
+	// principals are the directory principals role assignments are checked
+	// against; nil when the orphaned role assignment check is skipped
+	principals map[string]bool
+}
+
+// newNDJSONWriter creates <dir>/iam-pull-<tenant>.ndjson for --format ndjson.
+// It returns nil for the json format, and a nil writer ignores every call.
+func newNDJSONWriter(format, dir, tenantID string) (*ndjsonWriter, error) {
+	switch format {
+	case "", outputFormatJSON:
+		return nil, nil
+	case outputFormatNDJSON:
+	default:
+		return nil, fmt.Errorf("invalid format %q: must be %s or %s", format, outputFormatJSON, outputFormatNDJSON)
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create output directory: %v", err)
+	}
+	path := filepath.Join(dir, fmt.Sprintf("iam-pull-%s.ndjson", tenantID))
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create NDJSON output: %v", err)
+	}
+	buf := bufio.NewWriter(file)
+	message.Info("Streaming collected data as NDJSON to %s", path)
+	return &ndjsonWriter{path: path, file: file, buf: buf, enc: json.NewEncoder(buf), streamed: make(map[string]bool)}, nil
+}
+
+// streamDirectory streams the azure_ad section once Azure AD collection is
+// complete. Its member and owner types are normalized first, and its users,
+// groups and service principals are kept to flag orphaned role assignments in
+// the sections streamed after it.
+func (w *ndjsonWriter) streamDirectory(azureAD map[string]interface{}, collectionErrors []CollectionError) {
+	if w == nil {
+		return
+	}
+	normalizeODataTypeFields(azureAD)
+	if principals, incomplete := directoryPrincipals(azureAD, collectionErrors); incomplete == "" && len(principals) > 0 {
+		w.principals = principals
+	}
+	w.streamSection("azure_ad", "", azureAD)
+}
+
+// streamSection writes every object of a keyed section such as azure_ad, pim
+// or one subscription's azure_resources entry. Keys streamed before and
+// derived sections, which are computed when the run ends, are skipped.
+func (w *ndjsonWriter) streamSection(category, subscriptionID string, data map[string]interface{}) {
+	if w == nil {
+		return
+	}
+	if category == "azure_resources" && w.principals != nil {
+		markSubscriptionOrphans(w.principals, data)
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for _, key := range sortedSectionKeys(data) {
+		if !derivedSection(key) {
+			w.streamLocked(category, subscriptionID, key, data[key])
+		}
+	}
+	w.flushLocked()
+}
+
+// releaseSection writes every key of a section that was not streamed yet,
+// derived sections included, and removes each key from data once it is
+// written
+func (w *ndjsonWriter) releaseSection(category, subscriptionID string, data map[string]interface{}) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for _, key := range sortedSectionKeys(data) {
+		w.streamLocked(category, subscriptionID, key, data[key])
+		delete(data, key)
+	}
+	w.flushLocked()
+}
+
+// streamList writes every object of a section that is a plain list, such as
+// management_groups. A list streamed before is skipped.
+func (w *ndjsonWriter) streamList(category string, objects []interface{}) {
+	if w == nil {
+		return
+	}
+	if category == "management_group_rbac" && w.principals != nil {
+		markOrphans(w.principals, objects)
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.streamLocked(category, "", "", objects)
+	w.flushLocked()
+}
+
+// sortedSectionKeys returns the keys of a section in the order they are written
+func sortedSectionKeys(data map[string]interface{}) []string {
+	keys := make([]string, 0, len(data))
+	for key := range data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// flushLocked writes the buffered records to the file
+func (w *ndjsonWriter) flushLocked() {
+	if err := w.buf.Flush(); err != nil && w.err == nil {
+		w.err = err
+	}
+}
+
+// streamLocked writes one record per element of a list value, or a single
+// record for any other value
+func (w *ndjsonWriter) streamLocked(category, subscriptionID, key string, value interface{}) {
+	id := strings.Join([]string{category, subscriptionID, key}, "/")
+	if w.streamed[id] || w.err != nil {
+		return
+	}
+	w.streamed[id] = true
+
+	objects, ok := value.([]interface{})
+	if !ok {
+		objects = []interface{}{value}
+	}
+	for _, object := range objects {
+		if err := w.enc.Encode(ndjsonRecord{Category: category, Subscription: subscriptionID, Type: key, Data: object}); err != nil {
+			w.err = fmt.Errorf("failed to write NDJSON record: %v", err)
+			return
+		}
+		w.records++
+	}
+}
+
+// finish streams the parts of the finished output the phases did not, then
+// the collection errors, the baseline comparison and the collection metadata
+// as the last record. Each section is removed from the output as soon as it
+// is written, leaving sections empty in the output, whose metadata points at
+// the NDJSON file. It must run after the findings and summary are computed.
+func (w *ndjsonWriter) finish(o *ConsolidatedOutput) error {
+	if w == nil {
+		return nil
+	}
+	w.releaseSection("azure_ad", "", o.AzureAD)
+	w.releaseSection("pim", "", o.PIM)
+	for _, subscriptionID := range sortedSectionKeys(o.AzureResources) {
+		subDataMap, _ := o.AzureResources[subscriptionID].(map[string]interface{})
+		w.releaseSection("azure_resources", subscriptionID, subDataMap)
+		delete(o.AzureResources, subscriptionID)
+	}
+	w.streamList("management_groups", o.ManagementGroups)
+	o.ManagementGroups = []interface{}{}
+	w.streamList("management_group_rbac", o.ManagementGroupRBAC)
+	o.ManagementGroupRBAC = []interface{}{}
+	w.streamList("resource_locks", o.ResourceLocks)
+	o.ResourceLocks = []interface{}{}
+	w.streamAuditLogs(o.AuditLogs)
+	o.AuditLogs = nil
+
+	collectionErrors := make([]interface{}, 0, len(o.CollectionErrors))
+	for _, e := range o.CollectionErrors {
+		collectionErrors = append(collectionErrors, e)
+	}
+	w.streamList("collection_errors", collectionErrors)
+	if o.BaselineComparison != nil {
+		w.streamList("baseline_comparison", []interface{}{o.BaselineComparison})
+	}
+
+	o.CollectionMetadata.NDJSONFile = w.path
+	w.streamList("collection_metadata", []interface{}{o.CollectionMetadata})
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if err := w.file.Close(); err != nil && w.err == nil {
+		w.err = err
+	}
+	if w.err != nil {
+		return fmt.Errorf("failed to write %s: %v", w.path, w.err)
+	}
+	message.Info("Wrote %d NDJSON records to %s", w.records, w.path)
+	return nil
+}
+
+// streamAuditLogs writes the sign-in and directory audit entries of a run
+// with a log window
+func (w *ndjsonWriter) streamAuditLogs(auditLogs *AuditLogs) {
+	if w == nil || auditLogs == nil {
+		return
+	}
+	w.streamSection("audit_logs", "", map[string]interface{}{
+		"window":           auditLogs.Window,
+		"sign_ins":         auditLogs.SignIns,
+		"directory_audits": auditLogs.DirectoryAudits,
+	})
+}
+
+// loadNDJSONDump rebuilds the data sections of a --format ndjson run from its
+// records, keyed like the consolidated output. The file is tried as written,
+// then relative to the dump's directory. Sections with no objects have no
+// records and are left to Normalize.
+func loadNDJSONDump(file, dumpDir string) (map[string]interface{}, error) {
+	f, err := os.Open(file)
+	if err != nil && !filepath.IsAbs(file) {
+		f, err = os.Open(filepath.Join(dumpDir, file))
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	sections := map[string]interface{}{
+		"azure_ad":        map[string]interface{}{},
+		"pim":             map[string]interface{}{},
+		"azure_resources": map[string]interface{}{},
+	}
+	appendTo := func(section map[string]interface{}, key string, object interface{}) {
+		objects, _ := section[key].([]interface{})
+		section[key] = append(objects, object)
+	}
+
+	decoder := json.NewDecoder(bufio.NewReader(f))
+	for line := 1; decoder.More(); line++ {
+		var record ndjsonRecord
+		if err := decoder.Decode(&record); err != nil {
+			return nil, fmt.Errorf("record %d: %v", line, err)
+		}
+		switch record.Category {
+		case "azure_ad", "pim":
+			appendTo(sections[record.Category].(map[string]interface{}), record.Type, record.Data)
+		case "azure_resources":
+			azureResources := sections["azure_resources"].(map[string]interface{})
+			subData, ok := azureResources[record.Subscription].(map[string]interface{})
+			if !ok {
+				subData = make(map[string]interface{})
+				azureResources[record.Subscription] = subData
+			}
+			appendTo(subData, record.Type, record.Data)
+		case "management_groups", "management_group_rbac", "resource_locks":
+			objects, _ := sections[record.Category].([]interface{})
+			sections[record.Category] = append(objects, record.Data)
+		case "audit_logs":
+			auditLogs, ok := sections["audit_logs"].(map[string]interface{})
+			if !ok {
+				auditLogs = make(map[string]interface{})
+				sections["audit_logs"] = auditLogs
+			}
+			if record.Type == "window" {
+				auditLogs["window"] = record.Data
+			} else {
+				appendTo(auditLogs, record.Type, record.Data)
+			}
+		}
+		// collection_errors, baseline_comparison and collection_metadata are
+		// also in the JSON output the file is loaded with
+	}
+	return sections, nil
+}
+
+// loadNDJSONSections reads the data sections of a --format ndjson dump back
+// into the output, which then holds them inline again
+func loadNDJSONSections(o *ConsolidatedOutput, dumpDir string) error {
+	sections, err := loadNDJSONDump(o.CollectionMetadata.NDJSONFile, dumpDir)
+	if err != nil {
+		return fmt.Errorf("failed to load NDJSON file %s: %v", o.CollectionMetadata.NDJSONFile, err)
+	}
+	raw, err := json.Marshal(sections)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(raw, o); err != nil {
+		return fmt.Errorf("failed to load NDJSON file %s: %v", o.CollectionMetadata.NDJSONFile, err)
+	}
+	o.CollectionMetadata.NDJSONFile = ""
+	return nil
+}
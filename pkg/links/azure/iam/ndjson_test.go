@@ -0,0 +1,178 @@
+package iam
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/praetorian-inc/janus-framework/pkg/chain/cfg"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNDJSONWriter(t *testing.T) {
+	writer, err := newNDJSONWriter(outputFormatJSON, t.TempDir(), "tenant-1")
+	require.NoError(t, err)
+	assert.Nil(t, writer, "the json format writes no NDJSON file")
+	_, err = newNDJSONWriter("xml", t.TempDir(), "tenant-1")
+	assert.Error(t, err)
+
+	dir := t.TempDir()
+	writer, err = newNDJSONWriter(outputFormatNDJSON, dir, "tenant-1")
+	require.NoError(t, err)
+
+	azureAD := map[string]interface{}{
+		"users":  []interface{}{map[string]interface{}{"id": "u-1"}, map[string]interface{}{"id": "u-2"}},
+		"groups": []interface{}{},
+	}
+	writer.streamSection("azure_ad", "", azureAD)
+	subData := map[string]interface{}{"azureResources": []interface{}{map[string]interface{}{"id": "vm-1"}}}
+	writer.streamSection("azure_resources", "sub-a", subData)
+	writer.streamList("management_groups", []interface{}{map[string]interface{}{"id": "mg-1"}})
+
+	// Findings are added after the Azure AD phase was streamed
+	azureAD["ruleFindings"] = []interface{}{map[string]interface{}{"rule": "tenant-root-rbac"}}
+	o := &ConsolidatedOutput{
+		CollectionMetadata: CollectionMetadata{SchemaVersion: ConsolidatedSchemaVersion, TenantID: "tenant-1"},
+		AzureAD:            azureAD,
+		AzureResources:     map[string]interface{}{"sub-a": subData},
+		ManagementGroups:   []interface{}{map[string]interface{}{"id": "mg-1"}},
+		CollectionErrors:   []CollectionError{{Dataset: "devices", Scope: "tenant", Message: "timeout"}},
+	}
+	o.Normalize()
+	require.NoError(t, writer.finish(o))
+
+	file, err := os.Open(writer.path)
+	require.NoError(t, err)
+	defer file.Close()
+	var records []ndjsonRecord
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var record ndjsonRecord
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &record))
+		records = append(records, record)
+	}
+	require.NoError(t, scanner.Err())
+
+	require.Len(t, records, 7, "each object is written once")
+	assert.Equal(t, ndjsonRecord{Category: "azure_ad", Type: "users", Data: map[string]interface{}{"id": "u-1"}}, records[0])
+	assert.Equal(t, "sub-a", records[2].Subscription)
+	assert.Equal(t, "azureResources", records[2].Type)
+	assert.Equal(t, "management_groups", records[3].Category)
+	assert.Equal(t, "ruleFindings", records[4].Type, "data added after a phase is streamed when the run finishes")
+	assert.Equal(t, "collection_errors", records[5].Category)
+	last := records[6]
+	assert.Equal(t, "collection_metadata", last.Category)
+	assert.Equal(t, writer.path, last.Data.(map[string]interface{})["ndjson_file"])
+
+	assert.Equal(t, writer.path, o.CollectionMetadata.NDJSONFile)
+	assert.Empty(t, o.AzureAD)
+	assert.Empty(t, o.AzureResources)
+	assert.Empty(t, o.ManagementGroups)
+	assert.Len(t, o.CollectionErrors, 1, "the JSON output keeps the collection errors")
+
+	// The JSON output loads with the streamed data back inline
+	raw, err := json.Marshal([]interface{}{o})
+	require.NoError(t, err)
+	dumpPath := filepath.Join(dir, "dump.json")
+	require.NoError(t, os.WriteFile(dumpPath, raw, 0644))
+	loaded, err := loadConsolidatedDump(dumpPath)
+	require.NoError(t, err)
+	assert.Len(t, loaded.AzureAD["users"], 2)
+	assert.Len(t, loaded.AzureAD["ruleFindings"], 1)
+	assert.Empty(t, loaded.AzureAD["groups"])
+	subLoaded, _ := loaded.AzureResources["sub-a"].(map[string]interface{})
+	assert.Len(t, subLoaded["azureResources"], 1)
+	assert.Len(t, loaded.ManagementGroups, 1)
+	assert.Empty(t, loaded.CollectionMetadata.NDJSONFile)
+}
+
+func TestNDJSONMatchesJSONOutput(t *testing.T) {
+	dir := t.TempDir()
+	writer, err := newNDJSONWriter(outputFormatNDJSON, dir, "tenant-1")
+	require.NoError(t, err)
+
+	armAssignment := func(principalID, principalType string) map[string]interface{} {
+		return map[string]interface{}{"properties": map[string]interface{}{"principalId": principalID, "principalType": principalType}}
+	}
+	azureAD := map[string]interface{}{
+		"users":             []interface{}{map[string]interface{}{"id": "u-1"}},
+		"groups":            []interface{}{map[string]interface{}{"id": "g-1"}},
+		"servicePrincipals": []interface{}{map[string]interface{}{"id": "sp-1"}},
+		"groupMemberships": []interface{}{
+			map[string]interface{}{"groupId": "g-1", "memberId": "u-1", "memberType": "User"},
+		},
+		"dynamicGroupFindings": []interface{}{map[string]interface{}{"groupId": "g-1"}},
+	}
+	pim := map[string]interface{}{"eligible_assignments": []interface{}{}}
+	subData := map[string]interface{}{
+		"subscriptionRoleAssignments": []interface{}{armAssignment("u-1", "User"), armAssignment("sp-deleted", "ServicePrincipal")},
+	}
+	mgRBAC := []interface{}{map[string]interface{}{"principalId": "u-deleted", "scope": "/providers/Microsoft.Management/managementGroups/mg-1"}}
+
+	// Each phase streams its section as the collector does
+	writer.streamDirectory(azureAD, nil)
+	writer.streamSection("pim", "", pim)
+	writer.streamList("management_group_rbac", mgRBAC)
+	writer.streamSection("azure_resources", "sub-a", subData)
+	pim["arm_eligible_assignments"] = []interface{}{map[string]interface{}{"id": "arm-1"}}
+
+	o := &ConsolidatedOutput{
+		CollectionMetadata:  CollectionMetadata{SchemaVersion: ConsolidatedSchemaVersion, TenantID: "tenant-1"},
+		AzureAD:             azureAD,
+		PIM:                 pim,
+		ManagementGroups:    []interface{}{},
+		ManagementGroupRBAC: mgRBAC,
+		AzureResources:      map[string]interface{}{"sub-a": subData},
+		ResourceLocks:       []interface{}{},
+	}
+	orphaned, checked := markOrphanedAssignments(cfg.NewLogger(), o)
+	require.True(t, checked)
+	require.Equal(t, 2, orphaned)
+	o.Normalize()
+	o.PIM["eligible_activations"] = buildEligibleActivations(o)
+	evaluateFindingRules(o, nil)
+
+	// The JSON output would hold the sections as they are now
+	raw, err := json.Marshal(o)
+	require.NoError(t, err)
+	var want map[string]interface{}
+	require.NoError(t, json.Unmarshal(raw, &want))
+	assert.Contains(t, string(raw), `"memberType":"#microsoft.graph.user"`)
+
+	require.NoError(t, writer.finish(o))
+	assert.Empty(t, o.AzureAD, "sections are released once written")
+	assert.Empty(t, o.PIM)
+	assert.Empty(t, o.AzureResources)
+	assert.Empty(t, o.ManagementGroupRBAC)
+
+	// The records as written, without the normalization a dump gets on load
+	loaded, err := loadNDJSONDump(writer.path, dir)
+	require.NoError(t, err)
+	raw, err = json.Marshal(loaded)
+	require.NoError(t, err)
+	var got map[string]interface{}
+	require.NoError(t, json.Unmarshal(raw, &got))
+
+	// Sections with no objects have no records
+	var dropEmpty func(value interface{}) interface{}
+	dropEmpty = func(value interface{}) interface{} {
+		section, ok := value.(map[string]interface{})
+		if !ok {
+			return value
+		}
+		for key, v := range section {
+			if list, ok := v.([]interface{}); ok && len(list) == 0 {
+				delete(section, key)
+			} else {
+				section[key] = dropEmpty(v)
+			}
+		}
+		return section
+	}
+	for _, name := range []string{"azure_ad", "pim", "azure_resources", "management_group_rbac"} {
+		assert.Equal(t, dropEmpty(want[name]), got[name], "streamed %s records match the JSON output", name)
+	}
+}
@@ -0,0 +1,212 @@
+package iam
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"github.com/praetorian-inc/nebula/internal/message"
+	"github.com/praetorian-inc/nebula/pkg/graph"
+)
+
+// Import modes for the Neo4j importer. Nodes and relationships are always
+// MERGEd, so every mode is safe to run against a populated graph; the modes
+// only differ in what is removed first.
+const (
+	importModeAppend  = "append"
+	importModeReplace = "replace"
+	importModeClear   = "clear"
+)
+
+// runIdsSetClause records the import run on a node or relationship without
+// duplicating the ID when the same run is imported twice
+const runIdsSetClause = `%[1]s.runIds = CASE
+			WHEN $runId IN coalesce(%[1]s.runIds, []) THEN %[1]s.runIds
+			ELSE coalesce(%[1]s.runIds, []) + $runId
+		END,
+		%[1]s.lastRunId = $runId`
+
+// replaceBatchSize bounds how many entities each delete transaction touches so
+// replacing a large tenant does not exhaust the Neo4j heap
+const replaceBatchSize = 10000
+
+// resolveImportMode validates the mutually exclusive import flags. Append is
+// the default when none is set.
+func resolveImportMode(clearDB, appendMode, replace bool) (string, error) {
+	selected := 0
+	for _, set := range []bool{clearDB, appendMode, replace} {
+		if set {
+			selected++
+		}
+	}
+	if selected > 1 {
+		return "", fmt.Errorf("--clear-db, --append and --replace are mutually exclusive")
+	}
+
+	switch {
+	case clearDB:
+		return importModeClear, nil
+	case replace:
+		return importModeReplace, nil
+	default:
+		return importModeAppend, nil
+	}
+}
+
+// resolveRunID returns the run ID to stamp on imported data. Defaulting to the
+// tenant ID means re-importing a tenant with --replace swaps out that tenant's
+// previous import and leaves other tenants in the graph untouched.
+func resolveRunID(explicit, tenantID string) string {
+	if explicit != "" {
+		return explicit
+	}
+	if tenantID != "" {
+		return tenantID
+	}
+	return "default"
+}
+
+// importModeAction describes what an import does to the nodes already in the
+// graph, to complete the write confirmation
+func importModeAction(mode, runID string) string {
+	switch mode {
+	case importModeClear:
+		return "delete all of them before importing"
+	case importModeReplace:
+		return fmt.Sprintf("remove those imported by run %s and merge this import into the rest", runID)
+	default:
+		return "merge this import into them"
+	}
+}
+
+// confirmImport asks the operator to confirm importing into a graph that
+// already holds nodes, unless --yes was passed
+func (l *Neo4jImporterLink) confirmImport(assumeYes bool) error {
+	if assumeYes {
+		return nil
+	}
+
+	ctx := context.Background()
+	session := l.driver.NewSession(ctx, neo4j.SessionConfig{})
+	defer session.Close(ctx)
+
+	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		result, err := tx.Run(ctx, graph.CountNodesQuery, nil)
+		if err != nil {
+			return nil, err
+		}
+		record, err := result.Single(ctx)
+		if err != nil {
+			return nil, err
+		}
+		count, _ := record.Get("count")
+		return count, nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to count existing nodes: %v", err)
+	}
+
+	existing, _ := l.convertToInt64(result)
+	return graph.ConfirmWrite(l.neo4jURL, existing, importModeAction(l.importMode, l.runID), assumeYes)
+}
+
+// removePreviousRun deletes nodes and relationships that only the given run
+// imported and drops the run ID from entities shared with other runs
+func (l *Neo4jImporterLink) removePreviousRun() error {
+	message.Info("🗑️  Removing data from previous import run %s...", l.runID)
+
+	ctx := context.Background()
+	session := l.driver.NewSession(ctx, neo4j.SessionConfig{})
+	defer session.Close(ctx)
+
+	// Relationships go first so shared nodes keep only the edges other runs created
+	queries := []struct {
+		name   string
+		cypher string
+	}{
+		{"relationships", `
+			MATCH ()-[r]->()
+			WHERE $runId IN r.runIds
+			WITH r, size(r.runIds) > 1 AS shared LIMIT $batchSize
+			FOREACH (_ IN CASE WHEN shared THEN [1] ELSE [] END |
+				SET r.runIds = [id IN r.runIds WHERE id <> $runId])
+			FOREACH (_ IN CASE WHEN shared THEN [] ELSE [1] END | DELETE r)
+			RETURN count(*) AS processed`},
+		{"nodes", `
+			MATCH (n:Resource)
+			WHERE $runId IN n.runIds
+			WITH n, size(n.runIds) > 1 AS shared LIMIT $batchSize
+			FOREACH (_ IN CASE WHEN shared THEN [1] ELSE [] END |
+				SET n.runIds = [id IN n.runIds WHERE id <> $runId])
+			FOREACH (_ IN CASE WHEN shared THEN [] ELSE [1] END | DETACH DELETE n)
+			RETURN count(*) AS processed`},
+	}
+
+	params := map[string]interface{}{"runId": l.runID, "batchSize": replaceBatchSize}
+	for _, query := range queries {
+		total := 0
+		for {
+			result, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+				result, err := tx.Run(ctx, query.cypher, params)
+				if err != nil {
+					return nil, err
+				}
+				record, err := result.Single(ctx)
+				if err != nil {
+					return nil, err
+				}
+				processed, _ := record.Get("processed")
+				return processed, nil
+			})
+			if err != nil {
+				return fmt.Errorf("failed to remove previous run %s: %v", query.name, err)
+			}
+
+			processed, _ := l.convertToInt64(result)
+			total += int(processed)
+			if processed < replaceBatchSize {
+				break
+			}
+		}
+		l.Logger.Info("Removed previous import run data", "run_id", l.runID, "type", query.name, "count", total)
+	}
+
+	message.Info("✅ Previous import run removed")
+	return nil
+}
+
+// stampRunOnRelationships records the run on every relationship between nodes
+// this run imported. Edges are created by dozens of queries, so tagging them in
+// one pass afterwards keeps the run bookkeeping out of each of them.
+func (l *Neo4jImporterLink) stampRunOnRelationships() {
+	ctx := context.Background()
+	session := l.driver.NewSession(ctx, neo4j.SessionConfig{})
+	defer session.Close(ctx)
+
+	cypher := fmt.Sprintf(`
+		MATCH (a:Resource)-[r]->(b:Resource)
+		WHERE $runId IN a.runIds AND $runId IN b.runIds
+		SET %s
+		RETURN count(r) AS stamped
+	`, fmt.Sprintf(runIdsSetClause, "r"))
+
+	result, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		result, err := tx.Run(ctx, cypher, map[string]interface{}{"runId": l.runID})
+		if err != nil {
+			return nil, err
+		}
+		record, err := result.Single(ctx)
+		if err != nil {
+			return nil, err
+		}
+		stamped, _ := record.Get("stamped")
+		return stamped, nil
+	})
+	if err != nil {
+		l.Logger.Error("Failed to stamp run ID on relationships", "run_id", l.runID, "error", err)
+		return
+	}
+
+	stamped, _ := l.convertToInt64(result)
+	l.Logger.Info("Stamped run ID on relationships", "run_id", l.runID, "count", stamped)
+}
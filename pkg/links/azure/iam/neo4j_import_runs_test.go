@@ -0,0 +1,49 @@
+package iam
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveImportMode(t *testing.T) {
+	tests := []struct {
+		name                      string
+		clearDB, appendMode, repl bool
+		want                      string
+		wantErr                   bool
+	}{
+		{name: "defaults to append", want: importModeAppend},
+		{name: "explicit append", appendMode: true, want: importModeAppend},
+		{name: "replace", repl: true, want: importModeReplace},
+		{name: "clear", clearDB: true, want: importModeClear},
+		{name: "append and replace conflict", appendMode: true, repl: true, wantErr: true},
+		{name: "clear and append conflict", clearDB: true, appendMode: true, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mode, err := resolveImportMode(tt.clearDB, tt.appendMode, tt.repl)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, mode)
+		})
+	}
+}
+
+func TestResolveRunID(t *testing.T) {
+	assert.Equal(t, "nightly", resolveRunID("nightly", "tenant-1"))
+	assert.Equal(t, "tenant-1", resolveRunID("", "tenant-1"), "re-importing a tenant replaces that tenant's previous run")
+	assert.Equal(t, "default", resolveRunID("", ""))
+}
+
+func TestRunIdsSetClauseIsIdempotent(t *testing.T) {
+	clause := fmt.Sprintf(runIdsSetClause, "r")
+	assert.Contains(t, clause, "WHEN $runId IN coalesce(r.runIds, []) THEN r.runIds")
+	assert.Contains(t, clause, "r.lastRunId = $runId")
+}
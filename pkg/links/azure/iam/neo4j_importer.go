@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
 	"time"
 
@@ -27,17 +28,26 @@ type Neo4jImporterLink struct {
 	neo4jUser          string
 	neo4jPassword      string
 	roleDefinitionsMap map[string]interface{} // Cache role definitions for permission expansion
+	importMode         string                 // append, replace or clear
+	runID              string                 // Stamped on imported nodes and relationships
 }
 
 func NewNeo4jImporterLink(configs ...cfg.Config) chain.Link {
-	l := &Neo4jImporterLink{}
+	l := newNeo4jImporter()
 	l.Base = chain.NewBase(l, configs...)
-	l.nodeCounts = make(map[string]int)
-	l.edgeCounts = make(map[string]int)
-	l.roleDefinitionsMap = make(map[string]interface{})
 	return l
 }
 
+// newNeo4jImporter returns an importer with empty counters and caches, for
+// the links that import consolidated data to set their Base on
+func newNeo4jImporter() *Neo4jImporterLink {
+	return &Neo4jImporterLink{
+		nodeCounts:         make(map[string]int),
+		edgeCounts:         make(map[string]int),
+		roleDefinitionsMap: make(map[string]interface{}),
+	}
+}
+
 func (l *Neo4jImporterLink) Params() []cfg.Param {
 	return []cfg.Param{
 		options.AzureNeo4jURL(),
@@ -45,6 +55,10 @@ func (l *Neo4jImporterLink) Params() []cfg.Param {
 		options.AzureNeo4jPassword(),
 		options.AzureDataFile(),
 		options.AzureClearDB(),
+		options.AzureImportAppend(),
+		options.AzureImportReplace(),
+		options.AzureImportRunID(),
+		options.Neo4jAssumeYes(),
 	}
 }
 
@@ -53,7 +67,9 @@ func (l *Neo4jImporterLink) Process(input interface{}) error {
 	l.neo4jUser, _ = cfg.As[string](l.Arg("neo4j-user"))
 	l.neo4jPassword, _ = cfg.As[string](l.Arg("neo4j-password"))
 	dataFile, _ := cfg.As[string](l.Arg("data-file"))
-	clearDB, _ := cfg.As[bool](l.Arg("clear-db"))
+	if err := l.readImportMode(); err != nil {
+		return err
+	}
 
 	l.Logger.Info("Starting real Neo4j import", "neo4j_url", l.neo4jURL, "data_file", dataFile)
 	message.Info("📊 Azure Security Graph - Neo4j Import Tool")
@@ -65,38 +81,85 @@ func (l *Neo4jImporterLink) Process(input interface{}) error {
 		return fmt.Errorf("failed to load data: %v", err)
 	}
 
+	summary, err := l.importConsolidatedData()
+	if err != nil {
+		return err
+	}
+	l.Send(summary)
+	return nil
+}
+
+// readImportMode sets the import mode from --clear-db, --append and --replace
+func (l *Neo4jImporterLink) readImportMode() error {
+	clearDB, _ := cfg.As[bool](l.Arg("clear-db"))
+	appendMode, _ := cfg.As[bool](l.Arg("append"))
+	replace, _ := cfg.As[bool](l.Arg("replace"))
+
+	importMode, err := resolveImportMode(clearDB, appendMode, replace)
+	if err != nil {
+		return err
+	}
+	l.importMode = importMode
+	return nil
+}
+
+// importConsolidatedData writes the loaded consolidated data into Neo4j in
+// the import mode and returns the import summary
+func (l *Neo4jImporterLink) importConsolidatedData() (map[string]interface{}, error) {
+	runID, _ := cfg.As[string](l.Arg("run-id"))
+	assumeYes, _ := cfg.As[bool](l.Arg("yes"))
+
+	metadata := l.getMapValue(l.consolidatedData, "collection_metadata")
+	l.runID = resolveRunID(runID, l.getStringValue(metadata, "tenant_id"))
+	message.Info("Import mode: %s (run ID %s)", l.importMode, l.runID)
+
 	// Step 2: Connect to Neo4j with real driver
 	if err := l.connectToNeo4j(); err != nil {
-		return fmt.Errorf("failed to connect to Neo4j: %v", err)
+		return nil, fmt.Errorf("failed to connect to Neo4j: %v", err)
 	}
 	defer l.driver.Close(context.Background())
 
-	// Step 3: Clear database if requested
-	if clearDB {
+	if err := l.confirmImport(assumeYes); err != nil {
+		return nil, err
+	}
+
+	// Step 3: Clear the database or the previous import of this run if requested
+	switch l.importMode {
+	case importModeClear:
 		if err := l.clearDatabase(); err != nil {
-			return fmt.Errorf("failed to clear database: %v", err)
+			return nil, fmt.Errorf("failed to clear database: %v", err)
+		}
+	case importModeReplace:
+		if err := l.removePreviousRun(); err != nil {
+			return nil, err
 		}
 	}
 
 	// Step 4: Create constraints
 	if err := l.createConstraints(); err != nil {
-		return fmt.Errorf("failed to create constraints: %v", err)
+		return nil, fmt.Errorf("failed to create constraints: %v", err)
 	}
 
 	// Step 5: Build roleDefinitions cache for permission expansion
 	if err := l.buildRoleDefinitionsCache(); err != nil {
-		return fmt.Errorf("failed to build roleDefinitions cache: %v", err)
+		return nil, fmt.Errorf("failed to build roleDefinitions cache: %v", err)
 	}
 
 	// Step 6: Create all Resource nodes
 	if err := l.createAllResourceNodes(); err != nil {
-		return fmt.Errorf("failed to create nodes: %v", err)
+		return nil, fmt.Errorf("failed to create nodes: %v", err)
 	}
 
 	// Step 9: Create CONTAINS edges (hierarchy)
 	message.Info("🔗 Phase 2a: Creating CONTAINS edges (hierarchy)")
 	if err := l.createContainsEdges(); err != nil {
-		return fmt.Errorf("failed to create CONTAINS edges: %v", err)
+		return nil, fmt.Errorf("failed to create CONTAINS edges: %v", err)
+	}
+
+	// Step 9.5: Create USES_IDENTITY edges (resources to their managed identities)
+	message.Info("🔗 Creating USES_IDENTITY edges (attached managed identities)")
+	if err := l.createUsesIdentityEdges(); err != nil {
+		l.Logger.Error("Failed to create USES_IDENTITY edges", "error", err)
 	}
 
 	// Step 10: Create HAS_PERMISSION edges (permissions)
@@ -135,13 +198,24 @@ func (l *Neo4jImporterLink) Process(input interface{}) error {
 		l.Logger.Warn("No CAN_ESCALATE edges were created")
 	}
 
+	// Step 15.5: Create CAN_ESCALATE edges for password reset roles scoped to administrative units
+	if err := l.createAdministrativeUnitEscalationEdges(); err != nil {
+		l.Logger.Error("Failed to create administrative unit CAN_ESCALATE edges", "error", err)
+	}
+
+	// Step 15.6: Create CAN_ACTIVATE edges for PIM eligible directory roles
+	if err := l.createPIMActivationEdges(); err != nil {
+		l.Logger.Error("Failed to create PIM CAN_ACTIVATE edges", "error", err)
+	}
+
+	// Tag this run's relationships so a later --replace can find them
+	l.stampRunOnRelationships()
+
 	// Step 16: Generate summary
 	summary := l.generateImportSummary()
 	message.Info("🎉 Security graph creation completed successfully!")
 	message.Info("📈 Ready for Azure security analysis and attack path discovery")
-
-	l.Send(summary)
-	return nil
+	return summary, nil
 }
 
 // connectToNeo4j establishes real connection to Neo4j - exactly like AzureDumperConsolidated
@@ -331,15 +405,64 @@ func (l *Neo4jImporterLink) loadConsolidatedData(dataFile string) error {
 	}
 
 	message.Info("Successfully loaded consolidated Azure IAM data")
+	return l.prepareConsolidatedData(filepath.Dir(dataFile))
+}
 
+// prepareConsolidatedData checks the schema version of the loaded data and
+// merges in the subscription shards and NDJSON records it lists, resolving
+// them as written and then relative to dumpDir
+func (l *Neo4jImporterLink) prepareConsolidatedData(dumpDir string) error {
 	// Show data summary
 	metadata := l.getMapValue(l.consolidatedData, "collection_metadata")
+	if err := checkSchemaVersion(l.getStringValue(metadata, "schema_version")); err != nil {
+		return err
+	}
+	sampled, _ := metadata["sampled"].(bool)
+	warnIfSampled(sampled, metadata["sample_size"])
+	if err := l.loadSubscriptionShards(metadata, dumpDir); err != nil {
+		return err
+	}
+	if ndjsonFile := l.getStringValue(metadata, "ndjson_file"); ndjsonFile != "" {
+		sections, err := loadNDJSONDump(ndjsonFile, dumpDir)
+		if err != nil {
+			return fmt.Errorf("failed to load NDJSON file %s: %v", ndjsonFile, err)
+		}
+		for key, section := range sections {
+			l.consolidatedData[key] = section
+		}
+		message.Info("Loaded collected data from %s", ndjsonFile)
+	}
 	message.Info("Tenant ID: %s", l.getStringValue(metadata, "tenant_id"))
 	message.Info("Collection timestamp: %s", l.getStringValue(metadata, "collection_timestamp"))
 
 	return nil
 }
 
+// loadSubscriptionShards merges the subscription files of a
+// --split-subscriptions dump into azure_resources
+func (l *Neo4jImporterLink) loadSubscriptionShards(metadata map[string]interface{}, dumpDir string) error {
+	listed, ok := metadata["subscription_shards"]
+	if !ok {
+		return nil
+	}
+	raw, err := json.Marshal(listed)
+	if err != nil {
+		return err
+	}
+	var shards []SubscriptionShard
+	if err := json.Unmarshal(raw, &shards); err != nil {
+		return fmt.Errorf("invalid subscription_shards: %v", err)
+	}
+
+	azureResources := l.getMapValue(l.consolidatedData, "azure_resources")
+	l.consolidatedData["azure_resources"] = azureResources
+	if err := loadSubscriptionShards(shards, dumpDir, azureResources); err != nil {
+		return err
+	}
+	message.Info("Loaded %d subscription shards", len(shards))
+	return nil
+}
+
 // createAllResourceNodes creates all resources as unified Resource nodes
 func (l *Neo4jImporterLink) createAllResourceNodes() error {
 	message.Info("=== Creating All Resource Nodes (Unified Model) ===")
@@ -408,6 +531,8 @@ func (l *Neo4jImporterLink) generateImportSummary() map[string]interface{} {
 			"import_timestamp":    time.Now().UTC().Format("2006-01-02T15:04:05Z"),
 			"status":              "real_success",
 			"database_url":        l.neo4jURL,
+			"import_mode":         l.importMode,
+			"run_id":              l.runID,
 		},
 	}
 }
@@ -734,11 +859,12 @@ func (l *Neo4jImporterLink) createSystemAssignedManagedIdentityResources() int {
 			mi.location = resource.location,
 			mi.resourceGroup = resource.resourceGroup,
 			mi.metadata = '{"assignmentType":"System-Assigned","synthetic":true}'
+		SET `+fmt.Sprintf(runIdsSetClause, "mi")+`
 		RETURN count(mi) as created
 	`
 
 	result, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
-		result, err := tx.Run(ctx, cypher, nil)
+		result, err := tx.Run(ctx, cypher, map[string]interface{}{"runId": l.runID})
 		if err != nil {
 			return nil, err
 		}
@@ -1201,10 +1327,11 @@ func (l *Neo4jImporterLink) createResourceNodesBatchChunk(session neo4j.SessionW
 			r.credentialSummary_keyCredentials = resource.credentialSummary_keyCredentials,
 			r.department = resource.department,
 			r.jobTitle = resource.jobTitle
-	`, labelString)
+		SET %s
+	`, labelString, fmt.Sprintf(runIdsSetClause, "r"))
 
 	result, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
-		result, err := tx.Run(ctx, cypher, map[string]interface{}{"resources": resourceNodes})
+		result, err := tx.Run(ctx, cypher, map[string]interface{}{"resources": resourceNodes, "runId": l.runID})
 		if err != nil {
 			return nil, err
 		}
@@ -1692,7 +1819,8 @@ func (l *Neo4jImporterLink) createResourceGroupToResourceContains(session neo4j.
 		AND toLower(resource.resourceType) <> "microsoft.resources/resourcegroups"
 		AND toLower(resource.resourceType) <> "microsoft.directoryservices/tenant"
 		AND resource.resourceGroup IS NOT NULL
-		AND resource.resourceGroup = rg.displayName
+		AND toLower(resource.resourceGroup) = rg.resourceGroupName
+		AND toLower(resource.subscriptionId) = toLower(rg.subscriptionId)
 		MERGE (rg)-[:CONTAINS]->(resource)
 	`
 
@@ -2099,6 +2227,7 @@ func (l *Neo4jImporterLink) createPIMEnrichedPermissionEdges() bool {
 	pimDroppedNoPrincipal := 0
 	pimDroppedNoRole := 0
 	pimDroppedMissingFields := 0
+	pimSkippedAdministrativeUnit := 0
 
 	for _, assignment := range eligiblePIMAssignments {
 		assignmentMap, ok := assignment.(map[string]interface{})
@@ -2164,6 +2293,13 @@ func (l *Neo4jImporterLink) createPIMEnrichedPermissionEdges() bool {
 			continue
 		}
 
+		// A role scoped to an administrative unit is not a tenant permission;
+		// its escalation edges are limited to the unit's members
+		if strings.HasPrefix(l.getStringValue(assignmentMap, "directoryScopeId"), administrativeUnitScopePrefix) {
+			pimSkippedAdministrativeUnit++
+			continue
+		}
+
 		// Try to mark existing HAS_PERMISSION edge as PIM (if user is currently activated or permanent)
 		cypherUpdate := `
 		MATCH (principal:Resource {id: $principalId})-[r:HAS_PERMISSION]->(tenant:Resource)
@@ -2264,6 +2400,9 @@ func (l *Neo4jImporterLink) createPIMEnrichedPermissionEdges() bool {
 			"dropped_no_role", pimDroppedNoRole,
 			"dropped_missing_fields", pimDroppedMissingFields)
 	}
+	if pimSkippedAdministrativeUnit > 0 {
+		l.Logger.Info("Skipped eligible PIM assignments scoped to administrative units", "count", pimSkippedAdministrativeUnit)
+	}
 	message.Info("Processed eligible PIM: %d edges marked, %d edges created (dropped: %d)", pimEdgesMarked, pimEdgesCreated, totalPIMDropped)
 
 	// Mark remaining Entra ID HAS_PERMISSION edges as "Permanent" (not PIM-eligible)
@@ -3843,6 +3982,7 @@ func (l *Neo4jImporterLink) createApplicationOwnershipEdges() error {
 		}
 		totalEdges += count
 		l.edgeCounts["OWNS"] += count // Add to existing OWNS count
+		l.edgeCounts["CAN_MANAGE"] += count
 	}
 
 	// Process service principal ownership - direct ownership relationships
@@ -4014,6 +4154,11 @@ func (l *Neo4jImporterLink) createGroupOwnershipDirectEdges(groupOwnership []int
 		MERGE (source)-[r:OWNS]->(target)
 		SET r.source = edge.source,
 		    r.createdAt = edge.createdAt
+		// Owners manage membership, so ownership is also control over who holds the group's roles
+		MERGE (source)-[m:CAN_MANAGE]->(target)
+		SET m.source = edge.source,
+		    m.capability = CASE WHEN target.membershipRule IS NOT NULL AND target.membershipRule <> "" THEN "EditMembershipRule" ELSE "AddMembers" END,
+		    m.createdAt = edge.createdAt
 		RETURN count(r) as created`
 
 		result, err := session.Run(ctx, query, map[string]interface{}{"edges": batch})
@@ -4230,6 +4375,192 @@ func (l *Neo4jImporterLink) createValidatedEscalationEdges() bool {
 	return totalCreated > 0
 }
 
+// createAdministrativeUnitEscalationEdges creates PasswordResetViaAdministrativeUnit
+// CAN_ESCALATE edges from each holder of a password reset role scoped to an
+// administrative unit, standing or PIM eligible, to the unit's user members.
+// Unlike the tenant-wide password reset edges they never reach users outside
+// the unit. Members holding a directory role are left out, as Entra ID refuses
+// their password resets by a unit-scoped admin.
+func (l *Neo4jImporterLink) createAdministrativeUnitEscalationEdges() error {
+	o := &ConsolidatedOutput{
+		AzureAD: l.getMapValue(l.consolidatedData, "azure_ad"),
+		PIM:     l.getMapValue(l.consolidatedData, "pim"),
+	}
+
+	var edges []map[string]interface{}
+	for _, reset := range administrativeUnitResets(o) {
+		for _, user := range reset.users {
+			userID := l.getStringValue(user, "memberId")
+			if userID == "" || strings.EqualFold(userID, reset.principalID) {
+				continue
+			}
+			edges = append(edges, map[string]interface{}{
+				"sourceId":     l.normalizeResourceId(reset.principalID),
+				"targetId":     l.normalizeResourceId(userID),
+				"roleName":     reset.roleName,
+				"unitId":       reset.unitID,
+				"unitName":     fmt.Sprint(reset.unitName),
+				"assignmentId": reset.assignmentID,
+				"eligible":     reset.eligible,
+			})
+		}
+	}
+	if len(edges) == 0 {
+		l.Logger.Info("No administrative unit scoped password reset roles to process")
+		return nil
+	}
+
+	ctx := context.Background()
+	session := l.driver.NewSession(ctx, neo4j.SessionConfig{DatabaseName: ""})
+	defer session.Close(ctx)
+
+	batchSize := 500
+	totalCreated := 0
+	for i := 0; i < len(edges); i += batchSize {
+		end := i + batchSize
+		if end > len(edges) {
+			end = len(edges)
+		}
+
+		query := `
+		UNWIND $edges AS edge
+		MATCH (source:Resource {id: edge.sourceId})
+		MATCH (target:Resource {id: edge.targetId})
+		WHERE toLower(target.resourceType) = "microsoft.directoryservices/users"
+		  AND NOT EXISTS {
+		    (target)-[admin_perm:HAS_PERMISSION]->(:Resource)
+		    WHERE admin_perm.templateId IS NOT NULL
+		  }
+		MERGE (source)-[r:CAN_ESCALATE {method: "PasswordResetViaAdministrativeUnit", administrativeUnitId: edge.unitId}]->(target)
+		ON CREATE SET r.condition = "Can reset passwords of users without directory roles that are members of administrative unit " + edge.unitName,
+		    r.category = "DirectoryRole",
+		    r.targetRole = edge.roleName,
+		    r.administrativeUnitName = edge.unitName,
+		    r.assignmentId = edge.assignmentId,
+		    r.eligible = edge.eligible
+		RETURN count(r) as created`
+
+		result, err := session.Run(ctx, query, map[string]interface{}{"edges": edges[i:end]})
+		if err != nil {
+			return fmt.Errorf("failed to create administrative unit escalation edges batch: %w", err)
+		}
+		if result.Next(ctx) {
+			if count, ok := result.Record().Get("created"); ok {
+				if c, ok := count.(int64); ok {
+					totalCreated += int(c)
+				}
+			}
+		}
+		if err := result.Err(); err != nil {
+			return fmt.Errorf("error processing administrative unit escalation edges batch: %w", err)
+		}
+	}
+
+	l.edgeCounts["CAN_ESCALATE"] += totalCreated
+	l.Logger.Info("Created administrative unit CAN_ESCALATE edges", "count", totalCreated)
+	return nil
+}
+
+// createPIMActivationEdges creates a CAN_ACTIVATE edge from each principal
+// eligible for a tenant-wide directory role to the tenant, carrying what the
+// role's PIM policy requires to activate it. activationFriction weighs those
+// requirements so path queries can prefer eligibility that activates with the
+// least resistance. Dumps written before schema 1.34 have no
+// eligible_activations section; their records are derived from the eligible
+// assignments and policies in the dump.
+func (l *Neo4jImporterLink) createPIMActivationEdges() error {
+	o := &ConsolidatedOutput{
+		AzureAD: l.getMapValue(l.consolidatedData, "azure_ad"),
+		PIM:     l.getMapValue(l.consolidatedData, "pim"),
+	}
+	activations := l.getArrayValue(o.PIM, "eligible_activations")
+	if len(activations) == 0 {
+		activations = buildEligibleActivations(o)
+	}
+
+	var edges []map[string]interface{}
+	for _, activation := range activations {
+		a, ok := activation.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		principalID := l.getStringValue(a, "principalId")
+		templateID := l.getStringValue(a, "roleTemplateId")
+		// Unit-scoped eligibility does not activate a tenant-wide role
+		if principalID == "" || templateID == "" || strings.HasPrefix(l.getStringValue(a, "directoryScopeId"), administrativeUnitScopePrefix) {
+			continue
+		}
+		edges = append(edges, map[string]interface{}{
+			"sourceId":                      l.normalizeResourceId(principalID),
+			"roleTemplateId":                templateID,
+			"roleName":                      l.getStringValue(a, "roleName"),
+			"policyCollected":               a["policyCollected"],
+			"approvalRequired":              a["approvalRequired"],
+			"mfaRequired":                   a["mfaRequired"],
+			"authenticationContextRequired": a["authenticationContextRequired"],
+			"justificationRequired":         a["justificationRequired"],
+			"ticketingRequired":             a["ticketingRequired"],
+			"maximumActivationDuration":     l.getStringValue(a, "maximumActivationDuration"),
+			"activationFriction":            a["activationFriction"],
+			"activationFrictionLevel":       l.getStringValue(a, "activationFrictionLevel"),
+		})
+	}
+	if len(edges) == 0 {
+		l.Logger.Info("No PIM eligible directory role assignments to process")
+		return nil
+	}
+
+	ctx := context.Background()
+	session := l.driver.NewSession(ctx, neo4j.SessionConfig{DatabaseName: ""})
+	defer session.Close(ctx)
+
+	batchSize := 500
+	totalCreated := 0
+	for i := 0; i < len(edges); i += batchSize {
+		end := i + batchSize
+		if end > len(edges) {
+			end = len(edges)
+		}
+
+		query := `
+		UNWIND $edges AS edge
+		MATCH (source:Resource {id: edge.sourceId})
+		MATCH (tenant:Resource)
+		WHERE toLower(tenant.resourceType) = "microsoft.directoryservices/tenant"
+		MERGE (source)-[r:CAN_ACTIVATE {roleTemplateId: edge.roleTemplateId}]->(tenant)
+		SET r.roleName = edge.roleName,
+		    r.policyCollected = edge.policyCollected,
+		    r.approvalRequired = edge.approvalRequired,
+		    r.mfaRequired = edge.mfaRequired,
+		    r.authenticationContextRequired = edge.authenticationContextRequired,
+		    r.justificationRequired = edge.justificationRequired,
+		    r.ticketingRequired = edge.ticketingRequired,
+		    r.maximumActivationDuration = edge.maximumActivationDuration,
+		    r.activationFriction = edge.activationFriction,
+		    r.activationFrictionLevel = edge.activationFrictionLevel
+		RETURN count(r) as created`
+
+		result, err := session.Run(ctx, query, map[string]interface{}{"edges": edges[i:end]})
+		if err != nil {
+			return fmt.Errorf("failed to create PIM activation edges batch: %w", err)
+		}
+		if result.Next(ctx) {
+			if count, ok := result.Record().Get("created"); ok {
+				if c, ok := count.(int64); ok {
+					totalCreated += int(c)
+				}
+			}
+		}
+		if err := result.Err(); err != nil {
+			return fmt.Errorf("error processing PIM activation edges batch: %w", err)
+		}
+	}
+
+	l.edgeCounts["CAN_ACTIVATE"] += totalCreated
+	l.Logger.Info("Created PIM CAN_ACTIVATE edges", "count", totalCreated)
+	return nil
+}
+
 // wrapQueryWithBatching transforms a CAN_ESCALATE query to use CALL { } IN TRANSACTIONS
 // for defensive batching. All queries follow the pattern:
 //
@@ -0,0 +1,78 @@
+package iam
+
+import (
+	"context"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"github.com/praetorian-inc/nebula/internal/message"
+)
+
+// usesSystemAssignedIdentityQuery links each resource to the synthetic node of
+// its system-assigned managed identity
+const usesSystemAssignedIdentityQuery = `
+	MATCH (resource:Resource)
+	WHERE resource.identityPrincipalId IS NOT NULL
+	  AND toLower(resource.identityType) CONTAINS "systemassigned"
+	MATCH (mi:Resource {id: "/virtual/managedidentity/system/" + resource.identityPrincipalId})
+	MERGE (resource)-[r:USES_IDENTITY {assignmentType: "SystemAssigned"}]->(mi)
+	RETURN count(r) AS created
+`
+
+// usesUserAssignedIdentityQuery links each resource to the user-assigned
+// managed identities attached to it. One identity can be attached to many
+// resources, so it ties otherwise unrelated resources together.
+const usesUserAssignedIdentityQuery = `
+	MATCH (resource:Resource)
+	WHERE resource.userAssignedIdentities IS NOT NULL
+	UNWIND resource.userAssignedIdentities AS miResourceId
+	MATCH (mi:Resource {id: miResourceId})
+	WHERE toLower(mi.resourceType) = "microsoft.managedidentity/userassignedidentities"
+	MERGE (resource)-[r:USES_IDENTITY {assignmentType: "UserAssigned"}]->(mi)
+	RETURN count(r) AS created
+`
+
+// createUsesIdentityEdges creates USES_IDENTITY edges from Azure resources to
+// the managed identities attached to them. Together with MI CONTAINS SP and
+// the SP's HAS_PERMISSION edges they answer what compromising a resource
+// reaches: anyone who can run code on the resource can request its identities'
+// tokens from IMDS.
+func (l *Neo4jImporterLink) createUsesIdentityEdges() error {
+	message.Info("=== Creating USES_IDENTITY Edges (Attached Managed Identities) ===")
+
+	ctx := context.Background()
+	session := l.driver.NewSession(ctx, neo4j.SessionConfig{})
+	defer session.Close(ctx)
+
+	totalEdgesCreated := 0
+	for _, query := range []struct {
+		name   string
+		cypher string
+	}{
+		{"system-assigned", usesSystemAssignedIdentityQuery},
+		{"user-assigned", usesUserAssignedIdentityQuery},
+	} {
+		result, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+			result, err := tx.Run(ctx, query.cypher, nil)
+			if err != nil {
+				return nil, err
+			}
+			summary, err := result.Consume(ctx)
+			if err != nil {
+				return nil, err
+			}
+			return summary.Counters().RelationshipsCreated(), nil
+		})
+		if err != nil {
+			return err
+		}
+
+		if edgesCreated, ok := l.convertToInt64(result); ok && edgesCreated > 0 {
+			totalEdgesCreated += int(edgesCreated)
+			message.Info("Created %d resource → %s managed identity USES_IDENTITY edges", edgesCreated, query.name)
+		}
+	}
+
+	l.edgeCounts["USES_IDENTITY"] = totalEdgesCreated
+	message.Info("Created %d USES_IDENTITY edges in total", totalEdgesCreated)
+	return nil
+}
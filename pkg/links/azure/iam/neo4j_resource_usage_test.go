@@ -0,0 +1,16 @@
+package iam
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUsesIdentityQueries(t *testing.T) {
+	// A resource can have both kinds of identity; the assignment type keeps them apart
+	assert.Contains(t, usesSystemAssignedIdentityQuery, `MERGE (resource)-[r:USES_IDENTITY {assignmentType: "SystemAssigned"}]->(mi)`)
+	assert.Contains(t, usesUserAssignedIdentityQuery, `MERGE (resource)-[r:USES_IDENTITY {assignmentType: "UserAssigned"}]->(mi)`)
+
+	// The system-assigned target is the synthetic node createSystemAssignedManagedIdentityResources creates
+	assert.Contains(t, usesSystemAssignedIdentityQuery, `{id: "/virtual/managedidentity/system/" + resource.identityPrincipalId}`)
+}
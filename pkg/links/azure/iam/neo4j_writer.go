@@ -0,0 +1,86 @@
+package iam
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/praetorian-inc/janus-framework/pkg/chain"
+	"github.com/praetorian-inc/janus-framework/pkg/chain/cfg"
+	"github.com/praetorian-inc/nebula/internal/message"
+	"github.com/praetorian-inc/nebula/pkg/links/options"
+)
+
+// Neo4jWriterLink imports the consolidated output a collector link sends it
+// straight into Neo4j, building the same graph as Neo4jImporterLink without
+// writing the collection to a file and reading it back first
+type Neo4jWriterLink struct {
+	*Neo4jImporterLink
+}
+
+func NewNeo4jWriterLink(configs ...cfg.Config) chain.Link {
+	l := &Neo4jWriterLink{Neo4jImporterLink: newNeo4jImporter()}
+	l.Base = chain.NewBase(l, configs...)
+	return l
+}
+
+func (l *Neo4jWriterLink) Params() []cfg.Param {
+	return []cfg.Param{
+		options.AzureNeo4jURL(),
+		options.AzureNeo4jUser(),
+		options.AzureNeo4jPassword(),
+		options.AzureClearDB(),
+		options.AzureImportAppend(),
+		options.AzureImportReplace(),
+		options.AzureImportRunID(),
+		options.Neo4jAssumeYes(),
+	}
+}
+
+func (l *Neo4jWriterLink) Process(input interface{}) error {
+	l.neo4jURL, _ = cfg.As[string](l.Arg("neo4j-url"))
+	l.neo4jUser, _ = cfg.As[string](l.Arg("neo4j-user"))
+	l.neo4jPassword, _ = cfg.As[string](l.Arg("neo4j-password"))
+	if err := l.readImportMode(); err != nil {
+		return err
+	}
+
+	data, err := consolidatedDataMap(input)
+	if err != nil {
+		return err
+	}
+	l.consolidatedData = data
+	l.Logger.Info("Writing collected data to Neo4j", "neo4j_url", l.neo4jURL)
+	message.Info("📊 Writing the collected Azure IAM data to Neo4j")
+
+	// Shards and NDJSON files the collector wrote are listed as it wrote them
+	if err := l.prepareConsolidatedData("."); err != nil {
+		return fmt.Errorf("failed to load data: %v", err)
+	}
+
+	summary, err := l.importConsolidatedData()
+	if err != nil {
+		return err
+	}
+	l.Send(summary)
+	return nil
+}
+
+// consolidatedDataMap turns the consolidated output sent by a collector into
+// the generic form the importer reads from a dump file, so both import the
+// same values: objects as maps, arrays as []interface{} and numbers as float64
+func consolidatedDataMap(input interface{}) (map[string]interface{}, error) {
+	switch input.(type) {
+	case *ConsolidatedOutput, ConsolidatedOutput, map[string]interface{}:
+	default:
+		return nil, fmt.Errorf("expected consolidated Azure IAM data, got %T", input)
+	}
+	raw, err := json.Marshal(input)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode consolidated data: %v", err)
+	}
+	var data map[string]interface{}
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return nil, fmt.Errorf("failed to decode consolidated data: %v", err)
+	}
+	return data, nil
+}
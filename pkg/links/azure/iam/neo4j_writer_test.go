@@ -0,0 +1,42 @@
+package iam
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConsolidatedDataMap(t *testing.T) {
+	output := &ConsolidatedOutput{
+		CollectionMetadata: CollectionMetadata{SchemaVersion: ConsolidatedSchemaVersion, TenantID: "tenant-1", SubscriptionsProcessed: 2},
+		AzureAD: map[string]interface{}{
+			"appRoleAssignments": []interface{}{
+				map[string]interface{}{"id": "assignment-1", "directions": []string{"assigned_from", "assigned_to"}},
+			},
+		},
+	}
+
+	data, err := consolidatedDataMap(output)
+	require.NoError(t, err)
+
+	metadata := data["collection_metadata"].(map[string]interface{})
+	assert.Equal(t, "tenant-1", metadata["tenant_id"])
+	assert.Equal(t, float64(2), metadata["subscriptions_processed"], "numbers are read as from a dump file")
+	assignment := data["azure_ad"].(map[string]interface{})["appRoleAssignments"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, []interface{}{"assigned_from", "assigned_to"}, assignment["directions"])
+
+	_, err = consolidatedDataMap("dump.json")
+	assert.ErrorContains(t, err, "expected consolidated Azure IAM data, got string")
+}
+
+func TestNeo4jWriterLinkParams(t *testing.T) {
+	l := NewNeo4jWriterLink().(*Neo4jWriterLink)
+	var names []string
+	for _, param := range l.Params() {
+		names = append(names, param.Name())
+	}
+	assert.Contains(t, names, "neo4j-url")
+	assert.Contains(t, names, "replace")
+	assert.NotContains(t, names, "data-file", "the data comes from the previous link")
+}
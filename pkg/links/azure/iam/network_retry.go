@@ -0,0 +1,101 @@
+package iam
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+)
+
+const (
+	// networkRetryMaxAttempts bounds the tries of one request that keeps
+	// failing before a response arrives
+	networkRetryMaxAttempts = 4
+)
+
+// networkRetryBaseDelay is the backoff before the first network retry, doubled
+// for each further attempt
+var networkRetryBaseDelay = 500 * time.Millisecond
+
+// networkRetryTransport retries requests that fail before any response is
+// received, such as a DNS lookup that times out or a connection reset by a VPN
+// or corporate proxy. Responses, whatever their status, are returned as they
+// are; throttling and 5xx are retried by the callers that understand
+// Retry-After.
+type networkRetryTransport struct {
+	base http.RoundTripper
+}
+
+// newNetworkRetryTransport wraps base so transient network errors are retried
+// with exponential backoff
+func newNetworkRetryTransport(base http.RoundTripper) *networkRetryTransport {
+	return &networkRetryTransport{base: base}
+}
+
+func (t *networkRetryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	for attempt := 1; ; attempt++ {
+		resp, err := t.base.RoundTrip(req)
+		if err == nil || attempt >= networkRetryMaxAttempts || !isRetryableNetworkError(err) || req.Context().Err() != nil {
+			return resp, err
+		}
+
+		// A body that was partly sent must be rebuilt before the request goes out again
+		if req.Body != nil && req.Body != http.NoBody {
+			if req.GetBody == nil {
+				return resp, err
+			}
+			body, bodyErr := req.GetBody()
+			if bodyErr != nil {
+				return resp, err
+			}
+			req = req.Clone(req.Context())
+			req.Body = body
+		}
+
+		delay := networkRetryBaseDelay << (attempt - 1)
+		slog.Debug("Transient network error, retrying", "host", req.URL.Host, "attempt", attempt, "retry_after", delay, "error", err)
+		select {
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		case <-time.After(delay):
+		}
+	}
+}
+
+// isRetryableNetworkError reports whether err is a network failure that may
+// succeed on another try: a timeout, a reset or refused connection, a
+// connection closed mid-response, or a DNS lookup the resolver could not
+// complete. A name that does not exist, a rejected certificate and a cancelled
+// request fail the same way every time and are not retried.
+func isRetryableNetworkError(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var certErr *tls.CertificateVerificationError
+	var unknownAuthority x509.UnknownAuthorityError
+	var hostnameErr x509.HostnameError
+	if errors.As(err, &certErr) || errors.As(err, &unknownAuthority) || errors.As(err, &hostnameErr) {
+		return false
+	}
+
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return !dnsErr.IsNotFound && (dnsErr.IsTimeout || dnsErr.IsTemporary)
+	}
+
+	if errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.ECONNABORTED) || errors.Is(err, syscall.EPIPE) ||
+		errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF) {
+		return true
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
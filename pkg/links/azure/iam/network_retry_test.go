@@ -0,0 +1,99 @@
+package iam
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// flakyTransport fails the first len(errs) requests with the given errors and
+// records the body of every request it sees
+type flakyTransport struct {
+	errs   []error
+	calls  int
+	bodies []string
+}
+
+func (f *flakyTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	f.calls++
+	if req.Body != nil {
+		body, _ := io.ReadAll(req.Body)
+		f.bodies = append(f.bodies, string(body))
+	}
+	if f.calls <= len(f.errs) {
+		return nil, f.errs[f.calls-1]
+	}
+	return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewReader(nil))}, nil
+}
+
+func TestNetworkRetryTransport(t *testing.T) {
+	defer func(delay time.Duration) { networkRetryBaseDelay = delay }(networkRetryBaseDelay)
+	networkRetryBaseDelay = time.Millisecond
+
+	reset := &net.OpError{Op: "read", Net: "tcp", Err: os.NewSyscallError("read", syscall.ECONNRESET)}
+	dnsTimeout := &net.DNSError{Err: "i/o timeout", Name: "graph.microsoft.com", IsTimeout: true}
+
+	t.Run("transient errors are retried", func(t *testing.T) {
+		base := &flakyTransport{errs: []error{reset, dnsTimeout}}
+		req, err := http.NewRequest(http.MethodPost, "https://graph.microsoft.com/v1.0/$batch", bytes.NewBufferString(`{"requests":[]}`))
+		require.NoError(t, err)
+
+		resp, err := newNetworkRetryTransport(base).RoundTrip(req)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, 3, base.calls)
+		assert.Equal(t, []string{`{"requests":[]}`, `{"requests":[]}`, `{"requests":[]}`}, base.bodies, "the body is replayed on every attempt")
+	})
+
+	t.Run("attempts are bounded", func(t *testing.T) {
+		base := &flakyTransport{errs: []error{reset, reset, reset, reset, reset}}
+		req, _ := http.NewRequest(http.MethodGet, "https://graph.microsoft.com/v1.0/users", nil)
+		_, err := newNetworkRetryTransport(base).RoundTrip(req)
+		assert.ErrorIs(t, err, syscall.ECONNRESET)
+		assert.Equal(t, networkRetryMaxAttempts, base.calls)
+	})
+
+	t.Run("permanent errors are not retried", func(t *testing.T) {
+		notFound := &net.DNSError{Err: "no such host", Name: "graph.microsoft.invalid", IsNotFound: true}
+		base := &flakyTransport{errs: []error{notFound}}
+		req, _ := http.NewRequest(http.MethodGet, "https://graph.microsoft.invalid/v1.0/users", nil)
+		_, err := newNetworkRetryTransport(base).RoundTrip(req)
+		assert.Error(t, err)
+		assert.Equal(t, 1, base.calls)
+	})
+}
+
+func TestIsRetryableNetworkError(t *testing.T) {
+	for name, tc := range map[string]struct {
+		err       error
+		retryable bool
+	}{
+		"connection reset":   {&net.OpError{Op: "read", Err: os.NewSyscallError("read", syscall.ECONNRESET)}, true},
+		"connection refused": {&net.OpError{Op: "dial", Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)}, true},
+		"unexpected EOF":     {io.ErrUnexpectedEOF, true},
+		"dial timeout":       {&net.OpError{Op: "dial", Err: timeoutError{}}, true},
+		"temporary DNS":      {&net.DNSError{Err: "server misbehaving", IsTemporary: true}, true},
+		"unknown host":       {&net.DNSError{Err: "no such host", IsNotFound: true}, false},
+		"cancelled":          {context.Canceled, false},
+		"deadline":           {context.DeadlineExceeded, false},
+		"other":              {errors.New("unsupported protocol scheme"), false},
+	} {
+		assert.Equal(t, tc.retryable, isRetryableNetworkError(tc.err), name)
+	}
+}
+
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
@@ -0,0 +1,259 @@
+package iam
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/praetorian-inc/janus-framework/pkg/chain/cfg"
+)
+
+// managementPorts are the remote administration and database ports that
+// should never be reachable from the Internet
+var managementPorts = map[int]string{
+	22:    "SSH",
+	23:    "Telnet",
+	1433:  "SQL Server",
+	1521:  "Oracle",
+	3306:  "MySQL",
+	3389:  "RDP",
+	5432:  "PostgreSQL",
+	5985:  "WinRM HTTP",
+	5986:  "WinRM HTTPS",
+	6379:  "Redis",
+	9200:  "Elasticsearch",
+	27017: "MongoDB",
+}
+
+// internetSources are NSG source prefixes that match any Internet address
+var internetSources = map[string]bool{
+	"*":         true,
+	"any":       true,
+	"internet":  true,
+	"0.0.0.0/0": true,
+	"::/0":      true,
+}
+
+// nsgRule is the part of an NSG security rule the exposure check reads
+type nsgRule struct {
+	name       string
+	id         string
+	priority   int
+	allow      bool
+	inbound    bool
+	protocol   string
+	sources    []string
+	portRanges []string
+}
+
+// nsgSecurityRules reads the custom security rules of an NSG as projected by
+// Resource Graph. The default rules never allow Internet inbound and are not read.
+func nsgSecurityRules(nsg map[string]interface{}) []nsgRule {
+	properties, _ := nsg["properties"].(map[string]interface{})
+	raw, _ := properties["securityRules"].([]interface{})
+	rules := make([]nsgRule, 0, len(raw))
+	for _, entry := range raw {
+		entryMap, ok := entry.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		ruleProperties, _ := entryMap["properties"].(map[string]interface{})
+		if ruleProperties == nil {
+			continue
+		}
+		r := nsgRule{}
+		r.name, _ = entryMap["name"].(string)
+		r.id, _ = entryMap["id"].(string)
+		if priority, ok := ruleProperties["priority"].(float64); ok {
+			r.priority = int(priority)
+		}
+		access, _ := ruleProperties["access"].(string)
+		r.allow = strings.EqualFold(access, "Allow")
+		direction, _ := ruleProperties["direction"].(string)
+		r.inbound = strings.EqualFold(direction, "Inbound")
+		r.protocol, _ = ruleProperties["protocol"].(string)
+		r.sources = stringAndList(ruleProperties, "sourceAddressPrefix", "sourceAddressPrefixes")
+		r.portRanges = stringAndList(ruleProperties, "destinationPortRange", "destinationPortRanges")
+		rules = append(rules, r)
+	}
+	return rules
+}
+
+// stringAndList merges a single-valued property with its plural form, which
+// NSG rules use interchangeably
+func stringAndList(properties map[string]interface{}, single, plural string) []string {
+	var values []string
+	if value, _ := properties[single].(string); value != "" {
+		values = append(values, value)
+	}
+	list, _ := properties[plural].([]interface{})
+	for _, item := range list {
+		if value, _ := item.(string); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// sourceExposure classifies a rule's sources: "Internet" when any source
+// matches every Internet address, "AzureCloud" when it matches every Azure
+// public IP, which any Azure customer can originate traffic from, or ""
+func sourceExposure(sources []string) (string, string) {
+	exposure, matched := "", ""
+	for _, source := range sources {
+		lower := strings.ToLower(source)
+		if internetSources[lower] {
+			return "Internet", source
+		}
+		if lower == "azurecloud" || strings.HasPrefix(lower, "azurecloud.") {
+			exposure, matched = "AzureCloud", source
+		}
+	}
+	return exposure, matched
+}
+
+// portRangeContains reports whether an NSG port spec ("*", "22" or "20-25")
+// includes port
+func portRangeContains(spec string, port int) bool {
+	spec = strings.TrimSpace(spec)
+	if spec == "*" {
+		return true
+	}
+	low, high, isRange := strings.Cut(spec, "-")
+	from, err := strconv.Atoi(strings.TrimSpace(low))
+	if err != nil {
+		return false
+	}
+	to := from
+	if isRange {
+		if to, err = strconv.Atoi(strings.TrimSpace(high)); err != nil {
+			return false
+		}
+	}
+	return port >= from && port <= to
+}
+
+func rulePortsContain(r nsgRule, port int) bool {
+	for _, spec := range r.portRanges {
+		if portRangeContains(spec, port) {
+			return true
+		}
+	}
+	return false
+}
+
+// deniedFirst reports whether a higher priority inbound deny from the whole
+// Internet blocks port before the allow rule is reached
+func deniedFirst(rules []nsgRule, allow nsgRule, port int) bool {
+	for _, r := range rules {
+		if r.allow || !r.inbound || r.priority >= allow.priority {
+			continue
+		}
+		if exposure, _ := sourceExposure(r.sources); exposure != "Internet" {
+			continue
+		}
+		if r.protocol != "*" && !strings.EqualFold(r.protocol, allow.protocol) {
+			continue
+		}
+		if rulePortsContain(r, port) {
+			return true
+		}
+	}
+	return false
+}
+
+// nsgAttached reports whether an NSG is associated with any subnet or NIC;
+// an unattached NSG filters no traffic
+func nsgAttached(nsg map[string]interface{}) bool {
+	properties, _ := nsg["properties"].(map[string]interface{})
+	subnets, _ := properties["subnets"].([]interface{})
+	interfaces, _ := properties["networkInterfaces"].([]interface{})
+	return len(subnets) > 0 || len(interfaces) > 0
+}
+
+// buildNSGExposureFindings flags inbound allow rules in network security
+// groups that open a management or database port to the Internet or to all of
+// AzureCloud. There is one finding per offending rule.
+func buildNSGExposureFindings(o *ConsolidatedOutput) []interface{} {
+	findings := []interface{}{}
+	for _, subData := range o.AzureResources {
+		subDataMap, ok := subData.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		resources, _ := subDataMap["azureResources"].([]interface{})
+		for _, resource := range resources {
+			nsg, ok := resource.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			if resourceType, _ := nsg["type"].(string); !strings.EqualFold(resourceType, "microsoft.network/networksecuritygroups") {
+				continue
+			}
+
+			rules := nsgSecurityRules(nsg)
+			attached := nsgAttached(nsg)
+			for _, r := range rules {
+				if !r.allow || !r.inbound || strings.EqualFold(r.protocol, "Icmp") {
+					continue
+				}
+				exposure, source := sourceExposure(r.sources)
+				if exposure == "" {
+					continue
+				}
+
+				var ports []int
+				for port := range managementPorts {
+					if rulePortsContain(r, port) && !deniedFirst(rules, r, port) {
+						ports = append(ports, port)
+					}
+				}
+				if len(ports) == 0 {
+					continue
+				}
+				sort.Ints(ports)
+				var services []string
+				for _, port := range ports {
+					services = append(services, managementPorts[port])
+				}
+
+				severity := "High"
+				if exposure == "AzureCloud" {
+					severity = "Medium"
+				}
+				if !attached {
+					severity = "Low"
+				}
+				findings = append(findings, map[string]interface{}{
+					"type":     "NSGManagementPortExposed",
+					"severity": severity,
+					"description": fmt.Sprintf("NSG %s rule %s allows inbound %s from %s to %s",
+						nsg["name"], r.name, strings.Join(services, ", "), source, strings.Join(r.portRanges, ",")),
+					"nsgId":            nsg["id"],
+					"nsgName":          nsg["name"],
+					"subscriptionId":   nsg["subscriptionId"],
+					"attached":         attached,
+					"ruleName":         r.name,
+					"ruleId":           r.id,
+					"priority":         r.priority,
+					"protocol":         r.protocol,
+					"source":           source,
+					"sourceExposure":   exposure,
+					"destinationPorts": r.portRanges,
+					"exposedPorts":     ports,
+					"services":         services,
+				})
+			}
+		}
+	}
+
+	sortFindings(findings, "nsgId", "ruleName")
+	return findings
+}
+
+// logNSGExposureFindings reports NSG rules that expose management ports
+func logNSGExposureFindings(logger *cfg.Logger, findings []interface{}) {
+	logFindings(logger, findings, "🚨 %d network security group rules expose management or database ports", "Management port exposed",
+		"nsg", "nsgName", "rule", "ruleName", "source", "source", "ports", "exposedPorts")
+}
@@ -0,0 +1,82 @@
+package iam
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const nsgExposureFixture = `{
+  "azure_ad": {},
+  "pim": {},
+  "azure_resources": {
+    "sub-1": {
+      "azureResources": [
+        {
+          "id": "/subscriptions/sub-1/resourceGroups/net/providers/Microsoft.Network/networkSecurityGroups/web-nsg",
+          "name": "web-nsg", "type": "microsoft.network/networksecuritygroups", "subscriptionId": "sub-1",
+          "properties": {
+            "subnets": [{"id": "/subscriptions/sub-1/resourceGroups/net/providers/Microsoft.Network/virtualNetworks/vnet/subnets/web"}],
+            "securityRules": [
+              {"name": "allow-admin", "id": "rule-admin", "properties": {"access": "Allow", "direction": "Inbound", "priority": 100, "protocol": "Tcp",
+                "sourceAddressPrefix": "Internet", "destinationPortRanges": ["22", "3380-3390", "443"]}},
+              {"name": "allow-https", "id": "rule-https", "properties": {"access": "Allow", "direction": "Inbound", "priority": 110, "protocol": "Tcp",
+                "sourceAddressPrefix": "*", "destinationPortRange": "443"}},
+              {"name": "allow-sql-azure", "id": "rule-sql", "properties": {"access": "Allow", "direction": "Inbound", "priority": 120, "protocol": "*",
+                "sourceAddressPrefixes": ["10.0.0.0/8", "AzureCloud.westeurope"], "destinationPortRange": "1433"}},
+              {"name": "allow-office-ssh", "id": "rule-office", "properties": {"access": "Allow", "direction": "Inbound", "priority": 130, "protocol": "Tcp",
+                "sourceAddressPrefix": "203.0.113.0/24", "destinationPortRange": "22"}},
+              {"name": "deny-winrm", "id": "rule-deny", "properties": {"access": "Deny", "direction": "Inbound", "priority": 140, "protocol": "*",
+                "sourceAddressPrefix": "*", "destinationPortRange": "5985-5986"}},
+              {"name": "allow-any", "id": "rule-any", "properties": {"access": "Allow", "direction": "Inbound", "priority": 200, "protocol": "Tcp",
+                "sourceAddressPrefix": "0.0.0.0/0", "destinationPortRange": "5985-5986"}}
+            ]
+          }
+        },
+        {
+          "id": "/subscriptions/sub-1/resourceGroups/net/providers/Microsoft.Network/networkSecurityGroups/spare-nsg",
+          "name": "spare-nsg", "type": "Microsoft.Network/networkSecurityGroups",
+          "properties": {"securityRules": [
+            {"name": "allow-rdp", "properties": {"access": "Allow", "direction": "Inbound", "priority": 100, "protocol": "Tcp", "sourceAddressPrefix": "*", "destinationPortRange": "*"}}
+          ]}
+        }
+      ]
+    }
+  }
+}`
+
+func TestBuildNSGExposureFindings(t *testing.T) {
+	var output ConsolidatedOutput
+	require.NoError(t, json.Unmarshal([]byte(nsgExposureFixture), &output))
+
+	findings := buildNSGExposureFindings(&output)
+	require.Len(t, findings, 3, "HTTPS, private sources, and ports behind a higher priority deny are not exposures")
+
+	spare := findings[2].(map[string]interface{})
+	assert.Equal(t, "spare-nsg", spare["nsgName"])
+	assert.Equal(t, "Low", spare["severity"], "an unattached NSG filters no traffic")
+	assert.Len(t, spare["exposedPorts"], len(managementPorts))
+
+	admin := findings[0].(map[string]interface{})
+	assert.Equal(t, "allow-admin", admin["ruleName"])
+	assert.Equal(t, "High", admin["severity"])
+	assert.Equal(t, []int{22, 3389}, admin["exposedPorts"])
+	assert.Equal(t, []string{"SSH", "RDP"}, admin["services"])
+	assert.Equal(t, []string{"22", "3380-3390", "443"}, admin["destinationPorts"])
+
+	sql := findings[1].(map[string]interface{})
+	assert.Equal(t, "allow-sql-azure", sql["ruleName"])
+	assert.Equal(t, "Medium", sql["severity"])
+	assert.Equal(t, "AzureCloud.westeurope", sql["source"])
+	assert.Equal(t, "/subscriptions/sub-1/resourceGroups/net/providers/Microsoft.Network/networkSecurityGroups/web-nsg", sql["nsgId"])
+}
+
+func TestPortRangeContains(t *testing.T) {
+	assert.True(t, portRangeContains("*", 22))
+	assert.True(t, portRangeContains("22", 22))
+	assert.True(t, portRangeContains(" 3380 - 3390 ", 3389))
+	assert.False(t, portRangeContains("3390-3400", 3389))
+	assert.False(t, portRangeContains("VirtualNetwork", 22))
+}
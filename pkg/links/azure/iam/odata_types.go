@@ -0,0 +1,111 @@
+package iam
+
+import "strings"
+
+// Canonical @odata.type values of the directory objects the collectors record
+// as members, owners and role holders
+const (
+	odataTypeUser             = "#microsoft.graph.user"
+	odataTypeGroup            = "#microsoft.graph.group"
+	odataTypeServicePrincipal = "#microsoft.graph.servicePrincipal"
+	odataTypeApplication      = "#microsoft.graph.application"
+	odataTypeDevice           = "#microsoft.graph.device"
+	odataTypeOrgContact       = "#microsoft.graph.orgContact"
+	odataTypeDirectoryRole    = "#microsoft.graph.directoryRole"
+	odataTypeDirectoryObject  = "#microsoft.graph.directoryObject"
+)
+
+// odataTypePrincipalTypes maps the lowercased object type name to its
+// canonical @odata.type and the principal type used for collected principals
+// and in findings ("User", "Group", "ServicePrincipal", ...)
+var odataTypePrincipalTypes = map[string]struct {
+	odataType     string
+	principalType string
+}{
+	"user":             {odataTypeUser, "User"},
+	"group":            {odataTypeGroup, "Group"},
+	"serviceprincipal": {odataTypeServicePrincipal, "ServicePrincipal"},
+	"application":      {odataTypeApplication, "Application"},
+	"device":           {odataTypeDevice, "Device"},
+	"orgcontact":       {odataTypeOrgContact, "OrgContact"},
+	"contact":          {odataTypeOrgContact, "OrgContact"},
+	"directoryrole":    {odataTypeDirectoryRole, "DirectoryRole"},
+	"directoryobject":  {odataTypeDirectoryObject, "Unknown"},
+}
+
+// odataTypeName strips the namespace from an @odata.type. Graph v1.0 returns
+// "#microsoft.graph.user", some beta and batch responses drop the "#" or change
+// the casing, and Azure AD Graph era payloads use "Microsoft.DirectoryServices.User".
+func odataTypeName(value interface{}) string {
+	odataType, _ := value.(string)
+	odataType = strings.TrimPrefix(strings.TrimSpace(odataType), "#")
+	if i := strings.LastIndex(odataType, "."); i >= 0 {
+		odataType = odataType[i+1:]
+	}
+	return strings.ToLower(odataType)
+}
+
+// normalizeODataType maps any variant of an @odata.type to its canonical
+// "#microsoft.graph.<type>" form so records compare and import consistently.
+// Unrecognized types keep their name under the canonical prefix, and a missing
+// type stays empty.
+func normalizeODataType(value interface{}) string {
+	name := odataTypeName(value)
+	if name == "" {
+		return ""
+	}
+	if known, ok := odataTypePrincipalTypes[name]; ok {
+		return known.odataType
+	}
+	original, _ := value.(string)
+	original = strings.TrimPrefix(strings.TrimSpace(original), "#")
+	return "#microsoft.graph." + original[strings.LastIndex(original, ".")+1:]
+}
+
+// principalTypeFromODataType maps any variant of an @odata.type to the
+// principal type used for collected principals, or "Unknown"
+func principalTypeFromODataType(value interface{}) string {
+	if known, ok := odataTypePrincipalTypes[odataTypeName(value)]; ok {
+		return known.principalType
+	}
+	return "Unknown"
+}
+
+// isODataType reports whether value is any variant of the canonical odataType
+func isODataType(value interface{}, odataType string) bool {
+	return normalizeODataType(value) == odataType
+}
+
+// odataTypeFields are the Azure AD record fields holding an @odata.type
+var odataTypeFields = []struct {
+	section string
+	field   string
+}{
+	{"groupMemberships", "memberType"},
+	{"groupOwnership", "ownerType"},
+	{"servicePrincipalOwnership", "ownerType"},
+	{"applicationOwnership", "ownerType"},
+	{"directoryRoleAssignments", "principalType"},
+	{"attributeRoleAssignments", "principalType"},
+	{"deviceOwnership", "ownerType"},
+	{"administrativeUnitMembers", "memberType"},
+	{"administrativeUnitRoleAssignments", "principalType"},
+}
+
+// normalizeODataTypeFields rewrites the @odata.type fields of Azure AD records
+// to their canonical form, so dumps written before the collectors normalized
+// them type their members and owners the same way as new ones
+func normalizeODataTypeFields(azureAD map[string]interface{}) {
+	for _, f := range odataTypeFields {
+		records, _ := azureAD[f.section].([]interface{})
+		for _, record := range records {
+			recordMap, ok := record.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			if odataType := normalizeODataType(recordMap[f.field]); odataType != "" {
+				recordMap[f.field] = odataType
+			}
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package iam
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeODataType(t *testing.T) {
+	for _, tc := range []struct {
+		value         interface{}
+		odataType     string
+		principalType string
+	}{
+		{"#microsoft.graph.servicePrincipal", odataTypeServicePrincipal, "ServicePrincipal"},
+		{"microsoft.graph.servicePrincipal", odataTypeServicePrincipal, "ServicePrincipal"},
+		{"#Microsoft.Graph.ServicePrincipal", odataTypeServicePrincipal, "ServicePrincipal"},
+		{"Microsoft.DirectoryServices.User", odataTypeUser, "User"},
+		{" #microsoft.graph.group ", odataTypeGroup, "Group"},
+		{"#microsoft.graph.DIRECTORYROLE", odataTypeDirectoryRole, "DirectoryRole"},
+		{"#microsoft.graph.device", odataTypeDevice, "Device"},
+		{"Microsoft.DirectoryServices.Contact", odataTypeOrgContact, "OrgContact"},
+		{"#microsoft.graph.directoryObject", odataTypeDirectoryObject, "Unknown"},
+		{"microsoft.graph.agentIdentity", "#microsoft.graph.agentIdentity", "Unknown"},
+		{"", "", "Unknown"},
+		{nil, "", "Unknown"},
+		{42, "", "Unknown"},
+	} {
+		assert.Equal(t, tc.odataType, normalizeODataType(tc.value), "normalizeODataType(%v)", tc.value)
+		assert.Equal(t, tc.principalType, principalTypeFromODataType(tc.value), "principalTypeFromODataType(%v)", tc.value)
+	}
+
+	assert.True(t, isODataType("microsoft.graph.directoryRole", odataTypeDirectoryRole))
+	assert.False(t, isODataType("#microsoft.graph.group", odataTypeDirectoryRole))
+}
+
+func TestNormalizeODataTypeFields(t *testing.T) {
+	o := &ConsolidatedOutput{AzureAD: map[string]interface{}{
+		"groupMemberships": []interface{}{
+			map[string]interface{}{"groupId": "g1", "memberId": "u1", "memberType": "microsoft.graph.user"},
+			map[string]interface{}{"groupId": "g1", "memberId": "x1"},
+		},
+		"groupOwnership": []interface{}{
+			map[string]interface{}{"groupId": "g1", "ownerId": "sp1", "ownerType": "#Microsoft.Graph.ServicePrincipal"},
+		},
+		"directoryRoleAssignments": []interface{}{
+			map[string]interface{}{"roleId": "r1", "principalId": "sp1", "principalType": "Microsoft.DirectoryServices.ServicePrincipal"},
+		},
+	}}
+	o.Normalize()
+
+	memberships := o.AzureAD["groupMemberships"].([]interface{})
+	assert.Equal(t, odataTypeUser, memberships[0].(map[string]interface{})["memberType"])
+	assert.NotContains(t, memberships[1].(map[string]interface{}), "memberType", "a missing type is not invented")
+	assert.Equal(t, odataTypeServicePrincipal, o.AzureAD["groupOwnership"].([]interface{})[0].(map[string]interface{})["ownerType"])
+	assert.Equal(t, odataTypeServicePrincipal, o.AzureAD["directoryRoleAssignments"].([]interface{})[0].(map[string]interface{})["principalType"])
+}
@@ -0,0 +1,393 @@
+package iam
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/praetorian-inc/janus-framework/pkg/chain"
+	"github.com/praetorian-inc/janus-framework/pkg/chain/cfg"
+	"github.com/praetorian-inc/nebula/internal/message"
+	"github.com/praetorian-inc/nebula/pkg/links/options"
+)
+
+const (
+	ownerRoleGUID              = "8e3af657-a8ff-443c-a75c-2fe8c4bcb635"
+	globalAdminTemplateID      = "62e90394-69f5-4237-9190-012177145e10"
+	directoryReadWriteAllAppID = "19dbc75e-c2e2-444c-a770-ec69d8559fc7"
+)
+
+// offlineReport answers one question directly from a consolidated dump
+type offlineReport struct {
+	description string
+	run         func(o *ConsolidatedOutput, principals map[string]reportPrincipal) []map[string]interface{}
+}
+
+// offlineReports are the built-in reports, keyed by the --report name
+var offlineReports = map[string]offlineReport{
+	"owners": {
+		description: "Principals with Owner at subscription scope or above",
+		run:         ownersReport,
+	},
+	"directory-write": {
+		description: "Service principals granted the Directory.ReadWrite.All application permission",
+		run:         directoryWriteReport,
+	},
+	"guest-admins": {
+		description: "Guest users holding privileged directory roles, PIM eligibility, or high-privilege Azure RBAC",
+		run:         guestAdminsReport,
+	},
+	"global-admins": {
+		description: "Principals with active or PIM-eligible Global Administrator",
+		run:         globalAdminsReport,
+	},
+	"lock-coverage": {
+		description: "High-value resources and the management lock protecting each, if any",
+		run:         lockCoverageReport,
+	},
+	"pim-eligibility": {
+		description: "Directory roles with PIM eligible assignments and what activating each requires",
+		run:         pimEligibilityReport,
+	},
+	"privileged-role-counts": {
+		description: "Owner, Contributor, User Access Administrator and RBAC Administrator assignee counts per subscription and management group",
+		run:         privilegedRoleCountsReport,
+	},
+	"laps-readers": {
+		description: "Principals that can read Windows LAPS passwords backed up to Entra ID",
+		run:         lapsReadersReport,
+	},
+	"au-password-resets": {
+		description: "Users each administrative unit scoped password reset role can reset",
+		run:         administrativeUnitResetsReport,
+	},
+}
+
+// OfflineReportLink runs built-in reports over a consolidated Azure IAM dump
+// without a Neo4j database
+type OfflineReportLink struct {
+	*chain.Base
+}
+
+func NewOfflineReportLink(configs ...cfg.Config) chain.Link {
+	l := &OfflineReportLink{}
+	l.Base = chain.NewBase(l, configs...)
+	return l
+}
+
+func (l *OfflineReportLink) Params() []cfg.Param {
+	return []cfg.Param{
+		options.AzureDumpFile(),
+		options.AzureOfflineReport(),
+	}
+}
+
+func (l *OfflineReportLink) Process(input interface{}) error {
+	dumpFile, _ := cfg.As[string](l.Arg("dump"))
+	reportName, _ := cfg.As[string](l.Arg("report"))
+
+	names, err := selectOfflineReports(reportName)
+	if err != nil {
+		return err
+	}
+
+	output, err := loadConsolidatedDump(dumpFile)
+	if err != nil {
+		return err
+	}
+	message.Info("Loaded dump for tenant %s collected %s", output.CollectionMetadata.TenantID, output.CollectionMetadata.CollectionTimestamp)
+
+	results := runOfflineReports(output, names)
+	for _, name := range names {
+		logOfflineReport(name, results[name])
+	}
+
+	l.Send(map[string]interface{}{
+		"tenant_id":            output.CollectionMetadata.TenantID,
+		"collection_timestamp": output.CollectionMetadata.CollectionTimestamp,
+		"reports":              results,
+	})
+	return nil
+}
+
+// selectOfflineReports resolves the --report value to report names in a
+// stable order
+func selectOfflineReports(reportName string) ([]string, error) {
+	var names []string
+	for name := range offlineReports {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	if reportName == "" || reportName == "all" {
+		return names, nil
+	}
+	if _, ok := offlineReports[reportName]; !ok {
+		return nil, fmt.Errorf("unknown report %q, expected all or one of: %s", reportName, strings.Join(names, ", "))
+	}
+	return []string{reportName}, nil
+}
+
+// runOfflineReports runs the named reports against a normalized dump
+func runOfflineReports(o *ConsolidatedOutput, names []string) map[string][]map[string]interface{} {
+	principals := indexReportPrincipals(o)
+	results := make(map[string][]map[string]interface{}, len(names))
+	for _, name := range names {
+		rows := offlineReports[name].run(o, principals)
+		sort.SliceStable(rows, func(i, j int) bool {
+			return fmt.Sprint(rows[i]["principalName"], rows[i]["scope"]) < fmt.Sprint(rows[j]["principalName"], rows[j]["scope"])
+		})
+		results[name] = rows
+	}
+	return results
+}
+
+// loadConsolidatedDump reads a consolidated dump in any of the shapes the
+// importer accepts: the bare object, a RuntimeJSON array, or a RuntimeJSON
+// envelope with a "resources" array
+func loadConsolidatedDump(path string) (*ConsolidatedOutput, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read dump: %v", err)
+	}
+
+	raw := json.RawMessage(data)
+	var array []json.RawMessage
+	if err := json.Unmarshal(raw, &array); err == nil {
+		if len(array) == 0 {
+			return nil, fmt.Errorf("dump %s is an empty JSON array", path)
+		}
+		raw = array[0]
+	}
+	var envelope struct {
+		Resources []json.RawMessage `json:"resources"`
+	}
+	if err := json.Unmarshal(raw, &envelope); err == nil && len(envelope.Resources) > 0 {
+		raw = envelope.Resources[0]
+	}
+
+	var output ConsolidatedOutput
+	if err := json.Unmarshal(raw, &output); err != nil {
+		return nil, fmt.Errorf("failed to parse dump: %v", err)
+	}
+	if err := checkSchemaVersion(output.CollectionMetadata.SchemaVersion); err != nil {
+		return nil, err
+	}
+	warnIfSampled(output.CollectionMetadata.Sampled, output.CollectionMetadata.SampleSize)
+	if output.AzureResources == nil {
+		output.AzureResources = make(map[string]interface{})
+	}
+	if err := loadSubscriptionShards(output.CollectionMetadata.SubscriptionShards, filepath.Dir(path), output.AzureResources); err != nil {
+		return nil, err
+	}
+	// The loaded output holds every subscription inline again
+	output.CollectionMetadata.SubscriptionShards = nil
+	if output.CollectionMetadata.NDJSONFile != "" {
+		if err := loadNDJSONSections(&output, filepath.Dir(path)); err != nil {
+			return nil, err
+		}
+		// Empty sections have no records, so keep what the run recorded as
+		// not collected rather than every section that came back empty
+		notCollected := output.CollectionMetadata.NotCollected
+		output.Normalize()
+		output.CollectionMetadata.NotCollected = notCollected
+		return &output, nil
+	}
+	output.Normalize()
+	return &output, nil
+}
+
+type reportPrincipal struct {
+	name     string
+	kind     string
+	userType string
+}
+
+// indexReportPrincipals maps lowercased object IDs to display details for users,
+// groups and service principals
+func indexReportPrincipals(o *ConsolidatedOutput) map[string]reportPrincipal {
+	principals := make(map[string]reportPrincipal)
+	for section, kind := range map[string]string{"users": "User", "groups": "Group", "servicePrincipals": "ServicePrincipal"} {
+		objects, _ := o.AzureAD[section].([]interface{})
+		for _, object := range objects {
+			objectMap, ok := object.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			id, _ := objectMap["id"].(string)
+			name, _ := objectMap["userPrincipalName"].(string)
+			if name == "" {
+				name, _ = objectMap["displayName"].(string)
+			}
+			userType, _ := objectMap["userType"].(string)
+			principals[strings.ToLower(id)] = reportPrincipal{name: name, kind: kind, userType: userType}
+		}
+	}
+	return principals
+}
+
+// reportRow starts a result row for a principal, falling back to the ID when
+// the principal is not in the dump (deleted or from another tenant)
+func reportRow(principals map[string]reportPrincipal, principalID string) map[string]interface{} {
+	principal, ok := principals[strings.ToLower(principalID)]
+	if !ok {
+		principal = reportPrincipal{name: principalID, kind: "Unknown"}
+	}
+	return map[string]interface{}{
+		"principalId":   principalID,
+		"principalName": principal.name,
+		"principalType": principal.kind,
+	}
+}
+
+// rbacScopeLevel classifies an Azure RBAC scope
+func rbacScopeLevel(scope string) string {
+	lower := strings.ToLower(strings.TrimSuffix(scope, "/"))
+	switch {
+	case scope == "/":
+		return "root"
+	case strings.HasPrefix(lower, "/providers/microsoft.management/managementgroups/"):
+		return "managementGroup"
+	case strings.HasPrefix(lower, "/subscriptions/"):
+		switch strings.Count(lower, "/") {
+		case 2:
+			return "subscription"
+		case 4:
+			return "resourceGroup"
+		}
+		return "resource"
+	}
+	return "unknown"
+}
+
+func ownersReport(o *ConsolidatedOutput, principals map[string]reportPrincipal) []map[string]interface{} {
+	rows := []map[string]interface{}{}
+	for principalID, grants := range privilegedPrincipalGrants(o) {
+		for _, grant := range grants {
+			if grant["type"] != "azureRBAC" || grant["roleDefinitionId"] != ownerRoleGUID {
+				continue
+			}
+			scope, _ := grant["scope"].(string)
+			level := rbacScopeLevel(scope)
+			if level != "subscription" && level != "managementGroup" && level != "root" {
+				continue
+			}
+			row := reportRow(principals, principalID)
+			row["roleName"] = grant["roleName"]
+			row["scope"] = scope
+			row["scopeLevel"] = level
+			rows = append(rows, row)
+		}
+	}
+	return rows
+}
+
+func directoryWriteReport(o *ConsolidatedOutput, principals map[string]reportPrincipal) []map[string]interface{} {
+	rows := []map[string]interface{}{}
+	seen := make(map[string]bool)
+	assignments, _ := o.AzureAD["appRoleAssignments"].([]interface{})
+	for _, assignment := range assignments {
+		a, ok := assignment.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		appRoleID, _ := a["appRoleId"].(string)
+		principalID, _ := a["principalId"].(string)
+		if !strings.EqualFold(appRoleID, directoryReadWriteAllAppID) || principalID == "" || seen[strings.ToLower(principalID)] {
+			continue
+		}
+		seen[strings.ToLower(principalID)] = true
+
+		row := reportRow(principals, principalID)
+		if row["principalType"] == "Unknown" {
+			if principalType, _ := a["principalType"].(string); principalType != "" {
+				row["principalType"] = principalType
+			}
+		}
+		row["permission"] = "Directory.ReadWrite.All"
+		row["resourceDisplayName"] = a["resourceDisplayName"]
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+func guestAdminsReport(o *ConsolidatedOutput, principals map[string]reportPrincipal) []map[string]interface{} {
+	rows := []map[string]interface{}{}
+	for principalID, grants := range privilegedPrincipalGrants(o) {
+		if !strings.EqualFold(principals[principalID].userType, "Guest") {
+			continue
+		}
+		for _, grant := range grants {
+			row := reportRow(principals, principalID)
+			row["grantType"] = grant["type"]
+			row["roleName"] = grant["roleName"]
+			row["scope"] = grant["scope"]
+			rows = append(rows, row)
+		}
+	}
+	return rows
+}
+
+func globalAdminsReport(o *ConsolidatedOutput, principals map[string]reportPrincipal) []map[string]interface{} {
+	rows := []map[string]interface{}{}
+	for principalID, grants := range privilegedPrincipalGrants(o) {
+		for _, grant := range grants {
+			if !strings.EqualFold(fmt.Sprint(grant["roleTemplateId"]), globalAdminTemplateID) {
+				continue
+			}
+			row := reportRow(principals, principalID)
+			row["eligible"] = grant["type"] == "pimEligibleDirectoryRole"
+			rows = append(rows, row)
+		}
+	}
+	return rows
+}
+
+// logOfflineReport prints a report's rows for the console
+func logOfflineReport(name string, rows []map[string]interface{}) {
+	message.Section("%s: %s", name, offlineReports[name].description)
+	if len(rows) == 0 {
+		message.Info("No results")
+		return
+	}
+	for _, row := range rows {
+		if eligible, ok := row["eligible"].([]string); ok {
+			detail := " activation policy not collected"
+			if row["policyCollected"] == true {
+				detail = fmt.Sprintf(" approval=%v mfa=%v justification=%v", row["approvalRequired"], row["mfaRequired"], row["justificationRequired"])
+			}
+			message.Info("  %s: %d eligible%s", row["roleName"], len(eligible), detail)
+			continue
+		}
+		if assignees, ok := row["assignees"]; ok {
+			detail := fmt.Sprintf(" users=%v groups=%v servicePrincipals=%v guests=%v", row["users"], row["groups"], row["servicePrincipals"], row["guests"])
+			if flags, _ := row["flags"].([]string); len(flags) > 0 {
+				detail += " flags=" + strings.Join(flags, ",")
+			}
+			message.Info("  %s %s: %v assignees%s", row["scope"], row["roleName"], assignees, detail)
+			continue
+		}
+		if resourceID, ok := row["resourceId"]; ok {
+			detail := fmt.Sprintf(" lock=%v", row["lockLevel"])
+			if row["inherited"] == true {
+				detail += fmt.Sprintf(" (inherited from %v)", row["lockScope"])
+			}
+			message.Info("  %s [%s]%s", resourceID, row["resourceType"], detail)
+			continue
+		}
+		detail := ""
+		for _, key := range []string{"roleName", "permission", "scope"} {
+			if value, ok := row[key]; ok && value != nil && value != "" {
+				detail += fmt.Sprintf(" %v", value)
+			}
+		}
+		if row["eligible"] == true {
+			detail += " (PIM eligible)"
+		}
+		message.Info("  %s [%s]%s", row["principalName"], row["principalType"], detail)
+	}
+	message.Info("%d results", len(rows))
+}
@@ -0,0 +1,79 @@
+package iam
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const offlineReportFixture = `{
+  "collection_metadata": {"schema_version": "1.5", "tenant_id": "tenant-1"},
+  "azure_ad": {
+    "users": [
+      {"id": "u-guest", "userPrincipalName": "vendor_example.com#EXT#@contoso.onmicrosoft.com", "userType": "Guest"},
+      {"id": "u-admin", "userPrincipalName": "admin@contoso.com", "userType": "Member"}
+    ],
+    "servicePrincipals": [{"id": "sp-sync", "displayName": "Directory Sync"}],
+    "directoryRoleAssignments": [
+      {"roleTemplateId": "62e90394-69f5-4237-9190-012177145e10", "principalId": "u-admin"}
+    ],
+    "appRoleAssignments": [
+      {"appRoleId": "19dbc75e-c2e2-444c-a770-ec69d8559fc7", "principalId": "sp-sync", "resourceDisplayName": "Microsoft Graph", "direction": "assigned_to"},
+      {"appRoleId": "19dbc75e-c2e2-444c-a770-ec69d8559fc7", "principalId": "sp-sync", "resourceDisplayName": "Microsoft Graph", "direction": "assigned_from"},
+      {"appRoleId": "df021288-bdef-4463-88db-98f22de89214", "principalId": "sp-sync"}
+    ]
+  },
+  "pim": {
+    "eligible_assignments": [
+      {"principalId": "u-guest", "roleDefinitionId": "62e90394-69f5-4237-9190-012177145e10"}
+    ]
+  },
+  "azure_resources": {
+    "sub-1": {
+      "subscriptionRoleAssignments": [
+        {"properties": {"principalId": "u-admin", "roleDefinitionId": "/subscriptions/sub-1/providers/Microsoft.Authorization/roleDefinitions/8e3af657-a8ff-443c-a75c-2fe8c4bcb635", "scope": "/subscriptions/sub-1"}}
+      ],
+      "resourceGroupRoleAssignments": [
+        {"principalId": "u-guest", "roleDefinitionId": "8e3af657-a8ff-443c-a75c-2fe8c4bcb635", "scope": "/subscriptions/sub-1/resourceGroups/rg-1"}
+      ]
+    }
+  }
+}`
+
+func TestOfflineReports(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dump.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"resources": [`+offlineReportFixture+`]}`), 0644))
+
+	output, err := loadConsolidatedDump(path)
+	require.NoError(t, err, "RuntimeJSON envelopes are unwrapped")
+
+	names, err := selectOfflineReports("all")
+	require.NoError(t, err)
+	results := runOfflineReports(output, names)
+
+	owners := results["owners"]
+	require.Len(t, owners, 1, "resource group Owners are not subscription Owners")
+	assert.Equal(t, "admin@contoso.com", owners[0]["principalName"])
+	assert.Equal(t, "subscription", owners[0]["scopeLevel"])
+
+	directoryWrite := results["directory-write"]
+	require.Len(t, directoryWrite, 1, "both directions of the same grant are reported once")
+	assert.Equal(t, "Directory Sync", directoryWrite[0]["principalName"])
+
+	guests := results["guest-admins"]
+	require.Len(t, guests, 2)
+	assert.Equal(t, "pimEligibleDirectoryRole", guests[0]["grantType"])
+	assert.Equal(t, "azureRBAC", guests[1]["grantType"])
+
+	globalAdmins := results["global-admins"]
+	require.Len(t, globalAdmins, 2)
+	assert.Equal(t, "admin@contoso.com", globalAdmins[0]["principalName"])
+	assert.Equal(t, false, globalAdmins[0]["eligible"])
+	assert.Equal(t, true, globalAdmins[1]["eligible"])
+
+	_, err = selectOfflineReports("nope")
+	assert.Error(t, err)
+}
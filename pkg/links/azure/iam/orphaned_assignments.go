@@ -0,0 +1,129 @@
+package iam
+
+import (
+	"strings"
+
+	"github.com/praetorian-inc/janus-framework/pkg/chain/cfg"
+)
+
+// rbacAssignmentSections are the azure_resources sections holding Azure RBAC
+// role assignments
+var rbacAssignmentSections = []string{
+	"subscriptionRoleAssignments", "resourceGroupRoleAssignments",
+	"resourceLevelRoleAssignments", "managementGroupRoleAssignments", "tenantRoleAssignments",
+}
+
+// markOrphanedAssignments sets orphanedAssignment on every Azure RBAC role
+// assignment whose principal is not among the collected users, groups and
+// service principals. Azure keeps assignments of deleted principals, and they
+// grant their role again to an object recreated with the same ID. Assignments
+// to groups of another tenant are never in the directory and are skipped.
+//
+// It must run before --sample trims the directory. When users, groups or
+// service principals could not be fully collected nothing is marked and
+// checked is false.
+func markOrphanedAssignments(logger *cfg.Logger, o *ConsolidatedOutput) (orphaned int, checked bool) {
+	principals, incomplete := directoryPrincipals(o.AzureAD, o.CollectionErrors)
+	if incomplete != "" {
+		logger.Warn("Directory principals were not fully collected, skipping the orphaned role assignment check", "dataset", incomplete)
+		return 0, false
+	}
+	if len(principals) == 0 {
+		logger.Warn("No directory principals were collected, skipping the orphaned role assignment check")
+		return 0, false
+	}
+
+	for _, subData := range o.AzureResources {
+		subDataMap, _ := subData.(map[string]interface{})
+		orphaned += markSubscriptionOrphans(principals, subDataMap)
+	}
+	orphaned += markOrphans(principals, o.ManagementGroupRBAC)
+	return orphaned, true
+}
+
+// directoryPrincipals returns the lower-cased IDs of the collected users,
+// groups and service principals, or the first of those datasets that has a
+// collection error
+func directoryPrincipals(azureAD map[string]interface{}, collectionErrors []CollectionError) (principals map[string]bool, incomplete string) {
+	for _, e := range collectionErrors {
+		if e.Dataset == "users" || e.Dataset == "groups" || e.Dataset == "servicePrincipals" {
+			return nil, e.Dataset
+		}
+	}
+
+	principals = make(map[string]bool)
+	for _, section := range []string{"users", "groups", "servicePrincipals"} {
+		objects, _ := azureAD[section].([]interface{})
+		for _, object := range objects {
+			if objectMap, ok := object.(map[string]interface{}); ok {
+				if id, _ := objectMap["id"].(string); id != "" {
+					principals[strings.ToLower(id)] = true
+				}
+			}
+		}
+	}
+	return principals, ""
+}
+
+// markSubscriptionOrphans flags the orphaned assignments in every role
+// assignment section of one subscription's azure_resources entry
+func markSubscriptionOrphans(principals map[string]bool, subData map[string]interface{}) int {
+	orphaned := 0
+	for _, section := range rbacAssignmentSections {
+		assignments, _ := subData[section].([]interface{})
+		orphaned += markOrphans(principals, assignments)
+	}
+	return orphaned
+}
+
+// markOrphans sets orphanedAssignment on the assignments whose principal is
+// not in principals and clears it on the rest
+func markOrphans(principals map[string]bool, assignments []interface{}) int {
+	orphaned := 0
+	for _, assignment := range assignments {
+		a, ok := assignment.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		principalID, _, _ := rbacAssignmentFields(a)
+		if principalID == "" || strings.EqualFold(rbacAssignmentPrincipalType(a), "ForeignGroup") || principals[strings.ToLower(principalID)] {
+			delete(a, "orphanedAssignment")
+			continue
+		}
+		a["orphanedAssignment"] = true
+		orphaned++
+	}
+	return orphaned
+}
+
+// rbacAssignmentPrincipalType reads the principal type of an ARM or flattened
+// role assignment
+func rbacAssignmentPrincipalType(assignment map[string]interface{}) string {
+	if properties, ok := assignment["properties"].(map[string]interface{}); ok {
+		assignment = properties
+	}
+	principalType, _ := assignment["principalType"].(string)
+	return principalType
+}
+
+// countOrphanedAssignments counts the role assignments flagged by
+// markOrphanedAssignments
+func countOrphanedAssignments(o *ConsolidatedOutput) int {
+	count := 0
+	countFlagged := func(assignments []interface{}) {
+		for _, assignment := range assignments {
+			if a, ok := assignment.(map[string]interface{}); ok && a["orphanedAssignment"] == true {
+				count++
+			}
+		}
+	}
+	for _, subData := range o.AzureResources {
+		subDataMap, _ := subData.(map[string]interface{})
+		for _, section := range rbacAssignmentSections {
+			assignments, _ := subDataMap[section].([]interface{})
+			countFlagged(assignments)
+		}
+	}
+	countFlagged(o.ManagementGroupRBAC)
+	return count
+}
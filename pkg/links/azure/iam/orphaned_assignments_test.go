@@ -0,0 +1,49 @@
+package iam
+
+import (
+	"testing"
+
+	"github.com/praetorian-inc/janus-framework/pkg/chain/cfg"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMarkOrphanedAssignments(t *testing.T) {
+	armAssignment := func(principalID, principalType string) map[string]interface{} {
+		return map[string]interface{}{"properties": map[string]interface{}{"principalId": principalID, "principalType": principalType}}
+	}
+	live := armAssignment("U-1", "User")
+	deleted := armAssignment("sp-deleted", "ServicePrincipal")
+	foreign := armAssignment("g-other-tenant", "ForeignGroup")
+	stale := map[string]interface{}{"principalId": "g-1", "orphanedAssignment": true}
+	mgDeleted := map[string]interface{}{"principalId": "u-deleted", "scope": "/providers/Microsoft.Management/managementGroups/mg-1"}
+
+	o := &ConsolidatedOutput{
+		AzureAD: map[string]interface{}{
+			"users":             []interface{}{map[string]interface{}{"id": "u-1"}},
+			"groups":            []interface{}{map[string]interface{}{"id": "g-1"}},
+			"servicePrincipals": []interface{}{map[string]interface{}{"id": "sp-1"}},
+		},
+		AzureResources: map[string]interface{}{
+			"sub-1": map[string]interface{}{
+				"subscriptionRoleAssignments":  []interface{}{live, deleted, foreign},
+				"resourceGroupRoleAssignments": []interface{}{stale},
+			},
+		},
+		ManagementGroupRBAC: []interface{}{mgDeleted},
+	}
+
+	orphaned, checked := markOrphanedAssignments(cfg.NewLogger(), o)
+	assert.True(t, checked)
+	assert.Equal(t, 2, orphaned)
+	assert.Equal(t, true, deleted["orphanedAssignment"])
+	assert.Equal(t, true, mgDeleted["orphanedAssignment"])
+	assert.NotContains(t, live, "orphanedAssignment", "principal IDs match case-insensitively")
+	assert.NotContains(t, foreign, "orphanedAssignment", "groups of another tenant are never in the directory")
+	assert.NotContains(t, stale, "orphanedAssignment", "a flag carried forward from a prior dump is cleared once the principal exists")
+	assert.Equal(t, 2, o.Summarize().OrphanedRoleAssignments)
+
+	o.CollectionErrors = []CollectionError{{Dataset: "servicePrincipals", Scope: "tenant", Message: "forbidden"}}
+	orphaned, checked = markOrphanedAssignments(cfg.NewLogger(), o)
+	assert.False(t, checked, "an incomplete directory would flag live principals")
+	assert.Zero(t, orphaned)
+}
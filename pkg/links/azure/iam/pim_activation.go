@@ -0,0 +1,91 @@
+package iam
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Friction each PIM activation requirement adds. Approval needs a second
+// person and weighs the most; MFA and an authentication context need the
+// principal's strong credential; justification and a ticket number only slow
+// an attacker down.
+const (
+	activationFrictionApproval      = 4
+	activationFrictionStrongAuth    = 2
+	activationFrictionJustification = 1
+	activationFrictionTicketing     = 1
+)
+
+// friction sums the weight of the requirements a PIM policy places on
+// activation, 0 when the eligible principal can activate at will
+func (g activationGuardrails) friction() int {
+	friction := 0
+	if g.approval {
+		friction += activationFrictionApproval
+	}
+	if g.mfa || g.authenticationContext {
+		friction += activationFrictionStrongAuth
+	}
+	if g.justification {
+		friction += activationFrictionJustification
+	}
+	if g.ticketing {
+		friction += activationFrictionTicketing
+	}
+	return friction
+}
+
+// frictionLevel names the strongest requirement on activation
+func (g activationGuardrails) frictionLevel() string {
+	switch {
+	case g.approval:
+		return "High"
+	case g.mfa || g.authenticationContext:
+		return "Medium"
+	case g.justification || g.ticketing:
+		return "Low"
+	default:
+		return "None"
+	}
+}
+
+// buildEligibleActivations emits one record per PIM eligible directory role
+// assignment with what activating it requires, read from the role's
+// management policy. Roles without a collected policy are reported with
+// policyCollected false and an Unknown friction level rather than as
+// unguarded. The importer turns each record into a CAN_ACTIVATE edge.
+func buildEligibleActivations(o *ConsolidatedOutput) []interface{} {
+	activations := []interface{}{}
+	guardrails := pimActivationGuardrails(o)
+
+	for _, e := range pimEligibleAssignments(o) {
+		activation := map[string]interface{}{
+			"principalId":          e.principalID,
+			"principalDisplayName": e.displayName,
+			"roleTemplateId":       e.templateID,
+			"roleName":             e.roleName,
+			"directoryScopeId":     e.directoryScopeID,
+		}
+		g, ok := guardrails[e.templateID]
+		activation["policyCollected"] = ok
+		activation["approvalRequired"] = g.approval
+		activation["mfaRequired"] = g.mfa
+		activation["authenticationContextRequired"] = g.authenticationContext
+		activation["justificationRequired"] = g.justification
+		activation["ticketingRequired"] = g.ticketing
+		activation["maximumActivationDuration"] = g.maximumDuration
+		activation["activationFriction"] = g.friction()
+		if ok {
+			activation["activationFrictionLevel"] = g.frictionLevel()
+		} else {
+			activation["activationFrictionLevel"] = "Unknown"
+		}
+		activations = append(activations, activation)
+	}
+
+	sort.SliceStable(activations, func(i, j int) bool {
+		a, b := activations[i].(map[string]interface{}), activations[j].(map[string]interface{})
+		return fmt.Sprint(a["roleName"], a["principalId"]) < fmt.Sprint(b["roleName"], b["principalId"])
+	})
+	return activations
+}
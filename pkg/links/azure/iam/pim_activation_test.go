@@ -0,0 +1,47 @@
+package iam
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildEligibleActivations(t *testing.T) {
+	var output ConsolidatedOutput
+	require.NoError(t, json.Unmarshal([]byte(pimGuardrailsFixture), &output))
+
+	activations := buildEligibleActivations(&output)
+	require.Len(t, activations, 4, "one record per eligible assignment, privileged or not")
+
+	readers := activations[0].(map[string]interface{})
+	assert.Equal(t, "Directory Readers", readers["roleName"])
+	assert.Equal(t, "sp-deploy", readers["principalId"])
+	assert.Equal(t, "deploy-bot", readers["principalDisplayName"])
+	assert.Equal(t, false, readers["policyCollected"])
+	assert.Equal(t, "Unknown", readers["activationFrictionLevel"], "a role without a collected policy is not reported as unguarded")
+
+	alice := activations[1].(map[string]interface{})
+	assert.Equal(t, "u-alice", alice["principalId"])
+	assert.Equal(t, "62e90394-69f5-4237-9190-012177145e10", alice["roleTemplateId"])
+	assert.Equal(t, true, alice["approvalRequired"])
+	assert.Equal(t, true, alice["mfaRequired"])
+	assert.Equal(t, true, alice["justificationRequired"])
+	assert.Equal(t, false, alice["ticketingRequired"])
+	assert.Equal(t, "PT8H", alice["maximumActivationDuration"])
+	assert.Equal(t, activationFrictionApproval+activationFrictionStrongAuth+activationFrictionJustification, alice["activationFriction"])
+	assert.Equal(t, "High", alice["activationFrictionLevel"])
+
+	userAdmin := activations[3].(map[string]interface{})
+	assert.Equal(t, "User Administrator", userAdmin["roleName"])
+	assert.Equal(t, activationFrictionJustification, userAdmin["activationFriction"])
+	assert.Equal(t, "Low", userAdmin["activationFrictionLevel"])
+}
+
+func TestActivationGuardrailsFrictionLevel(t *testing.T) {
+	assert.Equal(t, "None", activationGuardrails{}.frictionLevel())
+	assert.Equal(t, 0, activationGuardrails{}.friction())
+	assert.Equal(t, "Medium", activationGuardrails{authenticationContext: true, ticketing: true}.frictionLevel())
+	assert.Equal(t, activationFrictionStrongAuth, activationGuardrails{mfa: true, authenticationContext: true}.friction(), "MFA and an authentication context are one strong authentication step")
+}
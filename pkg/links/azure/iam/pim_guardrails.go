@@ -0,0 +1,236 @@
+package iam
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/praetorian-inc/janus-framework/pkg/chain/cfg"
+)
+
+// roleManagementPolicyAssignmentsEndpoint lists the PIM policy of every
+// directory role, with the policy rules expanded so activation requirements
+// can be read without a request per policy
+const roleManagementPolicyAssignmentsEndpoint = "/policies/roleManagementPolicyAssignments?$filter=scopeId%20eq%20'/'%20and%20scopeType%20eq%20'DirectoryRole'&$expand=policy($expand=rules)"
+
+// activationGuardrails are the requirements a PIM policy places on a
+// principal activating an eligible assignment
+type activationGuardrails struct {
+	approval              bool
+	mfa                   bool
+	justification         bool
+	ticketing             bool
+	authenticationContext bool
+	maximumDuration       string
+}
+
+// weak reports whether activation needs neither approval nor a fresh strong
+// authentication, which leaves the eligible principal effectively always admin
+func (g activationGuardrails) weak() bool {
+	return !g.approval && !g.mfa && !g.authenticationContext
+}
+
+// collectRoleManagementPolicyAssignments lists directory role PIM policies
+// using a Graph token
+func (l *IAMComprehensiveCollectorLink) collectRoleManagementPolicyAssignments(graphAccessToken string) ([]interface{}, error) {
+	return l.collectPaginatedGraphData(graphAccessToken, graphV1, roleManagementPolicyAssignmentsEndpoint)
+}
+
+// collectRoleManagementPolicyAssignmentsSDK lists directory role PIM policies
+func (l *SDKComprehensiveCollectorLink) collectRoleManagementPolicyAssignmentsSDK(ctx context.Context) ([]interface{}, error) {
+	accessToken, err := l.getAccessToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return l.collectPaginatedGraphDataSDK(accessToken, graphV1, roleManagementPolicyAssignmentsEndpoint)
+}
+
+// pimActivationGuardrails reads the end user activation rules of each
+// directory role's PIM policy, keyed by lowercased role template ID
+func pimActivationGuardrails(o *ConsolidatedOutput) map[string]activationGuardrails {
+	guardrails := make(map[string]activationGuardrails)
+	assignments, _ := o.PIM["role_management_policy_assignments"].([]interface{})
+	for _, assignment := range assignments {
+		a, ok := assignment.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		roleID, _ := a["roleDefinitionId"].(string)
+		policy, _ := a["policy"].(map[string]interface{})
+		rules, _ := policy["rules"].([]interface{})
+		if roleID == "" || len(rules) == 0 {
+			continue
+		}
+
+		var g activationGuardrails
+		for _, rule := range rules {
+			r, ok := rule.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			switch id, _ := r["id"].(string); id {
+			case "Enablement_EndUser_Assignment":
+				enabled, _ := r["enabledRules"].([]interface{})
+				for _, name := range enabled {
+					switch name {
+					case "MultiFactorAuthentication":
+						g.mfa = true
+					case "Justification":
+						g.justification = true
+					case "Ticketing":
+						g.ticketing = true
+					}
+				}
+			case "Approval_EndUser_Assignment":
+				setting, _ := r["setting"].(map[string]interface{})
+				g.approval, _ = setting["isApprovalRequired"].(bool)
+			case "AuthenticationContext_EndUser_Assignment":
+				g.authenticationContext, _ = r["isEnabled"].(bool)
+			case "Expiration_EndUser_Assignment":
+				g.maximumDuration, _ = r["maximumDuration"].(string)
+			}
+		}
+		guardrails[strings.ToLower(roleID[strings.LastIndex(roleID, "/")+1:])] = g
+	}
+	return guardrails
+}
+
+// pimEligibleAssignment is a PIM eligible directory role assignment read from
+// either the SDK or the legacy PIM API shape
+type pimEligibleAssignment struct {
+	principalID string
+	displayName string
+	templateID  string
+	roleName    string
+	// directoryScopeID is "/" for a tenant-wide assignment, empty in the
+	// legacy PIM API shape
+	directoryScopeID string
+}
+
+// pimEligibleAssignments returns the eligible directory role assignments
+func pimEligibleAssignments(o *ConsolidatedOutput) []pimEligibleAssignment {
+	var eligible []pimEligibleAssignment
+	assignments, _ := o.PIM["eligible_assignments"].([]interface{})
+	for _, assignment := range assignments {
+		a, ok := assignment.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		principalID, templateID := pimAssignmentPrincipalAndRole(a)
+		if principalID == "" || templateID == "" {
+			continue
+		}
+		e := pimEligibleAssignment{principalID: principalID, templateID: strings.ToLower(templateID)}
+		e.displayName, _ = a["principalDisplayName"].(string)
+		e.directoryScopeID, _ = a["directoryScopeId"].(string)
+		if subject, ok := a["subject"].(map[string]interface{}); ok && e.displayName == "" {
+			e.displayName, _ = subject["displayName"].(string)
+		}
+		e.roleName, _ = a["roleDefinitionDisplayName"].(string)
+		if roleDefinition, ok := a["roleDefinition"].(map[string]interface{}); ok && e.roleName == "" {
+			e.roleName, _ = roleDefinition["displayName"].(string)
+		}
+		if name, ok := privilegedDirectoryRoles[e.templateID]; ok {
+			e.roleName = name
+		}
+		if e.roleName == "" {
+			e.roleName = templateID
+		}
+		eligible = append(eligible, e)
+	}
+	return eligible
+}
+
+// eligiblePrincipalName names the principal of an eligible assignment,
+// preferring the directory objects in the dump
+func eligiblePrincipalName(principals map[string]reportPrincipal, e pimEligibleAssignment) map[string]interface{} {
+	row := reportRow(principals, e.principalID)
+	if row["principalType"] == "Unknown" && e.displayName != "" {
+		row["principalName"] = e.displayName
+	}
+	return row
+}
+
+// buildPIMGuardrailFindings flags principals eligible for a privileged
+// directory role whose PIM policy lets them activate it with neither approval
+// nor MFA. Roles without a collected policy are not reported.
+func buildPIMGuardrailFindings(o *ConsolidatedOutput) []interface{} {
+	findings := []interface{}{}
+	principals := indexReportPrincipals(o)
+	guardrails := pimActivationGuardrails(o)
+
+	for _, e := range pimEligibleAssignments(o) {
+		if _, privileged := privilegedDirectoryRoles[e.templateID]; !privileged {
+			continue
+		}
+		g, ok := guardrails[e.templateID]
+		if !ok || !g.weak() {
+			continue
+		}
+		finding := eligiblePrincipalName(principals, e)
+		finding["type"] = "PIMEligibleWithoutActivationGuardrails"
+		finding["severity"] = "High"
+		finding["description"] = fmt.Sprintf("%s is eligible for %s and can activate it without approval or MFA, so the eligibility is effectively a standing assignment",
+			finding["principalName"], e.roleName)
+		finding["roleName"] = e.roleName
+		finding["roleTemplateId"] = e.templateID
+		finding["justificationRequired"] = g.justification
+		finding["maximumActivationDuration"] = g.maximumDuration
+		findings = append(findings, finding)
+	}
+
+	sortFindings(findings, "roleName", "principalName")
+	return findings
+}
+
+// pimEligibilityReport lists each directory role with PIM eligible
+// assignments, the eligible principals, and what activating the role requires
+func pimEligibilityReport(o *ConsolidatedOutput, principals map[string]reportPrincipal) []map[string]interface{} {
+	guardrails := pimActivationGuardrails(o)
+	byRole := make(map[string]map[string]interface{})
+	for _, e := range pimEligibleAssignments(o) {
+		row, ok := byRole[e.templateID]
+		if !ok {
+			_, privileged := privilegedDirectoryRoles[e.templateID]
+			row = map[string]interface{}{
+				"roleName":       e.roleName,
+				"roleTemplateId": e.templateID,
+				"privileged":     privileged,
+				"eligible":       []string{},
+			}
+			if g, ok := guardrails[e.templateID]; ok {
+				row["policyCollected"] = true
+				row["approvalRequired"] = g.approval
+				row["mfaRequired"] = g.mfa
+				row["justificationRequired"] = g.justification
+				row["ticketingRequired"] = g.ticketing
+				row["authenticationContextRequired"] = g.authenticationContext
+				row["maximumActivationDuration"] = g.maximumDuration
+				row["weakActivation"] = g.weak()
+			} else {
+				row["policyCollected"] = false
+			}
+			byRole[e.templateID] = row
+		}
+		name := eligiblePrincipalName(principals, e)["principalName"]
+		row["eligible"] = append(row["eligible"].([]string), fmt.Sprint(name))
+	}
+
+	rows := make([]map[string]interface{}, 0, len(byRole))
+	for _, row := range byRole {
+		sort.Strings(row["eligible"].([]string))
+		row["eligibleCount"] = len(row["eligible"].([]string))
+		rows = append(rows, row)
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		return fmt.Sprint(rows[i]["roleName"]) < fmt.Sprint(rows[j]["roleName"])
+	})
+	return rows
+}
+
+// logPIMGuardrailFindings reports privileged eligibility that activates without guardrails
+func logPIMGuardrailFindings(logger *cfg.Logger, findings []interface{}) {
+	logFindings(logger, findings, "🚨 %d PIM eligible privileged role assignments activate without approval or MFA", "Weakly guarded PIM eligibility",
+		"principal", "principalName", "role", "roleName")
+}
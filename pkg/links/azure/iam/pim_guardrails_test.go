@@ -0,0 +1,79 @@
+package iam
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const pimGuardrailsFixture = `{
+  "azure_ad": {
+    "users": [
+      {"id": "u-alice", "userPrincipalName": "alice@contoso.com"},
+      {"id": "u-bob", "userPrincipalName": "bob@contoso.com"}
+    ]
+  },
+  "pim": {
+    "eligible_assignments": [
+      {"principalId": "u-alice", "roleDefinitionId": "62e90394-69f5-4237-9190-012177145e10", "assignmentState": "Eligible"},
+      {"principalId": "u-bob", "roleDefinitionId": "62e90394-69f5-4237-9190-012177145e10", "assignmentState": "Eligible"},
+      {"principalId": "u-bob", "roleDefinitionId": "fe930be7-5e62-47db-91af-98c3a49a38b1", "assignmentState": "Eligible"},
+      {"subject": {"id": "sp-deploy", "displayName": "deploy-bot"}, "roleDefinition": {"templateId": "88d8e3e3-8f55-4a1e-953a-9b9898b8876b", "displayName": "Directory Readers"}}
+    ],
+    "role_management_policy_assignments": [
+      {
+        "roleDefinitionId": "62e90394-69f5-4237-9190-012177145e10",
+        "policy": {"rules": [
+          {"id": "Enablement_EndUser_Assignment", "enabledRules": ["MultiFactorAuthentication", "Justification"]},
+          {"id": "Approval_EndUser_Assignment", "setting": {"isApprovalRequired": true}},
+          {"id": "Expiration_EndUser_Assignment", "maximumDuration": "PT8H"}
+        ]}
+      },
+      {
+        "roleDefinitionId": "fe930be7-5e62-47db-91af-98c3a49a38b1",
+        "policy": {"rules": [
+          {"id": "Enablement_EndUser_Assignment", "enabledRules": ["Justification"]},
+          {"id": "Approval_EndUser_Assignment", "setting": {"isApprovalRequired": false}},
+          {"id": "AuthenticationContext_EndUser_Assignment", "isEnabled": false}
+        ]}
+      }
+    ]
+  }
+}`
+
+func TestBuildPIMGuardrailFindings(t *testing.T) {
+	var output ConsolidatedOutput
+	require.NoError(t, json.Unmarshal([]byte(pimGuardrailsFixture), &output))
+
+	findings := buildPIMGuardrailFindings(&output)
+	require.Len(t, findings, 1, "Global Administrator requires approval and MFA; Directory Readers is not privileged")
+
+	finding := findings[0].(map[string]interface{})
+	assert.Equal(t, "bob@contoso.com", finding["principalName"])
+	assert.Equal(t, "User Administrator", finding["roleName"])
+	assert.Equal(t, true, finding["justificationRequired"])
+}
+
+func TestPIMEligibilityReport(t *testing.T) {
+	var output ConsolidatedOutput
+	require.NoError(t, json.Unmarshal([]byte(pimGuardrailsFixture), &output))
+
+	rows := pimEligibilityReport(&output, indexReportPrincipals(&output))
+	require.Len(t, rows, 3)
+
+	readers, globalAdmin, userAdmin := rows[0], rows[1], rows[2]
+	assert.Equal(t, "Directory Readers", readers["roleName"])
+	assert.Equal(t, []string{"deploy-bot"}, readers["eligible"])
+	assert.Equal(t, false, readers["policyCollected"])
+
+	assert.Equal(t, "Global Administrator", globalAdmin["roleName"])
+	assert.Equal(t, 2, globalAdmin["eligibleCount"])
+	assert.Equal(t, []string{"alice@contoso.com", "bob@contoso.com"}, globalAdmin["eligible"])
+	assert.Equal(t, true, globalAdmin["approvalRequired"])
+	assert.Equal(t, "PT8H", globalAdmin["maximumActivationDuration"])
+	assert.Equal(t, false, globalAdmin["weakActivation"])
+
+	assert.Equal(t, true, userAdmin["weakActivation"])
+}
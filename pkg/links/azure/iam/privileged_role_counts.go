@@ -0,0 +1,109 @@
+package iam
+
+import (
+	"sort"
+	"strings"
+)
+
+const (
+	contributorRoleGUID             = "b24988ac-6180-42a0-ab88-20f7382dd24c"
+	userAccessAdministratorRoleGUID = "18d7d88d-d35e-4fb5-a5c3-7773c20a72d9"
+	rbacAdministratorRoleGUID       = "f58310d9-a9f6-439a-9e8d-f62e7b41a168"
+)
+
+// broadRoleAssigneeThresholds is the assignee count above which a role at one
+// scope is reported as broadly assigned. Contributor is routinely held by
+// deployment identities, so it gets more room than the roles that control access.
+var broadRoleAssigneeThresholds = map[string]int{
+	ownerRoleGUID:                   3,
+	contributorRoleGUID:             10,
+	userAccessAdministratorRoleGUID: 3,
+	rbacAdministratorRoleGUID:       3,
+}
+
+// privilegedRoleCountsReport counts who holds Owner, Contributor, User Access
+// Administrator and Role Based Access Control Administrator at each
+// subscription, management group and the tenant root. Rows are flagged when the
+// count is unusually high and when Owner is held by a group or a guest. User
+// Access Administrator and RBAC Administrator are always flagged: they exist to
+// grant roles, so every holder can grant themselves Owner.
+func privilegedRoleCountsReport(o *ConsolidatedOutput, principals map[string]reportPrincipal) []map[string]interface{} {
+	type scopeRole struct {
+		scope   string
+		roleID  string
+		members map[string]bool
+	}
+	counts := make(map[string]*scopeRole)
+	for principalID, grants := range privilegedPrincipalGrants(o) {
+		for _, grant := range grants {
+			if grant["type"] != "azureRBAC" {
+				continue
+			}
+			scope, _ := grant["scope"].(string)
+			if level := rbacScopeLevel(scope); level != "subscription" && level != "managementGroup" && level != "root" {
+				continue
+			}
+			roleID, _ := grant["roleDefinitionId"].(string)
+			key := strings.ToLower(scope) + "|" + roleID
+			if counts[key] == nil {
+				counts[key] = &scopeRole{scope: scope, roleID: roleID, members: make(map[string]bool)}
+			}
+			counts[key].members[principalID] = true
+		}
+	}
+
+	rows := []map[string]interface{}{}
+	for _, entry := range counts {
+		byKind := map[string]int{}
+		guests := 0
+		for principalID := range entry.members {
+			principal, ok := principals[principalID]
+			if !ok {
+				principal.kind = "Unknown"
+			}
+			byKind[principal.kind]++
+			if strings.EqualFold(principal.userType, "Guest") {
+				guests++
+			}
+		}
+
+		canGrantRoles := entry.roleID == ownerRoleGUID || entry.roleID == userAccessAdministratorRoleGUID || entry.roleID == rbacAdministratorRoleGUID
+		flags := []string{}
+		if len(entry.members) > broadRoleAssigneeThresholds[entry.roleID] {
+			flags = append(flags, "highAssigneeCount")
+		}
+		if entry.roleID == ownerRoleGUID && byKind["Group"] > 0 {
+			flags = append(flags, "ownerAssignedToGroup")
+		}
+		if entry.roleID == ownerRoleGUID && guests > 0 {
+			flags = append(flags, "ownerAssignedToGuest")
+		}
+		if entry.roleID == userAccessAdministratorRoleGUID || entry.roleID == rbacAdministratorRoleGUID {
+			flags = append(flags, "selfEscalation")
+		}
+
+		rows = append(rows, map[string]interface{}{
+			"scope":             entry.scope,
+			"scopeLevel":        rbacScopeLevel(entry.scope),
+			"roleName":          highPrivilegeRBACRoles[entry.roleID],
+			"roleDefinitionId":  entry.roleID,
+			"assignees":         len(entry.members),
+			"users":             byKind["User"],
+			"groups":            byKind["Group"],
+			"servicePrincipals": byKind["ServicePrincipal"],
+			"unknown":           byKind["Unknown"],
+			"guests":            guests,
+			"canGrantRoles":     canGrantRoles,
+			"flags":             flags,
+		})
+	}
+
+	// Rows carry no principal, so order them here by scope and role
+	sort.Slice(rows, func(i, j int) bool {
+		if scopeA, scopeB := rows[i]["scope"].(string), rows[j]["scope"].(string); scopeA != scopeB {
+			return scopeA < scopeB
+		}
+		return rows[i]["roleName"].(string) < rows[j]["roleName"].(string)
+	})
+	return rows
+}
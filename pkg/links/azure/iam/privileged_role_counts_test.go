@@ -0,0 +1,69 @@
+package iam
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const privilegedRoleCountsFixture = `{
+  "azure_ad": {
+    "users": [
+      {"id": "u1", "userPrincipalName": "alice@contoso.com", "userType": "Member"},
+      {"id": "u2", "userPrincipalName": "bob@contoso.com", "userType": "Member"},
+      {"id": "u-guest", "userPrincipalName": "vendor_example.com#EXT#@contoso.onmicrosoft.com", "userType": "Guest"}
+    ],
+    "groups": [{"id": "g1", "displayName": "Platform Team"}],
+    "servicePrincipals": [{"id": "sp1", "displayName": "Deployer"}]
+  },
+  "azure_resources": {
+    "sub-1": {
+      "subscriptionRoleAssignments": [
+        {"properties": {"principalId": "u1", "roleDefinitionId": "/subscriptions/sub-1/providers/Microsoft.Authorization/roleDefinitions/8e3af657-a8ff-443c-a75c-2fe8c4bcb635", "scope": "/subscriptions/sub-1"}},
+        {"properties": {"principalId": "u2", "roleDefinitionId": "/subscriptions/sub-1/providers/Microsoft.Authorization/roleDefinitions/8e3af657-a8ff-443c-a75c-2fe8c4bcb635", "scope": "/subscriptions/sub-1"}},
+        {"properties": {"principalId": "g1", "roleDefinitionId": "/subscriptions/sub-1/providers/Microsoft.Authorization/roleDefinitions/8e3af657-a8ff-443c-a75c-2fe8c4bcb635", "scope": "/subscriptions/sub-1"}},
+        {"properties": {"principalId": "u-guest", "roleDefinitionId": "/subscriptions/sub-1/providers/Microsoft.Authorization/roleDefinitions/8e3af657-a8ff-443c-a75c-2fe8c4bcb635", "scope": "/subscriptions/sub-1"}},
+        {"properties": {"principalId": "sp1", "roleDefinitionId": "/subscriptions/sub-1/providers/Microsoft.Authorization/roleDefinitions/18d7d88d-d35e-4fb5-a5c3-7773c20a72d9", "scope": "/subscriptions/sub-1"}}
+      ],
+      "resourceGroupRoleAssignments": [
+        {"principalId": "u2", "roleDefinitionId": "8e3af657-a8ff-443c-a75c-2fe8c4bcb635", "scope": "/subscriptions/sub-1/resourceGroups/rg-1"}
+      ]
+    }
+  },
+  "management_group_rbac": [
+    {"principalId": "u1", "roleDefinitionId": "b24988ac-6180-42a0-ab88-20f7382dd24c", "scope": "/providers/Microsoft.Management/managementGroups/platform"},
+    {"principalId": "u1", "roleDefinitionId": "b24988ac-6180-42a0-ab88-20f7382dd24c", "scope": "/providers/Microsoft.Management/managementGroups/Platform"}
+  ]
+}`
+
+func TestPrivilegedRoleCountsReport(t *testing.T) {
+	var output ConsolidatedOutput
+	require.NoError(t, json.Unmarshal([]byte(privilegedRoleCountsFixture), &output))
+	output.Normalize()
+
+	rows := runOfflineReports(&output, []string{"privileged-role-counts"})["privileged-role-counts"]
+	require.Len(t, rows, 3, "resource group assignments are not counted")
+
+	contributor := rows[0]
+	assert.Equal(t, "Contributor", contributor["roleName"])
+	assert.Equal(t, "managementGroup", contributor["scopeLevel"])
+	assert.Equal(t, 1, contributor["assignees"], "scopes differing only in case are one scope")
+	assert.Empty(t, contributor["flags"])
+
+	owner := rows[1]
+	assert.Equal(t, "Owner", owner["roleName"])
+	assert.Equal(t, "/subscriptions/sub-1", owner["scope"])
+	assert.Equal(t, 4, owner["assignees"])
+	assert.Equal(t, 3, owner["users"])
+	assert.Equal(t, 1, owner["groups"])
+	assert.Equal(t, 1, owner["guests"])
+	assert.Equal(t, true, owner["canGrantRoles"])
+	assert.Equal(t, []string{"highAssigneeCount", "ownerAssignedToGroup", "ownerAssignedToGuest"}, owner["flags"])
+
+	uaa := rows[2]
+	assert.Equal(t, "User Access Administrator", uaa["roleName"])
+	assert.Equal(t, 1, uaa["servicePrincipals"])
+	assert.Equal(t, []string{"selfEscalation"}, uaa["flags"])
+}
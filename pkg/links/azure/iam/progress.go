@@ -0,0 +1,76 @@
+package iam
+
+import (
+	"sync"
+	"time"
+
+	"github.com/praetorian-inc/nebula/internal/message"
+)
+
+// progressInterval is how long a phase stays quiet between progress events
+const progressInterval = 5 * time.Second
+
+// progressStep is the share of a phase, in percent, that is always reported
+// even when it finishes within progressInterval
+const progressStep = 25
+
+// progressReporter decides which progress updates of each phase are worth
+// printing. The first and last update of a phase are always reported; in
+// between an update is reported once progressInterval has passed or the phase
+// crossed another progressStep percent since the last report.
+type progressReporter struct {
+	mu      sync.Mutex
+	now     func() time.Time
+	reports map[string]progressReport
+}
+
+// progressReport is the last reported update of a phase
+type progressReport struct {
+	at      time.Time
+	percent int
+}
+
+// due reports whether an update of phase should be emitted and records it
+func (p *progressReporter) due(phase string, done, total int) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now
+	if p.now != nil {
+		now = p.now
+	}
+	if p.reports == nil {
+		p.reports = make(map[string]progressReport)
+	}
+
+	percent := progressPercent(done, total)
+	last, seen := p.reports[phase]
+	if done >= total {
+		delete(p.reports, phase)
+		return true
+	}
+	if seen && now().Sub(last.at) < progressInterval && percent/progressStep == last.percent/progressStep {
+		return false
+	}
+	p.reports[phase] = progressReport{at: now(), percent: percent}
+	return true
+}
+
+// Progress reports that done of total items of phase have been collected.
+// Updates are throttled per phase so large tenants print a steady progress
+// line instead of one line per batch; every update is logged at debug level.
+func (l *IAMComprehensiveCollectorLink) Progress(phase string, done, total int) {
+	l.Logger.Debug("Collection progress", "phase", phase, "done", done, "total", total)
+	if !l.progress.due(phase, done, total) {
+		return
+	}
+	message.Info("[progress] %s: %d/%d (%d%%)", phase, done, total, progressPercent(done, total))
+}
+
+// progressPercent returns done as a whole percentage of total
+func progressPercent(done, total int) int {
+	if total <= 0 {
+		return 100
+	}
+	return min(100, done*100/total)
+}
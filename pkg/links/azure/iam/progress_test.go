@@ -0,0 +1,26 @@
+package iam
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProgressReporterCadence(t *testing.T) {
+	clock := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	p := progressReporter{now: func() time.Time { return clock }}
+
+	assert.True(t, p.due("roles", 20, 1000), "the first update of a phase is reported")
+	assert.False(t, p.due("roles", 40, 1000), "updates within the interval are dropped")
+	assert.True(t, p.due("members", 20, 1000), "phases are throttled separately")
+
+	clock = clock.Add(progressInterval)
+	assert.True(t, p.due("roles", 60, 1000), "an update is reported once the interval passed")
+	assert.False(t, p.due("roles", 80, 1000))
+	assert.True(t, p.due("roles", 250, 1000), "crossing a progress step is reported within the interval")
+	assert.False(t, p.due("roles", 260, 1000))
+	assert.True(t, p.due("roles", 1000, 1000), "completion is always reported")
+	assert.True(t, p.due("roles", 20, 1000), "a finished phase starts over")
+	assert.True(t, p.due("empty", 0, 0))
+}
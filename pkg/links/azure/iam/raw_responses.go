@@ -0,0 +1,291 @@
+package iam
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/praetorian-inc/janus-framework/pkg/chain/cfg"
+)
+
+// rawResponseRedacted replaces every secret removed from a raw response
+const rawResponseRedacted = "REDACTED"
+
+// rawResponseSecretKeys are JSON keys whose values are credentials, matched
+// case-insensitively
+var rawResponseSecretKeys = map[string]bool{
+	"access_token":  true,
+	"refresh_token": true,
+	"id_token":      true,
+	"client_secret": true,
+	"assertion":     true,
+	"secrettext":    true,
+}
+
+// rawResponseSecretParams are query parameters whose values are credentials,
+// matched case-insensitively. Unlike in bodies, where it holds error codes,
+// "code" here is an authorization code.
+var rawResponseSecretParams = map[string]bool{
+	"access_token":  true,
+	"client_secret": true,
+	"code":          true,
+	"sig":           true,
+}
+
+// rawResponseJWT matches bearer tokens embedded anywhere in a response
+var rawResponseJWT = regexp.MustCompile(`eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`)
+
+// rawResponseHeaders are the response headers kept in a raw response, enough
+// to correlate it with Microsoft support and replay throttling
+var rawResponseHeaders = []string{"Content-Type", "Retry-After", "Request-Id", "X-Ms-Request-Id", "Client-Request-Id", "X-Ms-Ratelimit-Remaining-Tenant-Reads"}
+
+// rawResponse is one recorded request and its response. Request headers are
+// never kept, so the Authorization header does not reach the file.
+type rawResponse struct {
+	Method      string            `json:"method"`
+	URL         string            `json:"url"`
+	RequestBody json.RawMessage   `json:"request_body,omitempty"`
+	Status      int               `json:"status"`
+	Headers     map[string]string `json:"headers,omitempty"`
+	Body        json.RawMessage   `json:"body,omitempty"`
+	RawBody     string            `json:"raw_body,omitempty"`
+}
+
+// rawResponseRecorder writes the raw JSON of every Graph, ARM and Resource
+// Graph response to a directory, one numbered file per response, with tokens
+// and secrets redacted. The files replay with newRawResponseReplayer.
+type rawResponseRecorder struct {
+	base   http.RoundTripper
+	dir    string
+	logger *cfg.Logger
+	seq    atomic.Int64
+	failed sync.Once
+}
+
+// newRawResponseRecorder creates dir and wraps base so its responses are written there
+func newRawResponseRecorder(base http.RoundTripper, dir string, logger *cfg.Logger) (*rawResponseRecorder, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create raw response directory: %v", err)
+	}
+	return &rawResponseRecorder{base: base, dir: dir, logger: logger}, nil
+}
+
+// Do lets the recorder stand in as an Azure SDK pipeline transport
+func (r *rawResponseRecorder) Do(req *http.Request) (*http.Response, error) {
+	return r.RoundTrip(req)
+}
+
+func (r *rawResponseRecorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	requestBody := rawRequestBody(req)
+	resp, err := r.base.RoundTrip(req)
+	if err != nil {
+		return resp, err
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return resp, nil
+	}
+
+	record := rawResponse{
+		Method:  req.Method,
+		URL:     redactRawResponseURL(req.URL),
+		Status:  resp.StatusCode,
+		Headers: make(map[string]string),
+	}
+	record.RequestBody = requestBody
+	for _, header := range rawResponseHeaders {
+		if value := resp.Header.Get(header); value != "" {
+			record.Headers[header] = value
+		}
+	}
+	if redacted, ok := redactRawResponseBody(body); ok {
+		record.Body = redacted
+	} else {
+		record.RawBody = rawResponseJWT.ReplaceAllString(string(body), rawResponseRedacted)
+	}
+
+	if err := r.write(req, record); err != nil {
+		r.failed.Do(func() {
+			r.logger.Warn("Failed to write raw API response, continuing without recording", "dir", r.dir, "error", err)
+		})
+	}
+	return resp, nil
+}
+
+// write stores a record as <sequence>-<method>-<host><path>.json
+func (r *rawResponseRecorder) write(req *http.Request, record rawResponse) error {
+	name := strings.Trim(strings.NewReplacer("/", "_", ".", "_", "$", "").Replace(req.URL.Host+req.URL.Path), "_")
+	if len(name) > 120 {
+		name = name[:120]
+	}
+	raw, err := json.MarshalIndent(record, "", "  ")
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(r.dir, fmt.Sprintf("%06d-%s-%s.json", r.seq.Add(1), req.Method, name))
+	return os.WriteFile(path, raw, 0600)
+}
+
+// rawRequestBody returns the redacted JSON body of a request, leaving the
+// request able to send it, or nil when there is none
+func rawRequestBody(req *http.Request) json.RawMessage {
+	if req.Body == nil || req.GetBody == nil {
+		return nil
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return nil
+	}
+	defer body.Close()
+	raw, err := io.ReadAll(body)
+	if err != nil || len(raw) == 0 {
+		return nil
+	}
+	redacted, _ := redactRawResponseBody(raw)
+	return redacted
+}
+
+// rawResponseKey identifies a request for replay by its method, redacted URL
+// and redacted body, so Graph $batch and Resource Graph queries sent to the
+// same URL are told apart
+func rawResponseKey(method, url string, body json.RawMessage) string {
+	return method + " " + url + " " + string(body)
+}
+
+// redactRawResponseURL removes credentials from the query string
+func redactRawResponseURL(u *url.URL) string {
+	redacted := *u
+	redacted.User = nil
+	query := redacted.Query()
+	for key := range query {
+		if rawResponseSecretParams[strings.ToLower(key)] {
+			query.Set(key, rawResponseRedacted)
+		}
+	}
+	redacted.RawQuery = query.Encode()
+	return redacted.String()
+}
+
+// redactRawResponseBody redacts secret keys and embedded tokens from a JSON
+// body. It reports false when body is not JSON.
+func redactRawResponseBody(body []byte) (json.RawMessage, bool) {
+	var decoded interface{}
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	if err := decoder.Decode(&decoded); err != nil {
+		return nil, false
+	}
+	redacted, err := json.Marshal(redactRawResponseValue(decoded))
+	if err != nil {
+		return nil, false
+	}
+	return redacted, true
+}
+
+func redactRawResponseValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, nested := range v {
+			if rawResponseSecretKeys[strings.ToLower(key)] {
+				v[key] = rawResponseRedacted
+				continue
+			}
+			v[key] = redactRawResponseValue(nested)
+		}
+	case []interface{}:
+		for i, nested := range v {
+			v[i] = redactRawResponseValue(nested)
+		}
+	case string:
+		return rawResponseJWT.ReplaceAllString(v, rawResponseRedacted)
+	}
+	return value
+}
+
+// rawResponseReplayer serves the responses of a raw response directory, so a
+// support bundle can be replayed against either collector in a test. Requests
+// match on method, redacted URL and redacted body; repeated requests get the
+// recorded responses in order, and the last one once they run out.
+type rawResponseReplayer struct {
+	mu        sync.Mutex
+	responses map[string][]rawResponse
+}
+
+// newRawResponseReplayer loads every response written to dir by a rawResponseRecorder
+func newRawResponseReplayer(dir string) (*rawResponseReplayer, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+
+	replayer := &rawResponseReplayer{responses: make(map[string][]rawResponse)}
+	for _, path := range paths {
+		raw, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		var record rawResponse
+		if err := json.Unmarshal(raw, &record); err != nil {
+			return nil, fmt.Errorf("failed to parse raw response %s: %v", filepath.Base(path), err)
+		}
+		// The file is indented, the key uses the compact body a request sends
+		if len(record.RequestBody) > 0 {
+			var compact bytes.Buffer
+			if err := json.Compact(&compact, record.RequestBody); err == nil {
+				record.RequestBody = compact.Bytes()
+			}
+		}
+		key := rawResponseKey(record.Method, record.URL, record.RequestBody)
+		replayer.responses[key] = append(replayer.responses[key], record)
+	}
+	return replayer, nil
+}
+
+// Do lets the replayer stand in as an Azure SDK pipeline transport
+func (r *rawResponseReplayer) Do(req *http.Request) (*http.Response, error) {
+	return r.RoundTrip(req)
+}
+
+func (r *rawResponseReplayer) RoundTrip(req *http.Request) (*http.Response, error) {
+	key := rawResponseKey(req.Method, redactRawResponseURL(req.URL), rawRequestBody(req))
+	r.mu.Lock()
+	records := r.responses[key]
+	if len(records) == 0 {
+		r.mu.Unlock()
+		return nil, fmt.Errorf("no recorded response for %s", key)
+	}
+	record := records[0]
+	if len(records) > 1 {
+		r.responses[key] = records[1:]
+	}
+	r.mu.Unlock()
+
+	header := make(http.Header)
+	for name, value := range record.Headers {
+		header.Set(name, value)
+	}
+	body := []byte(record.Body)
+	if len(body) == 0 {
+		body = []byte(record.RawBody)
+	}
+	return &http.Response{
+		StatusCode: record.Status,
+		Status:     fmt.Sprintf("%d %s", record.Status, http.StatusText(record.Status)),
+		Header:     header,
+		Body:       io.NopCloser(bytes.NewReader(body)),
+		Request:    req,
+	}, nil
+}
@@ -0,0 +1,147 @@
+package iam
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/praetorian-inc/janus-framework/pkg/chain/cfg"
+	"github.com/praetorian-inc/nebula/internal/message"
+)
+
+// RBAC deduplication keys accepted by --rbac-dedup
+const (
+	rbacDedupByID     = "id"
+	rbacDedupByAccess = "access"
+)
+
+// roleAssignmentSections are the per-subscription role assignment sections in
+// the order they are deduplicated. An assignment kept in an earlier section
+// wins over its duplicates in later ones.
+var roleAssignmentSections = []struct {
+	key   string
+	label string
+}{
+	{"subscriptionRoleAssignments", "Subscription"},
+	{"resourceGroupRoleAssignments", "Resource Group"},
+	{"resourceLevelRoleAssignments", "Resource-level"},
+	{"managementGroupRoleAssignments", "Management Group"},
+	{"tenantRoleAssignments", "Tenant"},
+}
+
+// RBACDeduplication records how many role assignments were collected across
+// all subscriptions and how many remained after deduplication
+type RBACDeduplication struct {
+	Key               string `json:"key"` // "id" or "access"
+	RawAssignments    int    `json:"raw_assignments"`
+	UniqueAssignments int    `json:"unique_assignments"`
+}
+
+// rbacDeduplicator removes duplicate role assignments from each subscription's
+// ARM data and keeps run-wide totals. Subscriptions are collected in parallel,
+// so the totals are guarded by a mutex.
+//
+// Deduplicating by id collapses an assignment returned more than once under the
+// same resource ID. Deduplicating by access additionally collapses assignments
+// that grant the same principal the same role at the same scope, which is how
+// one assignment shows up when ARM and Resource Graph spell its ID or scope
+// differently.
+type rbacDeduplicator struct {
+	byAccess bool
+	mu       sync.Mutex
+	raw      int
+	unique   int
+}
+
+func newRBACDeduplicator(key string) *rbacDeduplicator {
+	return &rbacDeduplicator{byAccess: strings.EqualFold(key, rbacDedupByAccess)}
+}
+
+// rbacDedupKeyArg reads --rbac-dedup, defaulting to deduplication by id
+func rbacDedupKeyArg(arg any) string {
+	if key, err := cfg.As[string](arg); err == nil && strings.EqualFold(key, rbacDedupByAccess) {
+		return rbacDedupByAccess
+	}
+	return rbacDedupByID
+}
+
+// deduplicate rewrites the role assignment sections of one subscription's ARM
+// data in place. Assignments without an id cannot be traced back to ARM and
+// are dropped, as they always have been.
+func (d *rbacDeduplicator) deduplicate(logger *cfg.Logger, azurermData map[string]interface{}) {
+	seenIDs := make(map[string]bool)
+	seenAccess := make(map[string]bool)
+	raw, unique := 0, 0
+
+	for _, section := range roleAssignmentSections {
+		assignments, ok := azurermData[section.key].([]interface{})
+		if !ok {
+			continue
+		}
+
+		kept := make([]interface{}, 0, len(assignments))
+		for _, assignment := range assignments {
+			assignmentMap, ok := assignment.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			id, _ := assignmentMap["id"].(string)
+			if id == "" || seenIDs[strings.ToLower(id)] {
+				continue
+			}
+			seenIDs[strings.ToLower(id)] = true
+
+			if d.byAccess {
+				if key := roleAssignmentAccessKey(assignmentMap); key != "" {
+					if seenAccess[key] {
+						continue
+					}
+					seenAccess[key] = true
+				}
+			}
+			kept = append(kept, assignment)
+		}
+
+		azurermData[section.key] = kept
+		raw += len(assignments)
+		unique += len(kept)
+		logger.Info(fmt.Sprintf("%s RBAC deduplication", section.label), "original", len(assignments), "unique", len(kept), "duplicates_removed", len(assignments)-len(kept))
+	}
+
+	d.mu.Lock()
+	d.raw += raw
+	d.unique += unique
+	d.mu.Unlock()
+	logger.Info("Total RBAC assignment deduplication complete", "key", d.key(), "raw_assignments", raw, "total_unique_assignments", unique)
+}
+
+// roleAssignmentAccessKey identifies the access an assignment grants: the
+// principal, the role definition GUID and the normalized scope. It is empty
+// when any of them is missing.
+func roleAssignmentAccessKey(assignment map[string]interface{}) string {
+	principalID, roleID, scope := rbacAssignmentFields(assignment)
+	if principalID == "" || roleID == "" || scope == "" {
+		return ""
+	}
+	return strings.ToLower(principalID) + "|" + roleID + "|" + normalizeScope(scope)
+}
+
+func (d *rbacDeduplicator) key() string {
+	if d.byAccess {
+		return rbacDedupByAccess
+	}
+	return rbacDedupByID
+}
+
+// stats returns the run-wide totals for the collection metadata
+func (d *rbacDeduplicator) stats() *RBACDeduplication {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return &RBACDeduplication{Key: d.key(), RawAssignments: d.raw, UniqueAssignments: d.unique}
+}
+
+// logRBACDeduplication prints the raw and deduplicated role assignment counts
+func logRBACDeduplication(stats *RBACDeduplication) {
+	message.Info("Total RBAC assignments: %d collected, %d after deduplication by %s (%d duplicates removed)",
+		stats.RawAssignments, stats.UniqueAssignments, stats.Key, stats.RawAssignments-stats.UniqueAssignments)
+}
@@ -0,0 +1,78 @@
+package iam
+
+import (
+	"testing"
+
+	"github.com/praetorian-inc/janus-framework/pkg/chain/cfg"
+	"github.com/stretchr/testify/assert"
+)
+
+func rbacDedupFixture() map[string]interface{} {
+	const sub = "/subscriptions/00000000-0000-0000-0000-000000000001"
+	return map[string]interface{}{
+		"subscriptionRoleAssignments": []interface{}{
+			map[string]interface{}{
+				"id":               sub + "/providers/Microsoft.Authorization/roleAssignments/a1",
+				"principalId":      "p1",
+				"roleDefinitionId": sub + "/providers/Microsoft.Authorization/roleDefinitions/8E3AF657-A8FF-443C-A75C-2FE8C4BCB635",
+				"scope":            sub,
+			},
+			// Same assignment returned twice by ARM
+			map[string]interface{}{
+				"id":               sub + "/providers/Microsoft.Authorization/roleAssignments/a1",
+				"principalId":      "p1",
+				"roleDefinitionId": sub + "/providers/Microsoft.Authorization/roleDefinitions/8E3AF657-A8FF-443C-A75C-2FE8C4BCB635",
+				"scope":            sub,
+			},
+		},
+		"resourceGroupRoleAssignments": []interface{}{
+			// Same access as a1, reported by Resource Graph under a different ID
+			// with the nested properties shape and a trailing slash on the scope
+			map[string]interface{}{
+				"id": "/SUBSCRIPTIONS/00000000-0000-0000-0000-000000000001/providers/microsoft.authorization/roleassignments/arg-a1",
+				"properties": map[string]interface{}{
+					"principalId":      "p1",
+					"roleDefinitionId": "/providers/Microsoft.Authorization/roleDefinitions/8e3af657-a8ff-443c-a75c-2fe8c4bcb635",
+					"scope":            sub + "/",
+				},
+			},
+			map[string]interface{}{
+				"id":               sub + "/resourceGroups/rg/providers/Microsoft.Authorization/roleAssignments/a2",
+				"principalId":      "p1",
+				"roleDefinitionId": sub + "/providers/Microsoft.Authorization/roleDefinitions/8e3af657-a8ff-443c-a75c-2fe8c4bcb635",
+				"scope":            sub + "/resourceGroups/rg",
+			},
+			map[string]interface{}{"principalId": "p2"},
+		},
+	}
+}
+
+func TestRBACDeduplicatorByID(t *testing.T) {
+	dedup := newRBACDeduplicator(rbacDedupByID)
+	data := rbacDedupFixture()
+	dedup.deduplicate(cfg.NewLogger(), data)
+
+	assert.Len(t, data["subscriptionRoleAssignments"], 1)
+	assert.Len(t, data["resourceGroupRoleAssignments"], 2, "different IDs are kept; the assignment without an id is dropped")
+	assert.Equal(t, &RBACDeduplication{Key: "id", RawAssignments: 5, UniqueAssignments: 3}, dedup.stats())
+}
+
+func TestRBACDeduplicatorByAccess(t *testing.T) {
+	dedup := newRBACDeduplicator(rbacDedupKeyArg("ACCESS"))
+	data := rbacDedupFixture()
+	dedup.deduplicate(cfg.NewLogger(), data)
+	// Counts accumulate across subscriptions
+	dedup.deduplicate(cfg.NewLogger(), rbacDedupFixture())
+
+	assert.Len(t, data["subscriptionRoleAssignments"], 1)
+	rgAssignments := data["resourceGroupRoleAssignments"].([]interface{})
+	assert.Len(t, rgAssignments, 1, "the Resource Graph copy of a1 collapses into it")
+	assert.Contains(t, rgAssignments[0].(map[string]interface{})["id"], "/roleAssignments/a2")
+	assert.Equal(t, &RBACDeduplication{Key: "access", RawAssignments: 10, UniqueAssignments: 4}, dedup.stats())
+}
+
+func TestRBACDedupKeyArg(t *testing.T) {
+	assert.Equal(t, rbacDedupByID, rbacDedupKeyArg(nil))
+	assert.Equal(t, rbacDedupByID, rbacDedupKeyArg("id"))
+	assert.Equal(t, rbacDedupByAccess, rbacDedupKeyArg("Access"))
+}
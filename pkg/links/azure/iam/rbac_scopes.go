@@ -0,0 +1,69 @@
+package iam
+
+import "strings"
+
+// Scope buckets RBAC assignments are grouped by. Each bucket is stored in its
+// own per-subscription section.
+const (
+	scopeBucketSubscription    = "subscription"
+	scopeBucketResourceGroup   = "resourceGroup"
+	scopeBucketResource        = "resource"
+	scopeBucketManagementGroup = "managementGroup"
+	scopeBucketTenant          = "tenant"
+)
+
+// rbacScopeBucket returns the section a collected assignment with the
+// normalized scope is stored in, classified by prefix:
+//
+//	/                                                   tenant
+//	/providers/microsoft.management/managementgroups/x  managementGroup
+//	/subscriptions/s                                    subscription
+//	/subscriptions/s/resourcegroups/rg                  resourceGroup
+//	anything else                                       resource
+//
+// Everything below a subscription that is not a resource group, including
+// resources in no resource group and nested resources, is a resource.
+func rbacScopeBucket(scope string) string {
+	switch {
+	case scope == "/" || scope == "":
+		return scopeBucketTenant
+	case strings.HasPrefix(scope, "/providers/microsoft.management/managementgroups/"):
+		return scopeBucketManagementGroup
+	}
+
+	parts := strings.Split(strings.TrimPrefix(scope, "/"), "/")
+	if parts[0] == "subscriptions" {
+		switch {
+		case len(parts) == 2:
+			return scopeBucketSubscription
+		case len(parts) == 4 && parts[2] == "resourcegroups":
+			return scopeBucketResourceGroup
+		}
+	}
+	return scopeBucketResource
+}
+
+// groupRBACAssignmentsByScope normalizes the scope of each assignment in
+// place and groups the assignments by scope bucket. Every bucket is present.
+func groupRBACAssignmentsByScope(assignments []interface{}) map[string][]interface{} {
+	grouped := map[string][]interface{}{
+		scopeBucketSubscription:    {},
+		scopeBucketResourceGroup:   {},
+		scopeBucketResource:        {},
+		scopeBucketManagementGroup: {},
+		scopeBucketTenant:          {},
+	}
+	for _, assignment := range assignments {
+		assignmentMap, ok := assignment.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		scope, _ := assignmentMap["scope"].(string)
+		scope = normalizeScope(scope)
+		assignmentMap["scope"] = scope
+
+		bucket := rbacScopeBucket(scope)
+		grouped[bucket] = append(grouped[bucket], assignmentMap)
+	}
+	return grouped
+}
@@ -0,0 +1,140 @@
+package iam
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resourcegraph/armresourcegraph"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// rbacScopeCases are real assignment scope shapes and the canonical bucket each
+// must be stored under. Both collectors previously classified them on their
+// own and disagreed on scopes below a subscription that are not in a resource
+// group.
+var rbacScopeCases = []struct {
+	scope  string
+	bucket string
+}{
+	// Tenant root, and assignments without a scope
+	{"/", scopeBucketTenant},
+	{"", scopeBucketTenant},
+	// Management groups, including the tenant root group and odd casing
+	{"/providers/Microsoft.Management/managementGroups/contoso-platform", scopeBucketManagementGroup},
+	{"/providers/microsoft.management/managementgroups/00000000-0000-0000-0000-000000000001/", scopeBucketManagementGroup},
+	// Subscriptions, with and without a trailing slash
+	{"/subscriptions/11111111-1111-1111-1111-111111111111", scopeBucketSubscription},
+	{"/subscriptions/11111111-1111-1111-1111-111111111111/", scopeBucketSubscription},
+	// Resource groups, whatever the casing of the segment
+	{"/subscriptions/11111111-1111-1111-1111-111111111111/resourceGroups/rg-app", scopeBucketResourceGroup},
+	{"/subscriptions/11111111-1111-1111-1111-111111111111/RESOURCEGROUPS/RG-App", scopeBucketResourceGroup},
+	// Resources in a resource group, top level and nested
+	{"/subscriptions/11111111-1111-1111-1111-111111111111/resourceGroups/rg-app/providers/Microsoft.KeyVault/vaults/kv-app", scopeBucketResource},
+	{"/subscriptions/11111111-1111-1111-1111-111111111111/resourceGroups/rg-app/providers/Microsoft.Storage/storageAccounts/stapp/blobServices/default/containers/logs", scopeBucketResource},
+	// Subscription-level resources that live in no resource group
+	{"/subscriptions/11111111-1111-1111-1111-111111111111/providers/Microsoft.Security/pricings/VirtualMachines", scopeBucketResource},
+	{"/subscriptions/11111111-1111-1111-1111-111111111111/providers/Microsoft.Insights/diagnosticSettings/to-sentinel", scopeBucketResource},
+	// Tenant-level provider scopes outside any subscription
+	{"/providers/Microsoft.aadiam", scopeBucketResource},
+}
+
+// argScopeFixture serves one role assignment per rbacScopeCases entry as the
+// response to any Resource Graph query
+type argScopeFixture struct{}
+
+func (argScopeFixture) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodPost || !strings.HasSuffix(req.URL.Path, "/providers/Microsoft.ResourceGraph/resources") {
+		return nil, fmt.Errorf("unexpected request %s %s", req.Method, req.URL)
+	}
+	data := make([]interface{}, 0, len(rbacScopeCases))
+	for i, tc := range rbacScopeCases {
+		data = append(data, map[string]interface{}{"id": fmt.Sprintf("assignment-%d", i), "scope": tc.scope})
+	}
+	return jsonResponse(map[string]interface{}{
+		"data":            data,
+		"count":           len(data),
+		"totalRecords":    len(data),
+		"resultTruncated": "false",
+	})
+}
+
+// assignmentBuckets maps each assignment ID to the bucket it was grouped under
+func assignmentBuckets(t *testing.T, grouped map[string][]interface{}) map[string]string {
+	t.Helper()
+	buckets := make(map[string]string)
+	for bucket, assignments := range grouped {
+		for _, assignment := range assignments {
+			id, _ := assignment.(map[string]interface{})["id"].(string)
+			require.NotContains(t, buckets, id, "assignment grouped twice")
+			buckets[id] = bucket
+		}
+	}
+	return buckets
+}
+
+func TestRBACScopeBucket(t *testing.T) {
+	for _, tc := range rbacScopeCases {
+		assert.Equal(t, tc.bucket, rbacScopeBucket(normalizeScope(tc.scope)), "scope %q", tc.scope)
+	}
+}
+
+// TestRBACScopeClassificationAgrees runs the Resource Graph role assignment
+// collection of both collectors against the same scopes and fails if they
+// store any of them in a different bucket
+func TestRBACScopeClassificationAgrees(t *testing.T) {
+	client := &http.Client{Transport: argScopeFixture{}}
+
+	httpLink := NewIAMComprehensiveCollectorLink().(*IAMComprehensiveCollectorLink)
+	httpLink.httpClientOnce.Do(func() {})
+	httpLink.httpClient = client
+	httpGrouped, err := httpLink.getAllRBACAssignmentsViaARG("fixture-token", []string{"11111111-1111-1111-1111-111111111111"})
+	require.NoError(t, err)
+
+	sdkLink := NewSDKComprehensiveCollectorLink().(*SDKComprehensiveCollectorLink)
+	sdkLink.resourceGraphClient, err = armresourcegraph.NewClient(fakeTokenCredential{}, &arm.ClientOptions{
+		ClientOptions: policy.ClientOptions{Transport: client},
+	})
+	require.NoError(t, err)
+	subscription, resourceGroup, resource, managementGroup, tenant, err := sdkLink.collectAllRoleAssignmentsSDK("11111111-1111-1111-1111-111111111111")
+	require.NoError(t, err)
+	sdkGrouped := map[string][]interface{}{
+		scopeBucketSubscription:    subscription,
+		scopeBucketResourceGroup:   resourceGroup,
+		scopeBucketResource:        resource,
+		scopeBucketManagementGroup: managementGroup,
+		scopeBucketTenant:          tenant,
+	}
+
+	httpBuckets := assignmentBuckets(t, httpGrouped)
+	sdkBuckets := assignmentBuckets(t, sdkGrouped)
+	require.Len(t, httpBuckets, len(rbacScopeCases))
+	for i, tc := range rbacScopeCases {
+		id := fmt.Sprintf("assignment-%d", i)
+		assert.Equal(t, tc.bucket, httpBuckets[id], "HTTP collector bucket of scope %q", tc.scope)
+		assert.Equal(t, tc.bucket, sdkBuckets[id], "SDK collector bucket of scope %q", tc.scope)
+	}
+
+	for _, assignments := range httpGrouped {
+		for _, assignment := range assignments {
+			scope := assignment.(map[string]interface{})["scope"].(string)
+			assert.Equal(t, normalizeScope(scope), scope, "scopes are stored normalized")
+		}
+	}
+
+	raw, err := json.Marshal(sdkGrouped)
+	require.NoError(t, err)
+	assert.JSONEq(t, string(raw), mustMarshal(t, httpGrouped), "both collectors store the same assignments")
+}
+
+func mustMarshal(t *testing.T, v interface{}) string {
+	t.Helper()
+	raw, err := json.Marshal(v)
+	require.NoError(t, err)
+	return string(raw)
+}
@@ -0,0 +1,169 @@
+package iam
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// resourceGraphQueryURL is the ARM path of the Azure Resource Graph query endpoint
+const resourceGraphQueryURL = "/providers/Microsoft.ResourceGraph/resources?api-version=2021-03-01"
+
+// resourceTypeShardSize is how many resource types each sharded resources
+// query fetches. Small shards keep the KQL short and let a type with many
+// resources page on its own without holding up the rest.
+const resourceTypeShardSize = 25
+
+// resourceGraphPageSize is the largest page Resource Graph returns
+const resourceGraphPageSize = 1000
+
+// resourceTypesQuery discovers the resource types that exist in the queried
+// subscriptions, so types Azure adds later are collected without a code change
+const resourceTypesQuery = `resources | distinct type | order by type asc`
+
+// resourceTypeShards splits the discovered resource types into groups of at
+// most size types
+func resourceTypeShards(resourceTypes []string, size int) [][]string {
+	var shards [][]string
+	for start := 0; start < len(resourceTypes); start += size {
+		shards = append(shards, resourceTypes[start:min(start+size, len(resourceTypes))])
+	}
+	return shards
+}
+
+// resourceShardQuery builds the resources query for one shard of types,
+// leaving out the kinds the filter denies
+func resourceShardQuery(resourceTypes []string, filter *resourceTypeFilter, projection string) string {
+	quoted := make([]string, len(resourceTypes))
+	for i, resourceType := range resourceTypes {
+		quoted[i] = kqlString(resourceType)
+	}
+	query := fmt.Sprintf("resources\n| where type in~ (%s)", strings.Join(quoted, ","))
+	if kinds := filter.kql(); kinds != "" {
+		query += "\n" + kinds
+	}
+	return query + "\n| project " + projection
+}
+
+// queryResourceGraph runs a Resource Graph query over the given subscriptions,
+// or every accessible subscription when none are given, following $skipToken
+// until all rows are read
+func (l *IAMComprehensiveCollectorLink) queryResourceGraph(accessToken string, subscriptionIDs []string, query string) ([]interface{}, error) {
+	var rows []interface{}
+	options := map[string]interface{}{"$top": resourceGraphPageSize}
+	for {
+		requestBody := map[string]interface{}{
+			"query":   query,
+			"options": options,
+		}
+		if len(subscriptionIDs) > 0 {
+			requestBody["subscriptions"] = subscriptionIDs
+		}
+		requestBodyBytes, err := json.Marshal(requestBody)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal request body: %v", err)
+		}
+
+		req, err := http.NewRequestWithContext(l.Context(), "POST", l.cloud.armURL(resourceGraphQueryURL), bytes.NewBuffer(requestBodyBytes))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create request: %v", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+accessToken)
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := l.httpClient.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("request failed: %v", err)
+		}
+		if resp.StatusCode != 200 {
+			statusErr := newAPIStatusError(resp)
+			resp.Body.Close()
+			return nil, statusErr
+		}
+
+		var result struct {
+			Data      []interface{} `json:"data"`
+			SkipToken string        `json:"$skipToken"`
+		}
+		err = json.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode Resource Graph response: %v", err)
+		}
+
+		rows = append(rows, result.Data...)
+		if result.SkipToken == "" {
+			return rows, nil
+		}
+		options = map[string]interface{}{"$top": resourceGraphPageSize, "$skipToken": result.SkipToken}
+	}
+}
+
+// collectResourcesByTypeShards lists the resource types present in the
+// subscriptions, then fetches the resources the resource type filter keeps
+// shard by shard. A failed shard is recorded and skipped so one throttled or
+// oversized query does not lose every other type. With --all-resource-types
+// every resource is fetched in a single unfiltered query instead.
+func (l *IAMComprehensiveCollectorLink) collectResourcesByTypeShards(accessToken string, subscriptionIDs []string, projection string) ([]interface{}, error) {
+	if l.allResourceTypes {
+		resources, err := l.queryResourceGraph(accessToken, subscriptionIDs, "resources\n| project "+projection)
+		if err != nil {
+			return nil, err
+		}
+		sortResourcesByID(resources)
+		return resources, nil
+	}
+
+	typeRows, err := l.queryResourceGraph(accessToken, subscriptionIDs, resourceTypesQuery)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list resource types: %w", err)
+	}
+	var resourceTypes []string
+	for _, row := range typeRows {
+		if rowMap, ok := row.(map[string]interface{}); ok {
+			if resourceType, ok := rowMap["type"].(string); ok && resourceType != "" {
+				resourceTypes = append(resourceTypes, resourceType)
+			}
+		}
+	}
+
+	selected := l.resourceTypeFilter.selectTypes(resourceTypes)
+	if skipped := len(resourceTypes) - len(selected); skipped > 0 {
+		l.Logger.Info("Resource types left out by the resource type filter", "skipped", skipped, "collected", len(selected))
+	}
+	shards := resourceTypeShards(selected, resourceTypeShardSize)
+	l.Logger.Info("Collecting Azure resources in type shards", "types", len(selected), "shards", len(shards))
+
+	resources := []interface{}{}
+	for i, shard := range shards {
+		shardResources, err := l.queryResourceGraph(accessToken, subscriptionIDs, resourceShardQuery(shard, l.resourceTypeFilter, projection))
+		if err != nil {
+			l.Logger.Error("Failed to collect resource shard", "shard", i+1, "types", shard, "error", err)
+			l.collectionErrors.record("azureResources", fmt.Sprintf("types %s..%s", shard[0], shard[len(shard)-1]), err)
+			continue
+		}
+		resources = append(resources, shardResources...)
+	}
+
+	sortResourcesByID(resources)
+	return resources, nil
+}
+
+// sortResourcesByID orders Resource Graph rows by resource ID, so the output
+// does not depend on the order shards and pages were returned in
+func sortResourcesByID(resources []interface{}) {
+	sort.SliceStable(resources, func(i, j int) bool {
+		return strings.ToLower(fmt.Sprint(resourceField(resources[i], "id"))) < strings.ToLower(fmt.Sprint(resourceField(resources[j], "id")))
+	})
+}
+
+// resourceField reads a field from a Resource Graph row
+func resourceField(resource interface{}, field string) interface{} {
+	if resourceMap, ok := resource.(map[string]interface{}); ok {
+		return resourceMap[field]
+	}
+	return nil
+}
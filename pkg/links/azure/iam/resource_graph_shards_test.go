@@ -0,0 +1,30 @@
+package iam
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResourceTypeShards(t *testing.T) {
+	types := []string{"a/1", "a/2", "b/1", "b/2", "c/1"}
+
+	shards := resourceTypeShards(types, 2)
+	assert.Equal(t, [][]string{{"a/1", "a/2"}, {"b/1", "b/2"}, {"c/1"}}, shards)
+
+	assert.Len(t, resourceTypeShards(types, 25), 1)
+	assert.Empty(t, resourceTypeShards(nil, 25), "no types means no shard queries")
+}
+
+func TestResourceShardQuery(t *testing.T) {
+	filter, err := loadResourceTypeFilter("")
+	require.NoError(t, err)
+	query := resourceShardQuery([]string{"microsoft.keyvault/vaults", "contoso.widgets/o'brien"}, filter, "id,name,type")
+
+	assert.Contains(t, query, `| where type in~ ('microsoft.keyvault/vaults','contoso.widgets/o\'brien')`)
+	assert.Contains(t, query, filter.kql())
+	assert.Contains(t, query, "| project id,name,type")
+
+	assert.Equal(t, "resources\n| where type in~ ('a/b')\n| project id", resourceShardQuery([]string{"a/b"}, nil, "id"))
+}
@@ -0,0 +1,217 @@
+package iam
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/praetorian-inc/janus-framework/pkg/chain/cfg"
+)
+
+// resourceLocksAPIVersion is the Microsoft.Authorization locks API version
+const resourceLocksAPIVersion = "2020-05-01"
+
+// highValueResourceTypes are resource types whose deletion loses data or
+// recovery capability, so they are expected to carry a CanNotDelete or
+// ReadOnly lock
+var highValueResourceTypes = map[string]string{
+	"microsoft.keyvault/vaults":                        "Key Vault",
+	"microsoft.keyvault/managedhsms":                   "Managed HSM",
+	"microsoft.storage/storageaccounts":                "Storage Account",
+	"microsoft.sql/servers":                            "SQL Server",
+	"microsoft.sql/managedinstances":                   "SQL Managed Instance",
+	"microsoft.documentdb/databaseaccounts":            "Cosmos DB Account",
+	"microsoft.dbforpostgresql/flexibleservers":        "PostgreSQL Flexible Server",
+	"microsoft.dbformysql/flexibleservers":             "MySQL Flexible Server",
+	"microsoft.recoveryservices/vaults":                "Recovery Services Vault",
+	"microsoft.dataprotection/backupvaults":            "Backup Vault",
+	"microsoft.containerservice/managedclusters":       "AKS Cluster",
+	"microsoft.containerregistry/registries":           "Container Registry",
+	"microsoft.network/dnszones":                       "DNS Zone",
+	"microsoft.operationalinsights/workspaces":         "Log Analytics Workspace",
+	"microsoft.managedidentity/userassignedidentities": "User-Assigned Managed Identity",
+}
+
+// resourceLocksURL is the ARM path listing every management lock in a
+// subscription, including locks on its resource groups and resources
+func resourceLocksURL(subscriptionID string) string {
+	return fmt.Sprintf("/subscriptions/%s/providers/Microsoft.Authorization/locks?api-version=%s",
+		subscriptionID, resourceLocksAPIVersion)
+}
+
+// newResourceLockRecord flattens an ARM management lock into a resource_locks entry
+func newResourceLockRecord(subscriptionID string, lock map[string]interface{}) map[string]interface{} {
+	lockID, _ := lock["id"].(string)
+	record := map[string]interface{}{
+		"id":             lockID,
+		"name":           lock["name"],
+		"subscriptionId": subscriptionID,
+		"scope":          resourceLockScope(lockID),
+	}
+	if properties, ok := lock["properties"].(map[string]interface{}); ok {
+		record["level"] = properties["level"]
+		record["notes"] = properties["notes"]
+		record["owners"] = properties["owners"]
+	}
+	return record
+}
+
+// resourceLockScope returns the subscription, resource group or resource a
+// lock applies to, derived from the lock ID
+func resourceLockScope(lockID string) string {
+	if idx := strings.LastIndex(strings.ToLower(lockID), "/providers/microsoft.authorization/locks/"); idx > 0 {
+		return normalizeScope(lockID[:idx])
+	}
+	return ""
+}
+
+// collectResourceLocks lists management locks for each subscription, recording
+// failures per subscription so one inaccessible subscription does not hide the rest
+func (l *IAMComprehensiveCollectorLink) collectResourceLocks(accessToken string, subscriptionIDs []string) []interface{} {
+	locks := []interface{}{}
+	for _, subscriptionID := range subscriptionIDs {
+		subscriptionLocks, err := l.collectPaginatedARMData(accessToken, resourceLocksURL(subscriptionID))
+		if err != nil {
+			l.Logger.Error("Failed to collect resource locks", "subscription", subscriptionID, "error", err)
+			l.collectionErrors.record("resource_locks", subscriptionID, err)
+			continue
+		}
+		for _, lock := range subscriptionLocks {
+			if lockMap, ok := lock.(map[string]interface{}); ok {
+				locks = append(locks, newResourceLockRecord(subscriptionID, lockMap))
+			}
+		}
+	}
+	return locks
+}
+
+// collectResourceLocksSDK lists management locks for each subscription using
+// the SDK collector's credential
+func (l *SDKComprehensiveCollectorLink) collectResourceLocksSDK(ctx context.Context, subscriptionIDs []string) []interface{} {
+	locks := []interface{}{}
+	token, err := l.getManagementAccessToken(ctx)
+	if err != nil {
+		l.Logger.Error("Failed to collect resource locks", "error", err)
+		l.collectionErrors.record("resource_locks", "tenant", err)
+		return locks
+	}
+	for _, subscriptionID := range subscriptionIDs {
+		subscriptionLocks, err := l.collectPaginatedARMDataSDK(ctx, token, resourceLocksURL(subscriptionID))
+		if err != nil {
+			l.Logger.Error("Failed to collect resource locks", "subscription", subscriptionID, "error", err)
+			l.collectionErrors.record("resource_locks", subscriptionID, err)
+			continue
+		}
+		for _, lock := range subscriptionLocks {
+			if lockMap, ok := lock.(map[string]interface{}); ok {
+				locks = append(locks, newResourceLockRecord(subscriptionID, lockMap))
+			}
+		}
+	}
+	return locks
+}
+
+// effectiveResourceLock returns the strongest lock that applies to a resource.
+// Locks are inherited from the subscription and resource group, and ReadOnly
+// also blocks deletion, so it outranks CanNotDelete.
+func effectiveResourceLock(resourceID string, locks []interface{}) map[string]interface{} {
+	resourceID = normalizeScope(resourceID)
+	var effective map[string]interface{}
+	for _, lock := range locks {
+		lockMap, ok := lock.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		scope, _ := lockMap["scope"].(string)
+		if scope == "" || (resourceID != scope && !strings.HasPrefix(resourceID, scope+"/")) {
+			continue
+		}
+		if effective == nil || (lockMap["level"] == "ReadOnly" && effective["level"] != "ReadOnly") {
+			effective = lockMap
+		}
+	}
+	return effective
+}
+
+// highValueResources returns the collected resources whose type is in
+// highValueResourceTypes, ordered by resource ID
+func highValueResources(o *ConsolidatedOutput) []map[string]interface{} {
+	var resources []map[string]interface{}
+	for _, subData := range o.AzureResources {
+		subDataMap, ok := subData.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		subResources, _ := subDataMap["azureResources"].([]interface{})
+		for _, resource := range subResources {
+			resourceMap, ok := resource.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			resourceType, _ := resourceMap["type"].(string)
+			if _, ok := highValueResourceTypes[strings.ToLower(resourceType)]; ok {
+				resources = append(resources, resourceMap)
+			}
+		}
+	}
+	sort.SliceStable(resources, func(i, j int) bool {
+		return strings.ToLower(fmt.Sprint(resources[i]["id"])) < strings.ToLower(fmt.Sprint(resources[j]["id"]))
+	})
+	return resources
+}
+
+// buildResourceLockFindings flags high-value resources that no CanNotDelete or
+// ReadOnly lock protects, at their own scope or inherited
+func buildResourceLockFindings(o *ConsolidatedOutput) []interface{} {
+	findings := []interface{}{}
+	for _, resource := range highValueResources(o) {
+		resourceID, _ := resource["id"].(string)
+		if effectiveResourceLock(resourceID, o.ResourceLocks) != nil {
+			continue
+		}
+		resourceType, _ := resource["type"].(string)
+		kind := highValueResourceTypes[strings.ToLower(resourceType)]
+		findings = append(findings, map[string]interface{}{
+			"type":           "HighValueResourceWithoutDeleteLock",
+			"severity":       "Medium",
+			"description":    fmt.Sprintf("%s %s has no CanNotDelete or ReadOnly lock at its own, resource group, or subscription scope", kind, resource["name"]),
+			"resourceId":     resourceID,
+			"resourceName":   resource["name"],
+			"resourceType":   resourceType,
+			"resourceGroup":  resource["resourceGroup"],
+			"subscriptionId": resource["subscriptionId"],
+		})
+	}
+	return findings
+}
+
+// logResourceLockFindings reports high-value resources without delete protection
+func logResourceLockFindings(logger *cfg.Logger, findings []interface{}) {
+	logFindings(logger, findings, "⚠️  %d high-value resources have no delete lock", "Resource without delete lock",
+		"resource", "resourceId", "description", "description")
+}
+
+// lockCoverageReport lists every high-value resource with the lock that
+// protects it, if any. A ReadOnly lock also blocks configuration changes, so
+// those resources cannot be modified without first removing the lock.
+func lockCoverageReport(o *ConsolidatedOutput, principals map[string]reportPrincipal) []map[string]interface{} {
+	rows := []map[string]interface{}{}
+	for _, resource := range highValueResources(o) {
+		resourceID, _ := resource["id"].(string)
+		row := map[string]interface{}{
+			"resourceId":   resourceID,
+			"resourceName": resource["name"],
+			"resourceType": resource["type"],
+			"lockLevel":    "None",
+		}
+		if lock := effectiveResourceLock(resourceID, o.ResourceLocks); lock != nil {
+			row["lockLevel"] = lock["level"]
+			row["lockName"] = lock["name"]
+			row["lockScope"] = lock["scope"]
+			row["inherited"] = lock["scope"] != normalizeScope(resourceID)
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
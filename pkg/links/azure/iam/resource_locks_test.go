@@ -0,0 +1,67 @@
+package iam
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const resourceLocksFixture = `{
+  "azure_ad": {},
+  "pim": {},
+  "azure_resources": {
+    "sub-1": {
+      "azureResources": [
+        {"id": "/subscriptions/sub-1/resourceGroups/prod/providers/Microsoft.KeyVault/vaults/prod-kv", "name": "prod-kv", "type": "Microsoft.KeyVault/vaults", "resourceGroup": "prod", "subscriptionId": "sub-1"},
+        {"id": "/subscriptions/sub-1/resourceGroups/prod/providers/Microsoft.Storage/storageAccounts/prodlogs", "name": "prodlogs", "type": "Microsoft.Storage/storageAccounts", "resourceGroup": "prod", "subscriptionId": "sub-1"},
+        {"id": "/subscriptions/sub-1/resourceGroups/dev/providers/Microsoft.Storage/storageAccounts/devdata", "name": "devdata", "type": "Microsoft.Storage/storageAccounts", "resourceGroup": "dev", "subscriptionId": "sub-1"},
+        {"id": "/subscriptions/sub-1/resourceGroups/dev/providers/Microsoft.Web/sites/devapp", "name": "devapp", "type": "Microsoft.Web/sites", "resourceGroup": "dev", "subscriptionId": "sub-1"}
+      ]
+    }
+  }
+}`
+
+func TestNewResourceLockRecord(t *testing.T) {
+	lock := newResourceLockRecord("sub-1", map[string]interface{}{
+		"id":         "/subscriptions/sub-1/resourceGroups/Prod/providers/Microsoft.Authorization/locks/no-delete",
+		"name":       "no-delete",
+		"properties": map[string]interface{}{"level": "CanNotDelete", "notes": "prod"},
+	})
+	assert.Equal(t, "/subscriptions/sub-1/resourcegroups/prod", lock["scope"])
+	assert.Equal(t, "CanNotDelete", lock["level"])
+	assert.Equal(t, "sub-1", lock["subscriptionId"])
+}
+
+func TestResourceLockCoverage(t *testing.T) {
+	var output ConsolidatedOutput
+	require.NoError(t, json.Unmarshal([]byte(resourceLocksFixture), &output))
+	output.ResourceLocks = []interface{}{
+		newResourceLockRecord("sub-1", map[string]interface{}{
+			"id": "/subscriptions/sub-1/resourceGroups/prod/providers/Microsoft.Authorization/locks/rg-lock", "name": "rg-lock",
+			"properties": map[string]interface{}{"level": "CanNotDelete"},
+		}),
+		newResourceLockRecord("sub-1", map[string]interface{}{
+			"id": "/subscriptions/sub-1/resourceGroups/prod/providers/Microsoft.KeyVault/vaults/prod-kv/providers/Microsoft.Authorization/locks/kv-lock", "name": "kv-lock",
+			"properties": map[string]interface{}{"level": "ReadOnly"},
+		}),
+		newResourceLockRecord("sub-1", map[string]interface{}{
+			"id": "/subscriptions/sub-1/resourceGroups/prod2/providers/Microsoft.Authorization/locks/other", "name": "other",
+			"properties": map[string]interface{}{"level": "CanNotDelete"},
+		}),
+	}
+	output.Normalize()
+
+	findings := buildResourceLockFindings(&output)
+	require.Len(t, findings, 1, "resources in a locked resource group inherit the lock and non high-value types are ignored")
+	assert.Equal(t, "devdata", findings[0].(map[string]interface{})["resourceName"])
+
+	rows := lockCoverageReport(&output, nil)
+	require.Len(t, rows, 3)
+	assert.Equal(t, "None", rows[0]["lockLevel"])
+	assert.Equal(t, "ReadOnly", rows[1]["lockLevel"], "ReadOnly outranks an inherited CanNotDelete")
+	assert.Equal(t, false, rows[1]["inherited"])
+	assert.Equal(t, "CanNotDelete", rows[2]["lockLevel"])
+	assert.Equal(t, true, rows[2]["inherited"])
+}
@@ -0,0 +1,132 @@
+package iam
+
+import (
+	_ "embed"
+	"fmt"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// defaultResourceTypeFilter is the filter used without --resource-type-filter
+//
+//go:embed resource_type_filter.yaml
+var defaultResourceTypeFilter []byte
+
+// resourceTypeFilter decides which Resource Graph resources are collected.
+// Allow narrows the discovered resource types, Deny leaves types or kinds of
+// a type out. JSON is valid YAML, so the file can be written in either.
+type resourceTypeFilter struct {
+	Allow []string           `yaml:"allow"`
+	Deny  []resourceTypeRule `yaml:"deny"`
+}
+
+// resourceTypeRule leaves out the resources of a type, or only those of the
+// listed kinds
+type resourceTypeRule struct {
+	Type  string   `yaml:"type"`
+	Kinds []string `yaml:"kinds,omitempty"`
+}
+
+// loadResourceTypeFilter reads a --resource-type-filter file, or the embedded
+// default filter when path is empty
+func loadResourceTypeFilter(path string) (*resourceTypeFilter, error) {
+	raw, source := defaultResourceTypeFilter, "default resource type filter"
+	if path != "" {
+		var err error
+		if raw, err = os.ReadFile(path); err != nil {
+			return nil, fmt.Errorf("failed to read resource type filter: %v", err)
+		}
+		source = path
+	}
+	var filter resourceTypeFilter
+	if err := yaml.Unmarshal(raw, &filter); err != nil {
+		return nil, fmt.Errorf("failed to parse resource type filter %s: %v", source, err)
+	}
+	for i, resourceType := range filter.Allow {
+		if strings.TrimSpace(resourceType) == "" {
+			return nil, fmt.Errorf("allow entry %d in %s has no type", i+1, source)
+		}
+	}
+	for i, rule := range filter.Deny {
+		if strings.TrimSpace(rule.Type) == "" {
+			return nil, fmt.Errorf("deny entry %d in %s has no type", i+1, source)
+		}
+		for _, kind := range rule.Kinds {
+			if strings.TrimSpace(kind) == "" {
+				return nil, fmt.Errorf("deny entry %q in %s has an empty kind", rule.Type, source)
+			}
+		}
+	}
+	return &filter, nil
+}
+
+// matchesResourceType reports whether resourceType matches pattern, a type
+// or a prefix ending in /*
+func matchesResourceType(pattern, resourceType string) bool {
+	pattern = strings.ToLower(strings.TrimSpace(pattern))
+	resourceType = strings.ToLower(resourceType)
+	if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+		return strings.HasPrefix(resourceType, prefix)
+	}
+	return pattern == resourceType
+}
+
+// selectTypes keeps the discovered resource types the filter allows and does
+// not deny outright. A nil filter keeps every type.
+func (f *resourceTypeFilter) selectTypes(resourceTypes []string) []string {
+	if f == nil {
+		return resourceTypes
+	}
+	var selected []string
+	for _, resourceType := range resourceTypes {
+		allowed := len(f.Allow) == 0
+		for _, pattern := range f.Allow {
+			allowed = allowed || matchesResourceType(pattern, resourceType)
+		}
+		for _, rule := range f.Deny {
+			if len(rule.Kinds) == 0 && matchesResourceType(rule.Type, resourceType) {
+				allowed = false
+			}
+		}
+		if allowed {
+			selected = append(selected, resourceType)
+		}
+	}
+	return selected
+}
+
+// kql returns the where clauses leaving out the kinds the filter denies, one
+// per line, or nothing when no rule names kinds
+func (f *resourceTypeFilter) kql() string {
+	if f == nil {
+		return ""
+	}
+	var clauses []string
+	for _, rule := range f.Deny {
+		if len(rule.Kinds) == 0 {
+			continue
+		}
+		kinds := make([]string, len(rule.Kinds))
+		for i, kind := range rule.Kinds {
+			kinds[i] = kqlString(kind)
+		}
+		clauses = append(clauses, fmt.Sprintf("| where not(%s and kind in~ (%s))", kqlTypeMatch(rule.Type), strings.Join(kinds, ",")))
+	}
+	return strings.Join(clauses, "\n")
+}
+
+// kqlTypeMatch returns the KQL condition matching a type or a /* prefix
+func kqlTypeMatch(pattern string) string {
+	pattern = strings.ToLower(strings.TrimSpace(pattern))
+	if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+		return "type startswith " + kqlString(prefix)
+	}
+	return "type =~ " + kqlString(pattern)
+}
+
+// kqlString quotes s as a single-quoted KQL string literal
+func kqlString(s string) string {
+	return "'" + strings.ReplaceAll(strings.ReplaceAll(s, `\`, `\\`), "'", `\'`) + "'"
+}
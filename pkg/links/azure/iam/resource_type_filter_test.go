@@ -0,0 +1,85 @@
+package iam
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// assertBalancedKQL checks that every string literal in query is closed and
+// every parenthesis outside them is matched
+func assertBalancedKQL(t *testing.T, query string) {
+	t.Helper()
+	depth, inString := 0, false
+	for i := 0; i < len(query); i++ {
+		switch c := query[i]; {
+		case inString && c == '\\':
+			i++
+		case c == '\'':
+			inString = !inString
+		case !inString && c == '(':
+			depth++
+		case !inString && c == ')':
+			depth--
+			require.GreaterOrEqual(t, depth, 0, "unmatched ) in %q", query)
+		}
+	}
+	assert.False(t, inString, "unterminated string literal in %q", query)
+	assert.Zero(t, depth, "unmatched ( in %q", query)
+}
+
+func TestDefaultResourceTypeFilter(t *testing.T) {
+	filter, err := loadResourceTypeFilter("")
+	require.NoError(t, err)
+	assert.Empty(t, filter.Allow, "every discovered type is collected by default")
+	require.Len(t, filter.Deny, 2)
+
+	kql := filter.kql()
+	assert.Equal(t, `| where not(type =~ 'microsoft.sql/servers' and kind in~ ('v12.0,analytics'))
+| where not(type =~ 'microsoft.sql/servers/databases' and kind in~ ('system','v2.0,system','v12.0,system','v12.0,system,serverless','v12.0,user,datawarehouse,gen2,analytics'))`, kql)
+	for _, clause := range strings.Split(kql, "\n") {
+		assert.True(t, strings.HasPrefix(clause, "| where "), clause)
+	}
+	assertBalancedKQL(t, resourceShardQuery([]string{"microsoft.sql/servers", "microsoft.sql/servers/databases"}, filter, "id,name,type,kind"))
+
+	types := []string{"microsoft.keyvault/vaults", "microsoft.sql/servers"}
+	assert.Equal(t, types, filter.selectTypes(types), "types denied only for some kinds are still queried")
+}
+
+func TestResourceTypeFilterFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "filter.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+allow: [Microsoft.KeyVault/*, microsoft.web/sites, "contoso.widgets/o'brien"]
+deny:
+  - type: microsoft.keyvault/managedhsms
+  - type: microsoft.web/*
+    kinds: ["functionapp,linux", "it's"]
+`), 0o644))
+
+	filter, err := loadResourceTypeFilter(path)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"microsoft.keyvault/vaults", "microsoft.web/sites", "contoso.widgets/o'brien"}, filter.selectTypes([]string{
+		"microsoft.keyvault/vaults", "microsoft.keyvault/managedHSMs", "microsoft.web/sites", "microsoft.storage/storageaccounts", "contoso.widgets/o'brien",
+	}))
+
+	kql := filter.kql()
+	assert.Equal(t, `| where not(type startswith 'microsoft.web/' and kind in~ ('functionapp,linux','it\'s'))`, kql)
+	assertBalancedKQL(t, resourceShardQuery(filter.selectTypes([]string{"contoso.widgets/o'brien"}), filter, "id"))
+
+	for name, content := range map[string]string{
+		"malformed":     "allow: [",
+		"untyped deny":  "deny:\n  - kinds: [system]\n",
+		"empty kind":    "deny:\n  - type: microsoft.sql/servers\n    kinds: ['']\n",
+		"empty allowed": "allow: ['']\n",
+	} {
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+		_, err := loadResourceTypeFilter(path)
+		assert.Error(t, err, name)
+	}
+	_, err = loadResourceTypeFilter(filepath.Join(t.TempDir(), "missing.yaml"))
+	assert.ErrorContains(t, err, "failed to read resource type filter")
+}
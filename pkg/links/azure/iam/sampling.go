@@ -0,0 +1,81 @@
+package iam
+
+import (
+	"log/slog"
+
+	"github.com/praetorian-inc/janus-framework/pkg/chain/cfg"
+	"github.com/praetorian-inc/nebula/internal/message"
+)
+
+// sampleSizeArg reads --sample. Zero or a negative value collects everything.
+func sampleSizeArg(arg any) int {
+	sample, err := cfg.As[int](arg)
+	if err != nil || sample < 0 {
+		return 0
+	}
+	return sample
+}
+
+// sampleReached reports whether a collection holding collected objects has
+// reached the sample size, so pagination can stop early
+func sampleReached(sample, collected int) bool {
+	return sample > 0 && collected >= sample
+}
+
+// sampleObjects keeps the first sample objects of a collection
+func sampleObjects(objects []interface{}, sample int) []interface{} {
+	if sample > 0 && len(objects) > sample {
+		return objects[:sample]
+	}
+	return objects
+}
+
+// ApplySample cuts every collected section down to its first n objects and
+// marks the output as sampled. Pagination already stops early during a sampled
+// run; this catches the collections that do not page, such as Resource Graph
+// queries and per-object fan-out, so no section exceeds n. It must run before
+// the findings are computed, which are not sampled.
+func (o *ConsolidatedOutput) ApplySample(n int) {
+	if n <= 0 {
+		return
+	}
+	sampleSection := func(section map[string]interface{}) {
+		for key, value := range section {
+			if objects, ok := value.([]interface{}); ok {
+				section[key] = sampleObjects(objects, n)
+			}
+		}
+	}
+
+	sampleSection(o.AzureAD)
+	sampleSection(o.PIM)
+	for _, subData := range o.AzureResources {
+		if subDataMap, ok := subData.(map[string]interface{}); ok {
+			sampleSection(subDataMap)
+		}
+	}
+	o.ManagementGroups = sampleObjects(o.ManagementGroups, n)
+	o.ManagementGroupRBAC = sampleObjects(o.ManagementGroupRBAC, n)
+	o.ResourceLocks = sampleObjects(o.ResourceLocks, n)
+	if o.AuditLogs != nil {
+		o.AuditLogs.SignIns = sampleObjects(o.AuditLogs.SignIns, n)
+		o.AuditLogs.DirectoryAudits = sampleObjects(o.AuditLogs.DirectoryAudits, n)
+	}
+
+	o.CollectionMetadata.Sampled = true
+	o.CollectionMetadata.SampleSize = n
+}
+
+// logSampledRun reminds the operator that a sampled dump is not an assessment
+func logSampledRun(sample int) {
+	if sample > 0 {
+		message.Warning("Sampled run: every collection was cut to its first %d objects. This output is incomplete and must not be used as an assessment.", sample)
+	}
+}
+
+// warnIfSampled warns when a dump being loaded came from a --sample run
+func warnIfSampled(sampled bool, sampleSize interface{}) {
+	if sampled {
+		slog.Warn("This dump was collected with --sample and holds only the first objects of each collection; results are incomplete", "sample_size", sampleSize)
+	}
+}
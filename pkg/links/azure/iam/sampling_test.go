@@ -0,0 +1,69 @@
+package iam
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/praetorian-inc/janus-framework/pkg/chain/cfg"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplySample(t *testing.T) {
+	objects := func(n int) []interface{} {
+		out := make([]interface{}, n)
+		for i := range out {
+			out[i] = map[string]interface{}{"id": i}
+		}
+		return out
+	}
+	output := &ConsolidatedOutput{
+		AzureAD: map[string]interface{}{"users": objects(5), "groups": objects(1), "tenant": map[string]interface{}{"id": "t"}},
+		PIM:     map[string]interface{}{"eligible_assignments": objects(4)},
+		AzureResources: map[string]interface{}{
+			"sub-1": map[string]interface{}{"azureResources": objects(10), "subscriptionRoleAssignments": objects(3)},
+		},
+		ManagementGroups:    objects(3),
+		ManagementGroupRBAC: objects(2),
+		AuditLogs:           &AuditLogs{SignIns: objects(7)},
+	}
+
+	output.ApplySample(2)
+
+	assert.Len(t, output.AzureAD["users"], 2)
+	assert.Len(t, output.AzureAD["groups"], 1, "smaller collections are kept whole")
+	assert.Equal(t, map[string]interface{}{"id": "t"}, output.AzureAD["tenant"], "non-list values are untouched")
+	assert.Len(t, output.PIM["eligible_assignments"], 2)
+	sub := output.AzureResources["sub-1"].(map[string]interface{})
+	assert.Len(t, sub["azureResources"], 2)
+	assert.Len(t, sub["subscriptionRoleAssignments"], 2)
+	assert.Len(t, output.ManagementGroups, 2)
+	assert.Len(t, output.ManagementGroupRBAC, 2)
+	assert.Len(t, output.AuditLogs.SignIns, 2)
+
+	raw, err := json.Marshal(output.CollectionMetadata)
+	require.NoError(t, err)
+	assert.Contains(t, string(raw), `"sampled":true,"sample_size":2`)
+}
+
+func TestApplySampleDisabled(t *testing.T) {
+	output := &ConsolidatedOutput{AzureAD: map[string]interface{}{"users": []interface{}{1, 2, 3}}}
+	output.ApplySample(0)
+
+	assert.Len(t, output.AzureAD["users"], 3)
+	raw, err := json.Marshal(output.CollectionMetadata)
+	require.NoError(t, err)
+	assert.NotContains(t, string(raw), "sampled", "complete runs carry no sample marker")
+}
+
+func TestARMPagerStopsAtSampleSize(t *testing.T) {
+	server := newARMPageServer(t, 5, nil)
+	var errs collectionErrorLog
+	pager := &armPager{client: server.Client(), logger: cfg.NewLogger(), errs: &errs, maxPages: defaultARMMaxPages, maxItems: 2}
+
+	items, err := pager.collect(context.Background(), "token", server.URL+"/list")
+	require.NoError(t, err)
+	assert.Len(t, items, 2)
+	assert.Empty(t, errs.list(), "a sampled list is not a collection error")
+}
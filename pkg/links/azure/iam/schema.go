@@ -0,0 +1,320 @@
+package iam
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/praetorian-inc/nebula/version"
+)
+
+// ConsolidatedSchemaVersion is the version of the consolidated collector output
+// contract. Bump the minor version for additive changes and the major version
+// when existing keys are renamed, removed, or change shape.
+const ConsolidatedSchemaVersion = "1.38"
+
+// ConsolidatedOutput is the document emitted by both the HTTP (iam-pull) and
+// SDK (iam-pull-sdk) collectors and consumed by the Neo4j importer. The
+// per-object payloads stay untyped because they are passed through verbatim
+// from Graph, PIM and ARM; the envelope around them is fixed.
+type ConsolidatedOutput struct {
+	CollectionMetadata  CollectionMetadata     `json:"collection_metadata"`
+	AzureAD             map[string]interface{} `json:"azure_ad"`
+	PIM                 map[string]interface{} `json:"pim"`
+	ManagementGroups    []interface{}          `json:"management_groups"`
+	ManagementGroupRBAC []interface{}          `json:"management_group_rbac"`
+	AzureResources      map[string]interface{} `json:"azure_resources"`
+	ResourceLocks       []interface{}          `json:"resource_locks"`
+	AuditLogs           *AuditLogs             `json:"audit_logs,omitempty"`
+	BaselineComparison  *BaselineComparison    `json:"baseline_comparison,omitempty"`
+	CollectionErrors    []CollectionError      `json:"collection_errors"`
+}
+
+// CollectionMetadata describes how and when a consolidated output was produced.
+type CollectionMetadata struct {
+	SchemaVersion          string            `json:"schema_version"`
+	TenantID               string            `json:"tenant_id"`
+	CollectionTimestamp    string            `json:"collection_timestamp"`
+	SubscriptionsProcessed int               `json:"subscriptions_processed"`
+	CollectorVersions      CollectorVersions `json:"collector_versions"`
+	DataSummary            DataSummary       `json:"data_summary"`
+	// RBACDeduplication is absent from outputs written before schema 1.19
+	RBACDeduplication *RBACDeduplication `json:"rbac_deduplication,omitempty"`
+	// Sampled marks a --sample run, in which every collection holds at most
+	// SampleSize objects; such output is never a complete assessment
+	Sampled    bool `json:"sampled,omitempty"`
+	SampleSize int  `json:"sample_size,omitempty"`
+	// IncrementalCollection is set on --prior-dump runs and lists which
+	// subscriptions were collected and which were carried forward
+	IncrementalCollection *IncrementalCollection `json:"incremental_collection,omitempty"`
+	// SubscriptionShards is set on --split-subscriptions runs, whose
+	// azure_resources data is in one file per subscription rather than inline
+	SubscriptionShards []SubscriptionShard `json:"subscription_shards,omitempty"`
+	// ResourceProjection is set on --project runs and lists the only fields
+	// kept on azure_resources resource entries
+	ResourceProjection []string `json:"resource_projection,omitempty"`
+	// NDJSONFile is set on --format ndjson runs, whose collected data is
+	// streamed to this file rather than written inline
+	NDJSONFile string `json:"ndjson_file,omitempty"`
+	// GraphObjects is set on --graph-objects runs and lists the only Graph
+	// object types collected, prerequisites included
+	GraphObjects []string `json:"graph_objects,omitempty"`
+	// NotCollected lists the sections Normalize had to fill in because the
+	// collector never produced them, so their empty arrays are not read as
+	// "none found"
+	NotCollected []string `json:"not_collected,omitempty"`
+}
+
+// CollectorVersions records which collector implementation and Nebula build
+// produced the output, so a dump can be traced back to the exact binary.
+type CollectorVersions struct {
+	Collector        string `json:"collector"`        // "comprehensive" (iam-pull) or "comprehensive_sdk" (iam-pull-sdk)
+	NebulaCollector  string `json:"nebula_collector"` // Nebula build version
+	NebulaCommit     string `json:"nebula_commit"`
+	GoVersion        string `json:"go_version"`
+	GraphCollector   string `json:"graph_collector"`
+	PIMCollector     string `json:"pim_collector"`
+	AzureRMCollector string `json:"azurerm_collector"`
+}
+
+// newCollectorVersions stamps the running build into the collector metadata.
+// Every section is collected by the same binary, so each records its version.
+func newCollectorVersions(collector string) CollectorVersions {
+	info := version.Info()
+	return CollectorVersions{
+		Collector:        collector,
+		NebulaCollector:  info.Version,
+		NebulaCommit:     info.Commit,
+		GoVersion:        info.GoVersion,
+		GraphCollector:   version.AbbreviatedVersion(),
+		PIMCollector:     version.AbbreviatedVersion(),
+		AzureRMCollector: version.AbbreviatedVersion(),
+	}
+}
+
+// DataSummary holds object totals for each section of the consolidated output.
+type DataSummary struct {
+	TotalAzureADObjects    int `json:"total_azure_ad_objects"`
+	TotalPIMObjects        int `json:"total_pim_objects"`
+	TotalManagementGroups  int `json:"total_management_groups"`
+	TotalMGRBACAssignments int `json:"total_mg_rbac_assignments"`
+	TotalAzureRMObjects    int `json:"total_azurerm_objects"`
+	TotalAuditLogEntries   int `json:"total_audit_log_entries,omitempty"`
+	TotalObjects           int `json:"total_objects"`
+	// OrphanedRoleAssignments counts the role assignments flagged
+	// orphanedAssignment; absent before schema 1.32
+	OrphanedRoleAssignments int `json:"orphaned_role_assignments,omitempty"`
+}
+
+// Canonical section keys. Every key listed here is present in the output, as an
+// empty array when a collector did not produce it; such sections are listed in
+// collection_metadata.not_collected so consumers can tell "none" from "not
+// collected".
+var (
+	azureADSections = []string{
+		"users", "groups", "servicePrincipals", "applications", "devices",
+		"directoryRoles", "roleDefinitions", "conditionalAccessPolicies",
+		"oauth2PermissionGrants", "authenticationMethodConfigurations",
+		"tokenLifetimePolicies", "userRegistrationDetails", "customSecurityAttributes",
+		"attributeRoleAssignments", "groupMemberships",
+		"groupOwnership", "servicePrincipalOwnership", "directoryRoleAssignments",
+		"appRoleAssignments", "applicationOwnership", "dynamicGroupFindings",
+		"groupOwnerFindings", "tenantRootRBACFindings", "resourceLockFindings",
+		"authenticationPolicyFindings", "appKeyVaultFindings", "pimGuardrailFindings",
+		"networkExposureFindings", "consentGrantFindings", "armEligibilityFindings",
+		"attributeManagementFindings", "logicAppFindings", "deviceOwnership",
+		"deviceFindings", "administrativeUnits", "administrativeUnitMembers",
+		"administrativeUnitScopedRoleMembers", "administrativeUnitRoleAssignments",
+		"administrativeUnitFindings", "ruleFindings",
+		"argRuleFindings", "storageReplicationFindings", "graphPermissionFindings",
+		"federatedIdentityCredentials",
+	}
+	pimSections = []string{
+		"eligible_assignments", "active_assignments",
+		"role_management_policies", "role_management_policy_assignments",
+		"arm_eligible_assignments", "arm_active_assignments",
+		"eligible_activations",
+	}
+	subscriptionSections = []string{
+		"subscriptionRoleAssignments", "resourceGroupRoleAssignments",
+		"resourceLevelRoleAssignments", "managementGroupRoleAssignments",
+		"tenantRoleAssignments", "azureResourceGroups", "azureResources",
+		"azureRoleDefinitions", "keyVaultAccessPolicies",
+		"lighthouseRegistrationDefinitions", "lighthouseRegistrationAssignments",
+		"lighthouseDelegations", "lighthouseFindings",
+		"classicAdministrators", "classicAdministratorFindings",
+		"storageObjectReplicationPolicies",
+	}
+)
+
+// Normalize fills in every canonical section that a collector did not populate
+// and replaces nil slices with empty ones so the JSON shape is the same no
+// matter which collector produced it or which API calls failed. Collected data
+// sections it fills in are recorded in CollectionMetadata.NotCollected.
+func (o *ConsolidatedOutput) Normalize() {
+	notCollected := make(map[string]bool)
+	for _, section := range o.CollectionMetadata.NotCollected {
+		notCollected[section] = true
+	}
+
+	if o.AzureAD == nil {
+		o.AzureAD = make(map[string]interface{})
+	}
+	if o.PIM == nil {
+		o.PIM = make(map[string]interface{})
+	}
+	if o.AzureResources == nil {
+		o.AzureResources = make(map[string]interface{})
+	}
+	if o.ManagementGroups == nil {
+		o.ManagementGroups = []interface{}{}
+		notCollected["management_groups"] = true
+	}
+	if o.ManagementGroupRBAC == nil {
+		o.ManagementGroupRBAC = []interface{}{}
+		notCollected["management_group_rbac"] = true
+	}
+	if o.ResourceLocks == nil {
+		o.ResourceLocks = []interface{}{}
+		notCollected["resource_locks"] = true
+	}
+	if o.CollectionErrors == nil {
+		o.CollectionErrors = []CollectionError{}
+	}
+
+	fillSections(o.AzureAD, azureADSections, "azure_ad.", notCollected)
+	normalizeODataTypeFields(o.AzureAD)
+	fillSections(o.PIM, pimSections, "pim.", notCollected)
+	for subscriptionID, subData := range o.AzureResources {
+		subDataMap, ok := subData.(map[string]interface{})
+		if !ok || subDataMap == nil {
+			subDataMap = make(map[string]interface{})
+			o.AzureResources[subscriptionID] = subDataMap
+		}
+		fillSections(subDataMap, subscriptionSections, "azure_resources."+subscriptionID+".", notCollected)
+	}
+
+	o.CollectionMetadata.NotCollected = nil
+	for section := range notCollected {
+		o.CollectionMetadata.NotCollected = append(o.CollectionMetadata.NotCollected, section)
+	}
+	sort.Strings(o.CollectionMetadata.NotCollected)
+}
+
+// fillSections sets each missing or nil section key to an empty array and,
+// when notCollected is not nil, records the filled data sections under prefix.
+// Findings and eligible_activations are computed after Normalize runs and are
+// not recorded.
+func fillSections(data map[string]interface{}, keys []string, prefix string, notCollected map[string]bool) {
+	for _, key := range keys {
+		switch v := data[key].(type) {
+		case nil:
+		case []interface{}:
+			if v != nil {
+				continue
+			}
+		default:
+			continue
+		}
+		data[key] = []interface{}{}
+		if notCollected != nil && !derivedSection(key) {
+			notCollected[prefix+key] = true
+		}
+	}
+}
+
+// derivedSection reports whether a section is computed from the collected
+// data when the run ends, as the findings and eligible_activations are
+func derivedSection(key string) bool {
+	return strings.HasSuffix(key, "Findings") || key == "eligible_activations"
+}
+
+// newGroupMembershipRecord builds a groupMemberships entry from a Graph directoryObject
+func newGroupMembershipRecord(groupID string, member map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"groupId":    groupID,
+		"memberId":   member["id"],
+		"memberType": normalizeODataType(member["@odata.type"]),
+	}
+}
+
+// newAppRoleAssignmentRecord builds an appRoleAssignments entry from a Graph
+// appRoleAssignment. direction is "assigned_to" for assignments held by the
+// service principal (appRoleAssignments) and "assigned_from" for assignments
+// granted on it (appRoleAssignedTo).
+func newAppRoleAssignmentRecord(assignment map[string]interface{}, spID, spDisplayName, direction string) map[string]interface{} {
+	assignmentType := "AppRoleAssignments"
+	if direction == "assigned_from" {
+		assignmentType = "AppRoleAssignedTo"
+	}
+
+	return map[string]interface{}{
+		"id":                          assignment["id"],
+		"appRoleId":                   assignment["appRoleId"],
+		"principalId":                 assignment["principalId"],
+		"principalDisplayName":        assignment["principalDisplayName"],
+		"principalType":               assignment["principalType"],
+		"resourceId":                  assignment["resourceId"],
+		"resourceDisplayName":         assignment["resourceDisplayName"],
+		"createdDateTime":             assignment["createdDateTime"],
+		"assignmentType":              assignmentType,
+		"direction":                   direction,
+		"servicePrincipalId":          spID,
+		"servicePrincipalDisplayName": spDisplayName,
+	}
+}
+
+// Summarize computes the data summary from the collected sections and stores it
+// in the collection metadata.
+func (o *ConsolidatedOutput) Summarize() DataSummary {
+	summary := DataSummary{
+		TotalAzureADObjects:    countSliceValues(o.AzureAD),
+		TotalPIMObjects:        countSliceValues(o.PIM),
+		TotalManagementGroups:  len(o.ManagementGroups),
+		TotalMGRBACAssignments: len(o.ManagementGroupRBAC),
+	}
+
+	for _, subData := range o.AzureResources {
+		if subDataMap, ok := subData.(map[string]interface{}); ok {
+			summary.TotalAzureRMObjects += countSliceValues(subDataMap)
+		}
+	}
+
+	if o.AuditLogs != nil {
+		summary.TotalAuditLogEntries = len(o.AuditLogs.SignIns) + len(o.AuditLogs.DirectoryAudits)
+	}
+
+	summary.TotalObjects = summary.TotalAzureADObjects + summary.TotalPIMObjects +
+		summary.TotalManagementGroups + summary.TotalMGRBACAssignments + summary.TotalAzureRMObjects +
+		summary.TotalAuditLogEntries
+	summary.OrphanedRoleAssignments = countOrphanedAssignments(o)
+
+	o.CollectionMetadata.DataSummary = summary
+	return summary
+}
+
+// countSliceValues sums the lengths of every []interface{} value in a section map
+func countSliceValues(section map[string]interface{}) int {
+	total := 0
+	for _, data := range section {
+		if dataSlice, ok := data.([]interface{}); ok {
+			total += len(dataSlice)
+		}
+	}
+	return total
+}
+
+// checkSchemaVersion reports whether a consolidated output's schema_version is
+// compatible with this build. Files written before versioning was introduced
+// have no schema_version and are treated as version 1.0.
+func checkSchemaVersion(version string) error {
+	if version == "" {
+		return nil
+	}
+	major := strings.SplitN(version, ".", 2)[0]
+	expected := strings.SplitN(ConsolidatedSchemaVersion, ".", 2)[0]
+	if major != expected {
+		return fmt.Errorf("unsupported consolidated schema version %s (expected %s.x)", version, expected)
+	}
+	return nil
+}
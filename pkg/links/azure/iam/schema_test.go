@@ -0,0 +1,64 @@
+package iam
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConsolidatedOutputJSONShape(t *testing.T) {
+	output := &ConsolidatedOutput{
+		CollectionMetadata: CollectionMetadata{
+			SchemaVersion: ConsolidatedSchemaVersion,
+			TenantID:      "tenant-1",
+		},
+		AzureAD: map[string]interface{}{
+			"users":  []interface{}{map[string]interface{}{"id": "u1"}, map[string]interface{}{"id": "u2"}},
+			"groups": []interface{}{map[string]interface{}{"id": "g1"}},
+		},
+		PIM: map[string]interface{}{
+			"eligible_assignments": []interface{}{map[string]interface{}{"id": "e1"}},
+		},
+		ManagementGroups:    []interface{}{map[string]interface{}{"id": "mg1"}},
+		ManagementGroupRBAC: []interface{}{},
+		AzureResources: map[string]interface{}{
+			"sub-1": map[string]interface{}{
+				"azureResources":              []interface{}{map[string]interface{}{"id": "r1"}},
+				"subscriptionRoleAssignments": []interface{}{map[string]interface{}{"id": "ra1"}, map[string]interface{}{"id": "ra2"}},
+			},
+		},
+	}
+
+	summary := output.Summarize()
+	assert.Equal(t, 3, summary.TotalAzureADObjects)
+	assert.Equal(t, 1, summary.TotalPIMObjects)
+	assert.Equal(t, 1, summary.TotalManagementGroups)
+	assert.Equal(t, 0, summary.TotalMGRBACAssignments)
+	assert.Equal(t, 3, summary.TotalAzureRMObjects)
+	assert.Equal(t, 8, summary.TotalObjects)
+
+	raw, err := json.Marshal(output)
+	require.NoError(t, err)
+
+	var decoded map[string]interface{}
+	require.NoError(t, json.Unmarshal(raw, &decoded))
+
+	for _, key := range []string{"collection_metadata", "azure_ad", "pim", "management_groups", "management_group_rbac", "azure_resources"} {
+		assert.Contains(t, decoded, key)
+	}
+
+	metadata := decoded["collection_metadata"].(map[string]interface{})
+	assert.Equal(t, ConsolidatedSchemaVersion, metadata["schema_version"])
+	assert.Contains(t, metadata, "collector_versions")
+	dataSummary := metadata["data_summary"].(map[string]interface{})
+	assert.Equal(t, float64(8), dataSummary["total_objects"])
+}
+
+func TestCheckSchemaVersion(t *testing.T) {
+	assert.NoError(t, checkSchemaVersion(""), "pre-versioning files should be accepted")
+	assert.NoError(t, checkSchemaVersion(ConsolidatedSchemaVersion))
+	assert.NoError(t, checkSchemaVersion("1.7"))
+	assert.Error(t, checkSchemaVersion("2.0"))
+}
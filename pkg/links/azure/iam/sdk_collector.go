@@ -12,6 +12,8 @@ import (
 	"sync"
 	"sync/atomic"
 	"time"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
 	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
 	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/authorization/armauthorization"
@@ -27,6 +29,7 @@ import (
 	"github.com/praetorian-inc/janus-framework/pkg/chain/cfg"
 	"github.com/praetorian-inc/nebula/internal/message"
 	"github.com/praetorian-inc/nebula/pkg/links/options"
+	"github.com/praetorian-inc/nebula/pkg/rules"
 )
 
 // SDKComprehensiveCollectorLink collects comprehensive Azure IAM data using Azure SDKs
@@ -46,11 +49,32 @@ type SDKComprehensiveCollectorLink struct {
 	httpClient *http.Client
 
 	// Credential for all SDK clients
-	credential azidentity.DefaultAzureCredential
+	credential azcore.TokenCredential
+
+	// Datasets that could not be collected during this run
+	collectionErrors collectionErrorLog
+
+	// Role assignment deduplication and its run-wide counts
+	rbacDedup *rbacDeduplicator
+
+	// Objects kept per collection in a --sample run; zero collects everything
+	sampleSize int
+
+	// Workers collecting subscriptions at once, and the Retry-After hold they
+	// share for ARM list calls
+	concurrency int
+	armThrottle armThrottle
+
+	// Writes each subscription to its own file on --split-subscriptions runs
+	shardWriter *subscriptionShardWriter
+
+	// Endpoints of the cloud collected from; the SDK collector only supports
+	// the public cloud
+	cloud azureCloud
 }
 
 func NewSDKComprehensiveCollectorLink(configs ...cfg.Config) chain.Link {
-	l := &SDKComprehensiveCollectorLink{}
+	l := &SDKComprehensiveCollectorLink{cloud: azureClouds[azureCloudPublic]}
 	l.Base = chain.NewBase(l, configs...)
 	return l
 }
@@ -58,6 +82,23 @@ func NewSDKComprehensiveCollectorLink(configs ...cfg.Config) chain.Link {
 func (l *SDKComprehensiveCollectorLink) Params() []cfg.Param {
 	return []cfg.Param{
 		options.AzureSubscription(),
+		options.AzureARMMaxPages(),
+		options.AzureConcurrency(),
+		options.AzureRBACDedup(),
+		options.AzureSample(),
+		options.AzureUseBeta(),
+		options.AzureRules(),
+		options.AzureARGRules(),
+		options.AzureCompareBaseline(),
+		options.AzureWriteBaseline(),
+		options.AzureSummaryOut(),
+		options.AzureFromDump(),
+		options.AzurePriorDump(),
+		options.AzureChangedSince(),
+		options.AzureDumpRawResponses(),
+		options.AzureSplitSubscriptions(),
+		options.AzureMinify(),
+		options.AzureProject(),
 	}
 }
 
@@ -76,7 +117,8 @@ func (l *SDKComprehensiveCollectorLink) writeCheckpoint(name string, data interf
 	filePath := filepath.Join(checkpointDir, name)
 	tmpPath := filePath + ".tmp"
 
-	jsonData, err := json.MarshalIndent(data, "", "  ")
+	minify, _ := cfg.As[bool](l.Arg("minify"))
+	jsonData, err := marshalDumpFile(data, minify)
 	if err != nil {
 		l.Logger.Error("Failed to marshal checkpoint", "name", name, "error", err)
 		return
@@ -118,8 +160,45 @@ func countCheckpointItems(data interface{}) int {
 func (l *SDKComprehensiveCollectorLink) Process(input interface{}) error {
 	// Get parameters
 	subscriptions, _ := cfg.As[[]string](l.Arg("subscription"))
+	useBeta, _ := cfg.As[[]string](l.Arg("use-beta"))
+	betaSelected, err := selectBetaCollections(useBeta)
+	if err != nil {
+		return err
+	}
+	ruleSelectors, _ := cfg.As[[]string](l.Arg("rules"))
+	selectedRules, err := rules.Select(ruleProvider, ruleSelectors)
+	if err != nil {
+		return err
+	}
+	baselineFile, _ := cfg.As[string](l.Arg("compare-baseline"))
+	var baseline *rules.Baseline
+	if baselineFile != "" {
+		if baseline, err = rules.LoadBaseline(baselineFile); err != nil {
+			return err
+		}
+	}
+	argRulesFile, _ := cfg.As[string](l.Arg("arg-rules"))
+	argRules, err := loadARGRules(argRulesFile)
+	if err != nil {
+		return err
+	}
+
+	if fromDump, _ := cfg.As[string](l.Arg("from-dump")); fromDump != "" {
+		return l.sendReanalyzedDump(fromDump, selectedRules, baseline, baselineFile)
+	}
+	priorDump, _ := cfg.As[string](l.Arg("prior-dump"))
+	changedSince, _ := cfg.As[string](l.Arg("changed-since"))
+	incremental, err := newIncrementalCollection(priorDump, changedSince)
+	if err != nil {
+		return err
+	}
 
 	l.Logger.Info("Starting comprehensive Azure IAM collection via SDKs", "subscriptions_input", subscriptions)
+	l.collectionErrors = collectionErrorLog{}
+	l.rbacDedup = newRBACDeduplicator(rbacDedupKeyArg(l.Arg("rbac-dedup")))
+	l.sampleSize = sampleSizeArg(l.Arg("sample"))
+	logSampledRun(l.sampleSize)
+	l.concurrency = concurrencyArg(l.Arg("concurrency"))
 
 	// Initialize Azure SDK clients with standard authentication
 	if err := l.initializeSDKClients(); err != nil {
@@ -139,6 +218,7 @@ func (l *SDKComprehensiveCollectorLink) Process(input interface{}) error {
 
 		subscriptionIDs = allSubs
 		l.Logger.Info("Found subscriptions", "count", len(subscriptionIDs))
+		checkSubscriptionAccess(&l.collectionErrors, subscriptionIDs)
 	} else {
 		// Use the provided subscriptions
 		subscriptionIDs = subscriptions
@@ -152,6 +232,16 @@ func (l *SDKComprehensiveCollectorLink) Process(input interface{}) error {
 		l.Logger.Error("Failed to get tenant ID", "error", err)
 		return fmt.Errorf("failed to get tenant ID: %v", err)
 	}
+	if incremental != nil {
+		if err := incremental.checkTenant(tenantID); err != nil {
+			return err
+		}
+	}
+	splitDir, _ := cfg.As[string](l.Arg("split-subscriptions"))
+	minify, _ := cfg.As[bool](l.Arg("minify"))
+	if l.shardWriter, err = newSubscriptionShardWriter(splitDir, tenantID, minify, l.Logger); err != nil {
+		return err
+	}
 
 	// STEP 1: Collect Azure AD data ONCE for the entire tenant
 	l.Logger.Info("Collecting Azure AD data via Graph SDK (once for all subscriptions)")
@@ -164,6 +254,32 @@ func (l *SDKComprehensiveCollectorLink) Process(input interface{}) error {
 	}
 
 	message.Info("Graph SDK collector completed successfully! Collected %d object types", len(azureADData))
+	if err := checkGraphAccess(&l.collectionErrors, subscriptionIDs); err != nil {
+		return err
+	}
+
+	// STEP 1.1: Collect custom security attributes and who holds the attribute roles
+	message.Info("Collecting custom security attributes...")
+	graphAccessToken, err := l.getAccessToken(ctx)
+	if err != nil {
+		return err
+	}
+	collectCustomSecurityAttributes(l.Logger, &l.collectionErrors, func(version, endpoint string) ([]interface{}, error) {
+		return l.collectPaginatedGraphDataSDK(graphAccessToken, version, endpoint)
+	}, azureADData)
+	l.writeCheckpoint("14b-custom-security-attributes.json", azureADData["customSecurityAttributes"])
+	collectDeviceOwnership(l.Logger, &l.collectionErrors, func(version, endpoint string) ([]interface{}, error) {
+		return l.collectPaginatedGraphDataSDK(graphAccessToken, version, endpoint)
+	}, azureADData)
+	l.writeCheckpoint("14d-device-ownership.json", azureADData["deviceOwnership"])
+	collectAdministrativeUnits(l.Logger, &l.collectionErrors, func(version, endpoint string) ([]interface{}, error) {
+		return l.collectPaginatedGraphDataSDK(graphAccessToken, version, endpoint)
+	}, azureADData)
+	l.writeCheckpoint("14e-administrative-units.json", azureADData["administrativeUnitMembers"])
+	collectFederatedIdentityCredentials(l.Logger, &l.collectionErrors, func(requests []map[string]interface{}) (map[string]interface{}, error) {
+		return l.callGraphBatchAPI(ctx, graphAccessToken, requests)
+	}, azureADData)
+	l.writeCheckpoint("14f-federated-identity-credentials.json", azureADData["federatedIdentityCredentials"])
 
 	// STEP 2: Collect PIM data ONCE for the entire tenant using Graph SDK
 	l.Logger.Info("Collecting PIM data via Graph SDK (once for all subscriptions)")
@@ -183,6 +299,17 @@ func (l *SDKComprehensiveCollectorLink) Process(input interface{}) error {
 		l.writeCheckpoint("16-pim-active.json", active)
 	}
 
+	// STEP 2.5: Collect the beta-only datasets the user opted into
+	if len(betaSelected) > 0 {
+		accessToken, err := l.getAccessToken(ctx)
+		if err != nil {
+			return err
+		}
+		collectBetaCollections(l.Logger, &l.collectionErrors, betaSelected, func(version, endpoint string) ([]interface{}, error) {
+			return l.collectPaginatedGraphDataSDK(accessToken, version, endpoint)
+		}, azureADData, pimData)
+	}
+
 	// STEP 3: Collect Management Groups hierarchy using SDK
 	l.Logger.Info("Collecting Management Groups hierarchy via SDK")
 	message.Info("Collecting Management Groups hierarchy via SDK...")
@@ -191,6 +318,7 @@ func (l *SDKComprehensiveCollectorLink) Process(input interface{}) error {
 	if err != nil {
 		l.Logger.Warn("Failed to collect Management Groups data via ARG, continuing without it", "error", err)
 		message.Info("Warning: Failed to collect Management Groups data: %v", err)
+		l.collectionErrors.record("management_groups", "tenant", err)
 		managementGroupsData = []interface{}{}
 	}
 
@@ -205,75 +333,101 @@ func (l *SDKComprehensiveCollectorLink) Process(input interface{}) error {
 	if err != nil {
 		l.Logger.Warn("Failed to collect MG/tenant RBAC, continuing without it", "error", err)
 		message.Info("Warning: Failed to collect MG/tenant RBAC: %v", err)
+		l.collectionErrors.record("management_group_rbac", "tenant", err)
 		mgRBACData = []interface{}{}
 	}
 
 	message.Info("MG/tenant RBAC collection completed! Collected %d assignments", len(mgRBACData))
 	l.writeCheckpoint("17b-mg-tenant-rbac.json", mgRBACData)
 
-	// STEP 4: Process subscriptions using optimized batched SDK clients
-	l.Logger.Info("Processing %d subscriptions with optimized batched SDK clients", len(subscriptionIDs))
-	allSubscriptionData := l.processSubscriptionsOptimizedSDK(subscriptionIDs)
-
-	// Create consolidated data structure (exact same format as HTTP version)
-	consolidatedData := map[string]interface{}{
-		"collection_metadata": map[string]interface{}{
-			"tenant_id":               tenantID,
-			"collection_timestamp":    time.Now().UTC().Format("2006-01-02T15:04:05Z"),
-			"subscriptions_processed": len(subscriptionIDs),
-			"collector_versions": map[string]interface{}{
-				"nebula_collector": "comprehensive_sdk",
-				"graph_collector":     "sdk_completed",
-				"pim_collector":       "sdk_completed",
-				"azurerm_collector":   "sdk_completed",
-			},
-		},
-		"azure_ad":              azureADData,
-		"pim":                   pimData,
-		"management_groups":     managementGroupsData,
-		"management_group_rbac": mgRBACData,
-		"azure_resources":       allSubscriptionData,
-	}
-
-	// Calculate totals for summary (same logic as HTTP version)
-	adTotal := 0
-	for _, data := range azureADData {
-		if dataSlice, ok := data.([]interface{}); ok {
-			adTotal += len(dataSlice)
+	// STEP 3.5: With --prior-dump, only subscriptions changed since the watermark are collected again
+	collectSubscriptionIDs := subscriptionIDs
+	var carriedSubscriptionIDs []string
+	if incremental != nil {
+		message.Info("Checking activity logs for subscriptions changed since the prior dump...")
+		if activityToken, err := l.getManagementAccessToken(ctx); err != nil {
+			l.Logger.Error("Failed to get management token for activity logs, collecting every subscription", "error", err)
+			l.collectionErrors.record(activityLogDataset, "tenant", err)
+		} else {
+			collectSubscriptionIDs, carriedSubscriptionIDs = incremental.partition(l.Logger, &l.collectionErrors, subscriptionIDs, time.Now().UTC(), func(url string) ([]interface{}, error) {
+				return l.collectPaginatedARMDataSDK(ctx, activityToken, url)
+			})
 		}
 	}
 
-	pimTotal := 0
-	for _, data := range pimData {
-		if dataSlice, ok := data.([]interface{}); ok {
-			pimTotal += len(dataSlice)
-		}
-	}
+	// STEP 4: Process subscriptions using optimized batched SDK clients
+	l.Logger.Info("Processing %d subscriptions with optimized batched SDK clients", len(collectSubscriptionIDs))
+	allSubscriptionData := map[string]interface{}{}
+	if len(collectSubscriptionIDs) > 0 {
+		// Resource Graph reads every visible subscription when given none
+		allSubscriptionData = l.processSubscriptionsOptimizedSDK(collectSubscriptionIDs)
+	}
+	var incrementalMetadata *IncrementalCollection
+	if incremental != nil {
+		incrementalMetadata = incremental.carryForward(allSubscriptionData, collectSubscriptionIDs, carriedSubscriptionIDs)
+	}
+
+	// STEP 5: Collect management locks for every subscription
+	l.Logger.Info("Collecting resource locks via ARM")
+	message.Info("Collecting resource locks...")
+	resourceLocks := l.collectResourceLocksSDK(l.Context(), subscriptionIDs)
+	message.Info("Resource lock collection completed! Collected %d locks", len(resourceLocks))
+	l.writeCheckpoint("23-resource-locks.json", resourceLocks)
+
+	// STEP 6: Collect PIM for Azure resources eligible and active role assignments
+	l.Logger.Info("Collecting PIM for Azure resources role assignments via ARM")
+	message.Info("Collecting PIM for Azure resources role assignments...")
+	l.collectARMPIMDataSDK(l.Context(), subscriptionIDs, pimData)
+	l.writeCheckpoint("24-arm-pim.json", map[string]interface{}{
+		"arm_eligible_assignments": pimData["arm_eligible_assignments"],
+		"arm_active_assignments":   pimData["arm_active_assignments"],
+	})
 
-	azurermTotal := 0
-	for _, subData := range allSubscriptionData {
-		if subDataMap, ok := subData.(map[string]interface{}); ok {
-			for _, data := range subDataMap {
-				if dataSlice, ok := data.([]interface{}); ok {
-					azurermTotal += len(dataSlice)
-				}
-			}
-		}
+	// STEP 7: Run the Resource Graph detections from --arg-rules
+	if len(argRules) > 0 && len(subscriptionIDs) > 0 {
+		message.Info("Running %d ARG rules...", len(argRules))
+		azureADData["argRuleFindings"] = runARGRules(l.Logger, &l.collectionErrors, argRules, func(query string) ([]interface{}, error) {
+			return l.queryResourceGraphSDK(l.Context(), subscriptionIDs, query)
+		})
 	}
 
-	managementGroupsTotal := len(managementGroupsData)
-	mgRBACTotal := len(mgRBACData)
+	// Create consolidated data structure (exact same format as HTTP version)
+	consolidatedData := &ConsolidatedOutput{
+		CollectionMetadata: CollectionMetadata{
+			SchemaVersion:          ConsolidatedSchemaVersion,
+			TenantID:               tenantID,
+			CollectionTimestamp:    time.Now().UTC().Format("2006-01-02T15:04:05Z"),
+			SubscriptionsProcessed: len(subscriptionIDs),
+			CollectorVersions:      newCollectorVersions("comprehensive_sdk"),
+			RBACDeduplication:      l.rbacDedup.stats(),
+			IncrementalCollection:  incrementalMetadata,
+		},
+		AzureAD:             azureADData,
+		PIM:                 pimData,
+		ManagementGroups:    managementGroupsData,
+		ManagementGroupRBAC: mgRBACData,
+		AzureResources:      allSubscriptionData,
+		ResourceLocks:       resourceLocks,
+		CollectionErrors:    l.collectionErrors.list(),
+	}
 
-	// Add summary metadata
-	consolidatedData["collection_metadata"].(map[string]interface{})["data_summary"] = map[string]interface{}{
-		"total_azure_ad_objects":     adTotal,
-		"total_pim_objects":          pimTotal,
-		"total_management_groups":    managementGroupsTotal,
-		"total_mg_rbac_assignments":  mgRBACTotal,
-		"total_azurerm_objects":      azurermTotal,
-		"total_objects":              adTotal + pimTotal + managementGroupsTotal + mgRBACTotal + azurermTotal,
+	orphanedAssignments, orphansChecked := markOrphanedAssignments(l.Logger, consolidatedData)
+	consolidatedData.ApplySample(l.sampleSize)
+	consolidatedData.Normalize()
+	consolidatedData.PIM["eligible_activations"] = buildEligibleActivations(consolidatedData)
+	evaluateFindingRules(consolidatedData, selectedRules)
+	if baseline != nil {
+		compareFindingsBaseline(consolidatedData, selectedRules, baseline, baselineFile)
 	}
 
+	// Calculate totals for summary (same logic as HTTP version)
+	summary := consolidatedData.Summarize()
+	adTotal := summary.TotalAzureADObjects
+	pimTotal := summary.TotalPIMObjects
+	managementGroupsTotal := summary.TotalManagementGroups
+	mgRBACTotal := summary.TotalMGRBACAssignments
+	azurermTotal := summary.TotalAzureRMObjects
+
 	message.Info("=== Azure IAM Collection Summary (SDK) ====")
 	message.Info("Tenant: %s", tenantID)
 	message.Info("Total Azure AD objects: %d", adTotal)
@@ -281,6 +435,28 @@ func (l *SDKComprehensiveCollectorLink) Process(input interface{}) error {
 	message.Info("Total Management Groups: %d", managementGroupsTotal)
 	message.Info("Total MG/tenant RBAC assignments: %d", mgRBACTotal)
 	message.Info("Total AzureRM objects: %d", azurermTotal)
+	logRBACDeduplication(consolidatedData.CollectionMetadata.RBACDeduplication)
+	if orphansChecked {
+		message.Info("Orphaned role assignments (principal no longer in the directory): %d", orphanedAssignments)
+	}
+	printCollectionErrorSummary(consolidatedData.CollectionErrors)
+	logSampledRun(l.sampleSize)
+	if baseline != nil {
+		logBaselineComparison(l.Logger, consolidatedData, selectedRules)
+	} else {
+		logFindingRules(l.Logger, consolidatedData, selectedRules)
+	}
+	if writeBaseline, _ := cfg.As[string](l.Arg("write-baseline")); writeBaseline != "" {
+		writeFindingsBaseline(l.Logger, consolidatedData, selectedRules, writeBaseline)
+	}
+	if summaryOut, _ := cfg.As[string](l.Arg("summary-out")); summaryOut != "" {
+		writeExecutiveSummary(l.Logger, consolidatedData, selectedRules, summaryOut)
+	}
+	projectFields, _ := cfg.As[[]string](l.Arg("project"))
+	consolidatedData.Project(projectFields)
+	if err := l.shardWriter.finish(consolidatedData); err != nil {
+		return err
+	}
 	message.Info("🎉 Azure IAM SDK collection completed successfully!")
 
 	// Send consolidated data to outputter
@@ -288,6 +464,27 @@ func (l *SDKComprehensiveCollectorLink) Process(input interface{}) error {
 	return nil
 }
 
+// sendReanalyzedDump handles --from-dump: the detections run over a saved dump
+// and no SDK client is created
+func (l *SDKComprehensiveCollectorLink) sendReanalyzedDump(path string, selectedRules []rules.Rule, baseline *rules.Baseline, baselineFile string) error {
+	writeBaseline, _ := cfg.As[string](l.Arg("write-baseline"))
+	summaryOut, _ := cfg.As[string](l.Arg("summary-out"))
+	project, _ := cfg.As[[]string](l.Arg("project"))
+	output, err := reanalyzeDump(l.Logger, path, dumpReanalysis{
+		selected:      selectedRules,
+		baseline:      baseline,
+		baselineFile:  baselineFile,
+		writeBaseline: writeBaseline,
+		summaryOut:    summaryOut,
+		project:       project,
+	})
+	if err != nil {
+		return err
+	}
+	l.Send(output)
+	return nil
+}
+
 // initializeSDKClients initializes all Azure SDK clients with standard authentication
 func (l *SDKComprehensiveCollectorLink) initializeSDKClients() error {
 	// Use standard Azure SDK authentication (az login)
@@ -296,16 +493,31 @@ func (l *SDKComprehensiveCollectorLink) initializeSDKClients() error {
 		return fmt.Errorf("failed to get default Azure credentials: %v", err)
 	}
 
-	l.credential = *cred
+	l.credential = cred
+
+	// --dump-raw-responses records the ARM, Resource Graph and raw Graph calls.
+	// The typed Graph SDK client sends requests through its own pipeline and is
+	// not recorded.
+	transport := http.RoundTripper(newNetworkRetryTransport(http.DefaultTransport))
+	var armOptions *arm.ClientOptions
+	if rawDir, _ := cfg.As[string](l.Arg("dump-raw-responses")); rawDir != "" {
+		recorder, err := newRawResponseRecorder(transport, rawDir, l.Logger)
+		if err != nil {
+			return err
+		}
+		message.Warning("Writing raw API responses to %s, they contain tenant data", rawDir)
+		transport = recorder
+		armOptions = &arm.ClientOptions{ClientOptions: policy.ClientOptions{Transport: recorder}}
+	}
 
 	// Initialize Microsoft Graph SDK client
-	l.graphClient, err = msgraphsdk.NewGraphServiceClientWithCredentials(cred, []string{"https://graph.microsoft.com/.default"})
+	l.graphClient, err = msgraphsdk.NewGraphServiceClientWithCredentials(cred, []string{l.cloud.Graph + "/.default"})
 	if err != nil {
 		return fmt.Errorf("failed to create Graph SDK client: %v", err)
 	}
 
 	// Initialize Azure Resource Manager SDK clients
-	l.subscriptionClient, err = armsubscriptions.NewClient(cred, nil)
+	l.subscriptionClient, err = armsubscriptions.NewClient(cred, armOptions)
 	if err != nil {
 		return fmt.Errorf("failed to create subscriptions client: %v", err)
 	}
@@ -313,29 +525,30 @@ func (l *SDKComprehensiveCollectorLink) initializeSDKClients() error {
 	// Note: Authorization client will be created per subscription in collectAllRoleAssignmentsSDK
 	// since it requires subscription ID in constructor
 
-	l.roleDefClient, err = armauthorization.NewRoleDefinitionsClient(cred, nil)
+	l.roleDefClient, err = armauthorization.NewRoleDefinitionsClient(cred, armOptions)
 	if err != nil {
 		return fmt.Errorf("failed to create role definitions client: %v", err)
 	}
 
-	l.resourceGraphClient, err = armresourcegraph.NewClient(cred, nil)
+	l.resourceGraphClient, err = armresourcegraph.NewClient(cred, armOptions)
 	if err != nil {
 		return fmt.Errorf("failed to create resource graph client: %v", err)
 	}
 
-	l.managementGroupClient, err = armmanagementgroups.NewClient(cred, nil)
+	l.managementGroupClient, err = armmanagementgroups.NewClient(cred, armOptions)
 	if err != nil {
 		return fmt.Errorf("failed to create management groups client: %v", err)
 	}
 
-	l.resourceClient, err = armresources.NewClient("", cred, nil) // subscription set per call
+	l.resourceClient, err = armresources.NewClient("", cred, armOptions) // subscription set per call
 	if err != nil {
 		return fmt.Errorf("failed to create resources client: %v", err)
 	}
 
 	// Initialize HTTP client for batch operations
 	l.httpClient = &http.Client{
-		Timeout: 120 * time.Second, // Increased timeout for batch operations
+		Transport: transport,
+		Timeout:   120 * time.Second, // Increased timeout for batch operations
 	}
 
 	l.Logger.Info("Successfully initialized all Azure SDK clients and HTTP client")
@@ -362,24 +575,30 @@ func (l *SDKComprehensiveCollectorLink) getTenantIDFromContext(ctx context.Conte
 
 // getAccessToken gets an access token for Microsoft Graph API using the credential
 func (l *SDKComprehensiveCollectorLink) getAccessToken(ctx context.Context) (string, error) {
-	// Create a new credential instance for token generation
-	cred, err := azidentity.NewDefaultAzureCredential(nil)
+	// Reuse the credential created in initializeSDKClients so tokens are cached
+	token, err := l.credential.GetToken(ctx, policy.TokenRequestOptions{
+		Scopes: []string{l.cloud.Graph + "/.default"},
+	})
 	if err != nil {
-		return "", fmt.Errorf("failed to create credential for token: %v", err)
+		return "", fmt.Errorf("failed to get access token: %v", err)
 	}
+	return token.Token, nil
+}
 
-	token, err := cred.GetToken(ctx, policy.TokenRequestOptions{
-		Scopes: []string{"https://graph.microsoft.com/.default"},
+// getManagementAccessToken gets an access token for Azure Resource Manager using the credential
+func (l *SDKComprehensiveCollectorLink) getManagementAccessToken(ctx context.Context) (string, error) {
+	token, err := l.credential.GetToken(ctx, policy.TokenRequestOptions{
+		Scopes: []string{l.cloud.ARM + "/.default"},
 	})
 	if err != nil {
-		return "", fmt.Errorf("failed to get access token: %v", err)
+		return "", fmt.Errorf("failed to get management access token: %v", err)
 	}
 	return token.Token, nil
 }
 
 // callGraphBatchAPI makes batch Graph API call using HTTP client with retry logic
 func (l *SDKComprehensiveCollectorLink) callGraphBatchAPI(ctx context.Context, accessToken string, requests []map[string]interface{}) (map[string]interface{}, error) {
-	batchURL := "https://graph.microsoft.com/v1.0/$batch"
+	batchURL := l.cloud.graphURL(graphV1, "/$batch")
 
 	batchPayload := map[string]interface{}{
 		"requests": requests,
@@ -955,6 +1174,7 @@ func (l *SDKComprehensiveCollectorLink) collectAllGraphDataSDK() (map[string]int
 		collectionErrors["appRoleAssignments"] = err.Error()
 		l.logCollectionEnd("appRoleAssignments", startTime, 0)
 	} else {
+		appRoleAssignments = deduplicateAppRoleAssignments(l.Logger, appRoleAssignments)
 		azureADData["appRoleAssignments"] = appRoleAssignments
 		l.logCollectionEnd("appRoleAssignments", startTime, len(appRoleAssignments))
 		l.writeCheckpoint("12-app-role-assignments.json", appRoleAssignments)
@@ -1046,13 +1266,12 @@ func (l *SDKComprehensiveCollectorLink) collectGroupOwnershipSDK(ctx context.Con
 	startTime := l.logCollectionStart("groupOwnership")
 	l.Logger.Info("Collecting group ownership via Graph SDK batch API")
 
-	// Get all groups first
-	groupsResponse, err := l.graphClient.Groups().Get(ctx, nil)
+	// Get all groups first; every page, so owners of groups past the first
+	// page are not silently dropped
+	groups, err := l.collectAllGroupsWithPagination(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get groups: %v", err)
 	}
-
-	groups := groupsResponse.GetValue()
 	l.Logger.Info("Getting owners for groups", "count", len(groups))
 
 	// Get access token for batch API calls
@@ -1076,15 +1295,15 @@ func (l *SDKComprehensiveCollectorLink) collectGroupOwnershipSDK(ctx context.Con
 		groupDataMap := make(map[string]map[string]interface{})
 
 		for j, group := range batchGroups {
-			if group == nil || group.GetId() == nil {
+			groupMap, ok := group.(map[string]interface{})
+			if !ok {
 				continue
 			}
-
-			groupID := *group.GetId()
-			groupName := ""
-			if group.GetDisplayName() != nil {
-				groupName = *group.GetDisplayName()
+			groupID, _ := groupMap["id"].(string)
+			if groupID == "" {
+				continue
 			}
+			groupName, _ := groupMap["displayName"].(string)
 
 			groupDataMap[fmt.Sprintf("group_%d", j)] = map[string]interface{}{
 				"id":          groupID,
@@ -1132,7 +1351,7 @@ func (l *SDKComprehensiveCollectorLink) collectGroupOwnershipSDK(ctx context.Con
 												"groupName":      groupName,
 												"ownerId":        ownerID,
 												"ownerName":      ownerMap["displayName"],
-												"ownerType":      ownerMap["@odata.type"],
+												"ownerType":      normalizeODataType(ownerMap["@odata.type"]),
 												"permissionType": "GroupOwnership",
 												"role":           "Owner",
 											}
@@ -1249,7 +1468,7 @@ func (l *SDKComprehensiveCollectorLink) collectServicePrincipalOwnershipSDK(ctx
 												"servicePrincipalName": spName,
 												"ownerId":              ownerID,
 												"ownerName":            ownerMap["displayName"],
-												"ownerType":            ownerMap["@odata.type"],
+												"ownerType":            normalizeODataType(ownerMap["@odata.type"]),
 												"permissionType":       "ServicePrincipalOwnership",
 												"role":                 "Owner",
 											}
@@ -1372,8 +1591,7 @@ func (l *SDKComprehensiveCollectorLink) collectServicePrincipalDirectoryRolesSDK
 							}
 
 							// Filter for directory roles only
-							odataType, ok := memberMap["@odata.type"].(string)
-							if !ok || odataType != "#microsoft.graph.directoryRole" {
+							if !isODataType(memberMap["@odata.type"], odataTypeDirectoryRole) {
 								continue
 							}
 
@@ -1387,7 +1605,7 @@ func (l *SDKComprehensiveCollectorLink) collectServicePrincipalDirectoryRolesSDK
 								"roleTemplateId": memberMap["roleTemplateId"],
 								"roleName":       memberMap["displayName"],
 								"principalId":    spID,
-								"principalType":  "#microsoft.graph.servicePrincipal",
+								"principalType":  odataTypeServicePrincipal,
 							}
 							assignments = append(assignments, assignment)
 						}
@@ -1418,7 +1636,7 @@ func (l *SDKComprehensiveCollectorLink) collectApplicationOwnershipSDK(ctx conte
 	}
 
 	// Use paginated collection with $expand=owners
-	applications, err := l.collectPaginatedGraphDataSDK(accessToken, "/applications?$expand=owners")
+	applications, err := l.collectPaginatedGraphDataSDK(accessToken, graphV1, "/applications?$expand=owners")
 	if err != nil {
 		return nil, fmt.Errorf("failed to get applications with owners: %v", err)
 	}
@@ -1442,7 +1660,7 @@ func (l *SDKComprehensiveCollectorLink) collectApplicationOwnershipSDK(ctx conte
 				}
 
 				ownerID, _ := ownerMap["id"].(string)
-				ownerType, _ := ownerMap["@odata.type"].(string)
+				ownerType := normalizeODataType(ownerMap["@odata.type"])
 				ownerName, _ := ownerMap["displayName"].(string)
 
 				// Output format MUST match HTTP version exactly
@@ -1464,13 +1682,13 @@ func (l *SDKComprehensiveCollectorLink) collectApplicationOwnershipSDK(ctx conte
 	return applicationOwnerships, nil
 }
 
-// collectPaginatedGraphDataSDK is a helper to collect paginated Graph API data using HTTP client
-func (l *SDKComprehensiveCollectorLink) collectPaginatedGraphDataSDK(accessToken string, endpoint string) ([]interface{}, error) {
+// collectPaginatedGraphDataSDK is a helper to collect paginated Graph API data
+// using HTTP client, from endpoint on the given API version (graphV1 or graphBeta)
+func (l *SDKComprehensiveCollectorLink) collectPaginatedGraphDataSDK(accessToken string, version string, endpoint string) ([]interface{}, error) {
 	var allResults []interface{}
 	ctx := l.Context()
 
-	baseURL := "https://graph.microsoft.com/v1.0"
-	url := baseURL + endpoint
+	url := l.cloud.graphURL(version, endpoint)
 
 	for url != "" {
 		req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
@@ -1486,8 +1704,9 @@ func (l *SDKComprehensiveCollectorLink) collectPaginatedGraphDataSDK(accessToken
 			return nil, fmt.Errorf("failed to execute request: %v", err)
 		}
 		if resp.StatusCode != 200 {
+			apiErr := newAPIStatusError(resp)
 			resp.Body.Close()
-			return nil, fmt.Errorf("Graph API call failed with status %d", resp.StatusCode)
+			return nil, apiErr
 		}
 
 		var result map[string]interface{}
@@ -1500,6 +1719,9 @@ func (l *SDKComprehensiveCollectorLink) collectPaginatedGraphDataSDK(accessToken
 		if value, ok := result["value"].([]interface{}); ok {
 			allResults = append(allResults, value...)
 		}
+		if sampleReached(l.sampleSize, len(allResults)) {
+			return sampleObjects(allResults, l.sampleSize), nil
+		}
 
 		// Handle pagination
 		if nextLink, ok := result["@odata.nextLink"].(string); ok && nextLink != "" {
@@ -1680,6 +1902,7 @@ func (l *SDKComprehensiveCollectorLink) collectAllPIMDataSDK() (map[string]inter
 	eligibleAssignments, err := l.collectAllPIMEligibleWithPagination(ctx)
 	if err != nil {
 		l.Logger.Error("Failed to collect eligible assignments via paginated SDK", "error", err)
+		l.collectionErrors.record("eligible_assignments", "tenant", err)
 		pimData["eligible_assignments"] = []interface{}{} // Empty array on error
 		l.logCollectionEnd("PIM eligible assignments", startTime, 0)
 	} else {
@@ -1694,6 +1917,7 @@ func (l *SDKComprehensiveCollectorLink) collectAllPIMDataSDK() (map[string]inter
 	activeAssignments, err := l.collectAllPIMActiveWithPagination(ctx)
 	if err != nil {
 		l.Logger.Error("Failed to collect active assignments via paginated SDK", "error", err)
+		l.collectionErrors.record("active_assignments", "tenant", err)
 		pimData["active_assignments"] = []interface{}{} // Empty array on error
 		l.logCollectionEnd("PIM active assignments", startTime, 0)
 	} else {
@@ -1701,58 +1925,21 @@ func (l *SDKComprehensiveCollectorLink) collectAllPIMDataSDK() (map[string]inter
 		l.logCollectionEnd("PIM active assignments", startTime, len(activeAssignments))
 	}
 
-	// Collection 3: Role Management Policies (new data not available in HTTP version)
-	// TODO: Re-enable when API endpoint is available in SDK
-	l.Logger.Info("Skipping PIM role management policies - API not available in current SDK version")
-	/*
-	policiesResponse, err := l.graphClient.RoleManagement().Directory().RoleManagementPolicies().Get(ctx, nil)
-	if err != nil {
-		l.Logger.Error("Failed to collect role management policies via SDK", "error", err)
-		// Continue without policies - this is additional data not in HTTP version
-	} else {
-		policies := policiesResponse.GetValue()
-		policyInterfaces := make([]interface{}, len(policies))
-		for i, policy := range policies {
-			policyMap := map[string]interface{}{
-				"id":                 *policy.GetId(),
-				"displayName":        stringPtrToInterface(policy.GetDisplayName()),
-				"description":        stringPtrToInterface(policy.GetDescription()),
-				"isOrganizationDefault": boolPtrToInterface(policy.GetIsOrganizationDefault()),
-				"lastModifiedDateTime": timeToInterface(policy.GetLastModifiedDateTime()),
-				// Rules and other complex objects can be added later
-			}
-			policyInterfaces[i] = policyMap
-		}
-		pimData["role_management_policies"] = policyInterfaces
-		l.Logger.Info("Collected role management policies via SDK", "count", len(policies))
-	}
-	*/
+	// Role management policies are only served by the Graph beta endpoint and
+	// are collected with --use-beta role-management-policies
 
-	// Collection 4: Policy Assignments (new data not available in HTTP version)
-	// TODO: Re-enable when API endpoint is available in SDK
-	l.Logger.Info("Skipping PIM role management policy assignments - API not available in current SDK version")
-	/*
-	assignmentsResponse, err := l.graphClient.RoleManagement().Directory().RoleManagementPolicyAssignments().Get(ctx, nil)
+	// Collection 3: Policy assignments, with each directory role's activation rules
+	startTime = l.logCollectionStart("PIM role management policy assignments")
+	policyAssignments, err := l.collectRoleManagementPolicyAssignmentsSDK(ctx)
 	if err != nil {
-		l.Logger.Error("Failed to collect role management policy assignments via SDK", "error", err)
-		// Continue without policy assignments - this is additional data
+		l.Logger.Error("Failed to collect role management policy assignments", "error", err)
+		l.collectionErrors.record("role_management_policy_assignments", "tenant", err)
+		pimData["role_management_policy_assignments"] = []interface{}{}
+		l.logCollectionEnd("PIM role management policy assignments", startTime, 0)
 	} else {
-		assignments := assignmentsResponse.GetValue()
-		assignmentInterfaces := make([]interface{}, len(assignments))
-		for i, assignment := range assignments {
-			assignmentMap := map[string]interface{}{
-				"id":                 *assignment.GetId(),
-				"policyId":           stringPtrToInterface(assignment.GetPolicyId()),
-				"roleDefinitionId":   stringPtrToInterface(assignment.GetRoleDefinitionId()),
-				"scopeId":            stringPtrToInterface(assignment.GetScopeId()),
-				"scopeType":          stringPtrToInterface(assignment.GetScopeType()),
-			}
-			assignmentInterfaces[i] = assignmentMap
-		}
-		pimData["role_management_policy_assignments"] = assignmentInterfaces
-		l.Logger.Info("Collected role management policy assignments via SDK", "count", len(assignments))
+		pimData["role_management_policy_assignments"] = policyAssignments
+		l.logCollectionEnd("PIM role management policy assignments", startTime, len(policyAssignments))
 	}
-	*/
 
 	// Calculate total PIM resource counts for final summary
 	totalPIMItems := len(eligibleAssignments) + len(activeAssignments)
@@ -1851,40 +2038,19 @@ func (l *SDKComprehensiveCollectorLink) processSubscriptionsParallelSDK(
 		err            error
 	}
 
-	subChan := make(chan string, len(subscriptionIDs))
+	// The SDK clients are safe for concurrent use and their retry policy
+	// already waits out 429 responses for the Retry-After Azure asks for
 	resultChan := make(chan subResult, len(subscriptionIDs))
-
-	// Use single worker to avoid concurrency issues with Azure SDK rate limiting
-	// Multiple workers can cause API throttling and authentication conflicts
-	var wg sync.WaitGroup
-	numWorkers := 1
-
-	// Start workers
-	for i := 0; i < numWorkers; i++ {
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			for subscriptionID := range subChan {
-				l.Logger.Info("Processing subscription via SDK", "subscription", subscriptionID)
-				data, err := l.collectAllAzureRMDataSDK(subscriptionID)
-				resultChan <- subResult{
-					subscriptionID: subscriptionID,
-					data:           data,
-					err:            err,
-				}
-			}
-		}()
-	}
-
-	// Send subscriptions to workers
-	for _, subscriptionID := range subscriptionIDs {
-		subChan <- subscriptionID
-	}
-	close(subChan)
-
-	// Wait for all workers to complete
 	go func() {
-		wg.Wait()
+		runPool(subscriptionIDs, l.concurrency, func(_ int, subscriptionID string) {
+			l.Logger.Info("Processing subscription via SDK", "subscription", subscriptionID)
+			data, err := l.collectAllAzureRMDataSDK(subscriptionID)
+			resultChan <- subResult{
+				subscriptionID: subscriptionID,
+				data:           data,
+				err:            err,
+			}
+		})
 		close(resultChan)
 	}()
 
@@ -1894,9 +2060,11 @@ func (l *SDKComprehensiveCollectorLink) processSubscriptionsParallelSDK(
 		if result.err != nil {
 			l.Logger.Error("Failed to collect data for subscription via SDK",
 				"subscription", result.subscriptionID, "error", result.err)
+			l.collectionErrors.record("subscription", result.subscriptionID, result.err)
 			continue
 		}
 		allSubscriptionData[result.subscriptionID] = result.data
+		l.shardWriter.stream(result.subscriptionID, result.data)
 		l.Logger.Info("Successfully processed subscription via SDK",
 			"subscription", result.subscriptionID)
 	}
@@ -1983,7 +2151,7 @@ func (l *SDKComprehensiveCollectorLink) collectAllAzureRMDataSDK(subscriptionID
 	}
 
 	// Apply deduplication to RBAC assignments (matching HTTP version behavior)
-	l.deduplicateRBACAssignments(azurermData)
+	l.rbacDedup.deduplicate(l.Logger, azurermData)
 
 	return azurermData, nil
 }
@@ -2116,7 +2284,7 @@ func (l *SDKComprehensiveCollectorLink) collectRoleAssignmentsViaSDK(subscriptio
 	var roleAssignments []interface{}
 
 	// Create a subscription-scoped authorization client
-	authClient, err := armauthorization.NewRoleAssignmentsClient(subscriptionID, &l.credential, nil)
+	authClient, err := armauthorization.NewRoleAssignmentsClient(subscriptionID, l.credential, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create authorization client: %v", err)
 	}
@@ -2257,33 +2425,13 @@ func (l *SDKComprehensiveCollectorLink) collectAllRoleAssignmentsSDK(subscriptio
 		queryRequest.Options.SkipToken = response.SkipToken
 	}
 
-	// Group assignments by scope type (matching HTTP version's grouping logic at collector.go:601-631)
-	for _, assignment := range allAssignments {
-		assignmentMap, ok := assignment.(map[string]interface{})
-		if !ok {
-			continue
-		}
-
-		scope, _ := assignmentMap["scope"].(string)
-		scope = normalizeScope(scope)
-		assignmentMap["scope"] = scope
-
-		switch {
-		case strings.HasPrefix(scope, "/providers/microsoft.management/managementgroups/"):
-			managementGroupRoleAssignments = append(managementGroupRoleAssignments, assignmentMap)
-		case scope == "/" || scope == "":
-			tenantRoleAssignments = append(tenantRoleAssignments, assignmentMap)
-		case strings.Count(scope, "/") == 2:
-			// /subscriptions/{subscription-id}
-			subscriptionRoleAssignments = append(subscriptionRoleAssignments, assignmentMap)
-		case strings.Contains(scope, "/resourcegroups/") && strings.Count(scope, "/") == 4:
-			// /subscriptions/{sub}/resourcegroups/{rg}
-			resourceGroupRoleAssignments = append(resourceGroupRoleAssignments, assignmentMap)
-		default:
-			// Resource-level or other
-			resourceLevelRoleAssignments = append(resourceLevelRoleAssignments, assignmentMap)
-		}
-	}
+	// Group assignments by scope the same way as the HTTP collector
+	grouped := groupRBACAssignmentsByScope(allAssignments)
+	subscriptionRoleAssignments = grouped[scopeBucketSubscription]
+	resourceGroupRoleAssignments = grouped[scopeBucketResourceGroup]
+	resourceLevelRoleAssignments = grouped[scopeBucketResource]
+	managementGroupRoleAssignments = grouped[scopeBucketManagementGroup]
+	tenantRoleAssignments = grouped[scopeBucketTenant]
 
 	l.Logger.Info("Completed role assignments collection via Resource Graph",
 		"subscription", subscriptionID,
@@ -2353,80 +2501,6 @@ func (l *SDKComprehensiveCollectorLink) collectManagementGroupAndTenantRBAC() ([
 	return allAssignments, nil
 }
 
-// deduplicateRBACAssignments removes duplicate RBAC assignments by ID across all scope levels
-// This matches the HTTP version's deduplication behavior (collector.go lines 1104-1155)
-func (l *SDKComprehensiveCollectorLink) deduplicateRBACAssignments(azurermData map[string]interface{}) {
-	seenAssignments := make(map[string]bool)
-
-	deduplicateAssignments := func(assignments []interface{}) []interface{} {
-		var unique []interface{}
-		for _, assignment := range assignments {
-			if assignmentMap, ok := assignment.(map[string]interface{}); ok {
-				if id, ok := assignmentMap["id"].(string); ok && id != "" {
-					if !seenAssignments[id] {
-						seenAssignments[id] = true
-						unique = append(unique, assignment)
-					}
-				}
-			}
-		}
-		return unique
-	}
-
-	// Apply to subscription-level assignments
-	if val, exists := azurermData["subscriptionRoleAssignments"]; exists {
-		if assignments, ok := val.([]interface{}); ok {
-			originalCount := len(assignments)
-			deduplicated := deduplicateAssignments(assignments)
-			azurermData["subscriptionRoleAssignments"] = deduplicated
-			l.Logger.Info("Subscription RBAC deduplication", "original", originalCount, "unique", len(deduplicated), "duplicates_removed", originalCount-len(deduplicated))
-		}
-	}
-
-	// Apply to resource group-level assignments
-	if val, exists := azurermData["resourceGroupRoleAssignments"]; exists {
-		if assignments, ok := val.([]interface{}); ok {
-			originalCount := len(assignments)
-			deduplicated := deduplicateAssignments(assignments)
-			azurermData["resourceGroupRoleAssignments"] = deduplicated
-			l.Logger.Info("Resource Group RBAC deduplication", "original", originalCount, "unique", len(deduplicated), "duplicates_removed", originalCount-len(deduplicated))
-		}
-	}
-
-	// Apply to resource-level assignments
-	if val, exists := azurermData["resourceLevelRoleAssignments"]; exists {
-		if assignments, ok := val.([]interface{}); ok {
-			originalCount := len(assignments)
-			deduplicated := deduplicateAssignments(assignments)
-			azurermData["resourceLevelRoleAssignments"] = deduplicated
-			l.Logger.Info("Resource-level RBAC deduplication", "original", originalCount, "unique", len(deduplicated), "duplicates_removed", originalCount-len(deduplicated))
-		}
-	}
-
-	// Apply to management group-level assignments (SDK-only scope, not in HTTP)
-	if val, exists := azurermData["managementGroupRoleAssignments"]; exists {
-		if assignments, ok := val.([]interface{}); ok {
-			originalCount := len(assignments)
-			deduplicated := deduplicateAssignments(assignments)
-			azurermData["managementGroupRoleAssignments"] = deduplicated
-			l.Logger.Info("Management Group RBAC deduplication", "original", originalCount, "unique", len(deduplicated), "duplicates_removed", originalCount-len(deduplicated))
-		}
-	}
-
-	// Apply to tenant-level assignments (SDK-only scope, not in HTTP)
-	if val, exists := azurermData["tenantRoleAssignments"]; exists {
-		if assignments, ok := val.([]interface{}); ok {
-			originalCount := len(assignments)
-			deduplicated := deduplicateAssignments(assignments)
-			azurermData["tenantRoleAssignments"] = deduplicated
-			l.Logger.Info("Tenant RBAC deduplication", "original", originalCount, "unique", len(deduplicated), "duplicates_removed", originalCount-len(deduplicated))
-		}
-	}
-
-	totalUnique := len(seenAssignments)
-	l.Logger.Info("Total RBAC assignment deduplication complete", "total_unique_assignments", totalUnique)
-}
-
 // collectAllRoleDefinitionsSDK collects all role definitions for a subscription using Authorization SDK
 func (l *SDKComprehensiveCollectorLink) collectAllRoleDefinitionsSDK(subscriptionID string) ([]interface{}, error) {
 	ctx := l.Context()
@@ -2673,6 +2747,10 @@ func (l *SDKComprehensiveCollectorLink) collectAllUsersWithPagination(ctx contex
 		}
 
 		totalObjects += len(users)
+		if sampleReached(l.sampleSize, totalObjects) {
+			allUsers = sampleObjects(allUsers, l.sampleSize)
+			break // --sample: later pages are not needed
+		}
 
 		// Check if there's a next page using the @odata.nextLink
 		odataNextLink := response.GetOdataNextLink()
@@ -2730,6 +2808,10 @@ func (l *SDKComprehensiveCollectorLink) collectAllGroupsWithPagination(ctx conte
 		}
 
 		totalObjects += len(groups)
+		if sampleReached(l.sampleSize, totalObjects) {
+			allGroups = sampleObjects(allGroups, l.sampleSize)
+			break // --sample: later pages are not needed
+		}
 
 		// Check if there's a next page
 		odataNextLink := response.GetOdataNextLink()
@@ -2789,6 +2871,12 @@ func (l *SDKComprehensiveCollectorLink) collectAllServicePrincipalsWithPaginatio
 				"signInAudience":             stringPtrToInterface(sp.GetSignInAudience()),
 				"appOwnerOrganizationId":     uuidPtrToInterface(sp.GetAppOwnerOrganizationId()),
 			}
+			if publisher := sp.GetVerifiedPublisher(); publisher != nil {
+				spMap["verifiedPublisher"] = map[string]interface{}{
+					"displayName":         stringPtrToInterface(publisher.GetDisplayName()),
+					"verifiedPublisherId": stringPtrToInterface(publisher.GetVerifiedPublisherId()),
+				}
+			}
 
 			// Extract keyCredentials (certificates)
 			if keyCreds := sp.GetKeyCredentials(); keyCreds != nil {
@@ -2839,6 +2927,10 @@ func (l *SDKComprehensiveCollectorLink) collectAllServicePrincipalsWithPaginatio
 		}
 
 		totalObjects += len(sps)
+		if sampleReached(l.sampleSize, totalObjects) {
+			allSPs = sampleObjects(allSPs, l.sampleSize)
+			break // --sample: later pages are not needed
+		}
 
 		// Check if there's a next page
 		odataNextLink := response.GetOdataNextLink()
@@ -2976,6 +3068,10 @@ func (l *SDKComprehensiveCollectorLink) collectAllApplicationsWithPagination(ctx
 		}
 
 		totalObjects += len(apps)
+		if sampleReached(l.sampleSize, totalObjects) {
+			allApps = sampleObjects(allApps, l.sampleSize)
+			break // --sample: later pages are not needed
+		}
 
 		// Check if there's a next page
 		odataNextLink := response.GetOdataNextLink()
@@ -3070,6 +3166,10 @@ func (l *SDKComprehensiveCollectorLink) collectAllPIMEligibleWithPagination(ctx
 		}
 
 		totalObjects += len(schedules)
+		if sampleReached(l.sampleSize, totalObjects) {
+			allEligible = sampleObjects(allEligible, l.sampleSize)
+			break // --sample: later pages are not needed
+		}
 
 		// Check if there's a next page
 		odataNextLink := response.GetOdataNextLink()
@@ -3164,6 +3264,10 @@ func (l *SDKComprehensiveCollectorLink) collectAllPIMActiveWithPagination(ctx co
 		}
 
 		totalObjects += len(schedules)
+		if sampleReached(l.sampleSize, totalObjects) {
+			allActive = sampleObjects(allActive, l.sampleSize)
+			break // --sample: later pages are not needed
+		}
 
 		// Check if there's a next page
 		odataNextLink := response.GetOdataNextLink()
@@ -3233,6 +3337,10 @@ func (l *SDKComprehensiveCollectorLink) collectAllDevicesWithPagination(ctx cont
 		}
 
 		totalObjects += len(devices)
+		if sampleReached(l.sampleSize, totalObjects) {
+			allDevices = sampleObjects(allDevices, l.sampleSize)
+			break // --sample: later pages are not needed
+		}
 
 		// Check if there's a next page
 		odataNextLink := response.GetOdataNextLink()
@@ -3529,10 +3637,10 @@ func (l *SDKComprehensiveCollectorLink) collectAllDirectoryRoleAssignmentsWithPa
 												}
 
 												// Add principal type if available
-												if principalType, ok := memberMap["@odata.type"].(string); ok {
+												if principalType := normalizeODataType(memberMap["@odata.type"]); principalType != "" {
 													assignment["principalType"] = principalType
 												} else {
-													assignment["principalType"] = "#microsoft.graph.directoryObject"
+													assignment["principalType"] = odataTypeDirectoryObject
 												}
 
 												allAssignments = append(allAssignments, assignment)
@@ -3679,6 +3787,12 @@ func (l *SDKComprehensiveCollectorLink) collectAllConditionalAccessPoliciesWithP
 				if termsOfUse := grantControls.GetTermsOfUse(); termsOfUse != nil {
 					gcMap["termsOfUse"] = stringSliceToInterface(termsOfUse)
 				}
+				if strength := grantControls.GetAuthenticationStrength(); strength != nil && strength.GetId() != nil {
+					gcMap["authenticationStrength"] = map[string]interface{}{
+						"id":          *strength.GetId(),
+						"displayName": stringPtrToInterface(strength.GetDisplayName()),
+					}
+				}
 				policyMap["grantControls"] = gcMap
 			}
 
@@ -4134,18 +4248,10 @@ func (l *SDKComprehensiveCollectorLink) collectAllGroupMembershipsWithPagination
 			if !ok {
 				continue
 			}
-			memberID, ok := memberMap["id"].(string)
-			if !ok {
+			if _, ok := memberMap["id"].(string); !ok {
 				continue
 			}
-			membership := map[string]interface{}{
-				"groupId":  groupID,
-				"memberId": memberID,
-			}
-			if memberType, ok := memberMap["@odata.type"].(string); ok {
-				membership["memberType"] = memberType
-			}
-			allMemberships = append(allMemberships, membership)
+			allMemberships = append(allMemberships, newGroupMembershipRecord(groupID, memberMap))
 			totalObjects++
 		}
 	}
@@ -4213,15 +4319,10 @@ func (l *SDKComprehensiveCollectorLink) collectAllGroupMembershipsWithPagination
 						continue
 					}
 
-					membershipMap := map[string]interface{}{
-						"groupId":  groupId,
-						"memberId": *member.GetId(),
-					}
-
-					// Add member type if available
-					if member.GetOdataType() != nil {
-						membershipMap["memberType"] = *member.GetOdataType()
-					}
+					membershipMap := newGroupMembershipRecord(groupId, map[string]interface{}{
+						"id":          *member.GetId(),
+						"@odata.type": stringPtrToInterface(member.GetOdataType()),
+					})
 
 					allMemberships = append(allMemberships, membershipMap)
 					totalObjects++
@@ -4294,15 +4395,10 @@ func (l *SDKComprehensiveCollectorLink) processBatchGroupsFallback(ctx context.C
 					continue
 				}
 
-				membershipMap := map[string]interface{}{
-					"groupId":  groupID,
-					"memberId": *member.GetId(),
-				}
-
-				// Add member type if available
-				if member.GetOdataType() != nil {
-					membershipMap["memberType"] = *member.GetOdataType()
-				}
+				membershipMap := newGroupMembershipRecord(groupID, map[string]interface{}{
+					"id":          *member.GetId(),
+					"@odata.type": stringPtrToInterface(member.GetOdataType()),
+				})
 
 				memberships = append(memberships, membershipMap)
 			}
@@ -4390,12 +4486,11 @@ func (l *SDKComprehensiveCollectorLink) collectAllDirectoryRoleAssignmentsWithPa
 				}
 
 				// Determine principal type from OData type
-				odataType := member.GetOdataType()
-				if odataType != nil {
-					memberMap["principalType"] = *odataType
+				if odataType := normalizeODataType(stringPtrToInterface(member.GetOdataType())); odataType != "" {
+					memberMap["principalType"] = odataType
 				} else {
 					// Fallback to generic type
-					memberMap["principalType"] = "#microsoft.graph.directoryObject"
+					memberMap["principalType"] = odataTypeDirectoryObject
 				}
 
 				allAssignments = append(allAssignments, memberMap)
@@ -4467,10 +4562,10 @@ func (l *SDKComprehensiveCollectorLink) processBatchRolesFallback(ctx context.Co
 				}
 
 				// Add principal type if available
-				if member.GetOdataType() != nil {
-					assignmentMap["principalType"] = *member.GetOdataType()
+				if odataType := normalizeODataType(stringPtrToInterface(member.GetOdataType())); odataType != "" {
+					assignmentMap["principalType"] = odataType
 				} else {
-					assignmentMap["principalType"] = "#microsoft.graph.directoryObject"
+					assignmentMap["principalType"] = odataTypeDirectoryObject
 				}
 
 				assignments = append(assignments, assignmentMap)
@@ -4527,6 +4622,10 @@ func (l *SDKComprehensiveCollectorLink) collectAllOAuth2PermissionGrantsWithPagi
 		}
 
 		totalObjects += len(grants)
+		if sampleReached(l.sampleSize, totalObjects) {
+			allGrants = sampleObjects(allGrants, l.sampleSize)
+			break // --sample: later pages are not needed
+		}
 
 		// Check if there's a next page
 		odataNextLink := response.GetOdataNextLink()
@@ -4737,43 +4836,11 @@ func (l *SDKComprehensiveCollectorLink) collectAllAppRoleAssignmentsWithPaginati
 				if !ok {
 					continue
 				}
-				assignmentID, ok := assignmentMap["id"].(string)
-				if !ok {
+				if _, ok := assignmentMap["id"].(string); !ok {
 					continue
 				}
 
-				appRoleAssignment := map[string]interface{}{
-					"id":                          assignmentID,
-					"assignmentType":              assignmentType,
-					"serviceOnSpId":               spID,
-					"direction":                   direction,
-					"servicePrincipalId":          spID,
-					"servicePrincipalDisplayName": spDisplayName,
-				}
-
-				if v, ok := assignmentMap["principalId"].(string); ok {
-					appRoleAssignment["principalId"] = v
-				}
-				if v, ok := assignmentMap["principalDisplayName"].(string); ok {
-					appRoleAssignment["principalDisplayName"] = v
-				}
-				if v, ok := assignmentMap["principalType"].(string); ok {
-					appRoleAssignment["principalType"] = v
-				}
-				if v, ok := assignmentMap["resourceId"].(string); ok {
-					appRoleAssignment["resourceId"] = v
-				}
-				if v, ok := assignmentMap["resourceDisplayName"].(string); ok {
-					appRoleAssignment["resourceDisplayName"] = v
-				}
-				if v, ok := assignmentMap["appRoleId"].(string); ok {
-					appRoleAssignment["appRoleId"] = v
-				}
-				if v, ok := assignmentMap["createdDateTime"].(string); ok {
-					appRoleAssignment["createdDateTime"] = v
-				}
-
-				allAppRoleAssignments = append(allAppRoleAssignments, appRoleAssignment)
+				allAppRoleAssignments = append(allAppRoleAssignments, newAppRoleAssignmentRecord(assignmentMap, spID, spDisplayName, direction))
 				totalObjects++
 			}
 		}
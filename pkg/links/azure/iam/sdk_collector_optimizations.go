@@ -36,44 +36,22 @@ func (l *SDKComprehensiveCollectorLink) processSubscriptionsOptimizedSDK(
 		err            error
 	}
 
-	subChan := make(chan string, len(subscriptionIDs))
 	resultChan := make(chan subResult, len(subscriptionIDs))
-
-	// Still use single worker but with optimized per-subscription processing
-	var wg sync.WaitGroup
-	numWorkers := 1
-
-	// Start workers
-	for i := 0; i < numWorkers; i++ {
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			for subscriptionID := range subChan {
-				l.Logger.Info("Processing subscription with optimization", "subscription", subscriptionID)
-
-				// Use pre-collected batched resources
-				subscriptionResources := l.filterResourcesBySubscription(batchedResources, subscriptionID)
-				subscriptionResourceGroups := l.filterResourcesBySubscription(batchedResourceGroups, subscriptionID)
-
-				data, err := l.collectAllAzureRMDataOptimizedSDK(subscriptionID, subscriptionResources, subscriptionResourceGroups)
-				resultChan <- subResult{
-					subscriptionID: subscriptionID,
-					data:           data,
-					err:            err,
-				}
-			}
-		}()
-	}
-
-	// Send subscriptions to workers
-	for _, subscriptionID := range subscriptionIDs {
-		subChan <- subscriptionID
-	}
-	close(subChan)
-
-	// Wait for all workers to complete
 	go func() {
-		wg.Wait()
+		runPool(subscriptionIDs, l.concurrency, func(_ int, subscriptionID string) {
+			l.Logger.Info("Processing subscription with optimization", "subscription", subscriptionID)
+
+			// Use pre-collected batched resources
+			subscriptionResources := l.filterResourcesBySubscription(batchedResources, subscriptionID)
+			subscriptionResourceGroups := l.filterResourcesBySubscription(batchedResourceGroups, subscriptionID)
+
+			data, err := l.collectAllAzureRMDataOptimizedSDK(subscriptionID, subscriptionResources, subscriptionResourceGroups)
+			resultChan <- subResult{
+				subscriptionID: subscriptionID,
+				data:           data,
+				err:            err,
+			}
+		})
 		close(resultChan)
 	}()
 
@@ -81,6 +59,7 @@ func (l *SDKComprehensiveCollectorLink) processSubscriptionsOptimizedSDK(
 	for result := range resultChan {
 		if result.err != nil {
 			l.Logger.Error("Failed to process subscription", "subscription", result.subscriptionID, "error", result.err)
+			l.collectionErrors.record("subscription", result.subscriptionID, result.err)
 			continue
 		}
 		allData[result.subscriptionID] = result.data
@@ -135,6 +114,7 @@ func (l *SDKComprehensiveCollectorLink) collectAllResourcesWithBatching(subscrip
 		response, err := l.resourceGraphClient.Resources(ctx, queryRequest, nil)
 		if err != nil {
 			l.Logger.Error("Batched Resource Graph resources query failed", "error", err)
+			l.collectionErrors.record("azureResources", "all subscriptions", err)
 			break
 		}
 
@@ -168,6 +148,7 @@ func (l *SDKComprehensiveCollectorLink) collectAllResourcesWithBatching(subscrip
 		response, err := l.resourceGraphClient.Resources(ctx, queryRequest, nil)
 		if err != nil {
 			l.Logger.Error("Batched Resource Graph resource groups query failed", "error", err)
+			l.collectionErrors.record("azureResourceGroups", "all subscriptions", err)
 			break
 		}
 
@@ -216,6 +197,7 @@ func (l *SDKComprehensiveCollectorLink) collectAllAzureRMDataOptimizedSDK(subscr
 	subscriptionRoleAssignments, resourceGroupRoleAssignments, resourceLevelRoleAssignments, managementGroupRoleAssignments, tenantRoleAssignments, err := l.collectAllRoleAssignmentsSDK(subscriptionID)
 	if err != nil {
 		l.Logger.Error("Failed to collect role assignments via SDK", "subscription", subscriptionID, "error", err)
+		l.collectionErrors.record("roleAssignments", subscriptionID, err)
 		azurermData["subscriptionRoleAssignments"] = []interface{}{}
 		azurermData["resourceGroupRoleAssignments"] = []interface{}{}
 		azurermData["resourceLevelRoleAssignments"] = []interface{}{}
@@ -238,6 +220,7 @@ func (l *SDKComprehensiveCollectorLink) collectAllAzureRMDataOptimizedSDK(subscr
 	roleDefinitions, err := l.collectAllRoleDefinitionsSDK(subscriptionID)
 	if err != nil {
 		l.Logger.Error("Failed to collect role definitions via SDK", "subscription", subscriptionID, "error", err)
+		l.collectionErrors.record("azureRoleDefinitions", subscriptionID, err)
 		azurermData["azureRoleDefinitions"] = []interface{}{}
 		l.logCollectionEnd("role definitions - " + subscriptionID, startTime, 0)
 	} else {
@@ -249,8 +232,42 @@ func (l *SDKComprehensiveCollectorLink) collectAllAzureRMDataOptimizedSDK(subscr
 	// Skip Key Vault access policies collection for now (as in original)
 	azurermData["keyVaultAccessPolicies"] = []interface{}{}
 
+	// Collection 3: Azure Lighthouse delegations to external managing tenants
+	startTime = l.logCollectionStart("Lighthouse delegations - " + subscriptionID)
+	lighthouseData, err := l.collectLighthouseDataSDK(context.Background(), subscriptionID)
+	if err != nil {
+		l.Logger.Error("Failed to collect Lighthouse delegations", "subscription", subscriptionID, "error", err)
+		l.collectionErrors.record("lighthouse", subscriptionID, err)
+		lighthouseData = newLighthouseSections(subscriptionID, nil, nil)
+	}
+	for key, value := range lighthouseData {
+		azurermData[key] = value
+	}
+	l.logCollectionEnd("Lighthouse delegations - "+subscriptionID, startTime, len(lighthouseData["lighthouseDelegations"].([]interface{})))
+	logLighthouseFindings(l.Logger, subscriptionID, lighthouseData["lighthouseFindings"].([]interface{}))
+
+	// Collection 4: Classic subscription administrators outside Azure RBAC
+	startTime = l.logCollectionStart("classic administrators - " + subscriptionID)
+	classicData, err := l.collectClassicAdministratorsSDK(context.Background(), subscriptionID)
+	if err != nil {
+		l.Logger.Error("Failed to collect classic administrators", "subscription", subscriptionID, "error", err)
+		l.collectionErrors.record("classicAdministrators", subscriptionID, err)
+		classicData = newClassicAdministratorSections(subscriptionID, nil)
+	}
+	for key, value := range classicData {
+		azurermData[key] = value
+	}
+	l.logCollectionEnd("classic administrators - "+subscriptionID, startTime, len(classicData["classicAdministrators"].([]interface{})))
+	logClassicAdministratorFindings(l.Logger, subscriptionID, classicData["classicAdministratorFindings"].([]interface{}))
+
+	// Collection 5: Object replication policies of the collected storage accounts
+	startTime = l.logCollectionStart("object replication policies - " + subscriptionID)
+	replicationPolicies := l.collectObjectReplicationPoliciesSDK(context.Background(), subscriptionID, preCollectedResources)
+	azurermData["storageObjectReplicationPolicies"] = replicationPolicies
+	l.logCollectionEnd("object replication policies - "+subscriptionID, startTime, len(replicationPolicies))
+
 	// Apply deduplication to RBAC assignments (matching HTTP version behavior)
-	l.deduplicateRBACAssignments(azurermData)
+	l.rbacDedup.deduplicate(l.Logger, azurermData)
 	l.writeCheckpoint(fmt.Sprintf("22-rbac-deduplicated-%s.json", subscriptionID[:8]), azurermData)
 
 	// Calculate total resource counts for final summary
@@ -275,9 +292,6 @@ func (l *SDKComprehensiveCollectorLink) collectAllGraphDataSDKOptimized() (map[s
 		err  error
 	}
 
-	// Channel to collect results
-	resultChan := make(chan collectionResult, 9)
-
 	// Start all independent collections in parallel
 	collections := []struct {
 		name string
@@ -292,8 +306,13 @@ func (l *SDKComprehensiveCollectorLink) collectAllGraphDataSDKOptimized() (map[s
 		{"roleDefinitions", l.collectAllRoleDefinitionsWithPagination},
 		{"conditionalAccessPolicies", l.collectAllConditionalAccessPoliciesWithPagination},
 		{"oauth2PermissionGrants", l.collectAllOAuth2PermissionGrantsWithPagination},
+		{"authenticationMethodConfigurations", l.collectAuthenticationMethodConfigurationsSDK},
+		{"tokenLifetimePolicies", l.collectTokenLifetimePoliciesSDK},
 	}
 
+	// Channel to collect results
+	resultChan := make(chan collectionResult, len(collections))
+
 	// Launch goroutines for independent collections (with controlled concurrency)
 	semaphore := make(chan struct{}, 3) // Limit to 3 concurrent Graph API collections
 	var wg sync.WaitGroup
@@ -339,6 +358,7 @@ func (l *SDKComprehensiveCollectorLink) collectAllGraphDataSDKOptimized() (map[s
 	for result := range resultChan {
 		if result.err != nil {
 			l.Logger.Error("Failed to collect data type", "type", result.name, "error", result.err)
+			l.collectionErrors.record(result.name, "tenant", result.err)
 			azureADData[result.name] = []interface{}{} // Empty array on error
 		} else {
 			azureADData[result.name] = result.data
@@ -355,6 +375,7 @@ func (l *SDKComprehensiveCollectorLink) collectAllGraphDataSDKOptimized() (map[s
 	directoryRoleAssignments, err := l.collectAllDirectoryRoleAssignmentsWithPagination(ctx)
 	if err != nil {
 		l.Logger.Error("Failed to collect directory role assignments via SDK", "error", err)
+		l.collectionErrors.record("directoryRoleAssignments", "tenant", err)
 		azureADData["directoryRoleAssignments"] = []interface{}{}
 		l.logCollectionEnd("directoryRoleAssignments (batched)", startTime, 0)
 	} else {
@@ -372,6 +393,7 @@ func (l *SDKComprehensiveCollectorLink) collectAllGraphDataSDKOptimized() (map[s
 	groupMemberships, err := l.collectAllGroupMembershipsWithPagination(ctx, preGroups)
 	if err != nil {
 		l.Logger.Error("Failed to collect group memberships via SDK", "error", err)
+		l.collectionErrors.record("groupMemberships", "tenant", err)
 		azureADData["groupMemberships"] = []interface{}{}
 		l.logCollectionEnd("groupMemberships (batched)", startTime, 0)
 	} else {
@@ -389,6 +411,7 @@ func (l *SDKComprehensiveCollectorLink) collectAllGraphDataSDKOptimized() (map[s
 	appRoleAssignments, err := l.collectAllAppRoleAssignmentsWithPagination(ctx, preSPs)
 	if err != nil {
 		l.Logger.Error("Failed to collect app role assignments via SDK", "error", err)
+		l.collectionErrors.record("appRoleAssignments", "tenant", err)
 		azureADData["appRoleAssignments"] = []interface{}{}
 		l.logCollectionEnd("appRoleAssignments (batched)", startTime, 0)
 	} else {
@@ -435,6 +458,7 @@ func (l *SDKComprehensiveCollectorLink) collectAllGraphDataSDKOptimized() (map[s
 	groupOwnership, err := l.collectGroupOwnershipSDK(ctx)
 	if err != nil {
 		l.Logger.Error("Failed to collect group ownership via SDK", "error", err)
+		l.collectionErrors.record("groupOwnership", "tenant", err)
 		azureADData["groupOwnership"] = []interface{}{}
 		l.logCollectionEnd("groupOwnership", startTime, 0)
 	} else {
@@ -448,6 +472,7 @@ func (l *SDKComprehensiveCollectorLink) collectAllGraphDataSDKOptimized() (map[s
 	spOwnership, err := l.collectServicePrincipalOwnershipSDK(ctx)
 	if err != nil {
 		l.Logger.Error("Failed to collect service principal ownership via SDK", "error", err)
+		l.collectionErrors.record("servicePrincipalOwnership", "tenant", err)
 		azureADData["servicePrincipalOwnership"] = []interface{}{}
 		l.logCollectionEnd("servicePrincipalOwnership", startTime, 0)
 	} else {
@@ -461,6 +486,7 @@ func (l *SDKComprehensiveCollectorLink) collectAllGraphDataSDKOptimized() (map[s
 	appOwnership, err := l.collectApplicationOwnershipSDK(ctx)
 	if err != nil {
 		l.Logger.Error("Failed to collect application ownership via SDK", "error", err)
+		l.collectionErrors.record("applicationOwnership", "tenant", err)
 		azureADData["applicationOwnership"] = []interface{}{}
 		l.logCollectionEnd("applicationOwnership", startTime, 0)
 	} else {
@@ -0,0 +1,83 @@
+package iam
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+const (
+	suppressionActionSuppress      = "suppress"
+	suppressionActionInformational = "informational"
+)
+
+// SPSuppression is an accepted-risk entry for a service principal whose
+// dangerous Graph permissions should not be reported as new findings
+type SPSuppression struct {
+	ID     string `json:"id"` // appId or service principal object ID
+	Reason string `json:"reason"`
+	Action string `json:"action,omitempty"` // "suppress" (default) or "informational"
+}
+
+// spSuppressionList indexes suppressions by lowercased appId or object ID
+type spSuppressionList map[string]SPSuppression
+
+// loadSPSuppressions reads a --suppress-sp-file. An empty path disables suppression.
+func loadSPSuppressions(path string) (spSuppressionList, error) {
+	if path == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read suppress-sp-file: %w", err)
+	}
+	suppressions, err := parseSPSuppressions(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid suppress-sp-file %s: %w", path, err)
+	}
+	return suppressions, nil
+}
+
+func parseSPSuppressions(data []byte) (spSuppressionList, error) {
+	var entries []SPSuppression
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, err
+	}
+
+	suppressions := make(spSuppressionList, len(entries))
+	for i, entry := range entries {
+		entry.ID = strings.TrimSpace(entry.ID)
+		if entry.ID == "" {
+			return nil, fmt.Errorf("entry %d has no id", i)
+		}
+		if strings.TrimSpace(entry.Reason) == "" {
+			return nil, fmt.Errorf("entry %d (%s) has no reason", i, entry.ID)
+		}
+		switch strings.ToLower(entry.Action) {
+		case "", suppressionActionSuppress:
+			entry.Action = suppressionActionSuppress
+		case suppressionActionInformational:
+			entry.Action = suppressionActionInformational
+		default:
+			return nil, fmt.Errorf("entry %d (%s) has unknown action %q, expected %q or %q",
+				i, entry.ID, entry.Action, suppressionActionSuppress, suppressionActionInformational)
+		}
+		suppressions[strings.ToLower(entry.ID)] = entry
+	}
+	return suppressions, nil
+}
+
+// match returns the suppression covering the service principal a permission
+// was granted to or through, matching either its object ID or appId
+func (s spSuppressionList) match(permission CompleteGraphPermission) (SPSuppression, bool) {
+	for _, id := range []string{permission.ServicePrincipalID, permission.ServicePrincipalAppID} {
+		if id == "" {
+			continue
+		}
+		if suppression, ok := s[strings.ToLower(id)]; ok {
+			return suppression, true
+		}
+	}
+	return SPSuppression{}, false
+}
@@ -0,0 +1,40 @@
+package iam
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSPSuppressions(t *testing.T) {
+	suppressions, err := parseSPSuppressions([]byte(`[
+		{"id": "00000003-0000-0000-C000-000000000000", "reason": "Microsoft Graph first-party app"},
+		{"id": "sp-object-1", "reason": "Approved backup tool", "action": "Informational"}
+	]`))
+	require.NoError(t, err)
+
+	graph, ok := suppressions.match(CompleteGraphPermission{ServicePrincipalAppID: "00000003-0000-0000-c000-000000000000"})
+	require.True(t, ok, "appIds should match case-insensitively")
+	assert.Equal(t, suppressionActionSuppress, graph.Action)
+	assert.Equal(t, "Microsoft Graph first-party app", graph.Reason)
+
+	backup, ok := suppressions.match(CompleteGraphPermission{ServicePrincipalID: "sp-object-1", ServicePrincipalAppID: "other-app"})
+	require.True(t, ok, "object IDs should match")
+	assert.Equal(t, suppressionActionInformational, backup.Action)
+
+	_, ok = suppressions.match(CompleteGraphPermission{UserID: "sp-object-1"})
+	assert.False(t, ok, "only service principals are suppressed")
+}
+
+func TestParseSPSuppressionsRejectsInvalidEntries(t *testing.T) {
+	for name, input := range map[string]string{
+		"missing id":     `[{"reason": "x"}]`,
+		"missing reason": `[{"id": "app-1"}]`,
+		"unknown action": `[{"id": "app-1", "reason": "x", "action": "ignore"}]`,
+		"not a list":     `{"id": "app-1"}`,
+	} {
+		_, err := parseSPSuppressions([]byte(input))
+		assert.Error(t, err, name)
+	}
+}
@@ -0,0 +1,193 @@
+package iam
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/praetorian-inc/janus-framework/pkg/chain/cfg"
+)
+
+// storageAPIVersion is the Microsoft.Storage API version used for object
+// replication policies
+const storageAPIVersion = "2023-05-01"
+
+// objectReplicationPoliciesURL is the ARM path listing the object replication
+// policies of a storage account. A policy is listed on both its source and its destination
+// account.
+func objectReplicationPoliciesURL(storageAccountID string) string {
+	return fmt.Sprintf("%s/objectReplicationPolicies?api-version=%s", storageAccountID, storageAPIVersion)
+}
+
+// collectObjectReplicationPolicies lists the object replication policies of
+// every storage account in a subscription's collected resources. Accounts
+// without blob storage cannot replicate and are skipped. A failure is recorded
+// per account so one inaccessible account does not hide the rest.
+func collectObjectReplicationPolicies(logger *cfg.Logger, errs *collectionErrorLog, subscriptionID string, resources []interface{}, fetch func(url string) ([]interface{}, error)) []interface{} {
+	policies := []interface{}{}
+	for _, resource := range resources {
+		account, ok := resource.(map[string]interface{})
+		if !ok || !strings.EqualFold(fmt.Sprint(account["type"]), "microsoft.storage/storageaccounts") {
+			continue
+		}
+		if kind, _ := account["kind"].(string); strings.EqualFold(kind, "FileStorage") {
+			continue
+		}
+		accountID, _ := account["id"].(string)
+		accountPolicies, err := fetch(objectReplicationPoliciesURL(accountID))
+		if err != nil {
+			logger.Warn("Failed to collect object replication policies", "storage_account", accountID, "error", err)
+			errs.record("storageObjectReplicationPolicies", accountID, err)
+			continue
+		}
+		for _, policy := range accountPolicies {
+			if policyMap, ok := policy.(map[string]interface{}); ok {
+				policies = append(policies, newObjectReplicationPolicyRecord(subscriptionID, account, policyMap))
+			}
+		}
+	}
+	logger.Info("Collected object replication policies", "subscription", subscriptionID, "count", len(policies))
+	return policies
+}
+
+// collectObjectReplicationPoliciesSDK lists object replication policies with
+// the SDK collector's credential
+func (l *SDKComprehensiveCollectorLink) collectObjectReplicationPoliciesSDK(ctx context.Context, subscriptionID string, resources []interface{}) []interface{} {
+	token, err := l.getManagementAccessToken(ctx)
+	if err != nil {
+		l.Logger.Error("Failed to collect object replication policies", "subscription", subscriptionID, "error", err)
+		l.collectionErrors.record("storageObjectReplicationPolicies", subscriptionID, err)
+		return []interface{}{}
+	}
+	return collectObjectReplicationPolicies(l.Logger, &l.collectionErrors, subscriptionID, resources, func(url string) ([]interface{}, error) {
+		return l.collectPaginatedARMDataSDK(ctx, token, url)
+	})
+}
+
+// newObjectReplicationPolicyRecord flattens an object replication policy into
+// a storageObjectReplicationPolicies entry for the account it was listed on
+func newObjectReplicationPolicyRecord(subscriptionID string, account, policy map[string]interface{}) map[string]interface{} {
+	record := map[string]interface{}{
+		"id":                 policy["id"],
+		"name":               policy["name"],
+		"subscriptionId":     subscriptionID,
+		"storageAccountId":   account["id"],
+		"storageAccountName": account["name"],
+		"rules":              []interface{}{},
+	}
+	if accountProperties, ok := account["properties"].(map[string]interface{}); ok {
+		record["allowCrossTenantReplication"] = accountProperties["allowCrossTenantReplication"]
+	}
+	properties, _ := policy["properties"].(map[string]interface{})
+	record["policyId"] = properties["policyId"]
+	record["sourceAccount"] = properties["sourceAccount"]
+	record["destinationAccount"] = properties["destinationAccount"]
+	record["enabledTime"] = properties["enabledTime"]
+
+	rules, _ := properties["rules"].([]interface{})
+	for _, rule := range rules {
+		ruleMap, ok := rule.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		flattened := map[string]interface{}{
+			"ruleId":               ruleMap["ruleId"],
+			"sourceContainer":      ruleMap["sourceContainer"],
+			"destinationContainer": ruleMap["destinationContainer"],
+		}
+		if filters, ok := ruleMap["filters"].(map[string]interface{}); ok {
+			flattened["prefixMatch"] = filters["prefixMatch"]
+			flattened["minCreationTime"] = filters["minCreationTime"]
+		}
+		record["rules"] = append(record["rules"].([]interface{}), flattened)
+	}
+	return record
+}
+
+// buildObjectReplicationFindings flags object replication policies whose
+// source is a collected storage account and whose destination is not in any
+// collected subscription. Replicated blobs are copied out of the tenant's
+// control, to another tenant when cross-tenant replication is allowed. The
+// destination is named by resource ID, or by account name alone when the
+// policy was created with cross-tenant replication allowed; a name that
+// matches no collected account is treated as outside.
+func buildObjectReplicationFindings(o *ConsolidatedOutput) []interface{} {
+	findings := []interface{}{}
+
+	subscriptions := make(map[string]bool, len(o.AzureResources))
+	accountNames := make(map[string]bool)
+	var policies []map[string]interface{}
+	for subscriptionID, subData := range o.AzureResources {
+		subscriptions[strings.ToLower(subscriptionID)] = true
+		subDataMap, ok := subData.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		resources, _ := subDataMap["azureResources"].([]interface{})
+		for _, resource := range resources {
+			if resourceMap, ok := resource.(map[string]interface{}); ok && strings.EqualFold(fmt.Sprint(resourceMap["type"]), "microsoft.storage/storageaccounts") {
+				accountNames[strings.ToLower(fmt.Sprint(resourceMap["name"]))] = true
+			}
+		}
+		records, _ := subDataMap["storageObjectReplicationPolicies"].([]interface{})
+		for _, record := range records {
+			if recordMap, ok := record.(map[string]interface{}); ok {
+				policies = append(policies, recordMap)
+			}
+		}
+	}
+
+	for _, policy := range policies {
+		accountID, _ := policy["storageAccountId"].(string)
+		accountName, _ := policy["storageAccountName"].(string)
+		source, _ := policy["sourceAccount"].(string)
+		if !strings.EqualFold(source, accountID) && !strings.EqualFold(source, accountName) {
+			// Listed on its destination account; the source side reports it
+			continue
+		}
+		destination, _ := policy["destinationAccount"].(string)
+		destinationSubscription := storageAccountSubscription(destination)
+		if destinationSubscription != "" && subscriptions[destinationSubscription] {
+			continue
+		}
+		if destinationSubscription == "" && accountNames[strings.ToLower(destination)] {
+			continue
+		}
+
+		rules, _ := policy["rules"].([]interface{})
+		findings = append(findings, map[string]interface{}{
+			"type":     "StorageObjectReplicationOutsideTenant",
+			"severity": "High",
+			"description": fmt.Sprintf("Storage account %s replicates blobs to %s, outside the collected subscriptions (%d rules)",
+				accountName, destination, len(rules)),
+			"resourceId":                  accountID,
+			"subscriptionId":              policy["subscriptionId"],
+			"policyId":                    policy["policyId"],
+			"sourceAccount":               source,
+			"destinationAccount":          destination,
+			"destinationSubscriptionId":   destinationSubscription,
+			"allowCrossTenantReplication": policy["allowCrossTenantReplication"],
+			"rules":                       rules,
+		})
+	}
+
+	sortFindings(findings, "description")
+	return findings
+}
+
+// storageAccountSubscription returns the lowercased subscription of a storage
+// account resource ID, or "" when the account is named without one
+func storageAccountSubscription(account string) string {
+	parts := strings.Split(strings.Trim(strings.ToLower(account), "/"), "/")
+	if len(parts) < 2 || parts[0] != "subscriptions" {
+		return ""
+	}
+	return parts[1]
+}
+
+// logObjectReplicationFindings reports storage accounts replicating outside
+// the collected subscriptions
+func logObjectReplicationFindings(logger *cfg.Logger, findings []interface{}) {
+	logFindings(logger, findings, "🚨 %d object replication policies copy blobs outside the collected subscriptions", "Object replication outside the tenant",
+		"source", "sourceAccount", "destination", "destinationAccount")
+}
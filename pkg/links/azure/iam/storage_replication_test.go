@@ -0,0 +1,81 @@
+package iam
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/praetorian-inc/janus-framework/pkg/chain/cfg"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	replicationSourceID = "/subscriptions/sub-1/resourceGroups/rg/providers/Microsoft.Storage/storageAccounts/finance"
+	replicationBackupID = "/subscriptions/sub-1/resourceGroups/rg/providers/Microsoft.Storage/storageAccounts/backup"
+)
+
+func replicationPolicy(policyID, source, destination string) map[string]interface{} {
+	return map[string]interface{}{
+		"id":   "/policies/" + policyID,
+		"name": policyID,
+		"properties": map[string]interface{}{
+			"policyId":           policyID,
+			"sourceAccount":      source,
+			"destinationAccount": destination,
+			"rules": []interface{}{map[string]interface{}{
+				"ruleId":               "rule-1",
+				"sourceContainer":      "invoices",
+				"destinationContainer": "copy",
+				"filters":              map[string]interface{}{"prefixMatch": []interface{}{"2026/"}},
+			}},
+		},
+	}
+}
+
+func TestObjectReplicationFindings(t *testing.T) {
+	resources := []interface{}{
+		map[string]interface{}{"id": replicationSourceID, "name": "finance", "type": "Microsoft.Storage/storageAccounts", "kind": "StorageV2",
+			"properties": map[string]interface{}{"allowCrossTenantReplication": true}},
+		map[string]interface{}{"id": replicationBackupID, "name": "backup", "type": "microsoft.storage/storageaccounts", "kind": "StorageV2"},
+		map[string]interface{}{"id": "/subscriptions/sub-1/resourceGroups/rg/providers/Microsoft.Storage/storageAccounts/shares", "name": "shares", "type": "microsoft.storage/storageaccounts", "kind": "FileStorage"},
+		map[string]interface{}{"id": "/subscriptions/sub-1/resourceGroups/rg/providers/Microsoft.KeyVault/vaults/kv", "type": "microsoft.keyvault/vaults"},
+	}
+	fetch := func(url string) ([]interface{}, error) {
+		switch url {
+		case objectReplicationPoliciesURL(replicationSourceID):
+			return []interface{}{
+				replicationPolicy("to-backup", replicationSourceID, replicationBackupID),
+				replicationPolicy("to-other-sub", replicationSourceID, "/subscriptions/sub-external/resourceGroups/rg/providers/Microsoft.Storage/storageAccounts/drop"),
+				replicationPolicy("to-other-tenant", "finance", "attackerdrop"),
+			}, nil
+		case objectReplicationPoliciesURL(replicationBackupID):
+			// Listed again on the destination side
+			return []interface{}{replicationPolicy("to-backup", replicationSourceID, replicationBackupID)}, nil
+		}
+		return nil, fmt.Errorf("unexpected request for %s", url)
+	}
+
+	errs := collectionErrorLog{}
+	policies := collectObjectReplicationPolicies(cfg.NewLogger(), &errs, "sub-1", resources, fetch)
+	assert.Empty(t, errs.list(), "FileStorage accounts and other resource types are not queried")
+	require.Len(t, policies, 4)
+	record := policies[0].(map[string]interface{})
+	assert.Equal(t, true, record["allowCrossTenantReplication"])
+	assert.Equal(t, []interface{}{"2026/"}, record["rules"].([]interface{})[0].(map[string]interface{})["prefixMatch"])
+
+	o := &ConsolidatedOutput{AzureResources: map[string]interface{}{
+		"sub-1": map[string]interface{}{"azureResources": resources, "storageObjectReplicationPolicies": policies},
+	}}
+	findings := buildObjectReplicationFindings(o)
+	require.Len(t, findings, 2, "replication between collected accounts is not reported")
+
+	byPolicy := make(map[string]map[string]interface{})
+	for _, finding := range findings {
+		findingMap := finding.(map[string]interface{})
+		byPolicy[findingMap["policyId"].(string)] = findingMap
+	}
+	assert.Equal(t, "sub-external", byPolicy["to-other-sub"]["destinationSubscriptionId"])
+	assert.Equal(t, replicationSourceID, byPolicy["to-other-sub"]["resourceId"])
+	assert.Equal(t, "", byPolicy["to-other-tenant"]["destinationSubscriptionId"], "a destination named only by account is outside unless collected")
+	assert.Len(t, byPolicy["to-other-tenant"]["rules"], 1)
+}
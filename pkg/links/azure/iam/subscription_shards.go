@@ -0,0 +1,175 @@
+package iam
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/praetorian-inc/janus-framework/pkg/chain/cfg"
+	"github.com/praetorian-inc/nebula/internal/message"
+)
+
+// SubscriptionShard is one subscription's azure_resources data written to its
+// own file by --split-subscriptions, listed in the collection metadata
+type SubscriptionShard struct {
+	SubscriptionID string `json:"subscription_id"`
+	File           string `json:"file"`
+	Objects        int    `json:"objects"`
+}
+
+// subscriptionShardFile is the content of a shard file. It carries enough of
+// the collection metadata to be processed on its own.
+type subscriptionShardFile struct {
+	SchemaVersion       string                 `json:"schema_version"`
+	TenantID            string                 `json:"tenant_id"`
+	CollectionTimestamp string                 `json:"collection_timestamp,omitempty"`
+	SubscriptionID      string                 `json:"subscription_id"`
+	AzureResources      map[string]interface{} `json:"azure_resources"`
+}
+
+// subscriptionShardWriter writes the azure_resources data of each subscription
+// to <dir>/subscription-<id>.json. Shards are written as each subscription is
+// collected, so downstream processing can start before the run ends, and
+// written again in their final form when the consolidated output is built.
+type subscriptionShardWriter struct {
+	dir      string
+	tenantID string
+	minify   bool
+	logger   *cfg.Logger
+
+	mu     sync.Mutex
+	shards map[string]SubscriptionShard
+}
+
+// newSubscriptionShardWriter creates dir for --split-subscriptions. It returns
+// nil when dir is empty, and a nil writer ignores every call. Shards are
+// written without indentation when minify is set.
+func newSubscriptionShardWriter(dir, tenantID string, minify bool, logger *cfg.Logger) (*subscriptionShardWriter, error) {
+	if dir == "" {
+		return nil, nil
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create subscription shard directory: %v", err)
+	}
+	return &subscriptionShardWriter{dir: dir, tenantID: tenantID, minify: minify, logger: logger, shards: make(map[string]SubscriptionShard)}, nil
+}
+
+// subscriptionShardPath is the file a subscription's shard is written to
+func subscriptionShardPath(dir, subscriptionID string) string {
+	return filepath.Join(dir, fmt.Sprintf("subscription-%s.json", subscriptionID))
+}
+
+// stream writes a subscription's shard as soon as it is collected. A failure
+// is logged; the shard is retried when the run finishes.
+func (w *subscriptionShardWriter) stream(subscriptionID string, data map[string]interface{}) {
+	if w == nil {
+		return
+	}
+	// Fill a copy so Normalize still sees which sections were never collected
+	shard := make(map[string]interface{}, len(data))
+	for key, value := range data {
+		shard[key] = value
+	}
+	fillSections(shard, subscriptionSections, "", nil)
+	if err := w.write(subscriptionID, shard, ""); err != nil {
+		w.logger.Warn("Failed to write subscription shard, retrying at the end of the run", "subscription", subscriptionID, "error", err)
+	}
+}
+
+// write stores one shard, replacing the file atomically so a reader never
+// sees a partial shard
+func (w *subscriptionShardWriter) write(subscriptionID string, data map[string]interface{}, timestamp string) error {
+	raw, err := marshalDumpFile(subscriptionShardFile{
+		SchemaVersion:       ConsolidatedSchemaVersion,
+		TenantID:            w.tenantID,
+		CollectionTimestamp: timestamp,
+		SubscriptionID:      subscriptionID,
+		AzureResources:      data,
+	}, w.minify)
+	if err != nil {
+		return err
+	}
+
+	path := subscriptionShardPath(w.dir, subscriptionID)
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, raw, 0644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return err
+	}
+
+	w.mu.Lock()
+	w.shards[subscriptionID] = SubscriptionShard{SubscriptionID: subscriptionID, File: path, Objects: countSliceValues(data)}
+	w.mu.Unlock()
+	return nil
+}
+
+// finish writes every subscription of the finished output to its shard,
+// including ones carried forward from a prior dump, lists the shards in the
+// collection metadata and leaves only tenant-wide data in the output. It must
+// run after the findings and summary are computed, which need every
+// subscription.
+func (w *subscriptionShardWriter) finish(o *ConsolidatedOutput) error {
+	if w == nil {
+		return nil
+	}
+	shards := make([]SubscriptionShard, 0, len(o.AzureResources))
+	for subscriptionID, subData := range o.AzureResources {
+		subDataMap, _ := subData.(map[string]interface{})
+		if err := w.write(subscriptionID, subDataMap, o.CollectionMetadata.CollectionTimestamp); err != nil {
+			return fmt.Errorf("failed to write subscription shard for %s: %v", subscriptionID, err)
+		}
+		shards = append(shards, w.shards[subscriptionID])
+	}
+	sort.Slice(shards, func(i, j int) bool { return shards[i].SubscriptionID < shards[j].SubscriptionID })
+
+	o.CollectionMetadata.SubscriptionShards = shards
+	o.AzureResources = map[string]interface{}{}
+	message.Info("Wrote %d subscription shards to %s", len(shards), w.dir)
+	return nil
+}
+
+// loadSubscriptionShards reads back the shards listed in a dump's metadata
+// into azure_resources. Shard paths are tried as written, then relative to
+// the dump's directory, so a dump and its shard directory can be moved
+// together.
+func loadSubscriptionShards(shards []SubscriptionShard, dumpDir string, azureResources map[string]interface{}) error {
+	for _, shard := range shards {
+		if _, ok := azureResources[shard.SubscriptionID]; ok {
+			continue
+		}
+		data, err := readSubscriptionShard(shard.File, dumpDir)
+		if err != nil {
+			return fmt.Errorf("failed to load shard for subscription %s: %v", shard.SubscriptionID, err)
+		}
+		azureResources[shard.SubscriptionID] = data
+	}
+	return nil
+}
+
+// readSubscriptionShard reads the azure_resources data of one shard file
+func readSubscriptionShard(file, dumpDir string) (map[string]interface{}, error) {
+	raw, err := os.ReadFile(file)
+	if err != nil && !filepath.IsAbs(file) {
+		raw, err = os.ReadFile(filepath.Join(dumpDir, file))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var shard subscriptionShardFile
+	if err := json.Unmarshal(raw, &shard); err != nil {
+		return nil, err
+	}
+	if err := checkSchemaVersion(shard.SchemaVersion); err != nil {
+		return nil, err
+	}
+	if shard.AzureResources == nil {
+		shard.AzureResources = make(map[string]interface{})
+	}
+	return shard.AzureResources, nil
+}
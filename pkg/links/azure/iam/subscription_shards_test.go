@@ -0,0 +1,78 @@
+package iam
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/praetorian-inc/janus-framework/pkg/chain/cfg"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSubscriptionShards(t *testing.T) {
+	dir := t.TempDir()
+	shardDir := filepath.Join(dir, "shards")
+	writer, err := newSubscriptionShardWriter(shardDir, "tenant-1", false, cfg.NewLogger())
+	require.NoError(t, err)
+
+	streamed := map[string]interface{}{"virtualMachines": []interface{}{map[string]interface{}{"id": "vm-1"}}}
+	writer.stream("sub-a", streamed)
+	_, err = os.Stat(subscriptionShardPath(shardDir, "sub-a"))
+	require.NoError(t, err, "a shard is written as soon as its subscription is collected")
+
+	o := &ConsolidatedOutput{
+		CollectionMetadata: CollectionMetadata{SchemaVersion: ConsolidatedSchemaVersion, TenantID: "tenant-1", CollectionTimestamp: "2026-10-18T00:00:00Z"},
+		AzureResources: map[string]interface{}{
+			"sub-a": streamed,
+			// carried forward from a prior dump, never streamed
+			"sub-b": map[string]interface{}{"storageAccounts": []interface{}{map[string]interface{}{"id": "sa-1"}, map[string]interface{}{"id": "sa-2"}}},
+		},
+	}
+	o.Normalize()
+	o.Summarize()
+	require.NoError(t, writer.finish(o))
+
+	assert.Empty(t, o.AzureResources, "the main output keeps only tenant-wide data")
+	assert.Equal(t, 3, o.CollectionMetadata.DataSummary.TotalAzureRMObjects, "the summary still counts every subscription")
+	shards := o.CollectionMetadata.SubscriptionShards
+	require.Len(t, shards, 2)
+	assert.Equal(t, "sub-a", shards[0].SubscriptionID)
+	assert.Equal(t, 2, shards[1].Objects)
+
+	// Move the dump and its shard directory together and point the manifest
+	// at relative paths, as a copied support bundle would
+	moved := filepath.Join(dir, "moved")
+	require.NoError(t, os.MkdirAll(moved, 0755))
+	require.NoError(t, os.Rename(shardDir, filepath.Join(moved, "shards")))
+	for i := range o.CollectionMetadata.SubscriptionShards {
+		shard := &o.CollectionMetadata.SubscriptionShards[i]
+		shard.File = filepath.Join("shards", filepath.Base(shard.File))
+	}
+	dumpPath := filepath.Join(moved, "dump.json")
+	raw, err := json.Marshal([]interface{}{o})
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(dumpPath, raw, 0644))
+
+	loaded, err := loadConsolidatedDump(dumpPath)
+	require.NoError(t, err)
+	require.Len(t, loaded.AzureResources, 2, "shards are read back into azure_resources")
+	assert.Len(t, loaded.AzureResources["sub-b"].(map[string]interface{})["storageAccounts"], 2)
+	assert.Empty(t, loaded.CollectionMetadata.SubscriptionShards, "a loaded dump holds every subscription inline")
+
+	require.NoError(t, os.Remove(filepath.Join(moved, "shards", "subscription-sub-b.json")))
+	_, err = loadConsolidatedDump(dumpPath)
+	assert.Error(t, err, "a missing shard fails the load rather than dropping the subscription")
+}
+
+func TestSubscriptionShardWriterDisabled(t *testing.T) {
+	writer, err := newSubscriptionShardWriter("", "tenant-1", false, cfg.NewLogger())
+	require.NoError(t, err)
+	assert.Nil(t, writer)
+
+	o := &ConsolidatedOutput{AzureResources: map[string]interface{}{"sub-a": map[string]interface{}{}}}
+	writer.stream("sub-a", map[string]interface{}{})
+	require.NoError(t, writer.finish(o))
+	assert.Len(t, o.AzureResources, 1, "without --split-subscriptions the output is unchanged")
+}
@@ -0,0 +1,56 @@
+package iam
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/praetorian-inc/nebula/internal/message"
+)
+
+// coreGraphDatasets are the directory objects the findings and the Neo4j
+// import build on. A run that could read none of them has no usable Entra ID
+// data.
+var coreGraphDatasets = []string{"users", "groups", "servicePrincipals", "applications"}
+
+const noSubscriptionsMessage = "no subscriptions visible to this identity — check that it holds Reader or another RBAC role on at least one subscription"
+
+// checkSubscriptionAccess warns when subscription discovery found nothing to
+// collect. Listing subscriptions succeeds with an empty result when the
+// identity holds no RBAC role on any of them, so without this the run ends in
+// an empty success.
+func checkSubscriptionAccess(errs *collectionErrorLog, subscriptionIDs []string) {
+	if len(subscriptionIDs) > 0 {
+		return
+	}
+	message.Warning("No subscriptions visible to this identity — check that it holds Reader or another RBAC role on at least one subscription. Continuing with Entra ID data only.")
+	errs.recordNoAccess("subscription", "tenant", noSubscriptionsMessage)
+}
+
+// checkGraphAccess reports a run where none of the core directory datasets
+// could be read. With no subscription to collect either there is nothing to
+// analyze, so it returns an error; otherwise it warns and the run continues
+// with Azure resource data only.
+func checkGraphAccess(errs *collectionErrorLog, subscriptionIDs []string) error {
+	failed := make(map[string]CollectionError)
+	for _, e := range errs.list() {
+		if e.Scope == "tenant" {
+			failed[e.Dataset] = e
+		}
+	}
+	var reasons []string
+	for _, dataset := range coreGraphDatasets {
+		e, ok := failed[dataset]
+		if !ok {
+			return nil
+		}
+		reasons = append(reasons, fmt.Sprintf("%s: %s", dataset, e.Message))
+	}
+
+	graphMessage := fmt.Sprintf("no Microsoft Graph directory data readable by this identity — grant %s or the Directory Readers role (%s)",
+		datasetPermissions["users"], strings.Join(reasons, "; "))
+	if len(subscriptionIDs) == 0 {
+		return fmt.Errorf("%s, and %s", graphMessage, noSubscriptionsMessage)
+	}
+	message.Warning("%s. Continuing with Azure resource data only.", strings.ToUpper(graphMessage[:1])+graphMessage[1:])
+	return nil
+}
@@ -0,0 +1,43 @@
+package iam
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckSubscriptionAccess(t *testing.T) {
+	var log collectionErrorLog
+	checkSubscriptionAccess(&log, []string{"sub-1"})
+	assert.Empty(t, log.list())
+
+	checkSubscriptionAccess(&log, nil)
+	errs := log.list()
+	require.Len(t, errs, 1)
+	assert.Equal(t, "subscription", errs[0].Dataset)
+	assert.True(t, errs[0].PermissionDenied)
+	assert.Equal(t, "Reader role", errs[0].RequiredPermission)
+	assert.Contains(t, summarizeCollectionErrors(errs), "Missing Reader role caused subscription to be skipped")
+}
+
+func TestCheckGraphAccess(t *testing.T) {
+	forbidden := apiStatusErrorFromBody(http.StatusForbidden, []byte(`{"error":{"code":"Authorization_RequestDenied","message":"Insufficient privileges"}}`))
+
+	var partial collectionErrorLog
+	partial.record("users", "tenant", forbidden)
+	partial.record("groups", "tenant", forbidden)
+	assert.NoError(t, checkGraphAccess(&partial, nil), "some directory data is still worth analyzing")
+
+	var none collectionErrorLog
+	for _, dataset := range coreGraphDatasets {
+		none.record(dataset, "tenant", forbidden)
+	}
+	assert.NoError(t, checkGraphAccess(&none, []string{"sub-1"}), "subscriptions can still be collected")
+
+	err := checkGraphAccess(&none, nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Directory.Read.All")
+	assert.Contains(t, err.Error(), "no subscriptions visible to this identity")
+}
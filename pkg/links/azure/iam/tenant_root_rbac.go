@@ -0,0 +1,115 @@
+package iam
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/praetorian-inc/janus-framework/pkg/chain/cfg"
+)
+
+// buildTenantRootRBACFindings flags every Azure RBAC assignment at the tenant
+// root scope ("/"). Root assignments are inherited by every management group,
+// subscription and resource in the tenant, Azure only allows them to be created
+// through elevated access, and they almost never belong in a healthy tenant, so
+// each one is reported regardless of role.
+func buildTenantRootRBACFindings(o *ConsolidatedOutput) []interface{} {
+	findings := []interface{}{}
+	principals := indexReportPrincipals(o)
+	roleNames := azureRoleDefinitionNames(o)
+
+	seen := make(map[string]bool)
+	add := func(assignment interface{}, bucketedAsTenant bool) {
+		a, ok := assignment.(map[string]interface{})
+		if !ok {
+			return
+		}
+		principalID, roleGUID, scope := rbacAssignmentFields(a)
+		if principalID == "" || (!bucketedAsTenant && scope != "/") {
+			return
+		}
+		assignmentID, _ := a["id"].(string)
+		key := strings.ToLower(assignmentID)
+		if key == "" {
+			key = strings.ToLower(principalID + "|" + roleGUID)
+		}
+		if seen[key] {
+			return
+		}
+		seen[key] = true
+
+		roleName := highPrivilegeRBACRoles[roleGUID]
+		if roleName == "" {
+			roleName = roleNames[roleGUID]
+		}
+		if roleName == "" {
+			roleName = roleGUID
+		}
+
+		finding := reportRow(principals, principalID)
+		if finding["principalType"] == "Unknown" {
+			if principalType, _ := a["principalType"].(string); principalType != "" {
+				finding["principalType"] = principalType
+			}
+		}
+		finding["type"] = "TenantRootRoleAssignment"
+		finding["severity"] = "High"
+		finding["description"] = fmt.Sprintf("%s holds %s at the tenant root scope (/), which applies to every management group and subscription in the tenant",
+			finding["principalName"], roleName)
+		finding["roleDefinitionId"] = roleGUID
+		finding["roleName"] = roleName
+		finding["scope"] = "/"
+		finding["assignmentId"] = assignmentID
+		findings = append(findings, finding)
+	}
+
+	for _, subData := range o.AzureResources {
+		subDataMap, ok := subData.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		tenantAssignments, _ := subDataMap["tenantRoleAssignments"].([]interface{})
+		for _, assignment := range tenantAssignments {
+			add(assignment, true)
+		}
+	}
+	for _, assignment := range o.ManagementGroupRBAC {
+		add(assignment, false)
+	}
+
+	sortFindings(findings, "principalName", "roleName")
+	return findings
+}
+
+// azureRoleDefinitionNames maps lowercased role definition GUIDs to role names
+// from every subscription's azureRoleDefinitions
+func azureRoleDefinitionNames(o *ConsolidatedOutput) map[string]string {
+	names := make(map[string]string)
+	for _, subData := range o.AzureResources {
+		subDataMap, ok := subData.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		definitions, _ := subDataMap["azureRoleDefinitions"].([]interface{})
+		for _, definition := range definitions {
+			d, ok := definition.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			guid, _ := d["name"].(string)
+			roleName, _ := d["roleName"].(string)
+			if properties, ok := d["properties"].(map[string]interface{}); ok && roleName == "" {
+				roleName, _ = properties["roleName"].(string)
+			}
+			if guid != "" && roleName != "" {
+				names[strings.ToLower(guid)] = roleName
+			}
+		}
+	}
+	return names
+}
+
+// logTenantRootRBACFindings reports role assignments at the tenant root scope
+func logTenantRootRBACFindings(logger *cfg.Logger, findings []interface{}) {
+	logFindings(logger, findings, "🚨 %d Azure RBAC assignments at the tenant root scope (/) apply to every subscription in the tenant", "Tenant root role assignment",
+		"principal", "principalName", "role", "roleName", "description", "description")
+}
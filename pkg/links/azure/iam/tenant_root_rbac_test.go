@@ -0,0 +1,51 @@
+package iam
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const tenantRootRBACFixture = `{
+  "azure_ad": {
+    "users": [{"id": "u-alice", "userPrincipalName": "alice@contoso.com"}]
+  },
+  "pim": {},
+  "management_group_rbac": [
+    {"id": "/providers/Microsoft.Authorization/roleAssignments/a1", "scope": "", "properties": {"principalId": "u-alice", "roleDefinitionId": "/providers/Microsoft.Authorization/roleDefinitions/8e3af657-a8ff-443c-a75c-2fe8c4bcb635", "scope": "/"}},
+    {"id": "/providers/Microsoft.Authorization/roleAssignments/a2", "scope": "", "principalType": "ServicePrincipal", "properties": {"principalId": "sp-gone", "roleDefinitionId": "/providers/Microsoft.Authorization/roleDefinitions/acdd72a7-3385-48ef-bd42-f606fba81ae7", "scope": "/"}},
+    {"id": "/providers/Microsoft.Management/managementGroups/mg1/providers/Microsoft.Authorization/roleAssignments/a3", "properties": {"principalId": "u-alice", "roleDefinitionId": "/providers/Microsoft.Authorization/roleDefinitions/8e3af657-a8ff-443c-a75c-2fe8c4bcb635", "scope": "/providers/Microsoft.Management/managementGroups/mg1"}}
+  ],
+  "azure_resources": {
+    "sub-1": {
+      "tenantRoleAssignments": [
+        {"id": "/providers/Microsoft.Authorization/roleAssignments/a1", "principalId": "u-alice", "roleDefinitionId": "/providers/Microsoft.Authorization/roleDefinitions/8e3af657-a8ff-443c-a75c-2fe8c4bcb635", "scope": ""}
+      ],
+      "azureRoleDefinitions": [
+        {"id": "/subscriptions/sub-1/providers/Microsoft.Authorization/roleDefinitions/acdd72a7-3385-48ef-bd42-f606fba81ae7", "name": "acdd72a7-3385-48ef-bd42-f606fba81ae7", "properties": {"roleName": "Reader"}}
+      ]
+    }
+  }
+}`
+
+func TestBuildTenantRootRBACFindings(t *testing.T) {
+	var output ConsolidatedOutput
+	require.NoError(t, json.Unmarshal([]byte(tenantRootRBACFixture), &output))
+
+	findings := buildTenantRootRBACFindings(&output)
+	require.Len(t, findings, 2, "management group assignments are skipped and an assignment seen in both sections is reported once")
+
+	owner := findings[0].(map[string]interface{})
+	assert.Equal(t, "TenantRootRoleAssignment", owner["type"])
+	assert.Equal(t, "alice@contoso.com", owner["principalName"])
+	assert.Equal(t, "Owner", owner["roleName"])
+	assert.Equal(t, "/", owner["scope"])
+	assert.Contains(t, owner["description"], "every management group and subscription")
+
+	reader := findings[1].(map[string]interface{})
+	assert.Equal(t, "sp-gone", reader["principalName"])
+	assert.Equal(t, "ServicePrincipal", reader["principalType"])
+	assert.Equal(t, "Reader", reader["roleName"], "roles outside the high-privilege set are still reported, named from the role definitions")
+}
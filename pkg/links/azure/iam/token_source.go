@@ -0,0 +1,97 @@
+package iam
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/praetorian-inc/nebula/internal/helpers"
+)
+
+// Authentication methods of a comprehensive collection
+const (
+	authRefreshToken    = "refresh-token"
+	authManagedIdentity = "managed-identity"
+)
+
+// azureTokenSource acquires the Graph, PIM and ARM tokens of a collection. It
+// exchanges --refresh-token when one is set and otherwise asks the managed
+// identity of the Azure VM, Function or App Service Nebula runs on, selected
+// by --managed-identity-client-id when it is user-assigned. Tokens are issued
+// by and for the endpoints of cloud.
+type azureTokenSource struct {
+	refreshToken            string
+	tenantID                string
+	proxyURL                string
+	managedIdentityClientID string
+	cloud                   azureCloud
+}
+
+// method names the authentication path the tokens come from
+func (s azureTokenSource) method() string {
+	if s.refreshToken != "" {
+		return authRefreshToken
+	}
+	return authManagedIdentity
+}
+
+func (s azureTokenSource) graphToken() (*helpers.TokenResponse, error) {
+	if s.refreshToken != "" {
+		return helpers.ExchangeRefreshTokenAt(s.cloud.Login, s.refreshToken, helpers.AzureGraphClientID, s.tenantID, s.cloud.Graph+"/.default", s.proxyURL)
+	}
+	return helpers.GetManagedIdentityToken(s.cloud.Graph, s.managedIdentityClientID)
+}
+
+func (s azureTokenSource) pimToken() (*helpers.TokenResponse, error) {
+	if s.refreshToken != "" {
+		return helpers.ExchangeRefreshTokenAt(s.cloud.Login, s.refreshToken, helpers.AzureGraphClientID, s.tenantID, helpers.AzurePIMAudience+"/.default", s.proxyURL)
+	}
+	return helpers.GetManagedIdentityToken(helpers.AzurePIMAudience, s.managedIdentityClientID)
+}
+
+func (s azureTokenSource) armToken() (*helpers.TokenResponse, error) {
+	if s.refreshToken != "" {
+		return helpers.ExchangeRefreshTokenAt(s.cloud.Login, s.refreshToken, helpers.AzurePortalClientID, s.tenantID, s.cloud.ARMResource+"/.default", s.proxyURL)
+	}
+	return helpers.GetManagedIdentityToken(s.cloud.ARMResource, s.managedIdentityClientID)
+}
+
+// resolveTenant checks the parameters of the selected method. A refresh token
+// is exchanged at a tenant's endpoint and needs --tenant; a managed identity
+// belongs to one tenant, which is read from its ARM token when not given.
+func (s *azureTokenSource) resolveTenant() error {
+	if s.tenantID != "" {
+		return nil
+	}
+	if s.refreshToken != "" {
+		return fmt.Errorf("tenant is required with refresh-token")
+	}
+	token, err := s.armToken()
+	if err != nil {
+		return fmt.Errorf("refresh-token is not set and no managed identity token is available: %v", err)
+	}
+	if s.tenantID = tokenTenantID(token.AccessToken); s.tenantID == "" {
+		return fmt.Errorf("managed identity token has no tenant, set --tenant")
+	}
+	return nil
+}
+
+// tokenTenantID reads the tid claim of an access token without verifying it
+func tokenTenantID(accessToken string) string {
+	parts := strings.Split(accessToken, ".")
+	if len(parts) != 3 {
+		return ""
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return ""
+	}
+	var claims struct {
+		TenantID string `json:"tid"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return ""
+	}
+	return claims.TenantID
+}
@@ -0,0 +1,49 @@
+package iam
+
+import (
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAzureTokenSourceManagedIdentity(t *testing.T) {
+	claims := base64.RawURLEncoding.EncodeToString([]byte(`{"tid":"tenant-1","aud":"https://management.core.windows.net/"}`))
+	accessToken := "eyJhbGciOiJub25lIn0." + claims + ".sig"
+
+	var resources []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "secret", r.Header.Get("X-IDENTITY-HEADER"))
+		assert.Equal(t, "mi-client", r.URL.Query().Get("client_id"))
+		resources = append(resources, r.URL.Query().Get("resource"))
+		w.Write([]byte(`{"access_token":"` + accessToken + `","expires_in":"3599","token_type":"Bearer"}`))
+	}))
+	defer server.Close()
+	// App Service and Functions point managed identity requests at IDENTITY_ENDPOINT
+	t.Setenv("IDENTITY_ENDPOINT", server.URL)
+	t.Setenv("IDENTITY_HEADER", "secret")
+
+	tokens := azureTokenSource{managedIdentityClientID: "mi-client", cloud: azureClouds[azureCloudPublic]}
+	assert.Equal(t, authManagedIdentity, tokens.method())
+	require.NoError(t, tokens.resolveTenant())
+	assert.Equal(t, "tenant-1", tokens.tenantID, "the tenant is read from the managed identity token")
+
+	token, err := tokens.graphToken()
+	require.NoError(t, err)
+	assert.Equal(t, accessToken, token.AccessToken)
+	assert.Equal(t, 3599, token.ExpiresIn)
+	_, err = tokens.pimToken()
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"https://management.core.windows.net/",
+		"https://graph.microsoft.com",
+		"01fc33a7-78ba-4d2f-a4b7-768e336e890e",
+	}, resources)
+
+	refresh := azureTokenSource{refreshToken: "rt"}
+	assert.Equal(t, authRefreshToken, refresh.method())
+	assert.Error(t, refresh.resolveTenant(), "a refresh token needs --tenant")
+}
@@ -0,0 +1,44 @@
+package iam
+
+import (
+	"sync"
+
+	"github.com/praetorian-inc/janus-framework/pkg/chain/cfg"
+)
+
+// defaultConcurrency is used when concurrency is unset or not positive
+const defaultConcurrency = 4
+
+// concurrencyArg reads --concurrency, falling back to the default
+func concurrencyArg(arg any) int {
+	concurrency, err := cfg.As[int](arg)
+	if err != nil || concurrency <= 0 {
+		return defaultConcurrency
+	}
+	return concurrency
+}
+
+// runPool calls work once for every item from at most workers goroutines, and
+// never more goroutines than items, returning when every call has finished.
+// work is handed the ID of the worker running it.
+func runPool[T any](items []T, workers int, work func(workerID int, item T)) {
+	workers = max(min(workers, len(items)), 1)
+
+	jobs := make(chan T, len(items))
+	for _, item := range items {
+		jobs <- item
+	}
+	close(jobs)
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(workerID int) {
+			defer wg.Done()
+			for item := range jobs {
+				work(workerID, item)
+			}
+		}(i)
+	}
+	wg.Wait()
+}
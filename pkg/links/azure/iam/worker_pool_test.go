@@ -0,0 +1,63 @@
+package iam
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunPool(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		items   int
+		workers int
+		want    int
+	}{
+		{name: "configured worker count", items: 20, workers: 4, want: 4},
+		{name: "single worker", items: 5, workers: 1, want: 1},
+		{name: "fewer items than workers", items: 2, workers: 8, want: 2},
+		{name: "non-positive count runs one worker", items: 3, workers: 0, want: 1},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			items := make([]int, tc.items)
+			for i := range items {
+				items[i] = i
+			}
+
+			var running, peak atomic.Int32
+			var mu sync.Mutex
+			done := make(map[int]bool)
+			workerIDs := make(map[int]bool)
+			runPool(items, tc.workers, func(workerID int, item int) {
+				now := running.Add(1)
+				for {
+					seen := peak.Load()
+					if now <= seen || peak.CompareAndSwap(seen, now) {
+						break
+					}
+				}
+				// Hold the worker long enough for the others to start
+				time.Sleep(10 * time.Millisecond)
+				running.Add(-1)
+
+				mu.Lock()
+				defer mu.Unlock()
+				done[item] = true
+				workerIDs[workerID] = true
+			})
+
+			assert.Len(t, done, tc.items, "every item is processed once")
+			assert.Equal(t, int32(tc.want), peak.Load(), "peak concurrency")
+			assert.Len(t, workerIDs, tc.want)
+		})
+	}
+}
+
+func TestConcurrencyArg(t *testing.T) {
+	assert.Equal(t, 8, concurrencyArg(8))
+	assert.Equal(t, defaultConcurrency, concurrencyArg(0))
+	assert.Equal(t, defaultConcurrency, concurrencyArg(nil))
+}
@@ -0,0 +1,449 @@
+package iam
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/praetorian-inc/janus-framework/pkg/chain"
+	"github.com/praetorian-inc/janus-framework/pkg/chain/cfg"
+	"github.com/praetorian-inc/nebula/internal/message"
+	"github.com/praetorian-inc/nebula/pkg/links/gcp/base"
+	"github.com/praetorian-inc/nebula/pkg/links/options"
+	"google.golang.org/api/cloudresourcemanager/v1"
+	cloudresourcemanagerv2 "google.golang.org/api/cloudresourcemanager/v2"
+	"google.golang.org/api/iam/v1"
+	"google.golang.org/api/option"
+)
+
+// FILE INFO:
+// GcpIamCollectorLink - Collect the IAM bindings of projects, their folders and
+// organization, and the service accounts and keys of each project into one
+// consolidated document, like the Azure iam-pull collector
+
+// ConsolidatedSchemaVersion is the version of the GCP consolidated output.
+// Bump the minor version for additive changes and the major version when
+// existing keys are renamed, removed, or change shape.
+const ConsolidatedSchemaVersion = "1.0"
+
+// Credential sources recorded in the collection metadata
+const (
+	credentialSourceFile               = "creds-file"
+	credentialSourceApplicationDefault = "application-default"
+)
+
+// ConsolidatedOutput is the document emitted by the GCP IAM collector. The
+// hierarchy section holds the organizations and folders above the collected
+// projects and their bindings; the projects section is keyed by project ID.
+// Records are flat maps, as in the Azure consolidated output.
+type ConsolidatedOutput struct {
+	CollectionMetadata CollectionMetadata     `json:"collection_metadata"`
+	Hierarchy          map[string]interface{} `json:"hierarchy"`
+	Projects           map[string]interface{} `json:"projects"`
+	CollectionErrors   []CollectionError      `json:"collection_errors"`
+}
+
+// CollectionMetadata describes how and when a GCP consolidated output was produced
+type CollectionMetadata struct {
+	SchemaVersion          string         `json:"schema_version"`
+	CollectionTimestamp    string         `json:"collection_timestamp"`
+	CredentialSource       string         `json:"credential_source"`
+	ProjectsProcessed      int            `json:"projects_processed"`
+	FoldersProcessed       int            `json:"folders_processed"`
+	OrganizationsProcessed int            `json:"organizations_processed"`
+	DataSummary            map[string]int `json:"data_summary"`
+}
+
+// CollectionError records a dataset that could not be collected for a scope,
+// such as a project whose service accounts the credentials cannot list
+type CollectionError struct {
+	Dataset string `json:"dataset"`
+	Scope   string `json:"scope"`
+	Message string `json:"message"`
+}
+
+type GcpIamCollectorLink struct {
+	*base.GcpBaseLink
+	resourceManagerService *cloudresourcemanager.Service
+	foldersService         *cloudresourcemanagerv2.Service
+	iamService             *iam.Service
+	collectionErrors       []CollectionError
+}
+
+// creates a link to collect GCP IAM bindings, service accounts and keys
+func NewGcpIamCollectorLink(configs ...cfg.Config) chain.Link {
+	g := &GcpIamCollectorLink{}
+	g.GcpBaseLink = base.NewGcpBaseLink(g, configs...)
+	return g
+}
+
+func (g *GcpIamCollectorLink) Params() []cfg.Param {
+	return append(g.GcpBaseLink.Params(), options.GcpProjects())
+}
+
+func (g *GcpIamCollectorLink) Initialize() error {
+	if err := g.GcpBaseLink.Initialize(); err != nil {
+		return err
+	}
+	return g.initializeServices(context.Background(), g.ClientOptions...)
+}
+
+func (g *GcpIamCollectorLink) initializeServices(ctx context.Context, opts ...option.ClientOption) error {
+	var err error
+	g.resourceManagerService, err = cloudresourcemanager.NewService(ctx, opts...)
+	if err != nil {
+		return fmt.Errorf("failed to create resource manager service: %w", err)
+	}
+	g.foldersService, err = cloudresourcemanagerv2.NewService(ctx, opts...)
+	if err != nil {
+		return fmt.Errorf("failed to create folders service: %w", err)
+	}
+	g.iamService, err = iam.NewService(ctx, opts...)
+	if err != nil {
+		return fmt.Errorf("failed to create IAM service: %w", err)
+	}
+	return nil
+}
+
+func (g *GcpIamCollectorLink) Process(input interface{}) error {
+	projectIDs, _ := cfg.As[[]string](g.Arg("projects"))
+	output, err := g.collect(context.Background(), projectIDs)
+	if err != nil {
+		return err
+	}
+	if g.CredentialsFile != "" {
+		output.CollectionMetadata.CredentialSource = credentialSourceFile
+	}
+
+	summary := output.CollectionMetadata.DataSummary
+	message.Info("=== GCP IAM Collection Summary ====")
+	message.Info("Projects: %d, folders: %d, organizations: %d", output.CollectionMetadata.ProjectsProcessed,
+		output.CollectionMetadata.FoldersProcessed, output.CollectionMetadata.OrganizationsProcessed)
+	message.Info("Total IAM bindings: %d", summary["iamBindings"])
+	message.Info("Total service accounts: %d", summary["serviceAccounts"])
+	message.Info("Total user-managed service account keys: %d", summary["userManagedServiceAccountKeys"])
+	if len(output.CollectionErrors) > 0 {
+		message.Warning("%d datasets could not be collected, see collection_errors", len(output.CollectionErrors))
+	}
+	message.Info("🎉 GCP IAM collection completed successfully!")
+
+	g.Send(output)
+	return nil
+}
+
+// collect gathers the projects selected by projectIDs, or every active project
+// for "all", then the folders and organizations above them
+func (g *GcpIamCollectorLink) collect(ctx context.Context, projectIDs []string) (*ConsolidatedOutput, error) {
+	g.collectionErrors = nil
+	projects, err := g.resolveProjects(ctx, projectIDs)
+	if err != nil {
+		return nil, err
+	}
+	message.Info("Collecting IAM data for %d projects...", len(projects))
+
+	projectsData := make(map[string]interface{}, len(projects))
+	var folderIDs, organizationIDs []string
+	seen := make(map[string]bool)
+	for _, project := range projects {
+		projectData, ancestry := g.collectProject(ctx, project)
+		projectsData[project.ProjectId] = projectData
+		for _, ancestor := range ancestry {
+			if seen[ancestor] {
+				continue
+			}
+			seen[ancestor] = true
+			if strings.HasPrefix(ancestor, "folders/") {
+				folderIDs = append(folderIDs, ancestor)
+			} else if strings.HasPrefix(ancestor, "organizations/") {
+				organizationIDs = append(organizationIDs, ancestor)
+			}
+		}
+	}
+	sort.Strings(folderIDs)
+	sort.Strings(organizationIDs)
+
+	hierarchy := map[string]interface{}{
+		"organizations":           []interface{}{},
+		"organizationIamBindings": []interface{}{},
+		"folders":                 []interface{}{},
+		"folderIamBindings":       []interface{}{},
+	}
+	for _, name := range organizationIDs {
+		g.collectOrganization(ctx, name, hierarchy)
+	}
+	for _, name := range folderIDs {
+		g.collectFolder(ctx, name, hierarchy)
+	}
+
+	output := &ConsolidatedOutput{
+		CollectionMetadata: CollectionMetadata{
+			SchemaVersion:          ConsolidatedSchemaVersion,
+			CollectionTimestamp:    time.Now().UTC().Format("2006-01-02T15:04:05Z"),
+			CredentialSource:       credentialSourceApplicationDefault,
+			ProjectsProcessed:      len(projects),
+			FoldersProcessed:       len(folderIDs),
+			OrganizationsProcessed: len(organizationIDs),
+		},
+		Hierarchy:        hierarchy,
+		Projects:         projectsData,
+		CollectionErrors: g.collectionErrors,
+	}
+	if output.CollectionErrors == nil {
+		output.CollectionErrors = []CollectionError{}
+	}
+	output.CollectionMetadata.DataSummary = output.summarize()
+	return output, nil
+}
+
+// resolveProjects looks up the listed projects, or lists every active
+// project the credentials can see when projectIDs is empty or "all"
+func (g *GcpIamCollectorLink) resolveProjects(ctx context.Context, projectIDs []string) ([]*cloudresourcemanager.Project, error) {
+	if len(projectIDs) == 0 || (len(projectIDs) == 1 && strings.EqualFold(projectIDs[0], "all")) {
+		var projects []*cloudresourcemanager.Project
+		err := g.resourceManagerService.Projects.List().Filter("lifecycleState:ACTIVE").Pages(ctx, func(resp *cloudresourcemanager.ListProjectsResponse) error {
+			projects = append(projects, resp.Projects...)
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list projects: %w", err)
+		}
+		if len(projects) == 0 {
+			return nil, fmt.Errorf("no active projects are visible to the credentials, set --projects")
+		}
+		return projects, nil
+	}
+
+	projects := make([]*cloudresourcemanager.Project, 0, len(projectIDs))
+	for _, projectID := range projectIDs {
+		project, err := g.resourceManagerService.Projects.Get(projectID).Context(ctx).Do()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get project %s: %w", projectID, err)
+		}
+		projects = append(projects, project)
+	}
+	return projects, nil
+}
+
+// collectProject collects the bindings, service accounts and keys of one
+// project. It returns the project's data and its ancestry as resource names,
+// nearest first, e.g. folders/123 then organizations/456.
+func (g *GcpIamCollectorLink) collectProject(ctx context.Context, project *cloudresourcemanager.Project) (map[string]interface{}, []string) {
+	projectID := project.ProjectId
+	resource := "projects/" + projectID
+	data := map[string]interface{}{
+		"project": map[string]interface{}{
+			"projectId":      projectID,
+			"projectNumber":  project.ProjectNumber,
+			"name":           project.Name,
+			"lifecycleState": project.LifecycleState,
+			"labels":         project.Labels,
+		},
+		"ancestry":           []interface{}{},
+		"projectIamBindings": []interface{}{},
+		"serviceAccounts":    []interface{}{},
+		"serviceAccountKeys": []interface{}{},
+	}
+
+	var ancestry []string
+	ancestryResp, err := g.resourceManagerService.Projects.GetAncestry(projectID, &cloudresourcemanager.GetAncestryRequest{}).Context(ctx).Do()
+	if err != nil {
+		g.recordError("ancestry", resource, err)
+	} else {
+		names := []interface{}{}
+		for _, ancestor := range ancestryResp.Ancestor {
+			if ancestor.ResourceId == nil || ancestor.ResourceId.Type == "project" {
+				continue
+			}
+			name := ancestor.ResourceId.Type + "s/" + ancestor.ResourceId.Id
+			ancestry = append(ancestry, name)
+			names = append(names, name)
+		}
+		data["ancestry"] = names
+	}
+
+	policy, err := g.resourceManagerService.Projects.GetIamPolicy(projectID, &cloudresourcemanager.GetIamPolicyRequest{
+		Options: &cloudresourcemanager.GetPolicyOptions{RequestedPolicyVersion: 3},
+	}).Context(ctx).Do()
+	if err != nil {
+		g.recordError("projectIamBindings", resource, err)
+	} else {
+		data["projectIamBindings"] = flattenBindings(resource, policy.Bindings)
+	}
+
+	serviceAccounts := []interface{}{}
+	keys := []interface{}{}
+	err = g.iamService.Projects.ServiceAccounts.List(resource).Pages(ctx, func(resp *iam.ListServiceAccountsResponse) error {
+		for _, sa := range resp.Accounts {
+			serviceAccounts = append(serviceAccounts, map[string]interface{}{
+				"name":        sa.Name,
+				"email":       sa.Email,
+				"uniqueId":    sa.UniqueId,
+				"displayName": sa.DisplayName,
+				"description": sa.Description,
+				"disabled":    sa.Disabled,
+				"projectId":   projectID,
+			})
+			keys = append(keys, g.collectServiceAccountKeys(ctx, sa)...)
+		}
+		return nil
+	})
+	if err != nil {
+		g.recordError("serviceAccounts", resource, err)
+	}
+	data["serviceAccounts"] = serviceAccounts
+	data["serviceAccountKeys"] = keys
+	return data, ancestry
+}
+
+// collectServiceAccountKeys lists the user-managed and system-managed keys
+// of a service account. The key material is never returned by the list call.
+func (g *GcpIamCollectorLink) collectServiceAccountKeys(ctx context.Context, sa *iam.ServiceAccount) []interface{} {
+	resp, err := g.iamService.Projects.ServiceAccounts.Keys.List(sa.Name).Context(ctx).Do()
+	if err != nil {
+		g.recordError("serviceAccountKeys", sa.Name, err)
+		return nil
+	}
+	keys := make([]interface{}, 0, len(resp.Keys))
+	for _, key := range resp.Keys {
+		keys = append(keys, map[string]interface{}{
+			"name":                key.Name,
+			"keyId":               key.Name[strings.LastIndex(key.Name, "/")+1:],
+			"serviceAccountEmail": sa.Email,
+			"keyType":             key.KeyType,
+			"keyAlgorithm":        key.KeyAlgorithm,
+			"keyOrigin":           key.KeyOrigin,
+			"validAfterTime":      key.ValidAfterTime,
+			"validBeforeTime":     key.ValidBeforeTime,
+			"disabled":            key.Disabled,
+		})
+	}
+	return keys
+}
+
+// collectOrganization adds an organization and its bindings to the hierarchy
+func (g *GcpIamCollectorLink) collectOrganization(ctx context.Context, name string, hierarchy map[string]interface{}) {
+	record := map[string]interface{}{"name": name}
+	if org, err := g.resourceManagerService.Organizations.Get(name).Context(ctx).Do(); err != nil {
+		g.recordError("organizations", name, err)
+	} else {
+		record["displayName"] = org.DisplayName
+		record["lifecycleState"] = org.LifecycleState
+	}
+	hierarchy["organizations"] = append(hierarchy["organizations"].([]interface{}), record)
+
+	policy, err := g.resourceManagerService.Organizations.GetIamPolicy(name, &cloudresourcemanager.GetIamPolicyRequest{
+		Options: &cloudresourcemanager.GetPolicyOptions{RequestedPolicyVersion: 3},
+	}).Context(ctx).Do()
+	if err != nil {
+		g.recordError("organizationIamBindings", name, err)
+		return
+	}
+	hierarchy["organizationIamBindings"] = append(hierarchy["organizationIamBindings"].([]interface{}), flattenBindings(name, policy.Bindings)...)
+}
+
+// collectFolder adds a folder and its bindings to the hierarchy
+func (g *GcpIamCollectorLink) collectFolder(ctx context.Context, name string, hierarchy map[string]interface{}) {
+	record := map[string]interface{}{"name": name}
+	if folder, err := g.foldersService.Folders.Get(name).Context(ctx).Do(); err != nil {
+		g.recordError("folders", name, err)
+	} else {
+		record["displayName"] = folder.DisplayName
+		record["parent"] = folder.Parent
+		record["lifecycleState"] = folder.LifecycleState
+	}
+	hierarchy["folders"] = append(hierarchy["folders"].([]interface{}), record)
+
+	policy, err := g.foldersService.Folders.GetIamPolicy(name, &cloudresourcemanagerv2.GetIamPolicyRequest{
+		Options: &cloudresourcemanagerv2.GetPolicyOptions{RequestedPolicyVersion: 3},
+	}).Context(ctx).Do()
+	if err != nil {
+		g.recordError("folderIamBindings", name, err)
+		return
+	}
+	bindings := make([]*cloudresourcemanager.Binding, 0, len(policy.Bindings))
+	for _, binding := range policy.Bindings {
+		converted := &cloudresourcemanager.Binding{Role: binding.Role, Members: binding.Members}
+		if binding.Condition != nil {
+			converted.Condition = &cloudresourcemanager.Expr{Title: binding.Condition.Title, Expression: binding.Condition.Expression}
+		}
+		bindings = append(bindings, converted)
+	}
+	hierarchy["folderIamBindings"] = append(hierarchy["folderIamBindings"].([]interface{}), flattenBindings(name, bindings)...)
+}
+
+func (g *GcpIamCollectorLink) recordError(dataset, scope string, err error) {
+	g.Logger.Warn("Failed to collect GCP IAM data, continuing without it", "dataset", dataset, "scope", scope, "error", err)
+	g.collectionErrors = append(g.collectionErrors, CollectionError{Dataset: dataset, Scope: scope, Message: err.Error()})
+}
+
+// flattenBindings returns one record per member of each binding of a policy
+// on resource, with the member type split from the member, e.g. user for
+// user:alice@example.com. Conditional bindings keep their condition.
+func flattenBindings(resource string, bindings []*cloudresourcemanager.Binding) []interface{} {
+	records := []interface{}{}
+	for _, binding := range bindings {
+		for _, member := range binding.Members {
+			record := map[string]interface{}{
+				"resource":   resource,
+				"role":       binding.Role,
+				"member":     member,
+				"memberType": bindingMemberType(member),
+			}
+			if binding.Condition != nil {
+				record["condition"] = map[string]interface{}{
+					"title":      binding.Condition.Title,
+					"expression": binding.Condition.Expression,
+				}
+			}
+			records = append(records, record)
+		}
+	}
+	return records
+}
+
+// bindingMemberType returns the type of a binding member: user,
+// serviceAccount, group, domain, deleted, principal, principalSet, or the
+// member itself for allUsers and allAuthenticatedUsers
+func bindingMemberType(member string) string {
+	if memberType, _, ok := strings.Cut(member, ":"); ok {
+		return memberType
+	}
+	return member
+}
+
+// summarize counts the collected records for data_summary
+func (o *ConsolidatedOutput) summarize() map[string]int {
+	summary := map[string]int{
+		"iamBindings":                   0,
+		"serviceAccounts":               0,
+		"serviceAccountKeys":            0,
+		"userManagedServiceAccountKeys": 0,
+	}
+	for _, key := range []string{"organizationIamBindings", "folderIamBindings"} {
+		if bindings, ok := o.Hierarchy[key].([]interface{}); ok {
+			summary["iamBindings"] += len(bindings)
+		}
+	}
+	for _, projectData := range o.Projects {
+		data, ok := projectData.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if bindings, ok := data["projectIamBindings"].([]interface{}); ok {
+			summary["iamBindings"] += len(bindings)
+		}
+		if serviceAccounts, ok := data["serviceAccounts"].([]interface{}); ok {
+			summary["serviceAccounts"] += len(serviceAccounts)
+		}
+		keys, _ := data["serviceAccountKeys"].([]interface{})
+		summary["serviceAccountKeys"] += len(keys)
+		for _, key := range keys {
+			if keyMap, ok := key.(map[string]interface{}); ok && keyMap["keyType"] == "USER_MANAGED" {
+				summary["userManagedServiceAccountKeys"]++
+			}
+		}
+	}
+	return summary
+}
@@ -0,0 +1,96 @@
+package iam
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/api/option"
+)
+
+func TestGcpIamCollectorCollect(t *testing.T) {
+	responses := map[string]interface{}{
+		"GET /v1/projects": map[string]interface{}{"projects": []interface{}{
+			map[string]interface{}{"projectId": "prod", "projectNumber": "111", "name": "Production", "lifecycleState": "ACTIVE"},
+		}},
+		"POST /v1/projects/prod:getAncestry": map[string]interface{}{"ancestor": []interface{}{
+			map[string]interface{}{"resourceId": map[string]interface{}{"type": "project", "id": "prod"}},
+			map[string]interface{}{"resourceId": map[string]interface{}{"type": "folder", "id": "22"}},
+			map[string]interface{}{"resourceId": map[string]interface{}{"type": "organization", "id": "33"}},
+		}},
+		"POST /v1/projects/prod:getIamPolicy": map[string]interface{}{"bindings": []interface{}{
+			map[string]interface{}{"role": "roles/owner", "members": []interface{}{"user:alice@example.com", "serviceAccount:ci@prod.iam.gserviceaccount.com"}},
+			map[string]interface{}{"role": "roles/viewer", "members": []interface{}{"allUsers"},
+				"condition": map[string]interface{}{"title": "temporary", "expression": "request.time < timestamp('2030-01-01T00:00:00Z')"}},
+		}},
+		"GET /v1/projects/prod/serviceAccounts": map[string]interface{}{"accounts": []interface{}{
+			map[string]interface{}{"name": "projects/prod/serviceAccounts/ci@prod.iam.gserviceaccount.com", "email": "ci@prod.iam.gserviceaccount.com", "uniqueId": "444"},
+		}},
+		"GET /v1/projects/prod/serviceAccounts/ci@prod.iam.gserviceaccount.com/keys": map[string]interface{}{"keys": []interface{}{
+			map[string]interface{}{"name": "projects/prod/serviceAccounts/ci@prod.iam.gserviceaccount.com/keys/k1", "keyType": "USER_MANAGED"},
+			map[string]interface{}{"name": "projects/prod/serviceAccounts/ci@prod.iam.gserviceaccount.com/keys/k2", "keyType": "SYSTEM_MANAGED"},
+		}},
+		"GET /v2/folders/22": map[string]interface{}{"name": "folders/22", "displayName": "Engineering", "parent": "organizations/33"},
+		"POST /v2/folders/22:getIamPolicy": map[string]interface{}{"bindings": []interface{}{
+			map[string]interface{}{"role": "roles/resourcemanager.folderAdmin", "members": []interface{}{"group:eng-admins@example.com"}},
+		}},
+		"GET /v1/organizations/33": map[string]interface{}{"name": "organizations/33", "displayName": "example.com"},
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		response, ok := responses[r.Method+" "+r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"error": {"code": 403, "message": "permission denied"}}`))
+			return
+		}
+		json.NewEncoder(w).Encode(response)
+	}))
+	defer server.Close()
+
+	link := NewGcpIamCollectorLink().(*GcpIamCollectorLink)
+	require.NoError(t, link.initializeServices(context.Background(), option.WithEndpoint(server.URL+"/"), option.WithoutAuthentication()))
+	output, err := link.collect(context.Background(), []string{"all"})
+	require.NoError(t, err)
+
+	metadata := output.CollectionMetadata
+	assert.Equal(t, ConsolidatedSchemaVersion, metadata.SchemaVersion)
+	assert.Equal(t, 1, metadata.ProjectsProcessed)
+	assert.Equal(t, 1, metadata.FoldersProcessed)
+	assert.Equal(t, 1, metadata.OrganizationsProcessed)
+	assert.Equal(t, map[string]int{"iamBindings": 4, "serviceAccounts": 1, "serviceAccountKeys": 2, "userManagedServiceAccountKeys": 1}, metadata.DataSummary)
+
+	prod := output.Projects["prod"].(map[string]interface{})
+	assert.Equal(t, []interface{}{"folders/22", "organizations/33"}, prod["ancestry"])
+	bindings := prod["projectIamBindings"].([]interface{})
+	require.Len(t, bindings, 3, "one record per member")
+	assert.Equal(t, map[string]interface{}{"resource": "projects/prod", "role": "roles/owner", "member": "user:alice@example.com", "memberType": "user"}, bindings[0])
+	assert.Equal(t, "serviceAccount", bindings[1].(map[string]interface{})["memberType"])
+	public := bindings[2].(map[string]interface{})
+	assert.Equal(t, "allUsers", public["memberType"])
+	assert.Equal(t, "temporary", public["condition"].(map[string]interface{})["title"])
+
+	keys := prod["serviceAccountKeys"].([]interface{})
+	require.Len(t, keys, 2)
+	assert.Equal(t, "k1", keys[0].(map[string]interface{})["keyId"])
+	assert.Equal(t, "ci@prod.iam.gserviceaccount.com", keys[0].(map[string]interface{})["serviceAccountEmail"])
+
+	folders := output.Hierarchy["folders"].([]interface{})
+	require.Len(t, folders, 1)
+	assert.Equal(t, "Engineering", folders[0].(map[string]interface{})["displayName"])
+	assert.Len(t, output.Hierarchy["folderIamBindings"], 1)
+	assert.Empty(t, output.Hierarchy["organizationIamBindings"])
+
+	require.Len(t, output.CollectionErrors, 1, "a policy the credentials cannot read is recorded and skipped")
+	assert.Equal(t, CollectionError{Dataset: "organizationIamBindings", Scope: "organizations/33", Message: output.CollectionErrors[0].Message}, output.CollectionErrors[0])
+}
+
+func TestBindingMemberType(t *testing.T) {
+	assert.Equal(t, "user", bindingMemberType("user:alice@example.com"))
+	assert.Equal(t, "deleted", bindingMemberType("deleted:serviceAccount:old@p.iam.gserviceaccount.com?uid=1"))
+	assert.Equal(t, "principalSet", bindingMemberType("principalSet://iam.googleapis.com/locations/global/workforcePools/p/*"))
+	assert.Equal(t, "allAuthenticatedUsers", bindingMemberType("allAuthenticatedUsers"))
+}
@@ -0,0 +1,99 @@
+package multi
+
+import (
+	"fmt"
+
+	"github.com/praetorian-inc/janus-framework/pkg/chain"
+	"github.com/praetorian-inc/janus-framework/pkg/chain/cfg"
+	"github.com/praetorian-inc/nebula/internal/message"
+	"github.com/praetorian-inc/nebula/pkg/links/aws"
+	"github.com/praetorian-inc/nebula/pkg/links/azure/iam"
+	"github.com/praetorian-inc/nebula/pkg/links/options"
+	"github.com/praetorian-inc/nebula/pkg/outputters"
+	"github.com/praetorian-inc/nebula/pkg/rules"
+)
+
+// findingsReportTopItems is how many findings of each band are printed
+const findingsReportTopItems = 5
+
+// FindingsReportLink runs the AWS analysis over a GAAD and the Azure
+// detections over a consolidated dump, and merges both into one report
+type FindingsReportLink struct {
+	*chain.Base
+}
+
+func NewFindingsReportLink(configs ...cfg.Config) chain.Link {
+	l := &FindingsReportLink{}
+	l.Base = chain.NewBase(l, configs...)
+	return l
+}
+
+func (l *FindingsReportLink) Params() []cfg.Param {
+	return []cfg.Param{
+		options.AwsGaadFile(),
+		options.AwsOrgPoliciesFile(),
+		options.AwsResourcePoliciesFile(),
+		options.AwsResourcesFile(),
+		options.AwsResourceFormat(),
+		options.AwsIdentityCenterFile(),
+		options.AwsAdminActions(),
+		options.AwsAdminActionThreshold(),
+		options.AwsMaxHops(),
+		options.AwsAnalyzerWorkers(),
+		options.AzureFindingsDump(),
+		options.AzureRules(),
+	}
+}
+
+func (l *FindingsReportLink) Process(input interface{}) error {
+	gaadFile, _ := cfg.As[string](l.Arg("gaad-file"))
+	azureDump, _ := cfg.As[string](l.Arg("azure-dump"))
+	if gaadFile == "" && azureDump == "" {
+		return fmt.Errorf("at least one of gaad-file or azure-dump is required")
+	}
+
+	byProvider := make(map[string][]rules.Finding)
+	if gaadFile != "" {
+		pd, err := aws.LoadOfflinePolicyData(l.Arg)
+		if err != nil {
+			return err
+		}
+		summary, err := aws.AnalyzeOfflinePolicyData(l.Arg, pd)
+		if err != nil {
+			return err
+		}
+		byProvider["aws"] = summary.Findings()
+		message.Info("AWS analysis of %s produced %d findings", gaadFile, len(byProvider["aws"]))
+	}
+	if azureDump != "" {
+		ruleSelectors, _ := cfg.As[[]string](l.Arg("rules"))
+		tenantID, findings, err := iam.AnalyzeDumpFindings(azureDump, ruleSelectors)
+		if err != nil {
+			return err
+		}
+		byProvider["azure"] = findings
+		message.Info("Azure detections over tenant %s produced %d findings", tenantID, len(findings))
+	}
+
+	report := rules.Combine(byProvider)
+	logFindingsReport(report)
+	l.Send(outputters.NewNamedOutputData(report, "findings-report"))
+	return nil
+}
+
+// logFindingsReport prints the count of each severity band and its first findings
+func logFindingsReport(report *rules.CombinedReport) {
+	message.Section("Combined findings: %d (%d duplicates removed)", report.TotalFindings, report.DuplicatesRemoved)
+	for _, band := range report.Bands {
+		if band.Count == 0 {
+			continue
+		}
+		message.Info("%s: %d", band.Severity, band.Count)
+		for _, finding := range band.Findings[:min(len(band.Findings), findingsReportTopItems)] {
+			message.Info("  [%s] %s: %s", finding["provider"], finding["type"], finding["description"])
+		}
+		if band.Count > findingsReportTopItems {
+			message.Info("  ... and %d more", band.Count-findingsReportTopItems)
+		}
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"strings"
 
 	"github.com/praetorian-inc/janus-framework/pkg/chain/cfg"
+	"github.com/praetorian-inc/nebula/pkg/graph"
 	"github.com/praetorian-inc/nebula/pkg/types"
 )
 
@@ -250,20 +251,80 @@ func AwsCacheErrorTypes() cfg.Param {
 }
 
 func AwsOrgPoliciesFile() cfg.Param {
-	return cfg.NewParam[string]("org-policies", "Path to AWS organization policies JSON file from get-org-policies module").
+	return cfg.NewParam[string]("org-policies", "Path to AWS organization policies JSON file from get-org-policies module, or - for stdin").
 		WithShortcode("o")
 }
 
+func AwsOrgID() cfg.Param {
+	return cfg.NewParam[string]("org-id", "AWS Organizations ID (o-xxxxxxxxxx) of the scanned account; grants restricted to it with aws:PrincipalOrgID or aws:PrincipalOrgPaths are treated as internal")
+}
+
 func AwsGaadFile() cfg.Param {
-	return cfg.NewParam[string]("gaad-file", "Path to AWS GAAD (GetAccountAuthorizationDetails) JSON file from account-auth-details module").
+	return cfg.NewParam[string]("gaad-file", "Path to AWS GAAD (GetAccountAuthorizationDetails) JSON file from account-auth-details module, or - for stdin").
 		WithShortcode("g")
 }
 
+func AwsIdentityCenterFile() cfg.Param {
+	return cfg.NewParam[string]("identity-center-file", "Path to an IAM Identity Center export JSON file with permission sets, account assignments, users, groups and group memberships, or - for stdin")
+}
+
+func AwsLastAccessedFile() cfg.Param {
+	return cfg.NewParam[string]("last-accessed", "Path to IAM service last accessed data JSON file, a map of principal ARN to get-service-last-accessed-details output, or - for stdin; enables the unused permissions report")
+}
+
+func AwsNoCompressActions() cfg.Param {
+	return cfg.NewParam[bool]("no-compress-actions", "List every allowed action in the analysis output instead of collapsing them to service and verb wildcards").
+		WithDefault(false)
+}
+
+func AwsAdminActions() cfg.Param {
+	return cfg.NewParam[[]string]("admin-actions", "IAM actions that make a principal admin-equivalent when it is allowed them on itself, its groups, or its attached customer managed policies").
+		WithDefault([]string{
+			"iam:AttachUserPolicy",
+			"iam:PutUserPolicy",
+			"iam:AttachGroupPolicy",
+			"iam:PutGroupPolicy",
+			"iam:AttachRolePolicy",
+			"iam:PutRolePolicy",
+			"iam:CreatePolicyVersion",
+		})
+}
+
+func AwsAdminActionThreshold() cfg.Param {
+	return cfg.NewParam[int]("admin-action-threshold", "Number of --admin-actions a principal must be allowed on itself to be reported as an effective admin").
+		WithDefault(1)
+}
+
+func AwsBlastRadiusStart() cfg.Param {
+	return cfg.NewParam[string]("start", "ARN of the compromised user, role or assumed-role session to compute the blast radius from").
+		AsRequired()
+}
+
+func AwsMaxHops() cfg.Param {
+	return cfg.NewParam[int]("max-hops", "Maximum assume-role or escalation hops followed from a principal; output notes when a walk was cut short (0 for no limit)").
+		WithDefault(graph.DefaultMaxHops)
+}
+
+func AwsAnalyzerWorkers() cfg.Param {
+	return cfg.NewParam[int]("analyzer-workers", "Number of workers evaluating principal permissions in parallel (0 uses three per CPU core)").
+		WithDefault(0)
+}
+
 func AwsResourcePoliciesFile() cfg.Param {
-	return cfg.NewParam[string]("resource-policies-file", "Path to AWS resource policies JSON file from resource-policies module").
+	return cfg.NewParam[string]("resource-policies-file", "Path to AWS resource policies JSON file from resource-policies module, or - for stdin").
 		WithShortcode("rp")
 }
 
+func AwsResourcesFile() cfg.Param {
+	return cfg.NewParam[string]("resources-file", "Path to AWS resource inventory JSON file, in the format selected by --resource-format, or - for stdin")
+}
+
+func AwsResourceFormat() cfg.Param {
+	return cfg.NewParam[string]("resource-format", "Format of --resources-file: list-all, config (AWS Config), cloudcontrol (Cloud Control list-resources), or steampipe").
+		WithDefault("list-all").
+		WithRegex(regexp.MustCompile(`(?i)^(list-all|config|cloudcontrol|steampipe)$`))
+}
+
 func AwsCacheErrorResp() cfg.Param {
 	return cfg.NewParam[bool]("cache-error-resp", "Cache error response").
 		WithDefault(false)
@@ -370,5 +431,14 @@ func AwsApolloOfflineOptions() []cfg.Param {
 		AwsOrgPoliciesFile(),
 		AwsGaadFile(),
 		AwsResourcePoliciesFile(),
+		AwsResourcesFile(),
+		AwsResourceFormat(),
+		AwsIdentityCenterFile(),
+		AwsLastAccessedFile(),
+		AwsNoCompressActions(),
+		AwsAdminActions(),
+		AwsAdminActionThreshold(),
+		AwsMaxHops(),
+		AwsAnalyzerWorkers(),
 	}...)
 }
@@ -2,6 +2,7 @@ package options
 
 import (
 	"fmt"
+	"regexp"
 	"strings"
 
 	"github.com/praetorian-inc/janus-framework/pkg/chain/cfg"
@@ -189,19 +190,107 @@ func AzureDisableEnrichment() cfg.Param {
 
 // Azure IAM Pull parameters
 func AzureRefreshToken() cfg.Param {
-	return cfg.NewParam[string]("refresh-token", "Azure refresh token for authentication").
-		AsRequired()
+	return cfg.NewParam[string]("refresh-token", "Azure refresh token for authentication; without it the managed identity of the Azure host is used (not needed with --from-dump)").
+		WithDefault("")
 }
 
 func AzureTenantID() cfg.Param {
-	return cfg.NewParam[string]("tenant", "Azure AD tenant ID").
-		AsRequired()
+	return cfg.NewParam[string]("tenant", "Azure AD tenant ID (required with --refresh-token, read from the managed identity token otherwise; not needed with --from-dump)").
+		WithDefault("")
+}
+
+func AzureManagedIdentityClientID() cfg.Param {
+	return cfg.NewParam[string]("managed-identity-client-id", "Client ID of the user-assigned managed identity to authenticate as when --refresh-token is not set (default: the system-assigned identity)").
+		WithDefault("")
+}
+
+func AzureCloud() cfg.Param {
+	return cfg.NewParam[string]("cloud", "Azure cloud to collect from, selecting its login, ARM, Graph and PIM endpoints: public, usgov or china").
+		WithDefault("public")
 }
 
 func AzureProxy() cfg.Param {
 	return cfg.NewParam[string]("proxy", "Proxy URL for requests (e.g., http://127.0.0.1:8080)")
 }
 
+// AzureInsecure disables TLS certificate verification, for intercepting proxies
+func AzureInsecure() cfg.Param {
+	return cfg.NewParam[bool]("insecure", "Skip TLS certificate verification (e.g. behind an intercepting proxy)").
+		WithDefault(false)
+}
+
+// AzureHTTPTimeout bounds each Graph, PIM, ARM and Resource Graph request
+func AzureHTTPTimeout() cfg.Param {
+	return cfg.NewParam[int]("http-timeout", "Timeout in seconds for each Azure API request").
+		WithDefault(60)
+}
+
+// AzureARMMaxPages caps the pages read from one ARM list call. Hitting the cap
+// is recorded in collection_errors because the results are partial.
+func AzureARMMaxPages() cfg.Param {
+	return cfg.NewParam[int]("arm-max-pages", "Maximum pages to read from one paginated ARM API call").
+		WithDefault(100)
+}
+
+// AzureConcurrency sizes the collector worker pools that fetch subscriptions,
+// resource group and resource role assignments, and Key Vault access policies
+func AzureConcurrency() cfg.Param {
+	return cfg.NewParam[int]("concurrency", "Number of concurrent workers for per-subscription, resource group and resource collection; throttled requests are retried after the Retry-After Azure asks for").
+		WithDefault(4)
+}
+
+// AzureRBACDedup selects how duplicate role assignments are collapsed. "id"
+// matches on the assignment resource ID; "access" also collapses assignments
+// granting the same principal the same role at the same scope.
+func AzureRBACDedup() cfg.Param {
+	return cfg.NewParam[string]("rbac-dedup", "Role assignment deduplication key: id, or access (principal, role and scope)").
+		WithDefault("id").
+		WithRegex(regexp.MustCompile(`(?i)^(id|access)$`))
+}
+
+// AzureSample limits each collection to its first N objects so a development
+// or demo run finishes quickly. The output is marked as sampled.
+func AzureSample() cfg.Param {
+	return cfg.NewParam[int]("sample", "Collect only the first N objects of each collection for quick test runs; the output is marked as sampled and incomplete (0 collects everything)").
+		WithDefault(0)
+}
+
+// Azure AD audit/sign-in log collection parameters
+func AzureLogStart() cfg.Param {
+	return cfg.NewParam[string]("log-start", "Start of the sign-in/audit log window (RFC3339 or YYYY-MM-DD); enables log collection")
+}
+
+func AzureLogEnd() cfg.Param {
+	return cfg.NewParam[string]("log-end", "End of the sign-in/audit log window (RFC3339 or YYYY-MM-DD, default: now)")
+}
+
+func AzureLogFailuresOnly() cfg.Param {
+	return cfg.NewParam[bool]("log-failures-only", "Only collect failed sign-ins and failed directory audit events").
+		WithDefault(false)
+}
+
+func AzureLogUser() cfg.Param {
+	return cfg.NewParam[string]("log-user", "Only collect sign-in/audit log entries for this user principal name")
+}
+
+func AzureSuppressSPFile() cfg.Param {
+	return cfg.NewParam[string]("suppress-sp-file", "Path to JSON file of service principal appIds/object IDs whose dangerous permission findings are suppressed or downgraded to informational")
+}
+
+func AzureGraphPermissions() cfg.Param {
+	return cfg.NewParam[bool]("graph-permissions", "Resolve the Graph API permissions of every service principal, user and group and report dangerous ones under graphPermissionFindings (slow on large tenants)").
+		WithDefault(false)
+}
+
+func AzureDangerousGraphPermissionsFile() cfg.Param {
+	return cfg.NewParam[string]("dangerous-graph-permissions-file", "Path to JSON object mapping Graph permissions to the risk they carry, added to or overriding the built-in dangerous permission list")
+}
+
+func AzureEstimate() cfg.Param {
+	return cfg.NewParam[bool]("estimate", "Count directory objects and subscription resources, print the projected number of Graph and ARM requests and the collection time, then exit without collecting").
+		WithDefault(false)
+}
+
 // Azure IAM Push (Neo4j) parameters
 func AzureNeo4jURL() cfg.Param {
 	return cfg.NewParam[string]("neo4j-url", "Neo4j database URL").
@@ -229,6 +318,31 @@ func AzureClearDB() cfg.Param {
 		WithDefault(false)
 }
 
+func AzureDumpFile() cfg.Param {
+	return cfg.NewParam[string]("dump", "Path to a consolidated Azure IAM dump from iam-pull or iam-pull-sdk").
+		AsRequired()
+}
+
+func AzureOfflineReport() cfg.Param {
+	return cfg.NewParam[string]("report", "Report to run against the dump: all, owners, directory-write, guest-admins, global-admins, lock-coverage, pim-eligibility, privileged-role-counts, laps-readers, au-password-resets").
+		WithDefault("all")
+}
+
+func AzureImportAppend() cfg.Param {
+	return cfg.NewParam[bool]("append", "Merge into the existing graph without removing data from earlier imports").
+		WithDefault(false)
+}
+
+func AzureImportReplace() cfg.Param {
+	return cfg.NewParam[bool]("replace", "Remove data from the previous import with the same run ID before importing").
+		WithDefault(false)
+}
+
+func AzureImportRunID() cfg.Param {
+	return cfg.NewParam[string]("run-id", "Identifier stamped on imported nodes and relationships (defaults to the tenant ID)").
+		WithDefault("")
+}
+
 // AzureReconBaseOptions provides common options for Azure reconnaissance modules
 func AzureReconBaseOptions() []cfg.Param {
 	return []cfg.Param{
@@ -237,3 +351,98 @@ func AzureReconBaseOptions() []cfg.Param {
 		OutputDir(),
 	}
 }
+
+func AzureCompareBaseline() cfg.Param {
+	return cfg.NewParam[string]("compare-baseline", "Baseline file of accepted findings; report only findings that are new or resolved since it").
+		WithDefault("")
+}
+
+func AzureWriteBaseline() cfg.Param {
+	return cfg.NewParam[string]("write-baseline", "Write this run's findings to a baseline file for later --compare-baseline runs").
+		WithDefault("")
+}
+
+func AzureSummaryOut() cfg.Param {
+	return cfg.NewParam[string]("summary-out", "Write a markdown executive summary of principals, admin-equivalent principals, public resources and top findings to this file").
+		WithDefault("")
+}
+
+func AzureFromDump() cfg.Param {
+	return cfg.NewParam[string]("from-dump", "Re-run the detections over a consolidated dump from an earlier iam-pull or iam-pull-sdk run instead of collecting from Azure").
+		WithDefault("")
+}
+
+func AzureFindingsDump() cfg.Param {
+	return cfg.NewParam[string]("azure-dump", "Consolidated Azure IAM dump from iam-pull or iam-pull-sdk whose detections join the report").
+		WithDefault("")
+}
+
+func AzurePriorDump() cfg.Param {
+	return cfg.NewParam[string]("prior-dump", "Consolidated dump of an earlier run; subscriptions without ARM writes or deletes in the activity log since then are carried forward from it instead of collected again").
+		WithDefault("")
+}
+
+func AzureChangedSince() cfg.Param {
+	return cfg.NewParam[string]("changed-since", "Watermark for --prior-dump (RFC3339 or YYYY-MM-DD, default: the prior dump's collection timestamp)").
+		WithDefault("")
+}
+
+func AzureCheckpoint() cfg.Param {
+	return cfg.NewParam[string]("checkpoint", "Save each completed collection phase (Azure AD, PIM, management groups, each subscription) to this file and resume from it when the run is restarted with the same flags; removed once the run completes").
+		WithDefault("")
+}
+
+func AzureOutputFormat() cfg.Param {
+	return cfg.NewParam[string]("format", "Output format: json writes one consolidated document when the run ends; ndjson streams one record per collected object, tagged with its category and subscription, to <output>/iam-pull-<tenant>.ndjson as each phase completes and leaves only the metadata, errors and baseline comparison in the JSON output").
+		WithDefault("json")
+}
+
+func AzureDumpRawResponses() cfg.Param {
+	return cfg.NewParam[string]("dump-raw-responses", "Debug: write the raw JSON of every API response to this directory for a support bundle, with tokens redacted. The files hold tenant data").
+		WithDefault("")
+}
+
+func AzureSplitSubscriptions() cfg.Param {
+	return cfg.NewParam[string]("split-subscriptions", "Write each subscription's azure_resources data to its own file in this directory as it is collected; the main output keeps the tenant-wide data and lists the files in collection_metadata.subscription_shards").
+		WithDefault("")
+}
+
+func AzureMinify() cfg.Param {
+	return cfg.NewParam[bool]("minify", "Write the --split-subscriptions shards and collection checkpoints without indentation; the consolidated output is unindented unless --indent is set").
+		WithDefault(false)
+}
+
+func AzureProject() cfg.Param {
+	return cfg.NewParam[[]string]("project", "Keep only these fields on each azure_resources resource entry, e.g. name,identity, after the detections have run; id, type and subscriptionId are always kept (default keeps every field)").
+		WithDefault([]string{})
+}
+
+func AzureGraphObjects() cfg.Param {
+	return cfg.NewParam[[]string]("graph-objects", "Collect only these Graph object types and the types they are built from, e.g. servicePrincipals,appRoleAssignments,oauth2PermissionGrants: all, or azure_ad section names (users, groups, servicePrincipals, applications, devices, directoryRoles, roleDefinitions, conditionalAccessPolicies, tokenLifetimePolicies, authenticationMethodConfigurations, groupMemberships, groupOwnership, servicePrincipalOwnership, directoryRoleAssignments, oauth2PermissionGrants, appRoleAssignments, applicationOwnership, customSecurityAttributes, deviceOwnership, administrativeUnits, federatedIdentityCredentials)").
+		WithDefault([]string{})
+}
+
+func AzureResourceTypeFilter() cfg.Param {
+	return cfg.NewParam[string]("resource-type-filter", "YAML or JSON file of the Azure resource types to collect through Resource Graph (allow) and to leave out, optionally only for some kinds (deny); replaces the built-in filter, which leaves out system SQL databases and Synapse analytics servers").
+		WithDefault("")
+}
+
+func AzureAllResourceTypes() cfg.Param {
+	return cfg.NewParam[bool]("all-resource-types", "Collect every Azure resource Resource Graph returns in a single query, ignoring the resource type filter").
+		WithDefault(false)
+}
+
+func AzureUseBeta() cfg.Param {
+	return cfg.NewParam[[]string]("use-beta", "Collect datasets only served by the Graph beta endpoint, whose responses may change without notice: all, or collection names (role-management-policies, sign-in-activity, user-registration-details)").
+		WithDefault([]string{})
+}
+
+func AzureARGRules() cfg.Param {
+	return cfg.NewParam[string]("arg-rules", "YAML or JSON file of Azure Resource Graph detection rules (name, severity, description, labels, query, fields); each row a query returns is reported as a finding in argRuleFindings").
+		WithDefault("")
+}
+
+func AzureRules() cfg.Param {
+	return cfg.NewParam[[]string]("rules", "Detection rules to run: all, severity:<level>, or rule names (dynamic-group-escalation, group-owner-escalation, tenant-root-rbac, unlocked-high-value-resources, weak-authentication-methods, app-identity-keyvault-access, pim-weak-activation, nsg-internet-management-ports, illicit-consent-grants, privileged-arm-eligibility, tenant-wide-attribute-management, automation-privileged-access, privileged-user-devices, au-scoped-password-reset, storage-replication-outside-tenant)").
+		WithDefault([]string{"all"})
+}
@@ -45,3 +45,7 @@ func GcpResourceTypes() cfg.Param {
 func GcpAssetAPIProject() cfg.Param {
 	return cfg.NewParam[string]("asset-api-project", "GCP project ID where Asset API is enabled (defaults to ADC project for org/folder, scoped project otherwise)").WithDefault("")
 }
+
+func GcpProjects() cfg.Param {
+	return cfg.NewParam[[]string]("projects", "GCP project IDs to collect, or 'all' for every active project the credentials can list").WithDefault([]string{"all"})
+}
@@ -155,6 +155,10 @@ func OutputDir() cfg.Param {
 		WithDefault("nebula-output")
 }
 
+func OutputTemplate() cfg.Param {
+	return cfg.NewParam[string]("output-template", "file name template for output artifacts, e.g. {provider}-{tenant}-{timestamp}-{artifact}.json (placeholders: {provider}, {tenant}, {module}, {timestamp}, {artifact})")
+}
+
 func File() cfg.Param {
 	return cfg.NewParam[string]("file", "input file path").
 		WithShortcode("f")
@@ -20,6 +20,13 @@ func Neo4jPassword() cfg.Param {
 		WithDefault("neo4j")
 }
 
+// Neo4jAssumeYes skips the confirmation asked before writing to or clearing a
+// database that already holds nodes
+func Neo4jAssumeYes() cfg.Param {
+	return cfg.NewParam[bool]("yes", "Write to or clear a non-empty Neo4j database without asking for confirmation").
+		WithDefault(false)
+}
+
 func Neo4jOptions() []cfg.Param {
 	return []cfg.Param{
 		Neo4jURI(),
@@ -28,6 +35,37 @@ func Neo4jOptions() []cfg.Param {
 	}
 }
 
+// Neo4jEnrichOptions returns the parameters controlling graph enrichment queries
+func Neo4jEnrichOptions() []cfg.Param {
+	return []cfg.Param{
+		EnrichConcurrency(),
+		EnrichQuery(),
+		EnrichTimeout(),
+	}
+}
+
+// EnrichConcurrency bounds how many independent enrichment queries run at once
+func EnrichConcurrency() cfg.Param {
+	return cfg.NewParam[int]("enrich-concurrency", "Maximum number of independent enrichment queries (those sharing an order) to run at once").
+		WithDefault(4)
+}
+
+// EnrichQuery restricts enrichment to the named queries
+func EnrichQuery() cfg.Param {
+	return cfg.NewParam[[]string]("enrich-query", "Only run these enrichment queries, by ID or file name (e.g. method_01_iam_create_policy_version)")
+}
+
+// EnrichTimeout bounds how long a single enrichment query may run
+func EnrichTimeout() cfg.Param {
+	return cfg.NewParam[int]("enrich-timeout", "Timeout in seconds for each enrichment query; a query that runs longer is cancelled and fails enrichment (0 disables)").
+		WithDefault(900)
+}
+
+// EdgesOut writes the computed relationships to a JSONL edge file
+func EdgesOut() cfg.Param {
+	return cfg.NewParam[string]("edges-out", "Also write computed relationships to this JSONL edge file (source, target, type, properties), with or without Neo4j")
+}
+
 func Query() cfg.Param {
 	return cfg.NewParam[[]string]("query", "Query to run against the graph database").
 		WithDefault([]string{"all"}).
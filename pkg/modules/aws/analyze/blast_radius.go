@@ -0,0 +1,34 @@
+package analyze
+
+import (
+	"github.com/praetorian-inc/janus-framework/pkg/chain"
+	"github.com/praetorian-inc/janus-framework/pkg/chain/cfg"
+	"github.com/praetorian-inc/nebula/internal/registry"
+	"github.com/praetorian-inc/nebula/pkg/links/aws"
+	"github.com/praetorian-inc/nebula/pkg/outputters"
+)
+
+func init() {
+	registry.Register("aws", "analyze", BlastRadius.Metadata().Properties()["id"].(string), *BlastRadius)
+}
+
+var BlastRadius = chain.NewModule(
+	cfg.NewMetadata(
+		"Blast Radius",
+		"Computes everything reachable from a compromised user, role or session offline from a GAAD export: the principals it can act as through group memberships, assume-role chains and privilege escalation primitives, and the resources and actions those principals are allowed.",
+	).WithProperties(map[string]any{
+		"id":          "blast-radius",
+		"platform":    "aws",
+		"opsec_level": "safe",
+		"authors":     []string{"Praetorian"},
+		"references":  []string{},
+	}),
+).WithLinks(
+	aws.NewBlastRadiusLink,
+).WithOutputters(
+	outputters.NewRuntimeJSONOutputter,
+).WithParams(
+	cfg.NewParam[string]("module-name", "name of the module for dynamic file naming"),
+).WithConfigs(
+	cfg.WithArg("module-name", "blast-radius"),
+).WithAutoRun()
@@ -0,0 +1,34 @@
+package analyze
+
+import (
+	"github.com/praetorian-inc/janus-framework/pkg/chain"
+	"github.com/praetorian-inc/janus-framework/pkg/chain/cfg"
+	"github.com/praetorian-inc/nebula/internal/registry"
+	"github.com/praetorian-inc/nebula/pkg/links/aws"
+	"github.com/praetorian-inc/nebula/pkg/outputters"
+)
+
+func init() {
+	registry.Register("aws", "analyze", CrossAccountTrust.Metadata().Properties()["id"].(string), *CrossAccountTrust)
+}
+
+var CrossAccountTrust = chain.NewModule(
+	cfg.NewMetadata(
+		"Cross-Account Trust",
+		"Lists every resource whose policy, including role trust policies, grants access to a principal in a different AWS account, offline from a GAAD export and resource policies: the resource, the foreign account, the granted actions and whether the foreign account trusts back.",
+	).WithProperties(map[string]any{
+		"id":          "cross-account-trust",
+		"platform":    "aws",
+		"opsec_level": "safe",
+		"authors":     []string{"Praetorian"},
+		"references":  []string{},
+	}),
+).WithLinks(
+	aws.NewCrossAccountTrustLink,
+).WithOutputters(
+	outputters.NewRuntimeJSONOutputter,
+).WithParams(
+	cfg.NewParam[string]("module-name", "name of the module for dynamic file naming"),
+).WithConfigs(
+	cfg.WithArg("module-name", "cross-account-trust"),
+).WithAutoRun()
@@ -40,6 +40,7 @@ var AWSPublicResources = chain.NewModule(
 	cfg.NewParam[string]("module-name", "name of the module for dynamic file naming"),
 	options.AwsProfile(),
 	options.AwsOrgPoliciesFile(),
+	options.AwsOrgID(),
 ).WithConfigs(
 	cfg.WithArg("module-name", "public-resources"),
 )
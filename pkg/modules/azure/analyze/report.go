@@ -0,0 +1,34 @@
+package analyze
+
+import (
+	"github.com/praetorian-inc/janus-framework/pkg/chain"
+	"github.com/praetorian-inc/janus-framework/pkg/chain/cfg"
+	"github.com/praetorian-inc/nebula/internal/registry"
+	"github.com/praetorian-inc/nebula/pkg/links/azure/iam"
+	"github.com/praetorian-inc/nebula/pkg/outputters"
+)
+
+func init() {
+	registry.Register("azure", "analyze", AzureOfflineReport.Metadata().Properties()["id"].(string), *AzureOfflineReport)
+}
+
+var AzureOfflineReport = chain.NewModule(
+	cfg.NewMetadata(
+		"Azure Offline Report",
+		"Answers common access questions (subscription Owners, Directory.ReadWrite.All service principals, guest and Global Administrators) directly from a consolidated iam-pull dump, without Neo4j.",
+	).WithProperties(map[string]any{
+		"id":          "report",
+		"platform":    "azure",
+		"opsec_level": "safe",
+		"authors":     []string{"Praetorian"},
+		"references":  []string{},
+	}),
+).WithLinks(
+	iam.NewOfflineReportLink,
+).WithOutputters(
+	outputters.NewRuntimeJSONOutputter,
+).WithParams(
+	cfg.NewParam[string]("module-name", "name of the module for dynamic file naming"),
+).WithConfigs(
+	cfg.WithArg("module-name", "azure-report"),
+).WithAutoRun()
@@ -0,0 +1,41 @@
+package recon
+
+import (
+	"github.com/praetorian-inc/janus-framework/pkg/chain"
+	"github.com/praetorian-inc/janus-framework/pkg/chain/cfg"
+	"github.com/praetorian-inc/nebula/internal/registry"
+	"github.com/praetorian-inc/nebula/pkg/links/azure"
+	"github.com/praetorian-inc/nebula/pkg/links/options"
+	"github.com/praetorian-inc/nebula/pkg/outputters"
+)
+
+func init() {
+	registry.Register("azure", "recon", AzureDefenderCoverage.Metadata().Properties()["id"].(string), *AzureDefenderCoverage)
+}
+
+var AzureDefenderCoverage = chain.NewModule(
+	cfg.NewMetadata(
+		"Defender Coverage",
+		"Detect subscriptions where Microsoft Defender for Cloud plans for Servers, Storage, SQL, or Containers are left on the Free tier",
+	).WithProperties(map[string]any{
+		"id":          "defender-coverage",
+		"platform":    "azure",
+		"opsec_level": "stealth",
+		"authors":     []string{"Praetorian"},
+		"references": []string{
+			"https://learn.microsoft.com/en-us/azure/defender-for-cloud/defender-for-cloud-introduction",
+			"https://learn.microsoft.com/en-us/rest/api/defenderforcloud/pricings/list",
+		},
+	}),
+).WithLinks(
+	azure.NewAzureSubscriptionGeneratorLink,
+	azure.NewAzureDefenderCoverageLink,
+).WithOutputters(
+	outputters.NewRuntimeJSONOutputter,
+).WithInputParam(
+	options.AzureSubscription(),
+).WithParams(
+	cfg.NewParam[string]("module-name", "name of the module for dynamic file naming"),
+).WithConfigs(
+	cfg.WithArg("module-name", "defender-coverage"),
+).WithAutoRun()
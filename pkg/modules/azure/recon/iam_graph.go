@@ -0,0 +1,64 @@
+package recon
+
+import (
+	"github.com/praetorian-inc/janus-framework/pkg/chain"
+	"github.com/praetorian-inc/janus-framework/pkg/chain/cfg"
+	"github.com/praetorian-inc/nebula/internal/registry"
+	"github.com/praetorian-inc/nebula/pkg/links/azure/iam"
+	"github.com/praetorian-inc/nebula/pkg/links/options"
+	"github.com/praetorian-inc/nebula/pkg/outputters"
+)
+
+var AzureIAMGraph = chain.NewModule(
+	cfg.NewMetadata(
+		"Azure IAM Graph - Collect and Import into Neo4j",
+		"Collects Azure AD, PIM, and Azure Resource Manager data like iam-pull and writes it straight into Neo4j like iam-push, without saving the collection to a file first.",
+	).WithProperties(map[string]any{
+		"id":          "iam-graph",
+		"platform":    "azure",
+		"opsec_level": "moderate",
+		"authors":     []string{"Praetorian"},
+		"references": []string{
+			"https://learn.microsoft.com/en-us/graph/api/overview",
+			"https://learn.microsoft.com/en-us/azure/role-based-access-control/role-assignments-rest",
+			"https://neo4j.com/developer/graph-database/",
+		},
+	}),
+).WithLinks(
+	// Collect ALL Azure data (Graph, PIM, AzureRM), then import it in memory
+	iam.NewIAMComprehensiveCollectorLink,
+	iam.NewNeo4jWriterLink,
+).WithInputParam(
+	options.AzureSubscription(),
+).WithParams(
+	options.AzureRefreshToken(),
+	options.AzureTenantID(),
+	options.AzureManagedIdentityClientID(),
+	options.AzureCloud(),
+	options.AzureProxy(),
+	options.AzureSuppressSPFile(),
+	options.AzureGraphPermissions(),
+	options.AzureDangerousGraphPermissionsFile(),
+	options.AzureGraphObjects(),
+	options.AzureResourceTypeFilter(),
+	options.AzureAllResourceTypes(),
+	options.AzureNeo4jURL(),
+	options.AzureNeo4jUser(),
+	options.AzureNeo4jPassword(),
+	options.AzureClearDB(),
+	options.AzureImportAppend(),
+	options.AzureImportReplace(),
+	options.AzureImportRunID(),
+).WithOutputters(
+	// Standard Nebula JSON outputter for import summary
+	outputters.NewRuntimeJSONOutputter,
+).WithConfigs(
+	cfg.WithArg("output", "./nebula-output"),
+	cfg.WithArg("neo4j-url", "bolt://localhost:7687"),
+	cfg.WithArg("neo4j-user", "neo4j"),
+	cfg.WithArg("neo4j-password", ""),
+).WithAutoRun()
+
+func init() {
+	registry.Register("azure", "recon", "iam-graph", *AzureIAMGraph)
+}
@@ -12,7 +12,7 @@ import (
 var AzureIAMPull = chain.NewModule(
 	cfg.NewMetadata(
 		"Azure IAM Pull - Comprehensive Identity & Access Management Enumeration",
-		"Collects Azure AD, PIM, and Azure Resource Manager data. Requires refresh token authentication.",
+		"Collects Azure AD, PIM, and Azure Resource Manager data. Optionally collects sign-in and directory audit logs for a time window (--log-start/--log-end, requires AuditLog.Read.All). Authenticates with a refresh token, or without one through the managed identity of the Azure VM, Function or App Service it runs on; --from-dump re-runs the detections over a saved dump instead. With --prior-dump, subscriptions unchanged in the activity log since the prior run are carried forward instead of collected again.",
 	).WithProperties(map[string]any{
 		"id":          "iam-pull",
 		"platform":    "azure",
@@ -22,6 +22,7 @@ var AzureIAMPull = chain.NewModule(
 			"https://learn.microsoft.com/en-us/azure/active-directory/privileged-identity-management/",
 			"https://learn.microsoft.com/en-us/graph/api/overview",
 			"https://learn.microsoft.com/en-us/azure/role-based-access-control/role-assignments-rest",
+			"https://learn.microsoft.com/en-us/graph/api/resources/azure-ad-auditlog-overview",
 		},
 	}),
 ).WithLinks(
@@ -33,7 +34,20 @@ var AzureIAMPull = chain.NewModule(
 ).WithParams(
 	options.AzureRefreshToken(),
 	options.AzureTenantID(),
+	options.AzureManagedIdentityClientID(),
+	options.AzureCloud(),
 	options.AzureProxy(),
+	options.AzureLogStart(),
+	options.AzureLogEnd(),
+	options.AzureLogFailuresOnly(),
+	options.AzureLogUser(),
+	options.AzureSuppressSPFile(),
+	options.AzureGraphPermissions(),
+	options.AzureDangerousGraphPermissionsFile(),
+	options.AzureEstimate(),
+	options.AzureGraphObjects(),
+	options.AzureResourceTypeFilter(),
+	options.AzureAllResourceTypes(),
 ).WithOutputters(
 	// Use standard Nebula JSON outputter for single consolidated file
 	outputters.NewRuntimeJSONOutputter,
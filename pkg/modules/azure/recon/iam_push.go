@@ -36,6 +36,9 @@ var AzureIAMPush = chain.NewModule(
 	cfg.WithArg("neo4j-password", ""),
 	cfg.WithArg("data-file", ""),
 	cfg.WithArg("clear-db", false),
+	cfg.WithArg("append", false),
+	cfg.WithArg("replace", false),
+	cfg.WithArg("run-id", ""),
 ).WithAutoRun()
 
 func init() {
@@ -0,0 +1,40 @@
+package recon
+
+import (
+	"github.com/praetorian-inc/janus-framework/pkg/chain"
+	"github.com/praetorian-inc/janus-framework/pkg/chain/cfg"
+	"github.com/praetorian-inc/nebula/internal/registry"
+	"github.com/praetorian-inc/nebula/pkg/links/gcp/iam"
+	"github.com/praetorian-inc/nebula/pkg/links/options"
+	"github.com/praetorian-inc/nebula/pkg/outputters"
+)
+
+func init() {
+	registry.Register("gcp", "recon", GcpIAMPull.Metadata().Properties()["id"].(string), *GcpIAMPull)
+}
+
+var GcpIAMPull = chain.NewModule(
+	cfg.NewMetadata(
+		"GCP IAM Pull - Identity & Access Management Enumeration",
+		"Collects the IAM bindings of GCP projects and of the folders and organizations above them, with the service accounts and service account keys of each project, into one consolidated file. Authenticates with --creds-file or application-default credentials.",
+	).WithProperties(map[string]any{
+		"id":          "iam-pull",
+		"platform":    "gcp",
+		"category":    "recon",
+		"opsec_level": "moderate",
+		"authors":     []string{"Praetorian"},
+		"references": []string{
+			"https://cloud.google.com/resource-manager/reference/rest/v1/projects/getIamPolicy",
+			"https://cloud.google.com/iam/docs/reference/rest/v1/projects.serviceAccounts.keys/list",
+		},
+	}),
+).WithLinks(
+	// Project discovery, hierarchy and IAM collection are handled by this link
+	iam.NewGcpIamCollectorLink,
+).WithInputParam(
+	options.GcpProjects(),
+).WithOutputters(
+	outputters.NewRuntimeJSONOutputter,
+).WithConfigs(
+	cfg.WithArg("output", "./nebula-output"),
+).WithAutoRun()
@@ -0,0 +1,34 @@
+package analyze
+
+import (
+	"github.com/praetorian-inc/janus-framework/pkg/chain"
+	"github.com/praetorian-inc/janus-framework/pkg/chain/cfg"
+	"github.com/praetorian-inc/nebula/internal/registry"
+	"github.com/praetorian-inc/nebula/pkg/links/multi"
+	"github.com/praetorian-inc/nebula/pkg/outputters"
+)
+
+func init() {
+	registry.Register("multi", "analyze", MultiCloudFindingsReport.Metadata().Properties()["id"].(string), *MultiCloudFindingsReport)
+}
+
+var MultiCloudFindingsReport = chain.NewModule(
+	cfg.NewMetadata(
+		"Multi-Cloud Findings Report",
+		"Runs the AWS analysis over a GAAD export and the Azure detections over a consolidated iam-pull dump, and merges their findings into one report banded by severity with a provider column.",
+	).WithProperties(map[string]any{
+		"id":          "findings-report",
+		"platform":    "multi",
+		"opsec_level": "safe",
+		"authors":     []string{"Praetorian"},
+		"references":  []string{},
+	}),
+).WithLinks(
+	multi.NewFindingsReportLink,
+).WithOutputters(
+	outputters.NewRuntimeJSONOutputter,
+).WithParams(
+	cfg.NewParam[string]("module-name", "name of the module for dynamic file naming"),
+).WithConfigs(
+	cfg.WithArg("module-name", "findings-report"),
+).WithAutoRun()
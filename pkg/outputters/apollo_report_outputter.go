@@ -78,6 +78,12 @@ func (o *ApolloReportOutputter) Complete() error {
 				return err
 			}
 		}
+
+		if report.RoleTakeover != nil {
+			if err := o.writeRoleTakeoverMarkdown(report); err != nil {
+				return err
+			}
+		}
 	}
 
 	return nil
@@ -258,6 +264,43 @@ func (o *ApolloReportOutputter) writeExternalTrustMarkdown(report *types.ApolloR
 	return nil
 }
 
+func (o *ApolloReportOutputter) writeRoleTakeoverMarkdown(report *types.ApolloReportData) error {
+	mdPath := filepath.Join(o.outputDir, "role-takeover-report.md")
+
+	var sb strings.Builder
+
+	sb.WriteString("# Role Takeover Analysis\n\n")
+	sb.WriteString(fmt.Sprintf("**Generated:** %s  \n", report.Generated))
+	sb.WriteString(fmt.Sprintf("**Total Role Takeovers:** %d\n\n", report.RoleTakeover.Total))
+	sb.WriteString("Each principal below holds `iam:UpdateAssumeRolePolicy` on the role, so it can add itself to the role's trust policy and assume it.\n\n")
+
+	sb.WriteString("## Summary\n\n")
+	sb.WriteString("| Category | Count | Risk |\n")
+	sb.WriteString("|----------|-------|------|\n")
+	sb.WriteString(fmt.Sprintf("| Takeover of Admin Role | %d | Critical |\n", report.RoleTakeover.AdminRoles))
+	sb.WriteString(fmt.Sprintf("| Takeover of Other Role | %d | High |\n", report.RoleTakeover.Total-report.RoleTakeover.AdminRoles))
+	sb.WriteString("\n---\n\n")
+
+	if len(report.RoleTakeover.Takeovers) > 0 {
+		sb.WriteString("## Takeovers\n\n")
+		sb.WriteString("| Principal | Role | Is Admin | Is Privileged | Reachable Resources |\n")
+		sb.WriteString("|-----------|------|----------|---------------|---------------------|\n")
+		for _, takeover := range report.RoleTakeover.Takeovers {
+			sb.WriteString(fmt.Sprintf("| %s | %s | %s | %s | %d |\n",
+				truncateARN(takeover.Principal), truncateARN(takeover.Role),
+				boolToEmoji(takeover.RoleIsAdmin), boolToEmoji(takeover.RoleIsPrivileged), takeover.ReachableResources))
+		}
+		sb.WriteString("\n---\n\n")
+	}
+
+	if err := os.WriteFile(mdPath, []byte(sb.String()), 0644); err != nil {
+		return fmt.Errorf("failed to write role takeover markdown report: %w", err)
+	}
+
+	fmt.Printf("Written: %s\n", mdPath)
+	return nil
+}
+
 // Helper functions
 
 func sortInts(ints []int) {
@@ -1,11 +1,17 @@
 package outputters
 
 import (
+	"bufio"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/praetorian-inc/janus-framework/pkg/chain"
 	"github.com/praetorian-inc/janus-framework/pkg/chain/cfg"
@@ -25,7 +31,8 @@ type Neo4jGraphOutputter struct {
 	ctx             context.Context
 	nodes           []model.GraphModel
 	relationships   []model.GraphRelationship
-	connectionValid bool // Track if Neo4j connection is available
+	connectionValid bool   // Track if Neo4j connection is available
+	edgesOut        string // JSONL edge file written alongside (or instead of) Neo4j
 }
 
 
@@ -43,11 +50,14 @@ func NewNeo4jGraphOutputter(configs ...cfg.Config) chain.Outputter {
 
 // Params returns the parameters for this outputter
 func (o *Neo4jGraphOutputter) Params() []cfg.Param {
-	return options.Neo4jOptions()
+	params := append(options.Neo4jOptions(), options.Neo4jEnrichOptions()...)
+	return append(params, options.EdgesOut(), options.Neo4jAssumeYes())
 }
 
 // Initialize is called when the outputter is initialized
 func (o *Neo4jGraphOutputter) Initialize() error {
+	o.edgesOut, _ = cfg.As[string](o.Arg(options.EdgesOut().Name()))
+
 	// Initialize Neo4j connection using updated Konstellation adapter
 	graphConfig := &graph.Config{
 		URI:      o.Args()[options.Neo4jURI().Name()].(string),
@@ -72,15 +82,39 @@ func (o *Neo4jGraphOutputter) Initialize() error {
 		return nil
 	}
 
+	if err := o.confirmWrite(graphConfig.URI); err != nil {
+		o.db.Close()
+		return err
+	}
+
 	o.connectionValid = true
 	slog.Info("Neo4j graph outputter initialized successfully")
 	return nil
 }
 
+// confirmWrite asks before merging into a database that already holds nodes,
+// unless --yes was passed
+func (o *Neo4jGraphOutputter) confirmWrite(uri string) error {
+	assumeYes, _ := cfg.As[bool](o.Arg(options.Neo4jAssumeYes().Name()))
+	if assumeYes {
+		return nil
+	}
+
+	result, err := o.db.Query(o.ctx, graph.CountNodesQuery, nil)
+	if err != nil {
+		return fmt.Errorf("failed to count existing Neo4j nodes: %w", err)
+	}
+	var existing int64
+	if len(result.Records) > 0 {
+		existing, _ = result.Records[0]["count"].(int64)
+	}
+	return graph.ConfirmWrite(uri, existing, "merge this run's nodes and relationships into them", assumeYes)
+}
+
 // Output collects GraphModel nodes and GraphRelationship connections for batch processing
 func (o *Neo4jGraphOutputter) Output(v any) error {
-	// Skip processing if Neo4j connection is not valid
-	if !o.connectionValid {
+	// Skip processing if Neo4j connection is not valid and there is no edge file to write
+	if !o.connectionValid && o.edgesOut == "" {
 		slog.Debug("Skipping Neo4j output - connection not available")
 		return nil
 	}
@@ -121,6 +155,12 @@ func (o *Neo4jGraphOutputter) Output(v any) error {
 
 // Complete is called when the chain is complete - processes all collected data
 func (o *Neo4jGraphOutputter) Complete() error {
+	if o.edgesOut != "" {
+		if err := o.writeEdges(); err != nil {
+			return err
+		}
+	}
+
 	// Skip processing if Neo4j connection is not valid
 	if !o.connectionValid || o.db == nil {
 		slog.Warn("Skipping Neo4j Complete() - connection not available")
@@ -182,12 +222,23 @@ func (o *Neo4jGraphOutputter) Complete() error {
 	// Run AWS enrichment queries (keeping existing functionality)
 	if len(o.relationships) > 0 {
 		slog.Info("Running AWS enrichment queries")
-		eResults, err := queries.EnrichAWS(o.db)
+		concurrency, err := cfg.As[int](o.Arg(options.EnrichConcurrency().Name()))
 		if err != nil {
+			concurrency = 1
+		}
+		only, _ := cfg.As[[]string](o.Arg(options.EnrichQuery().Name()))
+		timeoutSeconds, _ := cfg.As[int](o.Arg(options.EnrichTimeout().Name()))
+		timeout := time.Duration(timeoutSeconds) * time.Second
+
+		eResults, timings, err := queries.Enrich(o.ctx, o.db, "aws", queries.EnrichOptions{Concurrency: concurrency, Only: only, Timeout: timeout})
+		if errors.Is(err, context.DeadlineExceeded) {
+			message.Error("Enrichment stopped: %v. Raise --enrich-timeout, or leave the query out with --enrich-query.", err)
+		} else if err != nil {
 			slog.Error(fmt.Sprintf("Failed to enrich AWS data: %s", err.Error()))
 		} else {
 			slog.Debug(fmt.Sprintf("AWS enrichment completed with %d results", len(eResults)))
 		}
+		logSlowestEnrichQueries(timings, 5)
 	}
 
 	// Run account enrichment (this will be moved from AwsApolloControlFlow)
@@ -199,6 +250,27 @@ func (o *Neo4jGraphOutputter) Complete() error {
 	return nil
 }
 
+// logSlowestEnrichQueries summarizes enrichment timing so slow queries stand out
+func logSlowestEnrichQueries(timings []queries.QueryTiming, n int) {
+	if len(timings) == 0 {
+		return
+	}
+	var total time.Duration
+	for _, t := range timings {
+		total += t.Duration
+	}
+	sorted := append([]queries.QueryTiming(nil), timings...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Duration > sorted[j].Duration })
+	if len(sorted) > n {
+		sorted = sorted[:n]
+	}
+
+	slog.Info("Enrichment query timing", "queries", len(timings), "totalQueryTime", total)
+	for _, t := range sorted {
+		slog.Info("Slow enrichment query", "id", t.ID, "duration", t.Duration)
+	}
+}
+
 // enrichAccountDetails performs account enrichment queries
 // This logic will be moved from AwsApolloControlFlow.enrichAccountDetails()
 func (o *Neo4jGraphOutputter) enrichAccountDetails() error {
@@ -257,6 +329,35 @@ func (o *Neo4jGraphOutputter) enrichAccountDetails() error {
 	return nil
 }
 
+// writeEdges writes the collected relationships to the --edges-out file
+func (o *Neo4jGraphOutputter) writeEdges() error {
+	graphRels := make([]*graph.Relationship, len(o.relationships))
+	for i, rel := range o.relationships {
+		graphRels[i] = o.tabullariumRelationshipToGraphRelationship(rel)
+	}
+
+	if dir := filepath.Dir(o.edgesOut); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("failed to create edge file directory: %w", err)
+		}
+	}
+	file, err := os.Create(o.edgesOut)
+	if err != nil {
+		return fmt.Errorf("failed to create edge file: %w", err)
+	}
+	defer file.Close()
+
+	writer := bufio.NewWriter(file)
+	if err := graph.WriteEdges(writer, graphRels); err != nil {
+		return err
+	}
+	if err := writer.Flush(); err != nil {
+		return fmt.Errorf("failed to write edge file: %w", err)
+	}
+	message.Success("Wrote %d relationships to %s", len(graphRels), o.edgesOut)
+	return nil
+}
+
 // Close closes the Neo4j database connection
 func (o *Neo4jGraphOutputter) Close() error {
 	if o.db != nil {
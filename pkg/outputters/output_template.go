@@ -0,0 +1,120 @@
+package outputters
+
+import (
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/praetorian-inc/janus-framework/pkg/chain/cfg"
+)
+
+// runTimestamp is shared by every artifact written in one run so they sort and
+// archive together
+var runTimestamp = time.Now().Format("20060102-150405")
+
+// OutputNameFields are the values substituted into an --output-template
+type OutputNameFields struct {
+	Provider string
+	Tenant   string
+	Module   string
+	Artifact string
+}
+
+// ExpandOutputTemplate renders a file name template. Supported placeholders are
+// {provider}, {tenant}, {module}, {timestamp} and {artifact}. The artifact's own
+// extension replaces the template's, so one template serves JSON, CSV and
+// markdown outputs. Empty values render as "unknown" so names stay aligned.
+func ExpandOutputTemplate(template string, fields OutputNameFields) string {
+	artifactExt := filepath.Ext(fields.Artifact)
+	value := func(s string) string {
+		if s == "" {
+			return "unknown"
+		}
+		return sanitizeNameSegment(s)
+	}
+
+	name := strings.NewReplacer(
+		"{provider}", value(fields.Provider),
+		"{tenant}", value(fields.Tenant),
+		"{module}", value(fields.Module),
+		"{timestamp}", runTimestamp,
+		"{artifact}", value(strings.TrimSuffix(fields.Artifact, artifactExt)),
+	).Replace(template)
+
+	if artifactExt != "" {
+		name = strings.TrimSuffix(name, filepath.Ext(name)) + artifactExt
+	} else if filepath.Ext(name) == "" {
+		name += ".json"
+	}
+	return name
+}
+
+// sanitizeNameSegment keeps substituted values from adding directories or
+// characters that are awkward in file names
+func sanitizeNameSegment(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case '/', '\\', ':', '*', '?', '"', '<', '>', '|', ' ':
+			return '_'
+		}
+		return r
+	}, s)
+}
+
+// templatedOutputPath returns the path for an artifact under the output
+// directory when --output-template is set, and false otherwise
+func templatedOutputPath(holder argHolder, fields OutputNameFields) (string, bool) {
+	template, err := cfg.As[string](holder.Arg("output-template"))
+	if err != nil || template == "" {
+		return "", false
+	}
+	outputDir, err := cfg.As[string](holder.Arg("output"))
+	if err != nil || outputDir == "" {
+		outputDir = "nebula-output"
+	}
+	return filepath.Join(outputDir, ExpandOutputTemplate(template, fields)), true
+}
+
+type argHolder interface {
+	Arg(name string) any
+}
+
+// outputNameFields infers the provider and tenant from the platform parameters
+// the module passed through
+func outputNameFields(holder argHolder, artifact string) OutputNameFields {
+	fields := OutputNameFields{Artifact: artifact}
+	if moduleName, err := cfg.As[string](holder.Arg("module-name")); err == nil {
+		fields.Module = moduleName
+	}
+
+	if profile, err := cfg.As[string](holder.Arg("profile")); err == nil {
+		fields.Provider = "aws"
+		cacheKey := profile
+		if cacheKey == "" {
+			cacheKey = "default"
+		}
+		fields.Tenant = awsAccountFromCache(cacheKey)
+		if fields.Tenant == "" {
+			fields.Tenant = profile
+		}
+		return fields
+	}
+	if subscriptions, err := cfg.As[[]string](holder.Arg("subscription")); err == nil && len(subscriptions) > 0 && subscriptions[0] != "" {
+		fields.Provider = "azure"
+		fields.Tenant = subscriptions[0]
+		if fields.Tenant == "all" {
+			fields.Tenant = "all-subscriptions"
+		}
+		return fields
+	}
+	if orgs, err := cfg.As[[]string](holder.Arg("org")); err == nil && len(orgs) > 0 && orgs[0] != "" {
+		fields.Provider = "gcp"
+		fields.Tenant = orgs[0]
+		return fields
+	}
+	if project, err := cfg.As[string](holder.Arg("project")); err == nil && project != "" {
+		fields.Provider = "gcp"
+		fields.Tenant = project
+	}
+	return fields
+}
@@ -0,0 +1,21 @@
+package outputters
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExpandOutputTemplate(t *testing.T) {
+	fields := OutputNameFields{Provider: "azure", Tenant: "contoso/prod", Module: "iam-pull", Artifact: "risks.csv"}
+
+	assert.Equal(t, "azure-contoso_prod-"+runTimestamp+"-risks.csv",
+		ExpandOutputTemplate("{provider}-{tenant}-{timestamp}-{artifact}.json", fields),
+		"the artifact extension wins and values cannot add directories")
+
+	fields.Artifact = "gaad-data"
+	assert.Equal(t, "iam-pull/gaad-data.json", ExpandOutputTemplate("{module}/{artifact}", fields),
+		"templates may add their own directories")
+
+	assert.Equal(t, "unknown-gaad-data.json", ExpandOutputTemplate("{provider}-{artifact}.json", OutputNameFields{Artifact: "gaad-data"}))
+}
@@ -6,11 +6,14 @@ import (
 	"fmt"
 	"log/slog"
 	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/praetorian-inc/janus-framework/pkg/chain"
 	"github.com/praetorian-inc/janus-framework/pkg/chain/cfg"
 	"github.com/praetorian-inc/nebula/internal/message"
+	"github.com/praetorian-inc/nebula/pkg/links/options"
+	"github.com/praetorian-inc/nebula/pkg/utils"
 	"github.com/praetorian-inc/tabularium/pkg/model/model"
 )
 
@@ -56,6 +59,9 @@ func (o *RiskCSVOutputter) Initialize() error {
 	if err == nil && outputFile != "" {
 		o.outputFile = outputFile
 	}
+	if outputFile, ok := templatedOutputPath(o, outputNameFields(o, filepath.Base(o.outputFile))); ok {
+		o.outputFile = outputFile
+	}
 	return nil
 }
 
@@ -67,6 +73,9 @@ func (o *RiskCSVOutputter) Complete() error {
 	}
 
 	// Create CSV file
+	if err := utils.EnsureFileDirectory(o.outputFile); err != nil {
+		return fmt.Errorf("failed to create directory for CSV file: %w", err)
+	}
 	file, err := os.Create(o.outputFile)
 	if err != nil {
 		return fmt.Errorf("failed to create CSV file: %w", err)
@@ -178,5 +187,7 @@ func formatAny(v any) (string, error) {
 func (o *RiskCSVOutputter) Params() []cfg.Param {
 	return []cfg.Param{
 		cfg.NewParam[string]("csvoutfile", "file to write the CSV output to").WithDefault("risks.csv"),
+		options.OutputDir(),
+		options.OutputTemplate(),
 	}
 }
@@ -52,6 +52,7 @@ type RuntimeJSONOutputter struct {
 	indent   int
 	sections *OutputSections
 	outfile  string
+	artifact string
 }
 
 // NewRuntimeJSONOutputter creates a new RuntimeJSONOutputter
@@ -101,6 +102,10 @@ func (j *RuntimeJSONOutputter) Initialize() error {
 	}
 
 	j.outfile = outfile
+	j.artifact = "results.json"
+	if outfile, ok := j.templatedOutfile(); ok {
+		j.outfile = outfile
+	}
 
 	// Ensure output directory exists early to prevent runtime errors
 	if err := j.EnsureOutputPath(j.outfile); err != nil {
@@ -125,8 +130,13 @@ func (j *RuntimeJSONOutputter) Output(val any) error {
 	// Check if we received an OutputData structure
 	if outputData, ok := val.(NamedOutputData); ok {
 		// If filename is provided, update the output file
-		if outputData.OutputFilename != "" && filepath.Base(j.outfile) == defaultOutfile {
-			j.SetOutputFile(outputData.OutputFilename)
+		if outputData.OutputFilename != "" {
+			if outfile, ok := j.templatedOutfileFor(outputData.OutputFilename); ok {
+				j.artifact = outputData.OutputFilename
+				j.SetOutputFile(outfile)
+			} else if filepath.Base(j.outfile) == defaultOutfile {
+				j.SetOutputFile(outputData.OutputFilename)
+			}
 		}
 		// Extract the actual data
 		dataToProcess = outputData.Data
@@ -278,7 +288,9 @@ func (j *RuntimeJSONOutputter) SetOutputFile(filename string) {
 func (j *RuntimeJSONOutputter) Complete() error {
 	// Check for module-specific parameters one more time at completion
 	// in case they're available now but weren't during initialization
-	if filepath.Base(j.outfile) == defaultOutfile || strings.Contains(j.outfile, "out-") {
+	if outfile, ok := j.templatedOutfile(); ok {
+		j.outfile = outfile
+	} else if filepath.Base(j.outfile) == defaultOutfile || strings.Contains(j.outfile, "out-") {
 		// Get output directory
 		outputDir, err := cfg.As[string](j.Arg("output"))
 		if err != nil {
@@ -326,6 +338,23 @@ func (j *RuntimeJSONOutputter) Complete() error {
 	return nil
 }
 
+// templatedOutfile renders --output-template for the current artifact. The
+// tenant ID from output metadata is preferred once results have arrived.
+func (j *RuntimeJSONOutputter) templatedOutfile() (string, bool) {
+	return j.templatedOutfileFor(j.artifact)
+}
+
+func (j *RuntimeJSONOutputter) templatedOutfileFor(artifact string) (string, bool) {
+	fields := outputNameFields(j, artifact)
+	if tenantID := j.extractTenantFromMetadata(); tenantID != "" {
+		fields.Tenant = tenantID
+		if fields.Provider == "" {
+			fields.Provider = "azure"
+		}
+	}
+	return templatedOutputPath(j, fields)
+}
+
 // generateContextualFilename creates a filename with appropriate context to avoid overwrites
 func (j *RuntimeJSONOutputter) generateContextualFilename() string {
 	timestamp := time.Now().Format("20060102-150405")
@@ -408,6 +437,11 @@ func (j *RuntimeJSONOutputter) generateAWSFilename(moduleName string) string {
 
 // getAWSAccountFromCache retrieves the AWS account ID from the cached authentication data
 func (j *RuntimeJSONOutputter) getAWSAccountFromCache(profile string) string {
+	return awsAccountFromCache(profile)
+}
+
+// awsAccountFromCache looks up the account ID in the ProfileIdentity cache populated during authentication
+func awsAccountFromCache(profile string) string {
 	// Access the same ProfileIdentity cache used during authentication
 	slog.Debug("looking up profile in cache", "profile", profile)
 	if value, ok := helpers.ProfileIdentity.Load(profile); ok {
@@ -544,5 +578,6 @@ func (j *RuntimeJSONOutputter) Params() []cfg.Param {
 		cfg.NewParam[int]("indent", "the number of spaces to use for the JSON indentation").WithDefault(0),
 		cfg.NewParam[string]("module-name", "the name of the module for dynamic file naming"),
 		options.OutputDir(),
+		options.OutputTemplate(),
 	}
 }
@@ -65,6 +65,9 @@ func (j *SecurityFindingsJSONOutputter) Initialize() error {
 	}
 
 	j.outfile = outfile
+	if outfile, ok := templatedOutputPath(j, outputNameFields(j, "security-findings.json")); ok {
+		j.outfile = outfile
+	}
 
 	// Ensure output directory exists early to prevent runtime errors
 	if err := j.EnsureOutputPath(j.outfile); err != nil {
@@ -93,7 +96,9 @@ func (j *SecurityFindingsJSONOutputter) Output(val any) error {
 // Complete writes all stored security findings to the specified file
 func (j *SecurityFindingsJSONOutputter) Complete() error {
 	// Update filename at completion if needed
-	if filepath.Base(j.outfile) == defaultSecurityOutfile || strings.Contains(j.outfile, "security-findings") {
+	if outfile, ok := templatedOutputPath(j, outputNameFields(j, "security-findings.json")); ok {
+		j.outfile = outfile
+	} else if filepath.Base(j.outfile) == defaultSecurityOutfile || strings.Contains(j.outfile, "security-findings") {
 		outputDir, err := cfg.As[string](j.Arg("output"))
 		if err != nil {
 			outputDir = "nebula-output"
@@ -189,5 +194,6 @@ func (j *SecurityFindingsJSONOutputter) Params() []cfg.Param {
 		cfg.NewParam[int]("indent", "the number of spaces to use for JSON indentation").WithDefault(2),
 		cfg.NewParam[string]("module-name", "the name of the security module for dynamic file naming"),
 		options.OutputDir(),
+		options.OutputTemplate(),
 	}
 }
\ No newline at end of file
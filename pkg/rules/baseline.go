@@ -0,0 +1,153 @@
+package rules
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Finding keys that identify what a finding is about. A fingerprint joins
+// every key present, in this order, so findings that differ only in their
+// description or severity keep the same fingerprint across scans.
+var (
+	fingerprintResourceKeys = []string{
+		"resourceId", "nsgId", "ruleId", "vaultId", "appId", "groupId",
+		"assignmentId", "policyId", "roleTemplateId", "roleDefinitionId",
+		"scope", "method",
+	}
+	fingerprintPrincipalKeys = []string{
+		"principalId", "identityPrincipalId", "ownerId", "principalArn",
+	}
+)
+
+// Baseline statuses of a finding compared against a baseline
+const (
+	BaselineNew      = "new"
+	BaselineExisting = "existing"
+	BaselineResolved = "resolved"
+)
+
+// Fingerprint returns a stable identifier for a finding, a hash of its type,
+// the resource it concerns and the principal involved. A finding that already
+// carries a "fingerprint" keeps it, so rules with unusual keys can set their own.
+func Fingerprint(f Finding) string {
+	if fingerprint, ok := f["fingerprint"].(string); ok && fingerprint != "" {
+		return fingerprint
+	}
+	parts := []string{fmt.Sprint(f["type"])}
+	for _, keys := range [][]string{fingerprintResourceKeys, fingerprintPrincipalKeys} {
+		var values []string
+		for _, key := range keys {
+			if value, ok := f[key]; ok && value != nil && fmt.Sprint(value) != "" {
+				values = append(values, key+"="+strings.ToLower(fmt.Sprint(value)))
+			}
+		}
+		parts = append(parts, strings.Join(values, ","))
+	}
+	sum := sha256.Sum256([]byte(strings.Join(parts, "\x00")))
+	return hex.EncodeToString(sum[:16])
+}
+
+// BaselineEntry is an accepted finding recorded in a baseline file
+type BaselineEntry struct {
+	Fingerprint string `json:"fingerprint"`
+	Rule        string `json:"rule,omitempty"`
+	Type        string `json:"type"`
+	Severity    string `json:"severity,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// Baseline is a set of accepted findings that later scans are compared against
+type Baseline struct {
+	Findings []BaselineEntry `json:"findings"`
+}
+
+// NewBaseline records findings as a baseline, one entry per fingerprint
+func NewBaseline(findings []Finding) *Baseline {
+	seen := make(map[string]bool)
+	baseline := &Baseline{Findings: []BaselineEntry{}}
+	for _, f := range findings {
+		fingerprint := Fingerprint(f)
+		if seen[fingerprint] {
+			continue
+		}
+		seen[fingerprint] = true
+		baseline.Findings = append(baseline.Findings, baselineEntry(f, fingerprint))
+	}
+	sort.Slice(baseline.Findings, func(i, j int) bool {
+		return baseline.Findings[i].Fingerprint < baseline.Findings[j].Fingerprint
+	})
+	return baseline
+}
+
+func baselineEntry(f Finding, fingerprint string) BaselineEntry {
+	entry := BaselineEntry{Fingerprint: fingerprint}
+	entry.Rule, _ = f["rule"].(string)
+	entry.Type, _ = f["type"].(string)
+	entry.Severity, _ = f["severity"].(string)
+	entry.Description, _ = f["description"].(string)
+	return entry
+}
+
+// LoadBaseline reads a baseline file written by WriteBaseline
+func LoadBaseline(path string) (*Baseline, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read baseline file %s: %w", path, err)
+	}
+	var baseline Baseline
+	if err := json.Unmarshal(data, &baseline); err != nil {
+		return nil, fmt.Errorf("failed to parse baseline file %s: %w", path, err)
+	}
+	return &baseline, nil
+}
+
+// WriteBaseline writes a baseline file
+func WriteBaseline(path string, baseline *Baseline) error {
+	data, err := json.MarshalIndent(baseline, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to write baseline file %s: %w", path, err)
+	}
+	return nil
+}
+
+// BaselineComparison classifies the findings of a scan against a baseline
+type BaselineComparison struct {
+	New      []Finding
+	Existing []Finding
+	Resolved []BaselineEntry
+}
+
+// Compare classifies each current finding as new or existing and lists the
+// baseline entries no current finding matches as resolved
+func (b *Baseline) Compare(findings []Finding) BaselineComparison {
+	accepted := make(map[string]BaselineEntry, len(b.Findings))
+	for _, entry := range b.Findings {
+		accepted[entry.Fingerprint] = entry
+	}
+
+	comparison := BaselineComparison{Resolved: []BaselineEntry{}}
+	current := make(map[string]bool, len(findings))
+	for _, f := range findings {
+		fingerprint := Fingerprint(f)
+		current[fingerprint] = true
+		if _, ok := accepted[fingerprint]; ok {
+			comparison.Existing = append(comparison.Existing, f)
+		} else {
+			comparison.New = append(comparison.New, f)
+		}
+	}
+	for _, entry := range b.Findings {
+		if !current[entry.Fingerprint] {
+			comparison.Resolved = append(comparison.Resolved, entry)
+		}
+	}
+	return comparison
+}
@@ -0,0 +1,53 @@
+package rules
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFingerprint(t *testing.T) {
+	f := Finding{"type": "NSGManagementPortExposed", "nsgId": "/subscriptions/A/nsg", "ruleId": "rule-1", "severity": "High", "description": "old"}
+	same := Finding{"type": "NSGManagementPortExposed", "nsgId": "/SUBSCRIPTIONS/a/NSG", "ruleId": "rule-1", "severity": "Low", "description": "new"}
+	assert.Equal(t, Fingerprint(f), Fingerprint(same), "case, severity and description do not change the fingerprint")
+
+	other := Finding{"type": "NSGManagementPortExposed", "nsgId": "/subscriptions/A/nsg", "ruleId": "rule-2"}
+	assert.NotEqual(t, Fingerprint(f), Fingerprint(other))
+
+	principal := Finding{"type": "Role", "resourceId": "x", "principalId": "p1"}
+	resource := Finding{"type": "Role", "resourceId": "x,principalId=p1"}
+	assert.NotEqual(t, Fingerprint(principal), Fingerprint(resource), "resource and principal keys are hashed apart")
+
+	assert.Equal(t, "preset", Fingerprint(Finding{"type": "Custom", "fingerprint": "preset"}))
+}
+
+func TestBaselineCompare(t *testing.T) {
+	accepted := Finding{"type": "A", "resourceId": "r1", "rule": "rule-a", "description": "accepted"}
+	fixed := Finding{"type": "A", "resourceId": "r2", "rule": "rule-a", "description": "fixed"}
+	baseline := NewBaseline([]Finding{accepted, fixed, accepted})
+	require.Len(t, baseline.Findings, 2, "duplicate fingerprints are recorded once")
+
+	introduced := Finding{"type": "B", "resourceId": "r3"}
+	comparison := baseline.Compare([]Finding{{"type": "A", "resourceId": "R1", "description": "reworded"}, introduced})
+	require.Len(t, comparison.Existing, 1)
+	assert.Equal(t, "reworded", comparison.Existing[0]["description"])
+	assert.Equal(t, []Finding{introduced}, comparison.New)
+	require.Len(t, comparison.Resolved, 1)
+	assert.Equal(t, "fixed", comparison.Resolved[0].Description)
+	assert.Equal(t, "rule-a", comparison.Resolved[0].Rule)
+}
+
+func TestBaselineRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "baseline.json")
+	baseline := NewBaseline([]Finding{{"type": "A", "resourceId": "r1", "severity": "High"}})
+	require.NoError(t, WriteBaseline(path, baseline))
+
+	loaded, err := LoadBaseline(path)
+	require.NoError(t, err)
+	assert.Equal(t, baseline, loaded)
+
+	_, err = LoadBaseline(filepath.Join(t.TempDir(), "missing.json"))
+	assert.ErrorContains(t, err, "failed to read baseline file")
+}
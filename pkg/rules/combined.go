@@ -0,0 +1,104 @@
+package rules
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Severities are the finding severities, most severe first
+var Severities = []string{"Critical", "High", "Medium", "Low"}
+
+// unratedSeverity is the band of findings whose severity is not one of Severities
+const unratedSeverity = "Unrated"
+
+// SeverityRank orders severities for sorting: Critical is 4, Low is 1 and
+// anything else 0. Matching is case-insensitive.
+func SeverityRank(severity interface{}) int {
+	for i, s := range Severities {
+		if strings.EqualFold(fmt.Sprint(severity), s) {
+			return len(Severities) - i
+		}
+	}
+	return 0
+}
+
+// SeverityBand holds the findings of one severity
+type SeverityBand struct {
+	Severity string    `json:"severity"`
+	Count    int       `json:"count"`
+	Findings []Finding `json:"findings"`
+}
+
+// CombinedReport merges the findings of several providers into one
+// deliverable, banded by severity. Every finding carries a "provider" key.
+type CombinedReport struct {
+	Providers         map[string]int `json:"providers"`
+	TotalFindings     int            `json:"total_findings"`
+	DuplicatesRemoved int            `json:"duplicates_removed"`
+	Bands             []SeverityBand `json:"severity_bands"`
+}
+
+// Combine merges findings keyed by provider. Findings of one provider with the
+// same fingerprint are reported once, keeping the most severe. Within a band,
+// findings are ordered by provider, type and description. Every standard
+// severity has a band, even when empty; findings with any other severity are
+// gathered in a trailing Unrated band.
+func Combine(byProvider map[string][]Finding) *CombinedReport {
+	report := &CombinedReport{Providers: make(map[string]int)}
+
+	merged := make(map[string]Finding)
+	for provider, findings := range byProvider {
+		report.Providers[provider] = 0
+		for _, f := range findings {
+			finding := make(Finding, len(f)+2)
+			for key, value := range f {
+				finding[key] = value
+			}
+			finding["provider"] = provider
+			finding["fingerprint"] = Fingerprint(f)
+
+			key := provider + "/" + finding["fingerprint"].(string)
+			if existing, ok := merged[key]; ok {
+				report.DuplicatesRemoved++
+				if SeverityRank(existing["severity"]) >= SeverityRank(finding["severity"]) {
+					continue
+				}
+			} else {
+				report.Providers[provider]++
+			}
+			merged[key] = finding
+		}
+	}
+
+	byBand := make(map[string][]Finding)
+	for _, finding := range merged {
+		band := unratedSeverity
+		if rank := SeverityRank(finding["severity"]); rank > 0 {
+			band = Severities[len(Severities)-rank]
+		}
+		byBand[band] = append(byBand[band], finding)
+	}
+
+	for _, severity := range append(append([]string{}, Severities...), unratedSeverity) {
+		findings := byBand[severity]
+		if severity == unratedSeverity && len(findings) == 0 {
+			continue
+		}
+		sort.Slice(findings, func(i, j int) bool {
+			a, b := findings[i], findings[j]
+			for _, key := range []string{"provider", "type", "description", "fingerprint"} {
+				if valueA, valueB := fmt.Sprint(a[key]), fmt.Sprint(b[key]); valueA != valueB {
+					return valueA < valueB
+				}
+			}
+			return false
+		})
+		if findings == nil {
+			findings = []Finding{}
+		}
+		report.Bands = append(report.Bands, SeverityBand{Severity: severity, Count: len(findings), Findings: findings})
+		report.TotalFindings += len(findings)
+	}
+	return report
+}
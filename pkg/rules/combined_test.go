@@ -0,0 +1,47 @@
+package rules
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCombine(t *testing.T) {
+	report := Combine(map[string][]Finding{
+		"aws": {
+			{"type": "EffectiveAdmin", "principalArn": "arn:aws:iam::1:user/a", "severity": "High", "description": "admin a"},
+			{"type": "EffectiveAdmin", "principalArn": "arn:aws:iam::1:user/A", "severity": "Critical", "description": "admin a again"},
+			{"type": "AdminRoleAssumable", "resourceId": "arn:aws:iam::1:role/admin", "severity": "Medium", "description": "role"},
+		},
+		"azure": {
+			{"type": "TenantRootRBAC", "principalId": "p1", "severity": "high", "description": "root owner"},
+			{"type": "Custom", "resourceId": "x", "severity": "Informational", "description": "note"},
+		},
+	})
+
+	assert.Equal(t, map[string]int{"aws": 2, "azure": 2}, report.Providers)
+	assert.Equal(t, 4, report.TotalFindings)
+	assert.Equal(t, 1, report.DuplicatesRemoved)
+
+	var severities []string
+	for _, band := range report.Bands {
+		severities = append(severities, band.Severity)
+		assert.Len(t, band.Findings, band.Count)
+	}
+	assert.Equal(t, []string{"Critical", "High", "Medium", "Low", "Unrated"}, severities)
+
+	require.Len(t, report.Bands[0].Findings, 1)
+	assert.Equal(t, "admin a again", report.Bands[0].Findings[0]["description"], "the most severe duplicate is kept")
+	assert.Equal(t, "aws", report.Bands[0].Findings[0]["provider"])
+	assert.NotEmpty(t, report.Bands[0].Findings[0]["fingerprint"])
+	assert.Equal(t, "azure", report.Bands[1].Findings[0]["provider"], "severity matching ignores case")
+	assert.Empty(t, report.Bands[3].Findings)
+	assert.Equal(t, "note", report.Bands[4].Findings[0]["description"])
+}
+
+func TestSeverityRank(t *testing.T) {
+	assert.Greater(t, SeverityRank("Critical"), SeverityRank("high"))
+	assert.Greater(t, SeverityRank("Low"), SeverityRank("Informational"))
+	assert.Equal(t, 0, SeverityRank(nil))
+}
@@ -0,0 +1,109 @@
+package rules
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Finding is a single detection result. Rules populate at least "type",
+// "severity" and "description"; everything else is rule specific.
+type Finding map[string]interface{}
+
+// Rule is a detection that evaluates a collected dataset. The dataset type is
+// provider specific, e.g. *iam.ConsolidatedOutput for Azure IAM rules, and a
+// rule returns no findings for a dataset it does not understand.
+type Rule interface {
+	Name() string
+	Provider() string
+	Severity() string
+	Evaluate(dataset interface{}) []Finding
+}
+
+type RuleRegistry struct {
+	mu    sync.RWMutex
+	rules map[string]Rule // provider/name -> rule
+}
+
+var Registry = &RuleRegistry{
+	rules: make(map[string]Rule),
+}
+
+// Register adds a rule to the registry. Rules register themselves from init(),
+// so a duplicate provider/name pair is a programming error and panics.
+func Register(rule Rule) {
+	Registry.mu.Lock()
+	defer Registry.mu.Unlock()
+
+	key := rule.Provider() + "/" + rule.Name()
+	if _, exists := Registry.rules[key]; exists {
+		panic(fmt.Sprintf("rules: %s is already registered", key))
+	}
+	Registry.rules[key] = rule
+}
+
+// GetRules returns every rule registered for a provider, ordered by name
+func GetRules(provider string) []Rule {
+	Registry.mu.RLock()
+	defer Registry.mu.RUnlock()
+
+	var rules []Rule
+	for _, rule := range Registry.rules {
+		if rule.Provider() == provider {
+			rules = append(rules, rule)
+		}
+	}
+	sort.Slice(rules, func(i, j int) bool { return rules[i].Name() < rules[j].Name() })
+	return rules
+}
+
+// GetRuleNames returns the names of every rule registered for a provider
+func GetRuleNames(provider string) []string {
+	var names []string
+	for _, rule := range GetRules(provider) {
+		names = append(names, rule.Name())
+	}
+	return names
+}
+
+// Select resolves a --rules selector to the provider's rules to run. Each
+// entry is a rule name, "severity:<level>" for every rule of that severity, or
+// "all". An empty selector selects every rule.
+func Select(provider string, selectors []string) ([]Rule, error) {
+	available := GetRules(provider)
+	if len(selectors) == 0 {
+		return available, nil
+	}
+
+	selected := make(map[string]bool)
+	for _, selector := range selectors {
+		selector = strings.TrimSpace(selector)
+		if selector == "" {
+			continue
+		}
+		if strings.EqualFold(selector, "all") {
+			return available, nil
+		}
+
+		matched := false
+		severity, bySeverity := strings.CutPrefix(strings.ToLower(selector), "severity:")
+		for _, rule := range available {
+			if (bySeverity && strings.EqualFold(rule.Severity(), severity)) || (!bySeverity && rule.Name() == selector) {
+				selected[rule.Name()] = true
+				matched = true
+			}
+		}
+		if !matched && !bySeverity {
+			return nil, fmt.Errorf("unknown %s rule %q, expected all, severity:<level>, or one of: %s", provider, selector, strings.Join(GetRuleNames(provider), ", "))
+		}
+	}
+
+	var rules []Rule
+	for _, rule := range available {
+		if selected[rule.Name()] {
+			rules = append(rules, rule)
+		}
+	}
+	return rules, nil
+}
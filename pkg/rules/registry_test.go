@@ -0,0 +1,66 @@
+package rules
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testRule struct {
+	name     string
+	severity string
+}
+
+func (r testRule) Name() string     { return r.name }
+func (r testRule) Provider() string { return "test" }
+func (r testRule) Severity() string { return r.severity }
+func (r testRule) Evaluate(dataset interface{}) []Finding {
+	return []Finding{{"type": r.name}}
+}
+
+func init() {
+	Register(testRule{name: "b-medium", severity: "Medium"})
+	Register(testRule{name: "a-high", severity: "High"})
+	Register(testRule{name: "c-high", severity: "High"})
+}
+
+func ruleNames(rules []Rule) []string {
+	var names []string
+	for _, rule := range rules {
+		names = append(names, rule.Name())
+	}
+	return names
+}
+
+func TestSelect(t *testing.T) {
+	tests := []struct {
+		name      string
+		selectors []string
+		want      []string
+	}{
+		{"empty selects every rule", nil, []string{"a-high", "b-medium", "c-high"}},
+		{"all", []string{"all"}, []string{"a-high", "b-medium", "c-high"}},
+		{"by name", []string{"c-high", "b-medium"}, []string{"b-medium", "c-high"}},
+		{"by severity", []string{"severity:high"}, []string{"a-high", "c-high"}},
+		{"severity and name overlap", []string{"severity:High", "a-high"}, []string{"a-high", "c-high"}},
+		{"no rules of a severity", []string{"severity:low"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			selected, err := Select("test", tt.selectors)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, ruleNames(selected))
+		})
+	}
+}
+
+func TestSelectUnknownRule(t *testing.T) {
+	_, err := Select("test", []string{"a-high", "missing"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "a-high, b-medium, c-high")
+}
+
+func TestRegisterDuplicatePanics(t *testing.T) {
+	assert.Panics(t, func() { Register(testRule{name: "a-high"}) })
+}
@@ -0,0 +1,56 @@
+package templates
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// loadTemplateByID is a test helper that loads an embedded template by ID
+func loadTemplateByID(t *testing.T, id string) *ARGQueryTemplate {
+	t.Helper()
+	loader, err := NewTemplateLoader(LoadEmbedded)
+	require.NoError(t, err, "Template loader should initialize successfully")
+
+	for _, tmpl := range loader.GetTemplates() {
+		if tmpl.ID == id {
+			return tmpl
+		}
+	}
+	t.Fatalf("Should find %s template", id)
+	return nil
+}
+
+// TestAppServicePublicUnauthenticatedTemplate verifies the template metadata and query structure
+func TestAppServicePublicUnauthenticatedTemplate(t *testing.T) {
+	tmpl := loadTemplateByID(t, "app_service_public_unauthenticated")
+
+	assert.Equal(t, "Publicly Accessible App Service Without Authentication", tmpl.Name)
+	assert.Equal(t, "High", tmpl.Severity)
+	assert.Contains(t, tmpl.Category, "arg-scan")
+	assert.Contains(t, tmpl.Category, "Public Access")
+	assert.NotEmpty(t, tmpl.References)
+	assert.Contains(t, tmpl.TriageNotes, "authsettingsV2")
+
+	query := tmpl.Query
+	assert.Contains(t, query, "where type =~ 'microsoft.web/sites'")
+	assert.Contains(t, query, "where kind !contains 'functionapp'")
+	assert.Contains(t, query, "where publicNetworkAccess != 'disabled'")
+	assert.Contains(t, query, "where hasIpRestrictions == false")
+	assert.Contains(t, query, "endpoint = strcat('https://', defaultHostName)")
+	for _, field := range []string{"id,", "name,", "resourceGroup,", "defaultHostName,", "subscriptionId"} {
+		assert.Contains(t, query, field, "Query project clause should include "+field)
+	}
+}
+
+// TestACRAnonymousPullTemplateNetworkExposure verifies the ACR template reports network exposure and the registry endpoint
+func TestACRAnonymousPullTemplateNetworkExposure(t *testing.T) {
+	tmpl := loadTemplateByID(t, "acr_anonymous_pull_access")
+
+	query := tmpl.Query
+	assert.Contains(t, query, "where anonymousPullEnabled == true")
+	assert.Contains(t, query, "properties.networkRuleSet.defaultAction")
+	assert.Contains(t, query, "isNetworkExposed")
+	assert.Contains(t, query, "endpoint = strcat('https://', loginServer, '/v2/_catalog')")
+}
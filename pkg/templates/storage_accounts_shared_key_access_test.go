@@ -0,0 +1,47 @@
+package templates
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// loadStorageSharedKeyTemplate is a test helper that loads the storage_accounts_shared_key_access template
+func loadStorageSharedKeyTemplate(t *testing.T) *ARGQueryTemplate {
+	t.Helper()
+	loader, err := NewTemplateLoader(LoadEmbedded)
+	require.NoError(t, err, "Template loader should initialize successfully")
+
+	for _, tmpl := range loader.GetTemplates() {
+		if tmpl.ID == "storage_accounts_shared_key_access" {
+			return tmpl
+		}
+	}
+	t.Fatal("Should find storage_accounts_shared_key_access template")
+	return nil
+}
+
+// TestStorageSharedKeyTemplateYAMLParsing verifies the YAML template parses correctly
+func TestStorageSharedKeyTemplateYAMLParsing(t *testing.T) {
+	tmpl := loadStorageSharedKeyTemplate(t)
+
+	assert.Equal(t, "Storage Accounts with Shared Key Access Enabled", tmpl.Name)
+	assert.Equal(t, "Medium", tmpl.Severity)
+	assert.Contains(t, tmpl.Category, "arg-scan")
+	assert.NotEmpty(t, tmpl.References)
+	assert.Contains(t, tmpl.TriageNotes, "--allow-shared-key-access false", "Should recommend disabling shared key access")
+}
+
+// TestStorageSharedKeyTemplateQueryStructure verifies the query treats an unset property as enabled
+func TestStorageSharedKeyTemplateQueryStructure(t *testing.T) {
+	query := loadStorageSharedKeyTemplate(t).Query
+
+	assert.Contains(t, query, "where type =~ 'Microsoft.Storage/storageAccounts'")
+	assert.Contains(t, query, "coalesce(tobool(properties.allowSharedKeyAccess), true)", "A null allowSharedKeyAccess means shared key is allowed")
+	assert.Contains(t, query, "where allowSharedKeyAccess == true")
+	assert.Contains(t, query, "publiclyReachable = publicNetworkAccess !~ 'Disabled' and defaultAction =~ 'Allow'")
+	for _, field := range []string{"id,", "subscriptionId,", "publiclyReachable,", "defaultAction,"} {
+		assert.Contains(t, query, field, "Query project clause should include "+field)
+	}
+}
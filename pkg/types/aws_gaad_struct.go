@@ -1,5 +1,10 @@
 package types
 
+import (
+	"encoding/json"
+	"strings"
+)
+
 type Gaad struct {
 	UserDetailList  []UserDL     `json:"UserDetailList"`
 	RoleDetailList  []RoleDL     `json:"RoleDetailList"`
@@ -17,6 +22,26 @@ type ManagedPL struct {
 	PolicyArn  string `json:"PolicyArn"`
 }
 
+// UnmarshalJSON also reads the PermissionsBoundary form of a policy reference,
+// {"PermissionsBoundaryType": "Policy", "PermissionsBoundaryArn": "..."}, which
+// is how GetAccountAuthorizationDetails reports the boundary of a user or role
+func (m *ManagedPL) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		PolicyName             string `json:"PolicyName"`
+		PolicyArn              string `json:"PolicyArn"`
+		PermissionsBoundaryArn string `json:"PermissionsBoundaryArn"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	m.PolicyName, m.PolicyArn = raw.PolicyName, raw.PolicyArn
+	if m.PolicyArn == "" && raw.PermissionsBoundaryArn != "" {
+		m.PolicyArn = raw.PermissionsBoundaryArn
+		m.PolicyName = raw.PermissionsBoundaryArn[strings.LastIndex(raw.PermissionsBoundaryArn, "/")+1:]
+	}
+	return nil
+}
+
 type UserDL struct {
 	Arn                     string        `json:"Arn"`
 	UserName                string        `json:"UserName"`
@@ -0,0 +1,72 @@
+package types
+
+// IdentityCenter is an export of an IAM Identity Center instance: its
+// permission sets, the accounts and principals they are assigned to, and the
+// identity store users and groups those assignments name. Field names follow
+// the sso-admin and identitystore API responses so the export can be built
+// from their output directly.
+type IdentityCenter struct {
+	InstanceArn        string                            `json:"InstanceArn"`
+	IdentityStoreId    string                            `json:"IdentityStoreId"`
+	PermissionSets     []IdentityCenterPermissionSet     `json:"PermissionSets"`
+	AccountAssignments []IdentityCenterAccountAssignment `json:"AccountAssignments"`
+	Users              []IdentityStoreUser               `json:"Users"`
+	Groups             []IdentityStoreGroup              `json:"Groups"`
+	GroupMemberships   []IdentityStoreGroupMembership    `json:"GroupMemberships"`
+}
+
+type IdentityCenterPermissionSet struct {
+	PermissionSetArn string `json:"PermissionSetArn"`
+	Name             string `json:"Name"`
+	Description      string `json:"Description"`
+	SessionDuration  string `json:"SessionDuration"`
+	// InlinePolicy is the policy document as returned by
+	// GetInlinePolicyForPermissionSet, a JSON string
+	InlinePolicy                    string                                `json:"InlinePolicy"`
+	AttachedManagedPolicies         []IdentityCenterManagedPolicy         `json:"AttachedManagedPolicies"`
+	CustomerManagedPolicyReferences []IdentityCenterCustomerManagedPolicy `json:"CustomerManagedPolicyReferences"`
+	PermissionsBoundary             *IdentityCenterPermissionsBoundary    `json:"PermissionsBoundary"`
+}
+
+type IdentityCenterManagedPolicy struct {
+	Arn  string `json:"Arn"`
+	Name string `json:"Name"`
+}
+
+// IdentityCenterCustomerManagedPolicy names a customer managed policy that
+// must exist, under the same name and path, in every account the permission
+// set is provisioned to
+type IdentityCenterCustomerManagedPolicy struct {
+	Name string `json:"Name"`
+	Path string `json:"Path"`
+}
+
+type IdentityCenterPermissionsBoundary struct {
+	CustomerManagedPolicyReference *IdentityCenterCustomerManagedPolicy `json:"CustomerManagedPolicyReference"`
+	ManagedPolicyArn               string                               `json:"ManagedPolicyArn"`
+}
+
+type IdentityCenterAccountAssignment struct {
+	AccountId        string `json:"AccountId"`
+	PermissionSetArn string `json:"PermissionSetArn"`
+	PrincipalType    string `json:"PrincipalType"` // USER or GROUP
+	PrincipalId      string `json:"PrincipalId"`
+}
+
+type IdentityStoreUser struct {
+	UserId      string `json:"UserId"`
+	UserName    string `json:"UserName"`
+	DisplayName string `json:"DisplayName"`
+}
+
+type IdentityStoreGroup struct {
+	GroupId     string `json:"GroupId"`
+	DisplayName string `json:"DisplayName"`
+}
+
+type IdentityStoreGroupMembership struct {
+	GroupId  string `json:"GroupId"`
+	MemberId struct {
+		UserId string `json:"UserId"`
+	} `json:"MemberId"`
+}
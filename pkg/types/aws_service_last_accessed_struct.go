@@ -0,0 +1,28 @@
+package types
+
+// ServiceLastAccessedDetails is the GetServiceLastAccessedDetails response for
+// one principal, as returned by `aws iam get-service-last-accessed-details`.
+// It lists every service the principal's policies allow and when the principal
+// last authenticated to it.
+type ServiceLastAccessedDetails struct {
+	JobStatus            string                `json:"JobStatus"`
+	JobType              string                `json:"JobType"`
+	JobCompletionDate    string                `json:"JobCompletionDate"`
+	ServicesLastAccessed []ServiceLastAccessed `json:"ServicesLastAccessed"`
+	IsTruncated          bool                  `json:"IsTruncated"`
+}
+
+type ServiceLastAccessed struct {
+	ServiceName      string `json:"ServiceName"`
+	ServiceNamespace string `json:"ServiceNamespace"`
+	// LastAuthenticated is empty for services the principal has not used
+	// within the IAM tracking period
+	LastAuthenticated          string `json:"LastAuthenticated,omitempty"`
+	LastAuthenticatedEntity    string `json:"LastAuthenticatedEntity,omitempty"`
+	LastAuthenticatedRegion    string `json:"LastAuthenticatedRegion,omitempty"`
+	TotalAuthenticatedEntities int    `json:"TotalAuthenticatedEntities"`
+}
+
+// ServiceLastAccessedReport maps principal ARNs to their service last accessed
+// details, one GenerateServiceLastAccessedDetails job per principal
+type ServiceLastAccessedReport map[string]*ServiceLastAccessedDetails
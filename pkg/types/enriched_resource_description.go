@@ -23,7 +23,7 @@ type EnrichedResourceDescription struct {
 
 func (e *EnrichedResourceDescription) ToArn() arn.ARN {
 	a := arn.ARN{
-		Partition: "aws",
+		Partition: PartitionForRegion(e.Region),
 		Service:   e.Service(),
 		Region:    e.Region,
 		AccountID: e.AccountId,
@@ -44,7 +44,7 @@ func NewEnrichedResourceDescription(identifier, typeName, region, accountId stri
 		}
 	case "AWS::EC2::Instance":
 		a = arn.ARN{
-			Partition: "aws",
+			Partition: PartitionForRegion(region),
 			Service:   "ec2",
 			Region:    region,
 			AccountID: accountId,
@@ -52,7 +52,7 @@ func NewEnrichedResourceDescription(identifier, typeName, region, accountId stri
 		}
 	case "AWS::S3::Bucket":
 		a = arn.ARN{
-			Partition: "aws",
+			Partition: PartitionForRegion(region),
 			Service:   "s3",
 			Region:    "",
 			AccountID: "",
@@ -64,7 +64,7 @@ func NewEnrichedResourceDescription(identifier, typeName, region, accountId stri
 			a = parsed
 		} else {
 			a = arn.ARN{
-				Partition: "aws",
+				Partition: PartitionForRegion(region),
 				Service:   "lambda",
 				Region:    region,
 				AccountID: accountId,
@@ -73,7 +73,7 @@ func NewEnrichedResourceDescription(identifier, typeName, region, accountId stri
 		}
 	case "AWS::Service":
 		a = arn.ARN{
-			Partition: "aws",
+			Partition: PartitionForRegion(region),
 			Service:   strings.Split(identifier, ".")[0],
 			Region:    "*",
 			AccountID: "*",
@@ -86,7 +86,7 @@ func NewEnrichedResourceDescription(identifier, typeName, region, accountId stri
 		} else {
 			serviceName := extractServiceFromTypeName(typeName)
 			a = arn.ARN{
-				Partition: "aws",
+				Partition: PartitionForRegion(region),
 				Service:   serviceName,
 				Region:    region,
 				AccountID: accountId,
@@ -255,24 +255,28 @@ func (e *EnrichedResourceDescription) Type() string {
 }
 
 func SQSUrlToArn(sqsUrl string) (arn.ARN, error) {
-	// Format: https://sqs.{region}.amazonaws.com/{accountId}/{queueName}
-	parts := strings.Split(sqsUrl, ".")
-	if len(parts) < 4 || !strings.HasPrefix(sqsUrl, "https://sqs.") {
+	// Format: https://sqs.{region}.amazonaws.com[.cn]/{accountId}/{queueName}
+	if !strings.HasPrefix(sqsUrl, "https://sqs.") {
+		return arn.ARN{}, fmt.Errorf("invalid SQS URL format: %s", sqsUrl)
+	}
+	host, path, _ := strings.Cut(strings.TrimPrefix(sqsUrl, "https://"), "/")
+	hostParts := strings.Split(host, ".")
+	if len(hostParts) < 4 {
 		return arn.ARN{}, fmt.Errorf("invalid SQS URL format: %s", sqsUrl)
 	}
 
-	region := parts[1]
+	region := hostParts[1]
 
-	pathParts := strings.Split(parts[3], "/")
-	if len(pathParts) < 3 {
+	pathParts := strings.Split(path, "/")
+	if len(pathParts) < 2 {
 		return arn.ARN{}, fmt.Errorf("invalid SQS URL path format: %s", sqsUrl)
 	}
 
-	accountId := pathParts[1]
-	queueName := pathParts[2]
+	accountId := pathParts[0]
+	queueName := pathParts[1]
 
 	a := arn.ARN{
-		Partition: "aws",
+		Partition: PartitionForRegion(region),
 		Service:   "sqs",
 		Region:    region,
 		AccountID: accountId,
@@ -282,6 +286,18 @@ func SQSUrlToArn(sqsUrl string) (arn.ARN, error) {
 	return a, nil
 }
 
+// PartitionForRegion returns the ARN partition a region belongs to. Global
+// and unknown regions are treated as the commercial partition.
+func PartitionForRegion(region string) string {
+	switch {
+	case strings.HasPrefix(region, "cn-"):
+		return "aws-cn"
+	case strings.HasPrefix(region, "us-gov-"):
+		return "aws-us-gov"
+	}
+	return "aws"
+}
+
 func (erd *EnrichedResourceDescription) PropertiesAsMap() (map[string]any, error) {
 	rawProps, ok := erd.Properties.(string)
 	if !ok {
@@ -178,6 +178,20 @@ func TestNewEnrichedResourceDescriptionFromSQS(t *testing.T) {
 	assert.Equal(t, "arn:aws:sqs:us-east-2:411435703965:ChariotTest", erd.Arn.String())
 }
 
+func TestPartitionAwareArns(t *testing.T) {
+	sqs, err := SQSUrlToArn("https://sqs.cn-north-1.amazonaws.com.cn/411435703965/ChariotTest")
+	assert.NoError(t, err)
+	assert.Equal(t, "arn:aws-cn:sqs:cn-north-1:411435703965:ChariotTest", sqs.String())
+
+	instance := NewEnrichedResourceDescription("i-0abc", "AWS::EC2::Instance", "us-gov-west-1", "411435703965", map[string]any{})
+	assert.Equal(t, "arn:aws-us-gov:ec2:us-gov-west-1:411435703965:instance/i-0abc", instance.Arn.String())
+
+	assert.Equal(t, "aws", PartitionForRegion("us-east-1"))
+	assert.Equal(t, "aws", PartitionForRegion(""))
+	assert.Equal(t, "aws-cn", PartitionForRegion("cn-northwest-1"))
+	assert.Equal(t, "aws-us-gov", PartitionForRegion("us-gov-east-1"))
+}
+
 func TestNewEnrichedResourceDescription(t *testing.T) {
 	tests := []struct {
 		name       string
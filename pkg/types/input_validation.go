@@ -0,0 +1,108 @@
+package types
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrMalformedGaad is returned for GAAD input that decodes as JSON but does not
+// look like get-account-authorization-details output. Unmarshaling ignores
+// unknown keys, so without this check a wrong file becomes an empty GAAD and
+// the analysis reports a clean account.
+var ErrMalformedGaad = errors.New("GAAD appears empty or malformed — did you pass the right file?")
+
+// ErrMalformedResourcePolicies is returned for a resource policies file whose
+// entries are not resource ARNs mapped to policy documents
+var ErrMalformedResourcePolicies = errors.New("resource policies appear malformed — did you pass the right file?")
+
+// gaadLists are the top-level arrays of get-account-authorization-details
+// output. --filter leaves some out, but a GAAD always has at least one.
+var gaadLists = []string{"UserDetailList", "GroupDetailList", "RoleDetailList", "Policies"}
+
+// DecodeGaad parses GAAD input, either the get-account-authorization-details
+// object or the single-element array the account-auth-details module writes,
+// and validates its shape
+func DecodeGaad(data []byte) (*Gaad, error) {
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '[' {
+		var documents []json.RawMessage
+		if err := json.Unmarshal(data, &documents); err != nil {
+			return nil, err
+		}
+		if len(documents) == 0 {
+			return nil, fmt.Errorf("%w: the file is an empty array", ErrMalformedGaad)
+		}
+		data = documents[0]
+	}
+
+	var keys map[string]json.RawMessage
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return nil, err
+	}
+	found := false
+	for _, list := range gaadLists {
+		if _, ok := keys[list]; ok {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("%w: none of %s is present", ErrMalformedGaad, strings.Join(gaadLists, ", "))
+	}
+
+	var gaad Gaad
+	if err := json.Unmarshal(data, &gaad); err != nil {
+		return nil, err
+	}
+	if err := gaad.Validate(); err != nil {
+		return nil, err
+	}
+	return &gaad, nil
+}
+
+// Validate checks that a GAAD has content and that every principal and policy
+// in it carries an ARN, which every analysis keys on
+func (g *Gaad) Validate() error {
+	if len(g.UserDetailList) == 0 && len(g.GroupDetailList) == 0 && len(g.RoleDetailList) == 0 && len(g.Policies) == 0 {
+		return fmt.Errorf("%w: it has no users, groups, roles or policies", ErrMalformedGaad)
+	}
+	for i, user := range g.UserDetailList {
+		if user.Arn == "" {
+			return fmt.Errorf("%w: UserDetailList[%d] has no Arn", ErrMalformedGaad, i)
+		}
+	}
+	for i, group := range g.GroupDetailList {
+		if group.Arn == "" {
+			return fmt.Errorf("%w: GroupDetailList[%d] has no Arn", ErrMalformedGaad, i)
+		}
+	}
+	for i, role := range g.RoleDetailList {
+		if role.Arn == "" {
+			return fmt.Errorf("%w: RoleDetailList[%d] has no Arn", ErrMalformedGaad, i)
+		}
+	}
+	for i, policy := range g.Policies {
+		if policy.Arn == "" {
+			return fmt.Errorf("%w: Policies[%d] has no Arn", ErrMalformedGaad, i)
+		}
+	}
+	return nil
+}
+
+// ValidateResourcePolicies checks that every entry maps a resource ARN to a
+// policy with statements. An empty map is valid: the account may have no
+// resource policies.
+func ValidateResourcePolicies(policies map[string]*Policy) error {
+	for resourceArn, policy := range policies {
+		if !strings.HasPrefix(resourceArn, "arn:") {
+			return fmt.Errorf("%w: key %q is not a resource ARN", ErrMalformedResourcePolicies, resourceArn)
+		}
+		if policy == nil || policy.Statement == nil || len(*policy.Statement) == 0 {
+			return fmt.Errorf("%w: the policy of %s has no Statement", ErrMalformedResourcePolicies, resourceArn)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeGaad(t *testing.T) {
+	const role = `{"RoleName": "admin", "Arn": "arn:aws:iam::111122223333:role/admin"}`
+
+	testCases := []struct {
+		name      string
+		input     string
+		roles     int
+		malformed bool
+	}{
+		{name: "object", input: `{"UserDetailList": [], "RoleDetailList": [` + role + `], "GroupDetailList": [], "Policies": []}`, roles: 1},
+		{name: "module array output", input: `[{"RoleDetailList": [` + role + `]}]`, roles: 1},
+		{name: "empty array", input: `[]`, malformed: true},
+		{name: "no GAAD lists", input: `{"arn:aws:s3:::bucket": {"Version": "2012-10-17"}}`, malformed: true},
+		{name: "empty lists", input: `{"UserDetailList": [], "RoleDetailList": [], "GroupDetailList": [], "Policies": []}`, malformed: true},
+		{name: "entries without Arn", input: `{"RoleDetailList": [{"name": "admin"}]}`, malformed: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			gaad, err := DecodeGaad([]byte(tc.input))
+			if tc.malformed {
+				require.ErrorIs(t, err, ErrMalformedGaad)
+				return
+			}
+			require.NoError(t, err)
+			assert.Len(t, gaad.RoleDetailList, tc.roles)
+		})
+	}
+
+	_, err := DecodeGaad([]byte(`not json`))
+	require.Error(t, err)
+	assert.NotErrorIs(t, err, ErrMalformedGaad, "invalid JSON is reported as a parse error")
+}
+
+func TestValidateResourcePolicies(t *testing.T) {
+	policy, err := NewPolicyFromJSON([]byte(`{"Version": "2012-10-17", "Statement": [{"Effect": "Allow", "Principal": "*", "Action": "s3:GetObject", "Resource": "*"}]}`))
+	require.NoError(t, err)
+
+	assert.NoError(t, ValidateResourcePolicies(map[string]*Policy{}))
+	assert.NoError(t, ValidateResourcePolicies(map[string]*Policy{"arn:aws:s3:::bucket": policy}))
+	assert.ErrorIs(t, ValidateResourcePolicies(map[string]*Policy{"bucket": policy}), ErrMalformedResourcePolicies)
+	assert.ErrorIs(t, ValidateResourcePolicies(map[string]*Policy{"arn:aws:s3:::bucket": {Version: "2012-10-17"}}), ErrMalformedResourcePolicies)
+}
@@ -139,9 +139,27 @@ type ExternalTrustReport struct {
 	OtherExternalTrustRoles []ExternalTrustRole `json:"other_external_trust_roles"`
 }
 
+// RoleTakeover is a principal that can rewrite a role's trust policy to let
+// itself assume the role
+type RoleTakeover struct {
+	Principal          string `json:"principal"`
+	Role               string `json:"role"`
+	RoleIsAdmin        bool   `json:"role_is_admin"`
+	RoleIsPrivileged   bool   `json:"role_is_privileged"`
+	ReachableResources int    `json:"reachable_resources"`
+}
+
+// RoleTakeoverReport contains aggregated CAN_TAKEOVER_ROLE data
+type RoleTakeoverReport struct {
+	Total      int            `json:"total"`
+	AdminRoles int            `json:"admin_roles"`
+	Takeovers  []RoleTakeover `json:"takeovers"`
+}
+
 // ApolloReportData is the complete report structure sent to outputters
 type ApolloReportData struct {
 	Generated     string               `json:"generated"`
 	Privesc       *PrivescReport       `json:"privesc,omitempty"`
 	ExternalTrust *ExternalTrustReport `json:"external_trust,omitempty"`
+	RoleTakeover  *RoleTakeoverReport  `json:"role_takeover,omitempty"`
 }
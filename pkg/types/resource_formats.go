@@ -0,0 +1,228 @@
+package types
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+)
+
+// Resource inventory formats accepted by ParseResourceDescriptions
+const (
+	ResourceFormatListAll      = "list-all"
+	ResourceFormatConfig       = "config"
+	ResourceFormatCloudControl = "cloudcontrol"
+	ResourceFormatSteampipe    = "steampipe"
+)
+
+var ResourceFormats = []string{
+	ResourceFormatListAll,
+	ResourceFormatConfig,
+	ResourceFormatCloudControl,
+	ResourceFormatSteampipe,
+}
+
+// ParseResourceDescriptions normalizes a resource inventory export into
+// EnrichedResourceDescriptions. format selects the adapter:
+//
+//   - list-all: nebula list-all module output
+//   - config: AWS Config select-resource-config results or configuration snapshots
+//   - cloudcontrol: aws cloudcontrol list-resources output
+//   - steampipe: steampipe query --output json rows that include an arn column
+func ParseResourceDescriptions(data []byte, format string) ([]EnrichedResourceDescription, error) {
+	switch strings.ToLower(format) {
+	case "", ResourceFormatListAll:
+		return parseListAllResources(data)
+	case ResourceFormatConfig:
+		return parseConfigResources(data)
+	case ResourceFormatCloudControl:
+		return parseCloudControlResources(data)
+	case ResourceFormatSteampipe:
+		return parseSteampipeResources(data)
+	default:
+		return nil, fmt.Errorf("unknown resource format %q, expected one of: %s", format, strings.Join(ResourceFormats, ", "))
+	}
+}
+
+func parseListAllResources(data []byte) ([]EnrichedResourceDescription, error) {
+	var resources []EnrichedResourceDescription
+	if err := unmarshalOneOrMany(data, &resources); err != nil {
+		return nil, fmt.Errorf("failed to parse list-all resources: %w", err)
+	}
+	return resources, nil
+}
+
+// configItem covers both the select-resource-config result shape and the
+// configuration item shape used in Config snapshots and get-resource-config-history
+type configItem struct {
+	ResourceID    string          `json:"resourceId"`
+	ResourceName  string          `json:"resourceName"`
+	ResourceType  string          `json:"resourceType"`
+	AwsRegion     string          `json:"awsRegion"`
+	AccountID     string          `json:"accountId"`
+	AwsAccountID  string          `json:"awsAccountId"`
+	Arn           string          `json:"arn"`
+	ARN           string          `json:"ARN"`
+	Configuration json.RawMessage `json:"configuration"`
+}
+
+func parseConfigResources(data []byte) ([]EnrichedResourceDescription, error) {
+	var export struct {
+		Results            []string     `json:"Results"`
+		ConfigurationItems []configItem `json:"configurationItems"`
+		ConfigItems        []configItem `json:"configurationItemList"`
+	}
+	if err := json.Unmarshal(data, &export); err != nil {
+		return nil, fmt.Errorf("failed to parse AWS Config export: %w", err)
+	}
+
+	items := append(export.ConfigurationItems, export.ConfigItems...)
+	// select-resource-config returns each result as an encoded JSON string
+	for i, result := range export.Results {
+		var item configItem
+		if err := json.Unmarshal([]byte(result), &item); err != nil {
+			return nil, fmt.Errorf("failed to parse AWS Config result %d: %w", i, err)
+		}
+		items = append(items, item)
+	}
+
+	resources := make([]EnrichedResourceDescription, 0, len(items))
+	for _, item := range items {
+		resourceArn := firstNonEmpty(item.Arn, item.ARN)
+		accountID := firstNonEmpty(item.AccountID, item.AwsAccountID)
+		identifier := firstNonEmpty(item.ResourceID, item.ResourceName)
+		if identifier == "" && resourceArn == "" {
+			continue
+		}
+		resources = append(resources, newImportedResource(resourceArn, identifier, item.ResourceType, item.AwsRegion, accountID, rawProperties(item.Configuration)))
+	}
+	return resources, nil
+}
+
+func parseCloudControlResources(data []byte) ([]EnrichedResourceDescription, error) {
+	type listResourcesOutput struct {
+		TypeName             string `json:"TypeName"`
+		ResourceDescriptions []struct {
+			Identifier string `json:"Identifier"`
+			Properties string `json:"Properties"`
+		} `json:"ResourceDescriptions"`
+	}
+
+	var outputs []listResourcesOutput
+	if err := unmarshalOneOrMany(data, &outputs); err != nil {
+		return nil, fmt.Errorf("failed to parse Cloud Control list-resources output: %w", err)
+	}
+
+	var resources []EnrichedResourceDescription
+	for _, output := range outputs {
+		for _, description := range output.ResourceDescriptions {
+			if description.Identifier == "" {
+				continue
+			}
+			// list-resources carries no region or account; recover them from the
+			// identifier or an Arn property when either is an ARN
+			var properties struct {
+				Arn string `json:"Arn"`
+			}
+			json.Unmarshal([]byte(description.Properties), &properties)
+			resourceArn := properties.Arn
+			if arn.IsARN(description.Identifier) {
+				resourceArn = description.Identifier
+			}
+			resources = append(resources, newImportedResource(resourceArn, description.Identifier, output.TypeName, "", "", description.Properties))
+		}
+	}
+	return resources, nil
+}
+
+func parseSteampipeResources(data []byte) ([]EnrichedResourceDescription, error) {
+	// Steampipe 0.21+ wraps rows in {"columns": [...], "rows": [...]}; older releases emit the rows directly
+	var rows []map[string]any
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) > 0 && trimmed[0] == '{' {
+		var wrapped struct {
+			Rows []map[string]any `json:"rows"`
+		}
+		if err := json.Unmarshal(trimmed, &wrapped); err != nil {
+			return nil, fmt.Errorf("failed to parse Steampipe output: %w", err)
+		}
+		rows = wrapped.Rows
+	} else if err := json.Unmarshal(trimmed, &rows); err != nil {
+		return nil, fmt.Errorf("failed to parse Steampipe output: %w", err)
+	}
+
+	resources := make([]EnrichedResourceDescription, 0, len(rows))
+	for _, row := range rows {
+		resourceArn, _ := row["arn"].(string)
+		if resourceArn == "" {
+			continue
+		}
+		resource, err := NewEnrichedResourceDescriptionFromArn(resourceArn)
+		if err != nil {
+			continue
+		}
+		if region, _ := row["region"].(string); region != "" && resource.Region == "" {
+			resource.Region = region
+		}
+		if accountID, _ := row["account_id"].(string); accountID != "" && resource.AccountId == "" {
+			resource.AccountId = accountID
+		}
+		resource.Properties = row
+		resources = append(resources, resource)
+	}
+	return resources, nil
+}
+
+// newImportedResource builds a resource from an external inventory. When the
+// source provides an ARN it is used as-is; otherwise the ARN is derived from the
+// identifier the same way list-all does.
+func newImportedResource(resourceArn, identifier, typeName, region, accountID string, properties any) EnrichedResourceDescription {
+	parsed, err := arn.Parse(resourceArn)
+	if err != nil {
+		return NewEnrichedResourceDescription(identifier, typeName, region, accountID, properties)
+	}
+	if identifier == "" {
+		identifier = parsed.Resource
+	}
+	return EnrichedResourceDescription{
+		Identifier: identifier,
+		TypeName:   typeName,
+		Region:     firstNonEmpty(region, parsed.Region),
+		Properties: properties,
+		AccountId:  firstNonEmpty(accountID, parsed.AccountID),
+		Arn:        parsed,
+	}
+}
+
+// rawProperties keeps properties as a JSON string, matching Cloud Control output
+func rawProperties(raw json.RawMessage) any {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil
+	}
+	return string(raw)
+}
+
+// unmarshalOneOrMany accepts either a JSON array or a single object
+func unmarshalOneOrMany[T any](data []byte, out *[]T) error {
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) > 0 && trimmed[0] == '[' {
+		return json.Unmarshal(trimmed, out)
+	}
+	var single T
+	if err := json.Unmarshal(trimmed, &single); err != nil {
+		return err
+	}
+	*out = []T{single}
+	return nil
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
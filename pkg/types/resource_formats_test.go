@@ -0,0 +1,92 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseResourceDescriptions(t *testing.T) {
+	testCases := []struct {
+		name       string
+		format     string
+		input      string
+		identifier string
+		typeName   string
+		region     string
+		accountID  string
+		arn        string
+	}{
+		{
+			name:       "list-all",
+			format:     "list-all",
+			input:      `[{"Identifier": "my-func", "TypeName": "AWS::Lambda::Function", "Region": "us-east-1", "AccountId": "111122223333", "Arn": {"Partition": "aws", "Service": "lambda", "Region": "us-east-1", "AccountID": "111122223333", "Resource": "function:my-func"}}]`,
+			identifier: "my-func",
+			typeName:   "AWS::Lambda::Function",
+			region:     "us-east-1",
+			accountID:  "111122223333",
+			arn:        "arn:aws:lambda:us-east-1:111122223333:function:my-func",
+		},
+		{
+			name:       "config select-resource-config",
+			format:     "config",
+			input:      `{"Results": ["{\"resourceId\":\"i-0abc\",\"resourceType\":\"AWS::EC2::Instance\",\"awsRegion\":\"us-west-2\",\"accountId\":\"111122223333\",\"arn\":\"arn:aws:ec2:us-west-2:111122223333:instance/i-0abc\",\"configuration\":{\"instanceType\":\"t3.micro\"}}"]}`,
+			identifier: "i-0abc",
+			typeName:   "AWS::EC2::Instance",
+			region:     "us-west-2",
+			accountID:  "111122223333",
+			arn:        "arn:aws:ec2:us-west-2:111122223333:instance/i-0abc",
+		},
+		{
+			name:       "config snapshot",
+			format:     "config",
+			input:      `{"configurationItems": [{"resourceId": "my-bucket", "resourceType": "AWS::S3::Bucket", "awsRegion": "us-east-1", "awsAccountId": "111122223333", "ARN": "arn:aws:s3:::my-bucket"}]}`,
+			identifier: "my-bucket",
+			typeName:   "AWS::S3::Bucket",
+			region:     "us-east-1",
+			accountID:  "111122223333",
+			arn:        "arn:aws:s3:::my-bucket",
+		},
+		{
+			name:       "cloudcontrol",
+			format:     "cloudcontrol",
+			input:      `{"TypeName": "AWS::SNS::Topic", "ResourceDescriptions": [{"Identifier": "arn:aws:sns:eu-west-1:111122223333:alerts", "Properties": "{\"TopicArn\":\"arn:aws:sns:eu-west-1:111122223333:alerts\"}"}]}`,
+			identifier: "arn:aws:sns:eu-west-1:111122223333:alerts",
+			typeName:   "AWS::SNS::Topic",
+			region:     "eu-west-1",
+			accountID:  "111122223333",
+			arn:        "arn:aws:sns:eu-west-1:111122223333:alerts",
+		},
+		{
+			name:       "steampipe",
+			format:     "steampipe",
+			input:      `{"columns": [{"name": "arn"}], "rows": [{"arn": "arn:aws:s3:::logs-bucket", "region": "us-east-2", "account_id": "111122223333", "name": "logs-bucket"}]}`,
+			identifier: "logs-bucket",
+			typeName:   "AWS::S3::Bucket",
+			region:     "us-east-2",
+			accountID:  "111122223333",
+			arn:        "arn:aws:s3:::logs-bucket",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			resources, err := ParseResourceDescriptions([]byte(tc.input), tc.format)
+			require.NoError(t, err)
+			require.Len(t, resources, 1)
+
+			resource := resources[0]
+			assert.Equal(t, tc.identifier, resource.Identifier)
+			assert.Equal(t, tc.typeName, resource.TypeName)
+			assert.Equal(t, tc.region, resource.Region)
+			assert.Equal(t, tc.accountID, resource.AccountId)
+			assert.Equal(t, tc.arn, resource.Arn.String())
+		})
+	}
+}
+
+func TestParseResourceDescriptionsUnknownFormat(t *testing.T) {
+	_, err := ParseResourceDescriptions([]byte(`[]`), "csv")
+	assert.ErrorContains(t, err, "unknown resource format")
+}
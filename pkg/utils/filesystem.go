@@ -1,12 +1,53 @@
 package utils
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	"log/slog"
 	"os"
 	"path/filepath"
+	"sync/atomic"
 )
 
+// StdinPath is the input file path that reads standard input instead of a file
+const StdinPath = "-"
+
+// errStdinInputs is returned when more than one input is read from stdin
+var errStdinInputs = errors.New("only one input file can be read from stdin (-)")
+
+// stdinRead records that standard input was read; it can only be consumed once
+var stdinRead atomic.Bool
+
+// ReadInputFile reads an input file, or standard input when path is "-", so
+// collector output can be piped straight into an analysis module. Standard
+// input can only be read once; a second read returns an error rather than
+// empty data.
+func ReadInputFile(path string) ([]byte, error) {
+	if path == StdinPath {
+		if !stdinRead.CompareAndSwap(false, true) {
+			return nil, errStdinInputs
+		}
+		return io.ReadAll(os.Stdin)
+	}
+	return os.ReadFile(path)
+}
+
+// CheckStdinInputs returns an error when more than one of the input paths is
+// "-", so a loader can reject the combination before reading any of them
+func CheckStdinInputs(paths ...string) error {
+	stdinInputs := 0
+	for _, path := range paths {
+		if path == StdinPath {
+			stdinInputs++
+		}
+	}
+	if stdinInputs > 1 {
+		return errStdinInputs
+	}
+	return nil
+}
+
 // EnsureDirectoryExists creates a directory and all necessary parent directories
 // with proper error handling and logging. It's safe to call multiple times.
 func EnsureDirectoryExists(dirPath string) error {
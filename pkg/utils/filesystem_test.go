@@ -0,0 +1,85 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// withStdin replaces os.Stdin with a file holding content for the test
+func withStdin(t *testing.T, content string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "stdin")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	f, err := os.Open(path)
+	require.NoError(t, err)
+
+	original := os.Stdin
+	os.Stdin = f
+	stdinRead.Store(false)
+	t.Cleanup(func() {
+		os.Stdin = original
+		stdinRead.Store(false)
+		f.Close()
+	})
+}
+
+func TestReadInputFile(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "gaad.json")
+	require.NoError(t, os.WriteFile(file, []byte(`{"from":"file"}`), 0o644))
+
+	tests := []struct {
+		name    string
+		paths   []string
+		want    []string
+		wantErr string
+	}{
+		{
+			name:  "stdin",
+			paths: []string{StdinPath},
+			want:  []string{`{"from":"stdin"}`},
+		},
+		{
+			name:  "file path",
+			paths: []string{file},
+			want:  []string{`{"from":"file"}`},
+		},
+		{
+			name:    "stdin read twice",
+			paths:   []string{StdinPath, StdinPath},
+			want:    []string{`{"from":"stdin"}`},
+			wantErr: "only one input file can be read from stdin (-)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			withStdin(t, `{"from":"stdin"}`)
+
+			if tt.wantErr != "" {
+				assert.EqualError(t, CheckStdinInputs(tt.paths...), tt.wantErr)
+			} else {
+				assert.NoError(t, CheckStdinInputs(tt.paths...))
+			}
+
+			var got []string
+			var err error
+			for _, path := range tt.paths {
+				var data []byte
+				if data, err = ReadInputFile(path); err != nil {
+					break
+				}
+				got = append(got, string(data))
+			}
+			assert.Equal(t, tt.want, got)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr, "a second read must not return empty data")
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
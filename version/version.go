@@ -1,6 +1,11 @@
 package version
 
-import "fmt"
+import (
+	"fmt"
+	"runtime"
+	"runtime/debug"
+	"sync"
+)
 
 var (
 	// Version is the current version of Nebula, set via build flags
@@ -13,11 +18,78 @@ var (
 	BuildTime = "unknown"
 )
 
+// BuildInfo describes the binary that is running
+type BuildInfo struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	BuildTime string `json:"build_time"`
+	GoVersion string `json:"go_version"`
+	Modified  bool   `json:"modified,omitempty"`
+}
+
+var (
+	buildInfo     BuildInfo
+	buildInfoOnce sync.Once
+)
+
+// Info returns the build information. Values set via build flags win; anything
+// left at its default is filled from the module and VCS data the Go toolchain
+// embeds, so `go install` and `go build` binaries are still traceable.
+func Info() BuildInfo {
+	buildInfoOnce.Do(func() {
+		buildInfo = BuildInfo{
+			Version:   Version,
+			Commit:    Commit,
+			BuildTime: BuildTime,
+			GoVersion: runtime.Version(),
+		}
+		if bi, ok := debug.ReadBuildInfo(); ok {
+			buildInfo = fromBuildInfo(buildInfo, bi)
+		}
+	})
+	return buildInfo
+}
+
+func fromBuildInfo(info BuildInfo, bi *debug.BuildInfo) BuildInfo {
+	if bi.GoVersion != "" {
+		info.GoVersion = bi.GoVersion
+	}
+	if info.Version == "dev" && bi.Main.Version != "" && bi.Main.Version != "(devel)" {
+		info.Version = bi.Main.Version
+	}
+	for _, setting := range bi.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			if info.Commit == "none" {
+				info.Commit = setting.Value
+			}
+		case "vcs.time":
+			if info.BuildTime == "unknown" {
+				info.BuildTime = setting.Value
+			}
+		case "vcs.modified":
+			info.Modified = setting.Value == "true"
+		}
+	}
+	return info
+}
+
 // FullVersion returns the full version string
 func FullVersion() string {
-	return fmt.Sprintf("Nebula %s, build %s, built at %s", Version, Commit, BuildTime)
+	info := Info()
+	commit := info.Commit
+	if info.Modified {
+		commit += "-dirty"
+	}
+	return fmt.Sprintf("Nebula %s, build %s, built at %s with %s", info.Version, commit, info.BuildTime, info.GoVersion)
 }
 
+// AbbreviatedVersion returns the version and short commit, e.g. for the banner
 func AbbreviatedVersion() string {
-	return fmt.Sprintf("%s-%s", Version, Commit)
+	info := Info()
+	commit := info.Commit
+	if len(commit) > 12 {
+		commit = commit[:12]
+	}
+	return fmt.Sprintf("%s-%s", info.Version, commit)
 }
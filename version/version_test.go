@@ -0,0 +1,31 @@
+package version
+
+import (
+	"runtime/debug"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFromBuildInfo(t *testing.T) {
+	bi := &debug.BuildInfo{
+		GoVersion: "go1.24.6",
+		Main:      debug.Module{Version: "v1.2.3"},
+		Settings: []debug.BuildSetting{
+			{Key: "vcs.revision", Value: "0123456789abcdef"},
+			{Key: "vcs.time", Value: "2025-01-02T03:04:05Z"},
+			{Key: "vcs.modified", Value: "true"},
+		},
+	}
+
+	info := fromBuildInfo(BuildInfo{Version: "dev", Commit: "none", BuildTime: "unknown"}, bi)
+	assert.Equal(t, BuildInfo{Version: "v1.2.3", Commit: "0123456789abcdef", BuildTime: "2025-01-02T03:04:05Z", GoVersion: "go1.24.6", Modified: true}, info)
+
+	stamped := fromBuildInfo(BuildInfo{Version: "v2.0.0", Commit: "abc1234", BuildTime: "yesterday"}, bi)
+	assert.Equal(t, "v2.0.0", stamped.Version, "build flag values take precedence")
+	assert.Equal(t, "abc1234", stamped.Commit)
+	assert.Equal(t, "yesterday", stamped.BuildTime)
+
+	devel := fromBuildInfo(BuildInfo{Version: "dev"}, &debug.BuildInfo{Main: debug.Module{Version: "(devel)"}})
+	assert.Equal(t, "dev", devel.Version)
+}